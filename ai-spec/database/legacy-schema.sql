@@ -24,7 +24,8 @@ CREATE TABLE `ufo_track` (
   `raw_id` int DEFAULT NULL,        -- Ссылка на сырой пакет
   `datestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
-  KEY `addr` (`addr`)
+  KEY `addr` (`addr`),
+  KEY `addr_datestamp` (`addr`, `datestamp`, `id`)
 );
 
 -- Имена пилотов/устройств
@@ -42,10 +43,35 @@ CREATE TABLE `thermal` (
   `longitude` float NOT NULL,
   `altitude` int DEFAULT NULL,      -- Высота термика (м)
   `quality` tinyint DEFAULT NULL,   -- Качество 0-5
-  `climb` smallint DEFAULT NULL,    -- Средняя скороподъемность (м/с * 10)
+  `climb` smallint DEFAULT NULL,    -- Средняя скороподъемность (м/с * 100, как в FANET Type 9)
   `wind_speed` smallint DEFAULT NULL,   -- Скорость ветра (м/с * 10)
   `wind_heading` smallint DEFAULT NULL, -- Направление ветра (градусы)
-  PRIMARY KEY (`id`)
+  `datestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Время записи (тепловая карта)
+  PRIMARY KEY (`id`),
+  KEY `datestamp` (`datestamp`),
+  KEY `position` (`latitude`, `longitude`)
+);
+
+-- Полеты (определяются по живому треку: взлет/посадка)
+CREATE TABLE `flight` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `addr` int NOT NULL,
+  `ufo_type` tinyint NOT NULL DEFAULT 0,
+  `status` varchar(10) NOT NULL,        -- active, landed, lost
+  `takeoff_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `landing_time` timestamp NULL DEFAULT NULL, -- Посадка (последняя позиция для lost)
+  `takeoff_latitude` float NOT NULL,
+  `takeoff_longitude` float NOT NULL,
+  `takeoff_altitude` int DEFAULT NULL,
+  `landing_latitude` float DEFAULT NULL,
+  `landing_longitude` float DEFAULT NULL,
+  `landing_altitude` int DEFAULT NULL,
+  `duration` int NOT NULL DEFAULT 0,    -- Продолжительность (с)
+  `distance` float NOT NULL DEFAULT 0,  -- Пройденное расстояние (км)
+  `max_altitude` int NOT NULL DEFAULT 0,
+  `max_climb` float NOT NULL DEFAULT 0, -- Максимальная скороподъемность (м/с)
+  PRIMARY KEY (`id`),
+  KEY `addr_takeoff` (`addr`, `takeoff_time`)
 );
 
 -- Метеостанции
@@ -69,6 +95,22 @@ CREATE TABLE `station` (
   PRIMARY KEY (`addr`)
 );
 
+-- Треки наземных объектов (FANET Type 7)
+-- Добавлено в новом backend, в MqttToDb отсутствовало
+CREATE TABLE `ground_track` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `addr` int NOT NULL,              -- FANET адрес
+  `ground_type` tinyint NOT NULL,   -- Тип: 1=пешеход, 2=автомобиль, 8=нужен транспорт, 14=SOS и т.д.
+  `latitude` float NOT NULL,
+  `longitude` float NOT NULL,
+  `track_online` tinyint DEFAULT NULL,
+  `emergency` tinyint NOT NULL DEFAULT 0, -- Экстренный статус
+  `datestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `addr` (`addr`),
+  KEY `emergency` (`emergency`, `datestamp`)
+);
+
 -- Сырые MQTT пакеты
 CREATE TABLE `packet` (
   `id` int NOT NULL AUTO_INCREMENT,
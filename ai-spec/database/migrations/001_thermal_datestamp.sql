@@ -0,0 +1,8 @@
+-- Время записи термика для тепловой карты (GET /api/v1/thermals/heatmap).
+-- Для новых баз колонка уже есть в legacy-schema.sql.
+-- Существующие записи получают время миграции и попадают в фильтры по времени суток неверно;
+-- при необходимости исключите их из выборки периодом (from).
+ALTER TABLE `thermal`
+  ADD COLUMN `datestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ADD KEY `datestamp` (`datestamp`),
+  ADD KEY `position` (`latitude`, `longitude`);
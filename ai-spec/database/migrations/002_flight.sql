@@ -0,0 +1,22 @@
+-- Полеты, определенные по живому треку (GET /api/v1/flights).
+-- Для новых баз таблица уже есть в legacy-schema.sql.
+CREATE TABLE IF NOT EXISTS `flight` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `addr` int NOT NULL,
+  `ufo_type` tinyint NOT NULL DEFAULT 0,
+  `status` varchar(10) NOT NULL,
+  `takeoff_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `landing_time` timestamp NULL DEFAULT NULL,
+  `takeoff_latitude` float NOT NULL,
+  `takeoff_longitude` float NOT NULL,
+  `takeoff_altitude` int DEFAULT NULL,
+  `landing_latitude` float DEFAULT NULL,
+  `landing_longitude` float DEFAULT NULL,
+  `landing_altitude` int DEFAULT NULL,
+  `duration` int NOT NULL DEFAULT 0,
+  `distance` float NOT NULL DEFAULT 0,
+  `max_altitude` int NOT NULL DEFAULT 0,
+  `max_climb` float NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  KEY `addr_takeoff` (`addr`, `takeoff_time`)
+);
@@ -0,0 +1,5 @@
+-- Индекс для запросов трека за произвольный период с keyset пагинацией
+-- (GET /api/v1/track/{addr}?from=&to=).
+-- Для новых баз индекс уже есть в legacy-schema.sql.
+ALTER TABLE `ufo_track`
+  ADD KEY `addr_datestamp` (`addr`, `datestamp`, `id`);
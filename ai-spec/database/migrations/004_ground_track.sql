@@ -0,0 +1,15 @@
+-- Треки наземных объектов (FANET Type 7), пишутся батчами SaveGroundObjectsBatch.
+-- Для новых баз таблица уже есть в legacy-schema.sql.
+CREATE TABLE IF NOT EXISTS `ground_track` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `addr` int NOT NULL,
+  `ground_type` tinyint NOT NULL,
+  `latitude` float NOT NULL,
+  `longitude` float NOT NULL,
+  `track_online` tinyint DEFAULT NULL,
+  `emergency` tinyint NOT NULL DEFAULT 0,
+  `datestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `addr` (`addr`),
+  KEY `emergency` (`emergency`, `datestamp`)
+);
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/capture"
+	"github.com/flybeeper/fanet-backend/internal/ingest"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// ingestTarget прогоняет записи через ingest.Pipeline в процессе, без Redis,
+// MySQL и MQTT брокера: хранилище и рассылка заменены реализациями в памяти.
+// Валидация, дедупликация приема, реестр станций и потоковый фильтр - те же,
+// что в fanet-api
+type ingestTarget struct {
+	parser      *mqtt.Parser
+	pipeline    *ingest.Pipeline
+	repository  *memoryRepository
+	broadcaster *countingBroadcaster
+	validation  *service.ValidationService
+	gateways    *service.GatewayRegistry
+	logger      *utils.Logger
+
+	stop    context.CancelFunc
+	done    chan struct{}
+	skipped int
+	failed  int
+	dropped int
+}
+
+func newIngestTarget(logger *utils.Logger, workers int, liveFilter bool) *ingestTarget {
+	t := &ingestTarget{
+		parser:      mqtt.NewParser(logger),
+		repository:  newMemoryRepository(),
+		broadcaster: newCountingBroadcaster(),
+		validation:  service.NewValidationService(logger, nil),
+		gateways:    service.NewGatewayRegistry(logger, nil, nil),
+		logger:      logger,
+		done:        make(chan struct{}),
+	}
+
+	deps := ingest.PipelineDeps{
+		Repository:  t.repository,
+		Broadcaster: t.broadcaster,
+		Validation:  t.validation,
+		Reception:   service.NewReceptionTracker(logger, nil),
+		Gateways:    t.gateways,
+	}
+	if liveFilter {
+		deps.LiveFilter = service.NewLiveFilter(logger, nil)
+	}
+	t.pipeline = ingest.NewPipeline(deps, ingest.PipelineConfig{Workers: workers, QueueSize: 1000}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stop = cancel
+	t.pipeline.Start(ctx)
+
+	// Отложенные фильтром позиции выпускаются так же, как в fanet-api
+	go func() {
+		defer close(t.done)
+		if deps.LiveFilter == nil {
+			return
+		}
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.pipeline.ReleaseDelayed()
+			}
+		}
+	}()
+
+	return t
+}
+
+func (t *ingestTarget) handle(record *capture.Record) error {
+	msg, err := t.parser.Parse(record.Topic, record.Payload)
+	if err != nil {
+		t.failed++
+		t.logger.WithFields(map[string]interface{}{
+			"topic": record.Topic,
+			"time":  record.Time,
+			"error": err,
+		}).Warn("Failed to parse captured message")
+		return err
+	}
+	if msg == nil {
+		t.skipped++
+		return nil
+	}
+
+	if err := t.pipeline.Handle(msg); err != nil {
+		t.dropped++
+		return err
+	}
+	return nil
+}
+
+// summary дожидается обработки принятых сообщений и выводит итоговую статистику
+func (t *ingestTarget) summary() {
+	t.stop()
+	<-t.done
+	t.pipeline.Stop()
+
+	validation := t.validation.GetMetrics()
+	fields := map[string]interface{}{
+		"parse_errors":        t.failed,
+		"skipped":             t.skipped,
+		"dropped":             t.dropped,
+		"validated":           validation.ValidatedPackets,
+		"rejected":            validation.RejectedPackets,
+		"gateways":            len(t.gateways.ListGateways(nil)),
+		"pilots":              len(t.repository.pilots),
+		"positions_saved":     t.repository.positions,
+		"ground_objects":      len(t.repository.groundObjects),
+		"thermals":            t.repository.thermals,
+		"messages":            t.repository.messages,
+		"stations":            t.repository.stations,
+		"landmarks":           t.repository.landmarks,
+		"names":               t.repository.names,
+		"broadcast_total":     t.broadcaster.total(),
+		"broadcast_by_update": t.broadcaster.byType(),
+	}
+	t.logger.WithFields(fields).Info("Ingest summary")
+}
+
+// memoryRepository хранит текущее состояние в памяти вместо Redis
+type memoryRepository struct {
+	mu            sync.Mutex
+	pilots        map[string]*models.Pilot
+	groundObjects map[string]*models.GroundObject
+	positions     int
+	thermals      int
+	messages      int
+	stations      int
+	landmarks     int
+	names         int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		pilots:        make(map[string]*models.Pilot),
+		groundObjects: make(map[string]*models.GroundObject),
+	}
+}
+
+func (r *memoryRepository) GetPilot(ctx context.Context, deviceID string) (*models.Pilot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pilots[deviceID], nil // nil без ошибки, если пилот не найден, как в Redis
+}
+
+func (r *memoryRepository) SavePilot(ctx context.Context, pilot *models.Pilot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pilots[pilot.DeviceID] = pilot
+	r.positions++
+	return nil
+}
+
+func (r *memoryRepository) RemovePilot(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pilots, deviceID)
+	return nil
+}
+
+func (r *memoryRepository) UpdatePilotName(ctx context.Context, deviceID string, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names++
+	return nil
+}
+
+func (r *memoryRepository) SaveGroundObject(ctx context.Context, groundObject *models.GroundObject) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groundObjects[groundObject.DeviceID] = groundObject
+	return nil
+}
+
+func (r *memoryRepository) GetGroundObject(ctx context.Context, deviceID string) (*models.GroundObject, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.groundObjects[deviceID], nil
+}
+
+func (r *memoryRepository) SaveThermal(ctx context.Context, thermal *models.Thermal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.thermals++
+	return nil
+}
+
+func (r *memoryRepository) SaveMessage(ctx context.Context, message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages++
+	return nil
+}
+
+func (r *memoryRepository) SaveStation(ctx context.Context, station *models.Station) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stations++
+	return nil
+}
+
+func (r *memoryRepository) SaveLandmark(ctx context.Context, landmark *models.Landmark) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.landmarks++
+	return nil
+}
+
+// countingBroadcaster считает обновления, которые fanet-api разослал бы клиентам
+type countingBroadcaster struct {
+	mu      sync.Mutex
+	updates map[pb.UpdateType]int
+}
+
+func newCountingBroadcaster() *countingBroadcaster {
+	return &countingBroadcaster{updates: make(map[pb.UpdateType]int)}
+}
+
+func (b *countingBroadcaster) BroadcastUpdate(updateType pb.UpdateType, action pb.Action, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.updates[updateType]++
+}
+
+func (b *countingBroadcaster) total() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	total := 0
+	for _, count := range b.updates {
+		total += count
+	}
+	return total
+}
+
+// byType возвращает количество обновлений по типам в виде "TYPE=N" по алфавиту
+func (b *countingBroadcaster) byType() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]string, 0, len(b.updates))
+	for updateType, count := range b.updates {
+		result = append(result, updateType.String()+"="+strconv.Itoa(count))
+	}
+	sort.Strings(result)
+	return result
+}
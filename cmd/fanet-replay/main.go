@@ -0,0 +1,261 @@
+// fanet-replay воспроизводит файлы захвата сырого MQTT трафика (CAPTURE_ENABLED)
+// или синтетический трафик пилотов.
+//
+// Режимы:
+//
+//	parse   - разбор каждого сообщения через mqtt.Parser, статистика и JSON вывод
+//	publish - публикация в MQTT брокер, сообщения проходят полный обработчик fanet-api
+//	ingest  - обработка конвейером ingest.Pipeline в процессе, без брокера, Redis и MySQL
+//
+// Примеры:
+//
+//	fanet-replay -mode parse -speed 0 -json captures/fanet-20240714T120000Z.jsonl.gz
+//	fanet-replay -mode ingest -speed 0 -retime captures/
+//	fanet-replay -mode publish -speed 10 -retime captures/
+//	fanet-replay -mode publish -synthetic 3 -rate 1s -count 500
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/flybeeper/fanet-backend/internal/capture"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+func main() {
+	var (
+		mode      = flag.String("mode", "parse", "Replay mode: parse, publish or ingest")
+		speed     = flag.Float64("speed", 1, "Playback speed: 1 = real time, 10 = 10x faster, 0 = as fast as possible")
+		retime    = flag.Bool("retime", false, "Replace base station timestamps with the current time")
+		jsonOut   = flag.Bool("json", false, "Print parsed messages as JSON lines (parse mode)")
+		logLevel  = flag.String("log-level", "info", "Log level")
+		brokerURL = flag.String("broker", "tcp://localhost:1883", "MQTT broker URL (publish mode)")
+		clientID  = flag.String("client", "fanet-replay", "MQTT client ID (publish mode)")
+		qos       = flag.Int("qos", 0, "MQTT QoS (publish mode)")
+		workers   = flag.Int("workers", 4, "Pipeline workers (ingest mode)")
+		live      = flag.Bool("live-filter", true, "Enable the streaming position filter (ingest mode)")
+
+		synthetic = flag.Int("synthetic", 0, "Generate synthetic traffic with N pilots per base station instead of reading files")
+		chips     = flag.String("chips", "8896672,7048812,2462966788", "Base station chip IDs for synthetic traffic (comma-separated)")
+		rate      = flag.Duration("rate", 2*time.Second, "Position interval per pilot for synthetic traffic")
+		count     = flag.Int("count", 0, "Max synthetic records (0 = unlimited)")
+		lat       = flag.Float64("lat", 46.0, "Synthetic start latitude")
+		lon       = flag.Float64("lon", 13.0, "Synthetic start longitude")
+		seed      = flag.Int64("seed", time.Now().UnixNano(), "Synthetic random seed")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [capture files or directories...]\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	logger := utils.NewLogger(*logLevel, "text")
+
+	// Источник записей: синтетический генератор или файлы захвата
+	var source capture.RecordSource
+	if *synthetic > 0 {
+		source = newSyntheticSource(splitList(*chips), *synthetic, *rate, *count, *lat, *lon, *seed)
+	} else {
+		files, err := resolveFiles(flag.Args())
+		if err != nil {
+			logger.WithField("error", err).Fatal("Failed to resolve capture files")
+		}
+		if len(files) == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		logger.WithField("files", len(files)).Info("Replaying capture files")
+
+		reader := capture.NewMultiReader(files)
+		defer reader.Close()
+		source = reader
+	}
+
+	var handle func(*capture.Record) error
+	var summary func()
+
+	switch *mode {
+	case "parse":
+		parse := newParseTarget(logger, *jsonOut)
+		handle, summary = parse.handle, parse.summary
+	case "publish":
+		client, err := connect(*brokerURL, *clientID)
+		if err != nil {
+			logger.WithField("error", err).Fatal("Failed to connect to MQTT broker")
+		}
+		defer client.Disconnect(1000)
+
+		handle = func(record *capture.Record) error {
+			token := client.Publish(record.Topic, byte(*qos), false, record.Payload)
+			token.Wait()
+			return token.Error()
+		}
+		summary = func() {}
+	case "ingest":
+		target := newIngestTarget(logger, *workers, *live)
+		handle, summary = target.handle, target.summary
+	default:
+		logger.WithField("mode", *mode).Fatal("Unknown mode, expected parse, publish or ingest")
+	}
+
+	if *retime {
+		next := handle
+		handle = func(record *capture.Record) error {
+			return next(retimeRecord(record, time.Now()))
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	player := &capture.Player{Speed: *speed}
+	stats, err := player.Play(ctx, source, handle)
+	summary()
+
+	logger.WithFields(map[string]interface{}{
+		"records":  stats.Records,
+		"errors":   stats.Errors,
+		"span":     stats.Span.Round(time.Second),
+		"duration": stats.Duration.Round(time.Millisecond),
+	}).Info("Replay finished")
+
+	if err != nil && err != context.Canceled {
+		logger.WithField("error", err).Fatal("Replay failed")
+	}
+}
+
+// parseTarget разбирает записи через mqtt.Parser и собирает статистику по типам
+type parseTarget struct {
+	parser  *mqtt.Parser
+	logger  *utils.Logger
+	encoder *json.Encoder
+	byType  map[uint8]int
+	skipped int
+	failed  int
+}
+
+func newParseTarget(logger *utils.Logger, jsonOut bool) *parseTarget {
+	t := &parseTarget{
+		parser: mqtt.NewParser(logger),
+		logger: logger,
+		byType: make(map[uint8]int),
+	}
+	if jsonOut {
+		t.encoder = json.NewEncoder(os.Stdout)
+	}
+	return t
+}
+
+func (t *parseTarget) handle(record *capture.Record) error {
+	msg, err := t.parser.Parse(record.Topic, record.Payload)
+	if err != nil {
+		t.failed++
+		t.logger.WithFields(map[string]interface{}{
+			"topic": record.Topic,
+			"time":  record.Time,
+			"error": err,
+		}).Warn("Failed to parse captured message")
+		return err
+	}
+	if msg == nil {
+		t.skipped++
+		return nil
+	}
+
+	t.byType[msg.Type]++
+	if t.encoder != nil {
+		return t.encoder.Encode(struct {
+			ReceivedAt time.Time          `json:"received_at"`
+			Message    *mqtt.FANETMessage `json:"message"`
+		}{record.Time, msg})
+	}
+	return nil
+}
+
+func (t *parseTarget) summary() {
+	types := make([]int, 0, len(t.byType))
+	for packetType := range t.byType {
+		types = append(types, int(packetType))
+	}
+	sort.Ints(types)
+
+	fields := map[string]interface{}{
+		"parse_errors": t.failed,
+		"skipped":      t.skipped,
+	}
+	for _, packetType := range types {
+		fields[fmt.Sprintf("type_%d", packetType)] = t.byType[uint8(packetType)]
+	}
+	t.logger.WithFields(fields).Info("Parse summary")
+}
+
+// retimeRecord возвращает копию записи с временем базовой станции now.
+// Без этого fanet-api отбросит старые позиции из захвата как устаревшие
+func retimeRecord(record *capture.Record, now time.Time) *capture.Record {
+	if len(record.Payload) < 4 {
+		return record
+	}
+
+	payload := append([]byte(nil), record.Payload...)
+	binary.LittleEndian.PutUint32(payload[0:4], uint32(now.Unix()))
+	return &capture.Record{Time: now, Topic: record.Topic, Payload: payload}
+}
+
+// resolveFiles раскрывает каталоги в списки файлов захвата
+func resolveFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		dirFiles, err := capture.ListFiles(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// connect подключается к MQTT брокеру для публикации
+func connect(brokerURL, clientID string) (paho.Client, error) {
+	opts := paho.NewClientOptions()
+	opts.AddBroker(brokerURL)
+	opts.SetClientID(clientID)
+	opts.SetCleanSession(true)
+	opts.SetAutoReconnect(true)
+
+	client := paho.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+	return client, nil
+}
+
+// splitList разбирает список значений через запятую
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
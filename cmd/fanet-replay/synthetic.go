@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/capture"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+)
+
+// syntheticPilot состояние симулированного пилота
+type syntheticPilot struct {
+	address      string
+	name         string
+	chipID       string
+	latitude     float64
+	longitude    float64
+	altitude     float64
+	speed        float64 // км/ч
+	heading      float64 // градусы
+	climbRate    float64 // м/с
+	aircraftType uint8
+}
+
+// syntheticSource генерирует записи с движущимися пилотами вместо файла захвата.
+// Время записей идет от текущего момента с шагом rate на пилота
+type syntheticSource struct {
+	pilots  []*syntheticPilot
+	rate    time.Duration
+	limit   int
+	encoder *mqtt.Encoder
+	rng     *rand.Rand
+
+	clock   time.Time
+	tick    int
+	index   int
+	count   int
+	pending []*capture.Record
+}
+
+// newSyntheticSource создает генератор: pilots пилотов на каждую базовую станцию
+func newSyntheticSource(chipIDs []string, pilots int, rate time.Duration, limit int, lat, lon float64, seed int64) *syntheticSource {
+	rng := rand.New(rand.NewSource(seed))
+	s := &syntheticSource{
+		rate:    rate,
+		limit:   limit,
+		encoder: mqtt.NewEncoder(),
+		rng:     rng,
+		clock:   time.Now(),
+	}
+
+	for i, chipID := range chipIDs {
+		for n := 1; n <= pilots; n++ {
+			s.pilots = append(s.pilots, &syntheticPilot{
+				address:      fmt.Sprintf("%06X", 0x100000+i*1000+n),
+				name:         fmt.Sprintf("TestPilot_%s_%d", chipID, n),
+				chipID:       chipID,
+				latitude:     lat + rng.Float64()*0.5 - 0.25,
+				longitude:    lon + rng.Float64()*0.5 - 0.25,
+				altitude:     float64(1000 + rng.Intn(2000)),
+				speed:        float64(30 + rng.Intn(50)),
+				heading:      float64(rng.Intn(360)),
+				aircraftType: uint8(1 + rng.Intn(4)),
+			})
+		}
+	}
+
+	return s
+}
+
+// Next возвращает следующую запись (реализует capture.RecordSource)
+func (s *syntheticSource) Next() (*capture.Record, error) {
+	if len(s.pilots) == 0 || (s.limit > 0 && s.count >= s.limit) {
+		return nil, io.EOF
+	}
+
+	if len(s.pending) == 0 {
+		if err := s.generate(); err != nil {
+			return nil, err
+		}
+	}
+
+	record := s.pending[0]
+	s.pending = s.pending[1:]
+	s.count++
+	return record, nil
+}
+
+// generate создает пакеты следующего пилота: позицию и, периодически, имя
+func (s *syntheticSource) generate() error {
+	pilot := s.pilots[s.index]
+	s.clock = s.clock.Add(s.rate / time.Duration(len(s.pilots)))
+
+	pilot.advance(s.rng, s.rate)
+
+	tracking, err := s.encoder.Encode(&mqtt.Frame{
+		Type:   1,
+		Source: pilot.address,
+		Data: &mqtt.AirTrackingData{
+			Latitude:       pilot.latitude,
+			Longitude:      pilot.longitude,
+			Altitude:       int32(pilot.altitude),
+			Speed:          uint16(pilot.speed),
+			Heading:        uint16(pilot.heading) % 360,
+			ClimbRate:      int16(pilot.climbRate * 10),
+			AircraftType:   pilot.aircraftType,
+			OnlineTracking: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.pending = append(s.pending, s.record(pilot.chipID, 1, tracking))
+
+	// Имя отправляется раз в 10 циклов, как это делают реальные устройства
+	if s.tick%10 == 0 {
+		name, err := s.encoder.Encode(&mqtt.Frame{Type: 2, Source: pilot.address, Data: &mqtt.NameData{Name: pilot.name}})
+		if err != nil {
+			return err
+		}
+		s.pending = append(s.pending, s.record(pilot.chipID, 2, name))
+	}
+
+	s.index++
+	if s.index == len(s.pilots) {
+		s.index = 0
+		s.tick++
+	}
+	return nil
+}
+
+// advance смещает пилота за интервал dt с небольшими случайными изменениями
+func (s *syntheticPilot) advance(rng *rand.Rand, dt time.Duration) {
+	s.heading = math.Mod(s.heading+rng.Float64()*20-10+360, 360)
+	s.speed = math.Max(20, math.Min(s.speed+rng.Float64()*4-2, 90))
+	s.climbRate = math.Max(-3, math.Min(s.climbRate+rng.Float64()*0.6-0.3, 3))
+	s.altitude = math.Max(300, math.Min(s.altitude+s.climbRate*dt.Seconds(), 4500))
+
+	distance := s.speed / 3.6 * dt.Seconds()
+	rad := s.heading * math.Pi / 180
+	s.latitude += distance * math.Cos(rad) / 111320
+	s.longitude += distance * math.Sin(rad) / (111320 * math.Cos(s.latitude*math.Pi/180))
+}
+
+// record оборачивает FANET пакет заголовком базовой станции (timestamp, RSSI, SNR)
+func (s *syntheticSource) record(chipID string, packetType int, frame []byte) *capture.Record {
+	payload := make([]byte, 8, 8+len(frame))
+	binary.LittleEndian.PutUint32(payload[0:4], uint32(s.clock.Unix()))
+	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-60-s.rng.Intn(50))))
+	binary.LittleEndian.PutUint16(payload[6:8], uint16(int16(5+s.rng.Intn(10))))
+	payload = append(payload, frame...)
+
+	return &capture.Record{
+		Time:    s.clock,
+		Topic:   fmt.Sprintf("fb/b/%s/f/%d", chipID, packetType),
+		Payload: payload,
+	}
+}
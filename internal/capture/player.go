@@ -0,0 +1,128 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// RecordSource источник записей для воспроизведения (Reader или генератор)
+type RecordSource interface {
+	Next() (*Record, error)
+}
+
+// Player воспроизводит записи с сохранением интервалов между ними.
+// Speed 1 — реальная скорость, 10 — в десять раз быстрее,
+// 0 или меньше — без задержек
+type Player struct {
+	Speed float64
+}
+
+// PlayStats итог воспроизведения
+type PlayStats struct {
+	Records  int           // Воспроизведено записей
+	Errors   int           // Ошибок обработчика
+	Span     time.Duration // Интервал времени записей
+	Duration time.Duration // Фактическая длительность воспроизведения
+}
+
+// Play передает записи в fn до конца источника или отмены контекста.
+// Ошибки обработчика не прерывают воспроизведение и учитываются в статистике
+func (p *Player) Play(ctx context.Context, source RecordSource, fn func(*Record) error) (PlayStats, error) {
+	var stats PlayStats
+	var first time.Time
+	start := time.Now()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			stats.Duration = time.Since(start)
+			return stats, err
+		}
+
+		record, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			stats.Duration = time.Since(start)
+			return stats, err
+		}
+
+		if first.IsZero() {
+			first = record.Time
+		}
+		offset := record.Time.Sub(first)
+		if offset > stats.Span {
+			stats.Span = offset
+		}
+
+		// Записи могут идти не строго по времени, такие воспроизводятся сразу
+		if p.Speed > 0 {
+			target := start.Add(time.Duration(float64(offset) / p.Speed))
+			if wait := time.Until(target); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					stats.Duration = time.Since(start)
+					return stats, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		stats.Records++
+		if err := fn(record); err != nil {
+			stats.Errors++
+		}
+	}
+
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
+
+// MultiReader последовательно читает несколько файлов захвата
+type MultiReader struct {
+	paths   []string
+	current *Reader
+}
+
+// NewMultiReader создает reader для списка файлов (в порядке воспроизведения)
+func NewMultiReader(paths []string) *MultiReader {
+	return &MultiReader{paths: paths}
+}
+
+// Next возвращает следующую запись, переходя к следующему файлу в конце текущего
+func (m *MultiReader) Next() (*Record, error) {
+	for {
+		if m.current == nil {
+			if len(m.paths) == 0 {
+				return nil, io.EOF
+			}
+			reader, err := OpenFile(m.paths[0])
+			if err != nil {
+				return nil, err
+			}
+			m.current = reader
+			m.paths = m.paths[1:]
+		}
+
+		record, err := m.current.Next()
+		if err == io.EOF {
+			m.current.Close()
+			m.current = nil
+			continue
+		}
+		return record, err
+	}
+}
+
+// Close закрывает текущий файл
+func (m *MultiReader) Close() error {
+	if m.current == nil {
+		return nil
+	}
+	err := m.current.Close()
+	m.current = nil
+	return err
+}
@@ -0,0 +1,86 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource отдает записи из слайса
+type sliceSource struct {
+	records []*Record
+}
+
+func (s *sliceSource) Next() (*Record, error) {
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+// recordsEvery создает n записей с интервалом step
+func recordsEvery(n int, step time.Duration) []*Record {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	records := make([]*Record, n)
+	for i := range records {
+		records[i] = &Record{Time: start.Add(time.Duration(i) * step), Topic: fmt.Sprintf("t/%d", i)}
+	}
+	return records
+}
+
+func TestPlayer_AsFastAsPossible(t *testing.T) {
+	player := &Player{Speed: 0}
+
+	var topics []string
+	stats, err := player.Play(context.Background(), &sliceSource{records: recordsEvery(5, time.Hour)}, func(r *Record) error {
+		topics = append(topics, r.Topic)
+		if r.Topic == "t/3" {
+			return fmt.Errorf("handler error")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"t/0", "t/1", "t/2", "t/3", "t/4"}, topics)
+	assert.Equal(t, 5, stats.Records)
+	assert.Equal(t, 1, stats.Errors)
+	assert.Equal(t, 4*time.Hour, stats.Span)
+	assert.Less(t, stats.Duration, time.Second)
+}
+
+func TestPlayer_Accelerated(t *testing.T) {
+	// 4 интервала по 1 секунде при скорости 40x — около 100 мс
+	player := &Player{Speed: 40}
+
+	var offsets []time.Duration
+	start := time.Now()
+	stats, err := player.Play(context.Background(), &sliceSource{records: recordsEvery(5, time.Second)}, func(r *Record) error {
+		offsets = append(offsets, time.Since(start))
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, stats.Records)
+	assert.GreaterOrEqual(t, stats.Duration, 100*time.Millisecond)
+	assert.Less(t, stats.Duration, 2*time.Second)
+	require.Len(t, offsets, 5)
+	assert.GreaterOrEqual(t, offsets[2], 50*time.Millisecond)
+}
+
+func TestPlayer_Cancel(t *testing.T) {
+	player := &Player{Speed: 1}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	stats, err := player.Play(ctx, &sliceSource{records: recordsEvery(3, time.Hour)}, func(r *Record) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, stats.Records, "only the first record is played before the deadline")
+}
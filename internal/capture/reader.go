@@ -0,0 +1,70 @@
+package capture
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Reader последовательно читает записи из потока JSON Lines
+type Reader struct {
+	decoder *json.Decoder
+	closers []io.Closer
+}
+
+// NewReader создает reader для несжатого потока JSON Lines
+func NewReader(r io.Reader) *Reader {
+	return &Reader{decoder: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// OpenFile открывает файл захвата. Файлы с расширением .gz распаковываются
+func OpenFile(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		reader := NewReader(file)
+		reader.closers = []io.Closer{file}
+		return reader, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open gzip stream %s: %w", path, err)
+	}
+
+	reader := NewReader(gz)
+	reader.closers = []io.Closer{gz, file}
+	return reader, nil
+}
+
+// Next возвращает следующую запись или io.EOF в конце потока.
+// Оборванный в конце файл (процесс остановлен без Close) считается концом потока
+func (r *Reader) Next() (*Record, error) {
+	var record Record
+	if err := r.decoder.Decode(&record); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Close закрывает файл
+func (r *Reader) Close() error {
+	var firstErr error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,245 @@
+// Package capture записывает сырой MQTT трафик в сжатые файлы и воспроизводит
+// его для отладки треков, регрессионных и нагрузочных тестов
+package capture
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+const (
+	filePrefix = "fanet-"
+	fileSuffix = ".jsonl.gz"
+)
+
+// Record одно MQTT сообщение в том виде, в котором оно получено от брокера.
+// Payload сериализуется в base64
+type Record struct {
+	Time    time.Time `json:"t"`
+	Topic   string    `json:"topic"`
+	Payload []byte    `json:"payload"`
+}
+
+// Writer пишет записи в gzip файлы JSON Lines с ротацией по времени и размеру.
+// Безопасен для параллельного использования
+type Writer struct {
+	dir            string
+	rotateInterval time.Duration
+	maxFileSize    int64
+	maxFiles       int
+	logger         *utils.Logger
+
+	mu       sync.Mutex
+	file     *os.File
+	counter  *countingWriter
+	gz       *gzip.Writer
+	buf      *bufio.Writer
+	encoder  *json.Encoder
+	openedAt time.Time
+	lastName string
+	closed   bool
+}
+
+// NewWriter создает writer и каталог для файлов захвата. Первый файл
+// открывается при первой записи
+func NewWriter(cfg *config.CaptureConfig, logger *utils.Logger) (*Writer, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("capture directory is required")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create capture directory: %w", err)
+	}
+
+	return &Writer{
+		dir:            cfg.Dir,
+		rotateInterval: cfg.RotateInterval,
+		maxFileSize:    int64(cfg.MaxFileSizeMB) * 1024 * 1024,
+		maxFiles:       cfg.MaxFiles,
+		logger:         logger,
+	}, nil
+}
+
+// Record записывает сообщение (реализует mqtt.Recorder)
+func (w *Writer) Record(topic string, payload []byte, receivedAt time.Time) error {
+	err := w.Write(&Record{Time: receivedAt, Topic: topic, Payload: payload})
+	if err != nil {
+		metrics.CaptureRecords.WithLabelValues("error").Inc()
+		return err
+	}
+	metrics.CaptureRecords.WithLabelValues("written").Inc()
+	return nil
+}
+
+// Write записывает запись, при необходимости открывая новый файл
+func (w *Writer) Write(record *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("capture writer is closed")
+	}
+
+	if w.file != nil && w.needsRotation() {
+		if err := w.closeFile(); err != nil {
+			w.logger.WithField("error", err).Warn("Failed to close capture file")
+		}
+	}
+
+	if w.file == nil {
+		if err := w.openFile(); err != nil {
+			return err
+		}
+	}
+
+	return w.encoder.Encode(record)
+}
+
+// Flush сбрасывает буферы в текущий файл. Файл остается читаемым
+// как корректный gzip поток только после Close или ротации
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.gz.Flush()
+}
+
+// Close закрывает текущий файл
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	return w.closeFile()
+}
+
+// needsRotation проверяет превышение интервала или размера текущего файла
+func (w *Writer) needsRotation() bool {
+	if w.rotateInterval > 0 && time.Since(w.openedAt) >= w.rotateInterval {
+		return true
+	}
+	if w.maxFileSize > 0 {
+		// Размер сжатых данных известен только после сброса буферов,
+		// оцениваем по уже записанным в файл байтам и буферу
+		return w.counter.n+int64(w.buf.Buffered()) >= w.maxFileSize
+	}
+	return false
+}
+
+// openFile открывает новый файл с меткой времени в имени
+func (w *Writer) openFile() error {
+	now := time.Now().UTC()
+	base := filePrefix + now.Format("20060102T150405Z")
+
+	// При нескольких ротациях в секунду добавляется суффикс. Имя нового файла
+	// должно сортироваться после предыдущего, иначе нарушится порядок воспроизведения
+	var file *os.File
+	var name string
+	err := os.ErrExist
+	for i := 0; i < 100 && err != nil; i++ {
+		name = base + fileSuffix
+		if i > 0 {
+			name = fmt.Sprintf("%s_%02d%s", base, i, fileSuffix) // "_" сортируется после "."
+		}
+		if name <= w.lastName {
+			continue
+		}
+		file, err = os.OpenFile(filepath.Join(w.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil && !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("open capture file: %w", err)
+	}
+	w.lastName = name
+
+	w.file = file
+	w.counter = &countingWriter{w: file}
+	w.gz = gzip.NewWriter(w.counter)
+	w.buf = bufio.NewWriterSize(w.gz, 64*1024)
+	w.encoder = json.NewEncoder(w.buf)
+	w.openedAt = time.Now()
+
+	w.logger.WithField("file", file.Name()).Info("Opened capture file")
+	w.removeOldFiles()
+	return nil
+}
+
+// closeFile сбрасывает буферы и закрывает текущий файл
+func (w *Writer) closeFile() error {
+	defer func() {
+		w.file, w.counter, w.gz, w.buf, w.encoder = nil, nil, nil, nil, nil
+	}()
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// removeOldFiles удаляет самые старые файлы сверх лимита MaxFiles
+func (w *Writer) removeOldFiles() {
+	if w.maxFiles <= 0 {
+		return
+	}
+
+	files, err := ListFiles(w.dir)
+	if err != nil {
+		w.logger.WithField("error", err).Warn("Failed to list capture files")
+		return
+	}
+
+	for len(files) > w.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			w.logger.WithField("error", err).WithField("file", files[0]).Warn("Failed to remove old capture file")
+		}
+		files = files[1:]
+	}
+}
+
+// ListFiles возвращает файлы захвата в каталоге в хронологическом порядке
+func ListFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// countingWriter считает байты, записанные в файл
+type countingWriter struct {
+	w *os.File
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,113 @@
+package capture
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, source RecordSource) []*Record {
+	t.Helper()
+
+	var records []*Record
+	for {
+		record, err := source.Next()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(&config.CaptureConfig{Dir: dir, RotateInterval: time.Hour}, utils.NewLogger("info", "text"))
+	require.NoError(t, err)
+
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, writer.Record(fmt.Sprintf("fb/b/ABC/f/%d", i+1), []byte{0x00, byte(i), 0xFF}, start.Add(time.Duration(i)*time.Second)))
+	}
+	require.NoError(t, writer.Close())
+	assert.Error(t, writer.Record("fb/b/ABC/f/1", nil, start), "writer is closed")
+
+	files, err := ListFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	reader, err := OpenFile(files[0])
+	require.NoError(t, err)
+	defer reader.Close()
+
+	records := readAll(t, reader)
+	require.Len(t, records, 3)
+	assert.Equal(t, "fb/b/ABC/f/2", records[1].Topic)
+	assert.Equal(t, []byte{0x00, 0x01, 0xFF}, records[1].Payload)
+	assert.True(t, start.Add(time.Second).Equal(records[1].Time))
+}
+
+func TestWriter_RotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(&config.CaptureConfig{Dir: dir, MaxFiles: 2}, utils.NewLogger("info", "text"))
+	require.NoError(t, err)
+	// Ротация после каждой записи: размер файла считается от первого байта
+	writer.maxFileSize = 1
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, writer.Record("fb/b/ABC/f/1", []byte{byte(i)}, time.Now()))
+		require.NoError(t, writer.Flush())
+	}
+	require.NoError(t, writer.Close())
+
+	files, err := ListFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2, "old files are removed")
+
+	// Сохраняются последние файлы, порядок имен соответствует порядку записи
+	records := readAll(t, NewMultiReader(files))
+	require.Len(t, records, 2)
+	assert.Equal(t, []byte{2}, records[0].Payload)
+	assert.Equal(t, []byte{3}, records[1].Payload)
+}
+
+func TestReader_TruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(&config.CaptureConfig{Dir: dir}, utils.NewLogger("info", "text"))
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Record("fb/b/ABC/f/1", []byte{1}, time.Now()))
+	require.NoError(t, writer.Record("fb/b/ABC/f/1", []byte{2}, time.Now()))
+	// Flush без Close: gzip поток без завершения, как после аварийной остановки
+	require.NoError(t, writer.Flush())
+
+	files, err := ListFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	reader, err := OpenFile(files[0])
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.Len(t, readAll(t, reader), 2)
+
+	t.Run("plain json lines", func(t *testing.T) {
+		path := filepath.Join(dir, "manual.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(`{"t":"2024-07-14T12:00:00Z","topic":"fb/b/ABC/f/1","payload":"AQI="}`+"\n"), 0o644))
+
+		reader, err := OpenFile(path)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		records := readAll(t, reader)
+		require.Len(t, records, 1)
+		assert.Equal(t, []byte{1, 2}, records[0].Payload)
+	})
+}
@@ -21,6 +21,14 @@ type Config struct {
 	Performance PerformanceConfig
 	Monitoring  MonitoringConfig
 	Features    FeaturesConfig
+	Alerts      AlertsConfig
+	Ingest      IngestConfig
+	Capture     CaptureConfig
+	Gateways    GatewaysConfig
+	Thermals    ThermalsConfig
+	Flights     FlightsConfig
+	Filters     FiltersConfig
+	Kalman      KalmanConfig
 }
 
 // ServerConfig конфигурация HTTP сервера
@@ -51,6 +59,10 @@ type MQTTConfig struct {
 	OrderMatters bool
 	TopicPrefix  string
 	DebugEnabled bool
+
+	// Downlink: отправка FANET пакетов устройствам через базовые станции
+	DownlinkTopic  string // Шаблон топика, {chip_id} заменяется ID базовой станции
+	DownlinkSource string // FANET адрес сервера (24-bit hex) для исходящих пакетов
 }
 
 // MySQLConfig конфигурация MySQL (backup)
@@ -93,6 +105,12 @@ type PerformanceConfig struct {
 	BatchTimeout        time.Duration
 	WebSocketPingInterval time.Duration
 	WebSocketPongTimeout  time.Duration
+
+	// Конвейер обработки входящих сообщений (WorkerPoolSize обработчиков)
+	PipelineQueueSize      int           // Размер очереди одного обработчика
+	PipelineEnqueueTimeout time.Duration // Ожидание места в очереди, после чего сообщение отбрасывается
+
+	HeatmapMaxThermals int // Максимум термиков из MySQL на один запрос тепловой карты
 }
 
 // MonitoringConfig конфигурация мониторинга
@@ -101,6 +119,85 @@ type MonitoringConfig struct {
 	MetricsPort    string
 }
 
+// AlertsConfig конфигурация инцидентов по сигналам бедствия
+type AlertsConfig struct {
+	WebhookURL      string        // URL для webhook оповещений (пусто - отключено)
+	WebhookTimeout  time.Duration // Таймаут webhook запроса
+	ResolveCooldown time.Duration // Время игнорирования сигналов устройства после закрытия инцидента
+	UpdateInterval  time.Duration // Минимальный интервал WebSocket обновлений одного инцидента
+	RetentionPeriod time.Duration // Время хранения закрытых инцидентов
+}
+
+// IngestConfig конфигурация источников данных о позициях
+type IngestConfig struct {
+	MQTTEnabled       bool          // FANET пакеты от базовых станций через MQTT
+	ReconnectInterval time.Duration // Пауза перед переподключением TCP источников
+
+	// OGN APRS-IS
+	OGNEnabled  bool
+	OGNServer   string // host:port APRS-IS сервера
+	OGNCallsign string // Позывной для входа (только чтение, passcode -1)
+	OGNFilter   string // Серверный фильтр APRS-IS, по умолчанию окружность вокруг OGN центра
+
+	// NMEA (FLARM PFLAA)
+	NMEAEnabled bool
+	NMEASource  string // Путь к файлу/последовательному порту или tcp://host:port
+
+	// Прием одного пакета несколькими базовыми станциями
+	DedupWindow          time.Duration // Окно дедупликации копий пакета, 0 - без дедупликации
+	ReceptionStatsWindow time.Duration // Период, за который считаются станции, слышащие устройство
+}
+
+// GatewaysConfig конфигурация реестра базовых станций
+type GatewaysConfig struct {
+	OfflineAfter  time.Duration // Время без пакетов, после которого станция считается offline
+	CheckInterval time.Duration // Интервал проверки состояния и обновления метрик
+	MaxPositions  int           // Количество принятых позиций на станцию для оценки покрытия
+	Retention     time.Duration // Время хранения молчащих станций в реестре
+}
+
+// ThermalsConfig конфигурация обнаружения термиков по кружению пилотов
+type ThermalsConfig struct {
+	DetectionEnabled bool
+	Window           time.Duration // Окно анализа трека одного пилота
+	MinClimb         float64       // Минимальный средний набор высоты (м/с)
+	ClusterRadiusKM  float64       // Радиус объединения кружений нескольких пилотов
+	HitTTL           time.Duration // Время, в течение которого кружение участвует в кластере
+}
+
+// FlightsConfig конфигурация определения взлетов и посадок
+type FlightsConfig struct {
+	DetectionEnabled bool
+	TakeoffConfirm   time.Duration // Длительность полетной активности до подтверждения взлета
+	LandingConfirm   time.Duration // Длительность наземной активности до подтверждения посадки
+	LostTimeout      time.Duration // Время без позиций, после которого полет закрывается как lost
+}
+
+// FiltersConfig конфигурация фильтрации треков
+type FiltersConfig struct {
+	ChainsFile   string        // YAML/JSON файл с цепочками фильтров (пусто - только встроенные уровни)
+	LiveEnabled  bool          // Потоковая фильтрация живых позиций перед Redis и WebSocket
+	LiveMaxDelay time.Duration // Ожидание следующей позиции для подозрительного скачка
+	LiveIdleTTL  time.Duration // Время без позиций, после которого состояние устройства удаляется
+}
+
+// KalmanConfig конфигурация сглаживания позиций фильтром Калмана
+type KalmanConfig struct {
+	Enabled           bool
+	PositionNoise     float64       // Погрешность GPS по горизонтали, 1 сигма (м)
+	GateChi2          float64       // Порог отсева выбросов: квадрат расстояния Махаланобиса
+	PredictionHorizon time.Duration // Горизонт прогноза позиции
+}
+
+// CaptureConfig конфигурация записи сырого MQTT трафика
+type CaptureConfig struct {
+	Enabled        bool
+	Dir            string        // Каталог для файлов захвата
+	RotateInterval time.Duration // Максимальный интервал одного файла
+	MaxFileSizeMB  int           // Максимальный размер одного файла (сжатый)
+	MaxFiles       int           // Количество хранимых файлов (0 - без ограничения)
+}
+
 // FeaturesConfig флаги функций
 type FeaturesConfig struct {
 	EnableMySQLFallback bool
@@ -131,9 +228,12 @@ func Load() (*Config, error) {
 			Username:     getEnv("MQTT_USERNAME", ""),
 			Password:     getEnv("MQTT_PASSWORD", ""),
 			CleanSession: getBool("MQTT_CLEAN_SESSION", false),
-			OrderMatters: getBool("MQTT_ORDER_MATTERS", false),
+			OrderMatters: getBool("MQTT_ORDER_MATTERS", true),
 			TopicPrefix:  getEnv("MQTT_TOPIC_PREFIX", "fb/b/+/f/#"),
 			DebugEnabled: getBool("MQTT_DEBUG", false),
+
+			DownlinkTopic:  getEnv("MQTT_DOWNLINK_TOPIC", "fb/b/{chip_id}/d"),
+			DownlinkSource: getEnv("MQTT_DOWNLINK_SOURCE", "FB0001"),
 		},
 		MySQL: MySQLConfig{
 			DSN:          getEnv("MYSQL_DSN", ""),
@@ -168,6 +268,11 @@ func Load() (*Config, error) {
 			BatchTimeout:          getDuration("BATCH_TIMEOUT", 5*time.Second),
 			WebSocketPingInterval: getDuration("WEBSOCKET_PING_INTERVAL", 30*time.Second),
 			WebSocketPongTimeout:  getDuration("WEBSOCKET_PONG_TIMEOUT", 60*time.Second),
+
+			PipelineQueueSize:      getInt("PIPELINE_QUEUE_SIZE", 256),
+			PipelineEnqueueTimeout: getDuration("PIPELINE_ENQUEUE_TIMEOUT", time.Second),
+
+			HeatmapMaxThermals: getInt("HEATMAP_MAX_THERMALS", 50000),
 		},
 		Monitoring: MonitoringConfig{
 			MetricsEnabled: getBool("METRICS_ENABLED", true),
@@ -177,6 +282,71 @@ func Load() (*Config, error) {
 			EnableMySQLFallback: getBool("ENABLE_MYSQL_FALLBACK", true),
 			EnableProfiling:     getBool("ENABLE_PROFILING", false),
 		},
+		Alerts: AlertsConfig{
+			WebhookURL:      getEnv("ALERT_WEBHOOK_URL", ""),
+			WebhookTimeout:  getDuration("ALERT_WEBHOOK_TIMEOUT", 5*time.Second),
+			ResolveCooldown: getDuration("ALERT_RESOLVE_COOLDOWN", 10*time.Minute),
+			UpdateInterval:  getDuration("ALERT_UPDATE_INTERVAL", 30*time.Second),
+			RetentionPeriod: getDuration("ALERT_RETENTION_PERIOD", 24*time.Hour),
+		},
+		Ingest: IngestConfig{
+			MQTTEnabled:       getBool("INGEST_MQTT_ENABLED", true),
+			ReconnectInterval: getDuration("INGEST_RECONNECT_INTERVAL", 10*time.Second),
+
+			OGNEnabled:  getBool("OGN_APRS_ENABLED", false),
+			OGNServer:   getEnv("OGN_APRS_SERVER", "aprs.glidernet.org:14580"),
+			OGNCallsign: getEnv("OGN_APRS_CALLSIGN", "FANETAPI"),
+			OGNFilter:   getEnv("OGN_APRS_FILTER", ""),
+
+			NMEAEnabled: getBool("NMEA_ENABLED", false),
+			NMEASource:  getEnv("NMEA_SOURCE", ""),
+
+			DedupWindow:          getDuration("DEDUP_WINDOW", 2*time.Second),
+			ReceptionStatsWindow: getDuration("RECEPTION_STATS_WINDOW", 5*time.Minute),
+		},
+		Capture: CaptureConfig{
+			Enabled:        getBool("CAPTURE_ENABLED", false),
+			Dir:            getEnv("CAPTURE_DIR", "./captures"),
+			RotateInterval: getDuration("CAPTURE_ROTATE_INTERVAL", time.Hour),
+			MaxFileSizeMB:  getInt("CAPTURE_MAX_FILE_SIZE_MB", 100),
+			MaxFiles:       getInt("CAPTURE_MAX_FILES", 168),
+		},
+		Gateways: GatewaysConfig{
+			OfflineAfter:  getDuration("GATEWAY_OFFLINE_AFTER", 10*time.Minute),
+			CheckInterval: getDuration("GATEWAY_CHECK_INTERVAL", 30*time.Second),
+			MaxPositions:  getInt("GATEWAY_MAX_POSITIONS", 500),
+			Retention:     getDuration("GATEWAY_RETENTION", 7*24*time.Hour),
+		},
+		Thermals: ThermalsConfig{
+			DetectionEnabled: getBool("THERMAL_DETECTION_ENABLED", true),
+			Window:           getDuration("THERMAL_DETECTION_WINDOW", 90*time.Second),
+			MinClimb:         getFloat("THERMAL_MIN_CLIMB", 0.3),
+			ClusterRadiusKM:  getFloat("THERMAL_CLUSTER_RADIUS_KM", 0.5),
+			HitTTL:           getDuration("THERMAL_HIT_TTL", 20*time.Minute),
+		},
+		Flights: FlightsConfig{
+			DetectionEnabled: getBool("FLIGHT_DETECTION_ENABLED", true),
+			TakeoffConfirm:   getDuration("FLIGHT_TAKEOFF_CONFIRM", 20*time.Second),
+			LandingConfirm:   getDuration("FLIGHT_LANDING_CONFIRM", 60*time.Second),
+			LostTimeout:      getDuration("FLIGHT_LOST_TIMEOUT", 10*time.Minute),
+		},
+		Filters: FiltersConfig{
+			ChainsFile:   getEnv("FILTER_CHAINS_FILE", ""),
+			LiveEnabled:  getBool("LIVE_FILTER_ENABLED", true),
+			LiveMaxDelay: getDuration("LIVE_FILTER_MAX_DELAY", 15*time.Second),
+			LiveIdleTTL:  getDuration("LIVE_FILTER_IDLE_TTL", 30*time.Minute),
+		},
+		Kalman: KalmanConfig{
+			Enabled:           getBool("KALMAN_ENABLED", true),
+			PositionNoise:     getFloat("KALMAN_POSITION_NOISE_M", 10),
+			GateChi2:          getFloat("KALMAN_GATE_CHI2", 13.8),
+			PredictionHorizon: getDuration("KALMAN_PREDICTION_HORIZON", 5*time.Second),
+		},
+	}
+
+	// По умолчанию OGN фильтр совпадает с зоной отслеживания OGN центра
+	if cfg.Ingest.OGNFilter == "" {
+		cfg.Ingest.OGNFilter = fmt.Sprintf("r/%.4f/%.4f/%.0f", cfg.Geo.OGNCenterLat, cfg.Geo.OGNCenterLon, cfg.Geo.OGNRadiusKM)
 	}
 
 	// Валидация
@@ -225,11 +395,64 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("OGN_RADIUS_KM must be positive")
 	}
 
+	// Проверка источников данных
+	if c.Ingest.OGNEnabled && (c.Ingest.OGNServer == "" || c.Ingest.OGNCallsign == "") {
+		return fmt.Errorf("OGN_APRS_SERVER and OGN_APRS_CALLSIGN are required when OGN_APRS_ENABLED is set")
+	}
+
+	if c.Ingest.NMEAEnabled && c.Ingest.NMEASource == "" {
+		return fmt.Errorf("NMEA_SOURCE is required when NMEA_ENABLED is set")
+	}
+
+	if c.Ingest.ReconnectInterval <= 0 {
+		return fmt.Errorf("INGEST_RECONNECT_INTERVAL must be positive")
+	}
+
+	if c.Ingest.DedupWindow < 0 {
+		return fmt.Errorf("DEDUP_WINDOW must be non-negative")
+	}
+
+	if c.Ingest.ReceptionStatsWindow <= 0 {
+		return fmt.Errorf("RECEPTION_STATS_WINDOW must be positive")
+	}
+
+	if c.Gateways.OfflineAfter <= 0 || c.Gateways.CheckInterval <= 0 {
+		return fmt.Errorf("GATEWAY_OFFLINE_AFTER and GATEWAY_CHECK_INTERVAL must be positive")
+	}
+
+	if c.Gateways.MaxPositions < 0 {
+		return fmt.Errorf("GATEWAY_MAX_POSITIONS must be non-negative")
+	}
+
+	if c.Thermals.DetectionEnabled && (c.Thermals.Window <= 0 || c.Thermals.ClusterRadiusKM <= 0 || c.Thermals.HitTTL <= 0) {
+		return fmt.Errorf("THERMAL_DETECTION_WINDOW, THERMAL_CLUSTER_RADIUS_KM and THERMAL_HIT_TTL must be positive")
+	}
+
+	if c.Flights.DetectionEnabled && (c.Flights.TakeoffConfirm <= 0 || c.Flights.LandingConfirm <= 0 || c.Flights.LostTimeout <= 0) {
+		return fmt.Errorf("FLIGHT_TAKEOFF_CONFIRM, FLIGHT_LANDING_CONFIRM and FLIGHT_LOST_TIMEOUT must be positive")
+	}
+
+	if c.Filters.LiveEnabled && (c.Filters.LiveMaxDelay <= 0 || c.Filters.LiveIdleTTL <= 0) {
+		return fmt.Errorf("LIVE_FILTER_MAX_DELAY and LIVE_FILTER_IDLE_TTL must be positive")
+	}
+
+	if c.Kalman.Enabled && (c.Kalman.PositionNoise <= 0 || c.Kalman.GateChi2 <= 0 || c.Kalman.PredictionHorizon < 0) {
+		return fmt.Errorf("KALMAN_POSITION_NOISE_M and KALMAN_GATE_CHI2 must be positive, KALMAN_PREDICTION_HORIZON non-negative")
+	}
+
+	if c.Capture.Enabled && c.Capture.Dir == "" {
+		return fmt.Errorf("CAPTURE_DIR is required when CAPTURE_ENABLED is set")
+	}
+
 	// Проверка производительности
 	if c.Performance.WorkerPoolSize <= 0 {
 		return fmt.Errorf("WORKER_POOL_SIZE must be positive")
 	}
 
+	if c.Performance.PipelineQueueSize <= 0 {
+		return fmt.Errorf("PIPELINE_QUEUE_SIZE must be positive")
+	}
+
 	if c.Performance.MaxBatchSize <= 0 {
 		return fmt.Errorf("MAX_BATCH_SIZE must be positive")
 	}
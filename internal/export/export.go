@@ -0,0 +1,90 @@
+// Package export формирует файлы треков для загрузки в XContest, Google Earth
+// и навигационные приложения: IGC, GPX и KML
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// Format формат файла трека
+type Format string
+
+const (
+	FormatIGC Format = "igc"
+	FormatGPX Format = "gpx"
+	FormatKML Format = "kml"
+)
+
+// ParseFormat проверяет, является ли строка форматом файла трека
+func ParseFormat(s string) (Format, bool) {
+	switch Format(s) {
+	case FormatIGC, FormatGPX, FormatKML:
+		return Format(s), true
+	default:
+		return "", false
+	}
+}
+
+// ContentType возвращает MIME тип файла
+func (f Format) ContentType() string {
+	switch f {
+	case FormatIGC:
+		return "application/vnd.fai.igc"
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// TrackInfo сведения о треке для заголовков файла
+type TrackInfo struct {
+	DeviceID     string           // FANET адрес в hex формате
+	AircraftType models.PilotType // Тип летательного аппарата
+	PilotName    string           // Имя пилота (может быть пустым)
+	FlightID     int64            // ID полета, 0 - произвольный отрезок трека
+}
+
+// Filename возвращает имя файла для Content-Disposition: ABC123-2024-06-10.igc
+// (для полета - ABC123-2024-06-10-flight42.igc)
+func Filename(info TrackInfo, points []models.TrackGeoPoint, format Format) string {
+	date := time.Now().UTC()
+	if len(points) > 0 {
+		date = points[0].Timestamp.UTC()
+	}
+
+	name := fmt.Sprintf("%s-%s", strings.ToUpper(info.DeviceID), date.Format("2006-01-02"))
+	if info.FlightID > 0 {
+		name += fmt.Sprintf("-flight%d", info.FlightID)
+	}
+	return name + "." + string(format)
+}
+
+// Write записывает трек в выбранном формате. Точки должны быть упорядочены по времени
+func Write(w io.Writer, format Format, info TrackInfo, points []models.TrackGeoPoint) error {
+	switch format {
+	case FormatIGC:
+		return WriteIGC(w, info, points)
+	case FormatGPX:
+		return WriteGPX(w, info, points)
+	case FormatKML:
+		return WriteKML(w, info, points)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// trackTitle возвращает название трека: имя пилота или адрес устройства
+func trackTitle(info TrackInfo) string {
+	if info.PilotName != "" {
+		return fmt.Sprintf("%s (%s)", info.PilotName, strings.ToUpper(info.DeviceID))
+	}
+	return strings.ToUpper(info.DeviceID)
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTrack() (TrackInfo, []models.TrackGeoPoint) {
+	pressure := int32(1480)
+	start := time.Date(2024, 6, 10, 11, 5, 30, 0, time.UTC)
+	return TrackInfo{
+		DeviceID:     "abc123",
+		AircraftType: models.PilotTypeParaglider,
+		PilotName:    "Jan Novák",
+	}, []models.TrackGeoPoint{
+		{
+			GeoPoint:         models.GeoPoint{Latitude: 46.123456, Longitude: 14.5, Altitude: 1500},
+			PressureAltitude: &pressure,
+			Timestamp:        start,
+		},
+		{
+			GeoPoint:  models.GeoPoint{Latitude: -33.5, Longitude: -70.25, Altitude: -12},
+			Timestamp: start.Add(5 * time.Second),
+		},
+	}
+}
+
+func TestWriteIGC(t *testing.T) {
+	info, points := testTrack()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteIGC(&buf, info, points))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Equal(t, "AXFBABC123 FANET", lines[0])
+	assert.Contains(t, lines, "HFDTEDATE:100624,01")
+	assert.Contains(t, lines, "HFPLTPILOTINCHARGE:Jan Nov_k")
+	assert.Contains(t, lines, "HFGTYGLIDERTYPE:Paraglider")
+	assert.Contains(t, lines, "HFGIDGLIDERID:ABC123")
+
+	// 46.123456 = 46°07.407'N, 14.5 = 014°30.000'E
+	assert.Equal(t, "B1105304607407N01430000EA0148001500", lines[len(lines)-2])
+	// Южное/западное полушарие, отрицательная высота и отсутствие барометра
+	assert.Equal(t, "B1105353330000S07015000WA00000-0012", lines[len(lines)-1])
+	for _, line := range lines {
+		if strings.HasPrefix(line, "B") {
+			assert.Len(t, line, 35)
+		}
+	}
+}
+
+func TestWriteGPXAndKML(t *testing.T) {
+	info, points := testTrack()
+
+	var gpx bytes.Buffer
+	require.NoError(t, WriteGPX(&gpx, info, points))
+	var parsedGPX struct {
+		Track struct {
+			Name   string `xml:"name"`
+			Points []struct {
+				Lat  float64 `xml:"lat,attr"`
+				Ele  int32   `xml:"ele"`
+				Time string  `xml:"time"`
+			} `xml:"trkseg>trkpt"`
+		} `xml:"trk"`
+	}
+	require.NoError(t, xml.Unmarshal(gpx.Bytes(), &parsedGPX))
+	assert.Equal(t, "Jan Novák (ABC123)", parsedGPX.Track.Name)
+	require.Len(t, parsedGPX.Track.Points, 2)
+	assert.Equal(t, 46.123456, parsedGPX.Track.Points[0].Lat)
+	assert.Equal(t, int32(1500), parsedGPX.Track.Points[0].Ele)
+	assert.Equal(t, "2024-06-10T11:05:30Z", parsedGPX.Track.Points[0].Time)
+
+	var kml bytes.Buffer
+	require.NoError(t, WriteKML(&kml, info, points))
+	assert.Contains(t, kml.String(), "<gx:coord>14.500000 46.123456 1500</gx:coord>")
+	assert.Contains(t, kml.String(), "<when>2024-06-10T11:05:35Z</when>")
+
+	assert.Error(t, WriteGPX(&gpx, info, nil))
+}
+
+func TestFilename(t *testing.T) {
+	info, points := testTrack()
+	assert.Equal(t, "ABC123-2024-06-10.igc", Filename(info, points, FormatIGC))
+
+	info.FlightID = 42
+	assert.Equal(t, "ABC123-2024-06-10-flight42.gpx", Filename(info, points, FormatGPX))
+
+	format, ok := ParseFormat("kml")
+	assert.True(t, ok)
+	assert.Equal(t, "application/vnd.google-earth.kml+xml", format.ContentType())
+	_, ok = ParseFormat("geojson")
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// gpxDocument корневой элемент GPX 1.1
+type gpxDocument struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Track    gpxTrack    `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Name    string          `xml:"name"`
+	Type    string          `xml:"type,omitempty"`
+	Segment gpxTrackSegment `xml:"trkseg"`
+}
+
+type gpxTrackSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Elevation int32   `xml:"ele"`
+	Time      string  `xml:"time"`
+}
+
+// WriteGPX записывает трек в формате GPX 1.1 (один сегмент, высота GNSS)
+func WriteGPX(w io.Writer, info TrackInfo, points []models.TrackGeoPoint) error {
+	if len(points) == 0 {
+		return fmt.Errorf("track has no points")
+	}
+
+	doc := gpxDocument{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "FANET API",
+		Metadata: gpxMetadata{
+			Name: trackTitle(info),
+			Time: points[0].Timestamp.UTC().Format(time.RFC3339),
+		},
+		Track: gpxTrack{
+			Name: trackTitle(info),
+			Type: info.AircraftType.String(),
+			Segment: gpxTrackSegment{
+				Points: make([]gpxPoint, len(points)),
+			},
+		},
+	}
+	for i, point := range points {
+		doc.Track.Segment.Points[i] = gpxPoint{
+			Latitude:  point.Latitude,
+			Longitude: point.Longitude,
+			Elevation: point.Altitude,
+			Time:      point.Timestamp.UTC().Format(time.RFC3339),
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode GPX: %w", err)
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// igcManufacturer код производителя в A-записи (X - не сертифицированный FAI регистратор)
+const igcManufacturer = "XFB"
+
+// WriteIGC записывает трек в формате IGC: A-запись, H-заголовки (дата, пилот,
+// тип и ID аппарата) и B-записи с барометрической и GNSS высотой.
+// Трек не подписывается (нет G-записи), поэтому не является FAI валидированным
+func WriteIGC(w io.Writer, info TrackInfo, points []models.TrackGeoPoint) error {
+	if len(points) == 0 {
+		return fmt.Errorf("track has no points")
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(bw, format+"\r\n", args...)
+	}
+
+	deviceID := strings.ToUpper(info.DeviceID)
+	date := points[0].Timestamp.UTC()
+
+	line("A%s%s FANET", igcManufacturer, igcText(deviceID))
+	line("HFDTEDATE:%s,01", date.Format("020106"))
+	line("HFPLTPILOTINCHARGE:%s", igcText(info.PilotName))
+	line("HFCM2CREW2:NIL")
+	line("HFGTYGLIDERTYPE:%s", igcGliderType(info.AircraftType))
+	line("HFGIDGLIDERID:%s", igcText(deviceID))
+	line("HFDTMGPSDATUM:WGS84")
+	line("HFRFWFIRMWAREVERSION:")
+	line("HFRHWHARDWAREVERSION:")
+	line("HFFTYFRTYPE:FANET,LIVE TRACKING")
+	line("HFGPSRECEIVER:")
+	line("HFPRSPRESSALTSENSOR:")
+	line("HFALGALTGPS:GEO")
+	line("HFALPALTPRESSURE:ISA")
+
+	for _, point := range points {
+		// Без барометрической высоты по спецификации пишется 00000
+		pressure := int32(0)
+		if point.PressureAltitude != nil {
+			pressure = *point.PressureAltitude
+		}
+
+		line("B%s%s%sA%s%s",
+			point.Timestamp.UTC().Format("150405"),
+			igcCoordinate(point.Latitude, 2, "N", "S"),
+			igcCoordinate(point.Longitude, 3, "E", "W"),
+			igcAltitude(pressure),
+			igcAltitude(point.Altitude))
+	}
+
+	return bw.Flush()
+}
+
+// igcCoordinate форматирует координату как DDMMmmmN / DDDMMmmmE
+func igcCoordinate(value float64, degreeDigits int, positive, negative string) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+		value = -value
+	}
+
+	thousandths := int64(math.Round(value * 60000)) // тысячные доли минуты
+	degrees := thousandths / 60000
+	minutes := thousandths % 60000
+	return fmt.Sprintf("%0*d%05d%s", degreeDigits, degrees, minutes, hemisphere)
+}
+
+// igcAltitude форматирует высоту в 5 символов (отрицательная - "-0012")
+func igcAltitude(altitude int32) string {
+	altitude = max(-9999, min(99999, altitude))
+	return fmt.Sprintf("%05d", altitude)
+}
+
+// igcGliderType возвращает тип аппарата для H-записи
+func igcGliderType(t models.PilotType) string {
+	switch t {
+	case models.PilotTypeParaglider:
+		return "Paraglider"
+	case models.PilotTypeHangglider:
+		return "Hangglider"
+	case models.PilotTypeBalloon:
+		return "Balloon"
+	case models.PilotTypeGlider:
+		return "Glider"
+	case models.PilotTypePowered:
+		return "Powered aircraft"
+	case models.PilotTypeHelicopter:
+		return "Helicopter"
+	case models.PilotTypeUAV:
+		return "UAV"
+	default:
+		return ""
+	}
+}
+
+// igcText оставляет в строке только печатные ASCII символы (требование формата)
+func igcText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
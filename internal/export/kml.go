@@ -0,0 +1,92 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// kmlDocument корневой элемент KML 2.2 с расширением gx:Track (трек со временем)
+type kmlDocument struct {
+	XMLName  xml.Name        `xml:"kml"`
+	Xmlns    string          `xml:"xmlns,attr"`
+	XmlnsGx  string          `xml:"xmlns:gx,attr"`
+	Document kmlDocumentBody `xml:"Document"`
+}
+
+type kmlDocumentBody struct {
+	Name      string       `xml:"name"`
+	Style     kmlStyle     `xml:"Style"`
+	Placemark kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string       `xml:"id,attr"`
+	LineStyle kmlLineStyle `xml:"LineStyle"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlPlacemark struct {
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty"`
+	StyleURL    string   `xml:"styleUrl"`
+	Track       kmlTrack `xml:"gx:Track"`
+}
+
+type kmlTrack struct {
+	AltitudeMode string   `xml:"altitudeMode"`
+	When         []string `xml:"when"`
+	Coords       []string `xml:"gx:coord"`
+}
+
+// WriteKML записывает трек в формате KML (gx:Track, абсолютная высота GNSS)
+func WriteKML(w io.Writer, info TrackInfo, points []models.TrackGeoPoint) error {
+	if len(points) == 0 {
+		return fmt.Errorf("track has no points")
+	}
+
+	track := kmlTrack{
+		AltitudeMode: "absolute",
+		When:         make([]string, len(points)),
+		Coords:       make([]string, len(points)),
+	}
+	for i, point := range points {
+		track.When[i] = point.Timestamp.UTC().Format(time.RFC3339)
+		track.Coords[i] = fmt.Sprintf("%.6f %.6f %d", point.Longitude, point.Latitude, point.Altitude)
+	}
+
+	doc := kmlDocument{
+		Xmlns:   "http://www.opengis.net/kml/2.2",
+		XmlnsGx: "http://www.google.com/kml/ext/2.2",
+		Document: kmlDocumentBody{
+			Name: trackTitle(info),
+			Style: kmlStyle{
+				ID:        "track",
+				LineStyle: kmlLineStyle{Color: "ff2eb11b", Width: 3}, // aabbggrr
+			},
+			Placemark: kmlPlacemark{
+				Name:        trackTitle(info),
+				Description: info.AircraftType.String(),
+				StyleURL:    "#track",
+				Track:       track,
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode KML: %w", err)
+	}
+	return encoder.Close()
+}
@@ -259,6 +259,12 @@ func (f *ActivitySegmentationFilter) classifyActivityWithHysteresis(speed float6
 	}
 }
 
+// ClassifyActivity определяет тип активности по скорости (км/ч) с учетом гистерезиса
+// относительно текущей активности. Используется для потоковой обработки позиций
+func (f *ActivitySegmentationFilter) ClassifyActivity(speed float64, currentActivity ActivityType) ActivityType {
+	return f.classifyActivityWithHysteresis(speed, currentActivity)
+}
+
 // createActivitySegment создает информацию о сегменте активности
 func (f *ActivitySegmentationFilter) createActivitySegment(points []TrackPoint, id int, startIndex int) SegmentInfo {
 	if len(points) == 0 {
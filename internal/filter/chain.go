@@ -53,6 +53,19 @@ func (fc *FilterChain) AddFilter(filter TrackFilter) {
 
 // Filter применяет все фильтры в цепочке
 func (fc *FilterChain) Filter(track *TrackData) (*FilterResult, error) {
+	return fc.run(track, nil)
+}
+
+// FilterWithReport применяет цепочку как Filter и дополнительно возвращает отчет
+// по каждому фильтру: статистику, время работы и удаленные точки
+func (fc *FilterChain) FilterWithReport(track *TrackData) (*FilterResult, []StepReport, error) {
+	reports := make([]StepReport, 0, len(fc.filters))
+	result, err := fc.run(track, &reports)
+	return result, reports, err
+}
+
+// run применяет фильтры последовательно; reports != nil - собирает отчет по шагам
+func (fc *FilterChain) run(track *TrackData, reports *[]StepReport) (*FilterResult, error) {
 	if len(track.Points) == 0 {
 		return &FilterResult{
 			OriginalCount: 0,
@@ -70,16 +83,27 @@ func (fc *FilterChain) Filter(track *TrackData) (*FilterResult, error) {
 	originalCount := len(track.Points)
 	currentTrack := *track // Копируем трек
 	combinedStats := FilterStats{}
+	collect := fc.config != nil && fc.config.CollectRejected
+	var rejected []RejectedPoint
 	
 	// Применяем каждый фильтр последовательно
 	for _, filter := range fc.filters {
 		start := time.Now()
+
+		// Некоторые фильтры помечают точки входного трека на месте
+		var input []TrackPoint
+		if collect || reports != nil {
+			input = append(input, currentTrack.Points...)
+		}
 		
 		result, err := filter.Filter(&currentTrack)
 		if err != nil {
 			fc.logger.WithField("filter", filter.Name()).
 				WithField("error", err).
 				Error("Filter failed")
+			if reports != nil {
+				*reports = append(*reports, StepReport{Filter: filter.Name(), Description: filter.Description(), Error: err.Error()})
+			}
 			continue
 		}
 
@@ -92,6 +116,16 @@ func (fc *FilterChain) Filter(track *TrackData) (*FilterResult, error) {
 			WithField("duration_ms", duration.Milliseconds()).
 			Debug("Filter applied")
 
+		if collect || reports != nil {
+			stepRejected := rejectedPoints(filter, input, result)
+			if collect {
+				rejected = append(rejected, stepRejected...)
+			}
+			if reports != nil {
+				*reports = append(*reports, newStepReport(filter, input, result, stepRejected, duration))
+			}
+		}
+
 		// Обновляем трек для следующего фильтра
 		currentTrack.Points = result.Points
 		
@@ -148,11 +182,14 @@ func (fc *FilterChain) Filter(track *TrackData) (*FilterResult, error) {
 		}
 	}
 
+	combinedStats.RejectedByFilter = rejectedByFilter(rejected)
+
 	result := &FilterResult{
 		OriginalCount: originalCount,
 		FilteredCount: filteredCount,
 		Points:        currentTrack.Points,
 		Statistics:    combinedStats,
+		Rejected:      rejected,
 	}
 
 	fc.logger.WithField("device_id", track.DeviceID).
@@ -0,0 +1,288 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigOverrides переопределяет поля FilterConfig для цепочки или отдельного фильтра.
+// Незаданные поля наследуются
+type ConfigOverrides struct {
+	MaxSpeeds             map[models.PilotType]float64 `json:"max_speeds,omitempty" yaml:"max_speeds"`
+	SpeedBuffer           *float64                     `json:"speed_buffer,omitempty" yaml:"speed_buffer"`
+	MinDistanceMeters     *float64                     `json:"min_distance_meters,omitempty" yaml:"min_distance_meters"`
+	MinTimeIntervalSec    *float64                     `json:"min_time_interval_sec,omitempty" yaml:"min_time_interval_sec"`
+	OutlierThresholdKm    *float64                     `json:"outlier_threshold_km,omitempty" yaml:"outlier_threshold_km"`
+	EnableSpeedFilter     *bool                        `json:"enable_speed_filter,omitempty" yaml:"enable_speed_filter"`
+	EnableDuplicateFilter *bool                        `json:"enable_duplicate_filter,omitempty" yaml:"enable_duplicate_filter"`
+	EnableOutlierFilter   *bool                        `json:"enable_outlier_filter,omitempty" yaml:"enable_outlier_filter"`
+}
+
+// Apply возвращает копию base с переопределенными полями
+func (o *ConfigOverrides) Apply(base *FilterConfig) *FilterConfig {
+	config := *base
+	config.MaxSpeeds = make(map[models.PilotType]float64, len(base.MaxSpeeds))
+	for aircraftType, speed := range base.MaxSpeeds {
+		config.MaxSpeeds[aircraftType] = speed
+	}
+	if o == nil {
+		return &config
+	}
+
+	for aircraftType, speed := range o.MaxSpeeds {
+		config.MaxSpeeds[aircraftType] = speed
+	}
+	if o.SpeedBuffer != nil {
+		config.SpeedBuffer = *o.SpeedBuffer
+	}
+	if o.MinDistanceMeters != nil {
+		config.MinDistanceMeters = *o.MinDistanceMeters
+	}
+	if o.MinTimeIntervalSec != nil {
+		config.MinTimeInterval = time.Duration(*o.MinTimeIntervalSec * float64(time.Second))
+	}
+	if o.OutlierThresholdKm != nil {
+		config.OutlierThresholdKm = *o.OutlierThresholdKm
+	}
+	if o.EnableSpeedFilter != nil {
+		config.EnableSpeedFilter = *o.EnableSpeedFilter
+	}
+	if o.EnableDuplicateFilter != nil {
+		config.EnableDuplicateFilter = *o.EnableDuplicateFilter
+	}
+	if o.EnableOutlierFilter != nil {
+		config.EnableOutlierFilter = *o.EnableOutlierFilter
+	}
+	return &config
+}
+
+// FilterParams параметры отдельных фильтров, которых нет в FilterConfig
+type FilterParams struct {
+	GapMinutes    int     `json:"gap_minutes,omitempty" yaml:"gap_minutes"`         // TimeGapSegmentationFilter (по умолчанию 30)
+	MaxDistanceKm float64 `json:"max_distance_km,omitempty" yaml:"max_distance_km"` // TeleportationFilter (по умолчанию 200)
+}
+
+// StepSpec фильтр в описании цепочки
+type StepSpec struct {
+	Filter string           `json:"filter" yaml:"filter"` // Имя фильтра (Name())
+	Config *ConfigOverrides `json:"config,omitempty" yaml:"config"`
+	Params FilterParams     `json:"params,omitempty" yaml:"params"`
+}
+
+// ChainSpec декларативное описание цепочки фильтров
+type ChainSpec struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description string           `json:"description,omitempty" yaml:"description"`
+	Config      *ConfigOverrides `json:"config,omitempty" yaml:"config"` // Общие параметры фильтров цепочки
+	Filters     []StepSpec       `json:"filters" yaml:"filters"`
+}
+
+// filterFactory создает фильтр с конфигурацией и параметрами шага
+type filterFactory func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter
+
+// filterFactories фильтры, доступные в декларативных цепочках
+var filterFactories = map[string]filterFactory{
+	"DuplicateFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewDuplicateFilter(config, logger)
+	},
+	"SmartTeleportationFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewSmartTeleportationFilter(config, logger)
+	},
+	"TeleportationFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		maxDistance := params.MaxDistanceKm
+		if maxDistance <= 0 {
+			maxDistance = 200
+		}
+		return NewTeleportationFilter(config, logger, maxDistance)
+	},
+	"PreCleanupFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewPreCleanupFilter(config, logger)
+	},
+	"TimeGapSegmentationFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewTimeGapSegmentationFilter(config, logger, params.GapMinutes)
+	},
+	"SegmentedFilterChain": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewSegmentedFilterChain(config, logger)
+	},
+	"ActivitySegmentationFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewActivitySegmentationFilter(config, logger)
+	},
+	"SegmentationFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewSegmentationFilter(config, logger)
+	},
+	"OutlierFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewOutlierFilter(config, logger)
+	},
+	"LocalOutlierFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewLocalOutlierFilter(config, logger)
+	},
+	"CrossSegmentFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewCrossSegmentFilter(config, logger)
+	},
+	"SpeedBasedFilter": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewSpeedBasedFilter(config, logger)
+	},
+	"TwoStageFilterChain": func(config *FilterConfig, params FilterParams, logger *utils.Logger) TrackFilter {
+		return NewTwoStageFilterChain(config, logger)
+	},
+}
+
+// filterParamNames параметры FilterParams, которые учитывает фильтр
+var filterParamNames = map[string][]string{
+	"TeleportationFilter":       {"max_distance_km"},
+	"TimeGapSegmentationFilter": {"gap_minutes"},
+}
+
+// FilterInfo описание фильтра, доступного в цепочках
+type FilterInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Params      []string `json:"params,omitempty"` // Параметры шага помимо config
+}
+
+// AvailableFilters возвращает фильтры для декларативных цепочек, отсортированные по имени
+func AvailableFilters(logger *utils.Logger) []FilterInfo {
+	config := DefaultFilterConfig()
+	filters := make([]FilterInfo, 0, len(filterFactories))
+	for name, factory := range filterFactories {
+		filters = append(filters, FilterInfo{
+			Name:        name,
+			Description: factory(config, FilterParams{}, logger).Description(),
+			Params:      filterParamNames[name],
+		})
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Name < filters[j].Name })
+	return filters
+}
+
+// Validate проверяет описание цепочки: имя и известные фильтры
+func (s *ChainSpec) Validate() error {
+	if len(s.Filters) == 0 {
+		return fmt.Errorf("chain %q has no filters", s.Name)
+	}
+	for i, step := range s.Filters {
+		if _, ok := filterFactories[step.Filter]; !ok {
+			return fmt.Errorf("chain %q: unknown filter %q at position %d", s.Name, step.Filter, i+1)
+		}
+	}
+	return nil
+}
+
+// Build создает цепочку фильтров по описанию. Конфигурация фильтра: base,
+// переопределенная config цепочки, затем config шага
+func (s *ChainSpec) Build(base *FilterConfig, logger *utils.Logger) (*FilterChain, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	chainConfig := s.Config.Apply(base)
+	chain := &FilterChain{
+		filters: make([]TrackFilter, 0, len(s.Filters)),
+		config:  chainConfig,
+		logger:  logger,
+	}
+	for _, step := range s.Filters {
+		chain.AddFilter(filterFactories[step.Filter](step.Config.Apply(chainConfig), step.Params, logger))
+	}
+	return chain, nil
+}
+
+// ChainSet набор именованных цепочек из файла конфигурации. Цепочки level1, level2
+// и level3 заменяют встроенные уровни фильтрации трека
+type ChainSet struct {
+	chains map[string]*ChainSpec
+	order  []string
+}
+
+// NewChainSet проверяет описания и создает набор цепочек
+func NewChainSet(specs []ChainSpec) (*ChainSet, error) {
+	set := &ChainSet{chains: make(map[string]*ChainSpec, len(specs))}
+	for i := range specs {
+		spec := &specs[i]
+		if spec.Name == "" {
+			return nil, fmt.Errorf("chain at position %d has no name", i+1)
+		}
+		if _, exists := set.chains[spec.Name]; exists {
+			return nil, fmt.Errorf("duplicate chain %q", spec.Name)
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		set.chains[spec.Name] = spec
+		set.order = append(set.order, spec.Name)
+	}
+	return set, nil
+}
+
+// LoadChainSet читает цепочки из YAML (.yaml, .yml) или JSON файла вида {"chains": [...]}
+func LoadChainSet(path string) (*ChainSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter chains: %w", err)
+	}
+
+	var file struct {
+		Chains []ChainSpec `json:"chains" yaml:"chains"`
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter chains %s: %w", path, err)
+	}
+
+	return NewChainSet(file.Chains)
+}
+
+// Get возвращает описание цепочки по имени
+func (cs *ChainSet) Get(name string) (*ChainSpec, bool) {
+	if cs == nil {
+		return nil, false
+	}
+	spec, ok := cs.chains[name]
+	return spec, ok
+}
+
+// Specs возвращает описания цепочек в порядке файла
+func (cs *ChainSet) Specs() []*ChainSpec {
+	if cs == nil {
+		return nil
+	}
+	specs := make([]*ChainSpec, len(cs.order))
+	for i, name := range cs.order {
+		specs[i] = cs.chains[name]
+	}
+	return specs
+}
+
+// LevelChain возвращает цепочку для уровня фильтрации 1-3: из набора (level1..level3),
+// если она там описана, иначе встроенную (NewLevel1FilterChain и т.д.)
+func (cs *ChainSet) LevelChain(level int, config *FilterConfig, logger *utils.Logger) TrackFilter {
+	if spec, ok := cs.Get(fmt.Sprintf("level%d", level)); ok {
+		if chain, err := spec.Build(config, logger); err == nil {
+			return chain
+		}
+	}
+
+	switch level {
+	case 2:
+		return NewLevel2FilterChain(config, logger)
+	case 3:
+		return NewLevel3FilterChain(config, logger)
+	default:
+		// Для неизвестных уровней используем уровень 1
+		return NewLevel1FilterChain(config, logger)
+	}
+}
@@ -0,0 +1,313 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *utils.Logger {
+	return utils.NewLogger("error", "text")
+}
+
+func filterNames(chain *FilterChain) []string {
+	names := make([]string, len(chain.filters))
+	for i, filter := range chain.filters {
+		names[i] = filter.Name()
+	}
+	return names
+}
+
+func TestConfigOverrides_Apply(t *testing.T) {
+	speedBuffer := 1.2
+	minInterval := 2.5
+	disabled := false
+
+	tests := []struct {
+		name      string
+		overrides *ConfigOverrides
+		check     func(t *testing.T, config *FilterConfig)
+	}{
+		{
+			name:      "nil keeps base",
+			overrides: nil,
+			check: func(t *testing.T, config *FilterConfig) {
+				assert.Equal(t, DefaultFilterConfig(), config)
+			},
+		},
+		{
+			name: "scalar fields",
+			overrides: &ConfigOverrides{
+				SpeedBuffer:        &speedBuffer,
+				MinTimeIntervalSec: &minInterval,
+				EnableSpeedFilter:  &disabled,
+			},
+			check: func(t *testing.T, config *FilterConfig) {
+				assert.Equal(t, 1.2, config.SpeedBuffer)
+				assert.Equal(t, 2500*time.Millisecond, config.MinTimeInterval)
+				assert.False(t, config.EnableSpeedFilter)
+				assert.True(t, config.EnableDuplicateFilter)
+				assert.Equal(t, 50.0, config.OutlierThresholdKm)
+			},
+		},
+		{
+			name: "max speeds merged per aircraft type",
+			overrides: &ConfigOverrides{
+				MaxSpeeds: map[models.PilotType]float64{models.PilotTypeParaglider: 60},
+			},
+			check: func(t *testing.T, config *FilterConfig) {
+				assert.Equal(t, 60.0, config.MaxSpeeds[models.PilotTypeParaglider])
+				assert.Equal(t, 120.0, config.MaxSpeeds[models.PilotTypeHangglider])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := DefaultFilterConfig()
+			config := tt.overrides.Apply(base)
+			tt.check(t, config)
+
+			// Базовая конфигурация не меняется
+			assert.Equal(t, DefaultFilterConfig(), base)
+		})
+	}
+}
+
+func TestChainSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ChainSpec
+		wantErr string
+	}{
+		{
+			name: "valid",
+			spec: ChainSpec{Name: "custom", Filters: []StepSpec{{Filter: "DuplicateFilter"}, {Filter: "SpeedBasedFilter"}}},
+		},
+		{
+			name:    "no filters",
+			spec:    ChainSpec{Name: "empty"},
+			wantErr: `chain "empty" has no filters`,
+		},
+		{
+			name:    "unknown filter",
+			spec:    ChainSpec{Name: "custom", Filters: []StepSpec{{Filter: "DuplicateFilter"}, {Filter: "MagicFilter"}}},
+			wantErr: `chain "custom": unknown filter "MagicFilter" at position 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChainSpec_Build(t *testing.T) {
+	chainBuffer := 1.0
+	stepBuffer := 2.0
+	spec := ChainSpec{
+		Name:   "custom",
+		Config: &ConfigOverrides{SpeedBuffer: &chainBuffer},
+		Filters: []StepSpec{
+			{Filter: "TimeGapSegmentationFilter", Params: FilterParams{GapMinutes: 10}},
+			{Filter: "TeleportationFilter"},
+			{Filter: "SpeedBasedFilter", Config: &ConfigOverrides{SpeedBuffer: &stepBuffer}},
+		},
+	}
+
+	chain, err := spec.Build(DefaultFilterConfig(), testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TimeGapSegmentationFilter", "TeleportationFilter", "SpeedBasedFilter"}, filterNames(chain))
+
+	// Параметры шага и значения по умолчанию
+	assert.Equal(t, 10, chain.filters[0].(*TimeGapSegmentationFilter).gapMinutes)
+	assert.Equal(t, 200.0, chain.filters[1].(*TeleportationFilter).maxDistance)
+
+	// Конфигурация: base, затем config цепочки, затем config шага
+	assert.Equal(t, 1.0, chain.config.SpeedBuffer)
+	assert.Equal(t, 1.0, chain.filters[1].(*TeleportationFilter).config.SpeedBuffer)
+	assert.Equal(t, 2.0, chain.filters[2].(*SpeedBasedFilter).config.SpeedBuffer)
+
+	_, err = (&ChainSpec{Name: "broken", Filters: []StepSpec{{Filter: "MagicFilter"}}}).Build(DefaultFilterConfig(), testLogger())
+	assert.Error(t, err)
+}
+
+func TestNewChainSet(t *testing.T) {
+	valid := []StepSpec{{Filter: "DuplicateFilter"}}
+
+	tests := []struct {
+		name    string
+		specs   []ChainSpec
+		wantErr string
+	}{
+		{
+			name:  "preserves order",
+			specs: []ChainSpec{{Name: "level2", Filters: valid}, {Name: "custom", Filters: valid}},
+		},
+		{
+			name:    "missing name",
+			specs:   []ChainSpec{{Name: "level1", Filters: valid}, {Filters: valid}},
+			wantErr: "chain at position 2 has no name",
+		},
+		{
+			name:    "duplicate name",
+			specs:   []ChainSpec{{Name: "custom", Filters: valid}, {Name: "custom", Filters: valid}},
+			wantErr: `duplicate chain "custom"`,
+		},
+		{
+			name:    "invalid chain",
+			specs:   []ChainSpec{{Name: "custom"}},
+			wantErr: `chain "custom" has no filters`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewChainSet(tt.specs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			specs := set.Specs()
+			require.Len(t, specs, len(tt.specs))
+			for i, spec := range specs {
+				assert.Equal(t, tt.specs[i].Name, spec.Name)
+			}
+		})
+	}
+}
+
+func TestLoadChainSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			file: "chains.yaml",
+			content: `chains:
+  - name: level1
+    config:
+      speed_buffer: 1.2
+    filters:
+      - filter: DuplicateFilter
+      - filter: TeleportationFilter
+        params:
+          max_distance_km: 100
+`,
+		},
+		{
+			name:    "json",
+			file:    "chains.json",
+			content: `{"chains": [{"name": "level1", "config": {"speed_buffer": 1.2}, "filters": [{"filter": "DuplicateFilter"}, {"filter": "TeleportationFilter", "params": {"max_distance_km": 100}}]}]}`,
+		},
+		{
+			name:    "malformed",
+			file:    "chains.yml",
+			content: "chains: [",
+			wantErr: true,
+		},
+		{
+			name:    "unknown filter",
+			file:    "chains.json",
+			content: `{"chains": [{"name": "level1", "filters": [{"filter": "MagicFilter"}]}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			set, err := LoadChainSet(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			spec, ok := set.Get("level1")
+			require.True(t, ok)
+			require.NotNil(t, spec.Config)
+			assert.Equal(t, 1.2, *spec.Config.SpeedBuffer)
+			require.Len(t, spec.Filters, 2)
+			assert.Equal(t, "TeleportationFilter", spec.Filters[1].Filter)
+			assert.Equal(t, 100.0, spec.Filters[1].Params.MaxDistanceKm)
+		})
+	}
+
+	_, err := LoadChainSet(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestChainSet_LevelChain(t *testing.T) {
+	set, err := NewChainSet([]ChainSpec{
+		{Name: "level2", Filters: []StepSpec{{Filter: "DuplicateFilter"}}},
+	})
+	require.NoError(t, err)
+
+	builtin := func(level int) []string {
+		var chain TrackFilter
+		switch level {
+		case 1:
+			chain = NewLevel1FilterChain(DefaultFilterConfig(), testLogger())
+		case 2:
+			chain = NewLevel2FilterChain(DefaultFilterConfig(), testLogger())
+		default:
+			chain = NewLevel3FilterChain(DefaultFilterConfig(), testLogger())
+		}
+		return filterNames(chain.(*FilterChain))
+	}
+
+	tests := []struct {
+		name  string
+		set   *ChainSet
+		level int
+		want  []string
+	}{
+		{name: "configured level", set: set, level: 2, want: []string{"DuplicateFilter"}},
+		{name: "builtin level", set: set, level: 3, want: builtin(3)},
+		{name: "nil set", set: nil, level: 2, want: builtin(2)},
+		{name: "unknown level", set: set, level: 7, want: builtin(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := tt.set.LevelChain(tt.level, DefaultFilterConfig(), testLogger())
+			require.IsType(t, &FilterChain{}, chain)
+			assert.Equal(t, tt.want, filterNames(chain.(*FilterChain)))
+		})
+	}
+}
+
+func TestAvailableFilters(t *testing.T) {
+	filters := AvailableFilters(testLogger())
+	require.Len(t, filters, len(filterFactories))
+
+	for i, info := range filters {
+		assert.NotEmpty(t, info.Description, info.Name)
+		if i > 0 {
+			assert.Less(t, filters[i-1].Name, info.Name)
+		}
+		if info.Name == "TeleportationFilter" {
+			assert.Equal(t, []string{"max_distance_km"}, info.Params)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package filter
+
+import "time"
+
+// TimeBucketDownsampler прореживает трек до заданного числа точек: период [from, to]
+// делится на maxPoints-1 равных интервалов, от каждого интервала остается первая точка.
+// Точка ровно в to попадает в отдельный интервал, поэтому конец периода сохраняется.
+// Решение принимается по одной точке, без буферизации, поэтому подходит для потоковой
+// выдачи трека, границы которого известны заранее
+type TimeBucketDownsampler struct {
+	from       time.Time
+	interval   time.Duration
+	lastBucket int64
+}
+
+// NewTimeBucketDownsampler создает прореживатель для периода [from, to]
+func NewTimeBucketDownsampler(from, to time.Time, maxPoints int) *TimeBucketDownsampler {
+	buckets := max(maxPoints-1, 1)
+	interval := to.Sub(from) / time.Duration(buckets)
+	if interval <= 0 {
+		interval = time.Nanosecond
+	}
+
+	return &TimeBucketDownsampler{
+		from:       from,
+		interval:   interval,
+		lastBucket: -1,
+	}
+}
+
+// Keep сообщает, остается ли точка с меткой времени ts. Точки должны подаваться по времени
+func (d *TimeBucketDownsampler) Keep(ts time.Time) bool {
+	bucket := max(int64(ts.Sub(d.from)/d.interval), 0)
+	if bucket <= d.lastBucket {
+		return false
+	}
+	d.lastBucket = bucket
+	return true
+}
+
+// Downsample прореживает результат фильтрации до maxPoints неотфильтрованных точек
+// (см. TimeBucketDownsampler). Отфильтрованные точки сохраняются как есть
+func Downsample(points []TrackPoint, maxPoints int) []TrackPoint {
+	var first, last time.Time
+	kept := 0
+	for _, point := range points {
+		if point.Filtered {
+			continue
+		}
+		if kept == 0 {
+			first = point.Timestamp
+		}
+		last = point.Timestamp
+		kept++
+	}
+	if maxPoints <= 0 || kept <= maxPoints {
+		return points
+	}
+
+	downsampler := NewTimeBucketDownsampler(first, last, maxPoints)
+	result := make([]TrackPoint, 0, maxPoints)
+	for _, point := range points {
+		if point.Filtered || downsampler.Keep(point.Timestamp) {
+			result = append(result, point)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// pointsEvery создает count точек с шагом step начиная со start
+func pointsEvery(start time.Time, step time.Duration, count int) []TrackPoint {
+	points := make([]TrackPoint, count)
+	for i := range points {
+		points[i] = TrackPoint{
+			Position:  models.GeoPoint{Latitude: 46.0 + float64(i)*0.001, Longitude: 13.0},
+			Timestamp: start.Add(time.Duration(i) * step),
+		}
+	}
+	return points
+}
+
+func timestamps(points []TrackPoint) []time.Time {
+	result := make([]time.Time, len(points))
+	for i, point := range points {
+		result[i] = point.Timestamp
+	}
+	return result
+}
+
+func TestTimeBucketDownsampler_Keep(t *testing.T) {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		to        time.Time
+		maxPoints int
+		offsets   []time.Duration
+		want      []bool
+	}{
+		{
+			name:      "first point of each interval",
+			to:        start.Add(40 * time.Second),
+			maxPoints: 5,
+			offsets:   []time.Duration{0, 5 * time.Second, 10 * time.Second, 15 * time.Second, 25 * time.Second, 39 * time.Second, 40 * time.Second},
+			want:      []bool{true, false, true, false, true, true, true},
+		},
+		{
+			name:      "points before period fall into first interval",
+			to:        start.Add(10 * time.Second),
+			maxPoints: 2,
+			offsets:   []time.Duration{-5 * time.Second, 0, 10 * time.Second},
+			want:      []bool{true, false, true},
+		},
+		{
+			name:      "empty period keeps distinct timestamps",
+			to:        start,
+			maxPoints: 3,
+			offsets:   []time.Duration{0, 0, time.Nanosecond},
+			want:      []bool{true, false, true},
+		},
+		{
+			name:      "single point budget",
+			to:        start.Add(time.Minute),
+			maxPoints: 1,
+			offsets:   []time.Duration{0, 30 * time.Second, time.Minute},
+			want:      []bool{true, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downsampler := NewTimeBucketDownsampler(start, tt.to, tt.maxPoints)
+			got := make([]bool, len(tt.offsets))
+			for i, offset := range tt.offsets {
+				got[i] = downsampler.Keep(start.Add(offset))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		points    []TrackPoint
+		maxPoints int
+		want      []time.Time
+	}{
+		{
+			name:      "under limit unchanged",
+			points:    pointsEvery(start, 10*time.Second, 5),
+			maxPoints: 5,
+			want:      timestamps(pointsEvery(start, 10*time.Second, 5)),
+		},
+		{
+			name:      "zero limit unchanged",
+			points:    pointsEvery(start, 10*time.Second, 5),
+			maxPoints: 0,
+			want:      timestamps(pointsEvery(start, 10*time.Second, 5)),
+		},
+		{
+			name:      "keeps first and last point",
+			points:    pointsEvery(start, 10*time.Second, 11),
+			maxPoints: 3,
+			want:      []time.Time{start, start.Add(50 * time.Second), start.Add(100 * time.Second)},
+		},
+		{
+			name:      "even spacing",
+			points:    pointsEvery(start, time.Second, 101),
+			maxPoints: 5,
+			want: []time.Time{
+				start,
+				start.Add(25 * time.Second),
+				start.Add(50 * time.Second),
+				start.Add(75 * time.Second),
+				start.Add(100 * time.Second),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Downsample(tt.points, tt.maxPoints)
+			assert.Equal(t, tt.want, timestamps(got))
+			assert.LessOrEqual(t, len(got), max(tt.maxPoints, len(tt.points)))
+		})
+	}
+}
+
+func TestDownsample_KeepsFilteredPoints(t *testing.T) {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	points := pointsEvery(start, 10*time.Second, 11)
+	points[3].Filtered = true
+	points[3].FilterReason = "speed"
+
+	got := Downsample(points, 3)
+
+	// Отфильтрованная точка не учитывается в лимите и остается на своем месте
+	assert.Equal(t, []time.Time{
+		start,
+		start.Add(30 * time.Second),
+		start.Add(50 * time.Second),
+		start.Add(100 * time.Second),
+	}, timestamps(got))
+	assert.True(t, got[1].Filtered)
+	assert.Equal(t, "speed", got[1].FilterReason)
+}
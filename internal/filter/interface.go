@@ -30,6 +30,7 @@ type FilterResult struct {
 	FilteredCount int          `json:"filtered_count"`
 	Points        []TrackPoint `json:"points"`
 	Statistics    FilterStats  `json:"statistics"`
+	Rejected      []RejectedPoint `json:"rejected,omitempty"` // Отклоненные точки с фильтром и причиной (FilterConfig.CollectRejected)
 }
 
 // FilterStats статистика фильтрации
@@ -44,6 +45,7 @@ type FilterStats struct {
 	SegmentCount      int           `json:"segment_count,omitempty"`   // Количество сегментов
 	SegmentBreaks     int           `json:"segment_breaks,omitempty"`  // Количество разрывов
 	Segments          []SegmentInfo `json:"segments,omitempty"`        // Информация о сегментах
+	RejectedByFilter  map[string]int `json:"rejected_by_filter,omitempty"` // Отклоненные точки по фильтрам (FilterConfig.CollectRejected)
 }
 
 // TrackFilter интерфейс для фильтров треков
@@ -79,6 +81,9 @@ type FilterConfig struct {
 	EnableSpeedFilter     bool `json:"enable_speed_filter"`
 	EnableDuplicateFilter bool `json:"enable_duplicate_filter"`
 	EnableOutlierFilter   bool `json:"enable_outlier_filter"`
+
+	// Собирать отклоненные точки в FilterResult.Rejected (режим диагностики)
+	CollectRejected bool `json:"collect_rejected,omitempty"`
 }
 
 // DefaultFilterConfig возвращает конфигурацию по умолчанию
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/flybeeper/fanet-backend/pkg/utils"
@@ -156,7 +157,7 @@ func (f *LocalOutlierFilter) detectLocalOutliers(points []TrackPoint, segmentID
 		// Проверяем аномальность точки относительно локального контекста
 		if f.isLocalOutlier(points, i, startWindow, endWindow, adaptiveThreshold) {
 			points[i].Filtered = true
-			points[i].FilterReason = "Local outlier within segment"
+			points[i].FilterReason = fmt.Sprintf("Local outlier within segment: MAD score %.1f", madScore(points[i-1].Position.DistanceTo(points[i].Position), median, mad))
 			outlierCount++
 			
 			f.logger.WithField("segment_id", segmentID).
@@ -214,6 +215,14 @@ func (f *LocalOutlierFilter) isLocalOutlier(points []TrackPoint, idx, startWindo
 	return avgDistance > threshold
 }
 
+// madScore возвращает отклонение расстояния от медианы в единицах MAD
+func madScore(distance, median, mad float64) float64 {
+	if mad == 0 {
+		return 0
+	}
+	return (distance - median) / mad
+}
+
 // calculateAdaptiveThreshold вычисляет адаптивный порог для сегмента
 func (f *LocalOutlierFilter) calculateAdaptiveThreshold(median, mad float64, segmentSize int) float64 {
 	// Базовый порог
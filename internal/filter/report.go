@@ -0,0 +1,184 @@
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// maxReportedRemovedPoints ограничивает список удаленных точек в отчете одного фильтра
+const maxReportedRemovedPoints = 1000
+
+// Категории причин отклонения точки
+const (
+	RejectSpeed     = "speed"
+	RejectTeleport  = "teleport"
+	RejectOutlier   = "outlier"
+	RejectDuplicate = "duplicate"
+	RejectPingPong  = "ping-pong"
+	RejectSegment   = "segment"
+	RejectOther     = "other"
+)
+
+// StepReport отчет о работе одного фильтра цепочки
+type StepReport struct {
+	Filter        string          `json:"filter"`
+	Description   string          `json:"description"`
+	InputPoints   int             `json:"input_points"`  // Неотфильтрованные точки на входе
+	OutputPoints  int             `json:"output_points"` // Неотфильтрованные точки на выходе
+	Removed       int             `json:"removed"`       // Точки, удаленные этим фильтром
+	DurationMs    float64         `json:"duration_ms"`
+	Statistics    FilterStats     `json:"statistics"`
+	RemovedPoints []RejectedPoint `json:"removed_points,omitempty"` // Не больше maxReportedRemovedPoints
+	Error         string          `json:"error,omitempty"`          // Фильтр завершился ошибкой и был пропущен
+}
+
+// RejectedPoint точка, отклоненная фильтром
+type RejectedPoint struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Position  models.GeoPoint `json:"position"`
+	Speed     float64         `json:"speed,omitempty"`
+	Filter    string          `json:"filter"` // Name() фильтра; для составных цепочек - вложенного фильтра
+	Reason    string          `json:"reason"`
+	Kind      string          `json:"kind"` // RejectSpeed, RejectTeleport и т.д.
+}
+
+// pointKey идентифицирует точку трека между шагами цепочки. Фильтры копируют точки,
+// а DuplicateFilter сдвигает время оставленной точки, поэтому сравниваются только координаты
+type pointKey struct {
+	latitude  float64
+	longitude float64
+}
+
+func keyOf(position models.GeoPoint) pointKey {
+	return pointKey{
+		latitude:  position.Latitude,
+		longitude: position.Longitude,
+	}
+}
+
+// rejectedPoints сравнивает неотфильтрованные точки до и после фильтра. Одни фильтры
+// помечают точки Filtered с причиной, другие убирают их из результата - во втором
+// случае причиной считается "dropped". Точки из result.Rejected (составные фильтры)
+// сохраняют вложенный фильтр и причину
+func rejectedPoints(filter TrackFilter, input []TrackPoint, result *FilterResult) []RejectedPoint {
+	kept := make(map[pointKey]int, len(result.Points))
+	filtered := make(map[pointKey]TrackPoint)
+	for _, point := range result.Points {
+		if point.Filtered {
+			filtered[keyOf(point.Position)] = point
+			continue
+		}
+		kept[keyOf(point.Position)]++
+	}
+
+	nested := make(map[pointKey][]RejectedPoint, len(result.Rejected))
+	for _, rejected := range result.Rejected {
+		key := keyOf(rejected.Position)
+		nested[key] = append(nested[key], rejected)
+	}
+
+	var rejected []RejectedPoint
+	for _, point := range input {
+		if point.Filtered {
+			continue
+		}
+
+		key := keyOf(point.Position)
+		if kept[key] > 0 {
+			kept[key]--
+			continue
+		}
+
+		if candidates := nested[key]; len(candidates) > 0 {
+			rejected = append(rejected, candidates[0])
+			nested[key] = candidates[1:]
+			continue
+		}
+
+		// Скорость вычисляется фильтрами, у помеченной точки результата она точнее
+		reason, speed := "dropped", point.Speed
+		if marked, ok := filtered[key]; ok {
+			if marked.FilterReason != "" {
+				reason = marked.FilterReason
+			}
+			if marked.Speed > 0 {
+				speed = marked.Speed
+			}
+		}
+		rejected = append(rejected, RejectedPoint{
+			Timestamp: point.Timestamp,
+			Position:  point.Position,
+			Speed:     speed,
+			Filter:    filter.Name(),
+			Reason:    reason,
+			Kind:      rejectKind(filter.Name(), reason),
+		})
+	}
+	return rejected
+}
+
+// rejectKind определяет категорию отклонения по тексту причины и фильтру
+func rejectKind(filterName, reason string) string {
+	reason = strings.ToLower(reason)
+	switch {
+	case strings.Contains(reason, "ping-pong"):
+		return RejectPingPong
+	case strings.Contains(reason, "teleport"), strings.HasPrefix(reason, "distance jump"):
+		return RejectTeleport
+	case strings.Contains(reason, "speed"):
+		return RejectSpeed
+	case strings.Contains(reason, "outlier"), strings.HasPrefix(reason, "isolated point"):
+		return RejectOutlier
+	case strings.Contains(reason, "duplicate"), strings.Contains(reason, "identical"),
+		strings.Contains(reason, "cluster"), strings.HasPrefix(reason, "distance"), strings.HasPrefix(reason, "time diff"):
+		return RejectDuplicate
+	case strings.Contains(reason, "segment"):
+		return RejectSegment
+	case filterName == "DuplicateFilter":
+		return RejectDuplicate
+	default:
+		return RejectOther
+	}
+}
+
+// rejectedByFilter считает отклоненные точки по фильтрам
+func rejectedByFilter(rejected []RejectedPoint) map[string]int {
+	if len(rejected) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, point := range rejected {
+		counts[point.Filter]++
+	}
+	return counts
+}
+
+// newStepReport собирает отчет фильтра по отклоненным им точкам
+func newStepReport(filter TrackFilter, input []TrackPoint, result *FilterResult, rejected []RejectedPoint, duration time.Duration) StepReport {
+	report := StepReport{
+		Filter:      filter.Name(),
+		Description: filter.Description(),
+		Removed:     len(rejected),
+		DurationMs:  float64(duration.Microseconds()) / 1000,
+		Statistics:  result.Statistics,
+	}
+
+	for _, point := range input {
+		if !point.Filtered {
+			report.InputPoints++
+		}
+	}
+	for _, point := range result.Points {
+		if !point.Filtered {
+			report.OutputPoints++
+		}
+	}
+
+	if len(rejected) > maxReportedRemovedPoints {
+		rejected = rejected[:maxReportedRemovedPoints]
+	}
+	report.RemovedPoints = rejected
+	return report
+}
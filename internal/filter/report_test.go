@@ -0,0 +1,221 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportTrack трек параплана на север каждые 10 секунд с дублем (точка 5)
+// и телепортацией на ~500 км в конце трека (точка 19)
+func reportTrack() *TrackData {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	points := pointsEvery(start, 10*time.Second, 20)
+	points[5].Position = points[4].Position
+	points[5].Timestamp = points[4].Timestamp.Add(time.Second)
+	points[19].Position = models.GeoPoint{Latitude: 50.5, Longitude: 13.0}
+
+	return &TrackData{
+		DeviceID:     "ABC123",
+		AircraftType: models.PilotTypeParaglider,
+		Points:       points,
+	}
+}
+
+func reportChain(t *testing.T) *FilterChain {
+	spec := ChainSpec{
+		Name:    "report",
+		Filters: []StepSpec{{Filter: "DuplicateFilter"}, {Filter: "TeleportationFilter"}},
+	}
+	chain, err := spec.Build(DefaultFilterConfig(), testLogger())
+	require.NoError(t, err)
+	return chain
+}
+
+func TestFilterChain_FilterWithReport(t *testing.T) {
+	chain := reportChain(t)
+	track := reportTrack()
+
+	result, reports, err := chain.FilterWithReport(track)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+
+	// Отчет не меняет результат фильтрации
+	plain, err := reportChain(t).Filter(reportTrack())
+	require.NoError(t, err)
+	assert.Equal(t, plain.FilteredCount, result.FilteredCount)
+	assert.Equal(t, len(plain.Points), len(result.Points))
+
+	assert.Equal(t, "DuplicateFilter", reports[0].Filter)
+	assert.Equal(t, "TeleportationFilter", reports[1].Filter)
+	assert.Equal(t, len(track.Points), reports[0].InputPoints)
+
+	for i, report := range reports {
+		assert.NotEmpty(t, report.Description, report.Filter)
+		assert.Empty(t, report.Error, report.Filter)
+		assert.GreaterOrEqual(t, report.DurationMs, 0.0)
+		assert.Equal(t, report.InputPoints-report.OutputPoints, report.Removed, report.Filter)
+		assert.Len(t, report.RemovedPoints, report.Removed, report.Filter)
+		if i > 0 {
+			assert.Equal(t, reports[i-1].OutputPoints, report.InputPoints, "step %d input", i)
+		}
+	}
+
+	// Каждый фильтр отчитывается о своей точке
+	require.Equal(t, 1, reports[0].Removed)
+	assert.Equal(t, track.Points[5].Position, reports[0].RemovedPoints[0].Position)
+	require.Equal(t, 1, reports[1].Removed)
+	assert.Equal(t, track.Points[19].Position, reports[1].RemovedPoints[0].Position)
+}
+
+func TestFilterChain_FilterWithReportEmptyTrack(t *testing.T) {
+	result, reports, err := reportChain(t).FilterWithReport(&TrackData{DeviceID: "ABC123"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Points)
+	assert.Empty(t, reports)
+}
+
+func TestNewStepReport_LimitsRemovedPoints(t *testing.T) {
+	filter := NewDuplicateFilter(DefaultFilterConfig(), testLogger())
+	input := pointsEvery(time.Now(), time.Second, maxReportedRemovedPoints+10)
+	rejected := make([]RejectedPoint, len(input))
+
+	report := newStepReport(filter, input, &FilterResult{}, rejected, time.Millisecond)
+	assert.Equal(t, len(input), report.InputPoints)
+	assert.Equal(t, 0, report.OutputPoints)
+	assert.Equal(t, len(rejected), report.Removed)
+	assert.Len(t, report.RemovedPoints, maxReportedRemovedPoints)
+	assert.Equal(t, 1.0, report.DurationMs)
+}
+
+// stubFilter возвращает заранее заданный результат
+type stubFilter struct {
+	result *FilterResult
+}
+
+func (f *stubFilter) Filter(track *TrackData) (*FilterResult, error) { return f.result, nil }
+func (f *stubFilter) Name() string                                   { return "StubFilter" }
+func (f *stubFilter) Description() string                            { return "Returns a fixed result" }
+
+func TestRejectKind(t *testing.T) {
+	tests := []struct {
+		filter string
+		reason string
+		want   string
+	}{
+		{filter: "SpeedBasedFilter", reason: "Speed 180.5 km/h exceeds limit", want: RejectSpeed},
+		{filter: "TeleportationFilter", reason: "Teleportation: 512.3 km jump", want: RejectTeleport},
+		{filter: "OutlierFilter", reason: "distance jump 60 km", want: RejectTeleport},
+		{filter: "LocalOutlierFilter", reason: "Local outlier within segment: MAD score 7.5", want: RejectOutlier},
+		{filter: "PreCleanupFilter", reason: "Isolated point", want: RejectOutlier},
+		{filter: "PreCleanupFilter", reason: "Ping-pong between clusters", want: RejectPingPong},
+		{filter: "DuplicateFilter", reason: "Identical position", want: RejectDuplicate},
+		{filter: "DuplicateFilter", reason: "time diff 1s", want: RejectDuplicate},
+		{filter: "DuplicateFilter", reason: "dropped", want: RejectDuplicate},
+		{filter: "SegmentedFilterChain", reason: "Short segment", want: RejectSegment},
+		{filter: "StubFilter", reason: "dropped", want: RejectOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rejectKind(tt.filter, tt.reason), "%s: %s", tt.filter, tt.reason)
+	}
+}
+
+func TestRejectedPoints(t *testing.T) {
+	input := pointsEvery(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC), 10*time.Second, 5)
+	input[0].Filtered = true // Отклонена раньше, не учитывается
+
+	marked := input[2]
+	marked.Filtered = true
+	marked.FilterReason = "Speed 200 km/h exceeds limit"
+	marked.Speed = 200
+
+	nested := RejectedPoint{Position: input[3].Position, Filter: "LocalOutlierFilter", Reason: "Local outlier", Kind: RejectOutlier}
+
+	// Точка 1 остается, 2 помечена, 3 отклонена вложенным фильтром, 4 удалена без пометки
+	result := &FilterResult{
+		Points:   []TrackPoint{input[0], input[1], marked},
+		Rejected: []RejectedPoint{nested},
+	}
+	rejected := rejectedPoints(&stubFilter{result: result}, input, result)
+	require.Len(t, rejected, 3)
+
+	assert.Equal(t, RejectedPoint{
+		Timestamp: input[2].Timestamp,
+		Position:  input[2].Position,
+		Speed:     200,
+		Filter:    "StubFilter",
+		Reason:    "Speed 200 km/h exceeds limit",
+		Kind:      RejectSpeed,
+	}, rejected[0])
+	assert.Equal(t, nested, rejected[1])
+	assert.Equal(t, "dropped", rejected[2].Reason)
+	assert.Equal(t, RejectOther, rejected[2].Kind)
+	assert.Equal(t, input[4].Position, rejected[2].Position)
+}
+
+func TestFilterChain_CollectRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		collect bool
+	}{
+		{name: "disabled", collect: false},
+		{name: "enabled", collect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultFilterConfig()
+			config.CollectRejected = tt.collect
+			spec := ChainSpec{
+				Name:    "collect",
+				Filters: []StepSpec{{Filter: "DuplicateFilter"}, {Filter: "TeleportationFilter"}},
+			}
+			chain, err := spec.Build(config, testLogger())
+			require.NoError(t, err)
+
+			track := reportTrack()
+			result, err := chain.Filter(track)
+			require.NoError(t, err)
+
+			if !tt.collect {
+				assert.Nil(t, result.Rejected)
+				assert.Nil(t, result.Statistics.RejectedByFilter)
+				return
+			}
+
+			require.Len(t, result.Rejected, 2)
+			assert.Equal(t, "DuplicateFilter", result.Rejected[0].Filter)
+			assert.Equal(t, RejectDuplicate, result.Rejected[0].Kind)
+			assert.Equal(t, track.Points[5].Position, result.Rejected[0].Position)
+			assert.Equal(t, "TeleportationFilter", result.Rejected[1].Filter)
+			assert.Equal(t, RejectTeleport, result.Rejected[1].Kind)
+			assert.Equal(t, track.Points[19].Position, result.Rejected[1].Position)
+			assert.Equal(t, map[string]int{"DuplicateFilter": 1, "TeleportationFilter": 1}, result.Statistics.RejectedByFilter)
+		})
+	}
+}
+
+func TestLevel3FilterChain_CollectRejectedNames(t *testing.T) {
+	config := DefaultFilterConfig()
+	config.CollectRejected = true
+
+	result, err := NewLevel3FilterChain(config, testLogger()).Filter(reportTrack())
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Rejected)
+
+	// Составные цепочки сообщают вложенный фильтр, а не себя
+	total := 0
+	for _, rejected := range result.Rejected {
+		assert.NotEmpty(t, rejected.Reason)
+		assert.NotEmpty(t, rejected.Kind)
+		assert.NotEqual(t, "TwoStageFilterChain", rejected.Filter)
+	}
+	for _, count := range result.Statistics.RejectedByFilter {
+		total += count
+	}
+	assert.Equal(t, len(result.Rejected), total)
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"sort"
 	"time"
 	"github.com/flybeeper/fanet-backend/pkg/utils"
 )
@@ -58,6 +59,7 @@ func (s *SegmentedFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 	totalFilteredCount := 0
 	combinedStats := FilterStats{}
 	var allSegments []SegmentInfo
+	var rejected []RejectedPoint
 
 	// Применяем Level 1 фильтры к каждому сегменту
 	for segmentID, indices := range segmentMap {
@@ -71,6 +73,16 @@ func (s *SegmentedFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 			for _, idx := range indices {
 				resultPoints[idx].Filtered = true
 				resultPoints[idx].FilterReason = "Isolated segment point"
+				if s.config.CollectRejected && !track.Points[idx].Filtered {
+					rejected = append(rejected, RejectedPoint{
+						Timestamp: track.Points[idx].Timestamp,
+						Position:  track.Points[idx].Position,
+						Speed:     track.Points[idx].Speed,
+						Filter:    s.Name(),
+						Reason:    resultPoints[idx].FilterReason,
+						Kind:      RejectSegment,
+					})
+				}
 			}
 			totalFilteredCount += len(indices)
 			continue
@@ -113,6 +125,9 @@ func (s *SegmentedFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 			}
 		}
 
+		// Отклоненные точки сегмента с фильтрами уровня 1, которые их удалили
+		rejected = append(rejected, segmentResult.Rejected...)
+
 		// Аккумулируем статистику
 		totalFilteredCount += segmentResult.FilteredCount
 		combinedStats.SpeedViolations += segmentResult.Statistics.SpeedViolations
@@ -206,6 +221,10 @@ func (s *SegmentedFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 	combinedStats.Segments = allSegments
 	combinedStats.SegmentCount = len(segmentMap)
 	combinedStats.SegmentBreaks = len(segmentMap) - 1
+	combinedStats.RejectedByFilter = rejectedByFilter(rejected)
+
+	// Сегменты обходятся в произвольном порядке
+	sort.SliceStable(rejected, func(i, j int) bool { return rejected[i].Timestamp.Before(rejected[j].Timestamp) })
 
 	// Пересчитываем общее количество отфильтрованных точек
 	// так как теперь все точки вне сегментов тоже считаются отфильтрованными
@@ -221,6 +240,7 @@ func (s *SegmentedFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 		FilteredCount: actualFilteredCount,
 		Points:        resultPoints,
 		Statistics:    combinedStats,
+		Rejected:      rejected,
 	}
 
 	s.logger.WithField("device_id", track.DeviceID).
@@ -0,0 +1,148 @@
+package filter
+
+import "math"
+
+const (
+	// MinLODZoom и MaxLODZoom диапазон масштабов карты, для которых строятся версии трека.
+	// Мельче MinLODZoom трек не упрощается сильнее, крупнее MaxLODZoom - слабее
+	MinLODZoom = 5
+	MaxLODZoom = 16
+
+	// lodPixelTolerance допустимое отклонение упрощенного трека в пикселях карты
+	lodPixelTolerance = 1.0
+
+	// metersPerPixelZoom0 разрешение Web Mercator на экваторе для масштаба 0 (тайл 256 px)
+	metersPerPixelZoom0 = 156543.03392
+
+	earthRadiusMeters = 6371000.0
+)
+
+// ClampLODZoom приводит масштаб карты к диапазону MinLODZoom..MaxLODZoom
+func ClampLODZoom(zoom int) int {
+	return max(MinLODZoom, min(MaxLODZoom, zoom))
+}
+
+// ToleranceForZoom возвращает допуск упрощения в метрах для масштаба карты:
+// размер lodPixelTolerance пикселей Web Mercator на широте latitude
+func ToleranceForZoom(zoom int, latitude float64) float64 {
+	return lodPixelTolerance * metersPerPixelZoom0 * math.Cos(latitude*math.Pi/180) / math.Exp2(float64(zoom))
+}
+
+// LODTolerance возвращает допуск упрощения трека для масштаба карты
+// (широта берется по первой неотфильтрованной точке)
+func LODTolerance(points []TrackPoint, zoom int) float64 {
+	latitude := 0.0
+	for _, point := range points {
+		if !point.Filtered {
+			latitude = point.Position.Latitude
+			break
+		}
+	}
+	return ToleranceForZoom(ClampLODZoom(zoom), latitude)
+}
+
+// Significance вычисляет значимость точек для упрощения Douglas-Peucker: точка
+// остается при допуске tolerance, если ее значимость больше tolerance. Так один проход
+// алгоритма дает результат для любого допуска, а версии для разных масштабов вложены
+// друг в друга. Первая и последняя точки каждого сегмента (SegmentID) всегда остаются,
+// чтобы не терялись границы и раскраска сегментов. Отфильтрованные точки не участвуют
+// в упрощении и получают значимость +Inf
+func Significance(points []TrackPoint) []float64 {
+	significance := make([]float64, len(points))
+
+	// Индексы неотфильтрованных точек
+	active := make([]int, 0, len(points))
+	for i, point := range points {
+		significance[i] = math.Inf(1)
+		if !point.Filtered {
+			active = append(active, i)
+		}
+	}
+
+	// Douglas-Peucker отдельно внутри каждого сегмента
+	for start := 0; start < len(active); {
+		end := start
+		for end+1 < len(active) && points[active[end+1]].SegmentID == points[active[start]].SegmentID {
+			end++
+		}
+		douglasPeucker(points, active[start:end+1], significance)
+		start = end + 1
+	}
+
+	return significance
+}
+
+// douglasPeucker заполняет значимость внутренних точек сегмента. Значимость точки -
+// ее отклонение от хорды интервала, но не больше значимости точки, разделившей
+// родительский интервал (иначе результат не совпадал бы с обычным Douglas-Peucker)
+func douglasPeucker(points []TrackPoint, indices []int, significance []float64) {
+	type interval struct {
+		first, last int // Позиции в indices
+		limit       float64
+	}
+
+	stack := []interval{{first: 0, last: len(indices) - 1, limit: math.Inf(1)}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current.last-current.first < 2 {
+			continue
+		}
+
+		a := points[indices[current.first]]
+		b := points[indices[current.last]]
+		split, maxDistance := -1, -1.0
+		for i := current.first + 1; i < current.last; i++ {
+			distance := segmentDistanceMeters(points[indices[i]], a, b)
+			if distance > maxDistance {
+				split, maxDistance = i, distance
+			}
+		}
+
+		value := min(maxDistance, current.limit)
+		significance[indices[split]] = value
+		stack = append(stack,
+			interval{first: current.first, last: split, limit: value},
+			interval{first: split, last: current.last, limit: value})
+	}
+}
+
+// SimplifyBySignificance оставляет точки со значимостью больше tolerance (метры)
+func SimplifyBySignificance(points []TrackPoint, significance []float64, tolerance float64) []TrackPoint {
+	result := make([]TrackPoint, 0, len(points))
+	for i, point := range points {
+		if significance[i] > tolerance {
+			result = append(result, point)
+		}
+	}
+	return result
+}
+
+// Simplify упрощает трек алгоритмом Douglas-Peucker с допуском tolerance (метры),
+// сохраняя границы сегментов и отфильтрованные точки
+func Simplify(points []TrackPoint, tolerance float64) []TrackPoint {
+	return SimplifyBySignificance(points, Significance(points), tolerance)
+}
+
+// segmentDistanceMeters расстояние от точки p до отрезка ab в метрах
+// (локальная равнопромежуточная проекция, достаточная для соседних точек трека)
+func segmentDistanceMeters(p, a, b TrackPoint) float64 {
+	cosLat := math.Cos(a.Position.Latitude * math.Pi / 180)
+	project := func(point TrackPoint) (float64, float64) {
+		x := (point.Position.Longitude - a.Position.Longitude) * math.Pi / 180 * cosLat * earthRadiusMeters
+		y := (point.Position.Latitude - a.Position.Latitude) * math.Pi / 180 * earthRadiusMeters
+		return x, y
+	}
+
+	px, py := project(p)
+	bx, by := project(b)
+
+	lengthSquared := bx*bx + by*by
+	if lengthSquared == 0 {
+		return math.Hypot(px, py)
+	}
+
+	// Проекция на отрезок с ограничением концами
+	t := max(0, min(1, (px*bx+py*by)/lengthSquared))
+	return math.Hypot(px-t*bx, py-t*by)
+}
@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineTrack создает трек на восток по широте 46° с шагом ~77 м; offsets задает
+// смещение точек на север в градусах (0.001° ≈ 111 м)
+func lineTrack(offsets ...float64) []TrackPoint {
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	points := make([]TrackPoint, len(offsets))
+	for i, offset := range offsets {
+		points[i] = TrackPoint{
+			Position:  models.GeoPoint{Latitude: 46.0 + offset, Longitude: 13.0 + float64(i)*0.001},
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+		}
+	}
+	return points
+}
+
+func longitudes(points []TrackPoint) []float64 {
+	result := make([]float64, len(points))
+	for i, point := range points {
+		result[i] = math.Round((point.Position.Longitude-13.0)*1000) / 1000
+	}
+	return result
+}
+
+func TestClampLODZoom(t *testing.T) {
+	tests := []struct {
+		zoom int
+		want int
+	}{
+		{zoom: 0, want: MinLODZoom},
+		{zoom: MinLODZoom, want: MinLODZoom},
+		{zoom: 12, want: 12},
+		{zoom: MaxLODZoom, want: MaxLODZoom},
+		{zoom: 22, want: MaxLODZoom},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClampLODZoom(tt.zoom), "zoom %d", tt.zoom)
+	}
+}
+
+func TestToleranceForZoom(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoom     int
+		latitude float64
+		want     float64
+	}{
+		{name: "zoom 0 at equator", zoom: 0, latitude: 0, want: 156543.03392},
+		{name: "each zoom halves tolerance", zoom: 1, latitude: 0, want: 78271.51696},
+		{name: "latitude 60", zoom: 16, latitude: 60, want: 156543.03392 * 0.5 / 65536},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, ToleranceForZoom(tt.zoom, tt.latitude), 1e-6)
+		})
+	}
+}
+
+func TestLODTolerance_UsesFirstUnfilteredPoint(t *testing.T) {
+	points := lineTrack(0, 0)
+	points[0].Position.Latitude = 80
+	points[0].Filtered = true
+
+	assert.InDelta(t, ToleranceForZoom(MaxLODZoom, points[1].Position.Latitude), LODTolerance(points, 20), 1e-9)
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []TrackPoint
+		tolerance float64
+		want      []float64 // Оставшиеся точки по смещению долготы
+	}{
+		{
+			name:      "straight line keeps endpoints",
+			points:    lineTrack(0, 0, 0, 0, 0),
+			tolerance: 1,
+			want:      []float64{0, 0.004},
+		},
+		{
+			name:      "peak above tolerance kept",
+			points:    lineTrack(0, 0, 0.001, 0, 0),
+			tolerance: 50,
+			want:      []float64{0, 0.002, 0.004},
+		},
+		{
+			name:      "peak below tolerance removed",
+			points:    lineTrack(0, 0, 0.001, 0, 0),
+			tolerance: 200,
+			want:      []float64{0, 0.004},
+		},
+		{
+			name:      "zero tolerance keeps deviating points",
+			points:    lineTrack(0, 0.0001, 0, 0.0001, 0),
+			tolerance: 0,
+			want:      []float64{0, 0.001, 0.002, 0.003, 0.004},
+		},
+		{
+			name:      "two points unchanged",
+			points:    lineTrack(0, 0),
+			tolerance: 1000,
+			want:      []float64{0, 0.001},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, longitudes(Simplify(tt.points, tt.tolerance)))
+		})
+	}
+}
+
+func TestSimplify_KeepsSegmentBoundaries(t *testing.T) {
+	points := lineTrack(0, 0, 0, 0, 0, 0)
+	for i := 3; i < len(points); i++ {
+		points[i].SegmentID = 1
+	}
+
+	// Концы обоих сегментов остаются даже на прямой
+	assert.Equal(t, []float64{0, 0.002, 0.003, 0.005}, longitudes(Simplify(points, 1000)))
+}
+
+func TestSimplify_KeepsFilteredPoints(t *testing.T) {
+	points := lineTrack(0, 0, 0.05, 0, 0)
+	points[2].Filtered = true
+
+	// Отфильтрованный выброс не влияет на упрощение и сохраняется
+	got := Simplify(points, 1)
+	assert.Equal(t, []float64{0, 0.002, 0.004}, longitudes(got))
+	assert.True(t, got[1].Filtered)
+}
+
+func TestSignificance_NestedVersions(t *testing.T) {
+	points := lineTrack(0, 0.0003, 0.002, 0.0005, 0, -0.001, 0.0002, 0)
+	significance := Significance(points)
+	require.Len(t, significance, len(points))
+
+	// Концы трека остаются при любом допуске
+	assert.True(t, math.IsInf(significance[0], 1))
+	assert.True(t, math.IsInf(significance[len(points)-1], 1))
+
+	// Версия для большего допуска вложена в версию для меньшего
+	previous := SimplifyBySignificance(points, significance, 0)
+	for _, tolerance := range []float64{10, 30, 60, 100, 200, 500} {
+		current := SimplifyBySignificance(points, significance, tolerance)
+		assert.Subset(t, longitudes(previous), longitudes(current), "tolerance %.0f", tolerance)
+		assert.LessOrEqual(t, len(current), len(previous))
+		previous = current
+	}
+	assert.Len(t, previous, 2)
+}
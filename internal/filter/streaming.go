@@ -0,0 +1,306 @@
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+)
+
+// StreamDecision решение потокового фильтра по живой точке
+type StreamDecision int
+
+const (
+	StreamAccept StreamDecision = iota // Точка принята и может быть показана сразу
+	StreamDelay                        // Подозрительный скачок: решение после следующей точки
+	StreamReject                       // Точка отклонена
+)
+
+// String возвращает название решения для логов и метрик
+func (d StreamDecision) String() string {
+	switch d {
+	case StreamAccept:
+		return "accept"
+	case StreamDelay:
+		return "delay"
+	case StreamReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// StreamResult результат обработки одной точки потоковым фильтром
+type StreamResult struct {
+	Decision StreamDecision  // Решение по добавленной точке
+	Released []TrackPoint    // Принятые точки в порядке времени: отложенная ранее и/или добавленная
+	Rejected []RejectedPoint // Отклоненные точки: отложенная ранее и/или добавленная
+}
+
+// StreamFilter потоковый вариант фильтров скорости (SpeedBasedFilter), телепортаций
+// (SmartTeleportationFilter с PingPongDetector) и локальных выбросов (LocalOutlierFilter)
+// для одного устройства. Точки проверяются относительно последней принятой точки,
+// память ограничена окном из slidingWindowSize шагов.
+//
+// Скачок больше адаптивного порога LocalOutlierFilter откладывается: если следующая
+// точка возвращается к треку, скачок отклоняется как выброс, иначе принимается.
+// Не потокобезопасен
+type StreamFilter struct {
+	config       *FilterConfig
+	aircraftType models.PilotType
+	outlier      *LocalOutlierFilter // Медиана, MAD, адаптивный порог и проверка обхода
+
+	maxJumpKm             float64       // Прыжок, отклоняемый независимо от скорости (SpeedBasedFilter)
+	pingPongThreshold     int           // Количество повторов позиции для детекции пинг-понга
+	slidingWindowSize     int           // Размер окна скоростей и расстояний
+	speedMultiplierThresh float64       // Превышение медианы скорости, считающееся аномалией
+	minOutlierSteps       int           // Минимум шагов в окне для поиска выбросов
+	segmentGap            time.Duration // Разрыв, после которого трек начинается заново
+	maxRejectStreak       int           // Отклонений подряд, после которых опорная точка считается ошибочной
+
+	last         *TrackPoint // Последняя принятая точка
+	pending      *TrackPoint // Отложенная точка
+	speeds       []float64   // Скорости последних принятых шагов (км/ч)
+	distances    []float64   // Длины последних принятых шагов (км)
+	pingPong     *PingPongDetector
+	rejectStreak int
+}
+
+// NewStreamFilter создает потоковый фильтр для устройства. config может быть nil
+func NewStreamFilter(config *FilterConfig, aircraftType models.PilotType) *StreamFilter {
+	if config == nil {
+		config = DefaultFilterConfig()
+	}
+
+	f := &StreamFilter{
+		config:                config,
+		aircraftType:          aircraftType,
+		outlier:               NewLocalOutlierFilter(config, nil),
+		maxJumpKm:             100,
+		pingPongThreshold:     3,
+		slidingWindowSize:     15,
+		speedMultiplierThresh: 10.0,
+		minOutlierSteps:       3,
+		segmentGap:            30 * time.Minute, // Как TimeGapSegmentationFilter во встроенных уровнях
+		maxRejectStreak:       5,
+	}
+	f.reset()
+	return f
+}
+
+// SetAircraftType меняет тип ЛА, определяющий лимит скорости
+func (f *StreamFilter) SetAircraftType(aircraftType models.PilotType) {
+	f.aircraftType = aircraftType
+}
+
+// HasPending сообщает, есть ли отложенная точка
+func (f *StreamFilter) HasPending() bool {
+	return f.pending != nil
+}
+
+// Push обрабатывает очередную точку устройства
+func (f *StreamFilter) Push(point TrackPoint) StreamResult {
+	var result StreamResult
+
+	if f.last == nil || point.Timestamp.Sub(f.last.Timestamp) > f.segmentGap {
+		// Первая точка или новый сегмент: отложенная точка последняя в своем сегменте
+		if f.pending != nil {
+			result.Released = append(result.Released, *f.pending)
+		}
+		f.reset()
+		f.accept(point, 0, 0)
+		result.Decision = StreamAccept
+		result.Released = append(result.Released, point)
+		return result
+	}
+
+	if f.pending != nil {
+		pending := *f.pending
+		f.pending = nil
+		if score, ok := f.isOutlier(pending, point); ok {
+			result.Rejected = append(result.Rejected, f.reject(pending, "LocalOutlierFilter",
+				fmt.Sprintf("Local outlier within segment: MAD score %.1f", score)))
+		} else {
+			f.accept(pending, pending.Distance, pending.Speed)
+			result.Released = append(result.Released, pending)
+		}
+	}
+
+	timeDiff := point.Timestamp.Sub(f.last.Timestamp)
+	if timeDiff <= 0 {
+		result.Decision = StreamReject
+		result.Rejected = append(result.Rejected, f.reject(point, "StreamFilter",
+			fmt.Sprintf("Time diff %v: point is not after the last accepted point", timeDiff)))
+		return result
+	}
+
+	distance := f.last.Position.DistanceTo(point.Position)
+	speed := speedBetween(*f.last, point, distance)
+	point.Distance = distance
+	point.Speed = speed
+
+	filterName, reason := f.check(point, distance, speed)
+	if reason != "" {
+		if rejectKind(filterName, reason) != RejectPingPong {
+			f.rejectStreak++
+		}
+		if f.rejectStreak < f.maxRejectStreak {
+			result.Decision = StreamReject
+			result.Rejected = append(result.Rejected, f.reject(point, filterName, reason))
+			return result
+		}
+
+		// Устройство стабильно находится в другом месте: ошибочна опорная точка
+		f.reset()
+		f.accept(point, 0, 0)
+		result.Decision = StreamAccept
+		result.Released = append(result.Released, point)
+		return result
+	}
+	f.rejectStreak = 0
+
+	if _, threshold, ok := f.outlierStats(); ok && distance > threshold {
+		f.pending = &point
+		result.Decision = StreamDelay
+		return result
+	}
+
+	f.accept(point, distance, speed)
+	result.Decision = StreamAccept
+	result.Released = append(result.Released, point)
+	return result
+}
+
+// Flush принимает отложенную точку, если следующая точка не пришла вовремя.
+// Последняя точка трека не считается выбросом и в LocalOutlierFilter
+func (f *StreamFilter) Flush() (TrackPoint, bool) {
+	if f.pending == nil {
+		return TrackPoint{}, false
+	}
+	pending := *f.pending
+	f.pending = nil
+	f.accept(pending, pending.Distance, pending.Speed)
+	return pending, true
+}
+
+// check проверяет точку фильтрами скорости и телепортаций, возвращает фильтр и причину
+func (f *StreamFilter) check(point TrackPoint, distance, speed float64) (string, string) {
+	if f.pingPong.AddPoint(point.Position) {
+		return "SmartTeleportationFilter", "Ping-pong pattern"
+	}
+
+	if maxSpeed := f.config.GetMaxSpeed(f.aircraftType); speed > maxSpeed {
+		return "SpeedBasedFilter", fmt.Sprintf("Speed %.1f km/h exceeds max %.1f km/h", speed, maxSpeed)
+	}
+
+	if distance > f.maxJumpKm {
+		return "SpeedBasedFilter", fmt.Sprintf("Distance jump %.1f km is too large", distance)
+	}
+
+	if median := f.medianSpeed(); median > 0 && speed > median*f.speedMultiplierThresh {
+		return "SmartTeleportationFilter", fmt.Sprintf("Speed anomaly: %.1f km/h (%.1fx median)", speed, speed/median)
+	}
+
+	return "", ""
+}
+
+// medianSpeed возвращает медиану скорости по полному окну или 0
+func (f *StreamFilter) medianSpeed() float64 {
+	if len(f.speeds) < f.slidingWindowSize {
+		return 0 // Недостаточно данных для медианы, как в SmartTeleportationFilter
+	}
+
+	speeds := make([]float64, 0, len(f.speeds))
+	for _, speed := range f.speeds {
+		if speed > 0 {
+			speeds = append(speeds, speed)
+		}
+	}
+	if len(speeds) < 3 {
+		return 0
+	}
+
+	sort.Float64s(speeds)
+	mid := len(speeds) / 2
+	if len(speeds)%2 == 0 {
+		return (speeds[mid-1] + speeds[mid]) / 2
+	}
+	return speeds[mid]
+}
+
+// outlierStats возвращает медиану и адаптивный порог длины шага по окну
+func (f *StreamFilter) outlierStats() (median, threshold float64, ok bool) {
+	if len(f.distances) < f.minOutlierSteps {
+		return 0, 0, false
+	}
+	median = f.outlier.calculateMedian(f.distances)
+	mad := f.outlier.calculateMAD(f.distances, median)
+	return median, f.outlier.calculateAdaptiveThreshold(median, mad, len(f.distances)+1), true
+}
+
+// isOutlier проверяет отложенную точку по соседям, как LocalOutlierFilter
+// с окном из трех точек. Возвращает MAD оценку скачка
+func (f *StreamFilter) isOutlier(pending, next TrackPoint) (float64, bool) {
+	median, threshold, ok := f.outlierStats()
+	if !ok {
+		return 0, false
+	}
+
+	points := []TrackPoint{*f.last, pending, next}
+	if !f.outlier.isLocalOutlier(points, 1, 0, 2, threshold) {
+		return 0, false
+	}
+
+	mad := f.outlier.calculateMAD(f.distances, median)
+	return madScore(f.last.Position.DistanceTo(pending.Position), median, mad), true
+}
+
+// accept делает точку опорной и добавляет шаг в окно
+func (f *StreamFilter) accept(point TrackPoint, distance, speed float64) {
+	if f.last != nil {
+		f.distances = appendWindow(f.distances, distance, f.slidingWindowSize)
+		f.speeds = appendWindow(f.speeds, speed, f.slidingWindowSize)
+	}
+	f.last = &point
+}
+
+// reject формирует отклоненную точку
+func (f *StreamFilter) reject(point TrackPoint, filterName, reason string) RejectedPoint {
+	return RejectedPoint{
+		Timestamp: point.Timestamp,
+		Position:  point.Position,
+		Speed:     point.Speed,
+		Filter:    filterName,
+		Reason:    reason,
+		Kind:      rejectKind(filterName, reason),
+	}
+}
+
+// reset начинает трек заново
+func (f *StreamFilter) reset() {
+	f.last = nil
+	f.pending = nil
+	f.speeds = f.speeds[:0]
+	f.distances = f.distances[:0]
+	f.pingPong = NewPingPongDetector(f.pingPongThreshold)
+	f.rejectStreak = 0
+}
+
+// speedBetween вычисляет скорость между точками в км/ч
+func speedBetween(from, to TrackPoint, distance float64) float64 {
+	hours := to.Timestamp.Sub(from.Timestamp).Hours()
+	if hours <= 0 || distance <= 0 {
+		return 0
+	}
+	return distance / hours
+}
+
+// appendWindow добавляет значение, сохраняя не больше size последних
+func appendWindow(window []float64, value float64, size int) []float64 {
+	if len(window) >= size {
+		copy(window, window[1:])
+		window = window[:len(window)-1]
+	}
+	return append(window, value)
+}
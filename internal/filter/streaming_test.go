@@ -0,0 +1,235 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamPoint точка после прямого участка: смещение времени от его последней точки
+type streamPoint struct {
+	lat, lon float64
+	after    time.Duration
+}
+
+// streamStep ожидаемый результат Push
+type streamStep struct {
+	decision StreamDecision
+	released []float64 // Широты принятых точек
+	rejected []string  // Категории отклоненных точек
+}
+
+// pushStraight подает count точек на север с шагом ~111 м каждые 10 секунд (40 км/ч)
+// и возвращает время последней точки
+func pushStraight(t *testing.T, f *StreamFilter, start time.Time, count int) time.Time {
+	ts := start
+	for i := 0; i < count; i++ {
+		ts = start.Add(time.Duration(i) * 10 * time.Second)
+		result := f.Push(TrackPoint{Position: models.GeoPoint{Latitude: 46.0 + float64(i)*0.001, Longitude: 13.0}, Timestamp: ts})
+		require.Equal(t, StreamAccept, result.Decision, "point %d must be accepted", i)
+		require.Len(t, result.Released, 1)
+	}
+	return ts
+}
+
+func releasedLatitudes(points []TrackPoint) []float64 {
+	var result []float64
+	for _, point := range points {
+		result = append(result, point.Position.Latitude)
+	}
+	return result
+}
+
+func rejectedKinds(points []RejectedPoint) []string {
+	var result []string
+	for _, point := range points {
+		result = append(result, point.Kind)
+	}
+	return result
+}
+
+func TestStreamFilter_Push(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []streamPoint
+		want   []streamStep
+	}{
+		{
+			name:   "smooth continuation",
+			points: []streamPoint{{46.020, 13.0, 10 * time.Second}},
+			want:   []streamStep{{decision: StreamAccept, released: []float64{46.020}}},
+		},
+		{
+			name: "teleport rejected by speed",
+			points: []streamPoint{
+				{47.0, 13.0, 10 * time.Second},
+				{46.021, 13.0, 20 * time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamReject, rejected: []string{RejectSpeed}},
+				{decision: StreamAccept, released: []float64{46.021}},
+			},
+		},
+		{
+			name: "local outlier delayed then rejected",
+			points: []streamPoint{
+				{46.019, 13.04, 2 * time.Minute},
+				{46.021, 13.0, 2*time.Minute + 10*time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamDelay},
+				{decision: StreamAccept, released: []float64{46.021}, rejected: []string{RejectOutlier}},
+			},
+		},
+		{
+			name: "jump delayed then confirmed",
+			points: []streamPoint{
+				{46.019, 13.04, 2 * time.Minute},
+				{46.020, 13.04, 2*time.Minute + 10*time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamDelay},
+				{decision: StreamAccept, released: []float64{46.019, 46.020}},
+			},
+		},
+		{
+			name: "point not after last accepted",
+			points: []streamPoint{
+				{46.020, 13.0, 0},
+				{46.019, 13.0, -10 * time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamReject, rejected: []string{RejectDuplicate}},
+				{decision: StreamReject, rejected: []string{RejectDuplicate}},
+			},
+		},
+		{
+			name: "segment gap starts track again",
+			points: []streamPoint{
+				{47.0, 13.0, 31 * time.Minute},
+				{47.001, 13.0, 31*time.Minute + 10*time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamAccept, released: []float64{47.0}},
+				{decision: StreamAccept, released: []float64{47.001}},
+			},
+		},
+		{
+			name: "pending released at segment gap",
+			points: []streamPoint{
+				{46.019, 13.04, 2 * time.Minute},
+				{47.0, 13.0, 40 * time.Minute},
+			},
+			want: []streamStep{
+				{decision: StreamDelay},
+				{decision: StreamAccept, released: []float64{46.019, 47.0}},
+			},
+		},
+		{
+			name: "reanchors after reject streak",
+			points: []streamPoint{
+				{47.001, 13.0, 10 * time.Second},
+				{47.002, 13.0, 20 * time.Second},
+				{47.003, 13.0, 30 * time.Second},
+				{47.004, 13.0, 40 * time.Second},
+				{47.005, 13.0, 50 * time.Second},
+				{47.006, 13.0, 60 * time.Second},
+			},
+			want: []streamStep{
+				{decision: StreamReject, rejected: []string{RejectSpeed}},
+				{decision: StreamReject, rejected: []string{RejectSpeed}},
+				{decision: StreamReject, rejected: []string{RejectSpeed}},
+				{decision: StreamReject, rejected: []string{RejectSpeed}},
+				{decision: StreamAccept, released: []float64{47.005}},
+				{decision: StreamAccept, released: []float64{47.006}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, tt.want, len(tt.points))
+
+			f := NewStreamFilter(nil, models.PilotTypeParaglider)
+			last := pushStraight(t, f, time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC), 20)
+
+			for i, point := range tt.points {
+				result := f.Push(TrackPoint{
+					Position:  models.GeoPoint{Latitude: point.lat, Longitude: point.lon},
+					Timestamp: last.Add(point.after),
+				})
+				assert.Equal(t, tt.want[i].decision.String(), result.Decision.String(), "point %d", i)
+				assert.Equal(t, tt.want[i].released, releasedLatitudes(result.Released), "point %d released", i)
+				assert.Equal(t, tt.want[i].rejected, rejectedKinds(result.Rejected), "point %d rejected", i)
+				assert.Equal(t, result.Decision == StreamDelay, f.HasPending(), "point %d pending", i)
+			}
+		})
+	}
+}
+
+func TestStreamFilter_RejectedPointDetails(t *testing.T) {
+	f := NewStreamFilter(nil, models.PilotTypeParaglider)
+	last := pushStraight(t, f, time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC), 20)
+
+	result := f.Push(TrackPoint{Position: models.GeoPoint{Latitude: 47.0, Longitude: 13.0}, Timestamp: last.Add(10 * time.Second)})
+	require.Len(t, result.Rejected, 1)
+
+	rejected := result.Rejected[0]
+	assert.Equal(t, "SpeedBasedFilter", rejected.Filter)
+	assert.Contains(t, rejected.Reason, "exceeds max 120.0 km/h")
+	assert.Equal(t, last.Add(10*time.Second), rejected.Timestamp)
+	assert.Greater(t, rejected.Speed, 10000.0)
+}
+
+func TestStreamFilter_AircraftTypeLimit(t *testing.T) {
+	// 100 км/ч: больше лимита параплана с буфером (120), но допустимо для планера
+	tests := []struct {
+		aircraftType models.PilotType
+		want         StreamDecision
+	}{
+		{aircraftType: models.PilotTypeParaglider, want: StreamAccept},
+		{aircraftType: models.PilotTypeBalloon, want: StreamReject},
+		{aircraftType: models.PilotTypeGlider, want: StreamAccept},
+	}
+
+	for _, tt := range tests {
+		f := NewStreamFilter(nil, tt.aircraftType)
+		start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+		f.Push(TrackPoint{Position: models.GeoPoint{Latitude: 46.0, Longitude: 13.0}, Timestamp: start})
+
+		// ~2.8 км за 100 секунд
+		result := f.Push(TrackPoint{Position: models.GeoPoint{Latitude: 46.025, Longitude: 13.0}, Timestamp: start.Add(100 * time.Second)})
+		assert.Equal(t, tt.want.String(), result.Decision.String(), "aircraft type %d", tt.aircraftType)
+	}
+}
+
+func TestStreamFilter_Flush(t *testing.T) {
+	f := NewStreamFilter(nil, models.PilotTypeParaglider)
+	last := pushStraight(t, f, time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC), 20)
+
+	_, ok := f.Flush()
+	assert.False(t, ok)
+
+	jump := TrackPoint{Position: models.GeoPoint{Latitude: 46.019, Longitude: 13.04}, Timestamp: last.Add(2 * time.Minute)}
+	require.Equal(t, StreamDelay, f.Push(jump).Decision)
+
+	flushed, ok := f.Flush()
+	require.True(t, ok)
+	assert.Equal(t, jump.Position, flushed.Position)
+	assert.False(t, f.HasPending())
+
+	// Принятая точка стала опорной
+	result := f.Push(TrackPoint{Position: models.GeoPoint{Latitude: 46.020, Longitude: 13.04}, Timestamp: last.Add(2*time.Minute + 10*time.Second)})
+	assert.Equal(t, StreamAccept, result.Decision)
+	assert.Equal(t, []float64{46.020}, releasedLatitudes(result.Released))
+}
+
+func TestStreamDecision_String(t *testing.T) {
+	assert.Equal(t, "accept", StreamAccept.String())
+	assert.Equal(t, "delay", StreamDelay.String())
+	assert.Equal(t, "reject", StreamReject.String())
+	assert.Equal(t, "unknown", StreamDecision(42).String())
+}
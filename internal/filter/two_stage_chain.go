@@ -125,6 +125,7 @@ func (fc *TwoStageFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 
 	// Если после первой стадии не осталось сегментов, возвращаем результат
 	if len(stage1Result.Statistics.Segments) == 0 {
+		stage1Result.Statistics.RejectedByFilter = rejectedByFilter(stage1Result.Rejected)
 		return stage1Result, nil
 	}
 
@@ -138,6 +139,8 @@ func (fc *TwoStageFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 	
 	// Обновляем финальную статистику
 	finalStats := fc.mergeStats(combinedStats, stage2Result.Statistics)
+	rejected := append(stage1Result.Rejected, stage2Result.Rejected...)
+	finalStats.RejectedByFilter = rejectedByFilter(rejected)
 	
 	// Вычисляем финальные метрики
 	finalCount := len(stage2Result.Points)
@@ -165,6 +168,7 @@ func (fc *TwoStageFilterChain) Filter(track *TrackData) (*FilterResult, error) {
 		FilteredCount: totalFiltered,
 		Points:        stage2Result.Points,
 		Statistics:    finalStats,
+		Rejected:      rejected,
 	}
 
 	fc.logger.WithField("device_id", track.DeviceID).
@@ -192,9 +196,16 @@ func (fc *TwoStageFilterChain) applyStage(filters []TrackFilter, track *TrackDat
 	stageTrack := *track // Копируем трек для стадии
 	stageStats := FilterStats{}
 	stageOriginalCount := len(track.Points)
+	var rejected []RejectedPoint
 
 	for _, filter := range filters {
 		start := time.Now()
+
+		// Некоторые фильтры помечают точки входного трека на месте
+		var input []TrackPoint
+		if fc.config.CollectRejected {
+			input = append(input, stageTrack.Points...)
+		}
 		
 		result, err := filter.Filter(&stageTrack)
 		if err != nil {
@@ -215,6 +226,10 @@ func (fc *TwoStageFilterChain) applyStage(filters []TrackFilter, track *TrackDat
 			WithField("duration_ms", duration.Milliseconds()).
 			Debug("Filter applied")
 
+		if fc.config.CollectRejected {
+			rejected = append(rejected, rejectedPoints(filter, input, result)...)
+		}
+
 		// Обновляем трек для следующего фильтра
 		stageTrack.Points = result.Points
 		
@@ -227,6 +242,7 @@ func (fc *TwoStageFilterChain) applyStage(filters []TrackFilter, track *TrackDat
 		FilteredCount: stageOriginalCount - len(stageTrack.Points),
 		Points:        stageTrack.Points,
 		Statistics:    stageStats,
+		Rejected:      rejected,
 	}, nil
 }
 
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/flybeeper/fanet-backend/internal/auth"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// AlertHandler обработчик инцидентов по сигналам бедствия
+type AlertHandler struct {
+	alertService *service.AlertService
+}
+
+// NewAlertHandler создает новый обработчик инцидентов
+func NewAlertHandler(alertService *service.AlertService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+	}
+}
+
+// AlertActionRequest запрос на изменение статуса инцидента
+type AlertActionRequest struct {
+	ID     string `json:"id" binding:"required"`
+	Action string `json:"action" binding:"required"` // acknowledge, resolve
+	Note   string `json:"note"`
+}
+
+// GetAlerts возвращает список инцидентов
+// GET /api/v1/alerts?status=open|acknowledged|resolved
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	status, err := models.ParseAlertStatus(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_status",
+			"message": "Status must be open, acknowledged or resolved",
+		})
+		return
+	}
+
+	alerts := h.alertService.ListAlerts(status)
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		response := &pb.AlertsResponse{
+			Alerts: make([]*pb.Alert, 0, len(alerts)),
+		}
+		for _, alert := range alerts {
+			response.Alerts = append(response.Alerts, alert.ToProto())
+		}
+		data, err := proto.Marshal(response)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "marshal_error",
+				"message": "Failed to serialize response",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "application/x-protobuf", data)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+	})
+}
+
+// UpdateAlert подтверждает или закрывает инцидент
+// POST /api/v1/alerts
+func (h *AlertHandler) UpdateAlert(c *gin.Context) {
+	var req AlertActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_request",
+			"message": "Request must contain id and action",
+		})
+		return
+	}
+
+	if _, exists := h.alertService.GetAlert(req.ID); !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "alert_not_found",
+			"message": "Alert not found",
+		})
+		return
+	}
+
+	userID, _ := auth.GetUserID(c)
+
+	var alert *models.Alert
+	var err error
+	switch req.Action {
+	case "acknowledge":
+		alert, err = h.alertService.Acknowledge(req.ID, userID, req.Note)
+	case "resolve":
+		alert, err = h.alertService.Resolve(req.ID, userID, req.Note)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_action",
+			"message": "Action must be acknowledge or resolve",
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    "invalid_transition",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/flybeeper/fanet-backend/internal/geo"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
 	"github.com/flybeeper/fanet-backend/internal/models"
 	"github.com/flybeeper/fanet-backend/pkg/pb"
 	"github.com/flybeeper/fanet-backend/pkg/pool"
@@ -17,9 +20,15 @@ import (
 type BroadcastManager struct {
 	groups      map[string]*GeohashGroup // geohash -> group
 	clients     map[*Client]*ClientInfo  // client -> info
+	followers   map[string]map[*Client]bool // device ID -> clients following it outside of their areas
 	spatial     *geo.SpatialIndex
 	mu          sync.RWMutex
 	
+	// Update sequence and journal for resuming clients
+	sequence    uint64
+	journal     *UpdateJournal
+	priorityMu  sync.Mutex // keeps priority updates journaled in sequence order
+	
 	// Update channels
 	updates     chan *UpdatePacket
 	register    chan *ClientRegistration
@@ -46,29 +55,53 @@ type GeohashGroup struct {
 
 // ClientInfo stores client subscription details
 type ClientInfo struct {
-	client      *Client
-	geohashes   map[string]bool
-	centerLat   float64
-	centerLon   float64
-	radiusKm    float64
-	lastActive  time.Time
+	client        *Client
+	geohashes     map[string]bool
+	devices       map[string]bool          // followed device IDs
+	subscriptions map[string]*Subscription // subscription ID -> area and type filter or devices
+	sent          map[string]*sentState    // object key -> last state sent, only for delta clients
+	lastActive    time.Time
 }
 
-// ClientRegistration represents a new client subscription
+// ClientRegistration represents a client connection or a subscription change.
+// Connections and subscription changes share one channel to keep them ordered
 type ClientRegistration struct {
-	client      *Client
-	centerLat   float64
-	centerLon   float64
-	radiusKm    float64
+	client       *Client
+	connect      bool          // first registration of the client
+	subscription *Subscription // added or replaced subscription
+	unsubscribe  bool          // remove subscription id ("" - all subscriptions)
+	id           string
+
+	response   *pb.SubscribeResponse // sent before replayed updates, nil - no response
+	resumeFrom uint64                // last sequence seen by the client, 0 - no resume
+}
+
+// encodedUpdate is an update serialized once per batch and shared by all recipients
+type encodedUpdate struct {
+	update   *UpdatePacket
+	index    int
+	key      string // type + object ID, for deduplication within a client batch
+	device   string // FANET device ID of pilots and ground objects, for follow subscriptions
+	action   pb.Action
+	msg      proto.Message // payload, base for field-level deltas
+	data     []byte
+	sequence uint64
+	lat      float64
+	lon      float64
+	at       time.Time // journal append time
+	priority bool      // sent to every client regardless of subscriptions
 }
 
 // UpdatePacket represents an update to broadcast
 type UpdatePacket struct {
-	Type        pb.UpdateType
-	Pilot       *models.Pilot
-	Thermal     *models.Thermal
-	Station     *models.Station
-	Timestamp   time.Time
+	Type         pb.UpdateType
+	Pilot        *models.Pilot
+	GroundObject *models.GroundObject
+	Thermal      *models.Thermal
+	Station      *models.Station
+	Message      *models.Message
+	Landmark     *models.Landmark
+	Timestamp    time.Time
 }
 
 // BroadcastMetrics tracks broadcast performance
@@ -83,9 +116,16 @@ type BroadcastMetrics struct {
 
 // NewBroadcastManager creates a new broadcast manager
 func NewBroadcastManager(spatial *geo.SpatialIndex) *BroadcastManager {
+	// Sequences start from the startup time, so they keep growing across restarts
+	// and a client resuming with a sequence from the previous run gets a snapshot
+	sequence := uint64(time.Now().UnixNano())
+	
 	bm := &BroadcastManager{
+		sequence:   sequence,
+		journal:    NewUpdateJournal(sequence+1, journalPrecision, journalMaxPerCell, journalMaxAge),
 		groups:     make(map[string]*GeohashGroup),
 		clients:    make(map[*Client]*ClientInfo),
+		followers:  make(map[string]map[*Client]bool),
 		spatial:    spatial,
 		updates:    make(chan *UpdatePacket, 1000),
 		register:   make(chan *ClientRegistration, 100),
@@ -103,13 +143,58 @@ func NewBroadcastManager(spatial *geo.SpatialIndex) *BroadcastManager {
 	return bm
 }
 
-// Register subscribes a client to geohash regions
-func (bm *BroadcastManager) Register(client *Client, centerLat, centerLon, radiusKm float64) {
+// Register adds a client with an optional initial subscription (nil - none)
+func (bm *BroadcastManager) Register(client *Client, subscription *Subscription) {
+	bm.register <- &ClientRegistration{
+		client:       client,
+		connect:      true,
+		subscription: subscription,
+	}
+}
+
+// Subscribe adds or replaces a named subscription of a registered client.
+// The response is queued to the client once the subscription is active; with
+// resumeFrom > 0 it is followed by the updates the client missed since that
+// sequence, or by a snapshot when the journal no longer covers the gap
+func (bm *BroadcastManager) Subscribe(client *Client, subscription *Subscription, response *pb.SubscribeResponse, resumeFrom uint64) {
 	bm.register <- &ClientRegistration{
-		client:    client,
-		centerLat: centerLat,
-		centerLon: centerLon,
-		radiusKm:  radiusKm,
+		client:       client,
+		subscription: subscription,
+		response:     response,
+		resumeFrom:   resumeFrom,
+	}
+}
+
+// NextSequence returns the next update sequence number
+func (bm *BroadcastManager) NextSequence() uint64 {
+	return atomic.AddUint64(&bm.sequence, 1)
+}
+
+// Sequence returns the last assigned update sequence number
+func (bm *BroadcastManager) Sequence() uint64 {
+	return atomic.LoadUint64(&bm.sequence)
+}
+
+// UserClients returns the connected clients authenticated as the user
+func (bm *BroadcastManager) UserClients(userID int) []*Client {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	var clients []*Client
+	for client := range bm.clients {
+		if client.userID == userID {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// Unsubscribe removes a named subscription of a client, empty id removes all of them
+func (bm *BroadcastManager) Unsubscribe(client *Client, id string) {
+	bm.register <- &ClientRegistration{
+		client:      client,
+		unsubscribe: true,
+		id:          id,
 	}
 }
 
@@ -128,6 +213,67 @@ func (bm *BroadcastManager) Broadcast(update *UpdatePacket) {
 	}
 }
 
+// BroadcastPriority sends an update to every connected client immediately,
+// bypassing batching and subscription radius filtering. The update takes the
+// next sequence and is journaled, so resuming clients get it replayed.
+// Used for critical updates (distress alerts, flight events) that all clients must see.
+// id identifies the object: a resuming client gets only its latest update
+func (bm *BroadcastManager) BroadcastPriority(updateType pb.UpdateType, action pb.Action, id string, payload []byte) int {
+	now := time.Now()
+	enc := &encodedUpdate{
+		update:   &UpdatePacket{Type: updateType, Timestamp: now},
+		key:      updateType.String() + ":" + id,
+		action:   action,
+		data:     payload,
+		priority: true,
+	}
+
+	// Sequence and journal under one lock: the journal requires ascending order
+	bm.priorityMu.Lock()
+	enc.sequence = bm.NextSequence()
+	bm.journal.AppendPriority(enc)
+	bm.priorityMu.Unlock()
+
+	data, err := proto.Marshal(&pb.UpdateBatch{
+		Updates: []*pb.Update{{
+			Type:     updateType,
+			Action:   action,
+			Data:     payload,
+			Sequence: enc.sequence,
+		}},
+		Timestamp: now.Unix(),
+	})
+	if err != nil {
+		bm.logger.WithError(err).WithField("type", updateType.String()).Error("Failed to marshal priority update")
+		return 0
+	}
+
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	var framed []byte
+	recipients := 0
+	for client := range bm.clients {
+		frame := data
+		if client.protocol == ProtocolV2 {
+			if framed == nil {
+				framed = wrapServerMessage(serverMessageBatch, data)
+			}
+			frame = framed
+		}
+
+		select {
+		case client.send <- frame:
+			recipients++
+		default:
+			bm.logger.WithField("client", client.remoteAddr()).Warn("Client send buffer full, priority update dropped")
+		}
+	}
+
+	atomic.AddUint64(&bm.metrics.UpdatesBroadcast, 1)
+	return recipients
+}
+
 // run is the main event loop
 func (bm *BroadcastManager) run() {
 	ticker := time.NewTicker(bm.batchTime)
@@ -159,29 +305,77 @@ func (bm *BroadcastManager) run() {
 	}
 }
 
-// handleRegister subscribes a client to relevant geohash groups
+// handleRegister applies a client connection or subscription change and
+// moves the client between geohash groups covering its subscriptions
 func (bm *BroadcastManager) handleRegister(reg *ClientRegistration) {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
-	
-	// Calculate geohashes covering the client's area
-	precision := geo.OptimalGeohashPrecision(reg.radiusKm)
-	geohashes := geo.Cover(reg.centerLat, reg.centerLon, reg.radiusKm, precision)
-	
-	// Create client info
-	info := &ClientInfo{
-		client:     reg.client,
-		geohashes:  make(map[string]bool),
-		centerLat:  reg.centerLat,
-		centerLon:  reg.centerLon,
-		radiusKm:   reg.radiusKm,
-		lastActive: time.Now(),
+
+	info, exists := bm.clients[reg.client]
+	if !exists {
+		if !reg.connect {
+			// Client disconnected before the change was processed
+			return
+		}
+		info = &ClientInfo{
+			client:        reg.client,
+			geohashes:     make(map[string]bool),
+			devices:       make(map[string]bool),
+			subscriptions: make(map[string]*Subscription),
+		}
+		if reg.client.delta {
+			info.sent = make(map[string]*sentState)
+		}
+		bm.clients[reg.client] = info
 	}
-	
-	// Subscribe to geohash groups
-	for _, gh := range geohashes {
-		info.geohashes[gh] = true
-		
+	info.lastActive = time.Now()
+
+	before := len(info.subscriptions)
+	switch {
+	case reg.subscription != nil:
+		info.subscriptions[reg.subscription.ID] = reg.subscription
+	case reg.unsubscribe && reg.id == "":
+		info.subscriptions = make(map[string]*Subscription)
+	case reg.unsubscribe:
+		delete(info.subscriptions, reg.id)
+	}
+	metrics.WebSocketSubscriptions.Add(float64(len(info.subscriptions) - before))
+
+	// Geohashes covering all subscriptions of the client and followed devices
+	geohashes := make(map[string]bool)
+	devices := make(map[string]bool)
+	for _, sub := range info.subscriptions {
+		for _, gh := range sub.Geohashes() {
+			geohashes[gh] = true
+		}
+		for deviceID := range sub.Devices {
+			devices[deviceID] = true
+		}
+	}
+
+	for deviceID := range info.devices {
+		if !devices[deviceID] {
+			bm.unfollow(deviceID, reg.client)
+		}
+	}
+	for deviceID := range devices {
+		if bm.followers[deviceID] == nil {
+			bm.followers[deviceID] = make(map[*Client]bool)
+		}
+		bm.followers[deviceID][reg.client] = true
+	}
+	info.devices = devices
+
+	for gh := range info.geohashes {
+		if !geohashes[gh] {
+			bm.leaveGroup(gh, reg.client)
+		}
+	}
+	for gh := range geohashes {
+		if info.geohashes[gh] {
+			continue
+		}
+
 		// Get or create group
 		group, exists := bm.groups[gh]
 		if !exists {
@@ -192,22 +386,130 @@ func (bm *BroadcastManager) handleRegister(reg *ClientRegistration) {
 			}
 			bm.groups[gh] = group
 		}
-		
-		// Add client to group
+
 		group.mu.Lock()
 		group.clients[reg.client] = true
 		group.mu.Unlock()
 	}
-	
-	bm.clients[reg.client] = info
+	info.geohashes = geohashes
+
 	atomic.StoreUint64(&bm.metrics.ClientsActive, uint64(len(bm.clients)))
 	atomic.StoreUint64(&bm.metrics.GroupsActive, uint64(len(bm.groups)))
-	
+
+	bm.logger.WithFields(logrus.Fields{
+		"client":        reg.client.remoteAddr(),
+		"geohashes":     len(geohashes),
+		"devices":       len(devices),
+		"subscriptions": len(info.subscriptions),
+	}).Debug("Client subscriptions updated for broadcast")
+
+	if reg.response != nil {
+		bm.respond(info, reg)
+	}
+}
+
+// respond queues the subscribe response and replays missed updates. Runs in the
+// event loop, so no batch can be broadcast between the replay and activation
+// of the subscription. Caller must hold bm.mu
+func (bm *BroadcastManager) respond(info *ClientInfo, reg *ClientRegistration) {
+	var missed []*encodedUpdate
+	if reg.subscription != nil && reg.subscription.Devices != nil {
+		// Followed devices can be anywhere, the journal is not scanned for them:
+		// the client always gets their current state as a snapshot
+		if reg.resumeFrom > 0 {
+			reg.response.Resume = pb.ResumeStatus_RESUME_STATUS_SNAPSHOT
+			metrics.WebSocketResumes.WithLabelValues("snapshot").Inc()
+		}
+	} else if reg.resumeFrom > 0 && reg.subscription != nil {
+		var ok bool
+		missed, ok = bm.journal.Since(reg.subscription, reg.resumeFrom, bm.Sequence())
+		if ok {
+			reg.response.Resume = pb.ResumeStatus_RESUME_STATUS_REPLAY
+			metrics.WebSocketResumes.WithLabelValues("replay").Inc()
+		} else {
+			reg.response.Resume = pb.ResumeStatus_RESUME_STATUS_SNAPSHOT
+			metrics.WebSocketResumes.WithLabelValues("snapshot").Inc()
+		}
+	}
+
+	data, err := encodeServerMessage(reg.client.protocol, serverMessageSubscribeResponse, reg.response)
+	if err != nil {
+		bm.logger.WithError(err).Error("Failed to marshal subscribe response")
+		return
+	}
+	bm.queue(reg.client, data)
+
+	if reg.response.Resume == pb.ResumeStatus_RESUME_STATUS_SNAPSHOT || (reg.subscription != nil && reg.subscription.Devices != nil) {
+		// Snapshot is loaded from the repository outside of the event loop
+		go reg.client.sendSnapshot(reg.subscription)
+		return
+	}
+
+	for start := 0; start < len(missed); start += replayBatchSize {
+		end := start + replayBatchSize
+		if end > len(missed) {
+			end = len(missed)
+		}
+		if data := bm.buildClientBatch(info, missed[start:end], newBatchCache()); data != nil {
+			bm.send(info, data)
+		}
+	}
+
 	bm.logger.WithFields(logrus.Fields{
-		"client":    reg.client.conn.RemoteAddr(),
-		"geohashes": len(geohashes),
-		"radius":    reg.radiusKm,
-	}).Debug("Client registered for broadcast")
+		"client":       reg.client.remoteAddr(),
+		"subscription": reg.subscription.ID,
+		"resume_from":  reg.resumeFrom,
+		"resume":       reg.response.Resume.String(),
+		"replayed":     len(missed),
+	}).Debug("Subscription resumed")
+}
+
+// send queues an update batch built by buildClientBatch. A dropped batch would
+// break the delta chain, so the client's sent states are reset and the next
+// update of every object is sent in full
+func (bm *BroadcastManager) send(info *ClientInfo, data []byte) bool {
+	if bm.queue(info.client, data) {
+		return true
+	}
+	if info.sent != nil {
+		info.sent = make(map[string]*sentState)
+	}
+	return false
+}
+
+// queue sends a message to the client without blocking the event loop
+func (bm *BroadcastManager) queue(client *Client, data []byte) bool {
+	select {
+	case client.send <- data:
+		return true
+	default:
+		bm.logger.WithField("client", client.remoteAddr()).Warn("Client send buffer full")
+		return false
+	}
+}
+
+// unfollow removes a client from the followers of a device. Caller must hold bm.mu
+func (bm *BroadcastManager) unfollow(deviceID string, client *Client) {
+	delete(bm.followers[deviceID], client)
+	if len(bm.followers[deviceID]) == 0 {
+		delete(bm.followers, deviceID)
+	}
+}
+
+// leaveGroup removes a client from a geohash group and drops the group when empty.
+// Caller must hold bm.mu
+func (bm *BroadcastManager) leaveGroup(gh string, client *Client) {
+	group, exists := bm.groups[gh]
+	if !exists {
+		return
+	}
+
+	group.mu.Lock()
+	delete(group.clients, client)
+	if len(group.clients) == 0 {
+		delete(bm.groups, gh)
+	}
+	group.mu.Unlock()
 }
 
 // handleUnregister removes a client from all groups
@@ -222,77 +524,96 @@ func (bm *BroadcastManager) handleUnregister(client *Client) {
 	
 	// Remove from all groups
 	for gh := range info.geohashes {
-		if group, exists := bm.groups[gh]; exists {
-			group.mu.Lock()
-			delete(group.clients, client)
-			
-			// Remove empty groups
-			if len(group.clients) == 0 {
-				delete(bm.groups, gh)
-			}
-			group.mu.Unlock()
-		}
+		bm.leaveGroup(gh, client)
 	}
+	for deviceID := range info.devices {
+		bm.unfollow(deviceID, client)
+	}
+	metrics.WebSocketSubscriptions.Sub(float64(len(info.subscriptions)))
 	
 	delete(bm.clients, client)
 	atomic.StoreUint64(&bm.metrics.ClientsActive, uint64(len(bm.clients)))
 	atomic.StoreUint64(&bm.metrics.GroupsActive, uint64(len(bm.groups)))
 	
-	bm.logger.WithField("client", client.conn.RemoteAddr()).Debug("Client unregistered from broadcast")
+	bm.logger.WithField("client", client.remoteAddr()).Debug("Client unregistered from broadcast")
 }
 
-// processBatch broadcasts a batch of updates efficiently
+// processBatch broadcasts a batch of updates efficiently. Every update is
+// serialized once; recipients are found through geohash groups, and each client
+// receives only the updates matching its subscriptions, tagged with their IDs
 func (bm *BroadcastManager) processBatch(batch []*UpdatePacket) {
 	start := time.Now()
-	
-	// Group updates by geohash
-	updatesByGeohash := make(map[string][]*UpdatePacket)
-	
+
+	encoded := make([]*encodedUpdate, 0, len(batch))
 	for _, update := range batch {
-		var lat, lon float64
-		
-		// Extract coordinates based on type
-		switch update.Type {
-		case pb.UpdateType_UPDATE_TYPE_PILOT:
-			if update.Pilot != nil && update.Pilot.Position != nil {
-				lat = update.Pilot.Position.Latitude
-				lon = update.Pilot.Position.Longitude
-			}
-		case pb.UpdateType_UPDATE_TYPE_THERMAL:
-			if update.Thermal != nil && update.Thermal.Position != nil {
-				lat = update.Thermal.Position.Latitude
-				lon = update.Thermal.Position.Longitude
+		if enc := bm.encodeUpdate(update); enc != nil {
+			enc.index = len(encoded)
+			enc.sequence = bm.NextSequence()
+			bm.journal.Append(enc)
+			encoded = append(encoded, enc)
+		}
+	}
+
+	totalRecipients := 0
+
+	bm.mu.RLock()
+
+	// Collect candidate updates per client from affected geohash groups
+	candidates := make(map[*Client][]*encodedUpdate)
+	for _, enc := range encoded {
+		for precision := 3; precision <= 7; precision++ {
+			group, exists := bm.groups[geo.Encode(enc.lat, enc.lon, precision)]
+			if !exists {
+				continue
 			}
-		case pb.UpdateType_UPDATE_TYPE_STATION:
-			if update.Station != nil && update.Station.Position != nil {
-				lat = update.Station.Position.Latitude
-				lon = update.Station.Position.Longitude
+
+			group.mu.Lock()
+			for client := range group.clients {
+				// A client subscribed at several precisions gets the update once
+				list := candidates[client]
+				if n := len(list); n == 0 || list[n-1] != enc {
+					candidates[client] = append(list, enc)
+				}
 			}
+			group.lastUpdate = start
+			group.mu.Unlock()
 		}
-		
-		// Find affected geohashes
-		for precision := 3; precision <= 7; precision++ {
-			gh := geo.Encode(lat, lon, precision)
-			updatesByGeohash[gh] = append(updatesByGeohash[gh], update)
+
+		// Clients following the device get it wherever it is
+		if enc.device == "" {
+			continue
+		}
+		for client := range bm.followers[enc.device] {
+			list := candidates[client]
+			if n := len(list); n == 0 || list[n-1] != enc {
+				candidates[client] = append(list, enc)
+			}
 		}
 	}
-	
-	// Broadcast to groups
-	totalRecipients := 0
-	
-	bm.mu.RLock()
-	for gh, updates := range updatesByGeohash {
-		if group, exists := bm.groups[gh]; exists {
-			recipients := bm.broadcastToGroup(group, updates)
-			totalRecipients += recipients
+
+	// Clients with identical matches share one serialized batch
+	cache := newBatchCache()
+	for client, updates := range candidates {
+		info, exists := bm.clients[client]
+		if !exists {
+			continue
+		}
+
+		data := bm.buildClientBatch(info, updates, cache)
+		if data == nil {
+			continue
+		}
+
+		if bm.send(info, data) {
+			totalRecipients++
 		}
 	}
 	bm.mu.RUnlock()
-	
+
 	// Update metrics
 	atomic.AddUint64(&bm.metrics.UpdatesBroadcast, uint64(len(batch)))
 	bm.updateBroadcastMetrics(time.Since(start), totalRecipients)
-	
+
 	bm.logger.WithFields(logrus.Fields{
 		"batch_size": len(batch),
 		"recipients": totalRecipients,
@@ -300,122 +621,214 @@ func (bm *BroadcastManager) processBatch(batch []*UpdatePacket) {
 	}).Debug("Batch broadcast completed")
 }
 
-// broadcastToGroup sends updates to all clients in a group
-func (bm *BroadcastManager) broadcastToGroup(group *GeohashGroup, updates []*UpdatePacket) int {
-	group.mu.RLock()
-	defer group.mu.RUnlock()
-	
-	if len(group.clients) == 0 {
-		return 0
+// encodeUpdate serializes the update payload and extracts its location.
+// Sequence is assigned by the caller. Returns nil for updates without payload or position
+func (bm *BroadcastManager) encodeUpdate(update *UpdatePacket) *encodedUpdate {
+	enc := &encodedUpdate{
+		update: update,
+		action: pb.Action_ACTION_UPDATE,
 	}
-	
-	// Build update batch message using pool
-	updateBatch := pool.Global.GetPbUpdateBatch()
-	updateBatch.Timestamp = time.Now().Unix()
-	
+
+	var objID string
+	var position *models.GeoPoint
+	var msg proto.Message
+
+	switch update.Type {
+	case pb.UpdateType_UPDATE_TYPE_PILOT:
+		if update.Pilot != nil {
+			objID, position, msg = update.Pilot.GetID(), update.Pilot.Position, update.Pilot.ToProto()
+			enc.device = objID
+		}
+	case pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT:
+		if update.GroundObject != nil {
+			objID, position, msg = update.GroundObject.DeviceID, update.GroundObject.Position, update.GroundObject.ToProto()
+			enc.device = objID
+		}
+	case pb.UpdateType_UPDATE_TYPE_THERMAL:
+		if update.Thermal != nil {
+			objID, position, msg = update.Thermal.ID, update.Thermal.Position, update.Thermal.ToProto()
+		}
+	case pb.UpdateType_UPDATE_TYPE_STATION:
+		if update.Station != nil {
+			objID, position, msg = update.Station.ChipID, update.Station.Position, update.Station.ToProto()
+		}
+	case pb.UpdateType_UPDATE_TYPE_MESSAGE:
+		if update.Message != nil {
+			enc.action = pb.Action_ACTION_ADD
+			objID, position, msg = update.Message.ID, update.Message.Position, update.Message.ToProto()
+		}
+	case pb.UpdateType_UPDATE_TYPE_LANDMARK:
+		if update.Landmark != nil {
+			center := update.Landmark.Center()
+			objID, position, msg = update.Landmark.ID, &center, update.Landmark.ToProto()
+		}
+	}
+
+	if objID == "" || position == nil {
+		return nil
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		bm.logger.WithError(err).WithField("type", update.Type.String()).Error("Failed to marshal update")
+		return nil
+	}
+
+	enc.key = update.Type.String() + ":" + objID
+	enc.msg = msg
+	enc.data = data
+	enc.lat = position.Latitude
+	enc.lon = position.Longitude
+	return enc
+}
+
+// buildClientBatch serializes the updates matching the client's subscriptions.
+// For delta clients an object already sent is encoded as a field-level delta
+// against the client's last state, unchanged objects are suppressed, and a full
+// keyframe is sent every deltaKeyframeEvery deltas or deltaKeyframeInterval.
+// Results are cached by content, so clients with the same matches and states
+// (e.g. v1 clients in one area) share a single marshal. Runs in the event loop
+// (sent states are not locked). Caller must hold bm.mu
+func (bm *BroadcastManager) buildClientBatch(info *ClientInfo, updates []*encodedUpdate, cache *batchCache) []byte {
+	type match struct {
+		enc   *encodedUpdate
+		tags  []string
+		delta *deltaResult // nil - full object
+	}
+
+	now := time.Now()
+
 	// Deduplicate updates by object ID
 	seen := make(map[string]bool)
-	
-	for _, update := range updates {
-		var objID string
-		var data []byte
-		var err error
-		
-		pbUpdate := pool.Global.GetPbUpdate()
-		pbUpdate.Type = update.Type
-		pbUpdate.Action = pb.Action_ACTION_UPDATE
-		pbUpdate.Sequence = uint64(time.Now().UnixNano())
-		
-		switch update.Type {
-		case pb.UpdateType_UPDATE_TYPE_PILOT:
-			if update.Pilot != nil {
-				objID = update.Pilot.Address
-				pbPilot := update.Pilot.ToProto()
-				data, err = proto.Marshal(pbPilot)
+	matches := make([]match, 0, len(updates))
+
+	var key strings.Builder
+	key.WriteString(strconv.Itoa(info.client.protocol))
+	for _, enc := range updates {
+		if seen[enc.key] {
+			continue
+		}
+		var tags []string
+		if !enc.priority {
+			var ok bool
+			if tags, ok = matchSubscriptions(info.subscriptions, enc.update.Type, enc.device, enc.lat, enc.lon); !ok {
+				continue
 			}
-		case pb.UpdateType_UPDATE_TYPE_THERMAL:
-			if update.Thermal != nil {
-				objID = update.Thermal.ID
-				pbThermal := update.Thermal.ToProto()
-				data, err = proto.Marshal(pbThermal)
+		}
+		seen[enc.key] = true
+
+		// Priority updates are always sent in full and do not join the delta chain
+		m := match{enc: enc, tags: tags}
+		if info.sent != nil && !enc.priority {
+			delta, suppress := bm.clientDelta(info, enc, cache, now)
+			if suppress {
+				metrics.WebSocketDeltaUpdates.WithLabelValues("suppressed").Inc()
+				continue
+			}
+			m.delta = delta
+		}
+		matches = append(matches, m)
+
+		key.WriteByte('|')
+		key.WriteString(strconv.Itoa(enc.index))
+		if m.delta != nil {
+			key.WriteString("d")
+			key.WriteString(strconv.FormatUint(info.sent[enc.key].sequence, 10))
+		}
+		for _, tag := range tags {
+			key.WriteByte(',')
+			key.WriteString(tag)
+		}
+	}
+
+	// Remember the states the client will hold after this batch
+	if info.sent != nil {
+		for _, m := range matches {
+			if m.enc.action != pb.Action_ACTION_UPDATE || m.enc.priority {
+				continue
 			}
-		case pb.UpdateType_UPDATE_TYPE_STATION:
-			if update.Station != nil {
-				objID = update.Station.ChipID
-				pbStation := update.Station.ToProto()
-				data, err = proto.Marshal(pbStation)
+			state := info.sent[m.enc.key]
+			if m.delta == nil || state == nil {
+				state = &sentState{keyframeAt: now}
+				info.sent[m.enc.key] = state
+				metrics.WebSocketDeltaUpdates.WithLabelValues("full").Inc()
+			} else {
+				state.deltas++
+				metrics.WebSocketDeltaUpdates.WithLabelValues("delta").Inc()
 			}
+			state.msg = m.enc.msg
+			state.sequence = m.enc.sequence
+			state.sentAt = now
 		}
-		
-		// Skip duplicates and marshal errors
-		if objID != "" && !seen[objID] && err == nil {
-			seen[objID] = true
-			pbUpdate.Data = data
-			updateBatch.Updates = append(updateBatch.Updates, pbUpdate)
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+	if data, ok := cache.frames[key.String()]; ok {
+		return data
+	}
+
+	// Build update batch message using pool
+	updateBatch := pool.Global.GetPbUpdateBatch()
+	defer pool.Global.PutPbUpdateBatch(updateBatch)
+	updateBatch.Timestamp = now.Unix()
+
+	for _, m := range matches {
+		pbUpdate := pool.Global.GetPbUpdate()
+		pbUpdate.Type = m.enc.update.Type
+		pbUpdate.Action = m.enc.action
+		pbUpdate.Data = m.enc.data
+		pbUpdate.Sequence = m.enc.sequence
+		pbUpdate.Subscriptions = append(pbUpdate.Subscriptions, m.tags...)
+		if m.delta != nil {
+			pbUpdate.Delta = true
+			pbUpdate.Data = m.delta.data
+			pbUpdate.ClearedFields = append(pbUpdate.ClearedFields, m.delta.cleared...)
 		}
+		updateBatch.Updates = append(updateBatch.Updates, pbUpdate)
 	}
-	
-	// Serialize message
+
 	data, err := proto.Marshal(updateBatch)
 	if err != nil {
 		bm.logger.WithError(err).Error("Failed to marshal update batch")
-		// Возвращаем объекты в пул даже при ошибке
-		pool.Global.PutPbUpdateBatch(updateBatch)
-		return 0
+		return nil
 	}
-	
-	// Возвращаем batch в пул после использования
-	defer pool.Global.PutPbUpdateBatch(updateBatch)
-	
-	// Send to all clients in group
-	recipients := 0
-	for client := range group.clients {
-		// Additional filtering by exact radius
-		if info, exists := bm.clients[client]; exists {
-			// Check if any update is within client's radius
-			inRadius := false
-			for _, update := range updates {
-				var lat, lon float64
-				
-				switch update.Type {
-				case pb.UpdateType_UPDATE_TYPE_PILOT:
-					if update.Pilot != nil && update.Pilot.Position != nil {
-						lat = update.Pilot.Position.Latitude
-						lon = update.Pilot.Position.Longitude
-					}
-				case pb.UpdateType_UPDATE_TYPE_THERMAL:
-					if update.Thermal != nil && update.Thermal.Position != nil {
-						lat = update.Thermal.Position.Latitude
-						lon = update.Thermal.Position.Longitude
-					}
-				case pb.UpdateType_UPDATE_TYPE_STATION:
-					if update.Station != nil && update.Station.Position != nil {
-						lat = update.Station.Position.Latitude
-						lon = update.Station.Position.Longitude
-					}
-				}
-				
-				dist := geo.Distance(info.centerLat, info.centerLon, lat, lon)
-				if dist <= info.radiusKm {
-					inRadius = true
-					break
-				}
-			}
-			
-			if inRadius {
-				select {
-				case client.send <- data:
-					recipients++
-				default:
-					// Client send buffer full, skip
-					bm.logger.WithField("client", client.conn.RemoteAddr()).Warn("Client send buffer full")
-				}
-			}
+	if info.client.protocol == ProtocolV2 {
+		data = wrapServerMessage(serverMessageBatch, data)
+	}
+
+	cache.frames[key.String()] = data
+	return data
+}
+
+// clientDelta decides how an update is sent to a delta client: nil delta means
+// a full object (first time, keyframe due, or not diffable), suppress means
+// nothing but time fields changed since the client's last state
+func (bm *BroadcastManager) clientDelta(info *ClientInfo, enc *encodedUpdate, cache *batchCache, now time.Time) (delta *deltaResult, suppress bool) {
+	state := info.sent[enc.key]
+	if state == nil || enc.action != pb.Action_ACTION_UPDATE || enc.msg == nil {
+		return nil, false
+	}
+	if state.deltas >= deltaKeyframeEvery || now.Sub(state.keyframeAt) >= deltaKeyframeInterval {
+		return nil, false
+	}
+
+	dk := deltaKey{index: enc.index, base: state.sequence}
+	delta, ok := cache.deltas[dk]
+	if !ok {
+		var err error
+		if delta, err = diffMessage(state.msg, enc.msg); err != nil {
+			bm.logger.WithError(err).WithField("object", enc.key).Warn("Failed to diff update, sending full object")
+			return nil, false
 		}
+		cache.deltas[dk] = delta
 	}
-	
-	group.lastUpdate = time.Now()
-	return recipients
+
+	if !delta.changed {
+		return nil, true
+	}
+	return delta, false
 }
 
 // updateBroadcastMetrics updates performance metrics
@@ -452,12 +865,24 @@ func (bm *BroadcastManager) metricsCollector() {
 			}
 		}
 		
+		// Forget delta states of objects that left the client's view
+		for _, info := range bm.clients {
+			for key, state := range info.sent {
+				if time.Since(state.sentAt) > deltaStateTTL {
+					delete(info.sent, key)
+				}
+			}
+		}
+		
 		// Update metrics
 		atomic.StoreUint64(&bm.metrics.ClientsActive, uint64(len(bm.clients)))
 		atomic.StoreUint64(&bm.metrics.GroupsActive, uint64(len(bm.groups)))
 		
 		bm.mu.Unlock()
 		
+		bm.journal.Prune()
+		metrics.WebSocketJournalUpdates.Set(float64(bm.journal.Size()))
+		
 		// Log metrics
 		bm.logger.WithFields(logrus.Fields{
 			"clients":             bm.metrics.ClientsActive,
@@ -22,9 +22,9 @@ func convertPilotsToProto(pilots []*models.Pilot) []*pb.Pilot {
 
 func convertPilotToProto(pilot *models.Pilot) *pb.Pilot {
 	// Конвертируем DeviceID из hex string в uint32
-	addr, _ := strconv.ParseUint(pilot.DeviceID, 16, 32)
+	addr, _ := models.DeviceAddr(pilot.DeviceID)
 
-	return &pb.Pilot{
+	result := &pb.Pilot{
 		Addr: uint32(addr),
 		Name: pilot.Name,
 		Type: pb.PilotType(pilot.Type), // Используем pilot.Type вместо pilot.AircraftType
@@ -40,6 +40,14 @@ func convertPilotToProto(pilot *models.Pilot) *pb.Pilot {
 		TrackOnline: pilot.TrackOnline,
 		Battery:     uint32(pilot.Battery),
 	}
+	if pilot.Reception != nil {
+		result.Receivers = uint32(pilot.Reception.Stations)
+		result.BestSnr = int32(pilot.Reception.BestSNR)
+	}
+	if pilot.Smoothed != nil {
+		result.Smoothed = pilot.Smoothed.ToProto()
+	}
+	return result
 }
 
 func convertGroundObjectsToProto(groundObjects []*models.GroundObject) []*pb.GroundObject {
@@ -52,7 +60,7 @@ func convertGroundObjectsToProto(groundObjects []*models.GroundObject) []*pb.Gro
 
 func convertGroundObjectToProto(groundObject *models.GroundObject) *pb.GroundObject {
 	// Конвертируем DeviceID из hex string в uint32
-	addr, _ := strconv.ParseUint(groundObject.DeviceID, 16, 32)
+	addr, _ := models.DeviceAddr(groundObject.DeviceID)
 
 	return &pb.GroundObject{
 		Addr: uint32(addr),
@@ -64,6 +72,7 @@ func convertGroundObjectToProto(groundObject *models.GroundObject) *pb.GroundObj
 		},
 		TrackOnline: groundObject.TrackOnline,
 		LastUpdate:  groundObject.LastUpdate.Unix(),
+		Emergency:   groundObject.IsEmergency(),
 	}
 }
 
@@ -79,7 +88,7 @@ func convertThermalsToProto(thermals []*models.Thermal) []*pb.Thermal {
 func convertThermalToProto(thermal *models.Thermal) *pb.Thermal {
 	// Конвертируем ID и ReportedBy
 	id, _ := strconv.ParseUint(thermal.ID, 10, 64)
-	addr, _ := strconv.ParseUint(thermal.ReportedBy, 16, 32)
+	addr, _ := models.DeviceAddr(thermal.ReportedBy)
 
 	return &pb.Thermal{
 		Id:   id,
@@ -90,10 +99,12 @@ func convertThermalToProto(thermal *models.Thermal) *pb.Thermal {
 			Altitude:  thermal.Position.Altitude,
 		},
 		Quality:     uint32(thermal.Quality),
-		Climb:       float32(thermal.ClimbRate) / 10, // ClimbRate в 0.1 м/с -> м/с
+		Climb:       thermal.ClimbRate,
 		WindSpeed:   float32(thermal.WindSpeed) / 3.6, // км/ч -> м/с
 		WindHeading: float32(thermal.WindDirection),
 		Timestamp:   thermal.Timestamp.Unix(),
+		Source:      thermal.Source,
+		Confidence:  thermal.Confidence,
 	}
 }
 
@@ -107,7 +118,7 @@ func convertStationsToProto(stations []*models.Station) []*pb.Station {
 
 func convertStationToProto(station *models.Station) *pb.Station {
 	// Конвертируем ID
-	addr, _ := strconv.ParseUint(station.ID, 16, 32)
+	addr, _ := models.DeviceAddr(station.ID)
 
 	return &pb.Station{
 		Addr: uint32(addr),
@@ -127,6 +138,22 @@ func convertStationToProto(station *models.Station) *pb.Station {
 	}
 }
 
+func convertMessagesToProto(messages []*models.Message) []*pb.Message {
+	result := make([]*pb.Message, len(messages))
+	for i, message := range messages {
+		result[i] = message.ToProto()
+	}
+	return result
+}
+
+func convertLandmarksToProto(landmarks []*models.Landmark) []*pb.Landmark {
+	result := make([]*pb.Landmark, len(landmarks))
+	for i, landmark := range landmarks {
+		result[i] = landmark.ToProto()
+	}
+	return result
+}
+
 func convertTrackToProto(points []models.GeoPoint) []*pb.TrackPoint {
 	result := make([]*pb.TrackPoint, len(points))
 	for i, point := range points {
@@ -152,6 +179,7 @@ func convertSnapshotToJSON(response *pb.SnapshotResponse) map[string]interface{}
 		"ground_objects": convertGroundObjectsToJSONArray(protoToModelsGroundObjects(response.GroundObjects)),
 		"thermals":       convertThermalsToJSONArray(protoToModelsThermals(response.Thermals)),
 		"stations":       convertStationsToJSONArray(protoToModelsStations(response.Stations)),
+		"landmarks":      convertLandmarksToGeoJSONFeatures(protoToModelsLandmarks(response.Landmarks)),
 		"sequence":       response.Sequence,
 	}
 }
@@ -165,9 +193,9 @@ func convertPilotsToJSONArray(pilots []*models.Pilot) []map[string]interface{} {
 }
 
 func convertPilotToJSON(pilot *models.Pilot) map[string]interface{} {
-	addr, _ := strconv.ParseUint(pilot.DeviceID, 16, 32)
+	addr, _ := models.DeviceAddr(pilot.DeviceID)
 	
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"addr": addr,
 		"name": pilot.Name,
 		"type": getAircraftTypeName(uint8(pilot.Type)), // Используем pilot.Type
@@ -183,6 +211,30 @@ func convertPilotToJSON(pilot *models.Pilot) map[string]interface{} {
 		"track_online": pilot.TrackOnline,
 		"battery":      pilot.Battery,
 	}
+	if pilot.Reception != nil {
+		result["receivers"] = pilot.Reception.Stations
+		result["best_snr"] = pilot.Reception.BestSNR
+	}
+	if pilot.Smoothed != nil {
+		result["smoothed"] = map[string]interface{}{
+			"position": map[string]interface{}{
+				"latitude":  pilot.Smoothed.Position.Latitude,
+				"longitude": pilot.Smoothed.Position.Longitude,
+			},
+			"altitude": pilot.Smoothed.Position.Altitude,
+			"speed":    pilot.Smoothed.Speed,
+			"course":   pilot.Smoothed.Heading,
+			"climb":    pilot.Smoothed.Climb,
+			"accuracy": pilot.Smoothed.Accuracy,
+			"predicted": map[string]interface{}{
+				"latitude":  pilot.Smoothed.Predicted.Latitude,
+				"longitude": pilot.Smoothed.Predicted.Longitude,
+				"altitude":  pilot.Smoothed.Predicted.Altitude,
+			},
+			"predicted_at": pilot.Smoothed.PredictedAt.Unix(),
+		}
+	}
+	return result
 }
 
 func convertGroundObjectsToJSONArray(groundObjects []*models.GroundObject) []map[string]interface{} {
@@ -194,7 +246,7 @@ func convertGroundObjectsToJSONArray(groundObjects []*models.GroundObject) []map
 }
 
 func convertGroundObjectToJSON(groundObject *models.GroundObject) map[string]interface{} {
-	addr, _ := strconv.ParseUint(groundObject.DeviceID, 16, 32)
+	addr, _ := models.DeviceAddr(groundObject.DeviceID)
 	
 	return map[string]interface{}{
 		"addr": addr,
@@ -206,6 +258,7 @@ func convertGroundObjectToJSON(groundObject *models.GroundObject) map[string]int
 		},
 		"last_update":  groundObject.LastUpdate.Unix(),
 		"track_online": groundObject.TrackOnline,
+		"emergency":    groundObject.IsEmergency(),
 	}
 }
 
@@ -219,7 +272,7 @@ func convertThermalsToJSONArray(thermals []*models.Thermal) []map[string]interfa
 
 func convertThermalToJSON(thermal *models.Thermal) map[string]interface{} {
 	id, _ := strconv.ParseUint(thermal.ID, 10, 64)
-	addr, _ := strconv.ParseUint(thermal.ReportedBy, 16, 32)
+	addr, _ := models.DeviceAddr(thermal.ReportedBy)
 
 	return map[string]interface{}{
 		"id":   id,
@@ -230,10 +283,12 @@ func convertThermalToJSON(thermal *models.Thermal) map[string]interface{} {
 			"altitude":  thermal.Position.Altitude,
 		},
 		"quality":      thermal.Quality,
-		"climb":        float32(thermal.ClimbRate) / 10,
+		"climb":        thermal.ClimbRate,
 		"wind_speed":   float32(thermal.WindSpeed) / 3.6,
 		"wind_heading": thermal.WindDirection,
 		"timestamp":    thermal.Timestamp.Unix(),
+		"source":       thermal.Source,
+		"confidence":   thermal.Confidence,
 	}
 }
 
@@ -246,7 +301,7 @@ func convertStationsToJSONArray(stations []*models.Station) []map[string]interfa
 }
 
 func convertStationToJSON(station *models.Station) map[string]interface{} {
-	addr, _ := strconv.ParseUint(station.ID, 16, 32)
+	addr, _ := models.DeviceAddr(station.ID)
 
 	return map[string]interface{}{
 		"addr": addr,
@@ -266,6 +321,136 @@ func convertStationToJSON(station *models.Station) map[string]interface{} {
 	}
 }
 
+func convertMessagesToJSONArray(messages []*models.Message) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(messages))
+	for i, message := range messages {
+		result[i] = convertMessageToJSON(message)
+	}
+	return result
+}
+
+func convertMessageToJSON(message *models.Message) map[string]interface{} {
+	from, _ := models.DeviceAddr(message.From)
+	to, _ := models.DeviceAddr(message.To)
+
+	result := map[string]interface{}{
+		"id":        message.ID,
+		"from_addr": from,
+		"to_addr":   to,
+		"broadcast": message.IsBroadcast(),
+		"subheader": message.Subheader,
+		"text":      message.Text,
+		"timestamp": message.Timestamp.Unix(),
+	}
+
+	if message.Position != nil {
+		result["position"] = map[string]interface{}{
+			"latitude":  message.Position.Latitude,
+			"longitude": message.Position.Longitude,
+			"altitude":  message.Position.Altitude,
+		}
+	}
+
+	return result
+}
+
+// convertLandmarksToGeoJSON конвертирует ориентиры в GeoJSON FeatureCollection
+func convertLandmarksToGeoJSON(landmarks []*models.Landmark) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": convertLandmarksToGeoJSONFeatures(landmarks),
+	}
+}
+
+func convertLandmarksToGeoJSONFeatures(landmarks []*models.Landmark) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(landmarks))
+	for i, landmark := range landmarks {
+		result[i] = convertLandmarkToGeoJSONFeature(landmark)
+	}
+	return result
+}
+
+// convertLandmarkToGeoJSONFeature конвертирует ориентир в GeoJSON Feature.
+// Text -> Point, Line/Arrow -> LineString, Area -> Polygon,
+// Circle -> Point/MultiPoint с радиусами в properties (GeoJSON не поддерживает круги)
+func convertLandmarkToGeoJSONFeature(landmark *models.Landmark) map[string]interface{} {
+	coordinates := make([][]float64, len(landmark.Points))
+	for i, point := range landmark.Points {
+		coordinates[i] = []float64{point.Longitude, point.Latitude}
+	}
+
+	addr, _ := models.DeviceAddr(landmark.ReportedBy)
+	properties := map[string]interface{}{
+		"id":         landmark.ID,
+		"addr":       addr,
+		"type":       landmark.Type.String(),
+		"layer":      landmark.Layer.String(),
+		"timestamp":  landmark.Timestamp.Unix(),
+		"expires_at": landmark.ExpiresAt.Unix(),
+	}
+	if landmark.Text != "" {
+		properties["text"] = landmark.Text
+	}
+	if landmark.WindSectors != 0 {
+		properties["wind_sectors"] = landmark.WindSectors
+	}
+	if landmark.Type.IsArea() || landmark.Type == models.LandmarkTypeCircleFilled {
+		properties["filled"] = landmark.Type != models.LandmarkTypeArea
+	}
+
+	var geometry map[string]interface{}
+	switch {
+	case landmark.Type == models.LandmarkTypeLine || landmark.Type == models.LandmarkTypeArrow:
+		geometry = map[string]interface{}{
+			"type":        "LineString",
+			"coordinates": coordinates,
+		}
+	case landmark.Type.IsArea():
+		// Полигон в GeoJSON должен быть замкнут
+		ring := coordinates
+		if len(ring) > 0 {
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				ring = append(ring, first)
+			}
+		}
+		geometry = map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{ring},
+		}
+	case landmark.Type.IsCircle():
+		properties["radius"] = landmark.Radii
+		if len(coordinates) == 1 && len(landmark.Radii) == 1 {
+			properties["radius"] = landmark.Radii[0]
+			geometry = map[string]interface{}{
+				"type":        "Point",
+				"coordinates": coordinates[0],
+			}
+		} else {
+			geometry = map[string]interface{}{
+				"type":        "MultiPoint",
+				"coordinates": coordinates,
+			}
+		}
+	default:
+		var point []float64
+		if len(coordinates) > 0 {
+			point = coordinates[0]
+		}
+		geometry = map[string]interface{}{
+			"type":        "Point",
+			"coordinates": point,
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "Feature",
+		"id":         landmark.ID,
+		"properties": properties,
+		"geometry":   geometry,
+	}
+}
+
 func convertTrackToJSON(track *pb.Track) map[string]interface{} {
 	points := make([]map[string]interface{}, len(track.Points))
 	for i, point := range track.Points {
@@ -379,6 +564,8 @@ func protoToModelsThermals(thermals []*pb.Thermal) []*models.Thermal {
 			WindSpeed:     uint8(thermal.WindSpeed * 3.6),
 			WindDirection: uint16(thermal.WindHeading),
 			Timestamp:     time.Unix(thermal.Timestamp, 0),
+			Source:        thermal.Source,
+			Confidence:    thermal.Confidence,
 		}
 	}
 	return result
@@ -492,10 +679,13 @@ func applyTrackFilters(points []models.GeoPoint, deviceID string, aircraftType m
 	return filterChain.Filter(trackData)
 }
 
-// applyTrackFiltersWithTimestamps применяет фильтры к треку с временными метками
-func applyTrackFiltersWithTimestamps(points []models.TrackGeoPoint, deviceID string, aircraftType models.PilotType, filterLevel int, logger *utils.Logger) (*filter.FilterResult, error) {
+// applyTrackFiltersWithTimestamps применяет фильтры к треку с временными метками.
+// Цепочки level1-level3 из chains заменяют встроенные уровни (chains может быть nil).
+// debug - собрать отклоненные точки в FilterResult.Rejected
+func applyTrackFiltersWithTimestamps(points []models.TrackGeoPoint, deviceID string, aircraftType models.PilotType, filterLevel int, chains *filter.ChainSet, debug bool, logger *utils.Logger) (*filter.FilterResult, error) {
 	// Создаем конфигурацию фильтров
 	config := filter.DefaultFilterConfig()
+	config.CollectRejected = debug
 	
 	// Конвертируем данные для фильтрации
 	trackData := convertTrackGeoPointsToTrackData(points, deviceID, aircraftType)
@@ -511,24 +701,33 @@ func applyTrackFiltersWithTimestamps(points []models.TrackGeoPoint, deviceID str
 	}
 	
 	// Выбираем цепочку фильтров в зависимости от уровня
-	var filterChain filter.TrackFilter
-	
-	switch filterLevel {
-	case 1:
-		filterChain = filter.NewLevel1FilterChain(config, logger)
-	case 2:
-		filterChain = filter.NewLevel2FilterChain(config, logger)
-	case 3:
-		filterChain = filter.NewLevel3FilterChain(config, logger)
-	default:
-		// Для неизвестных уровней используем уровень 1
-		filterChain = filter.NewLevel1FilterChain(config, logger)
-	}
+	filterChain := chains.LevelChain(filterLevel, config, logger)
 	
 	// Применяем фильтры
 	return filterChain.Filter(trackData)
 }
 
+// filterTrackWithTimestamps оставляет точки исходного трека, не отброшенные фильтрами.
+// Фильтры не переносят барометрическую высоту, поэтому точки сопоставляются по времени
+func filterTrackWithTimestamps(points []models.TrackGeoPoint, filterResult *filter.FilterResult) []models.TrackGeoPoint {
+	kept := make(map[int64]bool, len(filterResult.Points))
+	for _, trackPoint := range filterResult.Points {
+		if !trackPoint.Filtered {
+			kept[trackPoint.Timestamp.UnixNano()] = true
+		}
+	}
+
+	result := make([]models.TrackGeoPoint, 0, len(kept))
+	for _, point := range points {
+		if kept[point.Timestamp.UnixNano()] {
+			result = append(result, point)
+			// Из точек с одинаковым временем остается первая
+			delete(kept, point.Timestamp.UnixNano())
+		}
+	}
+	return result
+}
+
 // convertFilteredTrackToGeoPoints конвертирует отфильтрованный трек обратно в GeoPoint
 func convertFilteredTrackToGeoPoints(filterResult *filter.FilterResult) []models.GeoPoint {
 	points := make([]models.GeoPoint, 0, len(filterResult.Points))
@@ -592,7 +791,7 @@ func convertTrackToGeoJSONWithFilter(track *pb.Track, filterResult *filter.Filte
 		}
 	}
 	
-	return geoJSON
+	return appendRejectedFeatures(geoJSON, filterResult)
 }
 
 // convertTrackToGeoJSONWithSegments создает GeoJSON с MultiLineString для сегментированного трека
@@ -709,7 +908,7 @@ func convertTrackToGeoJSONWithSegments(track *pb.Track, filterResult *filter.Fil
 	}
 	
 	// GeoJSON FeatureCollection с множественными сегментами
-	return map[string]interface{}{
+	return appendRejectedFeatures(map[string]interface{}{
 		"type": "FeatureCollection",
 		"properties": map[string]interface{}{
 			"addr":              track.Addr,
@@ -728,7 +927,7 @@ func convertTrackToGeoJSONWithSegments(track *pb.Track, filterResult *filter.Fil
 			"max_distance_jump": filterResult.Statistics.MaxDistanceJump,
 		},
 		"features": features,
-	}
+	}, filterResult)
 }
 
 // convertTrackToJSONWithFilter создает JSON с информацией о фильтрации  
@@ -752,10 +951,72 @@ func convertTrackToJSONWithFilter(track *pb.Track, filterResult *filter.FilterRe
 		"avg_speed":            filterResult.Statistics.AvgSpeed,
 		"max_distance_jump":    filterResult.Statistics.MaxDistanceJump,
 	}
+
+	// Режим диагностики: отклоненные точки с фильтром и причиной
+	if len(filterResult.Rejected) > 0 {
+		trackData["filter_statistics"].(map[string]interface{})["rejected_by_filter"] = filterResult.Statistics.RejectedByFilter
+		rejected := make([]map[string]interface{}, len(filterResult.Rejected))
+		for i, point := range filterResult.Rejected {
+			rejected[i] = map[string]interface{}{
+				"position": map[string]interface{}{
+					"latitude":  point.Position.Latitude,
+					"longitude": point.Position.Longitude,
+				},
+				"speed":     point.Speed,
+				"timestamp": point.Timestamp.Unix(),
+				"filter":    point.Filter,
+				"reason":    point.Reason,
+				"kind":      point.Kind,
+			}
+		}
+		trackData["rejected"] = rejected
+	}
 	
 	return result
 }
 
+// rejectedPointColor цвет отклоненных точек на карте
+const rejectedPointColor = "#9E9E9E"
+
+// appendRejectedFeatures добавляет отклоненные фильтрами точки (режим диагностики)
+// в GeoJSON как Point features с rejected: true
+func appendRejectedFeatures(geoJSON map[string]interface{}, filterResult *filter.FilterResult) map[string]interface{} {
+	if len(filterResult.Rejected) == 0 {
+		return geoJSON
+	}
+
+	// Сводка по фильтрам - в свойствах коллекции (сегменты) или линии трека
+	features, _ := geoJSON["features"].([]map[string]interface{})
+	properties, ok := geoJSON["properties"].(map[string]interface{})
+	if !ok && len(features) > 0 {
+		properties, _ = features[0]["properties"].(map[string]interface{})
+	}
+	if properties != nil {
+		properties["rejected_by_filter"] = filterResult.Statistics.RejectedByFilter
+	}
+
+	for _, point := range filterResult.Rejected {
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"properties": map[string]interface{}{
+				"rejected":  true,
+				"color":     rejectedPointColor,
+				"timestamp": point.Timestamp.Unix(),
+				"speed":     point.Speed,
+				"filter":    point.Filter,
+				"reason":    point.Reason,
+				"kind":      point.Kind,
+			},
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{point.Position.Longitude, point.Position.Latitude},
+			},
+		})
+	}
+	geoJSON["features"] = features
+	return geoJSON
+}
+
 // getAircraftTypeFromAircraft конвертирует uint8 в PilotType
 func getAircraftTypeFromAircraft(aircraftType uint8) models.PilotType {
 	// FANET значения напрямую соответствуют PilotType enum
@@ -783,6 +1044,61 @@ func protoToModelsGroundObjects(groundObjects []*pb.GroundObject) []*models.Grou
 	return result
 }
 
+func protoToModelsLandmarks(landmarks []*pb.Landmark) []*models.Landmark {
+	result := make([]*models.Landmark, len(landmarks))
+	for i, landmark := range landmarks {
+		points := make([]models.GeoPoint, len(landmark.Points))
+		for j, point := range landmark.Points {
+			points[j] = models.GeoPoint{
+				Latitude:  point.Latitude,
+				Longitude: point.Longitude,
+				Altitude:  point.Altitude,
+			}
+		}
+
+		var radii []float64
+		for _, radius := range landmark.Radii {
+			radii = append(radii, float64(radius))
+		}
+
+		result[i] = &models.Landmark{
+			ID:          landmark.Id,
+			ReportedBy:  formatAddr(landmark.Addr),
+			Type:        models.LandmarkType(landmark.Type),
+			Layer:       models.LandmarkLayer(landmark.Layer),
+			Points:      points,
+			Radii:       radii,
+			Text:        landmark.Text,
+			WindSectors: uint8(landmark.WindSectors),
+			Timestamp:   time.Unix(landmark.Timestamp, 0),
+			ExpiresAt:   time.Unix(landmark.ExpiresAt, 0),
+		}
+	}
+	return result
+}
+
+// protoToModelsEstimate конвертирует оценку фильтра Калмана, nil - сглаживания нет
+func protoToModelsEstimate(estimate *pb.PilotEstimate) *models.PilotEstimate {
+	if estimate == nil {
+		return nil
+	}
+
+	result := &models.PilotEstimate{
+		Speed:       estimate.Speed,
+		Heading:     estimate.Course,
+		Climb:       estimate.Climb,
+		Accuracy:    estimate.Accuracy,
+		PredictedAt: time.Unix(estimate.PredictedAt, 0),
+	}
+	if p := estimate.Position; p != nil {
+		result.Position = models.GeoPoint{Latitude: p.Latitude, Longitude: p.Longitude, Altitude: p.Altitude}
+	}
+	if p := estimate.Predicted; p != nil {
+		result.Predicted = models.GeoPoint{Latitude: p.Latitude, Longitude: p.Longitude, Altitude: p.Altitude}
+	}
+	return result
+}
+
 func getGroundTypeName(t uint8) string {
 	// FANET спецификация для наземных объектов
 	switch t {
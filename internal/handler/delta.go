@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"bytes"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// deltaKeyframeEvery после стольких delta подряд объект отправляется целиком
+	deltaKeyframeEvery = 20
+	// deltaKeyframeInterval максимальный интервал между полными состояниями объекта
+	deltaKeyframeInterval = time.Minute
+	// deltaStateTTL состояние объекта, не отправлявшегося дольше, забывается
+	deltaStateTTL = 10 * time.Minute
+)
+
+// deltaVolatileFields поля времени, изменение только которых не считается
+// изменением объекта: такие обновления подавляются до следующего keyframe
+var deltaVolatileFields = map[protoreflect.Name]bool{
+	"last_update": true,
+	"timestamp":   true,
+}
+
+// sentState последнее состояние объекта, отправленное клиенту
+type sentState struct {
+	msg        proto.Message
+	sequence   uint64 // Последовательность обновления, которое содержит msg
+	deltas     int    // delta после последнего полного состояния
+	keyframeAt time.Time
+	sentAt     time.Time
+}
+
+// deltaKey идентифицирует разницу между двумя состояниями объекта в пределах батча
+type deltaKey struct {
+	index int    // encodedUpdate.index целевого состояния
+	base  uint64 // Последовательность состояния клиента
+}
+
+// deltaResult сериализованная разница состояний
+type deltaResult struct {
+	data    []byte
+	cleared []uint32
+	changed bool
+}
+
+// batchCache разделяет сериализацию между клиентами в пределах одного батча:
+// готовые фреймы по содержимому и delta по базовому состоянию
+type batchCache struct {
+	frames map[string][]byte
+	deltas map[deltaKey]*deltaResult
+}
+
+func newBatchCache() *batchCache {
+	return &batchCache{
+		frames: make(map[string][]byte),
+		deltas: make(map[deltaKey]*deltaResult),
+	}
+}
+
+// diffMessage сериализует поля current, отличающиеся от previous, и поле 1
+// (addr/id), по которому клиент находит объект. cleared - номера полей,
+// которые были заданы в previous и сброшены в current. changed = false, если
+// изменились только поля времени (deltaVolatileFields)
+func diffMessage(previous, current proto.Message) (*deltaResult, error) {
+	prev, cur := previous.ProtoReflect(), current.ProtoReflect()
+	delta := cur.New()
+	result := &deltaResult{}
+
+	fields := cur.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		has, had := cur.Has(fd), prev.Has(fd)
+
+		switch {
+		case fd.Number() == 1:
+			if has {
+				delta.Set(fd, cur.Get(fd))
+			}
+		case has && (!had || !fieldEqual(fd, prev.Get(fd), cur.Get(fd))):
+			delta.Set(fd, cur.Get(fd))
+			if !deltaVolatileFields[fd.Name()] {
+				result.changed = true
+			}
+		case !has && had:
+			result.cleared = append(result.cleared, uint32(fd.Number()))
+			if !deltaVolatileFields[fd.Name()] {
+				result.changed = true
+			}
+		}
+	}
+
+	data, err := proto.Marshal(delta.Interface())
+	if err != nil {
+		return nil, err
+	}
+	result.data = data
+	return result, nil
+}
+
+// fieldEqual сравнивает значения поля fd двух сообщений
+func fieldEqual(fd protoreflect.FieldDescriptor, a, b protoreflect.Value) bool {
+	switch {
+	case fd.IsList():
+		la, lb := a.List(), b.List()
+		if la.Len() != lb.Len() {
+			return false
+		}
+		for i := 0; i < la.Len(); i++ {
+			if !singularEqual(fd, la.Get(i), lb.Get(i)) {
+				return false
+			}
+		}
+		return true
+
+	case fd.IsMap():
+		ma, mb := a.Map(), b.Map()
+		if ma.Len() != mb.Len() {
+			return false
+		}
+		equal := true
+		ma.Range(func(key protoreflect.MapKey, va protoreflect.Value) bool {
+			vb := mb.Get(key)
+			equal = vb.IsValid() && singularEqual(fd.MapValue(), va, vb)
+			return equal
+		})
+		return equal
+
+	default:
+		return singularEqual(fd, a, b)
+	}
+}
+
+// singularEqual сравнивает одиночные значения (элементы списков и карт)
+func singularEqual(fd protoreflect.FieldDescriptor, a, b protoreflect.Value) bool {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return proto.Equal(a.Message().Interface(), b.Message().Interface())
+	case protoreflect.BytesKind:
+		return bytes.Equal(a.Bytes(), b.Bytes())
+	default:
+		return a.Interface() == b.Interface()
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/geo"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// movingPilot создает обновление пилота ABC123 с заданной позицией, набором и временем
+func movingPilot(lat float64, climb int16, ts time.Time) *UpdatePacket {
+	update := pilotUpdate("ABC123", lat, 13.0)
+	update.Pilot.DeviceID = "ABC123"
+	update.Pilot.Name = "Test Pilot"
+	update.Pilot.Speed = 36
+	update.Pilot.ClimbRate = climb
+	update.Pilot.LastUpdate = ts
+	return update
+}
+
+func TestDiffMessage(t *testing.T) {
+	ts := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	base := movingPilot(46.0, 15, ts).Pilot.ToProto()
+
+	// Сдвиг и сброс набора в ноль
+	moved := movingPilot(46.001, 0, ts.Add(time.Second)).Pilot.ToProto()
+	delta, err := diffMessage(base, moved)
+	require.NoError(t, err)
+	assert.True(t, delta.changed)
+	assert.Equal(t, []uint32{7}, delta.cleared, "climb")
+
+	var pilot pb.Pilot
+	require.NoError(t, proto.Unmarshal(delta.data, &pilot))
+	assert.Equal(t, uint32(0xABC123), pilot.Addr, "identity is always present")
+	assert.Equal(t, 46.001, pilot.GetPosition().GetLatitude())
+	assert.Equal(t, ts.Add(time.Second).Unix(), pilot.LastUpdate)
+	assert.Empty(t, pilot.Name)
+	assert.Zero(t, pilot.Speed)
+
+	// Клиент восстанавливает полное состояние слиянием
+	merged := proto.Clone(base).(*pb.Pilot)
+	proto.Merge(merged, &pilot)
+	merged.Climb = 0
+	assert.True(t, proto.Equal(moved, merged))
+
+	// Изменилось только время
+	delta, err = diffMessage(base, movingPilot(46.0, 15, ts.Add(time.Second)).Pilot.ToProto())
+	require.NoError(t, err)
+	assert.False(t, delta.changed)
+}
+
+func TestBroadcastManager_DeltaUpdates(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	sub := NewCircleSubscription("", 46.0, 13.0, 20)
+
+	deltaClient := newTestClient(t, ProtocolV1)
+	deltaClient.delta = true
+	fullClient := newTestClient(t, ProtocolV1)
+	bm.handleRegister(&ClientRegistration{client: deltaClient, connect: true, subscription: sub})
+	bm.handleRegister(&ClientRegistration{client: fullClient, connect: true, subscription: sub})
+
+	ts := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	// Первое обновление - полный объект
+	bm.processBatch([]*UpdatePacket{movingPilot(46.0, 15, ts)})
+	first := receiveBatch(t, deltaClient).Updates[0]
+	assert.False(t, first.Delta)
+	assert.Equal(t, first.Data, receiveBatch(t, fullClient).Updates[0].Data)
+
+	// Следующее - только изменившиеся поля
+	bm.processBatch([]*UpdatePacket{movingPilot(46.001, 15, ts.Add(time.Second))})
+	second := receiveBatch(t, deltaClient).Updates[0]
+	full := receiveBatch(t, fullClient).Updates[0]
+	assert.True(t, second.Delta)
+	assert.False(t, full.Delta)
+	assert.Less(t, len(second.Data), len(full.Data))
+
+	// Без изменений, кроме времени - подавляется
+	bm.processBatch([]*UpdatePacket{movingPilot(46.001, 15, ts.Add(2*time.Second))})
+	assert.Empty(t, deltaClient.send)
+	receiveBatch(t, fullClient)
+
+	// Keyframe по интервалу
+	bm.clients[deltaClient].sent["UPDATE_TYPE_PILOT:ABC123"].keyframeAt = ts.Add(-2 * deltaKeyframeInterval)
+	bm.processBatch([]*UpdatePacket{movingPilot(46.002, 15, ts.Add(3*time.Second))})
+	keyframe := receiveBatch(t, deltaClient).Updates[0]
+	assert.False(t, keyframe.Delta)
+	var pilot pb.Pilot
+	require.NoError(t, proto.Unmarshal(keyframe.Data, &pilot))
+	assert.Equal(t, "Test Pilot", pilot.Name)
+
+	// Keyframe после deltaKeyframeEvery delta подряд
+	fullCount := 0
+	for i := 1; i <= deltaKeyframeEvery+1; i++ {
+		bm.processBatch([]*UpdatePacket{movingPilot(46.002+float64(i)*0.001, 15, ts.Add(time.Duration(3+i)*time.Second))})
+		if !receiveBatch(t, deltaClient).Updates[0].Delta {
+			fullCount++
+		}
+	}
+	assert.Equal(t, 1, fullCount)
+}
+
+func TestBroadcastManager_DeltaResetOnDroppedBatch(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	client := newTestClient(t, ProtocolV1)
+	client.delta = true
+	client.send = make(chan []byte, 1)
+	bm.handleRegister(&ClientRegistration{client: client, connect: true, subscription: NewCircleSubscription("", 46.0, 13.0, 20)})
+
+	ts := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	bm.processBatch([]*UpdatePacket{movingPilot(46.0, 15, ts)})
+
+	// Очередь переполнена: batch теряется, состояние клиента сбрасывается
+	bm.processBatch([]*UpdatePacket{movingPilot(46.001, 15, ts.Add(time.Second))})
+	assert.Empty(t, bm.clients[client].sent)
+
+	receiveBatch(t, client)
+	bm.processBatch([]*UpdatePacket{movingPilot(46.002, 15, ts.Add(2*time.Second))})
+	assert.False(t, receiveBatch(t, client).Updates[0].Delta)
+}
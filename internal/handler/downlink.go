@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/flybeeper/fanet-backend/internal/auth"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DownlinkPublisher публикует сообщения в MQTT (реализуется mqtt.Client)
+type DownlinkPublisher interface {
+	PublishMessage(topic string, payload []byte, qos byte, retained bool) error
+}
+
+// DownlinkHandler отправляет FANET пакеты устройствам через downlink топик базовой станции
+type DownlinkHandler struct {
+	encoder       *mqtt.Encoder
+	publisher     DownlinkPublisher
+	publisherMu   sync.RWMutex
+	topicTemplate string
+	source        string
+	logger        *utils.Logger
+}
+
+// NewDownlinkHandler создает обработчик downlink. Публикатор устанавливается позже
+// через SetPublisher, так как MQTT клиент создается после HTTP сервера
+func NewDownlinkHandler(topicTemplate, source string, logger *utils.Logger) *DownlinkHandler {
+	return &DownlinkHandler{
+		encoder:       mqtt.NewEncoder(),
+		topicTemplate: topicTemplate,
+		source:        source,
+		logger:        logger,
+	}
+}
+
+// SetPublisher устанавливает MQTT публикатор
+func (h *DownlinkHandler) SetPublisher(publisher DownlinkPublisher) {
+	h.publisherMu.Lock()
+	defer h.publisherMu.Unlock()
+	h.publisher = publisher
+}
+
+// DownlinkRequest запрос на отправку FANET пакета.
+// Заполняется только поле полезной нагрузки, соответствующее типу
+type DownlinkRequest struct {
+	ChipID      string `json:"chip_id" binding:"required"` // ID базовой станции
+	Type        uint8  `json:"type" binding:"required"`    // Тип FANET пакета (1, 2, 3, 4, 6)
+	Source      string `json:"source,omitempty"`           // Адрес отправителя (по умолчанию MQTT_DOWNLINK_SOURCE)
+	Destination string `json:"destination,omitempty"`      // Адрес получателя (только Type 3 и 6), пусто для broadcast
+	Forward     bool   `json:"forward,omitempty"`          // Разрешить ретрансляцию
+
+	Tracking     *mqtt.AirTrackingData  `json:"tracking,omitempty"`      // Type 1
+	Name         *mqtt.NameData         `json:"name,omitempty"`          // Type 2
+	Message      *mqtt.MessageData      `json:"message,omitempty"`       // Type 3
+	Service      *DownlinkService       `json:"service,omitempty"`       // Type 4
+	RemoteConfig *mqtt.RemoteConfigData `json:"remote_config,omitempty"` // Type 6
+}
+
+// DownlinkService сервисные данные (Type 4) в запросе
+type DownlinkService struct {
+	ServiceHeader uint8             `json:"service_header"`
+	Latitude      float64           `json:"latitude"`
+	Longitude     float64           `json:"longitude"`
+	Weather       *mqtt.WeatherData `json:"weather,omitempty"`
+}
+
+// payload возвращает данные полезной нагрузки для кодировщика (nil если не заданы)
+func (r *DownlinkRequest) payload() interface{} {
+	switch {
+	case r.Type == 1 && r.Tracking != nil:
+		return r.Tracking
+	case r.Type == 2 && r.Name != nil:
+		return r.Name
+	case r.Type == 3 && r.Message != nil:
+		return r.Message
+	case r.Type == 4 && r.Service != nil:
+		service := &mqtt.ServiceData{
+			ServiceHeader: r.Service.ServiceHeader,
+			Latitude:      r.Service.Latitude,
+			Longitude:     r.Service.Longitude,
+		}
+		if r.Service.Weather != nil {
+			service.Data = r.Service.Weather
+		}
+		return service
+	case r.Type == 6 && r.RemoteConfig != nil:
+		return r.RemoteConfig
+	default:
+		return nil
+	}
+}
+
+// PublishFrame кодирует и публикует FANET пакет
+// POST /api/v1/admin/downlink
+func (h *DownlinkHandler) PublishFrame(c *gin.Context) {
+	h.publisherMu.RLock()
+	publisher := h.publisher
+	h.publisherMu.RUnlock()
+
+	if publisher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "mqtt_unavailable",
+			"message": "MQTT client is not available",
+		})
+		return
+	}
+
+	var req DownlinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_request",
+			"message": "Request must contain chip_id and type",
+		})
+		return
+	}
+
+	// ID базовой станции подставляется в топик, запрещаем разделители и wildcard
+	if strings.ContainsAny(req.ChipID, "/+#") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_chip_id",
+			"message": "chip_id must not contain MQTT topic separators or wildcards",
+		})
+		return
+	}
+
+	payload := req.payload()
+	if payload == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "missing_payload",
+			"message": "Payload for FANET type " + strconv.Itoa(int(req.Type)) + " is missing or type is not supported",
+		})
+		return
+	}
+
+	source := req.Source
+	if source == "" {
+		source = h.source
+	}
+
+	frame, err := h.encoder.Encode(&mqtt.Frame{
+		Type:        req.Type,
+		Source:      source,
+		Destination: req.Destination,
+		Forward:     req.Forward,
+		Data:        payload,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "encode_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	typeLabel := strconv.Itoa(int(req.Type))
+	topic := strings.ReplaceAll(h.topicTemplate, "{chip_id}", req.ChipID)
+
+	if err := publisher.PublishMessage(topic, frame, 1, false); err != nil {
+		metrics.DownlinkFrames.WithLabelValues(typeLabel, "error").Inc()
+		h.logger.WithField("error", err).WithField("topic", topic).Error("Failed to publish downlink frame")
+		c.JSON(http.StatusBadGateway, gin.H{
+			"code":    "publish_error",
+			"message": "Failed to publish frame to MQTT",
+		})
+		return
+	}
+
+	metrics.DownlinkFrames.WithLabelValues(typeLabel, "success").Inc()
+
+	userID, _ := auth.GetUserID(c)
+	h.logger.WithFields(map[string]interface{}{
+		"topic":       topic,
+		"fanet_type":  req.Type,
+		"destination": req.Destination,
+		"user_id":     userID,
+	}).Info("Downlink frame published")
+
+	c.JSON(http.StatusOK, gin.H{
+		"topic": topic,
+		"frame": hex.EncodeToString(frame),
+	})
+}
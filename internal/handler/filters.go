@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// FilterHandler отдает доступные фильтры трека и запускает цепочки фильтров
+// над сохраненными треками для настройки фильтрации
+type FilterHandler struct {
+	source  service.FilterTrackSource
+	chains  *filter.ChainSet
+	mu      sync.RWMutex
+	logger  *utils.Logger
+	timeout time.Duration
+}
+
+// NewFilterHandler создает обработчик. Зависимости устанавливаются через SetSource/SetChains
+func NewFilterHandler(logger *utils.Logger) *FilterHandler {
+	return &FilterHandler{
+		logger:  logger,
+		timeout: 30 * time.Second,
+	}
+}
+
+// SetSource устанавливает хранилище треков
+func (h *FilterHandler) SetSource(source service.FilterTrackSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.source = source
+}
+
+// SetChains устанавливает цепочки фильтров из конфигурации
+func (h *FilterHandler) SetChains(chains *filter.ChainSet) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.chains = chains
+}
+
+// dependencies возвращает текущие зависимости
+func (h *FilterHandler) dependencies() (service.FilterTrackSource, *filter.ChainSet) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.source, h.chains
+}
+
+// GetFilters возвращает фильтры, доступные в цепочках, и цепочки из конфигурации
+// GET /api/v1/admin/filters
+func (h *FilterHandler) GetFilters(c *gin.Context) {
+	_, chains := h.dependencies()
+
+	specs := chains.Specs()
+	if specs == nil {
+		specs = []*filter.ChainSpec{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"filters": filter.AvailableFilters(h.logger),
+		"chains":  specs,
+		"builtin": []string{"level1", "level2", "level3"},
+	})
+}
+
+// FilterRunBody запрос на запуск цепочки фильтров. Задается chain (имя цепочки из
+// конфигурации или level1-level3) либо filters (произвольная цепочка)
+type FilterRunBody struct {
+	Addr    string                  `json:"addr" binding:"required"` // FANET адрес в hex
+	From    *time.Time              `json:"from,omitempty"`          // По умолчанию to - 12 часов
+	To      *time.Time              `json:"to,omitempty"`            // По умолчанию текущее время
+	Chain   string                  `json:"chain,omitempty"`
+	Filters []filter.StepSpec       `json:"filters,omitempty"`
+	Config  *filter.ConfigOverrides `json:"config,omitempty"`
+}
+
+// RunFilters применяет цепочку фильтров к треку за период и возвращает статистику
+// и удаленные точки каждого фильтра
+// POST /api/v1/admin/filters/run
+func (h *FilterHandler) RunFilters(c *gin.Context) {
+	source, chains := h.dependencies()
+	if source == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "history_unavailable",
+			"message": "Track history is not available",
+		})
+		return
+	}
+
+	var body FilterRunBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	request, err := body.toRequest()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_parameter",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	run, err := service.NewFilterRunner(source, chains, h.logger, maxTrackRangePoints).Run(ctx, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilterChain) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_chain",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.WithField("error", err).WithField("addr", request.DeviceID).Error("Failed to run filter chain")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to run filter chain",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// toRequest проверяет тело запроса и применяет значения по умолчанию
+func (b *FilterRunBody) toRequest() (*service.FilterRunRequest, error) {
+	deviceID, err := models.ParseDeviceID(b.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("addr must be a FANET address in hex format or a prefixed OGN/FLARM id")
+	}
+	if b.Chain == "" && len(b.Filters) == 0 {
+		return nil, fmt.Errorf("chain or filters is required")
+	}
+	if b.Chain != "" && len(b.Filters) > 0 {
+		return nil, fmt.Errorf("chain and filters are mutually exclusive")
+	}
+
+	to := time.Now().UTC()
+	if b.To != nil {
+		to = *b.To
+	}
+	from := to.Add(-defaultTrackRange)
+	if b.From != nil {
+		from = *b.From
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > maxTrackRange {
+		return nil, fmt.Errorf("time range must not exceed %d days", int(maxTrackRange.Hours()/24))
+	}
+
+	return &service.FilterRunRequest{
+		DeviceID: deviceID,
+		From:     from,
+		To:       to,
+		Chain:    b.Chain,
+		Filters:  b.Filters,
+		Config:   b.Config,
+	}, nil
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/export"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultFlightsLimit  = 20
+	maxFlightsLimit      = 100
+	maxFlightTrackPoints = 20000 // Максимум точек трека одного полета
+)
+
+// FlightHistory хранилище полетов и треков (реализуется repository.MySQLRepository)
+type FlightHistory interface {
+	GetFlights(ctx context.Context, deviceID string, limit int) ([]*models.Flight, error)
+	GetFlight(ctx context.Context, id int64) (*models.Flight, error)
+	GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error)
+	GetPilotName(ctx context.Context, deviceID string) (string, error)
+}
+
+// FlightHandler отдает полеты, определенные по живым трекам
+type FlightHandler struct {
+	history FlightHistory
+	tracker *service.FlightTracker
+	mu      sync.RWMutex
+	logger  *utils.Logger
+	timeout time.Duration
+}
+
+// NewFlightHandler создает обработчик. Зависимости устанавливаются через SetHistory/SetTracker
+func NewFlightHandler(logger *utils.Logger) *FlightHandler {
+	return &FlightHandler{
+		logger:  logger,
+		timeout: 10 * time.Second,
+	}
+}
+
+// SetHistory устанавливает хранилище полетов
+func (h *FlightHandler) SetHistory(history FlightHistory) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = history
+}
+
+// SetTracker устанавливает трекер текущих полетов
+func (h *FlightHandler) SetTracker(tracker *service.FlightTracker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tracker = tracker
+}
+
+// dependencies возвращает текущие зависимости
+func (h *FlightHandler) dependencies() (FlightHistory, *service.FlightTracker) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.history, h.tracker
+}
+
+// GetFlights возвращает полеты устройства, новые первыми. Статистика
+// текущего полета берется из трекера на момент последней позиции.
+// Без параметра device возвращает все текущие полеты
+// GET /api/v1/flights?device=ABC123&limit=20
+func (h *FlightHandler) GetFlights(c *gin.Context) {
+	history, tracker := h.dependencies()
+	if history == nil && tracker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "flights_unavailable",
+			"message": "Flight detection is disabled",
+		})
+		return
+	}
+
+	deviceID := c.Query("device")
+	if deviceID != "" {
+		var err error
+		if deviceID, err = models.ParseDeviceID(deviceID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_device",
+				"message": "Device must be a FANET address in hex format or a prefixed OGN/FLARM id",
+			})
+			return
+		}
+	}
+
+	limit := defaultFlightsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxFlightsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_parameter",
+				"message": "Limit must be between 1 and 100",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	var flights []*models.Flight
+	switch {
+	case deviceID == "":
+		if tracker == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "missing_device",
+				"message": "Device parameter is required",
+			})
+			return
+		}
+		flights = tracker.ActiveFlights()
+	case history != nil:
+		ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+		defer cancel()
+
+		var err error
+		flights, err = history.GetFlights(ctx, deviceID, limit)
+		if err != nil {
+			h.logger.WithField("error", err).WithField("device_id", deviceID).Error("Failed to get flights")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "internal_error",
+				"message": "Failed to get flights",
+			})
+			return
+		}
+		if tracker != nil {
+			overlayActiveFlight(flights, tracker, deviceID)
+		}
+	default:
+		flights = make([]*models.Flight, 0, 1)
+		if flight, ok := tracker.ActiveFlight(deviceID); ok {
+			flights = append(flights, flight)
+		}
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		response := &pb.FlightsResponse{
+			Flights: make([]*pb.Flight, 0, len(flights)),
+		}
+		for _, flight := range flights {
+			response.Flights = append(response.Flights, flight.ToProto())
+		}
+		h.sendProtobuf(c, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"flights": flights,
+	})
+}
+
+// overlayActiveFlight заменяет запись текущего полета актуальной статистикой трекера
+func overlayActiveFlight(flights []*models.Flight, tracker *service.FlightTracker, deviceID string) {
+	active, ok := tracker.ActiveFlight(deviceID)
+	if !ok {
+		return
+	}
+	for i, flight := range flights {
+		if flight.IsActive() && flight.TakeoffTime.Equal(active.TakeoffTime) {
+			active.ID = flight.ID
+			flights[i] = active
+			return
+		}
+	}
+}
+
+// GetFlightTrack возвращает трек полета от взлета до посадки
+// GET /api/v1/flights/:id/track?format=geojson|json|igc|gpx|kml
+func (h *FlightHandler) GetFlightTrack(c *gin.Context) {
+	history, tracker := h.dependencies()
+	if history == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "mysql_unavailable",
+			"message": "Flight history requires MySQL",
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_flight_id",
+			"message": "Flight ID must be a positive number",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "geojson")
+	exportFormat, isExport := export.ParseFormat(format)
+	if format != "json" && format != "geojson" && !isExport {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_format",
+			"message": "Invalid format parameter. Supported: json, geojson, igc, gpx, kml",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	flight, err := history.GetFlight(ctx, id)
+	if err != nil {
+		h.logger.WithField("error", err).WithField("flight_id", id).Error("Failed to get flight")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to get flight",
+		})
+		return
+	}
+	if flight == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "flight_not_found",
+			"message": "Flight not found",
+		})
+		return
+	}
+
+	end := time.Now()
+	if flight.LandingTime != nil {
+		end = *flight.LandingTime
+	} else if tracker != nil {
+		flights := []*models.Flight{flight}
+		overlayActiveFlight(flights, tracker, flight.DeviceID)
+		flight = flights[0]
+	}
+
+	points, err := history.GetPilotTrackBetween(ctx, flight.DeviceID, flight.TakeoffTime, end, maxFlightTrackPoints)
+	if err != nil {
+		h.logger.WithField("error", err).WithField("flight_id", id).Error("Failed to get flight track")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to get flight track",
+		})
+		return
+	}
+
+	if isExport {
+		info := export.TrackInfo{
+			DeviceID:     flight.DeviceID,
+			AircraftType: flight.Type,
+			FlightID:     flight.ID,
+		}
+		if info.PilotName, err = history.GetPilotName(ctx, flight.DeviceID); err != nil {
+			h.logger.WithField("error", err).WithField("device_id", flight.DeviceID).Debug("Failed to get pilot name")
+		}
+		writeTrackFile(c, h.logger, exportFormat, info, points)
+		return
+	}
+
+	addr, _ := models.DeviceAddr(flight.DeviceID)
+	track := &pb.Track{
+		Addr:      uint32(addr),
+		Points:    make([]*pb.TrackPoint, len(points)),
+		StartTime: flight.TakeoffTime.Unix(),
+		EndTime:   end.Unix(),
+	}
+	for i, point := range points {
+		track.Points[i] = &pb.TrackPoint{
+			Position: &pb.GeoPoint{
+				Latitude:  point.Latitude,
+				Longitude: point.Longitude,
+				Altitude:  point.Altitude,
+			},
+			Altitude:  point.Altitude,
+			Timestamp: point.Timestamp.Unix(),
+		}
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		h.sendProtobuf(c, &pb.FlightTrackResponse{
+			Flight: flight.ToProto(),
+			Track:  track,
+		})
+		return
+	}
+
+	if format == "geojson" {
+		result := convertTrackToGeoJSON(track)
+		result["properties"] = map[string]interface{}{
+			"flight": flight,
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	result := convertTrackToJSON(track)
+	result["flight"] = flight
+	c.JSON(http.StatusOK, result)
+}
+
+// sendProtobuf сериализует и отправляет protobuf ответ
+func (h *FlightHandler) sendProtobuf(c *gin.Context, message proto.Message) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to marshal protobuf")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "marshal_error",
+			"message": "Failed to serialize response",
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/x-protobuf", data)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/flybeeper/fanet-backend/internal/auth"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// FriendsHandler управляет списком друзей пользователя: устройствами, обновления
+// которых клиент получает вне зависимости от области карты (friends=true)
+type FriendsHandler struct {
+	store    *service.FriendsStore
+	onChange func(userID int, devices []string) // Обновление подписок подключенных клиентов
+}
+
+// NewFriendsHandler создает обработчик. store = nil - списки недоступны (нет Redis)
+func NewFriendsHandler(store *service.FriendsStore, onChange func(userID int, devices []string)) *FriendsHandler {
+	return &FriendsHandler{
+		store:    store,
+		onChange: onChange,
+	}
+}
+
+// FriendsRequest запрос замены списка друзей
+type FriendsRequest struct {
+	Devices []string `json:"devices"`
+}
+
+// GetFriends возвращает список друзей пользователя
+// GET /api/v1/friends
+func (h *FriendsHandler) GetFriends(c *gin.Context) {
+	userID, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.store.List(c.Request.Context(), userID)
+	h.respond(c, userID, devices, err, false)
+}
+
+// ReplaceFriends заменяет список друзей
+// PUT /api/v1/friends {"devices": ["ABC123", "DEF456"]}
+func (h *FriendsHandler) ReplaceFriends(c *gin.Context) {
+	userID, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	var req FriendsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_request",
+			"message": "Request must contain devices",
+		})
+		return
+	}
+
+	devices := make([]string, 0, len(req.Devices))
+	for _, deviceID := range req.Devices {
+		normalized, err := service.NormalizeDeviceID(deviceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_device_id",
+				"message": err.Error(),
+			})
+			return
+		}
+		devices = append(devices, normalized)
+	}
+
+	devices, err := h.store.Replace(c.Request.Context(), userID, devices)
+	h.respond(c, userID, devices, err, true)
+}
+
+// AddFriend добавляет устройство в список друзей
+// POST /api/v1/friends/:device_id
+func (h *FriendsHandler) AddFriend(c *gin.Context) {
+	userID, deviceID, ok := h.userDevice(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.store.Add(c.Request.Context(), userID, []string{deviceID})
+	h.respond(c, userID, devices, err, true)
+}
+
+// RemoveFriend удаляет устройство из списка друзей
+// DELETE /api/v1/friends/:device_id
+func (h *FriendsHandler) RemoveFriend(c *gin.Context) {
+	userID, deviceID, ok := h.userDevice(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.store.Remove(c.Request.Context(), userID, []string{deviceID})
+	h.respond(c, userID, devices, err, true)
+}
+
+// user возвращает пользователя запроса. Отвечает ошибкой, если списки
+// недоступны или пользователь не аутентифицирован
+func (h *FriendsHandler) user(c *gin.Context) (int, bool) {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "redis_unavailable",
+			"message": "Friends list requires Redis",
+		})
+		return 0, false
+	}
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    "AUTH_REQUIRED",
+			"message": "Authentication required",
+		})
+		return 0, false
+	}
+	return userID, true
+}
+
+// userDevice возвращает пользователя и нормализованный адрес устройства из пути
+func (h *FriendsHandler) userDevice(c *gin.Context) (int, string, bool) {
+	userID, ok := h.user(c)
+	if !ok {
+		return 0, "", false
+	}
+
+	deviceID, err := service.NormalizeDeviceID(c.Param("device_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_device_id",
+			"message": err.Error(),
+		})
+		return 0, "", false
+	}
+	return userID, deviceID, true
+}
+
+// respond отвечает списком друзей. changed - список изменен, подписки
+// подключенных клиентов пользователя обновляются
+func (h *FriendsHandler) respond(c *gin.Context, userID int, devices []string, err error, changed bool) {
+	if errors.Is(err, service.ErrTooManyFriends) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "too_many_friends",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "redis_error",
+			"message": "Failed to access friends list",
+		})
+		return
+	}
+
+	if changed && h.onChange != nil {
+		h.onChange(userID, devices)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"devices": devices,
+	})
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/auth"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTokenValidator принимает токены из списка
+type stubTokenValidator map[string]int
+
+func (v stubTokenValidator) ValidateToken(ctx context.Context, token string) (*auth.User, error) {
+	if id, ok := v[token]; ok {
+		return &auth.User{ID: id}, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+func newTestFriendsStore() *service.FriendsStore {
+	return service.NewFriendsStore(testutil.NewMemorySets())
+}
+
+func TestFriendsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var changed []string
+	h := NewFriendsHandler(newTestFriendsStore(), func(userID int, devices []string) {
+		assert.Equal(t, 42, userID)
+		changed = devices
+	})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			c.Set("user_id", 42)
+		}
+	})
+	router.GET("/friends", h.GetFriends)
+	router.PUT("/friends", h.ReplaceFriends)
+	router.POST("/friends/:device_id", h.AddFriend)
+	router.DELETE("/friends/:device_id", h.RemoveFriend)
+
+	request := func(method, path, body string) (int, []string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		router.ServeHTTP(w, req)
+		var resp struct {
+			Devices []string `json:"devices"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Devices
+	}
+
+	code, devices := request("GET", "/friends", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, devices)
+
+	code, devices = request("POST", "/friends/abc123", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"ABC123"}, devices)
+	assert.Equal(t, devices, changed)
+
+	code, devices = request("PUT", "/friends", `{"devices":["def456","1234","DEF456"]}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"001234", "DEF456"}, devices)
+	assert.Equal(t, devices, changed)
+
+	code, devices = request("DELETE", "/friends/1234", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"DEF456"}, devices)
+
+	code, _ = request("POST", "/friends/XYZ", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = request("PUT", "/friends", `{"devices":["ABC123","XYZ"]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	many := make([]string, service.MaxFriends+1)
+	for i := range many {
+		many[i] = fmt.Sprintf(`"%06X"`, 0xA00000+i)
+	}
+	code, _ = request("PUT", "/friends", `{"devices":[`+strings.Join(many, ",")+`]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Без пользователя
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/friends", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Без Redis
+	router = gin.New()
+	router.GET("/friends", NewFriendsHandler(nil, nil).GetFriends)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/friends", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestStreamHandler_PollFriends(t *testing.T) {
+	router, stream := newTestStreamRouter(t)
+	store := newTestFriendsStore()
+	_, err := store.Add(context.Background(), 42, []string{"BBB222"})
+	require.NoError(t, err)
+
+	poll := func(query string) (int, string, []streamEvent) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stream/poll?"+query, nil))
+		var body struct {
+			Session string        `json:"session"`
+			Events  []streamEvent `json:"events"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Session, body.Events
+	}
+
+	// Без валидатора токен не определяет пользователя
+	code, _, _ := poll("friends=true&token=valid-token-42")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Списки друзей недоступны без Redis
+	stream.ws.SetAuthValidator(stubTokenValidator{"valid-token-42": 42})
+	code, _, _ = poll("friends=true&token=valid-token-42")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	stream.ws.SetFriendsStore(store)
+	code, _, _ = poll("friends=true")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _, _ = poll("friends=true&token=invalid-token")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Область не нужна: клиент следит только за друзьями
+	code, session, _ := poll("friends=true&token=valid-token-42&timeout=1")
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, session)
+
+	waitSubscribed := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		subscribed := false
+		for !subscribed && time.Now().Before(deadline) {
+			_, _, events := poll("session=" + session + "&timeout=1")
+			for _, event := range events {
+				subscribed = subscribed || event.Event == "subscribed"
+			}
+		}
+		require.True(t, subscribed)
+	}
+	waitSubscribed()
+
+	// Изменение списка применяется к подключенному клиенту
+	stream.ws.UpdateFriends(42, []string{"CCC333"})
+	waitSubscribed()
+
+	stream.ws.broadcast.Broadcast(pilotUpdate("BBB222", 48.0, 10.0))
+	stream.ws.broadcast.Broadcast(pilotUpdate("CCC333", 48.0, 10.0))
+	code, _, events := poll("session=" + session + "&timeout=5")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, events, 1)
+	assert.Equal(t, "batch", events[0].Event)
+
+	data, err := json.Marshal(events[0].Data)
+	require.NoError(t, err)
+	var batch struct {
+		Updates []struct {
+			Subscriptions []string `json:"subscriptions"`
+		} `json:"updates"`
+	}
+	require.NoError(t, json.Unmarshal(data, &batch))
+	require.Len(t, batch.Updates, 1)
+	assert.Equal(t, []string{friendsSubscriptionID}, batch.Updates[0].Subscriptions)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// GatewayHandler отдает реестр базовых станций
+type GatewayHandler struct {
+	registry   *service.GatewayRegistry
+	registryMu sync.RWMutex
+}
+
+// NewGatewayHandler создает обработчик. Реестр устанавливается через SetRegistry
+func NewGatewayHandler() *GatewayHandler {
+	return &GatewayHandler{}
+}
+
+// SetRegistry устанавливает реестр базовых станций
+func (h *GatewayHandler) SetRegistry(registry *service.GatewayRegistry) {
+	h.registryMu.Lock()
+	defer h.registryMu.Unlock()
+	h.registry = registry
+}
+
+// getRegistry возвращает реестр или отвечает 503, если он не подключен
+func (h *GatewayHandler) getRegistry(c *gin.Context) *service.GatewayRegistry {
+	h.registryMu.RLock()
+	registry := h.registry
+	h.registryMu.RUnlock()
+
+	if registry == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "gateways_unavailable",
+			"message": "Base station registry is not enabled",
+		})
+	}
+	return registry
+}
+
+// GetGateways возвращает список базовых станций
+// GET /api/v1/gateways?status=online|offline
+func (h *GatewayHandler) GetGateways(c *gin.Context) {
+	registry := h.getRegistry(c)
+	if registry == nil {
+		return
+	}
+
+	var online *bool
+	switch c.Query("status") {
+	case "":
+	case "online":
+		value := true
+		online = &value
+	case "offline":
+		value := false
+		online = &value
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_status",
+			"message": "Status must be online or offline",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"gateways": registry.ListGateways(online),
+	})
+}
+
+// GetGateway возвращает базовую станцию с принятыми позициями
+// GET /api/v1/gateways/:chip_id
+func (h *GatewayHandler) GetGateway(c *gin.Context) {
+	registry := h.getRegistry(c)
+	if registry == nil {
+		return
+	}
+
+	gateway, ok := registry.GetGateway(c.Param("chip_id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "not_found",
+			"message": "Base station not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gateway)
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultHeatmapPrecision = 6                    // ~1.2 x 0.6 км
+	defaultHeatmapPeriod    = 365 * 24 * time.Hour // Период по умолчанию
+)
+
+// HeatmapHandler отдает тепловую карту исторических термиков
+type HeatmapHandler struct {
+	service   *service.HeatmapService
+	serviceMu sync.RWMutex
+	logger    *utils.Logger
+	timeout   time.Duration
+}
+
+// NewHeatmapHandler создает обработчик. Сервис устанавливается через SetService
+func NewHeatmapHandler(logger *utils.Logger) *HeatmapHandler {
+	return &HeatmapHandler{
+		logger:  logger,
+		timeout: 30 * time.Second,
+	}
+}
+
+// SetService устанавливает сервис тепловой карты
+func (h *HeatmapHandler) SetService(heatmapService *service.HeatmapService) {
+	h.serviceMu.Lock()
+	defer h.serviceMu.Unlock()
+	h.service = heatmapService
+}
+
+// GetThermalHeatmap возвращает исторические термики, агрегированные по geohash ячейкам
+// GET /api/v1/thermals/heatmap?bounds=45.5,13.5,46.5,15.0&precision=6&from=2024-04-01&to=2024-09-30&months=5,6,7&hours=11-16&tz=Europe/Ljubljana&wind=270-45&min_climb=1&format=geojson
+func (h *HeatmapHandler) GetThermalHeatmap(c *gin.Context) {
+	h.serviceMu.RLock()
+	heatmapService := h.service
+	h.serviceMu.RUnlock()
+
+	if heatmapService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "mysql_unavailable",
+			"message": "Thermal history requires MySQL",
+		})
+		return
+	}
+
+	filter, precision, err := parseHeatmapQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_parameter",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "geojson")
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		format = "protobuf"
+	}
+	if format != "geojson" && format != "json" && format != "protobuf" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_format",
+			"message": "Invalid format parameter. Supported: geojson, json, protobuf",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	heatmap, err := heatmapService.BuildThermalHeatmap(ctx, filter, precision)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to build thermal heatmap")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to build thermal heatmap",
+		})
+		return
+	}
+
+	// Исторические данные меняются медленно - разрешаем кэширование тайлов
+	c.Header("Cache-Control", "public, max-age=3600")
+
+	switch format {
+	case "protobuf":
+		data, err := proto.Marshal(convertHeatmapToProto(heatmap))
+		if err != nil {
+			h.logger.WithField("error", err).Error("Failed to marshal protobuf")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "marshal_error",
+				"message": "Failed to serialize response",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "application/x-protobuf", data)
+	case "json":
+		c.JSON(http.StatusOK, heatmap)
+	default:
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, convertHeatmapToGeoJSON(heatmap))
+	}
+}
+
+// parseHeatmapQuery разбирает параметры запроса тепловой карты
+func parseHeatmapQuery(c *gin.Context) (*models.ThermalHeatmapFilter, int, error) {
+	bounds, err := parseBounds(c.Query("bounds"))
+	if err != nil {
+		return nil, 0, err
+	}
+	if bounds.Southwest.Latitude >= bounds.Northeast.Latitude || bounds.Southwest.Longitude >= bounds.Northeast.Longitude ||
+		bounds.Southwest.Validate() != nil || bounds.Northeast.Validate() != nil {
+		return nil, 0, fmt.Errorf("bounds must be sw_lat,sw_lon,ne_lat,ne_lon")
+	}
+
+	precision := defaultHeatmapPrecision
+	if value := c.Query("precision"); value != "" {
+		precision, err = strconv.Atoi(value)
+		if err != nil || precision < 3 || precision > 8 {
+			return nil, 0, fmt.Errorf("precision must be between 3 and 8")
+		}
+	}
+
+	location := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unknown time zone: %s", tz)
+		}
+	}
+
+	filter := &models.ThermalHeatmapFilter{
+		Bounds:   *bounds,
+		To:       time.Now(),
+		HourFrom: -1,
+		HourTo:   -1,
+		Location: location,
+	}
+
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = parseHeatmapTime(value, location); err != nil {
+			return nil, 0, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	filter.From = filter.To.Add(-defaultHeatmapPeriod)
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = parseHeatmapTime(value, location); err != nil {
+			return nil, 0, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, 0, fmt.Errorf("from must be before to")
+	}
+
+	if value := c.Query("months"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			month, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || month < 1 || month > 12 {
+				return nil, 0, fmt.Errorf("months must be comma separated numbers 1-12")
+			}
+			filter.Months = append(filter.Months, time.Month(month))
+		}
+	}
+
+	if value := c.Query("hours"); value != "" {
+		if filter.HourFrom, filter.HourTo, err = parseHeatmapRange(value, 23); err != nil {
+			return nil, 0, fmt.Errorf("hours: %w", err)
+		}
+	}
+
+	if value := c.Query("wind"); value != "" {
+		from, to, err := parseHeatmapRange(value, 359)
+		if err != nil {
+			return nil, 0, fmt.Errorf("wind: %w", err)
+		}
+		windFrom, windTo := uint16(from), uint16(to)
+		filter.WindFrom, filter.WindTo = &windFrom, &windTo
+	}
+
+	if value := c.Query("min_climb"); value != "" {
+		minClimb, err := strconv.ParseFloat(value, 32)
+		if err != nil || minClimb < 0 {
+			return nil, 0, fmt.Errorf("min_climb must be a non-negative number (m/s)")
+		}
+		filter.MinClimb = float32(minClimb)
+	}
+
+	return filter, precision, nil
+}
+
+// parseHeatmapTime разбирает RFC3339 или дату YYYY-MM-DD в часовом поясе запроса
+func parseHeatmapTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, location)
+}
+
+// parseHeatmapRange разбирает диапазон "from-to" с границами 0..maxValue
+func parseHeatmapRange(value string, maxValue int) (int, int, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("range must be from-to")
+	}
+
+	from, errFrom := strconv.Atoi(strings.TrimSpace(parts[0]))
+	to, errTo := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errFrom != nil || errTo != nil || from < 0 || to < 0 || from > maxValue || to > maxValue {
+		return 0, 0, fmt.Errorf("range values must be between 0 and %d", maxValue)
+	}
+	return from, to, nil
+}
+
+// convertHeatmapToGeoJSON конвертирует тепловую карту в FeatureCollection:
+// каждая ячейка - Polygon с границами geohash
+func convertHeatmapToGeoJSON(heatmap *models.ThermalHeatmap) map[string]interface{} {
+	features := make([]map[string]interface{}, len(heatmap.Cells))
+	for i, cell := range heatmap.Cells {
+		sw, ne := cell.Bounds.Southwest, cell.Bounds.Northeast
+		features[i] = map[string]interface{}{
+			"type": "Feature",
+			"id":   cell.Geohash,
+			"geometry": map[string]interface{}{
+				"type": "Polygon",
+				"coordinates": [][][]float64{{
+					{sw.Longitude, sw.Latitude},
+					{ne.Longitude, sw.Latitude},
+					{ne.Longitude, ne.Latitude},
+					{sw.Longitude, ne.Latitude},
+					{sw.Longitude, sw.Latitude},
+				}},
+			},
+			"properties": map[string]interface{}{
+				"geohash":      cell.Geohash,
+				"count":        cell.Count,
+				"pilots":       cell.Pilots,
+				"avg_climb":    cell.AvgClimb,
+				"max_climb":    cell.MaxClimb,
+				"avg_altitude": cell.AvgAltitude,
+				"days":         cell.Days,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+		"properties": map[string]interface{}{
+			"precision": heatmap.Precision,
+			"from":      heatmap.From.Unix(),
+			"to":        heatmap.To.Unix(),
+			"thermals":  heatmap.Thermals,
+			"truncated": heatmap.Truncated,
+		},
+	}
+}
+
+// convertHeatmapToProto конвертирует тепловую карту в компактную protobuf сетку
+func convertHeatmapToProto(heatmap *models.ThermalHeatmap) *pb.ThermalHeatmapResponse {
+	cells := make([]*pb.HeatmapCell, len(heatmap.Cells))
+	for i, cell := range heatmap.Cells {
+		cells[i] = &pb.HeatmapCell{
+			Geohash:     cell.Geohash,
+			Count:       uint32(cell.Count),
+			Pilots:      uint32(cell.Pilots),
+			AvgClimb:    cell.AvgClimb,
+			MaxClimb:    cell.MaxClimb,
+			AvgAltitude: cell.AvgAltitude,
+			Days:        uint32(cell.Days),
+		}
+	}
+
+	return &pb.ThermalHeatmapResponse{
+		Precision: uint32(heatmap.Precision),
+		From:      heatmap.From.Unix(),
+		To:        heatmap.To.Unix(),
+		Thermals:  uint32(heatmap.Thermals),
+		Truncated: heatmap.Truncated,
+		Cells:     cells,
+	}
+}
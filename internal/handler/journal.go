@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/geo"
+)
+
+const (
+	// journalPrecision точность geohash ячеек журнала (~39 км)
+	journalPrecision = 4
+	// journalMaxPerCell максимум обновлений в одной ячейке журнала
+	journalMaxPerCell = 2000
+	// journalMaxAge время хранения обновлений в журнале
+	journalMaxAge = 5 * time.Minute
+	// replayBatchSize максимум обновлений в одном батче повтора
+	replayBatchSize = 100
+)
+
+// UpdateJournal ограниченный журнал разосланных обновлений по geohash ячейкам
+// для восстановления клиентов после переподключения. Ячейка хранит не больше
+// maxPerCell обновлений, обновления старше maxAge удаляются. Если часть
+// пропущенных клиентом обновлений уже вытеснена, Since сообщает о разрыве,
+// и клиент получает снимок состояния вместо повтора. Приоритетные обновления
+// (инциденты, взлеты и посадки) рассылаются всем клиентам и хранятся отдельно
+// от ячеек с теми же ограничениями
+type UpdateJournal struct {
+	cells      map[string]*journalCell // geohash -> обновления
+	priority   *journalCell            // Обновления для всех клиентов вне зависимости от области
+	precision  int
+	maxPerCell int
+	maxAge     time.Duration
+	start      uint64 // Первая последовательность, которую мог записать журнал
+	prunedUpTo uint64 // Максимальная последовательность, удаленная по возрасту
+	size       int
+	mu         sync.RWMutex
+	now        func() time.Time
+}
+
+// journalCell обновления одной geohash ячейки по возрастанию sequence
+type journalCell struct {
+	updates []*encodedUpdate
+	dropped uint64 // Максимальная последовательность, вытесненная по размеру ячейки
+}
+
+// NewUpdateJournal создает журнал. start - первая последовательность после
+// запуска: клиенты с более ранней последовательностью получают снимок
+func NewUpdateJournal(start uint64, precision, maxPerCell int, maxAge time.Duration) *UpdateJournal {
+	return &UpdateJournal{
+		cells:      make(map[string]*journalCell),
+		priority:   &journalCell{},
+		precision:  precision,
+		maxPerCell: maxPerCell,
+		maxAge:     maxAge,
+		start:      start,
+		now:        time.Now,
+	}
+}
+
+// Append записывает разосланное обновление. Обновления должны поступать
+// по возрастанию sequence
+func (j *UpdateJournal) Append(enc *encodedUpdate) {
+	gh := geo.Encode(enc.lat, enc.lon, j.precision)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	enc.at = j.now()
+	cell, exists := j.cells[gh]
+	if !exists {
+		cell = &journalCell{}
+		j.cells[gh] = cell
+	}
+	j.appendTo(cell, enc)
+}
+
+// AppendPriority записывает приоритетное обновление, которое Since возвращает
+// любой подписке. Обновления должны поступать по возрастанию sequence
+func (j *UpdateJournal) AppendPriority(enc *encodedUpdate) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	enc.at = j.now()
+	j.appendTo(j.priority, enc)
+}
+
+// appendTo добавляет обновление в ячейку и вытесняет старые при переполнении.
+// Вызывающий держит j.mu
+func (j *UpdateJournal) appendTo(cell *journalCell, enc *encodedUpdate) {
+	cell.updates = append(cell.updates, enc)
+	j.size++
+
+	if len(cell.updates) > j.maxPerCell {
+		// Вытесняем четверть ячейки, чтобы не копировать слайс на каждой записи
+		n := j.maxPerCell / 4
+		if n < 1 {
+			n = 1
+		}
+		cell.dropped = cell.updates[n-1].sequence
+		cell.updates = append([]*encodedUpdate(nil), cell.updates[n:]...)
+		j.size -= n
+	}
+}
+
+// Since возвращает обновления области подписки и приоритетные обновления
+// с последовательностью больше from в порядке sequence, по одному (последнему)
+// на объект. current - последняя выданная последовательность. ok = false, если часть обновлений после from
+// уже вытеснена или from не относится к текущему запуску сервера
+func (j *UpdateJournal) Since(sub *Subscription, from, current uint64) ([]*encodedUpdate, bool) {
+	if from > current || from+1 < j.start {
+		return nil, false
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if from < j.prunedUpTo {
+		return nil, false
+	}
+
+	latest := make(map[string]*encodedUpdate)
+	collect := func(cell *journalCell, all bool) bool {
+		if from < cell.dropped {
+			return false
+		}
+
+		// Обновления в ячейке упорядочены: пропускаем полученные клиентом
+		first := sort.Search(len(cell.updates), func(i int) bool {
+			return cell.updates[i].sequence > from
+		})
+		for _, enc := range cell.updates[first:] {
+			if !all && !sub.Matches(enc.update.Type, enc.lat, enc.lon) {
+				continue
+			}
+			if prev, seen := latest[enc.key]; !seen || prev.sequence < enc.sequence {
+				latest[enc.key] = enc
+			}
+		}
+		return true
+	}
+
+	// Приоритетные обновления клиент получает при любой подписке
+	if !collect(j.priority, true) {
+		return nil, false
+	}
+	for _, gh := range j.cellsFor(sub) {
+		cell, exists := j.cells[gh]
+		if exists && !collect(cell, false) {
+			return nil, false
+		}
+	}
+
+	missed := make([]*encodedUpdate, 0, len(latest))
+	for _, enc := range latest {
+		missed = append(missed, enc)
+	}
+	sort.Slice(missed, func(a, b int) bool {
+		return missed[a].sequence < missed[b].sequence
+	})
+	return missed, true
+}
+
+// cellsFor возвращает ячейки журнала, пересекающие описанный вокруг области
+// подписки прямоугольник. Сетка обходится с шагом в размер ячейки
+func (j *UpdateJournal) cellsFor(sub *Subscription) []string {
+	var minLat, minLon, maxLat, maxLon float64
+	if sub.Bounds != nil {
+		minLat, minLon = sub.Bounds.Southwest.Latitude, sub.Bounds.Southwest.Longitude
+		maxLat, maxLon = sub.Bounds.Northeast.Latitude, sub.Bounds.Northeast.Longitude
+	} else {
+		radiusDeg := sub.RadiusKm / 111.0
+		lonDeg := radiusDeg / math.Max(math.Cos(sub.Center.Latitude*math.Pi/180), 0.01)
+		minLat, maxLat = math.Max(sub.Center.Latitude-radiusDeg, -90), math.Min(sub.Center.Latitude+radiusDeg, 90)
+		minLon, maxLon = math.Max(sub.Center.Longitude-lonDeg, -180), math.Min(sub.Center.Longitude+lonDeg, 180)
+	}
+
+	cellMinLat, cellMinLon, cellMaxLat, cellMaxLon := geo.BoundingBox(geo.Encode(minLat, minLon, j.precision))
+	latStep, lonStep := cellMaxLat-cellMinLat, cellMaxLon-cellMinLon
+
+	cells := make(map[string]bool)
+	for lat := minLat; ; lat += latStep {
+		lat = math.Min(lat, maxLat)
+		for lon := minLon; ; lon += lonStep {
+			lon = math.Min(lon, maxLon)
+			cells[geo.Encode(lat, lon, j.precision)] = true
+			if lon >= maxLon {
+				break
+			}
+		}
+		if lat >= maxLat {
+			break
+		}
+	}
+
+	result := make([]string, 0, len(cells))
+	for gh := range cells {
+		result = append(result, gh)
+	}
+	return result
+}
+
+// Prune удаляет обновления старше maxAge и пустые ячейки.
+// Возвращает количество удаленных обновлений
+func (j *UpdateJournal) Prune() int {
+	cutoff := j.now().Add(-j.maxAge)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	removed := j.pruneCell(j.priority, cutoff)
+	for gh, cell := range j.cells {
+		removed += j.pruneCell(cell, cutoff)
+
+		// Вытеснение по размеру перекрыто границей по возрасту - ячейка больше не нужна
+		if len(cell.updates) == 0 && cell.dropped <= j.prunedUpTo {
+			delete(j.cells, gh)
+		}
+	}
+
+	j.size -= removed
+	return removed
+}
+
+// pruneCell удаляет из ячейки обновления, записанные не позже cutoff.
+// Возвращает количество удаленных. Вызывающий держит j.mu
+func (j *UpdateJournal) pruneCell(cell *journalCell, cutoff time.Time) int {
+	n := sort.Search(len(cell.updates), func(i int) bool {
+		return cell.updates[i].at.After(cutoff)
+	})
+	if n == 0 {
+		return 0
+	}
+	if last := cell.updates[n-1].sequence; last > j.prunedUpTo {
+		j.prunedUpTo = last
+	}
+	cell.updates = append([]*encodedUpdate(nil), cell.updates[n:]...)
+	return n
+}
+
+// Size возвращает количество обновлений в журнале
+func (j *UpdateJournal) Size() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.size
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/geo"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// journalUpdate создает закодированное обновление пилота для журнала
+func journalUpdate(sequence uint64, address string, lat, lon float64) *encodedUpdate {
+	return &encodedUpdate{
+		update:   pilotUpdate(address, lat, lon),
+		key:      "pilot:" + address,
+		sequence: sequence,
+		lat:      lat,
+		lon:      lon,
+	}
+}
+
+func sequences(updates []*encodedUpdate) []uint64 {
+	result := make([]uint64, 0, len(updates))
+	for _, enc := range updates {
+		result = append(result, enc.sequence)
+	}
+	return result
+}
+
+func TestUpdateJournal_Since(t *testing.T) {
+	journal := NewUpdateJournal(101, journalPrecision, 100, time.Minute)
+	journal.Append(journalUpdate(101, "AAA111", 46.0, 13.0))
+	journal.Append(journalUpdate(102, "BBB222", 46.05, 13.05))
+	journal.Append(journalUpdate(103, "AAA111", 46.01, 13.0))
+	journal.Append(journalUpdate(104, "CCC333", 48.0, 13.0)) // вне области
+	journal.Append(journalUpdate(105, "BBB222", 46.06, 13.05))
+
+	sub := NewCircleSubscription("my-site", 46.0, 13.0, 20)
+
+	missed, ok := journal.Since(sub, 100, 105)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{103, 105}, sequences(missed), "only the latest update per object")
+
+	missed, ok = journal.Since(sub, 103, 105)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{105}, sequences(missed))
+
+	missed, ok = journal.Since(sub, 105, 105)
+	require.True(t, ok)
+	assert.Empty(t, missed)
+
+	// Последовательность из прошлого запуска или из будущего
+	_, ok = journal.Since(sub, 50, 105)
+	assert.False(t, ok)
+	_, ok = journal.Since(sub, 200, 105)
+	assert.False(t, ok)
+}
+
+func TestUpdateJournal_CellOverflow(t *testing.T) {
+	journal := NewUpdateJournal(1, journalPrecision, 8, time.Minute)
+	for sequence := uint64(1); sequence <= 9; sequence++ {
+		journal.Append(journalUpdate(sequence, "AAA111", 46.0, 13.0))
+	}
+	assert.Equal(t, 7, journal.Size())
+
+	sub := NewCircleSubscription("", 46.0, 13.0, 20)
+	_, ok := journal.Since(sub, 1, 9)
+	assert.False(t, ok, "sequence 2 was evicted")
+
+	missed, ok := journal.Since(sub, 2, 9)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{9}, sequences(missed))
+
+	// Вытеснение в другой ячейке не мешает подписке на эту область
+	_, ok = journal.Since(NewCircleSubscription("", 40.0, 5.0, 20), 1, 9)
+	assert.True(t, ok)
+}
+
+func TestUpdateJournal_Prune(t *testing.T) {
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC))
+	journal := NewUpdateJournal(1, journalPrecision, 100, time.Minute)
+	journal.now = clock.Now
+
+	journal.Append(journalUpdate(1, "AAA111", 46.0, 13.0))
+	journal.Append(journalUpdate(2, "BBB222", 40.0, 5.0))
+	clock.Advance(45 * time.Second)
+	journal.Append(journalUpdate(3, "AAA111", 46.0, 13.0))
+
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, 2, journal.Prune())
+	assert.Equal(t, 1, journal.Size())
+	assert.Len(t, journal.cells, 1)
+
+	sub := NewCircleSubscription("", 46.0, 13.0, 20)
+	_, ok := journal.Since(sub, 1, 3)
+	assert.False(t, ok, "sequence 2 expired")
+
+	missed, ok := journal.Since(sub, 2, 3)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{3}, sequences(missed))
+}
+
+// receiveResponse читает SubscribeResponse протокола v2 из очереди клиента
+func receiveResponse(t *testing.T, client *Client) *pb.SubscribeResponse {
+	select {
+	case data := <-client.send:
+		var msg pb.ServerMessage
+		require.NoError(t, proto.Unmarshal(data, &msg))
+		require.NotNil(t, msg.GetSubscribeResponse())
+		return msg.GetSubscribeResponse()
+	default:
+		require.FailNow(t, "no response queued")
+		return nil
+	}
+}
+
+func TestBroadcastManager_Resume(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	sub := NewCircleSubscription("my-site", 46.0, 13.0, 20)
+
+	// Клиент получил первое обновление и отключился
+	first := newTestClient(t, ProtocolV2)
+	bm.handleRegister(&ClientRegistration{client: first, connect: true, subscription: sub})
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 46.0, 13.0)})
+	lastSeen := receiveBatch(t, first).Updates[0].Sequence
+	bm.handleUnregister(first)
+
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 46.01, 13.0), pilotUpdate("BBB222", 46.02, 13.0)})
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 46.03, 13.0)})
+
+	// Переподключение: ответ, затем пропущенные обновления, помеченные подпиской
+	resumed := newTestClient(t, ProtocolV2)
+	bm.handleRegister(&ClientRegistration{client: resumed, connect: true})
+	bm.handleRegister(&ClientRegistration{
+		client:       resumed,
+		subscription: sub,
+		response:     &pb.SubscribeResponse{Success: true, Id: sub.ID},
+		resumeFrom:   lastSeen,
+	})
+
+	response := receiveResponse(t, resumed)
+	assert.Equal(t, pb.ResumeStatus_RESUME_STATUS_REPLAY, response.Resume)
+
+	batch := receiveBatch(t, resumed)
+	require.Len(t, batch.Updates, 2)
+	assert.False(t, batch.Snapshot)
+	assert.Equal(t, lastSeen+2, batch.Updates[0].Sequence, "BBB222")
+	assert.Equal(t, lastSeen+3, batch.Updates[1].Sequence, "latest AAA111")
+	assert.Equal(t, []string{"my-site"}, batch.Updates[0].Subscriptions)
+	assert.Empty(t, resumed.send)
+
+	// Последовательность из прошлого запуска - снимок
+	stale := newTestClient(t, ProtocolV2)
+	stale.handler = &WebSocketHandler{broadcast: bm, logger: logrus.NewEntry(logrus.New())}
+	bm.handleRegister(&ClientRegistration{client: stale, connect: true})
+	bm.handleRegister(&ClientRegistration{
+		client:       stale,
+		subscription: sub,
+		response:     &pb.SubscribeResponse{Success: true, Id: sub.ID},
+		resumeFrom:   1,
+	})
+	assert.Equal(t, pb.ResumeStatus_RESUME_STATUS_SNAPSHOT, receiveResponse(t, stale).Resume)
+}
+
+func TestUpdateJournal_Priority(t *testing.T) {
+	journal := NewUpdateJournal(1, journalPrecision, 2, time.Minute)
+	journal.Append(journalUpdate(1, "AAA111", 46.0, 13.0))
+	journal.AppendPriority(&encodedUpdate{
+		update:   &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_ALERT},
+		key:      "alert:1",
+		sequence: 2,
+		priority: true,
+	})
+
+	// Приоритетное обновление без координат получает любая подписка
+	missed, ok := journal.Since(NewCircleSubscription("", 46.0, 13.0, 20), 0, 2)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{1, 2}, sequences(missed))
+
+	missed, ok = journal.Since(NewCircleSubscription("", 40.0, 5.0, 20), 0, 2)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{2}, sequences(missed))
+
+	// Вытеснение приоритетных обновлений требует снимка в любой области
+	for sequence := uint64(3); sequence <= 4; sequence++ {
+		journal.AppendPriority(&encodedUpdate{
+			update:   &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_FLIGHT},
+			key:      "flight:BBB222",
+			sequence: sequence,
+			priority: true,
+		})
+	}
+	_, ok = journal.Since(NewCircleSubscription("", 40.0, 5.0, 20), 1, 4)
+	assert.False(t, ok, "sequence 2 was evicted")
+
+	missed, ok = journal.Since(NewCircleSubscription("", 40.0, 5.0, 20), 2, 4)
+	require.True(t, ok)
+	assert.Equal(t, []uint64{4}, sequences(missed))
+}
+
+func TestBroadcastManager_ResumeReplaysPriority(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	sub := NewCircleSubscription("my-site", 46.0, 13.0, 20)
+
+	first := newTestClient(t, ProtocolV2)
+	bm.handleRegister(&ClientRegistration{client: first, connect: true, subscription: sub})
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 46.0, 13.0)})
+	lastSeen := receiveBatch(t, first).Updates[0].Sequence
+
+	// Подключенный клиент получает инцидент сразу, без повторной отправки в батче
+	assert.Equal(t, 1, bm.BroadcastPriority(pb.UpdateType_UPDATE_TYPE_ALERT, pb.Action_ACTION_ADD, "alert-1", []byte{1}))
+	live := receiveBatch(t, first).Updates[0]
+	assert.Equal(t, lastSeen+1, live.Sequence)
+	bm.handleUnregister(first)
+
+	// Пропущенные клиентом инцидент и посадка
+	bm.BroadcastPriority(pb.UpdateType_UPDATE_TYPE_ALERT, pb.Action_ACTION_UPDATE, "alert-1", []byte{2})
+	bm.BroadcastPriority(pb.UpdateType_UPDATE_TYPE_FLIGHT, pb.Action_ACTION_REMOVE, "BBB222", []byte{3})
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 46.01, 13.0)})
+
+	resumed := newTestClient(t, ProtocolV2)
+	resumed.delta = true
+	bm.handleRegister(&ClientRegistration{client: resumed, connect: true})
+	bm.handleRegister(&ClientRegistration{
+		client:       resumed,
+		subscription: sub,
+		response:     &pb.SubscribeResponse{Success: true, Id: sub.ID},
+		resumeFrom:   lastSeen,
+	})
+	assert.Equal(t, pb.ResumeStatus_RESUME_STATUS_REPLAY, receiveResponse(t, resumed).Resume)
+
+	batch := receiveBatch(t, resumed)
+	require.Len(t, batch.Updates, 3)
+	assert.Equal(t, pb.UpdateType_UPDATE_TYPE_ALERT, batch.Updates[0].Type)
+	assert.Equal(t, pb.Action_ACTION_UPDATE, batch.Updates[0].Action)
+	assert.Equal(t, []byte{2}, batch.Updates[0].Data)
+	assert.False(t, batch.Updates[0].Delta)
+	assert.Empty(t, batch.Updates[0].Subscriptions)
+	assert.Equal(t, pb.UpdateType_UPDATE_TYPE_FLIGHT, batch.Updates[1].Type)
+	assert.Equal(t, pb.UpdateType_UPDATE_TYPE_PILOT, batch.Updates[2].Type)
+	assert.Equal(t, []string{"my-site"}, batch.Updates[2].Subscriptions)
+	assert.Less(t, batch.Updates[0].Sequence, batch.Updates[1].Sequence)
+	assert.Less(t, batch.Updates[1].Sequence, batch.Updates[2].Sequence)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ReceptionHandler отдает статистику приема устройства базовыми станциями
+type ReceptionHandler struct {
+	tracker   *service.ReceptionTracker
+	trackerMu sync.RWMutex
+}
+
+// NewReceptionHandler создает обработчик. Учет приема устанавливается через SetTracker
+func NewReceptionHandler() *ReceptionHandler {
+	return &ReceptionHandler{}
+}
+
+// SetTracker устанавливает учет приема
+func (h *ReceptionHandler) SetTracker(tracker *service.ReceptionTracker) {
+	h.trackerMu.Lock()
+	defer h.trackerMu.Unlock()
+	h.tracker = tracker
+}
+
+// GetReception возвращает станции, слышащие устройство, с RSSI и SNR
+// GET /api/v1/reception/:addr
+func (h *ReceptionHandler) GetReception(c *gin.Context) {
+	h.trackerMu.RLock()
+	tracker := h.tracker
+	h.trackerMu.RUnlock()
+
+	if tracker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    "reception_unavailable",
+			"message": "Reception tracking is not enabled",
+		})
+		return
+	}
+
+	deviceID := strings.ToUpper(c.Param("addr"))
+	stats := tracker.Stats(deviceID)
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "not_found",
+			"message": "Device has not been heard by any base station recently",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"device_id": deviceID,
+		"reception": stats,
+	})
+}
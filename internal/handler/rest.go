@@ -1,15 +1,19 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/flybeeper/fanet-backend/internal/auth"
+	"github.com/flybeeper/fanet-backend/internal/export"
 	"github.com/flybeeper/fanet-backend/internal/filter"
 	"github.com/flybeeper/fanet-backend/internal/models"
 	"github.com/flybeeper/fanet-backend/internal/repository"
@@ -26,6 +30,9 @@ type RESTHandler struct {
 	logger          *utils.Logger
 	timeout         time.Duration
 	boundaryTracker *service.BoundaryTracker
+	lodCache        *service.TrackLODCache // Кэш упрощенных версий треков (nil - без кэша)
+	chains          *filter.ChainSet       // Цепочки фильтров из конфигурации (nil - встроенные уровни)
+	chainsMu        sync.RWMutex
 }
 
 // NewRESTHandler создает новый REST handler
@@ -39,8 +46,22 @@ func NewRESTHandler(repo repository.Repository, historyRepo repository.HistoryRe
 	}
 }
 
+// SetFilterChains устанавливает цепочки фильтров из конфигурации
+func (h *RESTHandler) SetFilterChains(chains *filter.ChainSet) {
+	h.chainsMu.Lock()
+	defer h.chainsMu.Unlock()
+	h.chains = chains
+}
+
+// filterChains возвращает цепочки фильтров из конфигурации
+func (h *RESTHandler) filterChains() *filter.ChainSet {
+	h.chainsMu.RLock()
+	defer h.chainsMu.RUnlock()
+	return h.chains
+}
+
 // GetSnapshot возвращает начальный снимок всех объектов в радиусе
-// GET /api/v1/snapshot?lat=46.5&lon=15.6&radius=200&air-types=1,2,5&ground-types=1,2,4&max_age=300&pilots=true&stations=true&thermals=true&ground_objects=true
+// GET /api/v1/snapshot?lat=46.5&lon=15.6&radius=200&air-types=1,2,5&ground-types=1,2,4&max_age=300&pilots=true&stations=true&thermals=true&ground_objects=true&landmarks=true
 func (h *RESTHandler) GetSnapshot(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
@@ -97,6 +118,7 @@ func (h *RESTHandler) GetSnapshot(c *gin.Context) {
 	includeStations := c.DefaultQuery("stations", "true") == "true"
 	includeThermals := c.DefaultQuery("thermals", "true") == "true"
 	includeGroundObjects := c.DefaultQuery("ground_objects", "true") == "true"
+	includeLandmarks := c.DefaultQuery("landmarks", "true") == "true"
 
 	// Парсинг параметра air-types (опционально)
 	var filterAirTypes []models.PilotType
@@ -131,6 +153,7 @@ func (h *RESTHandler) GetSnapshot(c *gin.Context) {
 	var thermals []*models.Thermal
 	var stations []*models.Station
 	var groundObjects []*models.GroundObject
+	var landmarks []*models.Landmark
 
 	// Получаем пилотов если включены
 	if includePilots {
@@ -279,12 +302,26 @@ func (h *RESTHandler) GetSnapshot(c *gin.Context) {
 		}
 	}
 
+	// Получаем ориентиры если включены (истекшие уже удалены по TTL, max_age не применяется)
+	if includeLandmarks {
+		landmarks, err = h.repo.GetLandmarksInRadius(ctx, center, float64(radius))
+		if err != nil {
+			h.logger.WithField("error", err).Error("Failed to get landmarks")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "internal_error",
+				"message": "Failed to retrieve landmarks",
+			})
+			return
+		}
+	}
+
 	// Создаем Protobuf ответ
 	response := &pb.SnapshotResponse{
 		Pilots:        convertPilotsToProto(pilots),
 		GroundObjects: convertGroundObjectsToProto(groundObjects),
 		Thermals:      convertThermalsToProto(thermals),
 		Stations:      convertStationsToProto(stations),
+		Landmarks:     convertLandmarksToProto(landmarks),
 		Sequence:      uint64(time.Now().Unix()), // Простая последовательность
 	}
 
@@ -316,16 +353,18 @@ func (h *RESTHandler) GetSnapshot(c *gin.Context) {
 		"ground_objects": len(groundObjects),
 		"thermals":       len(thermals),
 		"stations":       len(stations),
+		"landmarks":      len(landmarks),
 	}
 	if maxAgeDuration < 24*time.Hour {
 		logFields["max_age_seconds"] = int(maxAgeDuration.Seconds())
 	}
-	if !includePilots || !includeStations || !includeThermals || !includeGroundObjects {
+	if !includePilots || !includeStations || !includeThermals || !includeGroundObjects || !includeLandmarks {
 		logFields["include_types"] = map[string]bool{
 			"pilots":         includePilots,
 			"stations":       includeStations,
 			"thermals":       includeThermals,
 			"ground_objects": includeGroundObjects,
+			"landmarks":      includeLandmarks,
 		}
 	}
 	if len(filterAirTypes) > 0 {
@@ -504,11 +543,155 @@ func (h *RESTHandler) GetStations(c *gin.Context) {
 	}
 }
 
+// GetMessages возвращает текстовые сообщения (FANET Type 3) в радиусе
+// GET /api/v1/messages?lat=46.5&lon=15.6&radius=50
+func (h *RESTHandler) GetMessages(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_latitude",
+			"message": "Latitude must be between -90 and 90",
+		})
+		return
+	}
+
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_longitude",
+			"message": "Longitude must be between -180 and 180",
+		})
+		return
+	}
+
+	radius, err := strconv.Atoi(c.Query("radius"))
+	if err != nil || radius < 1 || radius > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_radius",
+			"message": "Radius must be between 1 and 200 km",
+		})
+		return
+	}
+
+	center := models.GeoPoint{
+		Latitude:  lat,
+		Longitude: lon,
+	}
+
+	messages, err := h.repo.GetMessagesInRadius(ctx, center, float64(radius))
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get messages")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to retrieve messages",
+		})
+		return
+	}
+
+	response := &pb.MessagesResponse{
+		Messages: convertMessagesToProto(messages),
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		data, err := proto.Marshal(response)
+		if err != nil {
+			h.logger.WithField("error", err).Error("Failed to marshal protobuf")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "marshal_error",
+				"message": "Failed to serialize response",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "application/x-protobuf", data)
+	} else {
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"messages": convertMessagesToJSONArray(messages),
+		})
+	}
+}
+
+// GetLandmarks возвращает ориентиры и зоны (FANET Type 5) в радиусе в формате GeoJSON
+// GET /api/v1/landmarks?lat=46.5&lon=15.6&radius=50
+func (h *RESTHandler) GetLandmarks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_latitude",
+			"message": "Latitude must be between -90 and 90",
+		})
+		return
+	}
+
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_longitude",
+			"message": "Longitude must be between -180 and 180",
+		})
+		return
+	}
+
+	radius, err := strconv.Atoi(c.Query("radius"))
+	if err != nil || radius < 1 || radius > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_radius",
+			"message": "Radius must be between 1 and 200 km",
+		})
+		return
+	}
+
+	center := models.GeoPoint{
+		Latitude:  lat,
+		Longitude: lon,
+	}
+
+	landmarks, err := h.repo.GetLandmarksInRadius(ctx, center, float64(radius))
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get landmarks")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "internal_error",
+			"message": "Failed to retrieve landmarks",
+		})
+		return
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+		response := &pb.LandmarksResponse{
+			Landmarks: convertLandmarksToProto(landmarks),
+		}
+		data, err := proto.Marshal(response)
+		if err != nil {
+			h.logger.WithField("error", err).Error("Failed to marshal protobuf")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    "marshal_error",
+				"message": "Failed to serialize response",
+			})
+			return
+		}
+		c.Data(http.StatusOK, "application/x-protobuf", data)
+	} else {
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, convertLandmarksToGeoJSON(landmarks))
+	}
+}
+
 // GetTrack возвращает трек полета пилота
 // GET /api/v1/track/{addr}?hours=12&format=geojson&filter-level=1
 // Параметры:
 //   - hours: количество часов истории (1-12, по умолчанию 12)
-//   - format: формат ответа (json/geojson, по умолчанию json)
+//   - from, to: произвольный период в RFC3339 (до 31 дня) вместо hours. С filter-level=0
+//     трек json/geojson/ndjson отдается потоком по страницам, cursor/page_size - одна страница
+//   - max_points: прореживание по времени до заданного числа точек
+//   - zoom: упрощение Douglas-Peucker для масштаба карты (версии для всех масштабов кэшируются в Redis)
+//   - tolerance_m: упрощение с произвольным допуском в метрах (без кэша)
+//   - format: формат ответа (json/geojson, ndjson для потока, по умолчанию geojson) или файл для загрузки (igc/gpx/kml)
+//   - debug: true - вернуть отклоненные фильтрами точки с именем фильтра и причиной (json/geojson)
 //   - filter-level: уровень фильтрации (0-3, по умолчанию 0)
 //     0 - без фильтрации (raw data)
 //     1 - базовая: дубли + телепортации >200км
@@ -540,10 +723,53 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 	format := c.DefaultQuery("format", "geojson")
 	
 	// Валидация формата
-	if format != "json" && format != "geojson" {
+	exportFormat, isExport := export.ParseFormat(format)
+	if format != "json" && format != "geojson" && format != "ndjson" && !isExport {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    "invalid_format",
-			"message": "Invalid format parameter. Supported: json, geojson",
+			"message": "Invalid format parameter. Supported: json, geojson, ndjson, igc, gpx, kml",
+		})
+		return
+	}
+
+	// Произвольный период (from/to) вместо последних hours часов
+	from, to, isRange, err := parseTrackRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_time_range",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	maxPoints, err := parseMaxPoints(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_max_points",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Упрощение для карты; файлы для загрузки отдаются в полном разрешении
+	lod, err := parseTrackLOD(c)
+	if err == nil && lod != nil && isExport {
+		err = fmt.Errorf("zoom and tolerance_m are not supported for file formats")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_lod",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Диагностика фильтрации: отклоненные точки отдаются только в JSON и GeoJSON
+	debug := c.Query("debug") == "true"
+	if debug && (isExport || format == "ndjson" || strings.Contains(c.GetHeader("Accept"), "application/x-protobuf")) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_debug",
+			"message": "debug is supported only for json and geojson formats",
 		})
 		return
 	}
@@ -555,6 +781,15 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 		filterLevel = lvl
 	}
 
+	// ndjson - только потоковая выдача необработанного трека за период
+	if format == "ndjson" && (!isRange || filterLevel > 0 || lod != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_format",
+			"message": "ndjson format requires from/to and filter-level=0 without zoom/tolerance_m",
+		})
+		return
+	}
+
 	// Проверяем доступность historyRepo
 	if h.historyRepo == nil {
 		h.logger.Error("MySQL repository not available")
@@ -568,8 +803,73 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 	// Получаем трек из MySQL базы данных с временными метками
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
-	
-	trackWithTimestamps, err := h.historyRepo.GetPilotTrackWithTimestamps(ctx, addrStr, 1000)
+
+	// Приводим адрес к ID устройства: FANET адрес или ID с префиксом источника (FLRDDA5BA)
+	deviceID, err := models.ParseDeviceID(addrStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    "invalid_addr_format",
+			"message": "Invalid FANET address format",
+		})
+		return
+	}
+	addrStr = deviceID
+	addr, _ := models.DeviceAddr(addrStr)
+
+	wantsProtobuf := strings.Contains(c.GetHeader("Accept"), "application/x-protobuf")
+
+	startTime, endTime := time.Now().Add(-time.Duration(hours)*time.Hour), time.Now()
+	if isRange {
+		startTime, endTime = from, to
+	}
+
+	// Готовая версия трека для масштаба карты из Redis
+	var lodKey string
+	var lodTTL time.Duration
+	if lod != nil && !debug {
+		// В кэше нет отклоненных точек
+		lodKey, lodTTL = h.trackLODCacheKey(lod, addrStr, filterLevel, isRange, hours, from, to)
+	}
+	if lodKey != "" {
+		cached, err := h.lodCache.Get(ctx, lodKey, lod.zoom)
+		if err != nil {
+			h.logger.WithField("error", err).WithField("key", lodKey).Warn("Failed to get track LOD from cache")
+		} else if cached != nil {
+			if maxPoints > 0 {
+				cached.Points = filter.Downsample(cached.Points, maxPoints)
+			}
+			var filterResult *filter.FilterResult
+			if filterLevel > 0 {
+				filterResult = cached
+			}
+			h.writeTrackResponse(c, uint32(addr), format, wantsProtobuf, filterResult, convertFilteredTrackToGeoPoints(cached), startTime, endTime)
+			return
+		}
+	}
+
+	var trackWithTimestamps []models.TrackGeoPoint
+	if isRange {
+		// Постраничное чтение клиентом или поток без фильтрации не собирают трек в памяти
+		if c.Query("cursor") != "" || c.Query("page_size") != "" {
+			h.getTrackPage(c, addrStr, from, to)
+			return
+		}
+		if filterLevel == 0 && !isExport && !wantsProtobuf && lod == nil {
+			h.streamTrackRange(c, addrStr, uint32(addr), format, from, to, maxPoints)
+			return
+		}
+
+		trackWithTimestamps, err = h.loadTrackRange(c, addrStr, from, to)
+		if errors.Is(err, errTrackTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "track_too_large",
+				"message": "Track is too large for filtering, narrow the time range or use filter-level=0",
+			})
+			return
+		}
+	} else {
+		trackWithTimestamps, err = h.historyRepo.GetPilotTrackWithTimestamps(ctx, addrStr, 1000)
+	}
 	if err != nil {
 		h.logger.WithField("error", err).WithField("addr", addrStr).Error("Failed to get pilot track")
 		c.JSON(http.StatusNotFound, gin.H{
@@ -587,16 +887,6 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 		return
 	}
 
-	// Конвертируем адрес в uint32
-	addr, err := strconv.ParseUint(addrStr, 16, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    "invalid_addr_format",
-			"message": "Invalid FANET address format",
-		})
-		return
-	}
-
 	// Применяем фильтры в зависимости от уровня
 	var filterResult *filter.FilterResult
 	var filteredTrack []models.GeoPoint
@@ -615,7 +905,7 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 			aircraftType = models.PilotTypeUnknown
 		}
 		
-		filterResult, err = applyTrackFiltersWithTimestamps(trackWithTimestamps, addrStr, aircraftType, filterLevel, h.logger)
+		filterResult, err = applyTrackFiltersWithTimestamps(trackWithTimestamps, addrStr, aircraftType, filterLevel, h.filterChains(), debug, h.logger)
 		if err != nil {
 			h.logger.WithField("error", err).WithField("device_id", addrStr).
 				Warn("Failed to apply track filters, using original data")
@@ -641,16 +931,64 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 		}
 	}
 
+	// LOD: упрощение после фильтрации с сохранением границ сегментов
+	if lod != nil {
+		lodResult := filterResult
+		if lodResult == nil {
+			lodResult = &filter.FilterResult{
+				OriginalCount: len(trackWithTimestamps),
+				Points:        convertTrackGeoPointsToTrackData(trackWithTimestamps, addrStr, models.PilotTypeUnknown).Points,
+			}
+		}
+
+		lodResult = h.simplifyTrack(ctx, lod, lodKey, lodTTL, lodResult)
+		filteredTrack = convertFilteredTrackToGeoPoints(lodResult)
+		if filterResult != nil {
+			filterResult = lodResult
+		} else {
+			trackWithTimestamps = filterTrackWithTimestamps(trackWithTimestamps, lodResult)
+		}
+	}
+
+	// Прореживание после фильтрации: фильтрам нужна исходная плотность точек
+	if maxPoints > 0 {
+		if filterResult != nil {
+			filterResult.Points = filter.Downsample(filterResult.Points, maxPoints)
+			filteredTrack = convertFilteredTrackToGeoPoints(filterResult)
+		} else {
+			trackWithTimestamps = downsampleTrack(trackWithTimestamps, maxPoints)
+			filteredTrack = make([]models.GeoPoint, len(trackWithTimestamps))
+			for i, pt := range trackWithTimestamps {
+				filteredTrack[i] = pt.GeoPoint
+			}
+		}
+	}
+
+	// Файл для загрузки (XContest, Google Earth): точки после выбранного уровня фильтрации
+	if isExport {
+		points := trackWithTimestamps
+		if filterResult != nil {
+			points = filterTrackWithTimestamps(trackWithTimestamps, filterResult)
+		}
+		writeTrackFile(c, h.logger, exportFormat, h.trackExportInfo(ctx, addrStr), points)
+		return
+	}
+
+	h.writeTrackResponse(c, uint32(addr), format, wantsProtobuf, filterResult, filteredTrack, startTime, endTime)
+}
+
+// writeTrackResponse отдает трек в protobuf, GeoJSON или JSON. filterResult - nil без фильтрации
+func (h *RESTHandler) writeTrackResponse(c *gin.Context, addr uint32, format string, wantsProtobuf bool, filterResult *filter.FilterResult, filteredTrack []models.GeoPoint, startTime, endTime time.Time) {
 	response := &pb.TrackResponse{
 		Track: &pb.Track{
-			Addr:      uint32(addr),
+			Addr:      addr,
 			Points:    convertTrackToProto(filteredTrack),
-			StartTime: time.Now().Add(-time.Duration(hours) * time.Hour).Unix(),
-			EndTime:   time.Now().Unix(),
+			StartTime: startTime.Unix(),
+			EndTime:   endTime.Unix(),
 		},
 	}
 
-	if strings.Contains(c.GetHeader("Accept"), "application/x-protobuf") {
+	if wantsProtobuf {
 		data, err := proto.Marshal(response)
 		if err != nil {
 			h.logger.WithField("error", err).Error("Failed to marshal protobuf")
@@ -662,13 +1000,13 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 		}
 		c.Data(http.StatusOK, "application/x-protobuf", data)
 	} else if format == "geojson" {
-		if filterLevel > 0 && filterResult != nil {
+		if filterResult != nil {
 			c.JSON(http.StatusOK, convertTrackToGeoJSONWithFilter(response.Track, filterResult))
 		} else {
 			c.JSON(http.StatusOK, convertTrackToGeoJSON(response.Track))
 		}
 	} else {
-		if filterLevel > 0 && filterResult != nil {
+		if filterResult != nil {
 			c.JSON(http.StatusOK, convertTrackToJSONWithFilter(response.Track, filterResult))
 		} else {
 			c.JSON(http.StatusOK, convertTrackToJSON(response.Track))
@@ -676,6 +1014,56 @@ func (h *RESTHandler) GetTrack(c *gin.Context) {
 	}
 }
 
+// trackExportInfo собирает сведения для заголовков файла трека: тип ЛА и имя пилота
+// (из Redis, если пилот онлайн, иначе из MySQL)
+func (h *RESTHandler) trackExportInfo(ctx context.Context, deviceID string) export.TrackInfo {
+	info := export.TrackInfo{DeviceID: deviceID}
+
+	if pilot, err := h.repo.GetPilot(ctx, deviceID); err == nil && pilot != nil {
+		info.PilotName = pilot.Name
+		info.AircraftType = pilot.Type
+	}
+
+	if info.AircraftType == models.PilotTypeUnknown {
+		if aircraftType, err := h.historyRepo.GetPilotAircraftType(ctx, deviceID); err == nil {
+			info.AircraftType = aircraftType
+		}
+	}
+	if info.PilotName == "" {
+		if name, err := h.historyRepo.GetPilotName(ctx, deviceID); err == nil {
+			info.PilotName = name
+		} else {
+			h.logger.WithField("error", err).WithField("device_id", deviceID).Debug("Failed to get pilot name")
+		}
+	}
+
+	return info
+}
+
+// writeTrackFile отдает трек файлом для загрузки (Content-Disposition: attachment)
+func writeTrackFile(c *gin.Context, logger *utils.Logger, format export.Format, info export.TrackInfo, points []models.TrackGeoPoint) {
+	if len(points) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "track_empty",
+			"message": "No track data available",
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, info, points); err != nil {
+		logger.WithField("error", err).WithField("format", format).Error("Failed to export track")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "export_error",
+			"message": "Failed to export track",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename(info, points, format)))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
 // PostPosition принимает позицию от пилота (требует аутентификации)
 // POST /api/v1/position
 func (h *RESTHandler) PostPosition(c *gin.Context) {
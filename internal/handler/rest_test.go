@@ -12,6 +12,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/repository"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
 	"github.com/flybeeper/fanet-backend/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,125 +26,288 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) GetPilotsInRadius(ctx context.Context, center models.GeoPoint, radiusKm int, types []models.PilotType) ([]*models.Pilot, error) {
-	args := m.Called(ctx, center, radiusKm, types)
+var _ repository.Repository = (*MockRepository)(nil)
+
+func (m *MockRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRepository) SavePilot(ctx context.Context, pilot *models.Pilot) error {
+	args := m.Called(ctx, pilot)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPilotsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Pilot, error) {
+	args := m.Called(ctx, center, radiusKM)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Pilot), args.Error(1)
 }
 
-func (m *MockRepository) GetThermalsInRadius(ctx context.Context, center models.GeoPoint, radiusKm int) ([]*models.Thermal, error) {
-	args := m.Called(ctx, center, radiusKm)
+func (m *MockRepository) GetPilot(ctx context.Context, deviceID string) (*models.Pilot, error) {
+	args := m.Called(ctx, deviceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pilot), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePilotName(ctx context.Context, deviceID string, name string) error {
+	args := m.Called(ctx, deviceID, name)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeletePilot(ctx context.Context, deviceID string) error {
+	args := m.Called(ctx, deviceID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RemovePilot(ctx context.Context, deviceID string) error {
+	args := m.Called(ctx, deviceID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveThermal(ctx context.Context, thermal *models.Thermal) error {
+	args := m.Called(ctx, thermal)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetThermalsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Thermal, error) {
+	args := m.Called(ctx, center, radiusKM)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Thermal), args.Error(1)
 }
 
-func (m *MockRepository) GetStationsInRadius(ctx context.Context, center models.GeoPoint, radiusKm int) ([]*models.Station, error) {
-	args := m.Called(ctx, center, radiusKm)
+func (m *MockRepository) SaveStation(ctx context.Context, station *models.Station) error {
+	args := m.Called(ctx, station)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetStationsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Station, error) {
+	args := m.Called(ctx, center, radiusKM)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Station), args.Error(1)
 }
 
-func (m *MockRepository) GetGroundObjectsInRadius(ctx context.Context, center models.GeoPoint, radiusKm int, types []models.GroundType) ([]*models.GroundObject, error) {
-	args := m.Called(ctx, center, radiusKm, types)
+func (m *MockRepository) GetAllStations(ctx context.Context) ([]*models.Station, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.GroundObject), args.Error(1)
+	return args.Get(0).([]*models.Station), args.Error(1)
 }
 
-func (m *MockRepository) StorePilot(ctx context.Context, pilot *models.Pilot) error {
-	args := m.Called(ctx, pilot)
+func (m *MockRepository) SaveGroundObject(ctx context.Context, groundObject *models.GroundObject) error {
+	args := m.Called(ctx, groundObject)
 	return args.Error(0)
 }
 
-func (m *MockRepository) StoreThermal(ctx context.Context, thermal *models.Thermal) error {
-	args := m.Called(ctx, thermal)
+func (m *MockRepository) GetGroundObjectsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.GroundObject, error) {
+	args := m.Called(ctx, center, radiusKM)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.GroundObject), args.Error(1)
+}
+
+func (m *MockRepository) DeleteGroundObject(ctx context.Context, deviceID string) error {
+	args := m.Called(ctx, deviceID)
 	return args.Error(0)
 }
 
-func (m *MockRepository) StoreStation(ctx context.Context, station *models.Station) error {
-	args := m.Called(ctx, station)
+func (m *MockRepository) SaveMessage(ctx context.Context, message *models.Message) error {
+	args := m.Called(ctx, message)
 	return args.Error(0)
 }
 
-func (m *MockRepository) StoreGroundObject(ctx context.Context, ground *models.GroundObject) error {
-	args := m.Called(ctx, ground)
+func (m *MockRepository) GetMessagesInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Message, error) {
+	args := m.Called(ctx, center, radiusKM)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockRepository) SaveLandmark(ctx context.Context, landmark *models.Landmark) error {
+	args := m.Called(ctx, landmark)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetPilot(ctx context.Context, deviceID string) (*models.Pilot, error) {
-	args := m.Called(ctx, deviceID)
+func (m *MockRepository) GetLandmarksInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Landmark, error) {
+	args := m.Called(ctx, center, radiusKM)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Pilot), args.Error(1)
+	return args.Get(0).([]*models.Landmark), args.Error(1)
 }
 
-func (m *MockRepository) DeletePilot(ctx context.Context, deviceID string) error {
-	args := m.Called(ctx, deviceID)
+func (m *MockRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+// MockHistoryRepository для тестирования
+type MockHistoryRepository struct {
+	mock.Mock
+}
+
+var _ repository.HistoryRepository = (*MockHistoryRepository)(nil)
+
+func (m *MockHistoryRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
 	return args.Error(0)
 }
 
-func (m *MockRepository) DeleteThermal(ctx context.Context, deviceID string) error {
-	args := m.Called(ctx, deviceID)
+func (m *MockHistoryRepository) Close() error {
+	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockRepository) DeleteStation(ctx context.Context, deviceID string) error {
+func (m *MockHistoryRepository) LoadInitialPilots(ctx context.Context, limit int) ([]*models.Pilot, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Pilot), args.Error(1)
+}
+
+func (m *MockHistoryRepository) LoadInitialThermals(ctx context.Context, limit int) ([]*models.Thermal, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Thermal), args.Error(1)
+}
+
+func (m *MockHistoryRepository) LoadInitialStations(ctx context.Context, limit int) ([]*models.Station, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Station), args.Error(1)
+}
+
+func (m *MockHistoryRepository) GetThermalHistory(ctx context.Context, filter *models.ThermalHeatmapFilter, limit int) ([]*models.Thermal, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Thermal), args.Error(1)
+}
+
+func (m *MockHistoryRepository) GetPilotTrack(ctx context.Context, deviceID string, limit int) ([]models.GeoPoint, error) {
+	args := m.Called(ctx, deviceID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GeoPoint), args.Error(1)
+}
+
+func (m *MockHistoryRepository) GetPilotTrackWithTimestamps(ctx context.Context, deviceID string, limit int) ([]models.TrackGeoPoint, error) {
+	args := m.Called(ctx, deviceID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TrackGeoPoint), args.Error(1)
+}
+
+func (m *MockHistoryRepository) GetPilotAircraftType(ctx context.Context, deviceID string) (models.PilotType, error) {
 	args := m.Called(ctx, deviceID)
-	return args.Error(0)
+	return args.Get(0).(models.PilotType), args.Error(1)
 }
 
-func (m *MockRepository) DeleteGroundObject(ctx context.Context, deviceID string) error {
+func (m *MockHistoryRepository) GetPilotName(ctx context.Context, deviceID string) (string, error) {
 	args := m.Called(ctx, deviceID)
-	return args.Error(0)
+	return args.String(0), args.Error(1)
 }
 
-func (m *MockRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
-	args := m.Called(ctx)
-	return args.Get(0).(map[string]interface{}), args.Error(1)
+func (m *MockHistoryRepository) GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error) {
+	args := m.Called(ctx, deviceID, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TrackGeoPoint), args.Error(1)
 }
 
-func (m *MockRepository) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
-	args := m.Called(ctx, maxAge)
-	return args.Int(0), args.Error(1)
+func (m *MockHistoryRepository) GetPilotTrackRange(ctx context.Context, deviceID string, query *models.TrackRangeQuery) (*models.TrackPage, error) {
+	args := m.Called(ctx, deviceID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TrackPage), args.Error(1)
 }
 
-// MockHistoryRepository для тестирования
-type MockHistoryRepository struct {
-	mock.Mock
+func (m *MockHistoryRepository) CreateFlight(ctx context.Context, flight *models.Flight) (int64, error) {
+	args := m.Called(ctx, flight)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockHistoryRepository) UpdateFlight(ctx context.Context, flight *models.Flight) error {
+	args := m.Called(ctx, flight)
+	return args.Error(0)
 }
 
-func (m *MockHistoryRepository) GetTrack(ctx context.Context, deviceID string, startTime, endTime time.Time) ([]*models.TrackPoint, error) {
-	args := m.Called(ctx, deviceID, startTime, endTime)
+func (m *MockHistoryRepository) GetFlights(ctx context.Context, deviceID string, limit int) ([]*models.Flight, error) {
+	args := m.Called(ctx, deviceID, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.TrackPoint), args.Error(1)
+	return args.Get(0).([]*models.Flight), args.Error(1)
 }
 
-func (m *MockHistoryRepository) StoreTrackPoint(ctx context.Context, point *models.TrackPoint) error {
-	args := m.Called(ctx, point)
+func (m *MockHistoryRepository) GetFlight(ctx context.Context, id int64) (*models.Flight, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Flight), args.Error(1)
+}
+
+func (m *MockHistoryRepository) SavePilotToHistory(ctx context.Context, pilot *models.Pilot) error {
+	args := m.Called(ctx, pilot)
+	return args.Error(0)
+}
+
+func (m *MockHistoryRepository) CleanupOldTracks(ctx context.Context, olderThan time.Duration) error {
+	args := m.Called(ctx, olderThan)
 	return args.Error(0)
 }
 
+func (m *MockHistoryRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
 // Создаем тестовую Gin engine без middleware
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
 }
 
+// mockEmptySnapshot настраивает пустые ответы репозитория для всех типов объектов snapshot
+func mockEmptySnapshot(mockRepo *MockRepository, center models.GeoPoint, radius float64) {
+	mockRepo.On("GetThermalsInRadius", mock.Anything, center, radius).Return([]*models.Thermal{}, nil)
+	mockRepo.On("GetAllStations", mock.Anything).Return([]*models.Station{}, nil)
+	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, radius).Return([]*models.GroundObject{}, nil)
+	mockRepo.On("GetLandmarksInRadius", mock.Anything, center, radius).Return([]*models.Landmark{}, nil)
+}
+
 func TestRESTHandler_GetSnapshot_ValidParams(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	// Настраиваем моки
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
@@ -159,25 +324,28 @@ func TestRESTHandler_GetSnapshot_ValidParams(t *testing.T) {
 	
 	mockThermals := []*models.Thermal{
 		{
-			DeviceID: "THERM01",
-			Position: &center,
-			Quality:  3,
-			LastUpdate: time.Now(),
+			ID:         "1",
+			ReportedBy: "ABC123",
+			Position:   &center,
+			Quality:    3,
+			Timestamp:  time.Now(),
+			LastSeen:   time.Now(),
 		},
 	}
 	
 	mockStations := []*models.Station{
 		{
-			DeviceID: "STAT01",
+			ID:       "STAT01",
 			Position: &center,
 			LastUpdate: time.Now(),
 		},
 	}
 
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.PilotType")).Return(mockPilots, nil)
-	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50).Return(mockThermals, nil)
-	mockRepo.On("GetStationsInRadius", mock.Anything, center, 50).Return(mockStations, nil)
-	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.GroundType")).Return([]*models.GroundObject{}, nil)
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return(mockPilots, nil)
+	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50.0).Return(mockThermals, nil)
+	mockRepo.On("GetAllStations", mock.Anything).Return(mockStations, nil)
+	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50.0).Return([]*models.GroundObject{}, nil)
+	mockRepo.On("GetLandmarksInRadius", mock.Anything, center, 50.0).Return([]*models.Landmark{}, nil)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -196,7 +364,7 @@ func TestRESTHandler_GetSnapshot_ValidParams(t *testing.T) {
 	assert.Contains(t, response, "pilots")
 	assert.Contains(t, response, "thermals")
 	assert.Contains(t, response, "stations")
-	assert.Contains(t, response, "ground")
+	assert.Contains(t, response, "ground_objects")
 
 	pilots := response["pilots"].([]interface{})
 	assert.Len(t, pilots, 1)
@@ -208,7 +376,7 @@ func TestRESTHandler_GetSnapshot_InvalidParams(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -297,15 +465,13 @@ func TestRESTHandler_GetSnapshot_WithTypeFilters(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
-	// Настраиваем моки для фильтрации типов
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50, []models.PilotType{models.PilotTypeParaglider, models.PilotTypeHangglider}).Return([]*models.Pilot{}, nil)
-	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50).Return([]*models.Thermal{}, nil)
-	mockRepo.On("GetStationsInRadius", mock.Anything, center, 50).Return([]*models.Station{}, nil)
-	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50, []models.GroundType{models.GroundTypeCar, models.GroundTypeTruck}).Return([]*models.GroundObject{}, nil)
+	// Репозиторий возвращает все типы, фильтрация по типам выполняется в handler
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return([]*models.Pilot{}, nil)
+	mockEmptySnapshot(mockRepo, center, 50.0)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -324,7 +490,7 @@ func TestRESTHandler_GetSnapshot_InvalidTypeFilters(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -372,12 +538,12 @@ func TestRESTHandler_GetSnapshot_RepositoryError(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
 	// Симулируем ошибку репозитория
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.PilotType")).Return(nil, fmt.Errorf("database error"))
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return(nil, fmt.Errorf("database error"))
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -392,7 +558,7 @@ func TestRESTHandler_GetSnapshot_RepositoryError(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Equal(t, "database_error", response["code"])
+	assert.Equal(t, "internal_error", response["code"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -401,7 +567,7 @@ func TestRESTHandler_GetSnapshot_Protobuf(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
@@ -415,10 +581,8 @@ func TestRESTHandler_GetSnapshot_Protobuf(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.PilotType")).Return(mockPilots, nil)
-	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50).Return([]*models.Thermal{}, nil)
-	mockRepo.On("GetStationsInRadius", mock.Anything, center, 50).Return([]*models.Station{}, nil)
-	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.GroundType")).Return([]*models.GroundObject{}, nil)
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return(mockPilots, nil)
+	mockEmptySnapshot(mockRepo, center, 50.0)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
@@ -442,7 +606,7 @@ func TestRESTHandler_GetPilots(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
@@ -456,23 +620,26 @@ func TestRESTHandler_GetPilots(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 100, mock.AnythingOfType("[]models.PilotType")).Return(mockPilots, nil)
+	// Центр и радиус запроса вычисляются из bounds
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, mock.AnythingOfType("float64")).Return(mockPilots, nil)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/pilots", handler.GetPilots)
 
-	req := httptest.NewRequest("GET", "/api/v1/pilots?lat=46.0&lon=8.0&radius=100", nil)
+	req := httptest.NewRequest("GET", "/api/v1/pilots?bounds=45.5,7.5,46.5,8.5", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var pilots []*models.Pilot
-	err := json.Unmarshal(w.Body.Bytes(), &pilots)
+	var response struct {
+		Pilots []map[string]interface{} `json:"pilots"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Len(t, pilots, 1)
-	assert.Equal(t, "ABC123", pilots[0].DeviceID)
+	assert.Len(t, response.Pilots, 1)
+	assert.Equal(t, float64(0xABC123), response.Pilots[0]["addr"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -481,36 +648,40 @@ func TestRESTHandler_GetThermals(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
 	mockThermals := []*models.Thermal{
 		{
-			DeviceID: "THERM01",
-			Position: &center,
-			Quality:  4,
-			LastUpdate: time.Now(),
+			ID:         "1",
+			ReportedBy: "ABC123",
+			Position:   &center,
+			Quality:    4,
+			Timestamp:  time.Now(),
+			LastSeen:   time.Now(),
 		},
 	}
 
-	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 75, mock.AnythingOfType("[]models.ThermalType")).Return(mockThermals, nil)
+	mockRepo.On("GetThermalsInRadius", mock.Anything, center, mock.AnythingOfType("float64")).Return(mockThermals, nil)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/thermals", handler.GetThermals)
 
-	req := httptest.NewRequest("GET", "/api/v1/thermals?lat=46.0&lon=8.0&radius=75", nil)
+	req := httptest.NewRequest("GET", "/api/v1/thermals?bounds=45.5,7.5,46.5,8.5", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var thermals []*models.Thermal
-	err := json.Unmarshal(w.Body.Bytes(), &thermals)
+	var response struct {
+		Thermals []map[string]interface{} `json:"thermals"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Len(t, thermals, 1)
-	assert.Equal(t, "THERM01", thermals[0].DeviceID)
+	assert.Len(t, response.Thermals, 1)
+	assert.Equal(t, float64(1), response.Thermals[0]["id"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -519,50 +690,46 @@ func TestRESTHandler_GetTrack(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	deviceID := "ABC123"
-	now := time.Now()
-	startTime := now.Add(-1 * time.Hour)
-	endTime := now
+	startTime := time.Now().Add(-1 * time.Hour)
 
-	mockTrackPoints := []*models.TrackPoint{
+	mockTrackPoints := []models.TrackGeoPoint{
 		{
-			DeviceID:  deviceID,
-			Position:  &models.GeoPoint{Latitude: 46.0, Longitude: 8.0},
-			Altitude:  1000,
+			GeoPoint:  models.GeoPoint{Latitude: 46.0, Longitude: 8.0, Altitude: 1000},
 			Timestamp: startTime.Add(10 * time.Minute),
 		},
 		{
-			DeviceID:  deviceID,
-			Position:  &models.GeoPoint{Latitude: 46.01, Longitude: 8.01},
-			Altitude:  1100,
+			GeoPoint:  models.GeoPoint{Latitude: 46.01, Longitude: 8.01, Altitude: 1100},
 			Timestamp: startTime.Add(20 * time.Minute),
 		},
 	}
 
-	mockHistoryRepo.On("GetTrack", mock.Anything, deviceID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(mockTrackPoints, nil)
+	mockHistoryRepo.On("GetPilotTrackWithTimestamps", mock.Anything, deviceID, 1000).Return(mockTrackPoints, nil)
 
 	router := setupTestRouter()
-	router.GET("/api/v1/track/:device_id", handler.GetTrack)
-
-	// Тестируем с временным диапазоном
-	reqURL := fmt.Sprintf("/api/v1/track/%s?start=%d&end=%d", 
-		deviceID, 
-		startTime.Unix(), 
-		endTime.Unix())
-	req := httptest.NewRequest("GET", reqURL, nil)
+	router.GET("/api/v1/track/:addr", handler.GetTrack)
+
+	// Трек за последние часы без фильтрации
+	req := httptest.NewRequest("GET", "/api/v1/track/"+deviceID+"?format=json&filter-level=0", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var track []*models.TrackPoint
-	err := json.Unmarshal(w.Body.Bytes(), &track)
+	var response struct {
+		Track struct {
+			Addr   uint32                   `json:"addr"`
+			Points []map[string]interface{} `json:"points"`
+		} `json:"track"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Len(t, track, 2)
-	assert.Equal(t, deviceID, track[0].DeviceID)
+	assert.Equal(t, uint32(0xABC123), response.Track.Addr)
+	require.Len(t, response.Track.Points, 2)
+	assert.Equal(t, float64(1000), response.Track.Points[0]["altitude"])
 
 	mockHistoryRepo.AssertExpectations(t)
 }
@@ -571,7 +738,7 @@ func TestRESTHandler_PostPosition_RequiresAuth(t *testing.T) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	router := setupTestRouter()
 	router.POST("/api/v1/position", handler.PostPosition)
@@ -587,14 +754,11 @@ func TestRESTHandler_PostPosition_RequiresAuth(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
-func TestRESTHandler_HealthCheck(t *testing.T) {
-	mockRepo := &MockRepository{}
-	mockHistoryRepo := &MockHistoryRepository{}
-	logger := utils.NewLogger("info", "text")
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+func TestServer_HealthCheck(t *testing.T) {
+	server := &Server{}
 
 	router := setupTestRouter()
-	router.GET("/health", handler.HealthCheck)
+	router.GET("/health", server.healthCheck)
 
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
@@ -606,16 +770,195 @@ func TestRESTHandler_HealthCheck(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 
-	assert.Equal(t, "healthy", response["status"])
+	assert.Equal(t, "ok", response["status"])
 	assert.Contains(t, response, "timestamp")
 }
 
+func TestRESTHandler_GetSnapshot_FiltersTypes(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
+
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return([]*models.Pilot{
+		{DeviceID: "000001", Type: models.PilotTypeParaglider, Position: &center, LastUpdate: time.Now()},
+		{DeviceID: "000002", Type: models.PilotTypeHangglider, Position: &center, LastUpdate: time.Now()},
+		{DeviceID: "000003", Type: models.PilotTypeGlider, Position: &center, LastUpdate: time.Now()},
+	}, nil)
+	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50.0).Return([]*models.Thermal{}, nil)
+	mockRepo.On("GetAllStations", mock.Anything).Return([]*models.Station{}, nil)
+	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50.0).Return([]*models.GroundObject{
+		{DeviceID: "000011", Type: models.GroundTypeWalking, Position: &center, LastUpdate: time.Now()},
+		{DeviceID: "000012", Type: models.GroundTypeVehicle, Position: &center, LastUpdate: time.Now()},
+		{DeviceID: "000013", Type: models.GroundTypeNeedMedicalHelp, Position: &center, LastUpdate: time.Now()},
+	}, nil)
+	mockRepo.On("GetLandmarksInRadius", mock.Anything, center, 50.0).Return([]*models.Landmark{}, nil)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/snapshot", handler.GetSnapshot)
+
+	req := httptest.NewRequest("GET", "/api/v1/snapshot?lat=46.0&lon=8.0&radius=50&air-types=1,2&ground-types=1,2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.Len(t, response["pilots"].([]interface{}), 2)
+	assert.Len(t, response["ground_objects"].([]interface{}), 2)
+}
+
+func TestRESTHandler_GetSnapshot_InvalidQuery(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/snapshot", handler.GetSnapshot)
+
+	tests := []struct {
+		queryParams  string
+		expectedCode string
+	}{
+		{queryParams: "lat=46.0&lon=8.0&radius=50&max_age=-1", expectedCode: "invalid_max_age"},
+		{queryParams: "lat=46.0&lon=8.0&radius=50&ground-types=5", expectedCode: "invalid_ground_types"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/snapshot?"+tt.queryParams, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.queryParams)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, tt.expectedCode, response["code"], tt.queryParams)
+	}
+
+	// Невалидные запросы не доходят до репозитория
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRESTHandler_GetSnapshot_ProtobufPilots(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
+
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return([]*models.Pilot{
+		{DeviceID: "ABC123", Type: models.PilotTypeParaglider, Position: &center, Name: "Test Pilot", LastUpdate: time.Now()},
+	}, nil)
+	mockEmptySnapshot(mockRepo, center, 50.0)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/snapshot", handler.GetSnapshot)
+
+	req := httptest.NewRequest("GET", "/api/v1/snapshot?lat=46.0&lon=8.0&radius=50", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response pb.SnapshotResponse
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Pilots, 1)
+	assert.Equal(t, uint32(0xABC123), response.Pilots[0].Addr)
+	assert.Equal(t, "Test Pilot", response.Pilots[0].Name)
+}
+
+func TestRESTHandler_GetPilots_InvalidBounds(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/pilots", handler.GetPilots)
+
+	for _, query := range []string{"", "bounds=45.5,7.5,46.5", "bounds=a,b,c,d"} {
+		req := httptest.NewRequest("GET", "/api/v1/pilots?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_bounds", response["code"], query)
+	}
+}
+
+func TestRESTHandler_GetThermals_MinQuality(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
+
+	mockRepo.On("GetThermalsInRadius", mock.Anything, center, mock.AnythingOfType("float64")).Return([]*models.Thermal{
+		{ID: "1", ReportedBy: "ABC123", Position: &center, Quality: 4, Timestamp: time.Now(), LastSeen: time.Now()},
+		{ID: "2", ReportedBy: "ABC124", Position: &center, Quality: 2, Timestamp: time.Now(), LastSeen: time.Now()},
+	}, nil)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/thermals", handler.GetThermals)
+
+	// Термики ниже min_quality отбрасываются
+	req := httptest.NewRequest("GET", "/api/v1/thermals?bounds=45.5,7.5,46.5,8.5&min_quality=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Thermals []map[string]interface{} `json:"thermals"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	require.Len(t, response.Thermals, 1)
+	assert.Equal(t, float64(4), response.Thermals[0]["quality"])
+}
+
+func TestRESTHandler_GetTrack_NotFound(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockHistoryRepo := &MockHistoryRepository{}
+	logger := utils.NewLogger("info", "text")
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
+
+	mockHistoryRepo.On("GetPilotTrackWithTimestamps", mock.Anything, "ABC123", 1000).Return([]models.TrackGeoPoint{}, nil)
+
+	router := setupTestRouter()
+	router.GET("/api/v1/track/:addr", handler.GetTrack)
+
+	req := httptest.NewRequest("GET", "/api/v1/track/ABC123?format=json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "track_empty", response["code"])
+
+	mockHistoryRepo.AssertExpectations(t)
+}
+
 // Benchmark тесты
 func BenchmarkRESTHandler_GetSnapshot(b *testing.B) {
 	mockRepo := &MockRepository{}
 	mockHistoryRepo := &MockHistoryRepository{}
 	logger := utils.NewLogger("error", "text") // Минимальное логирование
-	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger)
+	handler := NewRESTHandler(mockRepo, mockHistoryRepo, logger, nil)
 
 	center := models.GeoPoint{Latitude: 46.0, Longitude: 8.0}
 
@@ -623,7 +966,7 @@ func BenchmarkRESTHandler_GetSnapshot(b *testing.B) {
 	pilots := make([]*models.Pilot, 1000)
 	for i := 0; i < 1000; i++ {
 		pilots[i] = &models.Pilot{
-			DeviceID: fmt.Sprintf("PILOT%04d", i),
+			DeviceID: fmt.Sprintf("%06X", i),
 			Type:     models.PilotTypeParaglider,
 			Position: &models.GeoPoint{
 				Latitude:  46.0 + float64(i)*0.001,
@@ -634,10 +977,8 @@ func BenchmarkRESTHandler_GetSnapshot(b *testing.B) {
 		}
 	}
 
-	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.PilotType")).Return(pilots, nil)
-	mockRepo.On("GetThermalsInRadius", mock.Anything, center, 50).Return([]*models.Thermal{}, nil)
-	mockRepo.On("GetStationsInRadius", mock.Anything, center, 50).Return([]*models.Station{}, nil)
-	mockRepo.On("GetGroundObjectsInRadius", mock.Anything, center, 50, mock.AnythingOfType("[]models.GroundType")).Return([]*models.GroundObject{}, nil)
+	mockRepo.On("GetPilotsInRadius", mock.Anything, center, 50.0).Return(pilots, nil)
+	mockEmptySnapshot(mockRepo, center, 50.0)
 
 	router := setupTestRouter()
 	router.GET("/api/v1/snapshot", handler.GetSnapshot)
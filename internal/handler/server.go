@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/flybeeper/fanet-backend/internal/auth"
 	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/filter"
 	"github.com/flybeeper/fanet-backend/internal/metrics"
 	"github.com/flybeeper/fanet-backend/internal/repository"
 	"github.com/flybeeper/fanet-backend/internal/service"
@@ -28,13 +29,22 @@ type Server struct {
 	config           *config.Config
 	restHandler      *RESTHandler
 	wsHandler        *WebSocketHandler
+	streamHandler    *StreamHandler
+	friendsHandler   *FriendsHandler
 	authMW           *auth.Middleware
 	validationHandler *ValidationHandler
+	alertHandler      *AlertHandler
+	downlinkHandler   *DownlinkHandler
+	receptionHandler  *ReceptionHandler
+	gatewayHandler    *GatewayHandler
+	heatmapHandler    *HeatmapHandler
+	flightHandler     *FlightHandler
+	filterHandler     *FilterHandler
 	boundaryTracker   *service.BoundaryTracker
 }
 
 // NewServer создает новый HTTP сервер
-func NewServer(cfg *config.Config, repo repository.Repository, historyRepo repository.HistoryRepository, redisClient *redis.Client, logger *utils.Logger, validationService *service.ValidationService, boundaryTracker *service.BoundaryTracker) *Server {
+func NewServer(cfg *config.Config, repo repository.Repository, historyRepo repository.HistoryRepository, redisClient *redis.Client, logger *utils.Logger, validationService *service.ValidationService, boundaryTracker *service.BoundaryTracker, alertService *service.AlertService) *Server {
 	// Production mode для Gin
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -53,6 +63,9 @@ func NewServer(cfg *config.Config, repo repository.Repository, historyRepo repos
 
 	// REST handler с boundary tracker
 	restHandler := NewRESTHandler(repo, historyRepo, logger, boundaryTracker)
+	if redisClient != nil {
+		restHandler.lodCache = service.NewTrackLODCache(redisClient, logger)
+	}
 	
 	// WebSocket handler
 	wsHandler := NewWebSocketHandler(repo, logger)
@@ -63,6 +76,12 @@ func NewServer(cfg *config.Config, repo repository.Repository, historyRepo repos
 		validationHandler = NewValidationHandler(validationService)
 	}
 
+	// Alert handler
+	var alertHandler *AlertHandler
+	if alertService != nil {
+		alertHandler = NewAlertHandler(alertService)
+	}
+
 	// Auth middleware - создаем logrus.Logger для совместимости
 	logrusLogger := logrus.New()
 	logrusLogger.SetLevel(logrus.InfoLevel)
@@ -71,14 +90,31 @@ func NewServer(cfg *config.Config, repo repository.Repository, historyRepo repos
 	authValidator := auth.NewValidator(cfg.Auth.Endpoint, authCache, logrusLogger)
 	authMW := auth.NewMiddleware(authValidator, logrusLogger)
 
+	// Потоки обновлений проверяют токены тем же валидатором; списки друзей хранятся в Redis
+	wsHandler.SetAuthValidator(authValidator)
+	var friendsStore *service.FriendsStore
+	if redisClient != nil {
+		friendsStore = service.NewFriendsStore(redisClient)
+		wsHandler.SetFriendsStore(friendsStore)
+	}
+
 	server := &Server{
 		router:           router,
 		logger:           logger,
 		config:           cfg,
 		restHandler:      restHandler,
 		wsHandler:        wsHandler,
+		streamHandler:    NewStreamHandler(wsHandler),
+		friendsHandler:   NewFriendsHandler(friendsStore, wsHandler.UpdateFriends),
 		authMW:           authMW,
 		validationHandler: validationHandler,
+		alertHandler:      alertHandler,
+		downlinkHandler:   NewDownlinkHandler(cfg.MQTT.DownlinkTopic, cfg.MQTT.DownlinkSource, logger),
+		receptionHandler:  NewReceptionHandler(),
+		gatewayHandler:    NewGatewayHandler(),
+		heatmapHandler:    NewHeatmapHandler(logger),
+		flightHandler:     NewFlightHandler(logger),
+		filterHandler:     NewFilterHandler(logger),
 		boundaryTracker:   boundaryTracker,
 	}
 
@@ -102,6 +138,47 @@ func (s *Server) GetWebSocketHandler() *WebSocketHandler {
 	return s.wsHandler
 }
 
+// SetDownlinkPublisher подключает MQTT клиент для отправки FANET пакетов через базовые станции
+func (s *Server) SetDownlinkPublisher(publisher DownlinkPublisher) {
+	s.downlinkHandler.SetPublisher(publisher)
+}
+
+// SetReceptionTracker подключает учет приема пакетов базовыми станциями
+func (s *Server) SetReceptionTracker(tracker *service.ReceptionTracker) {
+	s.receptionHandler.SetTracker(tracker)
+}
+
+// SetGatewayRegistry подключает реестр базовых станций
+func (s *Server) SetGatewayRegistry(registry *service.GatewayRegistry) {
+	s.gatewayHandler.SetRegistry(registry)
+}
+
+// SetHeatmapService подключает тепловую карту исторических термиков (требует MySQL)
+func (s *Server) SetHeatmapService(heatmapService *service.HeatmapService) {
+	s.heatmapHandler.SetService(heatmapService)
+}
+
+// SetFlightTracker подключает определение взлетов и посадок
+func (s *Server) SetFlightTracker(tracker *service.FlightTracker) {
+	s.flightHandler.SetTracker(tracker)
+}
+
+// SetFlightHistory подключает хранилище полетов (требует MySQL)
+func (s *Server) SetFlightHistory(history FlightHistory) {
+	s.flightHandler.SetHistory(history)
+}
+
+// SetFilterChains подключает цепочки фильтров трека из конфигурации
+func (s *Server) SetFilterChains(chains *filter.ChainSet) {
+	s.restHandler.SetFilterChains(chains)
+	s.filterHandler.SetChains(chains)
+}
+
+// SetFilterTrackSource подключает хранилище треков для запуска цепочек фильтров (требует MySQL)
+func (s *Server) SetFilterTrackSource(source service.FilterTrackSource) {
+	s.filterHandler.SetSource(source)
+}
+
 // setupRoutes настраивает маршруты согласно OpenAPI спецификации
 func (s *Server) setupRoutes() {
 	// Health check
@@ -114,14 +191,32 @@ func (s *Server) setupRoutes() {
 		v1.GET("/snapshot", s.restHandler.GetSnapshot)
 		v1.GET("/pilots", s.restHandler.GetPilots)
 		v1.GET("/thermals", s.restHandler.GetThermals)
+		v1.GET("/thermals/heatmap", s.heatmapHandler.GetThermalHeatmap)
 		v1.GET("/stations", s.restHandler.GetStations)
+		v1.GET("/messages", s.restHandler.GetMessages)
+		v1.GET("/landmarks", s.restHandler.GetLandmarks)
 		v1.GET("/track/:addr", s.restHandler.GetTrack)
+		v1.GET("/flights", s.flightHandler.GetFlights)
+		v1.GET("/flights/:id/track", s.flightHandler.GetFlightTrack)
+		v1.GET("/reception/:addr", s.receptionHandler.GetReception)
+		v1.GET("/gateways", s.gatewayHandler.GetGateways)
+		v1.GET("/gateways/:chip_id", s.gatewayHandler.GetGateway)
+
+		// HTTP потоки обновлений для клиентов без WebSocket (SSE и long-poll)
+		v1.GET("/stream", s.streamHandler.Stream)
+		v1.GET("/stream/poll", s.streamHandler.Poll)
 
 		// Protected endpoint (требует Bearer token)
 		protected := v1.Group("/")
 		protected.Use(s.authMW.Authenticate())
 		{
 			protected.POST("/position", s.restHandler.PostPosition)
+
+			// Список друзей: устройства, за которыми клиент следит вне области карты
+			protected.GET("/friends", s.friendsHandler.GetFriends)
+			protected.PUT("/friends", s.friendsHandler.ReplaceFriends)
+			protected.POST("/friends/:device_id", s.friendsHandler.AddFriend)
+			protected.DELETE("/friends/:device_id", s.friendsHandler.RemoveFriend)
 		}
 
 		// Validation endpoints (если validationHandler доступен)
@@ -130,6 +225,25 @@ func (s *Server) setupRoutes() {
 			v1.GET("/validation/:device_id", s.validationHandler.GetValidationState)
 			v1.GET("/validation/metrics", s.validationHandler.GetValidationMetrics)
 		}
+
+		// Alert endpoints (только для администраторов)
+		if s.alertHandler != nil {
+			alerts := v1.Group("/alerts")
+			alerts.Use(s.authMW.Authenticate(), s.authMW.RequireAdmin())
+			{
+				alerts.GET("", s.alertHandler.GetAlerts)
+				alerts.POST("", s.alertHandler.UpdateAlert)
+			}
+		}
+
+		// Admin endpoints
+		admin := v1.Group("/admin")
+		admin.Use(s.authMW.Authenticate(), s.authMW.RequireAdmin())
+		{
+			admin.POST("/downlink", s.downlinkHandler.PublishFrame)
+			admin.GET("/filters", s.filterHandler.GetFilters)
+			admin.POST("/filters/run", s.filterHandler.RunFilters)
+		}
 	}
 
 	// WebSocket endpoint (будет реализован позже)
@@ -169,10 +283,13 @@ func (s *Server) Start() error {
 	return s.httpServer.ListenAndServe()
 }
 
-// Shutdown корректное завершение сервера
+// Shutdown корректное завершение сервера. Фоновые задачи обработчиков
+// останавливаются после завершения активных запросов
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down HTTP server")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	s.streamHandler.Stop()
+	return err
 }
 
 // Health check endpoint
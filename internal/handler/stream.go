@@ -0,0 +1,547 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// streamPingInterval интервал ping событий SSE, чтобы прокси не закрывали соединение
+	streamPingInterval = 15 * time.Second
+	// streamWriteTimeout тайм-аут записи одного события SSE (вместо WriteTimeout сервера)
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry задержка переподключения EventSource
+	streamRetry = 3 * time.Second
+	// pollMaxTimeout максимальное ожидание событий long-poll, меньше WriteTimeout сервера (30 с)
+	pollMaxTimeout = 25 * time.Second
+	// pollSessionTTL сессия long-poll без запросов дольше удаляется
+	pollSessionTTL = time.Minute
+	// pollMaxEvents максимум событий в одном ответе long-poll
+	pollMaxEvents = 100
+)
+
+// Форматы событий потока (параметр format)
+const (
+	streamFormatJSON     = "json"
+	streamFormatProtobuf = "protobuf"
+)
+
+// streamJSON сериализация сообщений в формате json: имена полей как в .proto
+var streamJSON = protojson.MarshalOptions{UseProtoNames: true}
+
+// StreamHandler доставляет обновления по HTTP клиентам, которым недоступен
+// WebSocket: Server-Sent Events и long-poll. Клиенты регистрируются в
+// BroadcastManager наравне с WebSocket клиентами и получают те же сообщения
+type StreamHandler struct {
+	ws       *WebSocketHandler
+	sessions map[string]*pollSession // ID сессии -> клиент long-poll
+	mu       sync.Mutex
+	logger   *logrus.Entry
+
+	stop     chan struct{} // Закрывается в Stop
+	done     chan struct{} // Закрывается по завершении expireSessions
+	stopOnce sync.Once
+}
+
+// pollSession клиент long-poll между запросами
+type pollSession struct {
+	id       string
+	client   *Client
+	format   string
+	polling  bool // Запрос выполняется, параллельный запрос отклоняется
+	lastPoll time.Time
+}
+
+// streamEvent сообщение сервера в формате потока
+type streamEvent struct {
+	Event string      `json:"event"`        // welcome, subscribed, batch, ping
+	ID    string      `json:"id,omitempty"` // Последняя последовательность для resume_from
+	Data  interface{} `json:"data"`         // JSON объект или base64 protobuf
+}
+
+// streamBatch UpdateBatch в формате json с раскрытыми данными объектов
+type streamBatch struct {
+	Updates   []streamUpdate `json:"updates"`
+	Timestamp int64          `json:"timestamp"`
+	Snapshot  bool           `json:"snapshot,omitempty"`
+}
+
+// streamUpdate Update в формате json
+type streamUpdate struct {
+	Type          string          `json:"type"`
+	Action        string          `json:"action"`
+	Sequence      uint64          `json:"sequence,string"` // Строкой, как uint64 в protojson
+	Subscriptions []string        `json:"subscriptions,omitempty"`
+	Delta         bool            `json:"delta,omitempty"`
+	ClearedFields []uint32        `json:"cleared_fields,omitempty"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewStreamHandler создает обработчик HTTP потоков поверх WebSocket handler
+func NewStreamHandler(ws *WebSocketHandler) *StreamHandler {
+	h := &StreamHandler{
+		ws:       ws,
+		sessions: make(map[string]*pollSession),
+		logger:   ws.logger.WithField("component", "stream"),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.expireSessions()
+	return h
+}
+
+// Stop останавливает удаление устаревших сессий и отключает оставшихся клиентов
+// long-poll. Вызывается после остановки HTTP сервера, повторный вызов ничего не делает
+func (h *StreamHandler) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		<-h.done
+		h.expire(func(*pollSession) bool { return true })
+	})
+}
+
+// Stream GET /api/v1/stream - Server-Sent Events. Параметры как у /ws/v1/updates
+// (lat, lon, radius, follow, friends, token, delta, resume_from) и format.
+// Заголовок Last-Event-ID заменяет resume_from при автоматическом
+// переподключении EventSource
+func (h *StreamHandler) Stream(c *gin.Context) {
+	format, ok := parseStreamFormat(c)
+	if !ok {
+		return
+	}
+	params, ok := h.ws.parseStreamParams(c, ProtocolV1)
+	if !ok {
+		return
+	}
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" && params.resumeFrom == 0 {
+		if sequence, err := strconv.ParseUint(lastID, 10, 64); err == nil {
+			params.resumeFrom = sequence
+		}
+	}
+
+	client := h.connect(c, params, "sse")
+	defer h.disconnect(client, "sse")
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx не буферизует поток
+	c.Status(http.StatusOK)
+
+	// Поток дольше WriteTimeout сервера: дедлайн продлевается перед каждой записью
+	rc := http.NewResponseController(c.Writer)
+	rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		var frame []byte
+		select {
+		case <-ctx.Done():
+			return
+		case frame = <-client.send:
+		case <-ping.C:
+			data, err := encodeServerMessage(ProtocolV2, serverMessagePing, &pb.Ping{Timestamp: time.Now().Unix()})
+			if err != nil {
+				continue
+			}
+			frame = data
+		}
+
+		event, err := decodeStreamEvent(frame, format)
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to encode stream event")
+			continue
+		}
+
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := writeServerSentEvent(c.Writer, event); err != nil {
+			h.logger.WithError(err).Debug("SSE write error")
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// Poll GET /api/v1/stream/poll - long-poll. Запрос без session создает сессию
+// с параметрами как у /ws/v1/updates; запросы с session ждут до timeout секунд
+// первое событие и возвращают все накопленные. Сессия без запросов дольше
+// pollSessionTTL удаляется: клиент создает новую с resume_from
+func (h *StreamHandler) Poll(c *gin.Context) {
+	timeout := pollMaxTimeout
+	if timeoutStr := c.Query("timeout"); timeoutStr != "" {
+		seconds, err := strconv.Atoi(timeoutStr)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > pollMaxTimeout {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid timeout (0-%d s)", int(pollMaxTimeout.Seconds()))})
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	var session *pollSession
+	if id := c.Query("session"); id != "" {
+		var status int
+		if session, status = h.acquire(id); session == nil {
+			message := "unknown session"
+			if status == http.StatusConflict {
+				message = "session is already polling"
+			}
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	} else {
+		format, ok := parseStreamFormat(c)
+		if !ok {
+			return
+		}
+		params, ok := h.ws.parseStreamParams(c, ProtocolV1)
+		if !ok {
+			return
+		}
+		session = h.createSession(c, params, format)
+	}
+	defer h.release(session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session.id,
+		"events":  h.collect(c.Request.Context(), session, timeout),
+	})
+}
+
+// connect регистрирует HTTP клиента в broadcast manager и подписывает его на
+// область и устройства из параметров. Очередь клиента содержит сообщения в
+// обертке ServerMessage (как в протоколе v2), чтобы различать их типы;
+// подписка на область без имени, как в протоколе v1
+func (h *StreamHandler) connect(c *gin.Context, params *streamParams, transport string) *Client {
+	client := &Client{
+		remote:        c.ClientIP(),
+		send:          make(chan []byte, 256),
+		handler:       h.ws,
+		subscriptions: make(map[string]*Subscription),
+		protocol:      ProtocolV2,
+		delta:         params.delta,
+		authenticated: params.authenticated,
+		userID:        params.userID,
+	}
+	if params.initial != nil {
+		client.center = params.initial.Center
+		client.radius = int32(params.initial.RadiusKm)
+	}
+
+	h.ws.broadcast.Register(client, nil)
+	client.sendWelcome()
+	client.subscribeParams(params)
+	metrics.StreamClients.WithLabelValues(transport).Inc()
+
+	h.logger.WithFields(logrus.Fields{
+		"client_ip": client.remote,
+		"transport": transport,
+		"lat":       client.center.Latitude,
+		"lon":       client.center.Longitude,
+		"radius":    client.radius,
+		"delta":     params.delta,
+		"resume":    params.resumeFrom,
+		"auth":      params.authenticated,
+		"follow":    params.follow != nil,
+		"friends":   params.friends != nil,
+	}).Info("Stream client connected")
+	return client
+}
+
+// disconnect удаляет HTTP клиента из broadcast manager
+func (h *StreamHandler) disconnect(client *Client, transport string) {
+	h.ws.broadcast.Unregister(client)
+	metrics.StreamClients.WithLabelValues(transport).Dec()
+	h.logger.WithFields(logrus.Fields{
+		"client":    client.remote,
+		"transport": transport,
+	}).Debug("Stream client disconnected")
+}
+
+// createSession создает сессию long-poll, занятую текущим запросом
+func (h *StreamHandler) createSession(c *gin.Context, params *streamParams, format string) *pollSession {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	session := &pollSession{
+		id:      hex.EncodeToString(id),
+		client:  h.connect(c, params, "poll"),
+		format:  format,
+		polling: true,
+	}
+
+	h.mu.Lock()
+	h.sessions[session.id] = session
+	h.mu.Unlock()
+	return session
+}
+
+// acquire занимает сессию для запроса. При ошибке возвращает nil и HTTP статус
+func (h *StreamHandler) acquire(id string) (*pollSession, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, exists := h.sessions[id]
+	if !exists {
+		return nil, http.StatusNotFound
+	}
+	if session.polling {
+		return nil, http.StatusConflict
+	}
+	session.polling = true
+	return session, http.StatusOK
+}
+
+// release освобождает сессию после запроса
+func (h *StreamHandler) release(session *pollSession) {
+	h.mu.Lock()
+	session.polling = false
+	session.lastPoll = time.Now()
+	h.mu.Unlock()
+}
+
+// collect ждет до timeout первое сообщение сессии и забирает накопленные
+func (h *StreamHandler) collect(ctx context.Context, session *pollSession, timeout time.Duration) []*streamEvent {
+	events := make([]*streamEvent, 0)
+	add := func(frame []byte) {
+		event, err := decodeStreamEvent(frame, session.format)
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to encode stream event")
+			return
+		}
+		events = append(events, event)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case frame := <-session.client.send:
+		add(frame)
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	for len(events) < pollMaxEvents {
+		select {
+		case frame := <-session.client.send:
+			add(frame)
+		default:
+			return events
+		}
+	}
+	return events
+}
+
+// expireSessions удаляет сессии long-poll без запросов дольше pollSessionTTL до вызова Stop
+func (h *StreamHandler) expireSessions() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(pollSessionTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			h.expire(func(session *pollSession) bool {
+				return !session.polling && time.Since(session.lastPoll) > pollSessionTTL
+			})
+		}
+	}
+}
+
+// expire удаляет сессии, для которых expired возвращает true, и отключает их клиентов
+func (h *StreamHandler) expire(expired func(*pollSession) bool) {
+	var removed []*pollSession
+
+	h.mu.Lock()
+	for id, session := range h.sessions {
+		if expired(session) {
+			delete(h.sessions, id)
+			removed = append(removed, session)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, session := range removed {
+		h.disconnect(session.client, "poll")
+	}
+}
+
+// parseStreamFormat разбирает параметр format. При ошибке отвечает 400
+func parseStreamFormat(c *gin.Context) (string, bool) {
+	switch format := c.DefaultQuery("format", streamFormatJSON); format {
+	case streamFormatJSON, streamFormatProtobuf:
+		return format, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format (json or protobuf)"})
+		return "", false
+	}
+}
+
+// writeServerSentEvent записывает событие в формате text/event-stream
+func writeServerSentEvent(w http.ResponseWriter, event *streamEvent) error {
+	var data string
+	switch v := event.Data.(type) {
+	case json.RawMessage:
+		data = string(v)
+	case string:
+		data = v
+	}
+
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Event, data)
+	return err
+}
+
+// decodeStreamEvent преобразует сообщение из очереди клиента (ServerMessage)
+// в событие потока. В формате protobuf данные - base64 того же сообщения,
+// что получает WebSocket клиент протокола v1; в формате json данные объектов
+// Update раскрываются в JSON
+func decodeStreamEvent(frame []byte, format string) (*streamEvent, error) {
+	field, wireType, n := protowire.ConsumeTag(frame)
+	if n < 0 || wireType != protowire.BytesType {
+		return nil, fmt.Errorf("invalid server message")
+	}
+	payload, m := protowire.ConsumeBytes(frame[n:])
+	if m < 0 {
+		return nil, fmt.Errorf("invalid server message payload")
+	}
+
+	event := &streamEvent{}
+	var msg proto.Message
+	switch field {
+	case serverMessageWelcome:
+		event.Event, msg = "welcome", &pb.Welcome{}
+	case serverMessageSubscribeResponse:
+		event.Event, msg = "subscribed", &pb.SubscribeResponse{}
+	case serverMessageBatch:
+		event.Event, msg = "batch", &pb.UpdateBatch{}
+	case serverMessagePing:
+		event.Event, msg = "ping", &pb.Ping{}
+	default:
+		return nil, fmt.Errorf("unknown server message %d", field)
+	}
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil, err
+	}
+
+	// ID - последняя последовательность, которую клиент передаст в resume_from
+	switch v := msg.(type) {
+	case *pb.Welcome:
+		event.ID = strconv.FormatUint(v.Sequence, 10)
+	case *pb.UpdateBatch:
+		var last uint64
+		for _, update := range v.Updates {
+			if update.Sequence > last {
+				last = update.Sequence
+			}
+		}
+		if last > 0 {
+			event.ID = strconv.FormatUint(last, 10)
+		}
+	}
+
+	if format == streamFormatProtobuf {
+		event.Data = base64.StdEncoding.EncodeToString(payload)
+		return event, nil
+	}
+
+	data, err := marshalStreamJSON(msg)
+	if err != nil {
+		return nil, err
+	}
+	event.Data = json.RawMessage(data)
+	return event, nil
+}
+
+// marshalStreamJSON сериализует сообщение сервера в JSON. Данные обновлений
+// батча раскрываются в объекты своих типов
+func marshalStreamJSON(msg proto.Message) ([]byte, error) {
+	batch, ok := msg.(*pb.UpdateBatch)
+	if !ok {
+		return streamJSON.Marshal(msg)
+	}
+
+	result := streamBatch{
+		Updates:   make([]streamUpdate, 0, len(batch.Updates)),
+		Timestamp: batch.Timestamp,
+		Snapshot:  batch.Snapshot,
+	}
+	for _, update := range batch.Updates {
+		payload := updatePayload(update.Type)
+		if payload == nil {
+			return nil, fmt.Errorf("unknown update type %s", update.Type)
+		}
+		if err := proto.Unmarshal(update.Data, payload); err != nil {
+			return nil, fmt.Errorf("%s: %w", update.Type, err)
+		}
+		data, err := streamJSON.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Updates = append(result.Updates, streamUpdate{
+			Type:          update.Type.String(),
+			Action:        update.Action.String(),
+			Sequence:      update.Sequence,
+			Subscriptions: update.Subscriptions,
+			Delta:         update.Delta,
+			ClearedFields: update.ClearedFields,
+			Data:          data,
+		})
+	}
+	return json.Marshal(result)
+}
+
+// updatePayload возвращает пустое сообщение для данных Update заданного типа
+func updatePayload(updateType pb.UpdateType) proto.Message {
+	switch updateType {
+	case pb.UpdateType_UPDATE_TYPE_PILOT:
+		return &pb.Pilot{}
+	case pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT:
+		return &pb.GroundObject{}
+	case pb.UpdateType_UPDATE_TYPE_THERMAL:
+		return &pb.Thermal{}
+	case pb.UpdateType_UPDATE_TYPE_STATION:
+		return &pb.Station{}
+	case pb.UpdateType_UPDATE_TYPE_MESSAGE:
+		return &pb.Message{}
+	case pb.UpdateType_UPDATE_TYPE_LANDMARK:
+		return &pb.Landmark{}
+	case pb.UpdateType_UPDATE_TYPE_ALERT:
+		return &pb.Alert{}
+	case pb.UpdateType_UPDATE_TYPE_FLIGHT:
+		return &pb.Flight{}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestStreamRouter(t *testing.T) (*gin.Engine, *StreamHandler) {
+	gin.SetMode(gin.TestMode)
+	stream := NewStreamHandler(NewWebSocketHandler(nil, logrus.NewEntry(logrus.New())))
+	t.Cleanup(stream.Stop)
+
+	router := gin.New()
+	router.GET("/api/v1/stream", stream.Stream)
+	router.GET("/api/v1/stream/poll", stream.Poll)
+	return router, stream
+}
+
+func TestDecodeStreamEvent(t *testing.T) {
+	pilot := pilotUpdate("AAA111", 46.0, 13.0).Pilot.ToProto()
+	pilot.Name = "Test Pilot"
+	pilotData, err := proto.Marshal(pilot)
+	require.NoError(t, err)
+
+	batch := &pb.UpdateBatch{
+		Updates: []*pb.Update{
+			{Type: pb.UpdateType_UPDATE_TYPE_PILOT, Action: pb.Action_ACTION_UPDATE, Data: pilotData, Sequence: 1720958400000000002},
+			{Type: pb.UpdateType_UPDATE_TYPE_PILOT, Action: pb.Action_ACTION_UPDATE, Data: pilotData, Sequence: 1720958400000000001},
+		},
+		Timestamp: 1720958400,
+	}
+	batchData, err := proto.Marshal(batch)
+	require.NoError(t, err)
+	frame := wrapServerMessage(serverMessageBatch, batchData)
+
+	event, err := decodeStreamEvent(frame, streamFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "batch", event.Event)
+	assert.Equal(t, "1720958400000000002", event.ID)
+
+	var decoded struct {
+		Updates []struct {
+			Type     string `json:"type"`
+			Sequence string `json:"sequence"`
+			Data     struct {
+				Name     string `json:"name"`
+				Position struct {
+					Latitude float64 `json:"latitude"`
+				} `json:"position"`
+			} `json:"data"`
+		} `json:"updates"`
+	}
+	require.NoError(t, json.Unmarshal(event.Data.(json.RawMessage), &decoded))
+	require.Len(t, decoded.Updates, 2)
+	assert.Equal(t, "UPDATE_TYPE_PILOT", decoded.Updates[0].Type)
+	assert.Equal(t, "1720958400000000002", decoded.Updates[0].Sequence, "no float precision loss")
+	assert.Equal(t, "Test Pilot", decoded.Updates[0].Data.Name)
+	assert.Equal(t, 46.0, decoded.Updates[0].Data.Position.Latitude)
+
+	// protobuf - то же сообщение, что получает WebSocket клиент v1
+	event, err = decodeStreamEvent(frame, streamFormatProtobuf)
+	require.NoError(t, err)
+	raw, err := base64.StdEncoding.DecodeString(event.Data.(string))
+	require.NoError(t, err)
+	assert.Equal(t, batchData, raw)
+
+	_, err = decodeStreamEvent([]byte{0xff}, streamFormatJSON)
+	assert.Error(t, err)
+}
+
+func TestStreamHandler_Poll(t *testing.T) {
+	router, stream := newTestStreamRouter(t)
+
+	poll := func(query string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stream/poll?"+query, nil))
+		var body map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+	events := func(body map[string]json.RawMessage) []streamEvent {
+		var result []streamEvent
+		require.NoError(t, json.Unmarshal(body["events"], &result))
+		return result
+	}
+
+	w, _ := poll("lat=46.0&lon=13.0")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = poll("lat=46.0&lon=13.0&radius=20&format=xml")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = poll("session=unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Создание сессии: приветствие сразу
+	w, body := poll("lat=46.0&lon=13.0&radius=20&timeout=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var session string
+	require.NoError(t, json.Unmarshal(body["session"], &session))
+	require.NotEmpty(t, session)
+	assert.Equal(t, "welcome", events(body)[0].Event)
+
+	// Ждем подтверждения подписки, затем обновление области
+	deadline := time.Now().Add(5 * time.Second)
+	subscribed := false
+	for !subscribed && time.Now().Before(deadline) {
+		_, body = poll("session=" + session + "&timeout=1")
+		for _, event := range events(body) {
+			subscribed = subscribed || event.Event == "subscribed"
+		}
+	}
+	require.True(t, subscribed)
+
+	stream.ws.broadcast.Broadcast(pilotUpdate("AAA111", 46.0, 13.0))
+	stream.ws.broadcast.Broadcast(pilotUpdate("BBB222", 48.0, 13.0)) // вне области
+	w, body = poll("session=" + session + "&timeout=5")
+	require.Equal(t, http.StatusOK, w.Code)
+	received := events(body)
+	require.Len(t, received, 1)
+	assert.Equal(t, "batch", received[0].Event)
+	assert.NotEmpty(t, received[0].ID)
+
+	// Параллельный запрос той же сессии отклоняется
+	held, status := stream.acquire(session)
+	require.NotNil(t, held)
+	require.Equal(t, http.StatusOK, status)
+	w, _ = poll("session=" + session)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	stream.release(held)
+
+	// Остановка отключает оставшиеся сессии, повторный вызов безопасен
+	stream.Stop()
+	stream.Stop()
+	w, _ = poll("session=" + session)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStreamHandler_ServerSentEvents(t *testing.T) {
+	router, stream := newTestStreamRouter(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/stream?lat=46.0&lon=13.0&radius=20&format=protobuf", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Читаем события до первого батча
+	reader := bufio.NewReader(resp.Body)
+	var event, id, data string
+	for event != "batch" {
+		event, id, data = "", "", ""
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				break
+			}
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+		if event == "subscribed" {
+			stream.ws.broadcast.Broadcast(pilotUpdate("AAA111", 46.0, 13.0))
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	require.NoError(t, err)
+	var batch pb.UpdateBatch
+	require.NoError(t, proto.Unmarshal(raw, &batch))
+	require.Len(t, batch.Updates, 1)
+	assert.Equal(t, pb.UpdateType_UPDATE_TYPE_PILOT, batch.Updates[0].Type)
+	assert.Equal(t, strconv.FormatUint(batch.Updates[0].Sequence, 10), id)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flybeeper/fanet-backend/internal/geo"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+const (
+	// defaultSubscriptionID подписка из параметров подключения в протоколе v2
+	defaultSubscriptionID = "default"
+	// maxSubscriptionsPerClient максимум именованных подписок одного клиента
+	maxSubscriptionsPerClient = 10
+	// maxSubscriptionIDLength максимальная длина имени подписки
+	maxSubscriptionIDLength = 64
+	// maxSubscriptionRadiusKm максимальный радиус области подписки (для bounds - радиус описанной окружности)
+	maxSubscriptionRadiusKm = 200
+	// maxFollowDevices максимум устройств в подписке follow (список друзей ограничен service.MaxFriends)
+	maxFollowDevices = 20
+	// followSubscriptionID подписка на устройства из параметра follow и JSON сообщения follow
+	followSubscriptionID = "follow"
+	// friendsSubscriptionID подписка на список друзей пользователя
+	friendsSubscriptionID = "friends"
+)
+
+// Subscription именованная подписка клиента WebSocket: область (круг или
+// прямоугольник) и типы обновлений либо набор устройств. В протоколе v1 у
+// клиента одна подписка на область с пустым ID, и ее обновления не помечаются
+type Subscription struct {
+	ID       string
+	Center   models.GeoPoint
+	RadiusKm float64
+	Bounds   *models.Bounds         // Если задан, область - прямоугольник, Center/RadiusKm - описанная окружность
+	Types    map[pb.UpdateType]bool // Пусто - все типы
+	Devices  map[string]bool        // Если задан, подписка на пилотов и наземные объекты с этими адресами вне зависимости от области
+	Friends  bool                   // Devices - список друзей пользователя, заменяется при его изменении
+}
+
+// NewCircleSubscription создает подписку на круг без фильтра по типам
+func NewCircleSubscription(id string, lat, lon, radiusKm float64) *Subscription {
+	return &Subscription{
+		ID:       id,
+		Center:   models.GeoPoint{Latitude: lat, Longitude: lon},
+		RadiusKm: radiusKm,
+	}
+}
+
+// NewDeviceSubscription создает подписку на устройства. Адреса должны быть
+// нормализованы (service.NormalizeDeviceID); пустой список допустим
+func NewDeviceSubscription(id string, deviceIDs []string) *Subscription {
+	devices := make(map[string]bool, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		devices[deviceID] = true
+	}
+	return &Subscription{ID: id, Devices: devices}
+}
+
+// parseDeviceIDs нормализует адреса устройств подписки follow
+func parseDeviceIDs(deviceIDs []string) ([]string, error) {
+	if len(deviceIDs) > maxFollowDevices {
+		return nil, fmt.Errorf("too many devices (max %d)", maxFollowDevices)
+	}
+	result := make([]string, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		normalized, err := service.NormalizeDeviceID(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, normalized)
+	}
+	return result, nil
+}
+
+// subscriptionFromProto строит подписку из SubscribeRequest протокола v2.
+// Пустое имя заменяется на defaultID
+func subscriptionFromProto(req *pb.SubscribeRequest, defaultID string) (*Subscription, error) {
+	id := req.GetId()
+	if id == "" {
+		id = defaultID
+	}
+	if len(id) > maxSubscriptionIDLength {
+		return nil, fmt.Errorf("subscription id is longer than %d characters", maxSubscriptionIDLength)
+	}
+
+	var sub *Subscription
+	switch {
+	case len(req.GetDevices()) > 0:
+		if req.GetCenter() != nil || req.GetBounds() != nil {
+			return nil, fmt.Errorf("devices cannot be combined with center or bounds")
+		}
+		devices, err := parseDeviceIDs(req.GetDevices())
+		if err != nil {
+			return nil, err
+		}
+		return NewDeviceSubscription(id, devices), nil
+
+	case req.GetBounds() != nil:
+		sw, ne := req.GetBounds().GetSouthwest(), req.GetBounds().GetNortheast()
+		if sw == nil || ne == nil {
+			return nil, fmt.Errorf("bounds require southwest and northeast")
+		}
+		bounds := models.Bounds{
+			Southwest: models.GeoPoint{Latitude: sw.Latitude, Longitude: sw.Longitude},
+			Northeast: models.GeoPoint{Latitude: ne.Latitude, Longitude: ne.Longitude},
+		}
+		if err := bounds.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid bounds: %w", err)
+		}
+		center := bounds.Center()
+		sub = &Subscription{
+			ID:       id,
+			Center:   center,
+			RadiusKm: center.DistanceTo(bounds.Northeast),
+			Bounds:   &bounds,
+		}
+
+	case req.GetCenter() != nil:
+		center := req.GetCenter()
+		if center.Latitude < -90 || center.Latitude > 90 || center.Longitude < -180 || center.Longitude > 180 {
+			return nil, fmt.Errorf("invalid center")
+		}
+		if req.GetRadius() <= 0 {
+			return nil, fmt.Errorf("invalid radius (1-%d km)", maxSubscriptionRadiusKm)
+		}
+		sub = NewCircleSubscription(id, center.Latitude, center.Longitude, float64(req.GetRadius()))
+
+	default:
+		return nil, fmt.Errorf("center/radius or bounds are required")
+	}
+
+	if sub.RadiusKm > maxSubscriptionRadiusKm {
+		return nil, fmt.Errorf("subscription area exceeds %d km radius", maxSubscriptionRadiusKm)
+	}
+
+	if len(req.GetTypes()) > 0 {
+		sub.Types = make(map[pb.UpdateType]bool, len(req.GetTypes()))
+		for _, updateType := range req.GetTypes() {
+			sub.Types[updateType] = true
+		}
+	}
+
+	return sub, nil
+}
+
+// Matches проверяет, относится ли обновление с координатами lat/lon к области
+// подписки. У подписки на устройства области нет
+func (s *Subscription) Matches(updateType pb.UpdateType, lat, lon float64) bool {
+	if s.Devices != nil {
+		return false
+	}
+	if len(s.Types) > 0 && !s.Types[updateType] {
+		return false
+	}
+	if s.Bounds != nil {
+		return s.Bounds.Contains(models.GeoPoint{Latitude: lat, Longitude: lon})
+	}
+	return geo.Distance(s.Center.Latitude, s.Center.Longitude, lat, lon) <= s.RadiusKm
+}
+
+// Follows проверяет, подписан ли клиент на устройство deviceID
+func (s *Subscription) Follows(deviceID string) bool {
+	return deviceID != "" && s.Devices[deviceID]
+}
+
+// Geohashes возвращает geohash ячейки, покрывающие область подписки
+func (s *Subscription) Geohashes() []string {
+	if s.Devices != nil {
+		return nil
+	}
+	precision := geo.OptimalGeohashPrecision(s.RadiusKm)
+	return geo.Cover(s.Center.Latitude, s.Center.Longitude, s.RadiusKm, precision)
+}
+
+// matchSubscriptions возвращает отсортированные имена подписок, которым
+// соответствует обновление устройства deviceID (пусто - не устройство) с
+// координатами lat/lon. Для подписки v1 (пустое имя) возвращается пустая
+// строка, поэтому ok отдельно сообщает о совпадении
+func matchSubscriptions(subscriptions map[string]*Subscription, updateType pb.UpdateType, deviceID string, lat, lon float64) (ids []string, ok bool) {
+	for id, sub := range subscriptions {
+		if !sub.Follows(deviceID) && !sub.Matches(updateType, lat, lon) {
+			continue
+		}
+		ok = true
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, ok
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/geo"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestClient создает клиента с настоящим WebSocket соединением (нужен RemoteAddr)
+func newTestClient(t *testing.T, protocol int) *Client {
+	upgrader := websocket.Upgrader{}
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { peer.Close() })
+
+	conn := <-conns
+	t.Cleanup(func() { conn.Close() })
+
+	return &Client{
+		conn:          conn,
+		send:          make(chan []byte, 16),
+		subscriptions: make(map[string]*Subscription),
+		protocol:      protocol,
+	}
+}
+
+func pilotUpdate(address string, lat, lon float64) *UpdatePacket {
+	return &UpdatePacket{
+		Type: pb.UpdateType_UPDATE_TYPE_PILOT,
+		Pilot: &models.Pilot{
+			Address:  address,
+			Position: &models.GeoPoint{Latitude: lat, Longitude: lon},
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func thermalUpdate(id string, lat, lon float64) *UpdatePacket {
+	return &UpdatePacket{
+		Type: pb.UpdateType_UPDATE_TYPE_THERMAL,
+		Thermal: &models.Thermal{
+			ID:       id,
+			Position: &models.GeoPoint{Latitude: lat, Longitude: lon},
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// receiveBatch читает один батч из очереди клиента
+func receiveBatch(t *testing.T, client *Client) *pb.UpdateBatch {
+	select {
+	case data := <-client.send:
+		if client.protocol == ProtocolV2 {
+			var msg pb.ServerMessage
+			require.NoError(t, proto.Unmarshal(data, &msg))
+			require.NotNil(t, msg.GetBatch())
+			return msg.GetBatch()
+		}
+		var batch pb.UpdateBatch
+		require.NoError(t, proto.Unmarshal(data, &batch))
+		return &batch
+	default:
+		require.FailNow(t, "no batch queued")
+		return nil
+	}
+}
+
+func TestSubscriptionFromProto(t *testing.T) {
+	sub, err := subscriptionFromProto(&pb.SubscribeRequest{
+		Center: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0},
+		Radius: 20,
+		Types:  []pb.UpdateType{pb.UpdateType_UPDATE_TYPE_THERMAL},
+	}, defaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSubscriptionID, sub.ID)
+	assert.True(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_THERMAL, 46.1, 13.0))
+	assert.False(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_PILOT, 46.1, 13.0))
+	assert.False(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_THERMAL, 46.5, 13.0))
+
+	sub, err = subscriptionFromProto(&pb.SubscribeRequest{
+		Id: "route",
+		Bounds: &pb.Bounds{
+			Southwest: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0},
+			Northeast: &pb.GeoPoint{Latitude: 46.2, Longitude: 13.6},
+		},
+	}, defaultSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, "route", sub.ID)
+	assert.True(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_PILOT, 46.19, 13.59))
+	// Внутри описанной окружности, но вне прямоугольника
+	assert.False(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_PILOT, 46.25, 13.3))
+
+	invalid := []*pb.SubscribeRequest{
+		{},
+		{Center: &pb.GeoPoint{Latitude: 91, Longitude: 13.0}, Radius: 10},
+		{Center: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0}, Radius: 0},
+		{Center: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0}, Radius: 201},
+		{Bounds: &pb.Bounds{Southwest: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0}}},
+		{Bounds: &pb.Bounds{
+			Southwest: &pb.GeoPoint{Latitude: 40.0, Longitude: 5.0},
+			Northeast: &pb.GeoPoint{Latitude: 50.0, Longitude: 20.0},
+		}},
+		{Id: strings.Repeat("x", maxSubscriptionIDLength+1), Center: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0}, Radius: 10},
+	}
+	for i, req := range invalid {
+		_, err := subscriptionFromProto(req, defaultSubscriptionID)
+		assert.Error(t, err, "request %d must be rejected", i)
+	}
+}
+
+func TestWrapServerMessage(t *testing.T) {
+	data, err := proto.Marshal(&pb.Ping{Timestamp: 1720958400})
+	require.NoError(t, err)
+
+	var msg pb.ServerMessage
+	require.NoError(t, proto.Unmarshal(wrapServerMessage(serverMessagePing, data), &msg))
+	assert.Equal(t, int64(1720958400), msg.GetPing().GetTimestamp())
+}
+
+func TestBroadcastManager_TagsUpdatesWithSubscriptions(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	client := newTestClient(t, ProtocolV2)
+
+	site := NewCircleSubscription("my-site", 46.0, 13.0, 10)
+	route, err := subscriptionFromProto(&pb.SubscribeRequest{
+		Id: "friends-route",
+		Bounds: &pb.Bounds{
+			Southwest: &pb.GeoPoint{Latitude: 45.95, Longitude: 12.9},
+			Northeast: &pb.GeoPoint{Latitude: 46.5, Longitude: 13.1},
+		},
+		Types: []pb.UpdateType{pb.UpdateType_UPDATE_TYPE_PILOT},
+	}, defaultSubscriptionID)
+	require.NoError(t, err)
+
+	bm.handleRegister(&ClientRegistration{client: client, connect: true, subscription: site})
+	bm.handleRegister(&ClientRegistration{client: client, subscription: route})
+
+	bm.processBatch([]*UpdatePacket{
+		pilotUpdate("AAA111", 46.0, 13.0),   // обе подписки
+		pilotUpdate("BBB222", 46.4, 13.0),   // только маршрут
+		thermalUpdate("T1", 46.0, 13.0),     // только площадка: маршрут фильтрует типы
+		thermalUpdate("T2", 46.4, 13.0),     // ни одной
+		pilotUpdate("CCC333", 47.5, 13.0),   // вне обеих областей
+		pilotUpdate("AAA111", 46.01, 13.01), // дубликат объекта в батче
+	})
+
+	batch := receiveBatch(t, client)
+	require.Len(t, batch.Updates, 3)
+
+	// Обновления различаются по типу и широте
+	tags := make(map[string][]string)
+	for _, update := range batch.Updates {
+		key := "thermal"
+		if update.Type == pb.UpdateType_UPDATE_TYPE_PILOT {
+			var pilot pb.Pilot
+			require.NoError(t, proto.Unmarshal(update.Data, &pilot))
+			key = "AAA111"
+			if pilot.GetPosition().GetLatitude() > 46.2 {
+				key = "BBB222"
+			}
+		}
+		tags[key] = update.Subscriptions
+	}
+	assert.Equal(t, []string{"friends-route", "my-site"}, tags["AAA111"])
+	assert.Equal(t, []string{"friends-route"}, tags["BBB222"])
+	assert.Equal(t, []string{"my-site"}, tags["thermal"])
+
+	// После отписки от площадки термики больше не приходят
+	bm.handleRegister(&ClientRegistration{client: client, unsubscribe: true, id: "my-site"})
+	bm.processBatch([]*UpdatePacket{thermalUpdate("T1", 46.0, 13.0)})
+	assert.Empty(t, client.send)
+
+	bm.handleUnregister(client)
+	assert.Empty(t, bm.groups)
+}
+
+func TestBroadcastManager_ProtocolV1(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	near := newTestClient(t, ProtocolV1)
+	far := newTestClient(t, ProtocolV1)
+
+	bm.handleRegister(&ClientRegistration{client: near, connect: true, subscription: NewCircleSubscription("", 46.0, 13.0, 20)})
+	bm.handleRegister(&ClientRegistration{client: far, connect: true, subscription: NewCircleSubscription("", 46.3, 13.0, 20)})
+
+	// Обновление в общей geohash ячейке, но в радиусе только одного клиента
+	bm.processBatch([]*UpdatePacket{pilotUpdate("AAA111", 45.95, 13.0)})
+
+	batch := receiveBatch(t, near)
+	require.Len(t, batch.Updates, 1)
+	assert.Empty(t, batch.Updates[0].Subscriptions)
+	assert.Empty(t, far.send)
+
+	// Изменение подписки без регистрации (клиент уже отключен) игнорируется
+	bm.handleUnregister(far)
+	bm.handleRegister(&ClientRegistration{client: far, subscription: NewCircleSubscription("", 46.0, 13.0, 20)})
+	assert.NotContains(t, bm.clients, far)
+}
+
+func TestSubscriptionFromProto_Devices(t *testing.T) {
+	sub, err := subscriptionFromProto(&pb.SubscribeRequest{
+		Devices: []string{"abc123", "1234"},
+	}, followSubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, followSubscriptionID, sub.ID)
+	assert.True(t, sub.Follows("ABC123"))
+	assert.True(t, sub.Follows("001234"))
+	assert.False(t, sub.Follows("DEF456"))
+	// Подписка на устройства не зависит от области
+	assert.False(t, sub.Matches(pb.UpdateType_UPDATE_TYPE_PILOT, 46.0, 13.0))
+	assert.Empty(t, sub.Geohashes())
+
+	tooMany := make([]string, maxFollowDevices+1)
+	for i := range tooMany {
+		tooMany[i] = "ABC123"
+	}
+	invalid := []*pb.SubscribeRequest{
+		{Devices: []string{"XYZ"}},
+		{Devices: tooMany},
+		{Devices: []string{"ABC123"}, Center: &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0}, Radius: 10},
+	}
+	for i, req := range invalid {
+		_, err := subscriptionFromProto(req, followSubscriptionID)
+		assert.Error(t, err, "request %d must be rejected", i)
+	}
+}
+
+func TestBroadcastManager_FollowsDevices(t *testing.T) {
+	bm := NewBroadcastManager(geo.NewSpatialIndex(time.Minute, 10, time.Minute))
+	client := newTestClient(t, ProtocolV2)
+
+	bm.handleRegister(&ClientRegistration{client: client, connect: true, subscription: NewCircleSubscription("my-site", 46.0, 13.0, 10)})
+	bm.handleRegister(&ClientRegistration{client: client, subscription: NewDeviceSubscription(followSubscriptionID, []string{"BBB222"})})
+
+	bm.processBatch([]*UpdatePacket{
+		pilotUpdate("BBB222", 48.0, 10.0), // далеко от площадки
+		pilotUpdate("CCC333", 48.0, 10.0), // не отслеживается
+	})
+
+	batch := receiveBatch(t, client)
+	require.Len(t, batch.Updates, 1)
+	assert.Equal(t, []string{followSubscriptionID}, batch.Updates[0].Subscriptions)
+
+	// Замена списка устройств
+	bm.handleRegister(&ClientRegistration{client: client, subscription: NewDeviceSubscription(followSubscriptionID, []string{"CCC333"})})
+	assert.NotContains(t, bm.followers, "BBB222")
+	bm.processBatch([]*UpdatePacket{pilotUpdate("BBB222", 48.0, 10.0)})
+	assert.Empty(t, client.send)
+
+	bm.handleUnregister(client)
+	assert.Empty(t, bm.followers)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxLODZoom      = 22     // Максимальный масштаб карты в параметре zoom
+	maxLODTolerance = 100000 // Максимальный допуск tolerance_m (м)
+)
+
+// trackLOD параметры упрощения трека: масштаб карты (версии кэшируются в Redis)
+// или произвольный допуск в метрах (считается при каждом запросе)
+type trackLOD struct {
+	zoom      int
+	tolerance float64
+}
+
+// parseTrackLOD разбирает zoom и tolerance_m. nil - трек отдается без упрощения
+func parseTrackLOD(c *gin.Context) (*trackLOD, error) {
+	zoomStr, toleranceStr := c.Query("zoom"), c.Query("tolerance_m")
+	switch {
+	case zoomStr == "" && toleranceStr == "":
+		return nil, nil
+	case zoomStr != "" && toleranceStr != "":
+		return nil, fmt.Errorf("zoom and tolerance_m are mutually exclusive")
+	case zoomStr != "":
+		zoom, err := strconv.Atoi(zoomStr)
+		if err != nil || zoom < 0 || zoom > maxLODZoom {
+			return nil, fmt.Errorf("zoom must be between 0 and %d", maxLODZoom)
+		}
+		return &trackLOD{zoom: zoom}, nil
+	default:
+		tolerance, err := strconv.ParseFloat(toleranceStr, 64)
+		if err != nil || tolerance <= 0 || tolerance > maxLODTolerance {
+			return nil, fmt.Errorf("tolerance_m must be between 0 and %d meters", maxLODTolerance)
+		}
+		return &trackLOD{tolerance: tolerance}, nil
+	}
+}
+
+// trackLODCacheKey возвращает ключ кэша версий трека и время их жизни. Пустой ключ -
+// версии не кэшируются (задан tolerance_m, период еще не закончился или кэш недоступен)
+func (h *RESTHandler) trackLODCacheKey(lod *trackLOD, deviceID string, filterLevel int, isRange bool, hours int, from, to time.Time) (string, time.Duration) {
+	if h.lodCache == nil || lod.tolerance > 0 {
+		return "", 0
+	}
+
+	if !isRange {
+		return service.TrackLODKey(deviceID, filterLevel, fmt.Sprintf("h%d", hours)), service.TrackLODLiveTTL
+	}
+
+	// Период до текущего момента (to по умолчанию) дает новый ключ каждую секунду
+	if !to.Before(time.Now().Add(-service.TrackLODLiveTTL)) {
+		return "", 0
+	}
+	return service.TrackLODKey(deviceID, filterLevel, fmt.Sprintf("%d-%d", from.Unix(), to.Unix())), service.TrackLODHistoryTTL
+}
+
+// simplifyTrack упрощает отфильтрованный трек для запрошенного масштаба или допуска.
+// Для масштаба строятся и кэшируются версии для всех масштабов сразу
+func (h *RESTHandler) simplifyTrack(ctx context.Context, lod *trackLOD, cacheKey string, ttl time.Duration, result *filter.FilterResult) *filter.FilterResult {
+	simplified := *result
+	switch {
+	case lod.tolerance > 0:
+		simplified.Points = filter.Simplify(result.Points, lod.tolerance)
+	case cacheKey == "":
+		simplified.Points = filter.Simplify(result.Points, filter.LODTolerance(result.Points, lod.zoom))
+	default:
+		versions := service.BuildTrackLODs(result)
+		if err := h.lodCache.Save(ctx, cacheKey, versions, ttl); err != nil {
+			h.logger.WithField("error", err).WithField("key", cacheKey).Warn("Failed to cache track LOD")
+		}
+		return versions[filter.ClampLODZoom(lod.zoom)]
+	}
+	return &simplified
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTrackRange    = 12 * time.Hour      // Период по умолчанию, если задан только to
+	maxTrackRange        = 31 * 24 * time.Hour // Максимальный период запроса трека
+	defaultTrackPageSize = 5000                // Размер страницы по умолчанию (page_size)
+	maxTrackPageSize     = 10000               // Максимальный размер страницы
+	maxTrackRangePoints  = 200000              // Максимум точек, загружаемых в память для фильтрации и экспорта
+	maxTrackMaxPoints    = 100000              // Максимальное значение max_points
+)
+
+// errTrackTooLarge трек за период не помещается в maxTrackRangePoints
+var errTrackTooLarge = fmt.Errorf("track has more than %d points", maxTrackRangePoints)
+
+// parseTrackRange разбирает параметры from/to (RFC3339). ok = false, если ни один не задан
+// и запрос обслуживается в режиме последних hours часов
+func parseTrackRange(c *gin.Context) (from, to time.Time, ok bool, err error) {
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if fromStr == "" && toStr == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	to = time.Now().UTC()
+	if toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return time.Time{}, time.Time{}, true, fmt.Errorf("to must be RFC3339 time")
+		}
+	}
+	from = to.Add(-defaultTrackRange)
+	if fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return time.Time{}, time.Time{}, true, fmt.Errorf("from must be RFC3339 time")
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, true, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > maxTrackRange {
+		return time.Time{}, time.Time{}, true, fmt.Errorf("time range must not exceed %d days", int(maxTrackRange.Hours()/24))
+	}
+	return from, to, true, nil
+}
+
+// parseMaxPoints разбирает max_points: 0 - без прореживания
+func parseMaxPoints(c *gin.Context) (int, error) {
+	value := c.Query("max_points")
+	if value == "" {
+		return 0, nil
+	}
+	maxPoints, err := strconv.Atoi(value)
+	if err != nil || maxPoints < 2 || maxPoints > maxTrackMaxPoints {
+		return 0, fmt.Errorf("max_points must be between 2 and %d", maxTrackMaxPoints)
+	}
+	return maxPoints, nil
+}
+
+// fetchTrackPage читает одну страницу трека. Таймаут отсчитывается на каждую страницу,
+// чтобы многодневный трек не упирался в таймаут всего запроса
+func (h *RESTHandler) fetchTrackPage(c *gin.Context, deviceID string, from, to time.Time, after *models.TrackCursor, limit int) (*models.TrackPage, error) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	return h.historyRepo.GetPilotTrackRange(ctx, deviceID, &models.TrackRangeQuery{
+		From:  from,
+		To:    to,
+		After: after,
+		Limit: limit,
+	})
+}
+
+// loadTrackRange загружает трек за период целиком (для фильтрации, protobuf и экспорта)
+func (h *RESTHandler) loadTrackRange(c *gin.Context, deviceID string, from, to time.Time) ([]models.TrackGeoPoint, error) {
+	var (
+		points []models.TrackGeoPoint
+		after  *models.TrackCursor
+	)
+	for {
+		page, err := h.fetchTrackPage(c, deviceID, from, to, after, maxTrackPageSize)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, page.Points...)
+
+		if page.Next == nil {
+			return points, nil
+		}
+		if len(points) >= maxTrackRangePoints {
+			return nil, errTrackTooLarge
+		}
+		after = page.Next
+	}
+}
+
+// getTrackPage отдает одну страницу необработанного трека за период (без фильтрации).
+// Клиент продолжает чтение, передавая next_cursor в параметре cursor
+func (h *RESTHandler) getTrackPage(c *gin.Context, deviceID string, from, to time.Time) {
+	pageSize := defaultTrackPageSize
+	if value := c.Query("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxTrackPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_page_size",
+				"message": fmt.Sprintf("page_size must be between 1 and %d", maxTrackPageSize),
+			})
+			return
+		}
+		pageSize = size
+	}
+
+	var after *models.TrackCursor
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := models.ParseTrackCursor(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    "invalid_cursor",
+				"message": "Invalid cursor parameter",
+			})
+			return
+		}
+		after = cursor
+	}
+
+	page, err := h.fetchTrackPage(c, deviceID, from, to, after, pageSize)
+	if err != nil {
+		h.logger.WithField("error", err).WithField("addr", deviceID).Error("Failed to get pilot track page")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "track_query_error",
+			"message": "Failed to query pilot track",
+		})
+		return
+	}
+
+	points := page.Points
+	if points == nil {
+		points = []models.TrackGeoPoint{}
+	}
+	result := gin.H{
+		"addr":   deviceID,
+		"from":   from.UTC().Format(time.RFC3339),
+		"to":     to.UTC().Format(time.RFC3339),
+		"points": points,
+	}
+	if page.Next != nil {
+		result["next_cursor"] = page.Next.Encode()
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// streamTrackRange отдает необработанный трек за период по мере чтения страниц из MySQL,
+// не собирая его в памяти. max_points прореживает трек по времени на лету.
+// Ошибка после начала ответа обрывает его: клиент получит незавершенный JSON
+func (h *RESTHandler) streamTrackRange(c *gin.Context, deviceID string, addr uint32, format string, from, to time.Time, maxPoints int) {
+	page, err := h.fetchTrackPage(c, deviceID, from, to, nil, maxTrackPageSize)
+	if err != nil {
+		h.logger.WithField("error", err).WithField("addr", deviceID).Error("Failed to get pilot track")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    "track_query_error",
+			"message": "Failed to query pilot track",
+		})
+		return
+	}
+	if len(page.Points) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    "track_empty",
+			"message": "No track data available",
+		})
+		return
+	}
+
+	var downsampler *filter.TimeBucketDownsampler
+	if maxPoints > 0 {
+		downsampler = filter.NewTimeBucketDownsampler(from, to, maxPoints)
+	}
+
+	stream := &trackStreamWriter{w: c.Writer, format: format}
+	c.Header("Content-Type", stream.contentType())
+	c.Status(http.StatusOK)
+	stream.begin(deviceID, addr, from, to)
+
+	// Последняя отброшенная точка: конец трека сохраняется при прореживании
+	var pending *models.TrackGeoPoint
+	for {
+		for i := range page.Points {
+			if downsampler != nil && !downsampler.Keep(page.Points[i].Timestamp) {
+				pending = &page.Points[i]
+				continue
+			}
+			pending = nil
+			stream.point(page.Points[i])
+		}
+		c.Writer.Flush()
+
+		if page.Next == nil || stream.err != nil {
+			break
+		}
+		page, err = h.fetchTrackPage(c, deviceID, from, to, page.Next, maxTrackPageSize)
+		if err != nil {
+			h.logger.WithField("error", err).WithField("addr", deviceID).Error("Failed to get pilot track page, response truncated")
+			return
+		}
+	}
+	if pending != nil {
+		stream.point(*pending)
+	}
+	stream.end(addr, from, to)
+
+	if stream.err != nil {
+		h.logger.WithField("error", stream.err).WithField("addr", deviceID).Debug("Track stream interrupted")
+	}
+}
+
+// trackStreamWriter пишет трек по точкам в формате json, geojson или ndjson
+type trackStreamWriter struct {
+	w      io.Writer
+	format string
+	count  int
+	err    error
+}
+
+func (s *trackStreamWriter) contentType() string {
+	if s.format == "ndjson" {
+		return "application/x-ndjson"
+	}
+	return "application/json; charset=utf-8"
+}
+
+func (s *trackStreamWriter) write(data []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(data)
+	}
+}
+
+func (s *trackStreamWriter) begin(deviceID string, addr uint32, from, to time.Time) {
+	switch s.format {
+	case "json":
+		s.write([]byte(fmt.Sprintf(`{"addr":%q,"from":%q,"to":%q,"points":[`,
+			deviceID, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))))
+	case "geojson":
+		s.write([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"LineString","coordinates":[`))
+	}
+}
+
+func (s *trackStreamWriter) point(point models.TrackGeoPoint) {
+	var data []byte
+	switch s.format {
+	case "geojson":
+		// Координаты [longitude, latitude], как в convertTrackToGeoJSON
+		data = append(data, '[')
+		data = strconv.AppendFloat(data, point.Longitude, 'f', -1, 64)
+		data = append(data, ',')
+		data = strconv.AppendFloat(data, point.Latitude, 'f', -1, 64)
+		data = append(data, ']')
+	default:
+		var err error
+		if data, err = json.Marshal(point); err != nil {
+			s.err = err
+			return
+		}
+	}
+
+	switch {
+	case s.format == "ndjson":
+		data = append(data, '\n')
+	case s.count > 0:
+		s.write([]byte{','})
+	}
+	s.write(data)
+	s.count++
+}
+
+func (s *trackStreamWriter) end(addr uint32, from, to time.Time) {
+	switch s.format {
+	case "json":
+		s.write([]byte(fmt.Sprintf(`],"count":%d}`, s.count)))
+	case "geojson":
+		properties, err := json.Marshal(map[string]interface{}{
+			"addr":        addr,
+			"color":       generateColorFromAddr(addr),
+			"start_time":  from.Unix(),
+			"end_time":    to.Unix(),
+			"point_count": s.count,
+		})
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.write([]byte(`]},"properties":`))
+		s.write(properties)
+		s.write([]byte(`}]}`))
+	}
+}
+
+// downsampleTrack прореживает трек по времени до maxPoints точек (см. filter.TimeBucketDownsampler)
+func downsampleTrack(points []models.TrackGeoPoint, maxPoints int) []models.TrackGeoPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	downsampler := filter.NewTimeBucketDownsampler(points[0].Timestamp, points[len(points)-1].Timestamp, maxPoints)
+	result := make([]models.TrackGeoPoint, 0, maxPoints)
+	for _, point := range points {
+		if downsampler.Keep(point.Timestamp) {
+			result = append(result, point)
+		}
+	}
+	return result
+}
@@ -1,24 +1,73 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/flybeeper/fanet-backend/internal/auth"
 	"github.com/flybeeper/fanet-backend/internal/geo"
 	"github.com/flybeeper/fanet-backend/internal/metrics"
 	"github.com/flybeeper/fanet-backend/internal/models"
 	"github.com/flybeeper/fanet-backend/internal/repository"
+	"github.com/flybeeper/fanet-backend/internal/service"
 	"github.com/flybeeper/fanet-backend/pkg/pb"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
+// Версии протокола WebSocket (параметр подключения protocol)
+const (
+	// ProtocolV1 одна подписка из параметров подключения, JSON управление,
+	// сообщения сервера без обертки
+	ProtocolV1 = 1
+	// ProtocolV2 бинарный protobuf канал управления (ClientMessage/ServerMessage)
+	// и именованные подписки
+	ProtocolV2 = 2
+)
+
+// Номера полей oneof payload в pb.ServerMessage
+const (
+	serverMessageWelcome           protowire.Number = 1
+	serverMessageSubscribeResponse protowire.Number = 2
+	serverMessageBatch             protowire.Number = 3
+	serverMessagePing              protowire.Number = 4
+)
+
+// encodeServerMessage сериализует сообщение сервера для версии протокола клиента
+func encodeServerMessage(protocol int, field protowire.Number, msg proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == ProtocolV2 {
+		data = wrapServerMessage(field, data)
+	}
+	return data, nil
+}
+
+// wrapServerMessage оборачивает сериализованное сообщение в pb.ServerMessage.
+// Позволяет один раз сериализовать UpdateBatch для клиентов обеих версий
+func wrapServerMessage(field protowire.Number, data []byte) []byte {
+	framed := make([]byte, 0, len(data)+protowire.SizeTag(field)+protowire.SizeVarint(uint64(len(data))))
+	framed = protowire.AppendTag(framed, field, protowire.BytesType)
+	return protowire.AppendBytes(framed, data)
+}
+
+// TokenValidator проверяет Bearer token пользователя (auth.Validator)
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*auth.User, error)
+}
+
 // WebSocketHandler обрабатывает WebSocket соединения для real-time обновлений
 type WebSocketHandler struct {
 	upgrader   websocket.Upgrader
@@ -27,24 +76,37 @@ type WebSocketHandler struct {
 	broadcast  *BroadcastManager
 	spatial    *geo.SpatialIndex
 	adaptive   *AdaptiveScheduler
-	sequence   uint64
-	sequenceMu sync.Mutex
+	validator  TokenValidator        // nil - токен проверяется только по длине
+	friends    *service.FriendsStore // nil - списки друзей недоступны (нет Redis)
 }
 
 // Client представляет WebSocket соединение
 type Client struct {
-	conn          *websocket.Conn
+	conn          *websocket.Conn // nil у клиентов SSE и long-poll
+	remote        string          // Адрес клиента без WebSocket соединения
 	send          chan []byte
 	updateSignal  chan bool
 	handler       *WebSocketHandler
 	center        models.GeoPoint
 	radius        int32
 	geohashes     []string
+	subscriptions map[string]*Subscription // Имя подписки -> область и типы
+	protocol      int
+	delta         bool // Поля объектов передаются разницей с последним отправленным состоянием
 	lastSequence  uint64
 	authenticated bool
+	userID        int // Пользователь проверенного токена, 0 - анонимный клиент
 	mu            sync.RWMutex
 }
 
+// remoteAddr возвращает адрес клиента для логов
+func (c *Client) remoteAddr() string {
+	if c.conn != nil {
+		return c.conn.RemoteAddr().String()
+	}
+	return c.remote
+}
+
 // NewWebSocketHandler создает новый WebSocket handler
 func NewWebSocketHandler(repo repository.Repository, logger interface{}) *WebSocketHandler {
 	// Конвертируем logger в правильный тип
@@ -84,44 +146,33 @@ func NewWebSocketHandler(repo repository.Repository, logger interface{}) *WebSoc
 	}
 }
 
-// HandleWebSocket обрабатывает WebSocket подключения
-func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
-	// Извлекаем параметры подключения
-	latStr := c.Query("lat")
-	lonStr := c.Query("lon")
-	radiusStr := c.Query("radius")
-	token := c.Query("token")
-
-	if latStr == "" || lonStr == "" || radiusStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "lat, lon, radius are required"})
-		return
-	}
+// SetAuthValidator подключает проверку токенов клиентов через Laravel API
+func (h *WebSocketHandler) SetAuthValidator(validator TokenValidator) {
+	h.validator = validator
+}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
-	if err != nil || lat < -90 || lat > 90 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid latitude"})
-		return
-	}
+// SetFriendsStore подключает списки друзей пользователей
+func (h *WebSocketHandler) SetFriendsStore(store *service.FriendsStore) {
+	h.friends = store
+}
 
-	lon, err := strconv.ParseFloat(lonStr, 64)
-	if err != nil || lon < -180 || lon > 180 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid longitude"})
-		return
+// HandleWebSocket обрабатывает WebSocket подключения
+func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
+	protocol := ProtocolV1
+	if protocolStr := c.Query("protocol"); protocolStr != "" {
+		version, err := strconv.Atoi(protocolStr)
+		if err != nil || (version != ProtocolV1 && version != ProtocolV2) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid protocol (1 or 2)"})
+			return
+		}
+		protocol = version
 	}
 
-	radius, err := strconv.ParseInt(radiusStr, 10, 32)
-	if err != nil || radius <= 0 || radius > 200 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid radius (1-200 km)"})
+	params, ok := h.parseStreamParams(c, protocol)
+	if !ok {
 		return
 	}
 
-	// Проверяем аутентификацию если токен предоставлен
-	authenticated := false
-	if token != "" {
-		// TODO: Валидация токена через Laravel API
-		authenticated = len(token) > 10 // Базовая проверка
-	}
-
 	// Обновляем до WebSocket
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -130,28 +181,39 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &Client{
-		conn:         conn,
-		send:         make(chan []byte, 256),
-		updateSignal: make(chan bool, 1),
-		handler:      h,
-		center:       models.GeoPoint{Latitude: lat, Longitude: lon},
-		radius:       int32(radius),
-		authenticated: authenticated,
+		conn:          conn,
+		send:          make(chan []byte, 256),
+		updateSignal:  make(chan bool, 1),
+		handler:       h,
+		subscriptions: make(map[string]*Subscription),
+		protocol:      protocol,
+		delta:         params.delta,
+		authenticated: params.authenticated,
+		userID:        params.userID,
+	}
+	if params.initial != nil {
+		client.center = params.initial.Center
+		client.radius = int32(params.initial.RadiusKm)
 	}
 
 	// Регистрируем клиента в broadcast manager
-	h.broadcast.Register(client, lat, lon, float64(radius))
+	h.broadcast.Register(client, nil)
 	
 	// Анализируем активность региона и регистрируем в адаптивном планировщике
-	initialMetrics := AnalyzeRegionActivity(h.spatial, lat, lon, float64(radius))
+	initialMetrics := AnalyzeRegionActivity(h.spatial, client.center.Latitude, client.center.Longitude, float64(client.radius))
 	h.adaptive.RegisterClient(client, initialMetrics)
 
 	h.logger.WithFields(logrus.Fields{
 		"client_ip": c.ClientIP(),
-		"lat":       lat,
-		"lon":       lon,
-		"radius":    radius,
-		"auth":      authenticated,
+		"lat":       client.center.Latitude,
+		"lon":       client.center.Longitude,
+		"radius":    client.radius,
+		"protocol":  protocol,
+		"delta":     params.delta,
+		"resume":    params.resumeFrom,
+		"auth":      params.authenticated,
+		"follow":    params.follow != nil,
+		"friends":   params.friends != nil,
 		"activity":  initialMetrics.ObjectCount,
 	}).Info("WebSocket client connected")
 	
@@ -165,65 +227,310 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	// Отправляем приветственное сообщение
 	client.sendWelcome()
 
-	// Подписываем на регион
-	client.subscribeToRegion()
+	// Подписываем на регион и устройства из параметров подключения
+	client.subscribeParams(params)
+}
+
+// streamParams параметры подключения к потоку обновлений, общие для WebSocket,
+// SSE и long-poll
+type streamParams struct {
+	initial       *Subscription // Подписка из lat/lon/radius, nil - без подписки
+	follow        *Subscription // Устройства из параметра follow
+	friends       *Subscription // Список друзей пользователя (friends=true)
+	delta         bool
+	resumeFrom    uint64
+	authenticated bool
+	userID        int
+}
+
+// parseStreamParams разбирает параметры подключения и проверяет токен.
+// При ошибке отвечает клиенту и возвращает ok = false. Область обязательна
+// в протоколе v1, если клиент не следит за устройствами (follow, friends)
+func (h *WebSocketHandler) parseStreamParams(c *gin.Context, protocol int) (*streamParams, bool) {
+	latStr := c.Query("lat")
+	lonStr := c.Query("lon")
+	radiusStr := c.Query("radius")
+	token := c.Query("token")
+
+	params := &streamParams{}
+
+	// Устройства, обновления которых приходят вне зависимости от области
+	if followStr := c.Query("follow"); followStr != "" {
+		devices, err := parseDeviceIDs(strings.Split(followStr, ","))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid follow: " + err.Error()})
+			return nil, false
+		}
+		params.follow = NewDeviceSubscription(followSubscriptionID, devices)
+	}
+	friends := false
+	if friendsStr := c.Query("friends"); friendsStr != "" {
+		enabled, err := strconv.ParseBool(friendsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid friends (true or false)"})
+			return nil, false
+		}
+		friends = enabled
+	}
+
+	// Delta сжатие обновлений (клиент должен уметь применять Update.delta)
+	if deltaStr := c.Query("delta"); deltaStr != "" {
+		enabled, err := strconv.ParseBool(deltaStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delta (true or false)"})
+			return nil, false
+		}
+		params.delta = enabled
+	}
+
+	// Последняя полученная последовательность для восстановления после переподключения
+	if resumeStr := c.Query("resume_from"); resumeStr != "" {
+		sequence, err := strconv.ParseUint(resumeStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resume_from"})
+			return nil, false
+		}
+		params.resumeFrom = sequence
+	}
+
+	// В протоколе v2 область из параметров необязательна: подписки создаются через SubscribeRequest
+	regionRequired := protocol == ProtocolV1 && params.follow == nil && !friends
+	if regionRequired || latStr != "" || lonStr != "" || radiusStr != "" {
+		if latStr == "" || lonStr == "" || radiusStr == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat, lon, radius are required"})
+			return nil, false
+		}
+
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil || lat < -90 || lat > 90 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid latitude"})
+			return nil, false
+		}
+
+		lon, err := strconv.ParseFloat(lonStr, 64)
+		if err != nil || lon < -180 || lon > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid longitude"})
+			return nil, false
+		}
+
+		radius, err := strconv.ParseInt(radiusStr, 10, 32)
+		if err != nil || radius <= 0 || radius > maxSubscriptionRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid radius (1-200 km)"})
+			return nil, false
+		}
+
+		id := ""
+		if protocol == ProtocolV2 {
+			id = defaultSubscriptionID
+		}
+		params.initial = NewCircleSubscription(id, lat, lon, float64(radius))
+	}
+
+	// Проверяем аутентификацию если токен предоставлен
+	if token != "" {
+		if h.validator == nil {
+			params.authenticated = len(token) > 10 // Базовая проверка без Laravel API
+		} else if user, err := h.validator.ValidateToken(c.Request.Context(), token); err == nil {
+			params.authenticated = true
+			params.userID = user.ID
+		} else {
+			h.logger.WithError(err).WithField("client_ip", c.ClientIP()).Debug("Stream token validation failed")
+		}
+	}
+
+	if friends {
+		sub, err := h.friendsSubscription(c.Request.Context(), params.userID, friendsSubscriptionID)
+		if err != nil {
+			status := http.StatusServiceUnavailable
+			if params.userID == 0 {
+				status = http.StatusUnauthorized
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		params.friends = sub
+	}
+
+	return params, true
+}
+
+// friendsSubscription создает подписку id на список друзей пользователя
+func (h *WebSocketHandler) friendsSubscription(ctx context.Context, userID int, id string) (*Subscription, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("friends require a valid token")
+	}
+	if h.friends == nil {
+		return nil, fmt.Errorf("friends list is not available")
+	}
+
+	devices, err := h.friends.List(ctx, userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to load friends")
+		return nil, fmt.Errorf("failed to load friends")
+	}
+	sub := NewDeviceSubscription(id, devices)
+	sub.Friends = true
+	return sub, nil
+}
+
+// UpdateFriends заменяет устройства подписок на список друзей у подключенных
+// клиентов пользователя. Клиенты получают SubscribeResponse и снимок устройств
+func (h *WebSocketHandler) UpdateFriends(userID int, devices []string) {
+	for _, client := range h.broadcast.UserClients(userID) {
+		client.mu.RLock()
+		var ids []string
+		for id, sub := range client.subscriptions {
+			if sub.Friends {
+				ids = append(ids, id)
+			}
+		}
+		client.mu.RUnlock()
+
+		for _, id := range ids {
+			sub := NewDeviceSubscription(id, devices)
+			sub.Friends = true
+			client.subscribeToRegion(sub, 0)
+		}
+	}
+}
+
+// subscribeParams подписывает клиента на область и устройства из параметров подключения
+func (c *Client) subscribeParams(params *streamParams) {
+	for _, sub := range []*Subscription{params.initial, params.follow, params.friends} {
+		if sub != nil {
+			c.subscribeToRegion(sub, params.resumeFrom)
+		}
+	}
 }
 
 // sendWelcome отправляет приветственное сообщение
 func (c *Client) sendWelcome() {
 	welcome := &pb.Welcome{
 		ServerTime:    uint64(time.Now().Unix()),
-		Sequence:      c.handler.getNextSequence(),
+		Sequence:      c.handler.broadcast.Sequence(),
 		ServerVersion: "1.0.0",
 	}
 
-	data, err := proto.Marshal(welcome)
+	c.sendServerMessage(serverMessageWelcome, welcome, "welcome")
+}
+
+// sendServerMessage сериализует сообщение, в протоколе v2 оборачивает его
+// в pb.ServerMessage и ставит в очередь отправки
+func (c *Client) sendServerMessage(field protowire.Number, msg proto.Message, kind string) {
+	data, err := encodeServerMessage(c.protocol, field, msg)
 	if err != nil {
-		c.handler.logger.WithField("error", err).Error("Failed to marshal welcome message")
+		c.handler.logger.WithFields(logrus.Fields{
+			"error": err,
+			"type":  kind,
+		}).Error("Failed to marshal server message")
 		return
 	}
 
 	select {
 	case c.send <- data:
 	case <-time.After(5 * time.Second):
-		c.handler.logger.Warn("Welcome message send timeout")
+		c.handler.logger.WithField("type", kind).Warn("Server message send timeout")
 	}
 }
 
-// subscribeToRegion подписывает клиента на геопространственный регион
-func (c *Client) subscribeToRegion() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
+// subscribeToRegion добавляет или заменяет подписку клиента и переносит клиента
+// в geohash группы broadcast manager, который отправляет SubscribeResponse.
+// resumeFrom > 0 - последняя полученная клиентом последовательность: следом
+// за ответом придут пропущенные обновления области или снимок. Подписка на
+// устройства всегда получает снимок их текущего состояния
+func (c *Client) subscribeToRegion(sub *Subscription, resumeFrom uint64) error {
 	// Вычисляем geohash ячейки для региона
-	precision := geo.OptimalGeohashPrecision(float64(c.radius))
-	geohashes := geo.Cover(c.center.Latitude, c.center.Longitude, float64(c.radius), precision)
-	c.geohashes = geohashes
+	geohashes := sub.Geohashes()
 
-	// Отправляем подтверждение подписки
-	response := &pb.SubscribeResponse{
-		Success:   true,
-		Geohashes: c.geohashes,
+	c.mu.Lock()
+	if _, exists := c.subscriptions[sub.ID]; !exists && len(c.subscriptions) >= maxSubscriptionsPerClient {
+		c.mu.Unlock()
+		return fmt.Errorf("too many subscriptions (max %d)", maxSubscriptionsPerClient)
+	}
+	c.subscriptions[sub.ID] = sub
+	if sub.Devices == nil {
+		c.center = sub.Center
+		c.radius = int32(math.Ceil(sub.RadiusKm))
+		c.geohashes = geohashes
 	}
+	c.mu.Unlock()
+
+	c.handler.broadcast.Subscribe(c, sub, &pb.SubscribeResponse{
+		Success:   true,
+		Geohashes: geohashes,
+		Id:        sub.ID,
+	}, resumeFrom)
 
-	data, err := proto.Marshal(response)
+	c.handler.logger.WithFields(logrus.Fields{
+		"subscription": sub.ID,
+		"center":       fmt.Sprintf("%.4f,%.4f", sub.Center.Latitude, sub.Center.Longitude),
+		"radius":       sub.RadiusKm,
+		"bounds":       sub.Bounds != nil,
+		"types":        len(sub.Types),
+		"devices":      len(sub.Devices),
+		"geohashes":    len(geohashes),
+		"resume_from":  resumeFrom,
+	}).Debug("Client subscribed to region")
+	return nil
+}
+
+// sendSnapshot отправляет текущее состояние области подписки одним батчем
+// с snapshot = true, когда пропущенные обновления нельзя повторить из журнала
+func (c *Client) sendSnapshot(sub *Subscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sequence := c.handler.broadcast.Sequence()
+	packets, err := c.handler.loadSnapshot(ctx, sub)
 	if err != nil {
-		c.handler.logger.WithField("error", err).Error("Failed to marshal subscribe response")
+		c.handler.logger.WithFields(logrus.Fields{
+			"error":        err,
+			"subscription": sub.ID,
+		}).Error("Failed to load subscription snapshot")
 		return
 	}
 
-	select {
-	case c.send <- data:
-	case <-time.After(5 * time.Second):
-		c.handler.logger.Warn("Subscribe response send timeout")
+	var tags []string
+	if sub.ID != "" {
+		tags = []string{sub.ID}
 	}
 
-	c.handler.logger.WithFields(logrus.Fields{
-		"center":    fmt.Sprintf("%.4f,%.4f", c.center.Latitude, c.center.Longitude),
-		"radius":    c.radius,
-		"geohashes": len(c.geohashes),
-		"precision": precision,
-	}).Debug("Client subscribed to region")
+	batch := &pb.UpdateBatch{
+		Timestamp: time.Now().Unix(),
+		Snapshot:  true,
+	}
+	for _, packet := range packets {
+		enc := c.handler.broadcast.encodeUpdate(packet)
+		if enc == nil || (!sub.Follows(enc.device) && !sub.Matches(packet.Type, enc.lat, enc.lon)) {
+			continue
+		}
+		batch.Updates = append(batch.Updates, &pb.Update{
+			Type:          packet.Type,
+			Action:        pb.Action_ACTION_ADD,
+			Data:          enc.data,
+			Sequence:      sequence,
+			Subscriptions: tags,
+		})
+	}
+
+	c.sendServerMessage(serverMessageBatch, batch, "snapshot")
+}
+
+// unsubscribe удаляет подписку клиента по имени, пустое имя удаляет все подписки
+func (c *Client) unsubscribe(id string) error {
+	c.mu.Lock()
+	if id == "" {
+		c.subscriptions = make(map[string]*Subscription)
+	} else if _, exists := c.subscriptions[id]; exists {
+		delete(c.subscriptions, id)
+	} else {
+		c.mu.Unlock()
+		return fmt.Errorf("unknown subscription %q", id)
+	}
+	c.mu.Unlock()
+
+	c.handler.broadcast.Unsubscribe(c, id)
+	return nil
 }
 
 // readPump обрабатывает входящие сообщения от клиента
@@ -243,7 +550,7 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				c.handler.logger.WithField("error", err).Error("WebSocket read error")
@@ -252,6 +559,12 @@ func (c *Client) readPump() {
 		}
 
 		// Обрабатываем входящие сообщения
+		if c.protocol == ProtocolV2 {
+			if messageType == websocket.BinaryMessage {
+				c.handleControlMessage(message)
+			}
+			continue
+		}
 		c.handleMessage(message)
 	}
 }
@@ -299,6 +612,9 @@ func (c *Client) writePump() {
 				c.handler.logger.WithField("error", err).Error("Failed to marshal ping")
 				continue
 			}
+			if c.protocol == ProtocolV2 {
+				data = wrapServerMessage(serverMessagePing, data)
+			}
 
 			if err := c.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
 				c.handler.logger.WithField("error", err).Error("Ping write error")
@@ -334,6 +650,16 @@ func (c *Client) handleMessage(message []byte) {
 				c.updateSubscription(req.Lat, req.Lon, req.Radius)
 			}
 			
+		case "follow":
+			// Устройства вне области подписки, пустой список отменяет слежение
+			var req struct {
+				Devices []string `json:"devices"`
+			}
+			
+			if err := json.Unmarshal(message, &req); err == nil {
+				c.follow(req.Devices)
+			}
+			
 		case "pong":
 			// Обработка pong ответа
 			c.handler.logger.Debug("Received pong from client")
@@ -341,6 +667,93 @@ func (c *Client) handleMessage(message []byte) {
 	}
 }
 
+// handleControlMessage обрабатывает бинарное управляющее сообщение протокола v2.
+// На SubscribeRequest и UnsubscribeRequest сервер отвечает SubscribeResponse
+// с именем подписки
+func (c *Client) handleControlMessage(message []byte) {
+	var msg pb.ClientMessage
+	if err := proto.Unmarshal(message, &msg); err != nil || msg.GetPayload() == nil {
+		metrics.WebSocketControlMessages.WithLabelValues("unknown", "error").Inc()
+		c.sendServerMessage(serverMessageSubscribeResponse, &pb.SubscribeResponse{
+			Error: "invalid control message",
+		}, "subscribe_response")
+		return
+	}
+
+	var kind, id string
+	var err error
+
+	switch payload := msg.GetPayload().(type) {
+	case *pb.ClientMessage_Subscribe:
+		kind = "subscribe"
+		var sub *Subscription
+		if payload.Subscribe.GetFriends() {
+			id = payload.Subscribe.GetId()
+			if id == "" {
+				id = friendsSubscriptionID
+			}
+			if len(id) > maxSubscriptionIDLength {
+				err = fmt.Errorf("subscription id is longer than %d characters", maxSubscriptionIDLength)
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				sub, err = c.handler.friendsSubscription(ctx, c.userID, id)
+				cancel()
+			}
+		} else {
+			sub, err = subscriptionFromProto(payload.Subscribe, defaultSubscriptionID)
+		}
+		if err == nil {
+			id = sub.ID
+			err = c.subscribeToRegion(sub, payload.Subscribe.GetLastSequence())
+		} else {
+			id = payload.Subscribe.GetId()
+		}
+
+	case *pb.ClientMessage_Unsubscribe:
+		kind = "unsubscribe"
+		id = payload.Unsubscribe.GetId()
+		if err = c.unsubscribe(id); err == nil {
+			c.sendServerMessage(serverMessageSubscribeResponse, &pb.SubscribeResponse{
+				Success: true,
+				Id:      id,
+			}, "subscribe_response")
+			c.handler.logger.WithFields(logrus.Fields{
+				"subscription": id,
+				"reason":       payload.Unsubscribe.GetReason(),
+			}).Debug("Client unsubscribed")
+		}
+
+	case *pb.ClientMessage_Pong:
+		kind = "pong"
+		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	}
+
+	if err != nil {
+		metrics.WebSocketControlMessages.WithLabelValues(kind, "error").Inc()
+		c.sendServerMessage(serverMessageSubscribeResponse, &pb.SubscribeResponse{
+			Error: err.Error(),
+			Id:    id,
+		}, "subscribe_response")
+		return
+	}
+	metrics.WebSocketControlMessages.WithLabelValues(kind, "ok").Inc()
+}
+
+// follow заменяет подписку протокола v1 на устройства вне области
+func (c *Client) follow(deviceIDs []string) {
+	if len(deviceIDs) == 0 {
+		c.unsubscribe(followSubscriptionID)
+		return
+	}
+
+	devices, err := parseDeviceIDs(deviceIDs)
+	if err != nil {
+		c.handler.logger.WithError(err).Warn("Invalid follow devices")
+		return
+	}
+	c.subscribeToRegion(NewDeviceSubscription(followSubscriptionID, devices), 0)
+}
+
 // updateSubscription обновляет подписку клиента на новый регион
 func (c *Client) updateSubscription(lat, lon float64, radius int32) {
 	if lat < -90 || lat > 90 || lon < -180 || lon > 180 || radius <= 0 || radius > 200 {
@@ -348,12 +761,7 @@ func (c *Client) updateSubscription(lat, lon float64, radius int32) {
 		return
 	}
 
-	c.mu.Lock()
-	c.center = models.GeoPoint{Latitude: lat, Longitude: lon}
-	c.radius = radius
-	c.mu.Unlock()
-
-	c.subscribeToRegion()
+	c.subscribeToRegion(NewCircleSubscription("", lat, lon, float64(radius)), 0)
 
 	c.handler.logger.WithFields(map[string]interface{}{
 		"new_center": fmt.Sprintf("%.4f,%.4f", lat, lon),
@@ -372,12 +780,82 @@ func (h *WebSocketHandler) unregisterClient(client *Client) {
 	h.logger.Debug("WebSocket client disconnected")
 }
 
-// getNextSequence возвращает следующий номер последовательности
-func (h *WebSocketHandler) getNextSequence() uint64 {
-	h.sequenceMu.Lock()
-	defer h.sequenceMu.Unlock()
-	h.sequence++
-	return h.sequence
+// loadSnapshot загружает из репозитория текущие объекты области подписки
+// с учетом фильтра типов. Текстовые сообщения в снимок не входят. Для подписки
+// на устройства загружаются пилоты с этими адресами
+func (h *WebSocketHandler) loadSnapshot(ctx context.Context, sub *Subscription) ([]*UpdatePacket, error) {
+	if h.repository == nil {
+		return nil, fmt.Errorf("repository is not configured")
+	}
+
+	if sub.Devices != nil {
+		var packets []*UpdatePacket
+		for deviceID := range sub.Devices {
+			pilot, err := h.repository.GetPilot(ctx, deviceID)
+			if err != nil {
+				return nil, fmt.Errorf("pilot %s: %w", deviceID, err)
+			}
+			if pilot != nil {
+				packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_PILOT, Pilot: pilot, Timestamp: time.Now()})
+			}
+		}
+		return packets, nil
+	}
+
+	center := sub.Center
+	wants := func(updateType pb.UpdateType) bool {
+		return len(sub.Types) == 0 || sub.Types[updateType]
+	}
+	now := time.Now()
+
+	var packets []*UpdatePacket
+	if wants(pb.UpdateType_UPDATE_TYPE_PILOT) {
+		pilots, err := h.repository.GetPilotsInRadius(ctx, center, sub.RadiusKm)
+		if err != nil {
+			return nil, fmt.Errorf("pilots: %w", err)
+		}
+		for _, pilot := range pilots {
+			packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_PILOT, Pilot: pilot, Timestamp: now})
+		}
+	}
+	if wants(pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT) {
+		objects, err := h.repository.GetGroundObjectsInRadius(ctx, center, sub.RadiusKm)
+		if err != nil {
+			return nil, fmt.Errorf("ground objects: %w", err)
+		}
+		for _, object := range objects {
+			packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT, GroundObject: object, Timestamp: now})
+		}
+	}
+	if wants(pb.UpdateType_UPDATE_TYPE_THERMAL) {
+		thermals, err := h.repository.GetThermalsInRadius(ctx, center, sub.RadiusKm)
+		if err != nil {
+			return nil, fmt.Errorf("thermals: %w", err)
+		}
+		for _, thermal := range thermals {
+			packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_THERMAL, Thermal: thermal, Timestamp: now})
+		}
+	}
+	if wants(pb.UpdateType_UPDATE_TYPE_STATION) {
+		stations, err := h.repository.GetStationsInRadius(ctx, center, sub.RadiusKm)
+		if err != nil {
+			return nil, fmt.Errorf("stations: %w", err)
+		}
+		for _, station := range stations {
+			packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_STATION, Station: station, Timestamp: now})
+		}
+	}
+	if wants(pb.UpdateType_UPDATE_TYPE_LANDMARK) {
+		landmarks, err := h.repository.GetLandmarksInRadius(ctx, center, sub.RadiusKm)
+		if err != nil {
+			return nil, fmt.Errorf("landmarks: %w", err)
+		}
+		for _, landmark := range landmarks {
+			packets = append(packets, &UpdatePacket{Type: pb.UpdateType_UPDATE_TYPE_LANDMARK, Landmark: landmark, Timestamp: now})
+		}
+	}
+
+	return packets, nil
 }
 
 // BroadcastUpdate отправляет обновление всем подключенным клиентам
@@ -392,13 +870,42 @@ func (h *WebSocketHandler) BroadcastUpdate(updateType pb.UpdateType, action pb.A
 	switch v := data.(type) {
 	case *pb.Pilot:
 		if v.Position != nil {
+			// Без FANET адреса остается прежний идентификатор по имени
+			deviceID := v.Name
+			if v.Addr != 0 {
+				deviceID = models.DeviceIDFromAddr(v.Addr)
+			}
 			packet.Pilot = &models.Pilot{
-				Address:    v.Name, // Временно используем Name как Address
+				DeviceID:   deviceID,
+				Address:    deviceID,
 				Name:       v.Name,
+				Type:       models.PilotType(v.Type),
 				Position:   &models.GeoPoint{Latitude: v.Position.Latitude, Longitude: v.Position.Longitude, Altitude:   v.Altitude},				
 				Speed:      v.Speed,
+				ClimbRate:  int16(math.Round(float64(v.Climb) * 10)),
 				Heading:    v.Course,
+				TrackOnline: v.TrackOnline,
+				Battery:    uint8(v.Battery),
 				LastUpdate: time.Unix(v.LastUpdate, 0),
+				Smoothed:   protoToModelsEstimate(v.Smoothed),
+			}
+			if v.Receivers > 0 {
+				packet.Pilot.Reception = &models.ReceptionStats{
+					Stations: int(v.Receivers),
+					BestSNR:  int16(v.BestSnr),
+				}
+			}
+		}
+		
+	case *pb.GroundObject:
+		if v.Position != nil {
+			packet.GroundObject = &models.GroundObject{
+				DeviceID:    models.DeviceIDFromAddr(v.Addr),
+				Name:        v.Name,
+				Type:        models.GroundType(v.Type),
+				Position:    &models.GeoPoint{Latitude: v.Position.Latitude, Longitude: v.Position.Longitude},
+				TrackOnline: v.TrackOnline,
+				LastUpdate:  time.Unix(v.LastUpdate, 0),
 			}
 		}
 		
@@ -410,6 +917,8 @@ func (h *WebSocketHandler) BroadcastUpdate(updateType pb.UpdateType, action pb.A
 				ClimbRate:  v.Climb,
 				Quality:    int32(v.Quality),
 				Timestamp:  time.Unix(v.Timestamp, 0),
+				Source:     v.Source,
+				Confidence: v.Confidence,
 			}
 		}
 		
@@ -423,6 +932,26 @@ func (h *WebSocketHandler) BroadcastUpdate(updateType pb.UpdateType, action pb.A
 			}
 		}
 		
+	case *pb.Message:
+		if v.Position != nil {
+			packet.Message = &models.Message{
+				ID:        v.Id,
+				From:      models.DeviceIDFromAddr(v.FromAddr),
+				Subheader: uint8(v.Subheader),
+				Text:      v.Text,
+				Position:  &models.GeoPoint{Latitude: v.Position.Latitude, Longitude: v.Position.Longitude, Altitude: v.Position.Altitude},
+				Timestamp: time.Unix(v.Timestamp, 0),
+			}
+			if !v.Broadcast {
+				packet.Message.To = models.DeviceIDFromAddr(v.ToAddr)
+			}
+		}
+		
+	case *pb.Landmark:
+		if len(v.Points) > 0 {
+			packet.Landmark = protoToModelsLandmarks([]*pb.Landmark{v})[0]
+		}
+		
 	default:
 		h.logger.WithField("type", fmt.Sprintf("%T", data)).Warn("Unknown update data type")
 		return
@@ -437,6 +966,57 @@ func (h *WebSocketHandler) BroadcastUpdate(updateType pb.UpdateType, action pb.A
 	}).Debug("Update sent to broadcast manager")
 }
 
+// BroadcastAlert немедленно отправляет инцидент всем подключенным клиентам,
+// независимо от радиуса подписки. Реализует service.AlertBroadcaster
+func (h *WebSocketHandler) BroadcastAlert(event service.AlertEvent, alert *models.Alert) {
+	action := pb.Action_ACTION_UPDATE
+	switch event {
+	case service.AlertEventOpened:
+		action = pb.Action_ACTION_ADD
+	case service.AlertEventResolved:
+		action = pb.Action_ACTION_REMOVE
+	}
+
+	alertData, err := proto.Marshal(alert.ToProto())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal alert")
+		return
+	}
+
+	recipients := h.broadcast.BroadcastPriority(pb.UpdateType_UPDATE_TYPE_ALERT, action, alert.ID, alertData)
+
+	h.logger.WithFields(logrus.Fields{
+		"alert_id":   alert.ID,
+		"event":      string(event),
+		"recipients": recipients,
+	}).Info("Alert broadcast to all clients")
+}
+
+// BroadcastFlight рассылает взлет/посадку всем клиентам вне зависимости от подписки
+// (для команд эвакуации). Реализует service.FlightBroadcaster
+func (h *WebSocketHandler) BroadcastFlight(event service.FlightEvent, flight *models.Flight) {
+	action := pb.Action_ACTION_REMOVE
+	if event == service.FlightEventTakeoff {
+		action = pb.Action_ACTION_ADD
+	}
+
+	flightData, err := proto.Marshal(flight.ToProto())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal flight")
+		return
+	}
+
+	// У устройства один текущий полет: клиенту после переподключения нужна последняя смена статуса
+	recipients := h.broadcast.BroadcastPriority(pb.UpdateType_UPDATE_TYPE_FLIGHT, action, flight.DeviceID, flightData)
+
+	h.logger.WithFields(logrus.Fields{
+		"flight_id":  flight.ID,
+		"device_id":  flight.DeviceID,
+		"event":      string(event),
+		"recipients": recipients,
+	}).Debug("Flight event broadcast to all clients")
+}
+
 // shouldSendUpdate проверяет, нужно ли отправлять обновление клиенту
 func (h *WebSocketHandler) shouldSendUpdate(client *Client, data proto.Message) bool {
 	client.mu.RLock()
@@ -451,6 +1031,11 @@ func (h *WebSocketHandler) shouldSendUpdate(client *Client, data proto.Message)
 			return false
 		}
 		lat, lon = v.Position.Latitude, v.Position.Longitude
+	case *pb.GroundObject:
+		if v.Position == nil {
+			return false
+		}
+		lat, lon = v.Position.Latitude, v.Position.Longitude
 	case *pb.Thermal:
 		if v.Position == nil {
 			return false
@@ -461,6 +1046,17 @@ func (h *WebSocketHandler) shouldSendUpdate(client *Client, data proto.Message)
 			return false
 		}
 		lat, lon = v.Position.Latitude, v.Position.Longitude
+	case *pb.Message:
+		if v.Position == nil {
+			return false
+		}
+		lat, lon = v.Position.Latitude, v.Position.Longitude
+	case *pb.Landmark:
+		if len(v.Points) == 0 {
+			return false
+		}
+		center := protoToModelsLandmarks([]*pb.Landmark{v})[0].Center()
+		lat, lon = center.Latitude, center.Longitude
 	default:
 		return false
 	}
@@ -508,9 +1104,7 @@ func (c *Client) handleAdaptiveUpdate() {
 
 // GetStats возвращает статистику WebSocket handler
 func (h *WebSocketHandler) GetStats() map[string]interface{} {
-	h.sequenceMu.Lock()
-	currentSequence := h.sequence
-	h.sequenceMu.Unlock()
+	currentSequence := h.broadcast.Sequence()
 
 	// Получаем метрики из broadcast manager
 	broadcastMetrics := h.broadcast.GetMetrics()
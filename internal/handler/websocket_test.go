@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// registerTestClient регистрирует клиента v1 на область и ждет, пока broadcast manager его примет
+func registerTestClient(t *testing.T, h *WebSocketHandler, lat, lon float64) *Client {
+	client := newTestClient(t, ProtocolV1)
+	client.handler = h
+	h.broadcast.Register(client, NewCircleSubscription("", lat, lon, 20))
+
+	require.Eventually(t, func() bool {
+		h.broadcast.mu.RLock()
+		defer h.broadcast.mu.RUnlock()
+		_, ok := h.broadcast.clients[client]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	return client
+}
+
+// waitPilot ждет батч с пилотом в очереди клиента v1
+func waitPilot(t *testing.T, client *Client) *pb.Pilot {
+	select {
+	case data := <-client.send:
+		var batch pb.UpdateBatch
+		require.NoError(t, proto.Unmarshal(data, &batch))
+		require.Len(t, batch.Updates, 1)
+		require.Equal(t, pb.UpdateType_UPDATE_TYPE_PILOT, batch.Updates[0].Type)
+
+		var pilot pb.Pilot
+		require.NoError(t, proto.Unmarshal(batch.Updates[0].Data, &pilot))
+		return &pilot
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no batch received")
+		return nil
+	}
+}
+
+func TestWebSocketHandler_BroadcastUpdatePilot(t *testing.T) {
+	h := NewWebSocketHandler(nil, logrus.NewEntry(logrus.New()))
+	client := registerTestClient(t, h, 46.0, 13.0)
+
+	h.BroadcastUpdate(pb.UpdateType_UPDATE_TYPE_PILOT, pb.Action_ACTION_UPDATE, &pb.Pilot{
+		Addr:       0xABC123,
+		Name:       "Test Pilot",
+		Position:   &pb.GeoPoint{Latitude: 46.0, Longitude: 13.0},
+		Altitude:   1500,
+		LastUpdate: time.Now().Unix(),
+		Receivers:  3,
+		BestSnr:    12,
+		Smoothed: &pb.PilotEstimate{
+			Position:    &pb.GeoPoint{Latitude: 46.0001, Longitude: 13.0001, Altitude: 1498},
+			Speed:       32.5,
+			Course:      270,
+			Climb:       1.5,
+			Accuracy:    8,
+			Predicted:   &pb.GeoPoint{Latitude: 46.0002, Longitude: 12.9990, Altitude: 1500},
+			PredictedAt: 1720958405,
+		},
+	})
+
+	pilot := waitPilot(t, client)
+	assert.Equal(t, uint32(0xABC123), pilot.Addr)
+	assert.Equal(t, "Test Pilot", pilot.Name)
+	assert.Equal(t, uint32(3), pilot.Receivers)
+	assert.Equal(t, int32(12), pilot.BestSnr)
+
+	// Оценка фильтра Калмана с прогнозом доходит до клиента
+	require.NotNil(t, pilot.Smoothed)
+	assert.Equal(t, 46.0001, pilot.Smoothed.GetPosition().GetLatitude())
+	assert.Equal(t, float32(32.5), pilot.Smoothed.Speed)
+	assert.Equal(t, float32(270), pilot.Smoothed.Course)
+	assert.Equal(t, float32(8), pilot.Smoothed.Accuracy)
+	assert.Equal(t, 12.9990, pilot.Smoothed.GetPredicted().GetLongitude())
+	assert.Equal(t, int64(1720958405), pilot.Smoothed.PredictedAt)
+}
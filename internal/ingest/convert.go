@@ -0,0 +1,264 @@
+package ingest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// Конвертеры FANET сообщений в модели данных
+
+func convertFANETToPilot(msg *mqtt.FANETMessage) *models.Pilot {
+	// Получаем данные для Air tracking (Type 1)
+	airData, ok := msg.Data.(*mqtt.AirTrackingData)
+	if !ok {
+		return nil
+	}
+
+	return &models.Pilot{
+		DeviceID:     msg.DeviceID,
+		Name:         "", // Имя приходит в отдельном сообщении Type 2
+		Type:         models.PilotType(airData.AircraftType),
+		Position: &models.GeoPoint{
+			Latitude:  airData.Latitude,
+			Longitude: airData.Longitude,
+			Altitude:  airData.Altitude,
+		},
+		Speed:       float32(airData.Speed),
+		ClimbRate:   airData.ClimbRate,
+		Heading:     float32(airData.Heading),
+		TrackOnline: airData.OnlineTracking, // Из FANET alt_status bit 15
+		Battery:     100,  // Нет в FANET Type 1
+		LastUpdate:  msg.Timestamp,
+	}
+}
+
+func convertFANETToGroundObject(msg *mqtt.FANETMessage) *models.GroundObject {
+	// Получаем данные для Ground tracking (Type 7)
+	groundData, ok := msg.Data.(*mqtt.GroundTrackingData)
+	if !ok {
+		return nil
+	}
+
+	return &models.GroundObject{
+		DeviceID: msg.DeviceID,
+		Address:  msg.DeviceID,
+		Type:     models.GroundType(groundData.GroundType),
+		Position: &models.GeoPoint{
+			Latitude:  groundData.Latitude,
+			Longitude: groundData.Longitude,
+			Altitude:  groundData.Altitude,
+		},
+		TrackOnline: groundData.TrackOnline,
+		LastUpdate:  msg.Timestamp,
+		LastSeen:    msg.Timestamp,
+	}
+}
+
+func convertFANETToThermal(msg *mqtt.FANETMessage) *models.Thermal {
+	// Получаем данные для Thermal (Type 9) 
+	thermalData, ok := msg.Data.(*mqtt.ThermalData)
+	if !ok {
+		return nil
+	}
+
+	return &models.Thermal{
+		ID:         fmt.Sprintf("%s_%d", msg.DeviceID, msg.Timestamp.Unix()),
+		ReportedBy: msg.DeviceID,
+		Position: &models.GeoPoint{
+			Latitude:  thermalData.Latitude,
+			Longitude: thermalData.Longitude,
+			Altitude:  thermalData.Altitude,
+		},
+		Quality:       int32(thermalData.Strength / 20), // Конвертируем 0-100 в 0-5
+		ClimbRate:     thermalData.ClimbRate,
+		WindSpeed:     0,  // Нет в FANET Thermal
+		WindDirection: 0,  // Нет в FANET Thermal
+		Timestamp:     msg.Timestamp,
+		Source:        models.ThermalSourceFANET,
+	}
+}
+
+func convertFANETToStation(msg *mqtt.FANETMessage) *models.Station {
+	// Получаем данные для Weather service (Type 4)
+	serviceData, ok := msg.Data.(*mqtt.ServiceData)
+	if !ok {
+		return nil
+	}
+
+	// Создаем базовую станцию с координатами из ServiceData
+	station := &models.Station{
+		ID:   msg.DeviceID,
+		Name: "", // Имя приходит в отдельном сообщении Type 2
+		Position: &models.GeoPoint{
+			Latitude:  serviceData.Latitude,  // Координаты станции из FANET Type 4
+			Longitude: serviceData.Longitude, // Координаты станции из FANET Type 4
+		},
+		LastUpdate: msg.Timestamp,
+	}
+
+	// Если есть погодные данные, добавляем их
+	if weatherData, ok := serviceData.Data.(*mqtt.WeatherData); ok {
+		station.Temperature = int8(weatherData.Temperature)
+		station.WindSpeed = uint8(weatherData.WindSpeed)
+		station.WindDirection = weatherData.WindDirection
+		station.WindGusts = uint8(weatherData.WindGusts)
+		station.Humidity = weatherData.Humidity
+		station.Pressure = uint16(weatherData.Pressure)
+		station.Battery = weatherData.Battery
+	}
+
+	return station
+}
+
+// NameUpdate структура для обновления имени пилота
+type NameUpdate struct {
+	DeviceID string
+	Name     string
+}
+
+func convertFANETToNameUpdate(msg *mqtt.FANETMessage) *NameUpdate {
+	// Получаем данные для Name (Type 2)
+	nameData, ok := msg.Data.(*mqtt.NameData)
+	if !ok {
+		return nil
+	}
+
+	return &NameUpdate{
+		DeviceID: msg.DeviceID,
+		Name:     nameData.Name,
+	}
+}
+
+func convertFANETToMessage(msg *mqtt.FANETMessage) *models.Message {
+	// Получаем данные для Message (Type 3)
+	messageData, ok := msg.Data.(*mqtt.MessageData)
+	if !ok || messageData.Text == "" {
+		return nil
+	}
+
+	// Время в пакете назначает принявшая станция, поэтому ID строится по исходным данным
+	payload := msg.RawPayload
+	if len(payload) == 0 {
+		payload = []byte(messageData.Text) // Сообщение не из MQTT
+	}
+
+	return &models.Message{
+		ID:        models.GenerateMessageID(msg.DeviceID, payload),
+		From:      msg.DeviceID,
+		To:        messageData.Destination, // Пусто для broadcast
+		Subheader: messageData.Subheader,
+		Text:      messageData.Text,
+		Timestamp: msg.Timestamp,
+	}
+}
+
+func convertFANETToLandmark(msg *mqtt.FANETMessage) *models.Landmark {
+	// Получаем данные для Landmark (Type 5)
+	landmarkData, ok := msg.Data.(*mqtt.LandmarkData)
+	if !ok || len(landmarkData.Points) == 0 {
+		return nil
+	}
+
+	points := make([]models.GeoPoint, len(landmarkData.Points))
+	for i, point := range landmarkData.Points {
+		points[i] = models.GeoPoint{
+			Latitude:  point.Latitude,
+			Longitude: point.Longitude,
+		}
+	}
+
+	var radii []float64
+	for _, radius := range landmarkData.Radii {
+		radii = append(radii, float64(radius))
+	}
+
+	landmarkType := models.LandmarkType(landmarkData.Subtype)
+	layer := models.LandmarkLayer(landmarkData.Layer)
+
+	return &models.Landmark{
+		ID:          models.GenerateLandmarkID(msg.DeviceID, landmarkType, layer, points, landmarkData.Text),
+		ReportedBy:  msg.DeviceID,
+		Type:        landmarkType,
+		Layer:       layer,
+		Points:      points,
+		Radii:       radii,
+		Text:        landmarkData.Text,
+		WindSectors: landmarkData.WindSectors,
+		Timestamp:   msg.Timestamp,
+		ExpiresAt:   msg.Timestamp.Add(time.Duration(landmarkData.TTLMinutes) * time.Minute),
+	}
+}
+
+// Конвертеры для Protobuf
+
+func convertPilotToProtobuf(pilot *models.Pilot) *pb.Pilot {
+	addr, _ := models.DeviceAddr(pilot.DeviceID)
+
+	result := &pb.Pilot{
+		Addr: uint32(addr),
+		Name: pilot.Name,
+		Type: pb.PilotType(pilot.Type),
+		Position: &pb.GeoPoint{
+			Latitude:  pilot.Position.Latitude,
+			Longitude: pilot.Position.Longitude,
+			Altitude:  pilot.Position.Altitude,
+		},
+		Speed:      float32(pilot.Speed),
+		Climb:      float32(pilot.ClimbRate) / 10.0, // Конвертируем обратно в м/с
+		Course:     float32(pilot.Heading),
+		LastUpdate: pilot.LastUpdate.Unix(),
+		TrackOnline: pilot.TrackOnline,
+		Battery:    uint32(pilot.Battery),
+	}
+	if pilot.Reception != nil {
+		result.Receivers = uint32(pilot.Reception.Stations)
+		result.BestSnr = int32(pilot.Reception.BestSNR)
+	}
+	if pilot.Smoothed != nil {
+		result.Smoothed = pilot.Smoothed.ToProto()
+	}
+	return result
+}
+
+
+func convertThermalToProtobuf(thermal *models.Thermal) *pb.Thermal {
+	return &pb.Thermal{
+		Id:   0, // TODO: конвертировать ID в uint64
+		Addr: 0, // TODO: конвертировать ReportedBy в uint32
+		Position: &pb.GeoPoint{
+			Latitude:  thermal.Position.Latitude,
+			Longitude: thermal.Position.Longitude,
+			Altitude:  thermal.Position.Altitude,
+		},
+		Quality:     uint32(thermal.Quality),
+		Climb:       float32(thermal.ClimbRate),
+		WindSpeed:   float32(thermal.WindSpeed),
+		WindHeading: float32(thermal.WindDirection),
+		Timestamp:   thermal.Timestamp.Unix(),
+		Source:      thermal.Source,
+		Confidence:  thermal.Confidence,
+	}
+}
+
+func convertStationToProtobuf(station *models.Station) *pb.Station {
+	return &pb.Station{
+		Addr: 0, // TODO: конвертировать ID в uint32
+		Name: station.Name,
+		Position: &pb.GeoPoint{
+			Latitude:  station.Position.Latitude,
+			Longitude: station.Position.Longitude,
+		},
+		Temperature: float32(station.Temperature),
+		WindSpeed:   float32(station.WindSpeed),
+		WindHeading: float32(station.WindDirection),
+		WindGusts:   float32(station.WindGusts),
+		Humidity:    uint32(station.Humidity),
+		Pressure:    float32(station.Pressure),
+		Battery:     uint32(station.Battery),
+		LastUpdate:  station.LastUpdate.Unix(),
+	}
+}
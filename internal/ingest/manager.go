@@ -0,0 +1,88 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// Manager запускает источники данных и направляет их события в общий обработчик
+type Manager struct {
+	sources []Source
+	handler mqtt.MessageHandler
+	logger  *utils.Logger
+	wg      sync.WaitGroup
+}
+
+// NewManager создает менеджер источников с общим обработчиком сообщений
+func NewManager(handler mqtt.MessageHandler, logger *utils.Logger) *Manager {
+	return &Manager{
+		handler: handler,
+		logger:  logger,
+	}
+}
+
+// Add регистрирует источник. Вызывается до Start
+func (m *Manager) Add(source Source) {
+	m.sources = append(m.sources, source)
+}
+
+// Sources возвращает имена зарегистрированных источников
+func (m *Manager) Sources() []string {
+	names := make([]string, 0, len(m.sources))
+	for _, source := range m.sources {
+		names = append(names, source.Name())
+	}
+	return names
+}
+
+// Start запускает каждый источник в отдельной горутине. Источники
+// останавливаются при отмене контекста, дождаться их можно через Wait
+func (m *Manager) Start(ctx context.Context) {
+	for _, source := range m.sources {
+		source := source
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+
+			m.logger.WithField("source", source.Name()).Info("Starting ingest source")
+			err := source.Run(ctx, m.instrument(source.Name()))
+			metrics.IngestSourceConnected.WithLabelValues(source.Name()).Set(0)
+
+			if err != nil && !errors.Is(err, context.Canceled) {
+				m.logger.WithFields(map[string]interface{}{
+					"source": source.Name(),
+					"error":  err,
+				}).Error("Ingest source stopped with error")
+				return
+			}
+			m.logger.WithField("source", source.Name()).Info("Ingest source stopped")
+		}()
+	}
+}
+
+// Wait ожидает остановки всех источников
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// instrument оборачивает обработчик метриками и меткой источника
+func (m *Manager) instrument(name string) mqtt.MessageHandler {
+	return func(msg *mqtt.FANETMessage) error {
+		if msg.Source == "" {
+			msg.Source = name
+		}
+
+		if err := m.handler(msg); err != nil {
+			metrics.IngestEvents.WithLabelValues(name, "handler_error").Inc()
+			return err
+		}
+
+		metrics.IngestEvents.WithLabelValues(name, "processed").Inc()
+		return nil
+	}
+}
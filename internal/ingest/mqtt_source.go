@@ -0,0 +1,113 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// MQTTSourceName имя источника FANET пакетов от базовых станций
+const MQTTSourceName = "mqtt"
+
+// MQTTSource адаптер MQTT клиента к интерфейсу Source. Клиент также
+// используется для отправки downlink пакетов (PublishMessage)
+type MQTTSource struct {
+	client  *mqtt.Client
+	config  *config.MQTTConfig
+	logger  *utils.Logger
+	handler mqtt.MessageHandler
+	mu      sync.RWMutex
+}
+
+// NewMQTTSource создает MQTT источник. Подключение выполняется в Run
+func NewMQTTSource(cfg *config.MQTTConfig, logger *utils.Logger) (*MQTTSource, error) {
+	s := &MQTTSource{
+		config: cfg,
+		logger: logger,
+	}
+
+	client, err := mqtt.NewClient(cfg, logger, s.dispatch)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+
+	return s, nil
+}
+
+// Name возвращает имя источника
+func (s *MQTTSource) Name() string {
+	return MQTTSourceName
+}
+
+// Run подключается к брокеру и получает сообщения до отмены контекста.
+// Ошибка первого подключения не фатальна: paho продолжает попытки в фоне
+func (s *MQTTSource) Run(ctx context.Context, handler mqtt.MessageHandler) error {
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+
+	s.logger.WithField("broker", s.config.URL).Info("Connecting to MQTT broker")
+	if err := s.client.Connect(); err != nil {
+		s.logger.WithField("error", err).Error("Failed to connect to MQTT broker")
+	}
+
+	// Статус подключения обновляется периодически, переподключением управляет paho
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		s.updateConnectionStatus()
+
+		select {
+		case <-ctx.Done():
+			s.client.Disconnect()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateConnectionStatus обновляет метрику подключения источника
+func (s *MQTTSource) updateConnectionStatus() {
+	if s.client.IsConnected() {
+		metrics.IngestSourceConnected.WithLabelValues(MQTTSourceName).Set(1)
+	} else {
+		metrics.IngestSourceConnected.WithLabelValues(MQTTSourceName).Set(0)
+	}
+}
+
+// PublishMessage публикует сообщение через MQTT клиент (downlink)
+func (s *MQTTSource) PublishMessage(topic string, payload []byte, qos byte, retained bool) error {
+	return s.client.PublishMessage(topic, payload, qos, retained)
+}
+
+// SetRecorder включает запись сырого MQTT трафика
+func (s *MQTTSource) SetRecorder(recorder mqtt.Recorder) {
+	s.client.SetRecorder(recorder)
+}
+
+// IsConnected проверяет подключение к брокеру
+func (s *MQTTSource) IsConnected() bool {
+	return s.client.IsConnected()
+}
+
+// dispatch передает сообщение текущему обработчику
+func (s *MQTTSource) dispatch(msg *mqtt.FANETMessage) error {
+	s.mu.RLock()
+	handler := s.handler
+	s.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("mqtt source is not running")
+	}
+
+	msg.Source = MQTTSourceName
+	return handler(msg)
+}
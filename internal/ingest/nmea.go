@@ -0,0 +1,394 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// NMEASourceName имя источника NMEA/PFLAA
+const NMEASourceName = "nmea"
+
+// metersPerDegree длина градуса широты в метрах
+const metersPerDegree = 111320.0
+
+// NMEASource читает FLARM NMEA поток (PFLAA) из файла, последовательного порта
+// или TCP соединения. Порт должен быть предварительно настроен (скорость, режим)
+type NMEASource struct {
+	source            string
+	reconnectInterval time.Duration
+	logger            *utils.Logger
+	now               func() time.Time
+}
+
+// NewNMEASource создает источник NMEA. Адрес вида tcp://host:port читается
+// по сети с переподключением, остальные значения считаются путем к файлу
+func NewNMEASource(cfg *config.IngestConfig, logger *utils.Logger) *NMEASource {
+	return &NMEASource{
+		source:            cfg.NMEASource,
+		reconnectInterval: cfg.ReconnectInterval,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+// Name возвращает имя источника
+func (s *NMEASource) Name() string {
+	return NMEASourceName
+}
+
+// Run читает поток до отмены контекста. Файл читается один раз до конца
+func (s *NMEASource) Run(ctx context.Context, handler mqtt.MessageHandler) error {
+	if address, ok := strings.CutPrefix(s.source, "tcp://"); ok {
+		return s.runTCP(ctx, address, handler)
+	}
+
+	file, err := os.Open(s.source)
+	if err != nil {
+		return fmt.Errorf("open NMEA source: %w", err)
+	}
+	defer file.Close()
+
+	metrics.IngestSourceConnected.WithLabelValues(NMEASourceName).Set(1)
+	s.logger.WithField("path", s.source).Info("Reading NMEA source")
+
+	return s.read(ctx, file, handler)
+}
+
+// runTCP читает NMEA поток по TCP с переподключением
+func (s *NMEASource) runTCP(ctx context.Context, address string, handler mqtt.MessageHandler) error {
+	for {
+		err := s.readTCP(ctx, address, handler)
+		metrics.IngestSourceConnected.WithLabelValues(NMEASourceName).Set(0)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		s.logger.WithFields(map[string]interface{}{
+			"address": address,
+			"error":   err,
+			"retry":   s.reconnectInterval,
+		}).Warn("NMEA connection lost, reconnecting")
+		metrics.IngestReconnects.WithLabelValues(NMEASourceName).Inc()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.reconnectInterval):
+		}
+	}
+}
+
+// readTCP обслуживает одно TCP подключение
+func (s *NMEASource) readTCP(ctx context.Context, address string, handler mqtt.MessageHandler) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	metrics.IngestSourceConnected.WithLabelValues(NMEASourceName).Set(1)
+	s.logger.WithField("address", address).Info("Connected to NMEA stream")
+
+	if err := s.read(ctx, conn, handler); err != nil {
+		return err
+	}
+	return fmt.Errorf("connection closed by peer")
+}
+
+// read разбирает NMEA предложения из потока до его окончания или отмены контекста
+func (s *NMEASource) read(ctx context.Context, r io.Reader, handler mqtt.MessageHandler) error {
+	// Закрываем источник при отмене контекста, чтобы прервать блокирующее чтение
+	if closer, ok := r.(io.Closer); ok {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				closer.Close()
+			case <-done:
+			}
+		}()
+	}
+
+	decoder := NewNMEADecoder(s.now)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		position, err := decoder.Decode(scanner.Text())
+		if err != nil {
+			metrics.IngestEvents.WithLabelValues(NMEASourceName, "parse_error").Inc()
+			s.logger.WithFields(map[string]interface{}{
+				"sentence": scanner.Text(),
+				"error":    err,
+			}).Debug("Failed to parse NMEA sentence")
+			continue
+		}
+		if position == nil {
+			continue
+		}
+
+		if err := handler(position.ToFANET(NMEASourceName)); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"device_id": position.DeviceID,
+				"error":     err,
+			}).Debug("Failed to handle NMEA position")
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// NMEADecoder разбирает NMEA предложения FLARM. Собственная позиция берется
+// из RMC/GGA, позиции окружающих ВС вычисляются из относительных PFLAA
+type NMEADecoder struct {
+	now func() time.Time
+
+	hasFix    bool
+	ownLat    float64
+	ownLon    float64
+	ownAlt    float64
+	fixTime   time.Time
+	hasAltFix bool
+}
+
+// NewNMEADecoder создает декодер. now используется, если в потоке нет даты
+func NewNMEADecoder(now func() time.Time) *NMEADecoder {
+	if now == nil {
+		now = time.Now
+	}
+	return &NMEADecoder{now: now}
+}
+
+// Decode разбирает одно предложение. Возвращает позицию для PFLAA,
+// nil для остальных предложений и для PFLAA до получения собственной позиции
+func (d *NMEADecoder) Decode(sentence string) (*Position, error) {
+	sentence = strings.TrimSpace(sentence)
+	if sentence == "" || sentence[0] != '$' {
+		return nil, nil
+	}
+
+	body := sentence[1:]
+	if idx := strings.IndexByte(body, '*'); idx >= 0 {
+		if err := verifyNMEAChecksum(body[:idx], body[idx+1:]); err != nil {
+			return nil, err
+		}
+		body = body[:idx]
+	}
+
+	fields := strings.Split(body, ",")
+	if len(fields[0]) < 3 {
+		return nil, nil
+	}
+
+	switch {
+	case fields[0] == "PFLAA":
+		return d.decodePFLAA(fields)
+	case len(fields[0]) == 5 && strings.HasSuffix(fields[0], "RMC"):
+		return nil, d.decodeRMC(fields)
+	case len(fields[0]) == 5 && strings.HasSuffix(fields[0], "GGA"):
+		return nil, d.decodeGGA(fields)
+	}
+
+	return nil, nil
+}
+
+// decodeRMC обновляет собственную позицию и время:
+// $GPRMC,hhmmss.ss,A,llll.ll,a,yyyyy.yy,a,speed,course,ddmmyy,...
+func (d *NMEADecoder) decodeRMC(fields []string) error {
+	if len(fields) < 10 {
+		return fmt.Errorf("RMC: expected at least 10 fields, got %d", len(fields))
+	}
+	if fields[2] != "A" {
+		d.hasFix = false
+		return nil
+	}
+
+	lat, lon, err := parseNMEAPosition(fields[3], fields[4], fields[5], fields[6])
+	if err != nil {
+		return fmt.Errorf("RMC: %w", err)
+	}
+	d.ownLat, d.ownLon, d.hasFix = lat, lon, true
+
+	if ts, err := time.Parse("020106 150405", fields[9]+" "+truncateNMEATime(fields[1])); err == nil {
+		d.fixTime = ts
+	}
+	return nil
+}
+
+// decodeGGA обновляет собственную позицию и высоту (MSL):
+// $GPGGA,hhmmss.ss,llll.ll,a,yyyyy.yy,a,quality,sats,hdop,alt,M,...
+func (d *NMEADecoder) decodeGGA(fields []string) error {
+	if len(fields) < 10 {
+		return fmt.Errorf("GGA: expected at least 10 fields, got %d", len(fields))
+	}
+	if fields[6] == "" || fields[6] == "0" {
+		d.hasFix = false
+		return nil
+	}
+
+	lat, lon, err := parseNMEAPosition(fields[2], fields[3], fields[4], fields[5])
+	if err != nil {
+		return fmt.Errorf("GGA: %w", err)
+	}
+	d.ownLat, d.ownLon, d.hasFix = lat, lon, true
+
+	if alt, err := strconv.ParseFloat(fields[9], 64); err == nil {
+		d.ownAlt, d.hasAltFix = alt, true
+	}
+	return nil
+}
+
+// decodePFLAA вычисляет позицию соседнего ВС:
+// $PFLAA,alarm,relN,relE,relV,idType,id,track,turnRate,groundSpeed,climbRate,acftType[,noTrack,...]
+func (d *NMEADecoder) decodePFLAA(fields []string) (*Position, error) {
+	if len(fields) < 12 {
+		return nil, fmt.Errorf("PFLAA: expected at least 12 fields, got %d", len(fields))
+	}
+	if !d.hasFix {
+		return nil, nil
+	}
+	// Флаг no-track: владелец запретил отслеживание
+	if len(fields) > 12 && fields[12] == "1" {
+		return nil, nil
+	}
+
+	north, errN := strconv.ParseFloat(fields[2], 64)
+	east, errE := strconv.ParseFloat(fields[3], 64)
+	if errN != nil || errE != nil {
+		return nil, fmt.Errorf("PFLAA: invalid relative position")
+	}
+
+	addr, err := strconv.ParseUint(fields[6], 16, 24)
+	if err != nil || len(fields[6]) != 6 {
+		return nil, fmt.Errorf("PFLAA: invalid id %q", fields[6])
+	}
+	// Пустой ID-Type считается случайным адресом
+	idType, _ := strconv.ParseUint(fields[5], 10, 8)
+
+	flarmType, err := strconv.ParseUint(fields[11], 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("PFLAA: invalid aircraft type %q", fields[11])
+	}
+	aircraftType, visible := FlarmAircraftType(uint8(flarmType))
+	if !visible {
+		return nil, nil
+	}
+
+	lat := d.ownLat + north/metersPerDegree
+	lon := d.ownLon + east/(metersPerDegree*math.Cos(d.ownLat*math.Pi/180))
+
+	pos := &Position{
+		DeviceID:       models.NamespacedDeviceID(FlarmAddressPrefix(uint8(idType)), uint32(addr)),
+		AircraftType:   aircraftType,
+		Latitude:       lat,
+		Longitude:      lon,
+		OnlineTracking: true,
+		Timestamp:      d.fixTime,
+	}
+	if pos.Timestamp.IsZero() {
+		pos.Timestamp = d.now()
+	}
+
+	// Относительная высота доступна только вместе с собственной высотой
+	if vertical, err := strconv.ParseFloat(fields[4], 64); err == nil && d.hasAltFix {
+		pos.Altitude = int32(math.Round(d.ownAlt + vertical))
+	}
+	if track, err := strconv.ParseFloat(fields[7], 64); err == nil {
+		pos.Heading = track
+	}
+	if speed, err := strconv.ParseFloat(fields[9], 64); err == nil {
+		pos.Speed = speed * 3.6
+	}
+	if climb, err := strconv.ParseFloat(fields[10], 64); err == nil {
+		pos.ClimbRate = climb
+	}
+
+	return pos, nil
+}
+
+// verifyNMEAChecksum проверяет XOR контрольную сумму предложения
+func verifyNMEAChecksum(body, checksum string) error {
+	expected, err := strconv.ParseUint(strings.TrimSpace(checksum), 16, 8)
+	if err != nil {
+		return fmt.Errorf("invalid checksum %q", checksum)
+	}
+
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	if sum != byte(expected) {
+		return fmt.Errorf("checksum mismatch: got %02X, expected %02X", sum, expected)
+	}
+	return nil
+}
+
+// parseNMEAPosition разбирает координаты ddmm.mmmm/dddmm.mmmm с полушариями
+func parseNMEAPosition(latValue, latHemisphere, lonValue, lonHemisphere string) (float64, float64, error) {
+	lat, err := parseNMEACoordinate(latValue, 2)
+	if err != nil {
+		return 0, 0, fmt.Errorf("latitude: %w", err)
+	}
+	lon, err := parseNMEACoordinate(lonValue, 3)
+	if err != nil {
+		return 0, 0, fmt.Errorf("longitude: %w", err)
+	}
+
+	if latHemisphere == "S" {
+		lat = -lat
+	}
+	if lonHemisphere == "W" {
+		lon = -lon
+	}
+	if !validPosition(lat, lon) {
+		return 0, 0, fmt.Errorf("invalid coordinates: %f, %f", lat, lon)
+	}
+	return lat, lon, nil
+}
+
+// parseNMEACoordinate разбирает координату в формате градусы+минуты
+func parseNMEACoordinate(value string, degreeDigits int) (float64, error) {
+	if len(value) <= degreeDigits {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return 0, fmt.Errorf("invalid degrees in %q", value)
+	}
+	minutes, err := strconv.ParseFloat(value[degreeDigits:], 64)
+	if err != nil || minutes >= 60 {
+		return 0, fmt.Errorf("invalid minutes in %q", value)
+	}
+	return float64(degrees) + minutes/60, nil
+}
+
+// truncateNMEATime отбрасывает доли секунды в hhmmss.ss
+func truncateNMEATime(value string) string {
+	if idx := strings.IndexByte(value, '.'); idx >= 0 {
+		return value[:idx]
+	}
+	return value
+}
@@ -0,0 +1,140 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nmeaSentence добавляет к телу предложения '$' и контрольную сумму
+func nmeaSentence(body string) string {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X", body, sum)
+}
+
+func TestNMEADecoder(t *testing.T) {
+	decoder := NewNMEADecoder(nil)
+
+	t.Run("traffic before own fix is ignored", func(t *testing.T) {
+		pos, err := decoder.Decode(nmeaSentence("PFLAA,0,1000,0,100,2,DDA5BA,90,,20,1.5,1"))
+		require.NoError(t, err)
+		assert.Nil(t, pos)
+	})
+
+	pos, err := decoder.Decode(nmeaSentence("GPRMC,120000.00,A,4600.000,N,01400.000,E,0.0,0.0,140724,,,A"))
+	require.NoError(t, err)
+	assert.Nil(t, pos)
+
+	_, err = decoder.Decode(nmeaSentence("GPGGA,120000.00,4600.000,N,01400.000,E,1,08,1.0,500.0,M,45.0,M,,"))
+	require.NoError(t, err)
+
+	pos, err = decoder.Decode(nmeaSentence("PFLAA,0,1000,-500,150,2,dda5ba,90,,20,1.5,7"))
+	require.NoError(t, err)
+	require.NotNil(t, pos)
+
+	assert.Equal(t, "FLRDDA5BA", pos.DeviceID)
+	assert.Equal(t, models.PilotTypeParaglider, pos.AircraftType)
+	assert.InDelta(t, 46.0+1000/metersPerDegree, pos.Latitude, 1e-6)
+	assert.Less(t, pos.Longitude, 14.0)
+	assert.InDelta(t, 14.0-0.0065, pos.Longitude, 0.0002)
+	assert.Equal(t, int32(650), pos.Altitude)
+	assert.InDelta(t, 90, pos.Heading, 0.01)
+	assert.InDelta(t, 72, pos.Speed, 0.01)
+	assert.InDelta(t, 1.5, pos.ClimbRate, 0.01)
+	assert.Equal(t, time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC), pos.Timestamp)
+
+	t.Run("no-track and static objects are skipped", func(t *testing.T) {
+		pos, err := decoder.Decode(nmeaSentence("PFLAA,0,100,100,0,2,DDA5BA,90,,20,0,7,1,1,-80"))
+		require.NoError(t, err)
+		assert.Nil(t, pos)
+
+		pos, err = decoder.Decode(nmeaSentence("PFLAA,0,100,100,0,2,DDA5BA,90,,0,0,F"))
+		require.NoError(t, err)
+		assert.Nil(t, pos)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := decoder.Decode("$PFLAA,0,1000,-500,150,2,DDA5BA,90,,20,1.5,7*00")
+		assert.Error(t, err, "checksum mismatch")
+
+		_, err = decoder.Decode(nmeaSentence("PFLAA,0,1000"))
+		assert.Error(t, err)
+
+		_, err = decoder.Decode(nmeaSentence("PFLAA,0,1000,-500,150,2,NOTHEX,90,,20,1.5,7"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unrelated sentences", func(t *testing.T) {
+		for _, sentence := range []string{"", "garbage", nmeaSentence("PFLAU,1,1,2,1,0,,0,,"), nmeaSentence("PGRMZ,1640,f,3")} {
+			pos, err := decoder.Decode(sentence)
+			assert.NoError(t, err, sentence)
+			assert.Nil(t, pos, sentence)
+		}
+	})
+
+	t.Run("lost fix", func(t *testing.T) {
+		_, err := decoder.Decode(nmeaSentence("GPRMC,120001.00,V,,,,,,,140724,,,N"))
+		require.NoError(t, err)
+
+		pos, err := decoder.Decode(nmeaSentence("PFLAA,0,1000,-500,150,2,DDA5BA,90,,20,1.5,7"))
+		require.NoError(t, err)
+		assert.Nil(t, pos)
+	})
+}
+
+func TestNMEASource_File(t *testing.T) {
+	lines := []string{
+		nmeaSentence("GPRMC,120000.00,A,4600.000,N,01400.000,E,0.0,0.0,140724,,,A"),
+		nmeaSentence("GPGGA,120000.00,4600.000,N,01400.000,E,1,08,1.0,500.0,M,45.0,M,,"),
+		nmeaSentence("PFLAA,0,1000,-500,150,2,DDA5BA,90,,20,1.5,7"),
+		"$PFLAA,broken*00",
+		nmeaSentence("PFLAA,0,-200,300,-50,1,4B0E3A,180,,30,-2.0,1"),
+	}
+
+	path := filepath.Join(t.TempDir(), "flarm.nmea")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644))
+
+	source := NewNMEASource(&config.IngestConfig{
+		NMEASource:        path,
+		ReconnectInterval: time.Second,
+	}, utils.NewLogger("debug", "text"))
+	assert.Equal(t, NMEASourceName, source.Name())
+
+	var received []*mqtt.FANETMessage
+	err := source.Run(context.Background(), func(msg *mqtt.FANETMessage) error {
+		received = append(received, msg)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+
+	assert.Equal(t, "FLRDDA5BA", received[0].DeviceID)
+	assert.Equal(t, NMEASourceName, received[0].Source)
+	assert.Equal(t, "ICA4B0E3A", received[1].DeviceID)
+
+	air, ok := received[1].Data.(*mqtt.AirTrackingData)
+	require.True(t, ok)
+	assert.Equal(t, uint8(models.PilotTypeGlider), air.AircraftType)
+	assert.Equal(t, int32(450), air.Altitude)
+	assert.Equal(t, uint16(108), air.Speed)
+	assert.Equal(t, int16(-20), air.ClimbRate)
+
+	t.Run("missing file", func(t *testing.T) {
+		source := NewNMEASource(&config.IngestConfig{NMEASource: filepath.Join(t.TempDir(), "missing")}, utils.NewLogger("info", "text"))
+		assert.Error(t, source.Run(context.Background(), func(*mqtt.FANETMessage) error { return nil }))
+	})
+}
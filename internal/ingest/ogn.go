@@ -0,0 +1,358 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// OGNSourceName имя источника OGN APRS-IS
+const OGNSourceName = "ogn"
+
+const (
+	ognDialTimeout       = 10 * time.Second
+	ognReadTimeout       = 2 * time.Minute // Сервер шлет keepalive каждые ~20 секунд
+	ognKeepaliveInterval = 4 * time.Minute
+	ognSoftwareVersion   = "1.0"
+)
+
+// OGNSource получает позиции воздушных судов из OGN через APRS-IS
+type OGNSource struct {
+	server            string
+	callsign          string
+	filter            string
+	reconnectInterval time.Duration
+	logger            *utils.Logger
+	now               func() time.Time
+}
+
+// NewOGNSource создает источник OGN APRS-IS
+func NewOGNSource(cfg *config.IngestConfig, logger *utils.Logger) *OGNSource {
+	return &OGNSource{
+		server:            cfg.OGNServer,
+		callsign:          cfg.OGNCallsign,
+		filter:            cfg.OGNFilter,
+		reconnectInterval: cfg.ReconnectInterval,
+		logger:            logger,
+		now:               time.Now,
+	}
+}
+
+// Name возвращает имя источника
+func (s *OGNSource) Name() string {
+	return OGNSourceName
+}
+
+// Run подключается к APRS-IS серверу и переподключается при обрывах до отмены контекста
+func (s *OGNSource) Run(ctx context.Context, handler mqtt.MessageHandler) error {
+	for {
+		err := s.session(ctx, handler)
+		metrics.IngestSourceConnected.WithLabelValues(OGNSourceName).Set(0)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		s.logger.WithFields(map[string]interface{}{
+			"server": s.server,
+			"error":  err,
+			"retry":  s.reconnectInterval,
+		}).Warn("OGN APRS-IS connection lost, reconnecting")
+		metrics.IngestReconnects.WithLabelValues(OGNSourceName).Inc()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.reconnectInterval):
+		}
+	}
+}
+
+// session обслуживает одно подключение к APRS-IS серверу
+func (s *OGNSource) session(ctx context.Context, handler mqtt.MessageHandler) error {
+	dialer := net.Dialer{Timeout: ognDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.server)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", s.server, err)
+	}
+	defer conn.Close()
+
+	// Закрываем соединение при отмене контекста, чтобы прервать чтение
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	login := fmt.Sprintf("user %s pass -1 vers fanet-backend %s", s.callsign, ognSoftwareVersion)
+	if s.filter != "" {
+		login += " filter " + s.filter
+	}
+	if _, err := fmt.Fprintf(conn, "%s\r\n", login); err != nil {
+		return fmt.Errorf("send login: %w", err)
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"server": s.server,
+		"filter": s.filter,
+	}).Info("Connected to OGN APRS-IS")
+	metrics.IngestSourceConnected.WithLabelValues(OGNSourceName).Set(1)
+
+	go s.keepalive(conn, done)
+
+	scanner := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(ognReadTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("connection closed by server")
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// Комментарии сервера: баннер, logresp, keepalive
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "# logresp") {
+				s.logger.WithField("response", line).Info("OGN APRS-IS login response")
+			}
+			continue
+		}
+
+		position, err := ParseAPRS(line, s.now())
+		if err != nil {
+			metrics.IngestEvents.WithLabelValues(OGNSourceName, "parse_error").Inc()
+			s.logger.WithFields(map[string]interface{}{
+				"line":  line,
+				"error": err,
+			}).Debug("Failed to parse OGN APRS message")
+			continue
+		}
+		if position == nil {
+			metrics.IngestEvents.WithLabelValues(OGNSourceName, "skipped").Inc()
+			continue
+		}
+
+		if err := handler(position.ToFANET(OGNSourceName)); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"device_id": position.DeviceID,
+				"error":     err,
+			}).Debug("Failed to handle OGN position")
+		}
+	}
+}
+
+// keepalive периодически отправляет комментарий, чтобы сервер не закрыл соединение
+func (s *OGNSource) keepalive(conn net.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(ognKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprintf(conn, "#keepalive\r\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ParseAPRS разбирает APRS сообщение о позиции в формате OGN, например:
+//
+//	FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W52! id0ADDA5BA -454fpm -1.1rot
+//
+// Возвращает nil без ошибки для сообщений, которые не являются позицией
+// воздушного судна (статусы, приемники, объекты с флагом no-track)
+func ParseAPRS(line string, now time.Time) (*Position, error) {
+	header, body, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil, fmt.Errorf("missing APRS body")
+	}
+	if body == "" || (body[0] != '/' && body[0] != '@') {
+		return nil, nil
+	}
+
+	callsign, path, ok := strings.Cut(header, ">")
+	if !ok {
+		return nil, fmt.Errorf("invalid APRS header: %q", header)
+	}
+	pathParts := strings.Split(path, ",")
+	receiver := pathParts[len(pathParts)-1]
+
+	// Время (7) + широта (8) + таблица символов (1) + долгота (9) + символ (1)
+	body = body[1:]
+	if len(body) < 26 {
+		return nil, fmt.Errorf("position report too short")
+	}
+
+	timestamp, err := parseAPRSTime(body[0:7], now)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, err := parseAPRSCoordinate(body[7:15], 2, 'N', 'S')
+	if err != nil {
+		return nil, fmt.Errorf("latitude: %w", err)
+	}
+	lon, err := parseAPRSCoordinate(body[16:25], 3, 'E', 'W')
+	if err != nil {
+		return nil, fmt.Errorf("longitude: %w", err)
+	}
+
+	pos := &Position{
+		ChipID:         receiver,
+		Timestamp:      timestamp,
+		OnlineTracking: true,
+	}
+
+	rest := body[26:]
+
+	// Курс/скорость: "ccc/sss" (градусы, узлы)
+	if len(rest) >= 7 && rest[3] == '/' {
+		if course, err := strconv.Atoi(rest[0:3]); err == nil {
+			pos.Heading = float64(course % 360)
+		}
+		if speed, err := strconv.Atoi(rest[4:7]); err == nil {
+			pos.Speed = float64(speed) * 1.852
+		}
+		rest = rest[7:]
+	}
+
+	// Высота: "/A=nnnnnn" в футах
+	if idx := strings.Index(rest, "/A="); idx >= 0 && len(rest) >= idx+9 {
+		feet, err := strconv.Atoi(rest[idx+3 : idx+9])
+		if err != nil {
+			return nil, fmt.Errorf("invalid altitude: %w", err)
+		}
+		pos.Altitude = int32(math.Round(float64(feet) * 0.3048))
+		rest = rest[idx+9:]
+	}
+
+	var hasID bool
+	for _, field := range strings.Fields(rest) {
+		switch {
+		case len(field) == 5 && strings.HasPrefix(field, "!W") && strings.HasSuffix(field, "!"):
+			// Дополнительная точность: третий знак после запятой в минутах
+			if field[2] >= '0' && field[2] <= '9' && field[3] >= '0' && field[3] <= '9' {
+				latExtra := float64(field[2]-'0') / 1000 / 60
+				lonExtra := float64(field[3]-'0') / 1000 / 60
+				if lat < 0 {
+					latExtra = -latExtra
+				}
+				if lon < 0 {
+					lonExtra = -lonExtra
+				}
+				lat += latExtra
+				lon += lonExtra
+			}
+		case len(field) == 10 && strings.HasPrefix(field, "id"):
+			flags, err := strconv.ParseUint(field[2:4], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid id flags: %w", err)
+			}
+			// Bit 6: no-track, владелец запретил отслеживание
+			if flags&0x40 != 0 {
+				return nil, nil
+			}
+			aircraftType, visible := FlarmAircraftType(uint8(flags>>2) & 0x0F)
+			if !visible {
+				return nil, nil
+			}
+			addr, err := strconv.ParseUint(field[4:10], 16, 24)
+			if err != nil {
+				return nil, fmt.Errorf("invalid id address: %w", err)
+			}
+			pos.AircraftType = aircraftType
+			pos.DeviceID = models.NamespacedDeviceID(FlarmAddressPrefix(uint8(flags)&0x03), uint32(addr))
+			hasID = true
+		case strings.HasSuffix(field, "fpm"):
+			if fpm, err := strconv.ParseFloat(strings.TrimSuffix(field, "fpm"), 64); err == nil {
+				pos.ClimbRate = fpm * 0.00508
+			}
+		}
+	}
+
+	// Без поля id сообщение исходит от приемника или другого APRS объекта
+	if !hasID {
+		return nil, nil
+	}
+
+	pos.Latitude = lat
+	pos.Longitude = lon
+	if !validPosition(lat, lon) {
+		return nil, fmt.Errorf("invalid coordinates for %s: %f, %f", callsign, lat, lon)
+	}
+
+	return pos, nil
+}
+
+// parseAPRSTime разбирает время в формате HHMMSSh (UTC). Дата берется из now,
+// для времени в будущем (переход через полночь) используется предыдущий день
+func parseAPRSTime(value string, now time.Time) (time.Time, error) {
+	if value[6] != 'h' {
+		return now, nil
+	}
+
+	hour, errH := strconv.Atoi(value[0:2])
+	minute, errM := strconv.Atoi(value[2:4])
+	second, errS := strconv.Atoi(value[4:6])
+	if errH != nil || errM != nil || errS != nil || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, fmt.Errorf("invalid timestamp: %q", value)
+	}
+
+	now = now.UTC()
+	ts := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, second, 0, time.UTC)
+	if ts.Sub(now) > time.Hour {
+		ts = ts.AddDate(0, 0, -1)
+	}
+	return ts, nil
+}
+
+// parseAPRSCoordinate разбирает координату в формате DDMM.mm/DDDMM.mm с полушарием
+func parseAPRSCoordinate(value string, degreeDigits int, positive, negative byte) (float64, error) {
+	hemisphere := value[len(value)-1]
+	if hemisphere != positive && hemisphere != negative {
+		return 0, fmt.Errorf("invalid hemisphere in %q", value)
+	}
+
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return 0, fmt.Errorf("invalid degrees in %q", value)
+	}
+	minutes, err := strconv.ParseFloat(value[degreeDigits:len(value)-1], 64)
+	if err != nil || minutes >= 60 {
+		return 0, fmt.Errorf("invalid minutes in %q", value)
+	}
+
+	coord := float64(degrees) + minutes/60
+	if hemisphere == negative {
+		coord = -coord
+	}
+	return coord, nil
+}
+
+// validPosition проверяет диапазон координат
+func validPosition(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 && !(lat == 0 && lon == 0)
+}
@@ -0,0 +1,216 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ognNow = time.Date(2024, 7, 14, 17, 0, 0, 0, time.UTC)
+
+func TestParseAPRS(t *testing.T) {
+	pos, err := ParseAPRS("FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W52! id0ADDA5BA -454fpm -1.1rot", ognNow)
+	require.NoError(t, err)
+	require.NotNil(t, pos)
+
+	assert.Equal(t, "FLRDDA5BA", pos.DeviceID)
+	assert.Equal(t, "LFMX", pos.ChipID)
+	assert.Equal(t, models.PilotTypePowered, pos.AircraftType, "FLARM type 2 (tow plane)")
+	assert.InDelta(t, 44.0+15.415/60, pos.Latitude, 1e-6)
+	assert.InDelta(t, 6.0+0.032/60, pos.Longitude, 1e-6)
+	assert.Equal(t, int32(1684), pos.Altitude)
+	assert.InDelta(t, 342, pos.Heading, 0.01)
+	assert.InDelta(t, 49*1.852, pos.Speed, 0.01)
+	assert.InDelta(t, -2.31, pos.ClimbRate, 0.01)
+	assert.Equal(t, time.Date(2024, 7, 14, 16, 58, 29, 0, time.UTC), pos.Timestamp)
+	assert.True(t, pos.OnlineTracking)
+
+	t.Run("address type namespaces the device id", func(t *testing.T) {
+		// Тот же адрес DDA5BA у OGN трекера и случайного адреса не совпадает с FANET DDA5BA
+		for flags, expected := range map[string]string{"1F": "OGNDDA5BA", "1C": "RNDDDA5BA", "1D": "ICADDA5BA"} {
+			pos, err := ParseAPRS("OGNDDA5BA>OGNTRK,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 id"+flags+"DDA5BA", ognNow)
+			require.NoError(t, err)
+			require.NotNil(t, pos)
+			assert.Equal(t, expected, pos.DeviceID, flags)
+			assert.Equal(t, models.PilotTypeParaglider, pos.AircraftType, flags)
+		}
+	})
+
+	t.Run("southern and western hemispheres", func(t *testing.T) {
+		pos, err := ParseAPRS("ICA4B0E3A>OGFLR,qAS,Station:/165900h3315.00S\\07030.00W^180/010/A=003281 id1D4B0E3A +200fpm", ognNow)
+		require.NoError(t, err)
+		require.NotNil(t, pos)
+		assert.InDelta(t, -33.25, pos.Latitude, 1e-6)
+		assert.InDelta(t, -70.5, pos.Longitude, 1e-6)
+		assert.Equal(t, models.PilotTypeParaglider, pos.AircraftType)
+		assert.Equal(t, int32(1000), pos.Altitude)
+	})
+
+	t.Run("timestamp before midnight", func(t *testing.T) {
+		pos, err := ParseAPRS("FLRDDA5BA>APRS,qAS,LFMX:/235959h4415.41N/00600.03E'342/049/A=005524 id0ADDA5BA", time.Date(2024, 7, 15, 0, 0, 5, 0, time.UTC))
+		require.NoError(t, err)
+		require.NotNil(t, pos)
+		assert.Equal(t, time.Date(2024, 7, 14, 23, 59, 59, 0, time.UTC), pos.Timestamp)
+	})
+
+	t.Run("skipped messages", func(t *testing.T) {
+		lines := []string{
+			// Приемник без поля id
+			"LFMX>OGNSDR,TCPIP*,qAC,GLIDERN2:/165830h4415.41NI00600.03E&/A=001539",
+			// Статус приемника
+			"LFMX>OGNSDR,TCPIP*,qAC,GLIDERN2:>165830h v0.2.8.RPI-GPU CPU:0.5",
+			// Флаг no-track
+			"FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 id4ADDA5BA",
+			// Статический объект (тип 15)
+			"FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 id3EDDA5BA",
+		}
+		for _, line := range lines {
+			pos, err := ParseAPRS(line, ognNow)
+			assert.NoError(t, err, line)
+			assert.Nil(t, pos, line)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		lines := []string{
+			"garbage",
+			"FLRDDA5BA>APRS,qAS,LFMX:/165829h44",
+			"FLRDDA5BA>APRS,qAS,LFMX:/995829h4415.41N/00600.03E'342/049/A=005524 id0ADDA5BA",
+			"FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41X/00600.03E'342/049/A=005524 id0ADDA5BA",
+		}
+		for _, line := range lines {
+			_, err := ParseAPRS(line, ognNow)
+			assert.Error(t, err, line)
+		}
+	})
+}
+
+// fakeAPRSServer принимает одно подключение, проверяет логин и отправляет строки
+func fakeAPRSServer(t *testing.T, lines []string) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	logins := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				fmt.Fprintf(conn, "# aprsc 2.1.14-g408ed49\r\n")
+
+				login, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				logins <- strings.TrimSpace(login)
+
+				fmt.Fprintf(conn, "# logresp TEST unverified, server GLIDERN1\r\n")
+				for _, line := range lines {
+					fmt.Fprintf(conn, "%s\r\n", line)
+				}
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), logins
+}
+
+func TestOGNSource_FakeServer(t *testing.T) {
+	addr, logins := fakeAPRSServer(t, []string{
+		"# aprsc 2.1.14-g408ed49 14 Jul 2024 16:58:30 GMT GLIDERN1 127.0.0.1:14580",
+		"FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W52! id0ADDA5BA -454fpm -1.1rot",
+		"LFMX>OGNSDR,TCPIP*,qAC,GLIDERN2:/165830h4415.41NI00600.03E&/A=001539",
+		"FLRDDA5BA>APRS,qAS,LFMX:/165829h44",
+		"ICA4B0E3A>OGFLR,qAS,Station:/165900h4600.00N/01400.00E^090/020/A=003281 id1D4B0E3A +200fpm",
+	})
+
+	source := NewOGNSource(&config.IngestConfig{
+		OGNServer:         addr,
+		OGNCallsign:       "TEST",
+		OGNFilter:         "r/46.5000/14.2000/200",
+		ReconnectInterval: 50 * time.Millisecond,
+	}, utils.NewLogger("debug", "text"))
+	source.now = func() time.Time { return ognNow }
+
+	var mu sync.Mutex
+	var received []*mqtt.FANETMessage
+	gotAll := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- source.Run(ctx, func(msg *mqtt.FANETMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, msg)
+			if len(received) == 2 {
+				close(gotAll)
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case login := <-logins:
+		assert.Equal(t, "user TEST pass -1 vers fanet-backend "+ognSoftwareVersion+" filter r/46.5000/14.2000/200", login)
+	case <-time.After(2 * time.Second):
+		t.Fatal("source did not log in")
+	}
+
+	select {
+	case <-gotAll:
+	case <-time.After(2 * time.Second):
+		t.Fatal("positions were not delivered")
+	}
+
+	mu.Lock()
+	first, second := received[0], received[1]
+	mu.Unlock()
+
+	assert.Equal(t, uint8(1), first.Type)
+	assert.Equal(t, "FLRDDA5BA", first.DeviceID)
+	assert.Equal(t, OGNSourceName, first.Source)
+	assert.Equal(t, "LFMX", first.ChipID)
+	air, ok := first.Data.(*mqtt.AirTrackingData)
+	require.True(t, ok)
+	assert.Equal(t, uint8(models.PilotTypePowered), air.AircraftType)
+	assert.Equal(t, uint16(342), air.Heading)
+	assert.Equal(t, int16(-23), air.ClimbRate)
+
+	assert.Equal(t, "ICA4B0E3A", second.DeviceID)
+
+	// Сервер закрыл соединение после отправки строк: источник переподключается
+	select {
+	case <-logins:
+	case <-time.After(2 * time.Second):
+		t.Fatal("source did not reconnect")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("source did not stop")
+	}
+}
@@ -0,0 +1,756 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// ErrQueueFull возвращается, когда очередь обработчика не освободилась за EnqueueTimeout
+var ErrQueueFull = errors.New("ingest pipeline queue is full")
+
+// ErrPipelineStopped возвращается при отправке сообщения в остановленный конвейер
+var ErrPipelineStopped = errors.New("ingest pipeline is stopped")
+
+// Repository хранилище текущего состояния (реализуется repository.RedisRepository)
+type Repository interface {
+	GetPilot(ctx context.Context, deviceID string) (*models.Pilot, error)
+	SavePilot(ctx context.Context, pilot *models.Pilot) error
+	RemovePilot(ctx context.Context, deviceID string) error
+	UpdatePilotName(ctx context.Context, deviceID string, name string) error
+	SaveGroundObject(ctx context.Context, groundObject *models.GroundObject) error
+	GetGroundObject(ctx context.Context, deviceID string) (*models.GroundObject, error)
+	SaveThermal(ctx context.Context, thermal *models.Thermal) error
+	SaveMessage(ctx context.Context, message *models.Message) error
+	SaveStation(ctx context.Context, station *models.Station) error
+	SaveLandmark(ctx context.Context, landmark *models.Landmark) error
+}
+
+// Broadcaster рассылает обновления клиентам (реализуется handler.WebSocketHandler)
+type Broadcaster interface {
+	BroadcastUpdate(updateType pb.UpdateType, action pb.Action, data interface{})
+}
+
+// HistoryWriter асинхронно сохраняет историю (реализуется service.BatchWriter)
+type HistoryWriter interface {
+	QueuePilot(pilot *models.Pilot) error
+	QueueThermal(thermal *models.Thermal) error
+	QueueStation(station *models.Station) error
+	QueueGroundObject(groundObject *models.GroundObject) error
+}
+
+// TypeHandler обрабатывает сообщения одного FANET типа
+type TypeHandler func(ctx context.Context, msg *mqtt.FANETMessage) error
+
+// PipelineDeps зависимости конвейера. History, Alerts, Reception, Gateways, Thermals,
+// Flights и LiveFilter необязательны
+type PipelineDeps struct {
+	Repository  Repository
+	Broadcaster Broadcaster
+	History     HistoryWriter // nil, если MySQL недоступен
+	Validation  *service.ValidationService
+	Boundary    *service.BoundaryTracker
+	Alerts      *service.AlertService
+	Reception   *service.ReceptionTracker // Дедупликация копий пакета от нескольких станций
+	Gateways    *service.GatewayRegistry  // Реестр базовых станций
+	Thermals    *service.ThermalDetector  // Обнаружение термиков по кружению пилотов
+	Flights     *service.FlightTracker    // Определение взлетов и посадок
+	LiveFilter  *service.LiveFilter       // Потоковая фильтрация позиций перед Redis и WebSocket
+}
+
+// PipelineConfig параметры пула обработчиков
+type PipelineConfig struct {
+	Workers        int           // Количество обработчиков (PerformanceConfig.WorkerPoolSize)
+	QueueSize      int           // Размер очереди одного обработчика
+	EnqueueTimeout time.Duration // Ожидание места в очереди, 0 - ждать без ограничения
+}
+
+// Pipeline обрабатывает входящие FANET сообщения: валидация, сохранение
+// в Redis, постановка в очередь MySQL и рассылка по WebSocket.
+//
+// Сообщения распределяются по обработчикам по адресу устройства, поэтому
+// пакеты одного устройства обрабатываются в порядке вызовов Handle. MQTT клиент
+// вызывает Handle в порядке получения при MQTT_ORDER_MATTERS=true (по умолчанию),
+// иначе каждое сообщение передается из отдельной горутины. Когда очередь
+// обработчика заполнена, Handle блокирует источник до EnqueueTimeout
+// (backpressure), после чего сообщение отбрасывается
+type Pipeline struct {
+	deps     PipelineDeps
+	config   PipelineConfig
+	logger   *utils.Logger
+	handlers map[uint8]TypeHandler
+
+	queues  []chan job
+	depth   atomic.Int64
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	ctx     context.Context
+	started bool
+	stopped bool
+}
+
+// NewPipeline создает конвейер со стандартными обработчиками типов 1-5, 7, 9
+func NewPipeline(deps PipelineDeps, cfg PipelineConfig, logger *utils.Logger) *Pipeline {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1
+	}
+
+	p := &Pipeline{
+		deps:   deps,
+		config: cfg,
+		logger: logger,
+		ctx:    context.Background(),
+	}
+
+	p.handlers = map[uint8]TypeHandler{
+		1: p.handleAirTracking,
+		2: p.handleName,
+		3: p.handleMessage,
+		4: p.handleService,
+		5: p.handleLandmark,
+		7: p.handleGroundTracking,
+		9: p.handleThermal,
+	}
+
+	return p
+}
+
+// job - задание обработчика: входящее сообщение или выпуск позиции устройства,
+// отложенной потоковым фильтром
+type job struct {
+	msg     *mqtt.FANETMessage
+	release string
+}
+
+// RegisterHandler заменяет или добавляет обработчик FANET типа. Вызывается до Start
+func (p *Pipeline) RegisterHandler(msgType uint8, handler TypeHandler) {
+	p.handlers[msgType] = handler
+}
+
+// Start запускает обработчики. Отмена ctx прерывает ожидание места в очереди,
+// но не обработку уже принятых сообщений: их дорабатывает Stop
+func (p *Pipeline) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	p.ctx = ctx
+	workCtx := context.WithoutCancel(ctx)
+
+	p.queues = make([]chan job, p.config.Workers)
+	for i := range p.queues {
+		queue := make(chan job, p.config.QueueSize)
+		p.queues[i] = queue
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for j := range queue {
+				p.depth.Add(-1)
+				metrics.PipelineQueueDepth.Dec()
+				if j.msg != nil {
+					p.Process(workCtx, j.msg)
+				} else {
+					p.releaseDelayed(workCtx, j.release)
+				}
+			}
+		}()
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"workers":    p.config.Workers,
+		"queue_size": p.config.QueueSize,
+	}).Info("Ingest pipeline started")
+}
+
+// Stop прекращает прием сообщений и дожидается обработки уже принятых
+func (p *Pipeline) Stop() {
+	p.mu.Lock()
+	if !p.started || p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	p.logger.Info("Ingest pipeline stopped")
+}
+
+// Handle ставит сообщение в очередь обработчика (совместим с mqtt.MessageHandler).
+// Дубликаты пакета от других базовых станций отбрасываются до постановки в очередь
+func (p *Pipeline) Handle(msg *mqtt.FANETMessage) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.started || p.stopped {
+		return ErrPipelineStopped
+	}
+
+	// Каждая копия пакета учитывается в реестре принявшей ее станции. Реестр ведет
+	// только базовые станции FANET: приемники OGN и FLARM в него не попадают
+	if p.deps.Gateways != nil && msg.ChipID != "" && fanetGateway(msg.Source) {
+		p.deps.Gateways.Observe(service.GatewayObservation{
+			ChipID:   msg.ChipID,
+			DeviceID: msg.DeviceID,
+			Type:     msg.Type,
+			RSSI:     msg.RSSI,
+			SNR:      msg.SNR,
+			Position: transmitterPosition(msg),
+		})
+	}
+
+	// Копии пакета, принятые другими станциями, только учитываются в статистике приема
+	if p.deps.Reception != nil && !p.deps.Reception.Observe(msg.DeviceID, msg.Type, msg.RawPayload, models.GatewayReception{
+		ChipID: msg.ChipID,
+		RSSI:   msg.RSSI,
+		SNR:    msg.SNR,
+	}) {
+		return nil
+	}
+
+	queue := p.queues[p.shard(msg.DeviceID)]
+
+	// Быстрый путь: в очереди есть место
+	select {
+	case queue <- job{msg: msg}:
+		p.enqueued()
+		return nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if p.config.EnqueueTimeout > 0 {
+		timer := time.NewTimer(p.config.EnqueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case queue <- job{msg: msg}:
+		p.enqueued()
+		return nil
+	case <-timeout:
+	case <-p.ctx.Done():
+	}
+
+	metrics.PipelineMessages.WithLabelValues(strconv.Itoa(int(msg.Type)), "dropped").Inc()
+	p.logger.WithFields(map[string]interface{}{
+		"device_id":  msg.DeviceID,
+		"fanet_type": msg.Type,
+	}).Warn("Ingest pipeline queue is full, dropping message")
+	return ErrQueueFull
+}
+
+// QueueDepth возвращает количество сообщений в очередях
+func (p *Pipeline) QueueDepth() int {
+	return int(p.depth.Load())
+}
+
+// Process синхронно обрабатывает сообщение соответствующим обработчиком типа
+func (p *Pipeline) Process(ctx context.Context, msg *mqtt.FANETMessage) error {
+	typeLabel := strconv.Itoa(int(msg.Type))
+
+	handler, ok := p.handlers[msg.Type]
+	if !ok {
+		p.logger.WithField("fanet_type", msg.Type).Debug("Unhandled FANET message type")
+		return nil
+	}
+
+	start := time.Now()
+	err := handler(ctx, msg)
+	metrics.PipelineProcessingDuration.WithLabelValues(typeLabel).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		metrics.PipelineMessages.WithLabelValues(typeLabel, "error").Inc()
+		return err
+	}
+	metrics.PipelineMessages.WithLabelValues(typeLabel, "processed").Inc()
+	return nil
+}
+
+// transmitterPosition возвращает координаты передавшего пакет устройства или nil.
+// Координаты термика (Type 9) относятся к термику, а не к передатчику
+func transmitterPosition(msg *mqtt.FANETMessage) *models.GeoPoint {
+	var lat, lon float64
+	switch data := msg.Data.(type) {
+	case *mqtt.AirTrackingData:
+		lat, lon = data.Latitude, data.Longitude
+	case *mqtt.GroundTrackingData:
+		lat, lon = data.Latitude, data.Longitude
+	case *mqtt.ServiceData:
+		lat, lon = data.Latitude, data.Longitude
+	default:
+		return nil
+	}
+
+	if lat == 0 && lon == 0 {
+		return nil
+	}
+	return &models.GeoPoint{Latitude: lat, Longitude: lon}
+}
+
+// fanetGateway проверяет, что сообщение принято базовой станцией FANET.
+// Сообщение без источника считается полученным по MQTT
+func fanetGateway(source string) bool {
+	return source == "" || source == MQTTSourceName
+}
+
+// enqueued учитывает сообщение в глубине очереди
+func (p *Pipeline) enqueued() {
+	p.depth.Add(1)
+	metrics.PipelineQueueDepth.Inc()
+}
+
+// shard выбирает обработчик по адресу устройства
+func (p *Pipeline) shard(deviceID string) int {
+	if len(p.queues) == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(deviceID))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// broadcast рассылает обновление, если рассылка настроена
+func (p *Pipeline) broadcast(updateType pb.UpdateType, action pb.Action, data interface{}) {
+	if p.deps.Broadcaster != nil {
+		p.deps.Broadcaster.BroadcastUpdate(updateType, action, data)
+	}
+}
+
+// handleAirTracking обрабатывает позицию в воздухе (Type 1)
+func (p *Pipeline) handleAirTracking(ctx context.Context, msg *mqtt.FANETMessage) error {
+	pilot := convertFANETToPilot(msg)
+	if pilot == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to pilot model")
+		return nil
+	}
+
+	// Получаем предыдущую позицию для определения движения
+	existingPilot, err := p.deps.Repository.GetPilot(ctx, pilot.DeviceID)
+	var lastPosition *models.GeoPoint
+	if err == nil && existingPilot != nil && existingPilot.Position != nil {
+		lastPosition = existingPilot.Position
+	}
+
+	// Определяем статус объекта относительно границ всех центров отслеживания
+	if p.deps.Boundary != nil {
+		status := p.deps.Boundary.GetObjectStatus(*pilot.Position, lastPosition, pilot.LastUpdate)
+		pilot.TrackingDistance = status.Distance
+		pilot.VisibilityStatus = status.VisibilityStatus
+		if status.LastMovement != pilot.LastUpdate {
+			pilot.LastMovement = &status.LastMovement
+		}
+	}
+
+	if p.deps.Reception != nil {
+		pilot.Reception = p.deps.Reception.Stats(pilot.DeviceID)
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"device_id":         pilot.DeviceID,
+		"source":            msg.Source,
+		"latitude":          pilot.Position.Latitude,
+		"longitude":         pilot.Position.Longitude,
+		"altitude":          pilot.Position.Altitude,
+		"aircraft_type":     pilot.Type,
+		"online":            pilot.TrackOnline,
+		"visibility_status": pilot.VisibilityStatus,
+		"tracking_distance": pilot.TrackingDistance,
+	}).Debug("Processing pilot data")
+
+	// Валидируем данные пилота со скорингом
+	isValid, shouldStore, err := p.deps.Validation.ValidatePilot(pilot)
+	if err != nil {
+		p.logger.WithField("error", err).WithField("device_id", pilot.DeviceID).
+			Error("Failed to validate pilot")
+		return err
+	}
+
+	state, stateExists := p.deps.Validation.GetValidationState(pilot.DeviceID)
+	score := -1
+	if stateExists {
+		score = state.ValidationScore
+	}
+
+	if !shouldStore {
+		// Счет недостаточен - удаляем пилота, если он ранее был валидным
+		if stateExists && state.IsValidated {
+			if err := p.deps.Repository.RemovePilot(ctx, pilot.DeviceID); err != nil {
+				p.logger.WithField("error", err).WithField("device_id", pilot.DeviceID).
+					Warn("Failed to remove pilot from Redis")
+			} else {
+				p.logger.WithFields(map[string]interface{}{
+					"device_id":        pilot.DeviceID,
+					"validation_score": state.ValidationScore,
+				}).Info("Removed pilot from Redis due to low validation score")
+
+				p.broadcast(pb.UpdateType_UPDATE_TYPE_PILOT, pb.Action_ACTION_REMOVE, convertPilotToProtobuf(pilot))
+			}
+		}
+
+		p.logger.WithFields(map[string]interface{}{
+			"device_id":        pilot.DeviceID,
+			"is_valid":         isValid,
+			"validation_score": score,
+		}).Debug("Pilot validation score insufficient for Redis storage")
+		return nil
+	}
+
+	// История получает позицию до потоковой фильтрации: GetTrack фильтрует трек при чтении
+	if p.deps.History != nil {
+		if err := p.deps.History.QueuePilot(pilot); err != nil {
+			p.logger.WithField("error", err).WithField("device_id", pilot.DeviceID).
+				Warn("Failed to queue pilot for MySQL batch")
+		}
+	}
+
+	live := []*models.Pilot{pilot}
+	if p.deps.LiveFilter != nil {
+		live = p.deps.LiveFilter.Observe(pilot)
+	}
+
+	for _, livePilot := range live {
+		if err := p.publishPilot(ctx, livePilot); err != nil {
+			return err
+		}
+		p.logger.WithFields(map[string]interface{}{
+			"device_id":        livePilot.DeviceID,
+			"is_valid":         isValid,
+			"validation_score": score,
+		}).Debug("Successfully saved pilot to Redis")
+	}
+	return nil
+}
+
+// publishPilot сохраняет принятую позицию в Redis, рассылает ее и передает
+// детекторам полетов и термиков
+func (p *Pipeline) publishPilot(ctx context.Context, pilot *models.Pilot) error {
+	if err := p.deps.Repository.SavePilot(ctx, pilot); err != nil {
+		p.logger.WithField("error", err).WithField("device_id", pilot.DeviceID).
+			Error("Failed to save pilot to Redis")
+		return err
+	}
+
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_PILOT, pb.Action_ACTION_UPDATE, convertPilotToProtobuf(pilot))
+
+	if p.deps.Flights != nil {
+		p.deps.Flights.Observe(ctx, pilot)
+	}
+
+	if p.deps.Thermals != nil {
+		if thermal := p.deps.Thermals.Observe(pilot); thermal != nil {
+			// Ошибка сохранения термика не должна влиять на обработку позиции
+			_ = p.storeThermal(ctx, thermal)
+		}
+	}
+	return nil
+}
+
+// ReleaseDelayed ставит выпуск позиций, отложенных потоковым фильтром дольше
+// LiveFilterConfig.MaxDelay, в очереди обработчиков их устройств: позиция
+// публикуется тем же обработчиком, что и живые пакеты устройства, и не
+// обгоняет их. Если очередь заполнена, выпуск откладывается до следующего
+// вызова. Возвращает количество устройств, поставленных в очередь
+func (p *Pipeline) ReleaseDelayed() int {
+	if p.deps.LiveFilter == nil {
+		return 0
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.started || p.stopped {
+		return 0
+	}
+
+	queued := 0
+	for _, deviceID := range p.deps.LiveFilter.ExpiredDevices() {
+		select {
+		case p.queues[p.shard(deviceID)] <- job{release: deviceID}:
+			p.enqueued()
+			queued++
+		default:
+		}
+	}
+	return queued
+}
+
+// releaseDelayed публикует отложенную позицию устройства, если следующая
+// позиция не разрешила ее раньше
+func (p *Pipeline) releaseDelayed(ctx context.Context, deviceID string) {
+	if pilot := p.deps.LiveFilter.Release(deviceID); pilot != nil {
+		_ = p.publishPilot(ctx, pilot)
+	}
+}
+
+// handleName обрабатывает имя пилота (Type 2)
+func (p *Pipeline) handleName(ctx context.Context, msg *mqtt.FANETMessage) error {
+	nameUpdate := convertFANETToNameUpdate(msg)
+	if nameUpdate == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to name update")
+		return nil
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"device_id": nameUpdate.DeviceID,
+		"name":      nameUpdate.Name,
+	}).Debug("Processing name update")
+
+	if err := p.deps.Repository.UpdatePilotName(ctx, nameUpdate.DeviceID, nameUpdate.Name); err != nil {
+		p.logger.WithField("error", err).WithField("device_id", nameUpdate.DeviceID).
+			Error("Failed to update pilot name in Redis")
+	}
+
+	if p.deps.History != nil {
+		pilot := &models.Pilot{
+			DeviceID:   nameUpdate.DeviceID,
+			Name:       nameUpdate.Name,
+			LastUpdate: time.Now(),
+		}
+		if err := p.deps.History.QueuePilot(pilot); err != nil {
+			p.logger.WithField("error", err).WithField("device_id", nameUpdate.DeviceID).
+				Warn("Failed to queue name update for MySQL batch")
+		}
+	}
+	return nil
+}
+
+// handleMessage обрабатывает текстовое сообщение (Type 3)
+func (p *Pipeline) handleMessage(ctx context.Context, msg *mqtt.FANETMessage) error {
+	message := convertFANETToMessage(msg)
+	if message == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to message model")
+		return nil
+	}
+
+	// В FANET сообщении нет координат - используем последнюю известную позицию отправителя
+	position, source := p.messagePosition(ctx, msg)
+	metrics.PipelineMessagePositions.WithLabelValues(source).Inc()
+	if position == nil {
+		p.logger.WithFields(map[string]interface{}{
+			"device_id": message.From,
+			"chip_id":   msg.ChipID,
+		}).Warn("Dropping message: sender and receiving base station positions are unknown")
+		return nil
+	}
+	message.Position = position
+
+	p.logger.WithFields(map[string]interface{}{
+		"message_id": message.ID,
+		"from":       message.From,
+		"to":         message.To,
+		"subheader":  message.Subheader,
+		"text":       message.Text,
+	}).Debug("Processing message data")
+
+	if err := p.deps.Repository.SaveMessage(ctx, message); err != nil {
+		p.logger.WithField("error", err).WithField("message_id", message.ID).
+			Error("Failed to save message to Redis")
+		return err
+	}
+
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_MESSAGE, pb.Action_ACTION_ADD, message.ToProto())
+	return nil
+}
+
+// messagePosition возвращает позицию для текстового сообщения и ее источник:
+// последняя позиция отправителя-пилота, его наземного объекта или оценка
+// положения принявшей базовой станции
+func (p *Pipeline) messagePosition(ctx context.Context, msg *mqtt.FANETMessage) (*models.GeoPoint, string) {
+	if sender, err := p.deps.Repository.GetPilot(ctx, msg.DeviceID); err == nil && sender != nil && sender.Position != nil {
+		return sender.Position, "pilot"
+	}
+	if ground, err := p.deps.Repository.GetGroundObject(ctx, msg.DeviceID); err == nil && ground != nil && ground.Position != nil {
+		return ground.Position, "ground"
+	}
+	if p.deps.Gateways != nil && msg.ChipID != "" {
+		if position := p.deps.Gateways.Position(msg.ChipID); position != nil {
+			return position, "gateway"
+		}
+	}
+	return nil, "dropped"
+}
+
+// handleService обрабатывает данные метеостанции (Type 4)
+func (p *Pipeline) handleService(ctx context.Context, msg *mqtt.FANETMessage) error {
+	station := convertFANETToStation(msg)
+	if station == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to station model")
+		return nil
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"station_id":  station.ID,
+		"latitude":    station.Position.Latitude,
+		"longitude":   station.Position.Longitude,
+		"temperature": station.Temperature,
+		"pressure":    station.Pressure,
+	}).Debug("Processing station data")
+
+	if err := p.deps.Repository.SaveStation(ctx, station); err != nil {
+		p.logger.WithField("error", err).WithField("station_id", station.ID).
+			Error("Failed to save station to Redis")
+		return err
+	}
+
+	if p.deps.History != nil {
+		if err := p.deps.History.QueueStation(station); err != nil {
+			p.logger.WithField("error", err).WithField("station_id", station.ID).
+				Warn("Failed to queue station for MySQL batch")
+		}
+	}
+
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_STATION, pb.Action_ACTION_UPDATE, convertStationToProtobuf(station))
+	return nil
+}
+
+// handleLandmark обрабатывает ориентир (Type 5)
+func (p *Pipeline) handleLandmark(ctx context.Context, msg *mqtt.FANETMessage) error {
+	landmark := convertFANETToLandmark(msg)
+	if landmark == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to landmark model")
+		return nil
+	}
+	if err := landmark.Validate(); err != nil {
+		p.logger.WithField("error", err).WithField("device_id", msg.DeviceID).Warn("Invalid landmark data")
+		return nil
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"landmark_id": landmark.ID,
+		"type":        landmark.Type.String(),
+		"layer":       landmark.Layer.String(),
+		"points":      len(landmark.Points),
+		"expires_at":  landmark.ExpiresAt,
+	}).Debug("Processing landmark data")
+
+	if err := p.deps.Repository.SaveLandmark(ctx, landmark); err != nil {
+		p.logger.WithField("error", err).WithField("landmark_id", landmark.ID).
+			Error("Failed to save landmark to Redis")
+		return err
+	}
+
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_LANDMARK, pb.Action_ACTION_ADD, landmark.ToProto())
+	return nil
+}
+
+// handleGroundTracking обрабатывает наземный объект (Type 7)
+func (p *Pipeline) handleGroundTracking(ctx context.Context, msg *mqtt.FANETMessage) error {
+	groundObject := convertFANETToGroundObject(msg)
+	if groundObject == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to ground object model")
+		return nil
+	}
+
+	if err := groundObject.Validate(); err != nil ||
+		(groundObject.Position.Latitude == 0 && groundObject.Position.Longitude == 0) {
+		p.logger.WithField("error", err).WithField("device_id", groundObject.DeviceID).
+			Warn("Invalid ground object data, skipping")
+		return nil
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"device_id":   groundObject.DeviceID,
+		"latitude":    groundObject.Position.Latitude,
+		"longitude":   groundObject.Position.Longitude,
+		"ground_type": groundObject.Type.String(),
+		"online":      groundObject.TrackOnline,
+	}).Debug("Processing ground object data")
+
+	// Экстренные статусы должны быть сразу видны команде эвакуации
+	if groundObject.IsEmergency() {
+		metrics.GroundEmergencies.WithLabelValues(groundObject.Type.String()).Inc()
+		p.logger.WithFields(map[string]interface{}{
+			"device_id":   groundObject.DeviceID,
+			"ground_type": groundObject.Type.String(),
+			"latitude":    groundObject.Position.Latitude,
+			"longitude":   groundObject.Position.Longitude,
+		}).Warn("Ground object reports emergency status")
+
+		// Открываем или обновляем инцидент (повторные пакеты дедуплицируются)
+		if p.deps.Alerts != nil {
+			p.deps.Alerts.ProcessGroundObject(groundObject)
+		}
+	}
+
+	if err := p.deps.Repository.SaveGroundObject(ctx, groundObject); err != nil {
+		p.logger.WithField("error", err).WithField("device_id", groundObject.DeviceID).
+			Error("Failed to save ground object to Redis")
+		return err
+	}
+
+	if p.deps.History != nil {
+		if err := p.deps.History.QueueGroundObject(groundObject); err != nil {
+			p.logger.WithField("error", err).WithField("device_id", groundObject.DeviceID).
+				Warn("Failed to queue ground object for MySQL batch")
+		}
+	}
+
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT, pb.Action_ACTION_UPDATE, groundObject.ToProto())
+	return nil
+}
+
+// handleThermal обрабатывает термик (Type 9)
+func (p *Pipeline) handleThermal(ctx context.Context, msg *mqtt.FANETMessage) error {
+	thermal := convertFANETToThermal(msg)
+	if thermal == nil {
+		p.logger.WithField("fanet_type", msg.Type).Warn("Failed to convert FANET message to thermal model")
+		return nil
+	}
+
+	p.logger.WithFields(map[string]interface{}{
+		"thermal_id":  thermal.ID,
+		"reported_by": thermal.ReportedBy,
+		"latitude":    thermal.Position.Latitude,
+		"longitude":   thermal.Position.Longitude,
+		"quality":     thermal.Quality,
+	}).Debug("Processing thermal data")
+
+	return p.storeThermal(ctx, thermal)
+}
+
+// storeThermal сохраняет термик в Redis и MySQL и рассылает клиентам
+func (p *Pipeline) storeThermal(ctx context.Context, thermal *models.Thermal) error {
+	if err := p.deps.Repository.SaveThermal(ctx, thermal); err != nil {
+		p.logger.WithField("error", err).WithField("thermal_id", thermal.ID).
+			Error("Failed to save thermal to Redis")
+		return err
+	}
+
+	if p.deps.History != nil {
+		if err := p.deps.History.QueueThermal(thermal); err != nil {
+			p.logger.WithField("error", err).WithField("thermal_id", thermal.ID).
+				Warn("Failed to queue thermal for MySQL batch")
+		}
+	}
+
+	// Повторное обнаружение того же термика - обновление
+	action := pb.Action_ACTION_ADD
+	if thermal.LastSeen.After(thermal.Timestamp) {
+		action = pb.Action_ACTION_UPDATE
+	}
+	p.broadcast(pb.UpdateType_UPDATE_TYPE_THERMAL, action, convertThermalToProtobuf(thermal))
+	return nil
+}
@@ -0,0 +1,636 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/internal/service"
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository хранит состояние в памяти вместо Redis
+type memoryRepository struct {
+	mu            sync.Mutex
+	pilots        map[string]*models.Pilot
+	saved         map[string][]time.Time // Время сохраненных позиций по устройствам в порядке записи
+	names         map[string]string
+	groundObjects map[string]*models.GroundObject
+	thermals      []*models.Thermal
+	messages      []*models.Message
+	stations      []*models.Station
+	landmarks     []*models.Landmark
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		pilots:        make(map[string]*models.Pilot),
+		saved:         make(map[string][]time.Time),
+		names:         make(map[string]string),
+		groundObjects: make(map[string]*models.GroundObject),
+	}
+}
+
+func (r *memoryRepository) GetPilot(ctx context.Context, deviceID string) (*models.Pilot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pilot, ok := r.pilots[deviceID]
+	if !ok {
+		return nil, fmt.Errorf("pilot %s not found", deviceID)
+	}
+	return pilot, nil
+}
+
+func (r *memoryRepository) SavePilot(ctx context.Context, pilot *models.Pilot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pilots[pilot.DeviceID] = pilot
+	r.saved[pilot.DeviceID] = append(r.saved[pilot.DeviceID], pilot.LastUpdate)
+	return nil
+}
+
+func (r *memoryRepository) RemovePilot(ctx context.Context, deviceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pilots, deviceID)
+	return nil
+}
+
+func (r *memoryRepository) UpdatePilotName(ctx context.Context, deviceID string, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[deviceID] = name
+	return nil
+}
+
+func (r *memoryRepository) SaveGroundObject(ctx context.Context, groundObject *models.GroundObject) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groundObjects[groundObject.DeviceID] = groundObject
+	return nil
+}
+
+func (r *memoryRepository) GetGroundObject(ctx context.Context, deviceID string) (*models.GroundObject, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.groundObjects[deviceID], nil
+}
+
+func (r *memoryRepository) SaveThermal(ctx context.Context, thermal *models.Thermal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.thermals = append(r.thermals, thermal)
+	return nil
+}
+
+func (r *memoryRepository) SaveMessage(ctx context.Context, message *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *memoryRepository) SaveStation(ctx context.Context, station *models.Station) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stations = append(r.stations, station)
+	return nil
+}
+
+func (r *memoryRepository) SaveLandmark(ctx context.Context, landmark *models.Landmark) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.landmarks = append(r.landmarks, landmark)
+	return nil
+}
+
+// recordingBroadcaster запоминает разосланные обновления
+type recordingBroadcaster struct {
+	mu      sync.Mutex
+	updates []pb.UpdateType
+}
+
+func (b *recordingBroadcaster) BroadcastUpdate(updateType pb.UpdateType, action pb.Action, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.updates = append(b.updates, updateType)
+}
+
+func (b *recordingBroadcaster) count(updateType pb.UpdateType) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, u := range b.updates {
+		if u == updateType {
+			n++
+		}
+	}
+	return n
+}
+
+// recordingHistory запоминает поставленные в очередь MySQL записи
+type recordingHistory struct {
+	mu            sync.Mutex
+	pilots        []*models.Pilot
+	groundObjects int
+}
+
+func (h *recordingHistory) QueuePilot(pilot *models.Pilot) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pilots = append(h.pilots, pilot)
+	return nil
+}
+
+func (h *recordingHistory) QueueThermal(thermal *models.Thermal) error { return nil }
+
+func (h *recordingHistory) QueueStation(station *models.Station) error { return nil }
+
+func (h *recordingHistory) QueueGroundObject(groundObject *models.GroundObject) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.groundObjects++
+	return nil
+}
+
+type pipelineFixture struct {
+	pipeline    *Pipeline
+	repo        *memoryRepository
+	broadcaster *recordingBroadcaster
+	history     *recordingHistory
+	alerts      *service.AlertService
+}
+
+func newPipelineFixture(cfg PipelineConfig) *pipelineFixture {
+	logger := utils.NewLogger("error", "text")
+	f := &pipelineFixture{
+		repo:        newMemoryRepository(),
+		broadcaster: &recordingBroadcaster{},
+		history:     &recordingHistory{},
+		alerts:      service.NewAlertService(logger, nil, nil),
+	}
+	f.pipeline = NewPipeline(PipelineDeps{
+		Repository:  f.repo,
+		Broadcaster: f.broadcaster,
+		History:     f.history,
+		Validation:  service.NewValidationService(logger, nil),
+		Alerts:      f.alerts,
+	}, cfg, logger)
+	return f
+}
+
+func airTracking(deviceID string, lat, lon float64, ts time.Time) *mqtt.FANETMessage {
+	return &mqtt.FANETMessage{
+		Type:      1,
+		DeviceID:  deviceID,
+		Timestamp: ts,
+		Data: &mqtt.AirTrackingData{
+			Latitude:       lat,
+			Longitude:      lon,
+			Altitude:       1500,
+			Speed:          40,
+			AircraftType:   1,
+			OnlineTracking: true,
+		},
+	}
+}
+
+func TestPipeline_PilotStoredAfterValidation(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+
+	// Счет растет с каждым правдоподобным пакетом, порог сохранения достигается на третьем
+	for i := 0; i < 3; i++ {
+		msg := airTracking("ABC123", 46.0+float64(i)*0.0005, 13.0, start.Add(time.Duration(i)*5*time.Second))
+		require.NoError(t, f.pipeline.Process(ctx, msg))
+
+		_, err := f.repo.GetPilot(ctx, "ABC123")
+		if i < 2 {
+			assert.Error(t, err, "pilot must not be stored after packet %d", i+1)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, 1, f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_PILOT))
+	assert.Len(t, f.history.pilots, 1)
+}
+
+func TestPipeline_NameAndMessage(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	ctx := context.Background()
+
+	require.NoError(t, f.pipeline.Process(ctx, &mqtt.FANETMessage{
+		Type: 2, DeviceID: "ABC123", Data: &mqtt.NameData{Name: "Ivan"},
+	}))
+	assert.Equal(t, "Ivan", f.repo.names["ABC123"])
+	require.Len(t, f.history.pilots, 1)
+	assert.Equal(t, "Ivan", f.history.pilots[0].Name)
+
+	text := &mqtt.FANETMessage{
+		Type: 3, DeviceID: "ABC123", Timestamp: time.Now(), Data: &mqtt.MessageData{Text: "landed"},
+	}
+
+	// Позиция отправителя неизвестна - сообщение пропускается
+	require.NoError(t, f.pipeline.Process(ctx, text))
+	assert.Empty(t, f.repo.messages)
+
+	f.repo.pilots["ABC123"] = &models.Pilot{DeviceID: "ABC123", Position: &models.GeoPoint{Latitude: 46, Longitude: 13}}
+	require.NoError(t, f.pipeline.Process(ctx, text))
+	require.Len(t, f.repo.messages, 1)
+	assert.Equal(t, 46.0, f.repo.messages[0].Position.Latitude)
+	assert.Equal(t, 1, f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_MESSAGE))
+}
+
+func TestPipeline_MessagePositionFallback(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.pipeline.deps.Gateways = service.NewGatewayRegistry(utils.NewLogger("error", "text"), nil, nil)
+	ctx := context.Background()
+
+	// Станция GW1 оценивается по принятым позициям вокруг точки (46.0, 14.0)
+	for _, position := range []models.GeoPoint{
+		{Latitude: 46.1, Longitude: 14.0},
+		{Latitude: 45.9, Longitude: 14.0},
+		{Latitude: 46.0, Longitude: 14.1},
+		{Latitude: 46.0, Longitude: 13.9},
+	} {
+		f.pipeline.deps.Gateways.Observe(service.GatewayObservation{
+			ChipID: "GW1", DeviceID: "ABC123", Type: 1, RSSI: -90, Position: &position,
+		})
+	}
+	f.repo.groundObjects["GRD001"] = &models.GroundObject{
+		DeviceID: "GRD001", Position: &models.GeoPoint{Latitude: 45.5, Longitude: 13.5},
+	}
+
+	text := func(deviceID, chipID string) *mqtt.FANETMessage {
+		return &mqtt.FANETMessage{
+			Type: 3, DeviceID: deviceID, ChipID: chipID, Timestamp: time.Now(), Data: &mqtt.MessageData{Text: "help"},
+		}
+	}
+	dropped := promtestutil.ToFloat64(metrics.PipelineMessagePositions.WithLabelValues("dropped"))
+
+	// Наземный объект отправителя важнее положения станции
+	require.NoError(t, f.pipeline.Process(ctx, text("GRD001", "GW1")))
+	require.Len(t, f.repo.messages, 1)
+	assert.Equal(t, 45.5, f.repo.messages[0].Position.Latitude)
+
+	require.NoError(t, f.pipeline.Process(ctx, text("DEF456", "GW1")))
+	require.Len(t, f.repo.messages, 2)
+	assert.InDelta(t, 46.0, f.repo.messages[1].Position.Latitude, 0.01)
+	assert.InDelta(t, 14.0, f.repo.messages[1].Position.Longitude, 0.01)
+
+	// Положение станции GW2 неизвестно - сообщение отбрасывается и учитывается в метрике
+	require.NoError(t, f.pipeline.Process(ctx, text("DEF456", "GW2")))
+	assert.Len(t, f.repo.messages, 2)
+	assert.Equal(t, dropped+1, promtestutil.ToFloat64(metrics.PipelineMessagePositions.WithLabelValues("dropped")))
+}
+
+func TestPipeline_MessageIDFromPayload(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.repo.pilots["ABC123"] = &models.Pilot{DeviceID: "ABC123", Position: &models.GeoPoint{Latitude: 46, Longitude: 13}}
+	ctx := context.Background()
+
+	// Копии одного пакета от двух станций с разным временем станции
+	received := time.Now()
+	payload := []byte{0x03, 0x23, 0xC1, 0xAB, 0x00, 'h', 'e', 'l', 'p'}
+	for i, chipID := range []string{"GW1", "GW2"} {
+		require.NoError(t, f.pipeline.Process(ctx, &mqtt.FANETMessage{
+			Type: 3, DeviceID: "ABC123", ChipID: chipID, Timestamp: received.Add(time.Duration(i) * 1500 * time.Millisecond),
+			RawPayload: payload, Data: &mqtt.MessageData{Text: "help"},
+		}))
+	}
+	require.Len(t, f.repo.messages, 2)
+	assert.Equal(t, f.repo.messages[0].ID, f.repo.messages[1].ID)
+
+	// Другой пакет с тем же текстом - другое сообщение
+	require.NoError(t, f.pipeline.Process(ctx, &mqtt.FANETMessage{
+		Type: 3, DeviceID: "ABC123", ChipID: "GW1", Timestamp: received,
+		RawPayload: []byte{0x03, 0x23, 0xC1, 0xAB, 0x01, 'h', 'e', 'l', 'p'}, Data: &mqtt.MessageData{Text: "help"},
+	}))
+	require.Len(t, f.repo.messages, 3)
+	assert.NotEqual(t, f.repo.messages[0].ID, f.repo.messages[2].ID)
+}
+
+func TestPipeline_GroundEmergencyOpensAlert(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+
+	require.NoError(t, f.pipeline.Process(context.Background(), &mqtt.FANETMessage{
+		Type:      7,
+		DeviceID:  "DEF456",
+		Timestamp: time.Now(),
+		Data: &mqtt.GroundTrackingData{
+			Latitude:    46.1,
+			Longitude:   13.1,
+			GroundType:  uint8(models.GroundTypeDistressCall),
+			TrackOnline: true,
+		},
+	}))
+
+	assert.Contains(t, f.repo.groundObjects, "DEF456")
+	assert.Equal(t, 1, f.history.groundObjects)
+	assert.Equal(t, 1, f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_GROUND_OBJECT))
+	assert.Len(t, f.alerts.ListAlerts(models.AlertStatusOpen), 1)
+}
+
+func TestPipeline_DerivedThermal(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.pipeline.deps.Thermals = service.NewThermalDetector(utils.NewLogger("error", "text"), nil)
+	ctx := context.Background()
+	start := time.Now().Add(-5 * time.Minute)
+
+	// Круг радиусом 100 м за ~57 секунд с набором 2 м/с
+	for i := 0; i < 30; i++ {
+		elapsed := float64(i * 3)
+		angle := elapsed * 0.11 // рад
+		msg := airTracking("ABC123", 46.0+0.0009*math.Cos(angle), 13.0+0.0013*math.Sin(angle), start.Add(time.Duration(i*3)*time.Second))
+		data := msg.Data.(*mqtt.AirTrackingData)
+		data.Altitude = int32(1200 + 2*elapsed)
+		data.Heading = uint16(math.Mod(angle*180/math.Pi+90, 360))
+		require.NoError(t, f.pipeline.Process(ctx, msg))
+	}
+
+	require.NotEmpty(t, f.repo.thermals)
+	thermal := f.repo.thermals[0]
+	assert.Equal(t, models.ThermalSourceDerived, thermal.Source)
+	assert.Equal(t, "ABC123", thermal.ReportedBy)
+	assert.Greater(t, thermal.Confidence, float32(0))
+	assert.Equal(t, len(f.repo.thermals), f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_THERMAL))
+
+	// Термик из FANET Type 9 с тем же набором хранится в тех же единицах (м/с)
+	require.NoError(t, f.pipeline.Process(ctx, &mqtt.FANETMessage{
+		Type:      9,
+		DeviceID:  "DEF456",
+		Timestamp: time.Now(),
+		Data:      &mqtt.ThermalData{Latitude: 46.2, Longitude: 13.2, Altitude: 1500, Strength: 3, ClimbRate: 2},
+	}))
+	reported := f.repo.thermals[len(f.repo.thermals)-1]
+	assert.Equal(t, "DEF456", reported.ReportedBy)
+	assert.InDelta(t, 2, thermal.ClimbRate, 0.2)
+	assert.InDelta(t, thermal.ClimbRate, reported.ClimbRate, 0.2)
+}
+
+func TestPipeline_FlightTakeoff(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	tracker := service.NewFlightTracker(utils.NewLogger("error", "text"), nil, nil)
+	f.pipeline.deps.Flights = tracker
+	ctx := context.Background()
+	start := time.Now().Add(-5 * time.Minute)
+
+	// 40 км/ч в течение 45 секунд
+	for i := 0; i < 16; i++ {
+		msg := airTracking("ABC123", 46.0+0.0003*float64(i), 13.0, start.Add(time.Duration(i*3)*time.Second))
+		require.NoError(t, f.pipeline.Process(ctx, msg))
+	}
+
+	flight, ok := tracker.ActiveFlight("ABC123")
+	require.True(t, ok)
+	assert.Equal(t, models.FlightStatusActive, flight.Status)
+	assert.Equal(t, models.PilotTypeParaglider, flight.Type)
+	assert.Greater(t, flight.Distance, 0.0)
+}
+
+func TestPipeline_LiveFilterDropsTeleport(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.pipeline.deps.LiveFilter = service.NewLiveFilter(utils.NewLogger("error", "text"), nil)
+	ctx := context.Background()
+	start := time.Now().Add(-5 * time.Minute)
+
+	for i := 0; i < 10; i++ {
+		msg := airTracking("ABC123", 46.0+0.001*float64(i), 13.0, start.Add(time.Duration(i*10)*time.Second))
+		require.NoError(t, f.pipeline.Process(ctx, msg))
+	}
+	stored, err := f.repo.GetPilot(ctx, "ABC123")
+	require.NoError(t, err)
+	updates := f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_PILOT)
+	queued := len(f.history.pilots)
+
+	// Скачок на ~100 км за 10 секунд не попадает в Redis и WebSocket, но остается в истории
+	require.NoError(t, f.pipeline.Process(ctx, airTracking("ABC123", 47.0, 13.0, start.Add(100*time.Second))))
+
+	current, err := f.repo.GetPilot(ctx, "ABC123")
+	require.NoError(t, err)
+	assert.Equal(t, stored.Position.Latitude, current.Position.Latitude)
+	assert.Equal(t, updates, f.broadcaster.count(pb.UpdateType_UPDATE_TYPE_PILOT))
+	assert.Len(t, f.history.pilots, queued+1)
+}
+
+func TestPipeline_CustomHandlerAndUnknownType(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+
+	var handled []uint8
+	f.pipeline.RegisterHandler(8, func(ctx context.Context, msg *mqtt.FANETMessage) error {
+		handled = append(handled, msg.Type)
+		return nil
+	})
+
+	require.NoError(t, f.pipeline.Process(context.Background(), &mqtt.FANETMessage{Type: 8}))
+	require.NoError(t, f.pipeline.Process(context.Background(), &mqtt.FANETMessage{Type: 0}))
+	assert.Equal(t, []uint8{8}, handled)
+}
+
+func TestPipeline_DuplicatesFromOtherGateways(t *testing.T) {
+	logger := utils.NewLogger("error", "text")
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.pipeline.deps.Reception = service.NewReceptionTracker(logger, &service.ReceptionConfig{
+		DedupWindow: time.Minute,
+		StatsWindow: time.Minute,
+	})
+
+	var mu sync.Mutex
+	var handled []string
+	f.pipeline.RegisterHandler(8, func(ctx context.Context, msg *mqtt.FANETMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, msg.ChipID)
+		return nil
+	})
+
+	f.pipeline.Start(context.Background())
+	for _, chipID := range []string{"GW1", "GW2", "GW3"} {
+		require.NoError(t, f.pipeline.Handle(&mqtt.FANETMessage{
+			Type: 8, DeviceID: "ABC123", ChipID: chipID, SNR: 5, RawPayload: []byte{0x08, 0x01},
+		}))
+	}
+	f.pipeline.Stop()
+
+	assert.Equal(t, []string{"GW1"}, handled, "only the first copy is processed")
+	stats := f.pipeline.deps.Reception.Stats("ABC123")
+	require.NotNil(t, stats)
+	assert.Equal(t, 3, stats.Stations)
+}
+
+func TestPipeline_GatewayRegistryOnlyFANET(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 8})
+	f.pipeline.deps.Gateways = service.NewGatewayRegistry(utils.NewLogger("error", "text"), nil, nil)
+	f.pipeline.RegisterHandler(8, func(ctx context.Context, msg *mqtt.FANETMessage) error { return nil })
+
+	f.pipeline.Start(context.Background())
+	for _, msg := range []*mqtt.FANETMessage{
+		{Type: 8, DeviceID: "AAA001", ChipID: "GW1", Source: MQTTSourceName},
+		{Type: 8, DeviceID: "AAA002", ChipID: "GW2"},
+		{Type: 8, DeviceID: "FLR3E1234", ChipID: "LJUBLJANA", Source: OGNSourceName},
+		{Type: 8, DeviceID: "DDA8C1", ChipID: "FLARM", Source: NMEASourceName},
+	} {
+		require.NoError(t, f.pipeline.Handle(msg))
+	}
+	f.pipeline.Stop()
+
+	var chipIDs []string
+	for _, gateway := range f.pipeline.deps.Gateways.ListGateways(nil) {
+		chipIDs = append(chipIDs, gateway.ChipID)
+	}
+	assert.ElementsMatch(t, []string{"GW1", "GW2"}, chipIDs)
+}
+
+func TestPipeline_PerDeviceOrdering(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 4, QueueSize: 16})
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	f.pipeline.RegisterHandler(8, func(ctx context.Context, msg *mqtt.FANETMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[msg.DeviceID] = append(seen[msg.DeviceID], msg.Data.(int))
+		return nil
+	})
+
+	f.pipeline.Start(context.Background())
+	for i := 0; i < 100; i++ {
+		for _, device := range []string{"AAA001", "BBB002", "CCC003"} {
+			require.NoError(t, f.pipeline.Handle(&mqtt.FANETMessage{Type: 8, DeviceID: device, Data: i}))
+		}
+	}
+	f.pipeline.Stop()
+
+	require.Len(t, seen, 3)
+	for device, sequence := range seen {
+		require.Len(t, sequence, 100, device)
+		for i, n := range sequence {
+			assert.Equal(t, i, n, "device %s processed out of order", device)
+		}
+	}
+	assert.Equal(t, 0, f.pipeline.QueueDepth())
+}
+
+func TestPipeline_ReleaseDelayedKeepsDeviceOrder(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 4, QueueSize: 64})
+	f.pipeline.deps.LiveFilter = service.NewLiveFilter(utils.NewLogger("error", "text"), &service.LiveFilterConfig{
+		MaxDelay: time.Nanosecond,
+		IdleTTL:  time.Minute,
+	})
+	f.pipeline.Start(context.Background())
+
+	// Выпуск отложенных позиций идет параллельно с живыми пакетами
+	done := make(chan struct{})
+	var releases sync.WaitGroup
+	releases.Add(1)
+	go func() {
+		defer releases.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				f.pipeline.ReleaseDelayed()
+			}
+		}
+	}()
+
+	// Каждое устройство летит на север, а каждые 10 позиций после паузы в 2 минуты
+	// появляется со смещением: такая позиция откладывается до следующей или до выпуска
+	devices := []string{"AAA001", "BBB002", "CCC003", "DDD004"}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 60; i++ {
+		segment := i / 10
+		ts := start.Add(time.Duration(i)*10*time.Second + time.Duration(segment)*2*time.Minute)
+		for _, device := range devices {
+			lat := 46.0 + 0.001*float64(i) + 0.01*float64(segment)
+			lon := 13.0 + 0.04*float64(segment)
+			require.NoError(t, f.pipeline.Handle(airTracking(device, lat, lon, ts)))
+		}
+	}
+
+	close(done)
+	releases.Wait()
+	f.pipeline.Stop()
+
+	for _, device := range devices {
+		saved := f.repo.saved[device]
+		require.NotEmpty(t, saved, device)
+		for i := 1; i < len(saved); i++ {
+			assert.False(t, saved[i].Before(saved[i-1]), "device %s published out of order at %d", device, i)
+		}
+	}
+	assert.Equal(t, 0, f.pipeline.QueueDepth())
+}
+
+func TestPipeline_Backpressure(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 1, QueueSize: 1, EnqueueTimeout: 20 * time.Millisecond})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	f.pipeline.RegisterHandler(8, func(ctx context.Context, msg *mqtt.FANETMessage) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+
+	f.pipeline.Start(context.Background())
+	msg := &mqtt.FANETMessage{Type: 8, DeviceID: "AAA001"}
+
+	// Первое сообщение занимает обработчик, второе - единственное место в очереди
+	require.NoError(t, f.pipeline.Handle(msg))
+	<-started
+	require.NoError(t, f.pipeline.Handle(msg))
+	assert.Equal(t, 1, f.pipeline.QueueDepth())
+
+	begin := time.Now()
+	assert.ErrorIs(t, f.pipeline.Handle(msg), ErrQueueFull)
+	assert.GreaterOrEqual(t, time.Since(begin), 20*time.Millisecond, "source is blocked until the timeout")
+
+	close(release)
+	f.pipeline.Stop()
+	assert.ErrorIs(t, f.pipeline.Handle(msg), ErrPipelineStopped)
+}
+
+func TestPipeline_StopDrainsAfterCancel(t *testing.T) {
+	f := newPipelineFixture(PipelineConfig{Workers: 2, QueueSize: 64})
+
+	var mu sync.Mutex
+	processed := 0
+	f.pipeline.RegisterHandler(9, func(ctx context.Context, msg *mqtt.FANETMessage) error {
+		// Контекст обработки не отменяется вместе с контекстом приложения
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.pipeline.Start(ctx)
+	for i := 0; i < 50; i++ {
+		require.NoError(t, f.pipeline.Handle(&mqtt.FANETMessage{Type: 9, DeviceID: fmt.Sprintf("%06X", i)}))
+	}
+	cancel()
+	f.pipeline.Stop()
+
+	assert.Equal(t, 50, processed)
+}
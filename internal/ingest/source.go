@@ -0,0 +1,105 @@
+// Package ingest объединяет источники данных о позициях (FANET MQTT, OGN APRS-IS,
+// NMEA/PFLAA потоки) в единый поток FANET сообщений для общего конвейера
+// валидации, сохранения в Redis и трансляции по WebSocket
+package ingest
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+)
+
+// Source источник данных о позициях. Run блокируется до отмены контекста
+// и передает каждое нормализованное событие в handler. Переподключение
+// при обрывах связи — ответственность источника
+type Source interface {
+	Name() string
+	Run(ctx context.Context, handler mqtt.MessageHandler) error
+}
+
+// Position нормализованная позиция воздушного судна от не-FANET источника
+type Position struct {
+	DeviceID       string           // ID устройства с префиксом типа адреса (FLRDDA5BA)
+	AircraftType   models.PilotType // Тип ВС в нумерации FANET
+	Latitude       float64
+	Longitude      float64
+	Altitude       int32   // Высота в метрах
+	Speed          float64 // Скорость в км/ч
+	Heading        float64 // Курс в градусах
+	ClimbRate      float64 // Вертикальная скорость в м/с
+	OnlineTracking bool
+	ChipID         string // Приемник (OGN станция, FLARM устройство)
+	Timestamp      time.Time
+}
+
+// ToFANET преобразует позицию в FANET сообщение Type 1, чтобы оно прошло
+// тот же конвейер, что и пакеты от базовых станций
+func (p *Position) ToFANET(source string) *mqtt.FANETMessage {
+	heading := math.Mod(p.Heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+
+	return &mqtt.FANETMessage{
+		Type:      1,
+		DeviceID:  p.DeviceID,
+		ChipID:    p.ChipID,
+		Source:    source,
+		Timestamp: p.Timestamp,
+		Data: &mqtt.AirTrackingData{
+			Latitude:       p.Latitude,
+			Longitude:      p.Longitude,
+			Altitude:       p.Altitude,
+			Speed:          uint16(math.Max(0, math.Round(p.Speed))),
+			Heading:        uint16(math.Round(heading)) % 360,
+			ClimbRate:      int16(math.Round(p.ClimbRate * 10)),
+			AircraftType:   uint8(p.AircraftType),
+			OnlineTracking: p.OnlineTracking,
+		},
+	}
+}
+
+// FlarmAircraftType преобразует тип ВС FLARM/OGN в нумерацию FANET.
+// Второе значение false для наземных и статических объектов, которые
+// не отображаются как воздушные суда
+func FlarmAircraftType(flarmType uint8) (models.PilotType, bool) {
+	switch flarmType {
+	case 1: // Планер / мотопланер
+		return models.PilotTypeGlider, true
+	case 2, 5, 8, 9: // Буксировщик, сбрасыватель, поршневой и реактивный самолет
+		return models.PilotTypePowered, true
+	case 3: // Вертолет
+		return models.PilotTypeHelicopter, true
+	case 6: // Дельтаплан
+		return models.PilotTypeHangglider, true
+	case 7: // Параплан
+		return models.PilotTypeParaglider, true
+	case 11, 12: // Аэростат, дирижабль
+		return models.PilotTypeBalloon, true
+	case 13: // БПЛА
+		return models.PilotTypeUAV, true
+	case 15: // Статический объект
+		return models.PilotTypeUnknown, false
+	default: // 0 неизвестный, 4 парашют, 10 резерв, 14 резерв
+		return models.PilotTypeUnknown, true
+	}
+}
+
+// FlarmAddressPrefix возвращает префикс ID устройства по типу адреса FLARM/OGN
+// (биты 0-1 поля id в APRS, ID-Type в $PFLAA). Адреса разных типов и FANET
+// адреса пересекаются, поэтому хранятся в разных пространствах
+func FlarmAddressPrefix(addressType uint8) string {
+	switch addressType {
+	case 1:
+		return models.DevicePrefixICAO
+	case 2:
+		return models.DevicePrefixFLARM
+	case 3:
+		return models.DevicePrefixOGN
+	default: // 0 случайный адрес
+		return models.DevicePrefixRandom
+	}
+}
@@ -47,6 +47,52 @@ var (
 		},
 	)
 
+	WebSocketSubscriptions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_websocket_subscriptions_active",
+			Help: "Number of active WebSocket subscriptions across all clients",
+		},
+	)
+
+	WebSocketControlMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_websocket_control_messages_total",
+			Help: "Total number of WebSocket protocol v2 control messages received",
+		},
+		[]string{"type", "status"},
+	)
+
+	WebSocketResumes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_websocket_resumes_total",
+			Help: "Total number of WebSocket subscription resumes by result (replay or snapshot)",
+		},
+		[]string{"result"},
+	)
+
+	WebSocketJournalUpdates = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_websocket_journal_updates",
+			Help: "Number of updates kept in the WebSocket resume journal",
+		},
+	)
+
+	WebSocketDeltaUpdates = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_websocket_delta_updates_total",
+			Help: "Total number of updates for delta clients by encoding (full, delta, suppressed)",
+		},
+		[]string{"kind"},
+	)
+
+	StreamClients = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_stream_clients_active",
+			Help: "Number of active HTTP stream clients by transport (sse, poll)",
+		},
+		[]string{"transport"},
+	)
+
 	// MQTT метрики
 	MQTTMessagesReceived = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -169,6 +215,258 @@ var (
 		},
 	)
 
+	GroundEmergencies = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_ground_emergencies_total",
+			Help: "Total number of ground tracking packets with emergency status",
+		},
+		[]string{"type"}, // need_technical_support, need_medical_help, distress_call, distress_call_auto
+	)
+
+	// Метрики источников данных (MQTT, OGN APRS, NMEA)
+	IngestEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_ingest_events_total",
+			Help: "Total number of events received from ingest sources",
+		},
+		[]string{"source", "status"}, // status: processed, parse_error, handler_error, skipped
+	)
+
+	IngestSourceConnected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_ingest_source_connected",
+			Help: "Ingest source connection status (1 = connected, 0 = disconnected)",
+		},
+		[]string{"source"},
+	)
+
+	IngestReconnects = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_ingest_reconnects_total",
+			Help: "Total number of ingest source reconnect attempts",
+		},
+		[]string{"source"},
+	)
+
+	// Метрики конвейера обработки входящих сообщений
+	PipelineMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_pipeline_messages_total",
+			Help: "Total number of messages handled by the ingest pipeline",
+		},
+		[]string{"type", "status"}, // status: processed, error, dropped
+	)
+
+	PipelineQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_pipeline_queue_depth",
+			Help: "Number of messages waiting in ingest pipeline queues",
+		},
+	)
+
+	PipelineProcessingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fanet_pipeline_processing_duration_seconds",
+			Help:    "Duration of ingest pipeline message processing in seconds",
+			Buckets: []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"type"},
+	)
+
+	// Метрики приема пакетов несколькими базовыми станциями
+	PipelineMessagePositions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_pipeline_message_positions_total",
+			Help: "Total number of text messages by source of the attached position",
+		},
+		[]string{"source"}, // source: pilot, ground, gateway, dropped
+	)
+
+	ReceptionPackets = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_reception_packets_total",
+			Help: "Total number of received packet copies by deduplication result",
+		},
+		[]string{"status"}, // status: unique, duplicate
+	)
+
+	ReceptionReceivers = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "fanet_reception_receivers_per_packet",
+			Help:    "Number of base stations that heard the same packet",
+			Buckets: []float64{1, 2, 3, 4, 5, 6, 8, 10},
+		},
+	)
+
+	// Метрики базовых станций
+	GatewayPackets = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_gateway_packets_total",
+			Help: "Total number of packets received by base station",
+		},
+		[]string{"chip_id"},
+	)
+
+	GatewayOnline = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_gateway_online",
+			Help: "Base station status (1 = online, 0 = silent longer than GATEWAY_OFFLINE_AFTER)",
+		},
+		[]string{"chip_id"},
+	)
+
+	GatewayLastSeen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_gateway_last_seen_timestamp_seconds",
+			Help: "Unix time of the last packet received by base station",
+		},
+		[]string{"chip_id"},
+	)
+
+	GatewayPacketRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_gateway_packet_rate_per_minute",
+			Help: "Average packets per minute received by base station over the last 15 minutes",
+		},
+		[]string{"chip_id"},
+	)
+
+	GatewayCoverageRadius = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fanet_gateway_coverage_radius_km",
+			Help: "Estimated base station coverage radius in kilometers",
+		},
+		[]string{"chip_id"},
+	)
+
+	// Метрики обнаружения термиков по трекам пилотов
+	ThermalCirclingHits = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fanet_thermal_circling_hits_total",
+			Help: "Total number of detected climbing circles in pilot tracks",
+		},
+	)
+
+	ThermalsDerived = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fanet_thermals_derived_total",
+			Help: "Total number of thermal updates derived from circling pilots",
+		},
+	)
+
+	ThermalDetectorPilots = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_thermal_detector_tracked_pilots",
+			Help: "Number of pilots with recent fixes in the thermal detector",
+		},
+	)
+
+	// Метрики определения полетов
+	FlightEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_flight_events_total",
+			Help: "Total number of detected flight events",
+		},
+		[]string{"event"}, // takeoff, landing, lost
+	)
+
+	FlightsActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_flights_active",
+			Help: "Number of devices currently in flight",
+		},
+	)
+
+	// Метрики сглаживания позиций фильтром Калмана
+	KalmanGateRejections = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "fanet_kalman_gate_rejections_total",
+			Help: "Total number of positions rejected by the Kalman filter outlier gate",
+		},
+	)
+
+	KalmanTrackedDevices = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_kalman_tracked_devices",
+			Help: "Number of devices with Kalman filter state",
+		},
+	)
+
+	// Метрики потоковой фильтрации живых позиций
+	LiveFilterPoints = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_live_filter_points_total",
+			Help: "Total number of live positions processed by the stream filter",
+		},
+		[]string{"decision"}, // accept, delay, reject, release
+	)
+
+	LiveFilterRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_live_filter_rejected_total",
+			Help: "Total number of live positions rejected by the stream filter",
+		},
+		[]string{"kind"}, // speed, teleport, outlier, ping-pong, other
+	)
+
+	LiveFilterDevices = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_live_filter_devices",
+			Help: "Number of devices with stream filter state",
+		},
+	)
+
+	// Метрики записи сырого MQTT трафика
+	CaptureRecords = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_capture_records_total",
+			Help: "Total number of raw MQTT messages written to capture files",
+		},
+		[]string{"status"}, // status: written, error
+	)
+
+	// Downlink метрики (исходящие FANET пакеты)
+	DownlinkFrames = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_downlink_frames_total",
+			Help: "Total number of FANET frames published to base station downlink topics",
+		},
+		[]string{"type", "status"}, // status: success, error
+	)
+
+	// Метрики инцидентов (сигналы бедствия)
+	AlertsOpened = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_alerts_opened_total",
+			Help: "Total number of opened distress alerts",
+		},
+		[]string{"type"},
+	)
+
+	AlertsActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fanet_alerts_active",
+			Help: "Number of open or acknowledged distress alerts",
+		},
+	)
+
+	AlertNotifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_alert_notifications_total",
+			Help: "Total number of alert notifications sent to external notifiers",
+		},
+		[]string{"status"}, // success, error
+	)
+
+	// Кэш упрощенных версий треков (LOD)
+	TrackLODCache = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fanet_track_lod_cache_total",
+			Help: "Total number of track level-of-detail cache lookups",
+		},
+		[]string{"result"}, // hit, miss, error
+	)
+
 	// Database connection status
 	MySQLConnectionStatus = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// AlertStatus статус инцидента
+type AlertStatus string
+
+const (
+	AlertStatusOpen         AlertStatus = "open"         // Открыт, ожидает реакции
+	AlertStatusAcknowledged AlertStatus = "acknowledged" // Принят администратором
+	AlertStatusResolved     AlertStatus = "resolved"     // Закрыт
+)
+
+// ParseAlertStatus разбирает статус из строки (пустая строка допустима и означает "любой")
+func ParseAlertStatus(s string) (AlertStatus, error) {
+	switch AlertStatus(s) {
+	case "", AlertStatusOpen, AlertStatusAcknowledged, AlertStatusResolved:
+		return AlertStatus(s), nil
+	default:
+		return "", fmt.Errorf("invalid alert status: %s", s)
+	}
+}
+
+// ToProto конвертирует статус в protobuf
+func (s AlertStatus) ToProto() pb.AlertStatus {
+	switch s {
+	case AlertStatusAcknowledged:
+		return pb.AlertStatus_ALERT_STATUS_ACKNOWLEDGED
+	case AlertStatusResolved:
+		return pb.AlertStatus_ALERT_STATUS_RESOLVED
+	default:
+		return pb.AlertStatus_ALERT_STATUS_OPEN
+	}
+}
+
+// IsAlertType проверяет, открывает ли тип наземного объекта инцидент.
+// В отличие от IsEmergency, запрос технической помощи инцидентом не считается
+func IsAlertType(t GroundType) bool {
+	switch t {
+	case GroundTypeDistressCall, GroundTypeDistressCallAuto, GroundTypeNeedMedicalHelp:
+		return true
+	default:
+		return false
+	}
+}
+
+// Alert представляет инцидент по сигналу бедствия
+type Alert struct {
+	// Идентификация
+	ID       string      `json:"id"`        // Уникальный ID инцидента
+	DeviceID string      `json:"device_id"` // FANET адрес устройства в hex формате
+	Type     GroundType  `json:"type"`      // Тип сигнала
+	Status   AlertStatus `json:"status"`    // Статус инцидента
+
+	// Позиция
+	Position *GeoPoint `json:"position,omitempty"` // Последние известные координаты
+
+	// Время и счетчики
+	FirstSeen   time.Time `json:"first_seen"`   // Время первого пакета
+	LastSeen    time.Time `json:"last_seen"`    // Время последнего пакета
+	PacketCount int       `json:"packet_count"` // Количество полученных пакетов
+
+	// Обработка
+	AcknowledgedBy int        `json:"acknowledged_by,omitempty"` // ID администратора, принявшего инцидент
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"` // Время подтверждения
+	ResolvedBy     int        `json:"resolved_by,omitempty"`     // ID администратора, закрывшего инцидент
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`     // Время закрытия
+	Note           string     `json:"note,omitempty"`            // Комментарий
+}
+
+// IsActive проверяет, требует ли инцидент внимания (не закрыт)
+func (a *Alert) IsActive() bool {
+	return a.Status != AlertStatusResolved
+}
+
+// GenerateAlertID генерирует ID инцидента из устройства и времени первого пакета
+func GenerateAlertID(deviceID string, firstSeen time.Time) string {
+	return fmt.Sprintf("%s_%d", deviceID, firstSeen.Unix())
+}
+
+// ToProto конвертирует Alert в protobuf
+func (a *Alert) ToProto() *pb.Alert {
+	addr, _ := DeviceAddr(a.DeviceID)
+
+	alert := &pb.Alert{
+		Id:             a.ID,
+		Addr:           uint32(addr),
+		Type:           pb.GroundType(a.Type),
+		Status:         a.Status.ToProto(),
+		FirstSeen:      a.FirstSeen.Unix(),
+		LastSeen:       a.LastSeen.Unix(),
+		PacketCount:    uint32(a.PacketCount),
+		AcknowledgedBy: int32(a.AcknowledgedBy),
+		ResolvedBy:     int32(a.ResolvedBy),
+		Note:           a.Note,
+	}
+
+	if a.Position != nil {
+		alert.Position = &pb.GeoPoint{
+			Latitude:  a.Position.Latitude,
+			Longitude: a.Position.Longitude,
+		}
+	}
+	if a.AcknowledgedAt != nil {
+		alert.AcknowledgedAt = a.AcknowledgedAt.Unix()
+	}
+	if a.ResolvedAt != nil {
+		alert.ResolvedAt = a.ResolvedAt.Unix()
+	}
+
+	return alert
+}
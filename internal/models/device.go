@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Префиксы адресов устройств не-FANET источников. Адреса FLARM, ICAO и OGN
+// занимают те же 24 бита, что и FANET адреса, поэтому хранятся с префиксом
+// типа адреса, как в позывных OGN (FLRDDA5BA): ключи Redis, валидация
+// и история таких устройств не пересекаются с FANET устройствами
+const (
+	DevicePrefixICAO   = "ICA" // ICAO адрес транспондера
+	DevicePrefixFLARM  = "FLR" // Адрес FLARM
+	DevicePrefixOGN    = "OGN" // Адрес OGN трекера
+	DevicePrefixRandom = "RND" // Случайный (скрытый) адрес
+)
+
+// devicePrefixes префиксы по старшему байту числового адреса, 0 - FANET
+var devicePrefixes = [...]string{"", DevicePrefixICAO, DevicePrefixFLARM, DevicePrefixOGN, DevicePrefixRandom}
+
+// NamespacedDeviceID возвращает ID устройства не-FANET источника: префикс и 6 hex цифр адреса
+func NamespacedDeviceID(prefix string, addr uint32) string {
+	return fmt.Sprintf("%s%06X", prefix, addr&0xFFFFFF)
+}
+
+// DeviceAddr возвращает числовой адрес устройства для protobuf и MySQL.
+// FANET адрес передается как есть, у адресов других источников в старшем
+// байте номер префикса
+func DeviceAddr(deviceID string) (uint32, error) {
+	for namespace, prefix := range devicePrefixes {
+		if namespace == 0 || !strings.HasPrefix(deviceID, prefix) {
+			continue
+		}
+		addr, err := strconv.ParseUint(deviceID[len(prefix):], 16, 24)
+		if err != nil {
+			return 0, fmt.Errorf("invalid device id %q", deviceID)
+		}
+		return uint32(namespace)<<24 | uint32(addr), nil
+	}
+
+	addr, err := strconv.ParseUint(deviceID, 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device id %q", deviceID)
+	}
+	return uint32(addr), nil
+}
+
+// DeviceIDFromAddr восстанавливает ID устройства из числового адреса DeviceAddr
+func DeviceIDFromAddr(addr uint32) string {
+	namespace := addr >> 24
+	if namespace == 0 || int(namespace) >= len(devicePrefixes) {
+		return fmt.Sprintf("%06X", addr)
+	}
+	return NamespacedDeviceID(devicePrefixes[namespace], addr)
+}
+
+// ParseDeviceID приводит адрес устройства из запроса к виду устройств в Redis.
+// Принимает FANET адрес (до 6 hex цифр), ID с префиксом источника (FLRDDA5BA)
+// или числовой адрес из protobuf в hex (02DDA5BA)
+func ParseDeviceID(value string) (string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return "", fmt.Errorf("invalid device id %q", value)
+	}
+
+	if len(value) == 8 {
+		if addr, err := strconv.ParseUint(value, 16, 32); err == nil && addr>>24 < uint64(len(devicePrefixes)) {
+			return DeviceIDFromAddr(uint32(addr)), nil
+		}
+	}
+	if len(value) > 6 && len(value) != 9 {
+		return "", fmt.Errorf("invalid device id %q", value)
+	}
+
+	addr, err := DeviceAddr(value)
+	if err != nil {
+		return "", err
+	}
+	return DeviceIDFromAddr(addr), nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// FlightStatus статус полета
+type FlightStatus string
+
+const (
+	FlightStatusActive FlightStatus = "active" // В воздухе
+	FlightStatusLanded FlightStatus = "landed" // Посадка определена по треку
+	FlightStatusLost   FlightStatus = "lost"   // Позиции перестали приходить в воздухе
+)
+
+// ToProto конвертирует статус в protobuf
+func (s FlightStatus) ToProto() pb.FlightStatus {
+	switch s {
+	case FlightStatusLanded:
+		return pb.FlightStatus_FLIGHT_STATUS_LANDED
+	case FlightStatusLost:
+		return pb.FlightStatus_FLIGHT_STATUS_LOST
+	default:
+		return pb.FlightStatus_FLIGHT_STATUS_ACTIVE
+	}
+}
+
+// Flight представляет один полет устройства: от взлета до посадки
+type Flight struct {
+	// Идентификация
+	ID       int64        `json:"id"`        // ID записи в MySQL (0, если MySQL недоступен)
+	DeviceID string       `json:"device_id"` // FANET адрес в hex формате
+	Type     PilotType    `json:"type"`      // Тип летательного аппарата
+	Status   FlightStatus `json:"status"`    // Статус полета
+
+	// Взлет и посадка
+	TakeoffTime time.Time  `json:"takeoff_time"`           // Время взлета
+	LandingTime *time.Time `json:"landing_time,omitempty"` // Время посадки (последней позиции для lost)
+	Takeoff     GeoPoint   `json:"takeoff"`                // Точка взлета
+	Landing     *GeoPoint  `json:"landing,omitempty"`      // Точка посадки (последняя позиция для lost)
+
+	// Статистика (models.Track)
+	Duration    int64   `json:"duration"`     // Продолжительность (с)
+	Distance    float64 `json:"distance"`     // Пройденное расстояние (км)
+	MaxAltitude int32   `json:"max_altitude"` // Максимальная высота (м)
+	MaxClimb    float32 `json:"max_climb"`    // Максимальная скороподъемность (м/с)
+}
+
+// IsActive проверяет, находится ли устройство в воздухе
+func (f *Flight) IsActive() bool {
+	return f.Status == FlightStatusActive
+}
+
+// ToProto конвертирует в protobuf
+func (f *Flight) ToProto() *pb.Flight {
+	addr, _ := DeviceAddr(f.DeviceID)
+
+	flight := &pb.Flight{
+		Id:          uint64(f.ID),
+		Addr:        uint32(addr),
+		Type:        pb.PilotType(f.Type),
+		Status:      f.Status.ToProto(),
+		TakeoffTime: f.TakeoffTime.Unix(),
+		Takeoff: &pb.GeoPoint{
+			Latitude:  f.Takeoff.Latitude,
+			Longitude: f.Takeoff.Longitude,
+			Altitude:  f.Takeoff.Altitude,
+		},
+		Duration:    uint32(f.Duration),
+		Distance:    float32(f.Distance),
+		MaxAltitude: f.MaxAltitude,
+		MaxClimb:    f.MaxClimb,
+	}
+
+	if f.LandingTime != nil {
+		flight.LandingTime = f.LandingTime.Unix()
+	}
+	if f.Landing != nil {
+		flight.Landing = &pb.GeoPoint{
+			Latitude:  f.Landing.Latitude,
+			Longitude: f.Landing.Longitude,
+			Altitude:  f.Landing.Altitude,
+		}
+	}
+
+	return flight
+}
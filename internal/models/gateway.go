@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Gateway состояние базовой станции FANET (приемника), определяется по chip_id топика MQTT
+type Gateway struct {
+	ChipID    string    `json:"chip_id"`    // ID базовой станции
+	Online    bool      `json:"online"`     // Станция присылала пакеты в пределах GATEWAY_OFFLINE_AFTER
+	FirstSeen time.Time `json:"first_seen"` // Время первого пакета
+	LastSeen  time.Time `json:"last_seen"`  // Время последнего пакета
+
+	// Трафик
+	Packets    uint64           `json:"packets"`     // Всего принятых пакетов
+	PacketRate float64          `json:"packet_rate"` // Пакетов в минуту за последние 15 минут
+	Types      map[uint8]uint64 `json:"types"`       // Количество пакетов по FANET типам
+
+	// Качество приема
+	RSSI SignalStats `json:"rssi"` // Распределение RSSI (dBm)
+	SNR  SignalStats `json:"snr"`  // Распределение SNR (dB)
+
+	// Покрытие, оценивается по принятым позициям (nil, пока позиций недостаточно)
+	Coverage *GatewayCoverage `json:"coverage,omitempty"`
+
+	// Принятые позиции, только в детальном ответе
+	Positions []HeardPosition `json:"positions,omitempty"`
+}
+
+// SignalStats распределение уровня сигнала
+type SignalStats struct {
+	Min     float64           `json:"min"`
+	Max     float64           `json:"max"`
+	Avg     float64           `json:"avg"`
+	Buckets []HistogramBucket `json:"buckets"` // Количество пакетов по интервалам
+}
+
+// HistogramBucket интервал распределения: значения меньше или равные Le
+type HistogramBucket struct {
+	Le    float64 `json:"le"`
+	Count uint64  `json:"count"`
+}
+
+// GatewayCoverage оценка зоны покрытия базовой станции.
+// Положение станции неизвестно и оценивается центром принятых позиций,
+// взвешенным по уровню сигнала (ближние пакеты принимаются сильнее)
+type GatewayCoverage struct {
+	Center        GeoPoint `json:"center"`          // Оценка положения станции
+	RadiusKM      float64  `json:"radius_km"`       // 95-й процентиль расстояния до принятых позиций
+	MaxDistanceKM float64  `json:"max_distance_km"` // Самая дальняя принятая позиция
+	Positions     int      `json:"positions"`       // Количество позиций в оценке
+}
+
+// HeardPosition позиция устройства, принятая базовой станцией
+type HeardPosition struct {
+	DeviceID  string    `json:"device_id"`
+	Latitude  float64   `json:"lat"`
+	Longitude float64   `json:"lon"`
+	RSSI      int16     `json:"rssi"`
+	SNR       int16     `json:"snr"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -163,7 +163,8 @@ func (b Bounds) MaxLon() float64 {
 // TrackGeoPoint представляет географическую точку с временной меткой для треков
 type TrackGeoPoint struct {
 	GeoPoint
-	Timestamp time.Time `json:"timestamp"`
+	PressureAltitude *int32    `json:"pressure_altitude,omitempty"` // Барометрическая высота (м), если известна
+	Timestamp        time.Time `json:"timestamp"`
 }
 
 // geohashStepSize возвращает приблизительный размер шага в градусах для заданной точности
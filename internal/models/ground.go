@@ -146,6 +146,11 @@ func (g *GroundObject) IsStale(maxAge time.Duration) bool {
 	return time.Since(g.LastUpdate) > maxAge
 }
 
+// IsEmergency проверяет, требуется ли объекту помощь
+func (g *GroundObject) IsEmergency() bool {
+	return g.Type.IsEmergency()
+}
+
 // GetColor возвращает цвет для отображения на карте (на основе типа)
 func (g *GroundObject) GetColor() string {
 	switch g.Type {
@@ -174,12 +179,15 @@ func (g *GroundObject) GetColor() string {
 
 // ToProto конвертирует GroundObject в protobuf
 func (g *GroundObject) ToProto() *pb.GroundObject {
+	addr, _ := DeviceAddr(g.DeviceID)
+
 	groundObject := &pb.GroundObject{
-		Addr:        0, // TODO: конвертировать DeviceID в uint32
+		Addr:        uint32(addr),
 		Name:        g.Name,
 		Type:        pb.GroundType(g.Type),
 		TrackOnline: g.TrackOnline,
 		LastUpdate:  g.LastUpdate.Unix(),
+		Emergency:   g.IsEmergency(),
 	}
 
 	if g.Position != nil {
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ThermalHeatmapFilter фильтр исторических термиков для тепловой карты
+type ThermalHeatmapFilter struct {
+	Bounds Bounds    // Область карты
+	From   time.Time // Начало периода
+	To     time.Time // Конец периода
+
+	// Время суток в Location, включительно. Диапазон через полночь (22-3) допустим.
+	// -1 - без ограничения
+	HourFrom int
+	HourTo   int
+	Location *time.Location
+
+	Months []time.Month // Месяцы (пусто - все)
+
+	// Сектор направления ветра в градусах по часовой стрелке (WindFrom > WindTo -
+	// сектор через север). nil - без ограничения
+	WindFrom *uint16
+	WindTo   *uint16
+
+	MinClimb float32 // Минимальная скороподъемность (м/с)
+}
+
+// HeatmapCell ячейка тепловой карты термиков (geohash)
+type HeatmapCell struct {
+	Geohash     string   `json:"geohash"`
+	Center      GeoPoint `json:"center"`       // Центр ячейки
+	Bounds      Bounds   `json:"bounds"`       // Границы ячейки
+	Count       int      `json:"count"`        // Количество термиков
+	Pilots      int      `json:"pilots"`       // Количество разных устройств, сообщивших термики
+	AvgClimb    float32  `json:"avg_climb"`    // Средняя скороподъемность (м/с)
+	MaxClimb    float32  `json:"max_climb"`    // Максимальная скороподъемность (м/с)
+	AvgAltitude int32    `json:"avg_altitude"` // Средняя высота (м)
+	Days        int      `json:"days"`         // Количество разных дней с термиками (повторяемость)
+}
+
+// ThermalHeatmap агрегированная тепловая карта термиков
+type ThermalHeatmap struct {
+	Precision int           `json:"precision"` // Точность geohash ячеек
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Thermals  int           `json:"thermals"`  // Термиков после фильтрации
+	Truncated bool          `json:"truncated"` // Выборка из MySQL ограничена лимитом
+	Cells     []HeatmapCell `json:"cells"`     // Ячейки по убыванию количества
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// LandmarkType тип геометрии ориентира (согласно FANET спецификации, Type 5)
+type LandmarkType uint8
+
+const (
+	LandmarkTypeText         LandmarkType = 0 // Текстовая метка
+	LandmarkTypeLine         LandmarkType = 1 // Линия
+	LandmarkTypeArrow        LandmarkType = 2 // Стрелка
+	LandmarkTypeArea         LandmarkType = 3 // Область
+	LandmarkTypeAreaFilled   LandmarkType = 4 // Закрашенная область
+	LandmarkTypeCircle       LandmarkType = 5 // Круг
+	LandmarkTypeCircleFilled LandmarkType = 6 // Закрашенный круг
+)
+
+// String возвращает строковое представление типа
+func (t LandmarkType) String() string {
+	switch t {
+	case LandmarkTypeText:
+		return "text"
+	case LandmarkTypeLine:
+		return "line"
+	case LandmarkTypeArrow:
+		return "arrow"
+	case LandmarkTypeArea:
+		return "area"
+	case LandmarkTypeAreaFilled:
+		return "area_filled"
+	case LandmarkTypeCircle:
+		return "circle"
+	case LandmarkTypeCircleFilled:
+		return "circle_filled"
+	default:
+		return "unknown"
+	}
+}
+
+// IsArea проверяет, описывает ли тип замкнутую область
+func (t LandmarkType) IsArea() bool {
+	return t == LandmarkTypeArea || t == LandmarkTypeAreaFilled
+}
+
+// IsCircle проверяет, описывает ли тип круг
+func (t LandmarkType) IsCircle() bool {
+	return t == LandmarkTypeCircle || t == LandmarkTypeCircleFilled
+}
+
+// LandmarkLayer слой ориентира (согласно FANET спецификации)
+type LandmarkLayer uint8
+
+const (
+	LandmarkLayerInfo           LandmarkLayer = 0  // Информация
+	LandmarkLayerWarning        LandmarkLayer = 1  // Предупреждение
+	LandmarkLayerKeepOut        LandmarkLayer = 2  // Запретная зона
+	LandmarkLayerTouchDown      LandmarkLayer = 3  // Зона посадки
+	LandmarkLayerNoAirspaceWarn LandmarkLayer = 4  // Без предупреждений о воздушном пространстве
+	LandmarkLayerDontCare       LandmarkLayer = 15 // Без значения
+)
+
+// String возвращает строковое представление слоя
+func (l LandmarkLayer) String() string {
+	switch l {
+	case LandmarkLayerInfo:
+		return "info"
+	case LandmarkLayerWarning:
+		return "warning"
+	case LandmarkLayerKeepOut:
+		return "keep_out"
+	case LandmarkLayerTouchDown:
+		return "touch_down"
+	case LandmarkLayerNoAirspaceWarn:
+		return "no_airspace_warn"
+	case LandmarkLayerDontCare:
+		return "dont_care"
+	default:
+		return "unknown"
+	}
+}
+
+// Landmark представляет ориентир или зону (FANET Type 5)
+type Landmark struct {
+	// Идентификация
+	ID         string `json:"id"`          // Уникальный ID ориентира
+	ReportedBy string `json:"reported_by"` // Device ID отправителя
+
+	// Геометрия
+	Type   LandmarkType  `json:"type"`            // Тип геометрии
+	Layer  LandmarkLayer `json:"layer"`           // Слой
+	Points []GeoPoint    `json:"points"`          // Точки геометрии
+	Radii  []float64     `json:"radii,omitempty"` // Радиусы кругов в метрах (по одному на точку)
+
+	// Содержимое
+	Text        string `json:"text,omitempty"`         // Текст (для текстовых меток)
+	WindSectors uint8  `json:"wind_sectors,omitempty"` // Маска секторов ветра (0 = всегда активен)
+
+	// Метаданные
+	Timestamp time.Time `json:"timestamp"`  // Время получения
+	ExpiresAt time.Time `json:"expires_at"` // Время истечения
+}
+
+// GetID возвращает уникальный идентификатор для geo.Object
+func (l *Landmark) GetID() string {
+	return l.ID
+}
+
+// GetLatitude возвращает широту центра для geo.Object
+func (l *Landmark) GetLatitude() float64 {
+	return l.Center().Latitude
+}
+
+// GetLongitude возвращает долготу центра для geo.Object
+func (l *Landmark) GetLongitude() float64 {
+	return l.Center().Longitude
+}
+
+// GetTimestamp возвращает время получения для geo.Object
+func (l *Landmark) GetTimestamp() time.Time {
+	return l.Timestamp
+}
+
+// Center возвращает центр геометрии (среднее по точкам), используется для GEO индекса
+func (l *Landmark) Center() GeoPoint {
+	if len(l.Points) == 0 {
+		return GeoPoint{}
+	}
+
+	var lat, lon float64
+	for _, p := range l.Points {
+		lat += p.Latitude
+		lon += p.Longitude
+	}
+
+	n := float64(len(l.Points))
+	return GeoPoint{Latitude: lat / n, Longitude: lon / n}
+}
+
+// Validate проверяет корректность ориентира
+func (l *Landmark) Validate() error {
+	if l.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	if l.Type > LandmarkTypeCircleFilled {
+		return fmt.Errorf("invalid landmark type: %d", l.Type)
+	}
+
+	minPoints := 1
+	switch {
+	case l.Type == LandmarkTypeLine || l.Type == LandmarkTypeArrow:
+		minPoints = 2
+	case l.Type.IsArea():
+		minPoints = 3
+	}
+	if len(l.Points) < minPoints {
+		return fmt.Errorf("landmark type %s requires at least %d points, got %d", l.Type, minPoints, len(l.Points))
+	}
+
+	for i, p := range l.Points {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("point %d: %w", i, err)
+		}
+	}
+
+	if l.Type.IsCircle() && len(l.Radii) != len(l.Points) {
+		return fmt.Errorf("circle landmark requires radius for each point")
+	}
+
+	return nil
+}
+
+// IsExpired проверяет, истекло ли время жизни ориентира
+func (l *Landmark) IsExpired() bool {
+	return !l.ExpiresAt.IsZero() && time.Now().After(l.ExpiresAt)
+}
+
+// ToProto конвертирует Landmark в protobuf
+func (l *Landmark) ToProto() *pb.Landmark {
+	addr, _ := DeviceAddr(l.ReportedBy)
+
+	landmark := &pb.Landmark{
+		Id:          l.ID,
+		Addr:        uint32(addr),
+		Type:        pb.LandmarkType(l.Type),
+		Layer:       pb.LandmarkLayer(l.Layer),
+		Points:      make([]*pb.GeoPoint, 0, len(l.Points)),
+		Text:        l.Text,
+		WindSectors: uint32(l.WindSectors),
+		Timestamp:   l.Timestamp.Unix(),
+		ExpiresAt:   l.ExpiresAt.Unix(),
+	}
+
+	for _, p := range l.Points {
+		landmark.Points = append(landmark.Points, &pb.GeoPoint{
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Altitude:  p.Altitude,
+		})
+	}
+
+	for _, r := range l.Radii {
+		landmark.Radii = append(landmark.Radii, float32(r))
+	}
+
+	return landmark
+}
+
+// GenerateLandmarkID генерирует ID ориентира из отправителя и геометрии.
+// Повторная передача того же ориентира обновляет существующую запись
+func GenerateLandmarkID(reportedBy string, landmarkType LandmarkType, layer LandmarkLayer, points []GeoPoint, text string) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%d:", landmarkType, layer)
+	for _, p := range points {
+		fmt.Fprintf(h, "%.5f,%.5f;", p.Latitude, p.Longitude)
+	}
+	h.Write([]byte(text))
+
+	return fmt.Sprintf("%s_%08x", reportedBy, h.Sum32())
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// Message представляет текстовое сообщение (FANET Type 3)
+type Message struct {
+	// Идентификация
+	ID   string `json:"id"`           // Уникальный ID сообщения
+	From string `json:"from"`         // Device ID отправителя
+	To   string `json:"to,omitempty"` // Device ID получателя (пусто для broadcast)
+
+	// Содержимое
+	Subheader uint8  `json:"subheader"` // Подзаголовок сообщения (0 = обычное сообщение)
+	Text      string `json:"text"`      // Текст сообщения
+
+	// Позиция
+	Position *GeoPoint `json:"position"` // Последняя известная позиция отправителя
+
+	// Метаданные
+	Timestamp time.Time `json:"timestamp"` // Время получения
+}
+
+// GetID возвращает уникальный идентификатор для geo.Object
+func (m *Message) GetID() string {
+	return m.ID
+}
+
+// GetLatitude возвращает широту для geo.Object
+func (m *Message) GetLatitude() float64 {
+	if m.Position != nil {
+		return m.Position.Latitude
+	}
+	return 0
+}
+
+// GetLongitude возвращает долготу для geo.Object
+func (m *Message) GetLongitude() float64 {
+	if m.Position != nil {
+		return m.Position.Longitude
+	}
+	return 0
+}
+
+// GetTimestamp возвращает время сообщения для geo.Object
+func (m *Message) GetTimestamp() time.Time {
+	return m.Timestamp
+}
+
+// IsBroadcast проверяет, является ли сообщение широковещательным
+func (m *Message) IsBroadcast() bool {
+	return m.To == ""
+}
+
+// Validate проверяет корректность сообщения
+func (m *Message) Validate() error {
+	if m.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	if m.From == "" {
+		return fmt.Errorf("from is required")
+	}
+
+	if m.Text == "" {
+		return fmt.Errorf("text is required")
+	}
+
+	if m.Position != nil {
+		if err := m.Position.Validate(); err != nil {
+			return fmt.Errorf("position: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// IsStale проверяет, устарело ли сообщение
+func (m *Message) IsStale(maxAge time.Duration) bool {
+	return time.Since(m.Timestamp) > maxAge
+}
+
+// ToProto конвертирует Message в protobuf
+func (m *Message) ToProto() *pb.Message {
+	from, _ := DeviceAddr(m.From)
+	to, _ := DeviceAddr(m.To)
+
+	message := &pb.Message{
+		Id:        m.ID,
+		FromAddr:  uint32(from),
+		ToAddr:    uint32(to),
+		Broadcast: m.IsBroadcast(),
+		Subheader: uint32(m.Subheader),
+		Text:      m.Text,
+		Timestamp: m.Timestamp.Unix(),
+	}
+
+	if m.Position != nil {
+		message.Position = &pb.GeoPoint{
+			Latitude:  m.Position.Latitude,
+			Longitude: m.Position.Longitude,
+			Altitude:  m.Position.Altitude,
+		}
+	}
+
+	return message
+}
+
+// PacketHash хеш исходных FANET данных пакета. Копии пакета от разных базовых
+// станций совпадают по данным, но не по времени станции
+func PacketHash(payload []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(payload)
+	return h.Sum64()
+}
+
+// GenerateMessageID генерирует ID сообщения из отправителя и хеша исходных данных пакета.
+// Одно и то же сообщение, принятое несколькими базовыми станциями, получает одинаковый ID,
+// повторно отправленный тот же текст заменяет прежнее сообщение
+func GenerateMessageID(from string, payload []byte) string {
+	return fmt.Sprintf("%s_%016x", from, PacketHash(payload))
+}
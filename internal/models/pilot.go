@@ -83,6 +83,12 @@ type Pilot struct {
 	LastMovement     *time.Time `json:"last_movement,omitempty"`     // Время последнего значимого движения
 	TrackingDistance float64    `json:"tracking_distance,omitempty"` // Расстояние от центра отслеживания
 	VisibilityStatus string     `json:"visibility_status,omitempty"` // Статус видимости: "visible", "boundary", "outside"
+
+	// Качество приема базовыми станциями (только для FANET через MQTT)
+	Reception *ReceptionStats `json:"reception,omitempty"`
+
+	// Сглаженное состояние и прогноз (service.KalmanSmoother)
+	Smoothed *PilotEstimate `json:"smoothed,omitempty"`
 }
 
 // GetID возвращает уникальный идентификатор для geo.Object
@@ -188,8 +194,11 @@ func (p *Pilot) GetColor() string {
 
 // ToProto конвертирует Pilot в protobuf
 func (p *Pilot) ToProto() *pb.Pilot {
+	// FANET адрес из hex строки DeviceID
+	addr, _ := DeviceAddr(p.DeviceID)
+
 	pilot := &pb.Pilot{
-		Addr:     0, // TODO: конвертировать DeviceID в uint32
+		Addr:     uint32(addr),
 		Name:     p.Name,
 		Type:     pb.PilotType(p.Type),
 		Speed:    p.Speed,
@@ -207,6 +216,13 @@ func (p *Pilot) ToProto() *pb.Pilot {
 			Altitude:  p.Position.Altitude,
 		}
 	}
+	if p.Reception != nil {
+		pilot.Receivers = uint32(p.Reception.Stations)
+		pilot.BestSnr = int32(p.Reception.BestSNR)
+	}
+	if p.Smoothed != nil {
+		pilot.Smoothed = p.Smoothed.ToProto()
+	}
 	
 	return pilot
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/pb"
+)
+
+// PilotEstimate состояние пилота, сглаженное фильтром Калмана по позициям,
+// скорости, курсу и вертикальной скорости из FANET пакетов
+type PilotEstimate struct {
+	Position    GeoPoint  `json:"position"`     // Сглаженные координаты на момент LastUpdate пилота
+	Speed       float32   `json:"speed"`        // Путевая скорость (км/ч)
+	Heading     float32   `json:"heading"`      // Курс (градусы)
+	Climb       float32   `json:"climb"`        // Вертикальная скорость (м/с)
+	Accuracy    float32   `json:"accuracy"`     // Погрешность позиции, 1 сигма (м)
+	Predicted   GeoPoint  `json:"predicted"`    // Прогноз позиции на PredictedAt
+	PredictedAt time.Time `json:"predicted_at"` // Время прогноза
+}
+
+// ToProto конвертирует PilotEstimate в protobuf
+func (e *PilotEstimate) ToProto() *pb.PilotEstimate {
+	return &pb.PilotEstimate{
+		Position: &pb.GeoPoint{
+			Latitude:  e.Position.Latitude,
+			Longitude: e.Position.Longitude,
+			Altitude:  e.Position.Altitude,
+		},
+		Speed:    e.Speed,
+		Course:   e.Heading,
+		Climb:    e.Climb,
+		Accuracy: e.Accuracy,
+		Predicted: &pb.GeoPoint{
+			Latitude:  e.Predicted.Latitude,
+			Longitude: e.Predicted.Longitude,
+			Altitude:  e.Predicted.Altitude,
+		},
+		PredictedAt: e.PredictedAt.Unix(),
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// GatewayReception прием пакетов устройства одной базовой станцией
+type GatewayReception struct {
+	ChipID    string    `json:"chip_id"`    // ID базовой станции
+	RSSI      int16     `json:"rssi"`       // Уровень сигнала последнего пакета (dBm)
+	SNR       int16     `json:"snr"`        // Signal-to-Noise Ratio последнего пакета (dB)
+	LastHeard time.Time `json:"last_heard"` // Время последнего приема
+}
+
+// ReceptionStats качество приема устройства: сколько станций его слышат
+type ReceptionStats struct {
+	Stations int                `json:"stations"`           // Количество станций за окно статистики
+	BestSNR  int16              `json:"best_snr"`           // Лучший SNR среди станций
+	BestRSSI int16              `json:"best_rssi"`          // Лучший RSSI среди станций
+	Gateways []GatewayReception `json:"gateways,omitempty"` // Станции, от лучшего SNR к худшему
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/flybeeper/fanet-backend/pkg/pb"
 )
 
+// Источники термиков
+const (
+	ThermalSourceFANET   = "fanet"   // FANET Type 9 от устройства
+	ThermalSourceDerived = "derived" // Обнаружен сервером по кружению пилотов
+)
+
 // Thermal представляет термический поток
 type Thermal struct {
 	// Идентификация
@@ -26,8 +32,10 @@ type Thermal struct {
 	WindDirection uint16 `json:"wind_direction"` // Направление ветра (градусы)
 
 	// Метаданные
-	Timestamp time.Time `json:"timestamp"` // Время создания
-	LastSeen  time.Time `json:"last_seen"` // Время последнего обновления
+	Timestamp  time.Time `json:"timestamp"`            // Время создания
+	LastSeen   time.Time `json:"last_seen"`            // Время последнего обновления
+	Source     string    `json:"source,omitempty"`     // ThermalSourceFANET или ThermalSourceDerived
+	Confidence float32   `json:"confidence,omitempty"` // Достоверность обнаружения 0-1 (для derived)
 }
 
 // GetID возвращает уникальный идентификатор для geo.Object
@@ -84,7 +92,7 @@ func (t *Thermal) Validate() error {
 	}
 
 	// Проверка скороподъемности (реалистичные значения)
-	if t.ClimbRate < -500 || t.ClimbRate > 2000 {
+	if t.ClimbRate < -5 || t.ClimbRate > 20 {
 		return fmt.Errorf("invalid climb rate: %f", t.ClimbRate)
 	}
 
@@ -108,7 +116,7 @@ func (t *Thermal) IsStale(maxAge time.Duration) bool {
 
 // IsStrong проверяет, является ли термик сильным
 func (t *Thermal) IsStrong() bool {
-	return t.Quality >= 4 || t.ClimbRate >= 300
+	return t.Quality >= 4 || t.ClimbRate >= 3
 }
 
 // GetQualityDescription возвращает описание качества термика
@@ -174,9 +182,9 @@ func (t *Thermal) FromRedisHash(id string, data map[string]string) error {
 	}
 
 	if climb, ok := data["climb_rate"]; ok {
-		var c int
-		fmt.Sscanf(climb, "%d", &c)
-		t.ClimbRate = float32(c)
+		var c float32
+		fmt.Sscanf(climb, "%g", &c)
+		t.ClimbRate = c
 	}
 
 	if windSpeed, ok := data["wind_speed"]; ok {
@@ -287,6 +295,8 @@ func (t *Thermal) ToProto() *pb.Thermal {
 		WindSpeed:   float32(t.WindSpeed),
 		WindHeading: float32(t.WindDirection),
 		Timestamp:   t.Timestamp.Unix(),
+		Source:      t.Source,
+		Confidence:  t.Confidence,
 	}
 	
 	if t.Position != nil {
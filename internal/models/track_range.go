@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrackRangeQuery запрос страницы трека за произвольный период
+type TrackRangeQuery struct {
+	From  time.Time    // Начало периода (включительно)
+	To    time.Time    // Конец периода (включительно)
+	After *TrackCursor // Позиция, после которой начинается страница (nil - с начала периода)
+	Limit int          // Размер страницы
+}
+
+// TrackPage страница трека, упорядоченная по времени
+type TrackPage struct {
+	Points []TrackGeoPoint
+	Next   *TrackCursor // Позиция последней точки страницы, nil - страница последняя
+}
+
+// TrackCursor позиция в треке для keyset пагинации: время и ID записи
+// (у нескольких точек может быть одинаковое время)
+type TrackCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// Encode возвращает непрозрачное строковое представление курсора для API
+func (c TrackCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.Timestamp.Unix(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseTrackCursor разбирает курсор, полученный из Encode
+func ParseTrackCursor(s string) (*TrackCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid track cursor: %w", err)
+	}
+
+	unix, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid track cursor")
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid track cursor time: %w", err)
+	}
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid track cursor id: %w", err)
+	}
+
+	return &TrackCursor{Timestamp: time.Unix(seconds, 0).UTC(), ID: rowID}, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackCursor_RoundTrip(t *testing.T) {
+	cursor := TrackCursor{
+		Timestamp: time.Date(2024, 6, 10, 11, 5, 30, 0, time.UTC),
+		ID:        123456789,
+	}
+
+	parsed, err := ParseTrackCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.Timestamp.Equal(parsed.Timestamp))
+	assert.Equal(t, cursor.ID, parsed.ID)
+}
+
+func TestParseTrackCursor_Invalid(t *testing.T) {
+	for _, value := range []string{"", "!!!", "MTIz", "YWJjOjE"} {
+		_, err := ParseTrackCursor(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -20,6 +20,7 @@ type Client struct {
 	logger    *utils.Logger
 	parser    *Parser
 	handler   MessageHandler
+	recorder  Recorder
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
@@ -30,6 +31,11 @@ type Client struct {
 // MessageHandler функция обработки входящих MQTT сообщений
 type MessageHandler func(msg *FANETMessage) error
 
+// Recorder сохраняет сырые MQTT сообщения до разбора (см. internal/capture)
+type Recorder interface {
+	Record(topic string, payload []byte, receivedAt time.Time) error
+}
+
 // NewClient создает новый MQTT клиент
 func NewClient(cfg *config.MQTTConfig, logger *utils.Logger, handler MessageHandler) (*Client, error) {
 	if cfg == nil {
@@ -161,84 +167,114 @@ func (c *Client) IsConnected() bool {
 	return c.connected && c.client.IsConnected()
 }
 
+// SetRecorder включает запись сырых сообщений. Вызывается до Connect
+func (c *Client) SetRecorder(recorder Recorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = recorder
+}
+
 // messageHandler создает обработчик MQTT сообщений
 func (c *Client) messageHandler() mqtt.MessageHandler {
 	return func(client mqtt.Client, msg mqtt.Message) {
+		// Запись до запуска горутины сохраняет порядок получения сообщений
+		c.mu.RLock()
+		recorder := c.recorder
+		c.mu.RUnlock()
+		if recorder != nil {
+			if err := recorder.Record(msg.Topic(), msg.Payload(), time.Now()); err != nil {
+				c.logger.WithField("error", err).Warn("Failed to record MQTT message")
+			}
+		}
+
+		// Обработчик конвейера ставит сообщение в очередь устройства: при OrderMatters
+		// сообщения передаются по одному в порядке получения, и backpressure очереди
+		// замедляет чтение из брокера. Иначе каждое сообщение обрабатывается
+		// в отдельной горутине, и порядок пакетов одного устройства не гарантирован
 		c.wg.Add(1)
+		if c.config.OrderMatters {
+			defer c.wg.Done()
+			c.process(msg)
+			return
+		}
 		go func() {
 			defer c.wg.Done()
-			
-			// Извлекаем информацию о топике
-			topic := msg.Topic()
-			payload := msg.Payload()
-			
-			// Базовое логирование
-			logFields := map[string]interface{}{
+			c.process(msg)
+		}()
+	}
+}
+
+// process разбирает MQTT сообщение и передает его обработчику
+func (c *Client) process(msg mqtt.Message) {
+	// Извлекаем информацию о топике
+	topic := msg.Topic()
+	payload := msg.Payload()
+	
+	// Базовое логирование
+	logFields := map[string]interface{}{
+		"topic": topic,
+		"payload_size": len(payload),
+		"qos": msg.Qos(),
+		"retained": msg.Retained(),
+	}
+	
+	// Детальное логирование с hex dump если включен debug режим
+	if c.config.DebugEnabled {
+		logFields["payload_hex"] = hex.EncodeToString(payload)
+		c.logger.WithFields(logFields).Info("Received MQTT message (DEBUG MODE)")
+	} else {
+		c.logger.WithFields(logFields).Debug("Received MQTT message")
+	}
+	
+	// Парсим FANET сообщение
+	fanetMsg, err := c.parser.Parse(topic, payload)
+	if err != nil {
+		errorFields := map[string]interface{}{
+			"topic": topic,
+			"error": err,
+			"payload_size": len(payload),
+		}
+		if c.config.DebugEnabled {
+			errorFields["payload_hex"] = hex.EncodeToString(payload)
+		}
+		c.logger.WithFields(errorFields).Error("Failed to parse FANET message")
+		metrics.MQTTParseErrors.Inc()
+		return
+	}
+	
+	if fanetMsg == nil {
+		// Сообщение не является валидным FANET пакетом или не поддерживается
+		c.logger.WithField("topic", topic).Debug("Skipping non-FANET or unsupported message")
+		return
+	}
+	
+	// Передаем сообщение обработчику
+	if c.handler != nil {
+		if err := c.handler(fanetMsg); err != nil {
+			c.logger.WithFields(map[string]interface{}{
+				"topic": topic,
+				"message_type": fanetMsg.Type,
+				"device_id": fanetMsg.DeviceID,
+				"error": err,
+			}).Error("Message handler failed")
+		} else {
+			successFields := map[string]interface{}{
 				"topic": topic,
-				"payload_size": len(payload),
-				"qos": msg.Qos(),
-				"retained": msg.Retained(),
+				"message_type": fanetMsg.Type,
+				"device_id": fanetMsg.DeviceID,
 			}
-			
-			// Детальное логирование с hex dump если включен debug режим
 			if c.config.DebugEnabled {
-				logFields["payload_hex"] = hex.EncodeToString(payload)
-				c.logger.WithFields(logFields).Info("Received MQTT message (DEBUG MODE)")
-			} else {
-				c.logger.WithFields(logFields).Debug("Received MQTT message")
-			}
-			
-			// Парсим FANET сообщение
-			fanetMsg, err := c.parser.Parse(topic, payload)
-			if err != nil {
-				errorFields := map[string]interface{}{
-					"topic": topic,
-					"error": err,
-					"payload_size": len(payload),
-				}
-				if c.config.DebugEnabled {
-					errorFields["payload_hex"] = hex.EncodeToString(payload)
-				}
-				c.logger.WithFields(errorFields).Error("Failed to parse FANET message")
-				metrics.MQTTParseErrors.Inc()
-				return
-			}
-			
-			if fanetMsg == nil {
-				// Сообщение не является валидным FANET пакетом или не поддерживается
-				c.logger.WithField("topic", topic).Debug("Skipping non-FANET or unsupported message")
-				return
-			}
-			
-			// Передаем сообщение обработчику
-			if c.handler != nil {
-				if err := c.handler(fanetMsg); err != nil {
-					c.logger.WithFields(map[string]interface{}{
-						"topic": topic,
-						"message_type": fanetMsg.Type,
-						"device_id": fanetMsg.DeviceID,
-						"error": err,
-					}).Error("Message handler failed")
-				} else {
-					successFields := map[string]interface{}{
-						"topic": topic,
-						"message_type": fanetMsg.Type,
-						"device_id": fanetMsg.DeviceID,
-					}
-					if c.config.DebugEnabled {
-						successFields["timestamp"] = fanetMsg.Timestamp
-						successFields["rssi"] = fanetMsg.RSSI
-						successFields["snr"] = fanetMsg.SNR
-					}
-					c.logger.WithFields(successFields).Debug("Successfully processed FANET message")
-					// Увеличиваем счетчик по типу пакета
-					packetType := fmt.Sprintf("%d", fanetMsg.Type)
-					metrics.MQTTMessagesReceived.WithLabelValues(packetType).Inc()
-				}
-			} else {
-				c.logger.WithField("topic", topic).Warn("Message handler is nil")
+				successFields["timestamp"] = fanetMsg.Timestamp
+				successFields["rssi"] = fanetMsg.RSSI
+				successFields["snr"] = fanetMsg.SNR
 			}
-		}()
+			c.logger.WithFields(successFields).Debug("Successfully processed FANET message")
+			// Увеличиваем счетчик по типу пакета
+			packetType := fmt.Sprintf("%d", fanetMsg.Type)
+			metrics.MQTTMessagesReceived.WithLabelValues(packetType).Inc()
+		}
+	} else {
+		c.logger.WithField("topic", topic).Warn("Message handler is nil")
 	}
 }
 
@@ -0,0 +1,64 @@
+package mqtt
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/config"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMessage реализует paho mqtt.Message
+type testMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *testMessage) Duplicate() bool   { return false }
+func (m *testMessage) Qos() byte         { return 0 }
+func (m *testMessage) Retained() bool    { return false }
+func (m *testMessage) Topic() string     { return m.topic }
+func (m *testMessage) MessageID() uint16 { return 0 }
+func (m *testMessage) Payload() []byte   { return m.payload }
+func (m *testMessage) Ack()              {}
+
+// nameMessage создает MQTT сообщение с FANET пакетом имени (Type 2)
+func nameMessage(t *testing.T, name string) *testMessage {
+	encoded, err := NewEncoder().Encode(&Frame{Type: 2, Source: "0A1B2C", Data: &NameData{Name: name}})
+	require.NoError(t, err)
+	return &testMessage{topic: "fb/b/ABC123/f/2", payload: wrapUplink(encoded)}
+}
+
+func TestClient_OrderedDelivery(t *testing.T) {
+	var mu sync.Mutex
+	var names []string
+	handler := func(msg *FANETMessage) error {
+		// Медленная обработка первых сообщений обогнала бы следующие без упорядочивания
+		if len(msg.Data.(*NameData).Name) == 1 {
+			time.Sleep(time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, msg.Data.(*NameData).Name)
+		return nil
+	}
+
+	client, err := NewClient(&config.MQTTConfig{URL: "tcp://localhost:1883", OrderMatters: true}, utils.NewLogger("error", "text"), handler)
+	require.NoError(t, err)
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("%d", i)
+		want = append(want, name)
+		client.messageHandler()(nil, nameMessage(t, name))
+	}
+
+	// Сообщение обработано до возврата из обработчика paho
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, want, names)
+}
@@ -0,0 +1,288 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Frame описывает исходящий FANET пакет (заголовок + адрес + полезная нагрузка)
+type Frame struct {
+	Type        uint8       `json:"type"`                  // Тип пакета (1, 2, 3, 4, 6)
+	Source      string      `json:"source"`                // Адрес отправителя (24-bit hex)
+	Destination string      `json:"destination,omitempty"` // Адрес получателя (24-bit hex), пусто для broadcast
+	Forward     bool        `json:"forward,omitempty"`     // Разрешить ретрансляцию (bit 6 заголовка)
+	Data        interface{} `json:"data"`                  // *AirTrackingData, *NameData, *MessageData, *ServiceData или *RemoteConfigData
+}
+
+// Encoder кодирует FANET пакеты. Формат зеркален Parser: результат Encode,
+// обернутый заголовком базовой станции, декодируется Parser.Parse
+type Encoder struct{}
+
+// NewEncoder создает новый кодировщик FANET пакетов
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// Encode кодирует FANET пакет: заголовок (1 байт), адрес источника (3 байта),
+// расширенный заголовок для unicast пакетов и полезную нагрузку.
+// Адресными могут быть только сообщения (Type 3) и удаленная конфигурация (Type 6):
+// Parser разбирает расширенный заголовок только для них
+func (e *Encoder) Encode(frame *Frame) ([]byte, error) {
+	if frame == nil {
+		return nil, fmt.Errorf("frame is nil")
+	}
+	if frame.Type > 0x3F {
+		return nil, fmt.Errorf("invalid FANET type: %d", frame.Type)
+	}
+
+	source, err := ParseAddress(frame.Source)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+
+	destination := frame.Destination
+	// Для сообщений адрес получателя может быть задан в самом сообщении
+	if msg, ok := frame.Data.(*MessageData); ok && destination == "" && msg.Unicast {
+		destination = msg.Destination
+	}
+	if destination != "" && frame.Type != 3 && frame.Type != 6 {
+		return nil, fmt.Errorf("destination is not supported for FANET type %d", frame.Type)
+	}
+
+	payload, err := e.encodePayload(frame.Type, frame.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	header := frame.Type & 0x3F
+	if frame.Forward {
+		header |= 0x40
+	}
+
+	packet := make([]byte, 0, 8+len(payload))
+	packet = append(packet, header)
+	packet = appendAddress(packet, source)
+
+	if destination != "" {
+		destAddr, err := ParseAddress(destination)
+		if err != nil {
+			return nil, fmt.Errorf("destination: %w", err)
+		}
+		// Bit 7: extended header, в нем bit 5: unicast
+		packet[0] |= 0x80
+		packet = append(packet, 0x20)
+		packet = appendAddress(packet, destAddr)
+	}
+
+	return append(packet, payload...), nil
+}
+
+// encodePayload выбирает кодировщик полезной нагрузки по типу пакета
+func (e *Encoder) encodePayload(msgType uint8, data interface{}) ([]byte, error) {
+	switch msgType {
+	case 1:
+		if d, ok := data.(*AirTrackingData); ok && d != nil {
+			return e.EncodeAirTracking(d)
+		}
+	case 2:
+		if d, ok := data.(*NameData); ok && d != nil {
+			return e.EncodeName(d)
+		}
+	case 3:
+		if d, ok := data.(*MessageData); ok && d != nil {
+			return e.EncodeMessage(d)
+		}
+	case 4:
+		if d, ok := data.(*ServiceData); ok && d != nil {
+			return e.EncodeService(d)
+		}
+	case 6:
+		if d, ok := data.(*RemoteConfigData); ok && d != nil {
+			return e.EncodeRemoteConfig(d)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported FANET type for encoding: %d", msgType)
+	}
+
+	return nil, fmt.Errorf("data %T does not match FANET type %d", data, msgType)
+}
+
+// EncodeAirTracking кодирует данные отслеживания в воздухе (Type 1), обратно parseAirTracking
+func (e *Encoder) EncodeAirTracking(d *AirTrackingData) ([]byte, error) {
+	if !validCoordinates(d.Latitude, d.Longitude) {
+		return nil, fmt.Errorf("invalid coordinates: %f, %f", d.Latitude, d.Longitude)
+	}
+	if d.AircraftType > 7 {
+		return nil, fmt.Errorf("invalid aircraft type: %d", d.AircraftType)
+	}
+
+	data := make([]byte, 11)
+	encodeCoordinates(data[0:6], d.Latitude, d.Longitude)
+
+	// Alt_status: bit 15 - онлайн трекинг, bits 14-12 - тип ВС, bit 11 - масштаб 4x, bits 10-0 - высота
+	altitude := d.Altitude
+	if altitude < 0 {
+		altitude = 0
+	}
+	var altStatus uint16
+	if altitude > 0x7FF {
+		altStatus = 0x0800 | uint16(min(int(altitude+2)/4, 0x7FF))
+	} else {
+		altStatus = uint16(altitude)
+	}
+	altStatus |= uint16(d.AircraftType&0x07) << 12
+	if d.OnlineTracking {
+		altStatus |= 0x8000
+	}
+	binary.LittleEndian.PutUint16(data[6:8], altStatus)
+
+	// Скорость: единицы 0.5 км/ч, 7 бит, bit 7 - масштаб 5x
+	speed := int(math.Round(float64(d.Speed) * 2))
+	if speed > 0x7F {
+		scaled := int(math.Round(float64(speed) / 5))
+		data[8] = 0x80 | byte(max(0, min(scaled, 0x7F)))
+	} else {
+		data[8] = byte(max(0, speed))
+	}
+
+	// Вертикальная скорость: единицы 0.1 м/с, signed 7-bit, bit 7 - масштаб 5x
+	climb := int(d.ClimbRate)
+	if climb < -64 || climb > 63 {
+		scaled := int(math.Round(float64(climb) / 5))
+		scaled = max(-64, min(scaled, 63))
+		data[9] = 0x80 | byte(scaled)&0x7F
+	} else {
+		data[9] = byte(climb) & 0x7F
+	}
+
+	// Курс: 0-255 соответствует 0-360 градусам
+	data[10] = byte(int(math.Round(float64(d.Heading%360)*256.0/360.0)) & 0xFF)
+
+	return data, nil
+}
+
+// EncodeName кодирует имя (Type 2)
+func (e *Encoder) EncodeName(d *NameData) ([]byte, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if len(d.Name) > 64 {
+		return nil, fmt.Errorf("name too long: %d bytes (max 64)", len(d.Name))
+	}
+
+	return []byte(d.Name), nil
+}
+
+// EncodeMessage кодирует текстовое сообщение (Type 3). Адрес получателя
+// кодируется в расширенном заголовке (см. Encode)
+func (e *Encoder) EncodeMessage(d *MessageData) ([]byte, error) {
+	if len(d.Text) > 200 {
+		return nil, fmt.Errorf("message too long: %d bytes (max 200)", len(d.Text))
+	}
+
+	data := make([]byte, 0, 1+len(d.Text))
+	data = append(data, d.Subheader)
+	return append(data, d.Text...), nil
+}
+
+// EncodeService кодирует сервисные данные (Type 4), обратно parseService.
+// Кодируются только поля, для которых установлены флаги в ServiceHeader
+func (e *Encoder) EncodeService(d *ServiceData) ([]byte, error) {
+	if !validCoordinates(d.Latitude, d.Longitude) {
+		return nil, fmt.Errorf("invalid coordinates: %f, %f", d.Latitude, d.Longitude)
+	}
+
+	data := make([]byte, 7, 16)
+	data[0] = d.ServiceHeader
+	encodeCoordinates(data[1:7], d.Latitude, d.Longitude)
+
+	weather, _ := d.Data.(*WeatherData)
+	if weather == nil {
+		if d.ServiceHeader&0x7A != 0 {
+			return nil, fmt.Errorf("service header 0x%02X requires weather data", d.ServiceHeader)
+		}
+		return data, nil
+	}
+
+	// Bit 6: температура, единицы 0.5 °C
+	if d.ServiceHeader&0x40 != 0 {
+		temp := math.Round(float64(weather.Temperature) * 2)
+		data = append(data, byte(int8(math.Max(-128, math.Min(temp, 127)))))
+	}
+
+	// Bit 5: ветер (направление, скорость, порывы в единицах 0.2 км/ч)
+	if d.ServiceHeader&0x20 != 0 {
+		data = append(data,
+			byte(int(math.Round(float64(weather.WindDirection%360)*256.0/360.0))&0xFF),
+			clampByte(math.Round(float64(weather.WindSpeed)/0.2)),
+			clampByte(math.Round(float64(weather.WindGusts)/0.2)),
+		)
+	}
+
+	// Bit 4: влажность
+	if d.ServiceHeader&0x10 != 0 {
+		data = append(data, clampByte(float64(weather.Humidity)*4))
+	}
+
+	// Bit 3: давление, (гПа - 430) * 10
+	if d.ServiceHeader&0x08 != 0 {
+		pressure := math.Round((float64(weather.Pressure) - 430.0) * 10)
+		data = binary.LittleEndian.AppendUint16(data, uint16(math.Max(0, math.Min(pressure, math.MaxUint16))))
+	}
+
+	// Bit 1: заряд батареи, 0x0-0xF
+	if d.ServiceHeader&0x02 != 0 {
+		data = append(data, byte(math.Round(float64(min(weather.Battery, 100))*15/100))&0x0F)
+	}
+
+	return data, nil
+}
+
+// EncodeRemoteConfig кодирует удаленную конфигурацию (Type 6). Адрес получателя
+// кодируется в расширенном заголовке (см. Encode)
+func (e *Encoder) EncodeRemoteConfig(d *RemoteConfigData) ([]byte, error) {
+	data := make([]byte, 0, 1+len(d.Value))
+	data = append(data, d.Subheader)
+	return append(data, d.Value...), nil
+}
+
+// ParseAddress разбирает 24-bit FANET адрес в hex формате (например "11AABB")
+func ParseAddress(addr string) (uint32, error) {
+	if addr == "" {
+		return 0, fmt.Errorf("address is empty")
+	}
+	value, err := strconv.ParseUint(addr, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if value > 0xFFFFFF {
+		return 0, fmt.Errorf("address %q exceeds 24 bits", addr)
+	}
+	return uint32(value), nil
+}
+
+// appendAddress добавляет 24-bit адрес в little-endian
+func appendAddress(data []byte, addr uint32) []byte {
+	return append(data, byte(addr), byte(addr>>8), byte(addr>>16))
+}
+
+// encodeCoordinates кодирует координаты в 6 байт (signed 24-bit, как в Type 1)
+func encodeCoordinates(dst []byte, lat, lon float64) {
+	latRaw := int32(math.Round(lat * 93206.04))
+	lonRaw := int32(math.Round(lon * 46603.02))
+
+	dst[0], dst[1], dst[2] = byte(latRaw), byte(latRaw>>8), byte(latRaw>>16)
+	dst[3], dst[4], dst[5] = byte(lonRaw), byte(lonRaw>>8), byte(lonRaw>>16)
+}
+
+// validCoordinates проверяет диапазон координат
+func validCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// clampByte ограничивает значение диапазоном байта
+func clampByte(v float64) byte {
+	return byte(math.Max(0, math.Min(v, 255)))
+}
@@ -0,0 +1,272 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrapUplink добавляет обертку базовой станции (timestamp, RSSI, SNR) к FANET пакету
+func wrapUplink(frame []byte) []byte {
+	payload := make([]byte, 8, 8+len(frame))
+	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+	binary.LittleEndian.PutUint16(payload[4:6], uint16(0xFFB0)) // -80 dBm
+	binary.LittleEndian.PutUint16(payload[6:8], 10)
+	return append(payload, frame...)
+}
+
+// roundTrip кодирует пакет и декодирует его парсером
+func roundTrip(t *testing.T, frame *Frame) *FANETMessage {
+	t.Helper()
+
+	encoded, err := NewEncoder().Encode(frame)
+	require.NoError(t, err)
+
+	parser := NewParser(utils.NewLogger("info", "text"))
+	msg, err := parser.Parse(fmt.Sprintf("fb/b/ABC123/f/%d", frame.Type), wrapUplink(encoded))
+	require.NoError(t, err)
+	require.NotNil(t, msg.Data, "parser did not decode payload")
+
+	assert.Equal(t, frame.Type, msg.Type)
+	assert.Equal(t, frame.Source, msg.DeviceID)
+	return msg
+}
+
+func TestEncoder_Header(t *testing.T) {
+	encoder := NewEncoder()
+
+	data, err := encoder.Encode(&Frame{
+		Type:    2,
+		Source:  "11AABB",
+		Forward: true,
+		Data:    &NameData{Name: "X"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x42, 0xBB, 0xAA, 0x11, 'X'}, data)
+
+	data, err = encoder.Encode(&Frame{
+		Type:        3,
+		Source:      "11AABB",
+		Destination: "223344",
+		Data:        &MessageData{Text: "hi"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x83, 0xBB, 0xAA, 0x11, 0x20, 0x44, 0x33, 0x22, 0x00, 'h', 'i'}, data)
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := encoder.Encode(&Frame{Type: 2, Source: "1000000", Data: &NameData{Name: "X"}})
+		assert.Error(t, err, "address exceeds 24 bits")
+
+		_, err = encoder.Encode(&Frame{Type: 2, Source: "ZZ", Data: &NameData{Name: "X"}})
+		assert.Error(t, err)
+
+		_, err = encoder.Encode(&Frame{Type: 1, Source: "11AABB", Data: &NameData{Name: "X"}})
+		assert.Error(t, err, "data type mismatch")
+
+		_, err = encoder.Encode(&Frame{Type: 7, Source: "11AABB", Data: &NameData{Name: "X"}})
+		assert.Error(t, err, "unsupported type")
+	})
+}
+
+func TestEncoder_Destination(t *testing.T) {
+	// Parser разбирает адрес получателя только для Type 3 и Type 6
+	tests := []struct {
+		name  string
+		frame Frame
+	}{
+		{name: "air tracking", frame: Frame{Type: 1, Data: &AirTrackingData{Latitude: 46.5, Longitude: 15.6, Altitude: 1000}}},
+		{name: "name", frame: Frame{Type: 2, Data: &NameData{Name: "Pilot"}}},
+		{name: "service", frame: Frame{Type: 4, Data: &ServiceData{Latitude: 46.5, Longitude: 15.6}}},
+		{name: "message", frame: Frame{Type: 3, Data: &MessageData{Text: "landed OK"}}},
+		{name: "remote config", frame: Frame{Type: 6, Data: &RemoteConfigData{Subheader: RemoteConfigAck}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := tt.frame
+			frame.Source = "0A1B2C"
+			frame.Destination = "DDEEFF"
+
+			if frame.Type != 3 && frame.Type != 6 {
+				_, err := NewEncoder().Encode(&frame)
+				assert.EqualError(t, err, fmt.Sprintf("destination is not supported for FANET type %d", frame.Type))
+
+				// Тот же пакет без адреса декодируется парсером
+				frame.Destination = ""
+				roundTrip(t, &frame)
+				return
+			}
+
+			msg := roundTrip(t, &frame)
+			switch parsed := msg.Data.(type) {
+			case *MessageData:
+				assert.True(t, parsed.Unicast)
+				assert.Equal(t, "DDEEFF", parsed.Destination)
+				assert.Equal(t, "landed OK", parsed.Text)
+			case *RemoteConfigData:
+				assert.Equal(t, "DDEEFF", parsed.Destination)
+				assert.Equal(t, RemoteConfigAck, parsed.Subheader)
+			default:
+				t.Fatalf("unexpected data %T", msg.Data)
+			}
+		})
+	}
+}
+
+func TestEncoder_AirTrackingSpeed(t *testing.T) {
+	tests := []struct {
+		speed uint16
+		want  byte
+	}{
+		{speed: 0, want: 0x00},
+		{speed: 35, want: 70},
+		{speed: 63, want: 126},
+		{speed: 64, want: 0x80 | 26}, // 128 / 5 = 25.6
+		{speed: 317, want: 0x80 | 0x7F},
+		{speed: 1000, want: 0x80 | 0x7F},
+	}
+
+	for _, tt := range tests {
+		data, err := NewEncoder().EncodeAirTracking(&AirTrackingData{Latitude: 46.5, Longitude: 15.6, Speed: tt.speed})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, data[8], "speed %d", tt.speed)
+	}
+}
+
+func TestEncoder_RoundTrip_AirTracking(t *testing.T) {
+	tests := []struct {
+		name string
+		data AirTrackingData
+	}{
+		{
+			name: "low altitude and speed",
+			data: AirTrackingData{
+				Latitude: 46.5, Longitude: 15.6, Altitude: 1250,
+				Speed: 36, Heading: 90, ClimbRate: 25,
+				AircraftType: 1, OnlineTracking: true,
+			},
+		},
+		{
+			name: "scaled altitude, speed and climb",
+			data: AirTrackingData{
+				Latitude: -33.25, Longitude: -70.5, Altitude: 4000,
+				Speed: 100, Heading: 180, ClimbRate: -150,
+				AircraftType: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			msg := roundTrip(t, &Frame{Type: 1, Source: "0A1B2C", Data: &data})
+
+			parsed, ok := msg.Data.(*AirTrackingData)
+			require.True(t, ok)
+			assert.InDelta(t, data.Latitude, parsed.Latitude, 0.0001)
+			assert.InDelta(t, data.Longitude, parsed.Longitude, 0.0001)
+			assert.InDelta(t, data.Altitude, parsed.Altitude, 4)
+			assert.InDelta(t, data.Speed, parsed.Speed, 3)
+			assert.InDelta(t, data.Heading, parsed.Heading, 2)
+			assert.InDelta(t, data.ClimbRate, parsed.ClimbRate, 5)
+			assert.Equal(t, data.AircraftType, parsed.AircraftType)
+			assert.Equal(t, data.OnlineTracking, parsed.OnlineTracking)
+		})
+	}
+}
+
+func TestEncoder_RoundTrip_Name(t *testing.T) {
+	msg := roundTrip(t, &Frame{Type: 2, Source: "0A1B2C", Data: &NameData{Name: "Пилот Иван"}})
+
+	parsed, ok := msg.Data.(*NameData)
+	require.True(t, ok)
+	assert.Equal(t, "Пилот Иван", parsed.Name)
+
+	_, err := NewEncoder().EncodeName(&NameData{Name: string(make([]byte, 65))})
+	assert.Error(t, err)
+}
+
+func TestEncoder_RoundTrip_Message(t *testing.T) {
+	t.Run("broadcast", func(t *testing.T) {
+		msg := roundTrip(t, &Frame{Type: 3, Source: "0A1B2C", Data: &MessageData{Text: "Landing at field"}})
+
+		parsed, ok := msg.Data.(*MessageData)
+		require.True(t, ok)
+		assert.False(t, parsed.Unicast)
+		assert.Equal(t, "Landing at field", parsed.Text)
+	})
+
+	t.Run("unicast from message destination", func(t *testing.T) {
+		msg := roundTrip(t, &Frame{Type: 3, Source: "0A1B2C", Data: &MessageData{
+			Subheader: 1, Unicast: true, Destination: "DDEEFF", Text: "ok",
+		}})
+
+		parsed, ok := msg.Data.(*MessageData)
+		require.True(t, ok)
+		assert.True(t, parsed.Unicast)
+		assert.Equal(t, "DDEEFF", parsed.Destination)
+		assert.Equal(t, uint8(1), parsed.Subheader)
+		assert.Equal(t, "ok", parsed.Text)
+	})
+}
+
+func TestEncoder_RoundTrip_Service(t *testing.T) {
+	weather := &WeatherData{
+		Temperature:   12.5,
+		WindDirection: 270,
+		WindSpeed:     18.4,
+		WindGusts:     25.2,
+		Humidity:      40,
+		Pressure:      1013.2,
+		Battery:       80,
+	}
+	msg := roundTrip(t, &Frame{Type: 4, Source: "0A1B2C", Data: &ServiceData{
+		ServiceHeader: 0x40 | 0x20 | 0x10 | 0x08 | 0x02,
+		Latitude:      46.0569,
+		Longitude:     14.5058,
+		Data:          weather,
+	}})
+
+	parsed, ok := msg.Data.(*ServiceData)
+	require.True(t, ok)
+	assert.InDelta(t, 46.0569, parsed.Latitude, 0.0001)
+	assert.InDelta(t, 14.5058, parsed.Longitude, 0.0001)
+
+	parsedWeather, ok := parsed.Data.(*WeatherData)
+	require.True(t, ok)
+	assert.InDelta(t, weather.Temperature, parsedWeather.Temperature, 0.5)
+	assert.InDelta(t, weather.WindDirection, parsedWeather.WindDirection, 2)
+	assert.InDelta(t, weather.WindSpeed, parsedWeather.WindSpeed, 0.2)
+	assert.InDelta(t, weather.WindGusts, parsedWeather.WindGusts, 0.2)
+	assert.Equal(t, weather.Humidity, parsedWeather.Humidity)
+	assert.InDelta(t, weather.Pressure, parsedWeather.Pressure, 0.1)
+	assert.InDelta(t, weather.Battery, parsedWeather.Battery, 7)
+}
+
+func TestEncoder_RoundTrip_RemoteConfig(t *testing.T) {
+	msg := roundTrip(t, &Frame{Type: 6, Source: "FB0001", Destination: "0A1B2C", Data: &RemoteConfigData{
+		Subheader: RemoteConfigPosition,
+		Value:     []byte{0x01, 0x02, 0x03},
+	}})
+
+	parsed, ok := msg.Data.(*RemoteConfigData)
+	require.True(t, ok)
+	assert.Equal(t, RemoteConfigPosition, parsed.Subheader)
+	assert.Equal(t, "0A1B2C", parsed.Destination)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, parsed.Value)
+
+	t.Run("broadcast ack without value", func(t *testing.T) {
+		msg := roundTrip(t, &Frame{Type: 6, Source: "0A1B2C", Data: &RemoteConfigData{Subheader: RemoteConfigAck}})
+
+		parsed, ok := msg.Data.(*RemoteConfigData)
+		require.True(t, ok)
+		assert.Equal(t, RemoteConfigAck, parsed.Subheader)
+		assert.Empty(t, parsed.Destination)
+		assert.Empty(t, parsed.Value)
+	})
+}
@@ -13,9 +13,10 @@ import (
 
 // FANETMessage представляет распарсенное FANET сообщение
 type FANETMessage struct {
-	Type        uint8               `json:"type"`         // Тип сообщения (0=ACK, 1=Air tracking, 2=Name, 4=Service, 7=Ground tracking, 8=HW Info, 9=Thermal)
+	Type        uint8               `json:"type"`         // Тип сообщения (0=ACK, 1=Air tracking, 2=Name, 3=Message, 4=Service, 5=Landmarks, 6=Remote config, 7=Ground tracking, 8=HW Info, 9=Thermal)
 	DeviceID    string              `json:"device_id"`    // ID устройства (24-bit адрес)
 	ChipID      string              `json:"chip_id"`      // ID базовой станции (из топика)
+	Source      string              `json:"source,omitempty"` // Источник данных (mqtt, ogn, nmea)
 	PacketType  string              `json:"packet_type"`  // Тип пакета из топика для дополнительной валидации
 	Timestamp   time.Time           `json:"timestamp"`    // Время от базовой станции
 	RSSI        int16               `json:"rssi"`         // Уровень сигнала (dBm)
@@ -42,6 +43,14 @@ type NameData struct {
 	Name string `json:"name"` // Имя пилота/устройства (UTF-8, max 64 символа)
 }
 
+// MessageData текстовое сообщение (Type 3)
+type MessageData struct {
+	Subheader   uint8  `json:"subheader"`             // Подзаголовок сообщения (0 = обычное сообщение)
+	Unicast     bool   `json:"unicast"`               // Адресное сообщение (адрес получателя в extended header)
+	Destination string `json:"destination,omitempty"` // Адрес получателя для unicast (24-bit hex)
+	Text        string `json:"text"`                  // Текст сообщения (UTF-8)
+}
+
 // ServiceData данные сервиса/погоды (Type 4)
 type ServiceData struct {
 	ServiceHeader uint8       `json:"service_header"` // Битовые флаги сервиса
@@ -61,13 +70,45 @@ type WeatherData struct {
 	Battery       uint8   `json:"battery"`        // Заряд батареи в % (если флаг bit 1)
 }
 
-// GroundTrackingData данные наземного отслеживания (Type 7)
-type GroundTrackingData struct {
+// LandmarkData данные ориентира/зоны (Type 5)
+type LandmarkData struct {
+	Subtype     uint8           `json:"subtype"`          // Тип геометрии (0=Text, 1=Line, 2=Arrow, 3=Area, 4=Area filled, 5=Circle, 6=Circle filled)
+	Layer       uint8           `json:"layer"`            // Слой (0=Info, 1=Warning, 2=Keep out, 3=Touch down, 4=No airspace warn, 15=Don't care)
+	TTLMinutes  uint16          `json:"ttl_minutes"`      // Время жизни в минутах
+	WindSectors uint8           `json:"wind_sectors"`     // Битовая маска секторов ветра (0 = без зависимости от ветра)
+	Points      []LandmarkPoint `json:"points"`           // Точки геометрии
+	Radii       []uint32        `json:"radii,omitempty"`  // Радиусы кругов в метрах (по одному на точку для Circle)
+	Text        string          `json:"text,omitempty"`   // Текст (для Text)
+}
+
+// LandmarkPoint точка геометрии ориентира
+type LandmarkPoint struct {
 	Latitude  float64 `json:"latitude"`  // Широта
 	Longitude float64 `json:"longitude"` // Долгота
-	Altitude  int32   `json:"altitude"`  // Высота в метрах
-	Speed     uint16  `json:"speed"`     // Скорость в км/ч
-	Heading   uint16  `json:"heading"`   // Направление в градусах
+}
+
+// RemoteConfigData удаленная конфигурация устройства (Type 6)
+type RemoteConfigData struct {
+	Subheader   uint8  `json:"subheader"`             // Ключ конфигурации (0x00 = подтверждение)
+	Destination string `json:"destination,omitempty"` // Адрес получателя для unicast (24-bit hex)
+	Value       []byte `json:"value,omitempty"`       // Значение, формат зависит от ключа
+}
+
+// Ключи удаленной конфигурации (Type 6)
+const (
+	RemoteConfigAck      uint8 = 0x00 // Подтверждение конфигурации (value - подтверждаемый ключ)
+	RemoteConfigPosition uint8 = 0x03 // Позиция устройства (координаты как в Type 1 + высота)
+)
+
+// GroundTrackingData данные наземного отслеживания (Type 7)
+type GroundTrackingData struct {
+	Latitude    float64 `json:"latitude"`     // Широта
+	Longitude   float64 `json:"longitude"`    // Долгота
+	GroundType  uint8   `json:"ground_type"`  // Тип наземного объекта (0-15)
+	TrackOnline bool    `json:"track_online"` // Онлайн трекинг
+	Altitude    int32   `json:"altitude"`     // Высота в метрах
+	Speed       uint16  `json:"speed"`        // Скорость в км/ч
+	Heading     uint16  `json:"heading"`      // Направление в градусах
 }
 
 // ThermalData данные термика (Type 9)
@@ -75,7 +116,7 @@ type ThermalData struct {
 	Latitude    float64 `json:"latitude"`     // Широта центра термика
 	Longitude   float64 `json:"longitude"`    // Долгота центра термика
 	Altitude    int32   `json:"altitude"`     // Высота термика в метрах
-	ClimbRate   int16   `json:"climb_rate"`   // Скорость подъема в м/с * 10
+	ClimbRate   float32 `json:"climb_rate"`   // Средняя скорость подъема в м/с
 	Strength    uint8   `json:"strength"`     // Сила термика (0-100)
 	Radius      uint16  `json:"radius"`       // Радиус термика в метрах
 }
@@ -191,6 +232,13 @@ func (p *Parser) Parse(topic string, payload []byte) (*FANETMessage, error) {
 				p.logger.WithField("error", err).WithField("device_id", deviceID).Warn("Failed to parse name data")
 			}
 			
+		case 3: // Message
+			if parsed, err := p.parseMessage(header, data); err == nil {
+				msg.Data = parsed
+			} else {
+				p.logger.WithField("error", err).WithField("device_id", deviceID).Warn("Failed to parse message data")
+			}
+			
 		case 4: // Service/Weather
 			if parsed, err := p.parseService(data); err == nil {
 				msg.Data = parsed
@@ -198,6 +246,20 @@ func (p *Parser) Parse(topic string, payload []byte) (*FANETMessage, error) {
 				p.logger.WithField("error", err).WithField("device_id", deviceID).Warn("Failed to parse service data")
 			}
 			
+		case 5: // Landmarks
+			if parsed, err := p.parseLandmark(data); err == nil {
+				msg.Data = parsed
+			} else {
+				p.logger.WithField("error", err).WithField("device_id", deviceID).Warn("Failed to parse landmark data")
+			}
+			
+		case 6: // Remote configuration
+			if parsed, err := p.parseRemoteConfig(header, data); err == nil {
+				msg.Data = parsed
+			} else {
+				p.logger.WithField("error", err).WithField("device_id", deviceID).Warn("Failed to parse remote config data")
+			}
+			
 		case 7: // Ground tracking
 			if parsed, err := p.parseGroundTracking(data); err == nil {
 				msg.Data = parsed
@@ -403,6 +465,83 @@ func (p *Parser) parseName(data []byte) (*NameData, error) {
 	}, nil
 }
 
+// parseExtendedHeader разбирает расширенный заголовок FANET пакета.
+// Если в заголовке FANET установлен бит extended header (bit 7), то сразу после адреса
+// источника идет байт расширенного заголовка: bit 5 - unicast (далее 3 байта адреса
+// получателя), bit 4 - подпись (4 байта). Возвращает адрес получателя (пусто для
+// широковещательных пакетов) и смещение начала полезной нагрузки.
+func parseExtendedHeader(header byte, data []byte) (string, int, error) {
+	if header&0x80 == 0 {
+		return "", 0, nil
+	}
+	
+	if len(data) < 1 {
+		return "", 0, fmt.Errorf("extended header missing")
+	}
+	extHeader := data[0]
+	offset := 1
+	destination := ""
+	
+	// Bit 5: адрес получателя (unicast)
+	if extHeader&0x20 != 0 {
+		if len(data) < offset+3 {
+			return "", 0, fmt.Errorf("destination address too short: %d bytes", len(data)-offset)
+		}
+		destAddr := uint32(data[offset]) | uint32(data[offset+1])<<8 | uint32(data[offset+2])<<16
+		destination = fmt.Sprintf("%06X", destAddr)
+		offset += 3
+	}
+	
+	// Bit 4: подпись (не проверяем, просто пропускаем)
+	if extHeader&0x10 != 0 {
+		offset += 4
+	}
+	
+	return destination, offset, nil
+}
+
+// parseMessage парсит текстовое сообщение (Type 3)
+// Без extended header сообщение широковещательное.
+func (p *Parser) parseMessage(header byte, data []byte) (*MessageData, error) {
+	destination, offset, err := parseExtendedHeader(header, data)
+	if err != nil {
+		return nil, fmt.Errorf("message %w", err)
+	}
+	
+	message := &MessageData{
+		Unicast:     destination != "",
+		Destination: destination,
+	}
+	
+	if len(data) < offset+1 {
+		return nil, fmt.Errorf("message data too short: %d bytes", len(data))
+	}
+	
+	// Subheader (1 байт): 0 = обычное сообщение
+	message.Subheader = data[offset]
+	
+	text := data[offset+1:]
+	if len(text) > 200 {
+		p.logger.WithField("length", len(text)).Warn("Message too long, truncating to 200 bytes")
+		text = text[:200]
+	}
+	
+	// Текст в UTF-8, убираем null-терминаторы и битые последовательности
+	message.Text = strings.ToValidUTF8(strings.TrimRight(string(text), "\x00"), "")
+	
+	if p.debugEnabled {
+		p.logger.WithFields(map[string]interface{}{
+			"raw_data_hex": hex.EncodeToString(data),
+			"subheader":    message.Subheader,
+			"unicast":      message.Unicast,
+			"destination":  message.Destination,
+			"text":         message.Text,
+		}).Info("Parsed message (DEBUG)")
+	}
+	
+	return message, nil
+}
+
 // parseService парсит сервисные данные (Type 4) согласно новой спецификации
 func (p *Parser) parseService(data []byte) (*ServiceData, error) {
 	if len(data) < 7 {
@@ -447,7 +586,7 @@ func (p *Parser) parseService(data []byte) (*ServiceData, error) {
 	// Bit 5: Wind (3 байта)
 	if serviceHeader&0x20 != 0 && offset+2 < len(data) {
 		windDir := data[offset]
-		weather.WindDirection = uint16(windDir) * 360 / 256
+		weather.WindDirection = uint16(uint32(windDir) * 360 / 256)
 		
 		// Wind speed и gusts в следующих байтах (более сложная структура)
 		// Упрощенная реализация
@@ -478,7 +617,7 @@ func (p *Parser) parseService(data []byte) (*ServiceData, error) {
 	// Bit 1: State of Charge (battery)
 	if serviceHeader&0x02 != 0 && offset < len(data) {
 		batteryRaw := data[offset] & 0x0F // Младшие 4 бита
-		weather.Battery = uint8(batteryRaw) * 100 / 15 // 0x0-0xF -> 0-100%
+		weather.Battery = uint8(uint16(batteryRaw) * 100 / 15) // 0x0-0xF -> 0-100%
 		offset++
 		hasWeatherData = true
 	}
@@ -490,10 +629,147 @@ func (p *Parser) parseService(data []byte) (*ServiceData, error) {
 	return service, nil
 }
 
+// parseLandmark парсит данные ориентира/зоны (Type 5)
+// Byte 0: bits 7-4 - время жизни ((значение+1) * 10 мин, bit 7 - масштаб 6x), bits 3-0 - подтип
+// Byte 1: bit 4 - зависимость от ветра (+1 байт маски секторов), bits 3-0 - слой
+// Далее элементы геометрии. Координаты передаются в полном формате (3+3 байта, как в Type 1)
+func (p *Parser) parseLandmark(data []byte) (*LandmarkData, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("landmark data too short: %d bytes", len(data))
+	}
+	
+	ttlRaw := uint16((data[0] >> 4) & 0x07)
+	ttlScale := uint16(1)
+	if data[0]&0x80 != 0 {
+		ttlScale = 6
+	}
+	
+	landmark := &LandmarkData{
+		Subtype:    data[0] & 0x0F,
+		Layer:      data[1] & 0x0F,
+		TTLMinutes: (ttlRaw + 1) * 10 * ttlScale,
+	}
+	
+	offset := 2
+	
+	// Bit 4: маска секторов ветра
+	if data[1]&0x10 != 0 {
+		if len(data) < offset+1 {
+			return nil, fmt.Errorf("landmark wind sectors missing")
+		}
+		landmark.WindSectors = data[offset]
+		offset++
+	}
+	
+	switch landmark.Subtype {
+	case 0: // Text: позиция + строка
+		if len(data) < offset+6 {
+			return nil, fmt.Errorf("landmark text data too short: %d bytes", len(data))
+		}
+		landmark.Points = append(landmark.Points, decodeLandmarkPoint(data[offset:offset+6]))
+		offset += 6
+		landmark.Text = strings.ToValidUTF8(strings.TrimRight(string(data[offset:]), "\x00"), "")
+		
+	case 1, 2, 3, 4: // Line/Arrow (2+ точек), Area (3+ точек, максимум 50)
+		for offset+6 <= len(data) && len(landmark.Points) < 50 {
+			landmark.Points = append(landmark.Points, decodeLandmarkPoint(data[offset:offset+6]))
+			offset += 6
+		}
+		minPoints := 2
+		if landmark.Subtype >= 3 {
+			minPoints = 3
+		}
+		if len(landmark.Points) < minPoints {
+			return nil, fmt.Errorf("landmark subtype %d requires at least %d points, got %d", landmark.Subtype, minPoints, len(landmark.Points))
+		}
+		
+	case 5, 6: // Circle: [позиция + радиус (bit 7 - масштаб 8x, bits 6-0 - радиус в 25 м)]*n
+		for offset+7 <= len(data) {
+			landmark.Points = append(landmark.Points, decodeLandmarkPoint(data[offset:offset+6]))
+			radiusRaw := data[offset+6]
+			radius := uint32(radiusRaw&0x7F) * 25
+			if radiusRaw&0x80 != 0 {
+				radius *= 8
+			}
+			landmark.Radii = append(landmark.Radii, radius)
+			offset += 7
+		}
+		if len(landmark.Points) == 0 {
+			return nil, fmt.Errorf("landmark circle data too short: %d bytes", len(data))
+		}
+		
+	default:
+		// 3D геометрия (7-9) требует высот и пока не поддерживается
+		return nil, fmt.Errorf("unsupported landmark subtype: %d", landmark.Subtype)
+	}
+	
+	if p.debugEnabled {
+		p.logger.WithFields(map[string]interface{}{
+			"raw_data_hex": hex.EncodeToString(data),
+			"subtype":      landmark.Subtype,
+			"layer":        landmark.Layer,
+			"ttl_minutes":  landmark.TTLMinutes,
+			"points":       len(landmark.Points),
+		}).Info("Parsed landmark (DEBUG)")
+	}
+	
+	return landmark, nil
+}
+
+// decodeLandmarkPoint декодирует координаты (3 байта широта + 3 байта долгота)
+func decodeLandmarkPoint(data []byte) LandmarkPoint {
+	latRaw := int32(data[0]) | int32(data[1])<<8 | int32(data[2])<<16
+	if latRaw&0x800000 != 0 { // Знаковое расширение для 24-bit
+		latRaw |= ^0xFFFFFF
+	}
+	
+	lonRaw := int32(data[3]) | int32(data[4])<<8 | int32(data[5])<<16
+	if lonRaw&0x800000 != 0 {
+		lonRaw |= ^0xFFFFFF
+	}
+	
+	return LandmarkPoint{
+		Latitude:  float64(latRaw) / 93206.04,
+		Longitude: float64(lonRaw) / 46603.02,
+	}
+}
+
+// parseRemoteConfig парсит удаленную конфигурацию (Type 6)
+// Byte 0: ключ (subheader), далее значение. Конфигурация обычно адресная (extended header)
+func (p *Parser) parseRemoteConfig(header byte, data []byte) (*RemoteConfigData, error) {
+	destination, offset, err := parseExtendedHeader(header, data)
+	if err != nil {
+		return nil, fmt.Errorf("remote config %w", err)
+	}
+	
+	if len(data) < offset+1 {
+		return nil, fmt.Errorf("remote config data too short: %d bytes", len(data))
+	}
+	
+	config := &RemoteConfigData{
+		Subheader:   data[offset],
+		Destination: destination,
+	}
+	if len(data) > offset+1 {
+		config.Value = append([]byte(nil), data[offset+1:]...)
+	}
+	
+	if p.debugEnabled {
+		p.logger.WithFields(map[string]interface{}{
+			"raw_data_hex": hex.EncodeToString(data),
+			"subheader":    config.Subheader,
+			"destination":  config.Destination,
+		}).Info("Parsed remote config (DEBUG)")
+	}
+	
+	return config, nil
+}
 
 // parseGroundTracking парсит данные наземного отслеживания (Type 7)
+// Bytes 0-5: координаты, Byte 6: bits 7-4 - тип объекта, bit 0 - онлайн трекинг.
+// Некоторые базовые станции дополняют пакет высотой и скоростью/курсом (bytes 6-9)
 func (p *Parser) parseGroundTracking(data []byte) (*GroundTrackingData, error) {
-	if len(data) < 10 {
+	if len(data) < 7 {
 		return nil, fmt.Errorf("ground tracking data too short: %d bytes", len(data))
 	}
 	
@@ -512,19 +788,23 @@ func (p *Parser) parseGroundTracking(data []byte) (*GroundTrackingData, error) {
 	}
 	longitude := float64(lonRaw) / 46603.02
 	
-	altitude := int32(int16(binary.LittleEndian.Uint16(data[6:8])))
+	ground := &GroundTrackingData{
+		Latitude:    latitude,
+		Longitude:   longitude,
+		GroundType:  (data[6] >> 4) & 0x0F,
+		TrackOnline: data[6]&0x01 != 0,
+	}
 	
-	speedHeading := binary.LittleEndian.Uint16(data[8:10])
-	speed := uint16((speedHeading >> 6) & 0x3FF)
-	heading := uint16(speedHeading & 0x3F) * 6
+	// Расширенный формат с высотой, скоростью и курсом
+	if len(data) >= 10 {
+		ground.Altitude = int32(int16(binary.LittleEndian.Uint16(data[6:8])))
+		
+		speedHeading := binary.LittleEndian.Uint16(data[8:10])
+		ground.Speed = uint16((speedHeading >> 6) & 0x3FF)
+		ground.Heading = uint16(speedHeading & 0x3F) * 6
+	}
 	
-	return &GroundTrackingData{
-		Latitude:  latitude,
-		Longitude: longitude,
-		Altitude:  altitude,
-		Speed:     speed,
-		Heading:   heading,
-	}, nil
+	return ground, nil
 }
 
 // parseThermal парсит данные термика (Type 9) согласно спецификации
@@ -563,7 +843,7 @@ func (p *Parser) parseThermal(data []byte) (*ThermalData, error) {
 	if len(data) >= 11 {
 		// Средний подъем (2 байта): м/с * 100
 		avgClimb := int16(binary.LittleEndian.Uint16(data[9:11]))
-		thermal.ClimbRate = avgClimb // уже * 100
+		thermal.ClimbRate = float32(avgClimb) / 100
 	}
 	
 	// Ветер не входит в ThermalData структуру согласно спецификации
@@ -11,6 +11,21 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// rssiRaw кодирует RSSI как int16 в заголовке пакета базовой станции
+func rssiRaw(rssi int16) uint16 {
+	return uint16(rssi)
+}
+
+// latRaw кодирует широту в формате FANET (до обрезки до 24 бит)
+func latRaw(lat float64) int32 {
+	return int32(lat * 93206.04)
+}
+
+// lonRaw кодирует долготу в формате FANET (до обрезки до 24 бит)
+func lonRaw(lon float64) int32 {
+	return int32(lon * 46603.02)
+}
+
 func TestParser_Parse_ValidTopic(t *testing.T) {
 	logger := utils.NewLogger("info", "text")
 	parser := NewParser(logger)
@@ -47,7 +62,7 @@ func TestParser_Parse_ValidTopic(t *testing.T) {
 			// Минимальный валидный payload (wrapper + FANET header)
 			payload := make([]byte, 12)
 			binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-			binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-80))) // RSSI
+			binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-80)) // RSSI
 			binary.LittleEndian.PutUint16(payload[6:8], uint16(10))  // SNR
 			payload[8] = 1                                           // FANET type
 			payload[9] = 0x34                                        // Device ID low
@@ -102,7 +117,7 @@ func TestParser_Parse_PayloadLength(t *testing.T) {
 			payload := make([]byte, tt.payloadSize)
 			if tt.payloadSize >= 12 {
 				binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-				binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-80)))
+				binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-80))
 				binary.LittleEndian.PutUint16(payload[6:8], uint16(10))
 				payload[8] = 1     // FANET type
 				payload[9] = 0x34  // Device ID
@@ -133,7 +148,7 @@ func TestParser_ParseAirTracking(t *testing.T) {
 	// Base station wrapper
 	timestamp := uint32(time.Now().Unix())
 	binary.LittleEndian.PutUint32(payload[0:4], timestamp)
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-80))) // RSSI
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-80)) // RSSI
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(10))  // SNR
 
 	// FANET header
@@ -206,7 +221,7 @@ func TestParser_ParseName(t *testing.T) {
 
 	// Base station wrapper
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-75)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-75))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(15))
 
 	// FANET header
@@ -230,6 +245,194 @@ func TestParser_ParseName(t *testing.T) {
 	assert.Equal(t, testName, nameData.Name)
 }
 
+func TestParser_ParseMessage(t *testing.T) {
+	logger := utils.NewLogger("info", "text")
+	parser := NewParser(logger)
+
+	t.Run("Broadcast", func(t *testing.T) {
+		testText := "Landed OK"
+		payload := make([]byte, 8+4+1+len(testText))
+
+		// Base station wrapper
+		binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+		binary.LittleEndian.PutUint16(payload[4:6], uint16(10))
+		binary.LittleEndian.PutUint16(payload[6:8], uint16(12))
+
+		// FANET header
+		payload[8] = 3     // Type 3 (Message), без extended header
+		payload[9] = 0x56  // Device ID: 0x001256
+		payload[10] = 0x12
+		payload[11] = 0x00
+
+		// Subheader + текст
+		payload[12] = 0x00
+		copy(payload[13:], testText)
+
+		msg, err := parser.Parse("fb/b/BASE01/f/3", payload)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+
+		assert.Equal(t, uint8(3), msg.Type)
+		assert.Equal(t, "001256", msg.DeviceID)
+
+		require.IsType(t, &MessageData{}, msg.Data)
+		messageData := msg.Data.(*MessageData)
+		assert.False(t, messageData.Unicast)
+		assert.Empty(t, messageData.Destination)
+		assert.Equal(t, uint8(0), messageData.Subheader)
+		assert.Equal(t, testText, messageData.Text)
+	})
+
+	t.Run("Unicast", func(t *testing.T) {
+		testText := "Need pickup"
+		payload := make([]byte, 8+4+1+3+1+len(testText))
+
+		// Base station wrapper
+		binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+		binary.LittleEndian.PutUint16(payload[4:6], uint16(10))
+		binary.LittleEndian.PutUint16(payload[6:8], uint16(12))
+
+		// FANET header с extended header
+		payload[8] = 0x80 | 3
+		payload[9] = 0x56
+		payload[10] = 0x12
+		payload[11] = 0x00
+
+		// Extended header: unicast, адрес получателя 0x0ABCDE
+		payload[12] = 0x20
+		payload[13] = 0xDE
+		payload[14] = 0xBC
+		payload[15] = 0x0A
+
+		// Subheader + текст
+		payload[16] = 0x00
+		copy(payload[17:], testText)
+
+		msg, err := parser.Parse("fb/b/BASE01/f/3", payload)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+
+		assert.Equal(t, uint8(3), msg.Type)
+
+		require.IsType(t, &MessageData{}, msg.Data)
+		messageData := msg.Data.(*MessageData)
+		assert.True(t, messageData.Unicast)
+		assert.Equal(t, "0ABCDE", messageData.Destination)
+		assert.Equal(t, testText, messageData.Text)
+	})
+
+	t.Run("Truncated unicast header", func(t *testing.T) {
+		payload := make([]byte, 8+4+2)
+		binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+
+		payload[8] = 0x80 | 3
+		payload[9] = 0x56
+		payload[10] = 0x12
+		payload[11] = 0x00
+		payload[12] = 0x20 // unicast, но адреса получателя нет
+		payload[13] = 0xDE
+
+		msg, err := parser.Parse("fb/b/BASE01/f/3", payload)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		assert.Nil(t, msg.Data)
+	})
+}
+
+func TestParser_ParseLandmark(t *testing.T) {
+	logger := utils.NewLogger("info", "text")
+	parser := NewParser(logger)
+
+	// encodePoint кодирует координаты в формате FANET (3 байта широта + 3 байта долгота)
+	encodePoint := func(lat, lon float64) []byte {
+		latRaw := int32(lat * 93206.04)
+		lonRaw := int32(lon * 46603.02)
+		return []byte{
+			byte(latRaw), byte(latRaw >> 8), byte(latRaw >> 16),
+			byte(lonRaw), byte(lonRaw >> 8), byte(lonRaw >> 16),
+		}
+	}
+
+	buildPayload := func(landmark []byte) []byte {
+		payload := make([]byte, 8+4, 8+4+len(landmark))
+
+		// Base station wrapper
+		binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+		binary.LittleEndian.PutUint16(payload[4:6], uint16(10))
+		binary.LittleEndian.PutUint16(payload[6:8], uint16(12))
+
+		// FANET header
+		payload[8] = 5     // Type 5 (Landmarks)
+		payload[9] = 0x78  // Device ID: 0x345678
+		payload[10] = 0x56
+		payload[11] = 0x34
+
+		return append(payload, landmark...)
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		// TTL 30 мин (значение 2), подтип 0; слой 3 (Touch down)
+		data := []byte{0x20, 0x03}
+		data = append(data, encodePoint(46.5, 15.6)...)
+		data = append(data, []byte("LZ")...)
+
+		msg, err := parser.Parse("fb/b/BASE01/f/5", buildPayload(data))
+		require.NoError(t, err)
+		require.IsType(t, &LandmarkData{}, msg.Data)
+
+		landmark := msg.Data.(*LandmarkData)
+		assert.Equal(t, uint8(0), landmark.Subtype)
+		assert.Equal(t, uint8(3), landmark.Layer)
+		assert.Equal(t, uint16(30), landmark.TTLMinutes)
+		assert.Equal(t, "LZ", landmark.Text)
+		require.Len(t, landmark.Points, 1)
+		assert.InDelta(t, 46.5, landmark.Points[0].Latitude, 0.0001)
+		assert.InDelta(t, 15.6, landmark.Points[0].Longitude, 0.0001)
+	})
+
+	t.Run("Area with wind sectors", func(t *testing.T) {
+		// TTL 60 мин * 6 (bit 7), подтип 4; слой 2 (Keep out) + маска ветра
+		data := []byte{0x80 | 0x50 | 0x04, 0x10 | 0x02, 0x0F}
+		data = append(data, encodePoint(46.50, 15.60)...)
+		data = append(data, encodePoint(46.51, 15.60)...)
+		data = append(data, encodePoint(46.51, 15.62)...)
+
+		msg, err := parser.Parse("fb/b/BASE01/f/5", buildPayload(data))
+		require.NoError(t, err)
+		require.IsType(t, &LandmarkData{}, msg.Data)
+
+		landmark := msg.Data.(*LandmarkData)
+		assert.Equal(t, uint8(4), landmark.Subtype)
+		assert.Equal(t, uint8(2), landmark.Layer)
+		assert.Equal(t, uint16(360), landmark.TTLMinutes)
+		assert.Equal(t, uint8(0x0F), landmark.WindSectors)
+		assert.Len(t, landmark.Points, 3)
+	})
+
+	t.Run("Circle", func(t *testing.T) {
+		data := []byte{0x05, 0x01}
+		data = append(data, encodePoint(46.5, 15.6)...)
+		data = append(data, 0x80|0x05) // 5 * 25 м * 8 = 1000 м
+
+		msg, err := parser.Parse("fb/b/BASE01/f/5", buildPayload(data))
+		require.NoError(t, err)
+		require.IsType(t, &LandmarkData{}, msg.Data)
+
+		landmark := msg.Data.(*LandmarkData)
+		assert.Equal(t, uint8(5), landmark.Subtype)
+		assert.Equal(t, []uint32{1000}, landmark.Radii)
+	})
+
+	t.Run("Line with too few points", func(t *testing.T) {
+		data := []byte{0x01, 0x00}
+		data = append(data, encodePoint(46.5, 15.6)...)
+
+		msg, err := parser.Parse("fb/b/BASE01/f/5", buildPayload(data))
+		require.NoError(t, err)
+		assert.Nil(t, msg.Data)
+	})
+}
+
 func TestParser_ParseService(t *testing.T) {
 	logger := utils.NewLogger("info", "text")
 	parser := NewParser(logger)
@@ -239,7 +442,7 @@ func TestParser_ParseService(t *testing.T) {
 
 	// Base station wrapper
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-70)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-70))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(20))
 
 	// FANET header
@@ -255,8 +458,8 @@ func TestParser_ParseService(t *testing.T) {
 	data[0] = 0x40 // Bit 6 set = temperature data
 
 	// Координаты станции: 47.0, 8.5
-	lat := int32(47.0 * 93206.04) & 0xFFFFFF  // 24-bit
-	lon := int32(8.5 * 46603.02) & 0xFFFFFF   // 24-bit
+	lat := latRaw(47.0) & 0xFFFFFF  // 24-bit
+	lon := lonRaw(8.5) & 0xFFFFFF   // 24-bit
 
 	data[1] = byte(lat & 0xFF)
 	data[2] = byte((lat >> 8) & 0xFF)
@@ -297,7 +500,7 @@ func TestParser_ParseGroundTracking(t *testing.T) {
 
 	// Base station wrapper
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-85)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-85))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(5))
 
 	// FANET header
@@ -310,8 +513,8 @@ func TestParser_ParseGroundTracking(t *testing.T) {
 	data := payload[12:]
 
 	// Координаты: 46.5, 7.5
-	lat := int32(46.5 * 93206.04) & 0xFFFFFF  // 24-bit
-	lon := int32(7.5 * 46603.02) & 0xFFFFFF   // 24-bit
+	lat := latRaw(46.5) & 0xFFFFFF  // 24-bit
+	lon := lonRaw(7.5) & 0xFFFFFF   // 24-bit
 
 	data[0] = byte(lat & 0xFF)
 	data[1] = byte((lat >> 8) & 0xFF)
@@ -345,16 +548,60 @@ func TestParser_ParseGroundTracking(t *testing.T) {
 	assert.Equal(t, uint16(180), groundData.Heading)
 }
 
+func TestParser_ParseGroundTracking_Standard(t *testing.T) {
+	logger := utils.NewLogger("info", "text")
+	parser := NewParser(logger)
+
+	// Стандартный 7-байтовый пакет Type 7
+	payload := make([]byte, 8+4+7)
+
+	// Base station wrapper
+	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
+	binary.LittleEndian.PutUint16(payload[4:6], uint16(10))
+	binary.LittleEndian.PutUint16(payload[6:8], uint16(5))
+
+	// FANET header
+	payload[8] = 7     // Type 7 (Ground tracking)
+	payload[9] = 0x9B  // Device ID: 0x00129B
+	payload[10] = 0x12
+	payload[11] = 0x00
+
+	data := payload[12:]
+	latDeg, lonDeg := 46.5, 7.5
+	lat := int32(latDeg*93206.04) & 0xFFFFFF
+	lon := int32(lonDeg*46603.02) & 0xFFFFFF
+	data[0] = byte(lat)
+	data[1] = byte(lat >> 8)
+	data[2] = byte(lat >> 16)
+	data[3] = byte(lon)
+	data[4] = byte(lon >> 8)
+	data[5] = byte(lon >> 16)
+
+	// Тип 8 (Need a ride) + онлайн трекинг
+	data[6] = 8<<4 | 0x01
+
+	msg, err := parser.Parse("fb/b/GROUND/f/7", payload)
+	require.NoError(t, err)
+	require.IsType(t, &GroundTrackingData{}, msg.Data)
+
+	groundData := msg.Data.(*GroundTrackingData)
+	assert.InDelta(t, 46.5, groundData.Latitude, 0.001)
+	assert.InDelta(t, 7.5, groundData.Longitude, 0.001)
+	assert.Equal(t, uint8(8), groundData.GroundType)
+	assert.True(t, groundData.TrackOnline)
+	assert.Zero(t, groundData.Altitude)
+}
+
 func TestParser_ParseThermal(t *testing.T) {
 	logger := utils.NewLogger("info", "text")
 	parser := NewParser(logger)
 
 	// Создаем тестовый Thermal пакет (Type 9)
-	payload := make([]byte, 8+4+11) // wrapper + header + thermal data
+	payload := make([]byte, 8+4+13) // wrapper + header + thermal data
 
 	// Base station wrapper
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-70)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-70))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(25))
 
 	// FANET header
@@ -367,8 +614,8 @@ func TestParser_ParseThermal(t *testing.T) {
 	data := payload[12:]
 
 	// Координаты центра термика: 46.2, 8.1
-	lat := int32(46.2 * 93206.04) & 0xFFFFFF  // 24-bit
-	lon := int32(8.1 * 46603.02) & 0xFFFFFF   // 24-bit
+	lat := latRaw(46.2) & 0xFFFFFF  // 24-bit
+	lon := lonRaw(8.1) & 0xFFFFFF   // 24-bit
 
 	data[0] = byte(lat & 0xFF)
 	data[1] = byte((lat >> 8) & 0xFF)
@@ -387,6 +634,10 @@ func TestParser_ParseThermal(t *testing.T) {
 	// Average climb rate: 3.5 m/s = 350 (cm/s)
 	binary.LittleEndian.PutUint16(data[9:11], 350)
 
+	// Средний ветер: направление 180°, скорость 5 м/с
+	data[11] = 128
+	data[12] = 10
+
 	msg, err := parser.Parse("fb/b/THERMAL/f/9", payload)
 	require.NoError(t, err)
 	require.NotNil(t, msg)
@@ -401,7 +652,7 @@ func TestParser_ParseThermal(t *testing.T) {
 	assert.InDelta(t, 8.1, thermalData.Longitude, 0.001)
 	assert.Equal(t, int32(1200), thermalData.Altitude)
 	assert.Equal(t, uint8(4), thermalData.Strength)
-	assert.Equal(t, int16(350), thermalData.ClimbRate)
+	assert.InDelta(t, 3.5, thermalData.ClimbRate, 0.001)
 }
 
 func TestParser_ValidateCoordinates(t *testing.T) {
@@ -498,7 +749,7 @@ func TestParser_UnsupportedMessageType(t *testing.T) {
 
 	// Base station wrapper
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-80)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-80))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(10))
 
 	// FANET header с неподдерживаемым типом
@@ -530,8 +781,8 @@ func TestParser_RealWorldHexData(t *testing.T) {
 	parser := NewParser(logger)
 	parser.SetDebugMode(true)
 
-	// Реальный пример MQTT пакета в hex формате (из логов)
-	// Это Type 1 (Air Tracking) пакет
+	// Реальный пример MQTT пакета в hex формате (из логов).
+	// Пришел в топике Type 1, но по заголовку FANET (0x00) это ACK от 8E1B7B
 	hexData := "6b23496601F5000A007B1B8EC50000007C000000C82A"
 	payload, err := hex.DecodeString(hexData)
 	require.NoError(t, err)
@@ -540,18 +791,13 @@ func TestParser_RealWorldHexData(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, msg)
 
-	assert.Equal(t, uint8(1), msg.Type) // Air tracking
+	// Тип определяется заголовком FANET, тип из топика сохраняется отдельно
+	assert.Equal(t, uint8(0), msg.Type)
+	assert.Equal(t, "1", msg.PacketType)
+	assert.Equal(t, "8E1B7B", msg.DeviceID)
 	assert.Equal(t, "40FE17", msg.ChipID)
-
-	// Проверяем, что координаты в разумных пределах для Европы
-	if msg.Data != nil {
-		airData, ok := msg.Data.(*AirTrackingData)
-		if ok {
-			assert.True(t, parser.ValidateCoordinates(airData.Latitude, airData.Longitude))
-			t.Logf("Parsed coordinates: %.6f, %.6f", airData.Latitude, airData.Longitude)
-			t.Logf("Altitude: %dm, Speed: %dkm/h", airData.Altitude, airData.Speed)
-		}
-	}
+	assert.Equal(t, int64(0x6649236b), msg.Timestamp.Unix())
+	assert.Nil(t, msg.Data) // ACK не содержит данных для клиентов
 }
 
 // Benchmark тесты для производительности
@@ -562,7 +808,7 @@ func BenchmarkParser_Parse(b *testing.B) {
 	// Создаем типичный Air Tracking пакет
 	payload := make([]byte, 8+4+11)
 	binary.LittleEndian.PutUint32(payload[0:4], uint32(time.Now().Unix()))
-	binary.LittleEndian.PutUint16(payload[4:6], uint16(int16(-80)))
+	binary.LittleEndian.PutUint16(payload[4:6], rssiRaw(-80))
 	binary.LittleEndian.PutUint16(payload[6:8], uint16(10))
 	payload[8] = 1
 	payload[9] = 0x34
@@ -571,8 +817,8 @@ func BenchmarkParser_Parse(b *testing.B) {
 
 	// Добавляем реалистичные air tracking данные
 	data := payload[12:]
-	lat := int32(46.0 * 93206.04) & 0xFFFFFF  // 24-bit
-	lon := int32(8.0 * 46603.02) & 0xFFFFFF   // 24-bit
+	lat := latRaw(46.0) & 0xFFFFFF  // 24-bit
+	lon := lonRaw(8.0) & 0xFFFFFF   // 24-bit
 	data[0] = byte(lat & 0xFF)
 	data[1] = byte((lat >> 8) & 0xFF)
 	data[2] = byte((lat >> 16) & 0xFF)
@@ -601,8 +847,8 @@ func BenchmarkParser_ParseAirTracking(b *testing.B) {
 
 	// Air tracking data (11 bytes)
 	data := make([]byte, 11)
-	lat := int32(46.0 * 93206.04) & 0xFFFFFF  // 24-bit
-	lon := int32(8.0 * 46603.02) & 0xFFFFFF   // 24-bit
+	lat := latRaw(46.0) & 0xFFFFFF  // 24-bit
+	lon := lonRaw(8.0) & 0xFFFFFF   // 24-bit
 	data[0] = byte(lat & 0xFF)
 	data[1] = byte((lat >> 8) & 0xFF)
 	data[2] = byte((lat >> 16) & 0xFF)
@@ -35,6 +35,14 @@ type Repository interface {
 	GetGroundObjectsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.GroundObject, error)
 	DeleteGroundObject(ctx context.Context, deviceID string) error
 
+	// Операции с текстовыми сообщениями
+	SaveMessage(ctx context.Context, message *models.Message) error
+	GetMessagesInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Message, error)
+
+	// Операции с ориентирами
+	SaveLandmark(ctx context.Context, landmark *models.Landmark) error
+	GetLandmarksInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Landmark, error)
+
 	// Статистика
 	GetStats(ctx context.Context) (map[string]interface{}, error)
 }
@@ -50,10 +58,22 @@ type HistoryRepository interface {
 	LoadInitialThermals(ctx context.Context, limit int) ([]*models.Thermal, error)
 	LoadInitialStations(ctx context.Context, limit int) ([]*models.Station, error)
 
+	// Исторические термики для тепловой карты
+	GetThermalHistory(ctx context.Context, filter *models.ThermalHeatmapFilter, limit int) ([]*models.Thermal, error)
+
 	// Операции с треками
 	GetPilotTrack(ctx context.Context, deviceID string, limit int) ([]models.GeoPoint, error)
 	GetPilotTrackWithTimestamps(ctx context.Context, deviceID string, limit int) ([]models.TrackGeoPoint, error)
 	GetPilotAircraftType(ctx context.Context, deviceID string) (models.PilotType, error)
+	GetPilotName(ctx context.Context, deviceID string) (string, error)
+	GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error)
+	GetPilotTrackRange(ctx context.Context, deviceID string, query *models.TrackRangeQuery) (*models.TrackPage, error)
+
+	// Полеты
+	CreateFlight(ctx context.Context, flight *models.Flight) (int64, error)
+	UpdateFlight(ctx context.Context, flight *models.Flight) error
+	GetFlights(ctx context.Context, deviceID string, limit int) ([]*models.Flight, error)
+	GetFlight(ctx context.Context, id int64) (*models.Flight, error)
 
 	// Сохранение для backup
 	SavePilotToHistory(ctx context.Context, pilot *models.Pilot) error
@@ -71,6 +91,7 @@ type MySQLRepositoryInterface interface {
 	SavePilotsBatch(ctx context.Context, pilots []*models.Pilot) error
 	SaveThermalsBatch(ctx context.Context, thermals []*models.Thermal) error
 	SaveStationsBatch(ctx context.Context, stations []*models.Station) error
+	SaveGroundObjectsBatch(ctx context.Context, groundObjects []*models.GroundObject) error
 }
 
 // Ensure implementations
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,9 @@ import (
 	"github.com/flybeeper/fanet-backend/pkg/utils"
 )
 
+// thermalClimbScale масштаб колонки thermal.climb: м/с * 100, как в FANET Type 9
+const thermalClimbScale = 100
+
 // MySQLRepository репозиторий для работы с MySQL (fallback и исторические данные)
 type MySQLRepository struct {
 	db     *sql.DB
@@ -116,7 +120,7 @@ func (r *MySQLRepository) LoadInitialPilots(ctx context.Context, limit int) ([]*
 		}
 
 		pilot := &models.Pilot{
-			DeviceID:     fmt.Sprintf("%06X", addr),
+			DeviceID:     models.DeviceIDFromAddr(uint32(addr)),
 			Name:         name,
 			Type: models.PilotType(aircraftType),
 			Position: &models.GeoPoint{
@@ -188,14 +192,14 @@ func (r *MySQLRepository) LoadInitialThermals(ctx context.Context, limit int) ([
 
 		thermal := &models.Thermal{
 			ID:         strconv.Itoa(id),
-			ReportedBy: fmt.Sprintf("%06X", addr),
+			ReportedBy: models.DeviceIDFromAddr(uint32(addr)),
 			Position: &models.GeoPoint{
 				Latitude:  lat,
 				Longitude: lon,
 				Altitude:  int32(altitude),
 			},
 			Quality:       int32(quality),
-			ClimbRate:     float32(climb),
+			ClimbRate:     float32(climb) / thermalClimbScale,
 			WindSpeed:     uint8(float64(windSpeed) / 10 * 3.6), // м/с*10 -> км/ч
 			WindDirection: uint16(windHeading),
 			Timestamp:     time.Now(), // Неизвестно в legacy схеме
@@ -212,6 +216,86 @@ func (r *MySQLRepository) LoadInitialThermals(ctx context.Context, limit int) ([
 	return thermals, nil
 }
 
+// GetThermalHistory возвращает исторические термики в границах за период
+// с минимальной скороподъемностью, новые первыми. Остальные условия фильтра
+// (время суток, месяц, ветер) проверяются вызывающей стороной
+func (r *MySQLRepository) GetThermalHistory(ctx context.Context, filter *models.ThermalHeatmapFilter, limit int) ([]*models.Thermal, error) {
+	query := `
+		SELECT
+			addr,
+			latitude,
+			longitude,
+			COALESCE(altitude, 0) as altitude,
+			COALESCE(quality, 0) as quality,
+			COALESCE(climb, 0) as climb,
+			COALESCE(wind_speed, 0) as wind_speed,
+			COALESCE(wind_heading, 0) as wind_heading,
+			datestamp
+		FROM thermal
+		WHERE latitude BETWEEN ? AND ?
+			AND longitude BETWEEN ? AND ?
+			AND datestamp BETWEEN ? AND ?
+			AND COALESCE(climb, 0) >= ?
+		ORDER BY datestamp DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.Bounds.Southwest.Latitude, filter.Bounds.Northeast.Latitude,
+		filter.Bounds.Southwest.Longitude, filter.Bounds.Northeast.Longitude,
+		filter.From, filter.To,
+		int(math.Ceil(float64(filter.MinClimb)*thermalClimbScale)),
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thermal history: %w", err)
+	}
+	defer rows.Close()
+
+	var thermals []*models.Thermal
+	for rows.Next() {
+		var (
+			addr        int
+			lat, lon    float64
+			altitude    int
+			quality     int
+			climb       int
+			windSpeed   int
+			windHeading int
+			timestamp   time.Time
+		)
+
+		err := rows.Scan(&addr, &lat, &lon, &altitude, &quality, &climb, &windSpeed, &windHeading, &timestamp)
+		if err != nil {
+			r.logger.WithField("error", err).Warn("Failed to scan thermal history row")
+			continue
+		}
+
+		thermals = append(thermals, &models.Thermal{
+			ReportedBy: models.DeviceIDFromAddr(uint32(addr)),
+			Position: &models.GeoPoint{
+				Latitude:  lat,
+				Longitude: lon,
+				Altitude:  int32(altitude),
+			},
+			Quality:       int32(quality),
+			ClimbRate:     float32(climb) / thermalClimbScale,
+			WindSpeed:     uint8(float64(windSpeed) / 10 * 3.6), // м/с*10 -> км/ч
+			WindDirection: uint16(windHeading),
+			Timestamp:     timestamp,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating thermal history rows: %w", err)
+	}
+
+	r.logger.WithFields(map[string]interface{}{
+		"count": len(thermals),
+		"limit": limit,
+	}).Debug("Retrieved thermal history from MySQL")
+	return thermals, nil
+}
+
 // LoadInitialStations загружает начальные данные метеостанций
 func (r *MySQLRepository) LoadInitialStations(ctx context.Context, limit int) ([]*models.Station, error) {
 	query := `
@@ -264,7 +348,7 @@ func (r *MySQLRepository) LoadInitialStations(ctx context.Context, limit int) ([
 		}
 
 		station := &models.Station{
-			ID:   fmt.Sprintf("%06X", addr),
+			ID:   models.DeviceIDFromAddr(uint32(addr)),
 			Name: name,
 			Position: &models.GeoPoint{
 				Latitude:  lat,
@@ -294,7 +378,7 @@ func (r *MySQLRepository) LoadInitialStations(ctx context.Context, limit int) ([
 // GetPilotTrack получает историю трека пилота
 func (r *MySQLRepository) GetPilotTrack(ctx context.Context, deviceID string, limit int) ([]models.GeoPoint, error) {
 	// Конвертируем hex device ID в int
-	addr, err := strconv.ParseInt(deviceID, 16, 32)
+	addr, err := models.DeviceAddr(deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid device ID format: %s", deviceID)
 	}
@@ -369,7 +453,7 @@ func (r *MySQLRepository) GetPilotTrack(ctx context.Context, deviceID string, li
 // GetPilotTrackWithTimestamps получает историю трека пилота с временными метками
 func (r *MySQLRepository) GetPilotTrackWithTimestamps(ctx context.Context, deviceID string, limit int) ([]models.TrackGeoPoint, error) {
 	// Конвертируем hex device ID в int
-	addr, err := strconv.ParseInt(deviceID, 16, 32)
+	addr, err := models.DeviceAddr(deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid device ID format: %s", deviceID)
 	}
@@ -381,7 +465,7 @@ func (r *MySQLRepository) GetPilotTrackWithTimestamps(ctx context.Context, devic
 	}).Debug("Getting pilot track with timestamps from MySQL")
 
 	query := `
-		SELECT latitude, longitude, altitude_gps, datestamp
+		SELECT latitude, longitude, altitude_gps, altitude_bar, datestamp
 		FROM ufo_track
 		WHERE addr = ? AND datestamp > DATE_SUB(NOW(), INTERVAL 24 HOUR)
 		ORDER BY datestamp DESC
@@ -397,12 +481,13 @@ func (r *MySQLRepository) GetPilotTrackWithTimestamps(ctx context.Context, devic
 	var track []models.TrackGeoPoint
 	for rows.Next() {
 		var (
-			lat, lon  float64
-			altitude  sql.NullFloat64
-			timestamp time.Time
+			lat, lon    float64
+			altitude    sql.NullFloat64
+			pressureAlt sql.NullInt64
+			timestamp   time.Time
 		)
 
-		err := rows.Scan(&lat, &lon, &altitude, &timestamp)
+		err := rows.Scan(&lat, &lon, &altitude, &pressureAlt, &timestamp)
 		if err != nil {
 			r.logger.WithField("error", err).Warn("Failed to scan track point")
 			continue
@@ -421,6 +506,10 @@ func (r *MySQLRepository) GetPilotTrackWithTimestamps(ctx context.Context, devic
 			},
 			Timestamp: timestamp,
 		}
+		if pressureAlt.Valid {
+			alt := int32(pressureAlt.Int64)
+			point.PressureAltitude = &alt
+		}
 
 		track = append(track, point)
 	}
@@ -439,10 +528,269 @@ func (r *MySQLRepository) GetPilotTrackWithTimestamps(ctx context.Context, devic
 	return track, nil
 }
 
+// GetPilotTrackBetween получает трек пилота за период [from, to] с временными метками
+func (r *MySQLRepository) GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error) {
+	page, err := r.GetPilotTrackRange(ctx, deviceID, &models.TrackRangeQuery{From: from, To: to, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return page.Points, nil
+}
+
+// GetPilotTrackRange получает страницу трека пилота за период [From, To].
+// Keyset пагинация по (datestamp, id): следующая страница запрашивается с After = Next
+// и не зависит от глубины, в отличие от OFFSET
+func (r *MySQLRepository) GetPilotTrackRange(ctx context.Context, deviceID string, query *models.TrackRangeQuery) (*models.TrackPage, error) {
+	addr, err := models.DeviceAddr(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device ID format: %s", deviceID)
+	}
+	if query.Limit <= 0 {
+		return nil, fmt.Errorf("track page limit must be positive")
+	}
+
+	after := models.TrackCursor{Timestamp: query.From}
+	if query.After != nil {
+		after = *query.After
+	}
+
+	// Запрашиваем на одну точку больше, чтобы узнать, есть ли следующая страница
+	sqlQuery := `
+		SELECT id, latitude, longitude, altitude_gps, altitude_bar, datestamp
+		FROM ufo_track
+		WHERE addr = ? AND datestamp BETWEEN ? AND ?
+		  AND (datestamp > ? OR (datestamp = ? AND id > ?))
+		ORDER BY datestamp ASC, id ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, addr, query.From, query.To,
+		after.Timestamp, after.Timestamp, after.ID, query.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pilot track: %w", err)
+	}
+	defer rows.Close()
+
+	page := &models.TrackPage{}
+	var lastID int64
+	for rows.Next() {
+		var (
+			id          int64
+			lat, lon    float64
+			altitude    sql.NullFloat64
+			pressureAlt sql.NullInt64
+			timestamp   time.Time
+		)
+
+		if err := rows.Scan(&id, &lat, &lon, &altitude, &pressureAlt, &timestamp); err != nil {
+			r.logger.WithField("error", err).Warn("Failed to scan track point")
+			continue
+		}
+
+		if len(page.Points) == query.Limit {
+			last := page.Points[len(page.Points)-1]
+			page.Next = &models.TrackCursor{Timestamp: last.Timestamp, ID: lastID}
+			break
+		}
+
+		point := models.TrackGeoPoint{
+			GeoPoint: models.GeoPoint{
+				Latitude:  lat,
+				Longitude: lon,
+			},
+			Timestamp: timestamp,
+		}
+		if pressureAlt.Valid {
+			alt := int32(pressureAlt.Int64)
+			point.PressureAltitude = &alt
+		}
+		if altitude.Valid {
+			point.Altitude = int32(altitude.Float64)
+		}
+
+		page.Points = append(page.Points, point)
+		lastID = id
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating track rows: %w", err)
+	}
+
+	return page, nil
+}
+
+// CreateFlight сохраняет новый полет и возвращает его ID
+func (r *MySQLRepository) CreateFlight(ctx context.Context, flight *models.Flight) (int64, error) {
+	addr, err := models.DeviceAddr(flight.DeviceID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device ID format: %s", flight.DeviceID)
+	}
+
+	landingTime, landingLat, landingLon, landingAlt := flightLandingArgs(flight)
+
+	query := `
+		INSERT INTO flight (
+			addr, ufo_type, status, takeoff_time, landing_time,
+			takeoff_latitude, takeoff_longitude, takeoff_altitude,
+			landing_latitude, landing_longitude, landing_altitude,
+			duration, distance, max_altitude, max_climb
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		addr, int(flight.Type), string(flight.Status), flight.TakeoffTime, landingTime,
+		flight.Takeoff.Latitude, flight.Takeoff.Longitude, flight.Takeoff.Altitude,
+		landingLat, landingLon, landingAlt,
+		flight.Duration, flight.Distance, flight.MaxAltitude, flight.MaxClimb)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert flight: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get flight ID: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateFlight обновляет статус, посадку и статистику полета
+func (r *MySQLRepository) UpdateFlight(ctx context.Context, flight *models.Flight) error {
+	landingTime, landingLat, landingLon, landingAlt := flightLandingArgs(flight)
+
+	query := `
+		UPDATE flight SET
+			status = ?, landing_time = ?,
+			landing_latitude = ?, landing_longitude = ?, landing_altitude = ?,
+			duration = ?, distance = ?, max_altitude = ?, max_climb = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		string(flight.Status), landingTime,
+		landingLat, landingLon, landingAlt,
+		flight.Duration, flight.Distance, flight.MaxAltitude, flight.MaxClimb,
+		flight.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update flight %d: %w", flight.ID, err)
+	}
+	return nil
+}
+
+// flightLandingArgs возвращает параметры посадки (NULL для активного полета)
+func flightLandingArgs(flight *models.Flight) (interface{}, interface{}, interface{}, interface{}) {
+	var landingTime, lat, lon, alt interface{}
+	if flight.LandingTime != nil {
+		landingTime = *flight.LandingTime
+	}
+	if flight.Landing != nil {
+		lat, lon, alt = flight.Landing.Latitude, flight.Landing.Longitude, flight.Landing.Altitude
+	}
+	return landingTime, lat, lon, alt
+}
+
+const flightColumns = `
+	id, addr, ufo_type, status, takeoff_time, landing_time,
+	takeoff_latitude, takeoff_longitude, COALESCE(takeoff_altitude, 0),
+	landing_latitude, landing_longitude, landing_altitude,
+	duration, distance, max_altitude, max_climb
+`
+
+// flightScanner общий интерфейс sql.Row и sql.Rows
+type flightScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFlight читает полет из строки результата
+func scanFlight(row flightScanner) (*models.Flight, error) {
+	var (
+		flight      models.Flight
+		addr        int64
+		aircraft    int
+		status      string
+		landingTime sql.NullTime
+		landingLat  sql.NullFloat64
+		landingLon  sql.NullFloat64
+		landingAlt  sql.NullInt64
+	)
+
+	err := row.Scan(&flight.ID, &addr, &aircraft, &status, &flight.TakeoffTime, &landingTime,
+		&flight.Takeoff.Latitude, &flight.Takeoff.Longitude, &flight.Takeoff.Altitude,
+		&landingLat, &landingLon, &landingAlt,
+		&flight.Duration, &flight.Distance, &flight.MaxAltitude, &flight.MaxClimb)
+	if err != nil {
+		return nil, err
+	}
+
+	flight.DeviceID = models.DeviceIDFromAddr(uint32(addr))
+	flight.Type = models.PilotType(aircraft)
+	flight.Status = models.FlightStatus(status)
+	if landingTime.Valid {
+		flight.LandingTime = &landingTime.Time
+	}
+	if landingLat.Valid && landingLon.Valid {
+		flight.Landing = &models.GeoPoint{
+			Latitude:  landingLat.Float64,
+			Longitude: landingLon.Float64,
+			Altitude:  int32(landingAlt.Int64),
+		}
+	}
+	return &flight, nil
+}
+
+// GetFlights возвращает последние полеты устройства, новые первыми
+func (r *MySQLRepository) GetFlights(ctx context.Context, deviceID string, limit int) ([]*models.Flight, error) {
+	addr, err := models.DeviceAddr(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device ID format: %s", deviceID)
+	}
+
+	query := `SELECT ` + flightColumns + `
+		FROM flight
+		WHERE addr = ?
+		ORDER BY takeoff_time DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, addr, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flights: %w", err)
+	}
+	defer rows.Close()
+
+	flights := make([]*models.Flight, 0)
+	for rows.Next() {
+		flight, err := scanFlight(rows)
+		if err != nil {
+			r.logger.WithField("error", err).Warn("Failed to scan flight row")
+			continue
+		}
+		flights = append(flights, flight)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating flight rows: %w", err)
+	}
+	return flights, nil
+}
+
+// GetFlight возвращает полет по ID. Если полет не найден, возвращает (nil, nil)
+func (r *MySQLRepository) GetFlight(ctx context.Context, id int64) (*models.Flight, error) {
+	query := `SELECT ` + flightColumns + ` FROM flight WHERE id = ?`
+
+	flight, err := scanFlight(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flight %d: %w", id, err)
+	}
+	return flight, nil
+}
+
 // GetPilotAircraftType получает тип ЛА пилота из последней записи в треке
 func (r *MySQLRepository) GetPilotAircraftType(ctx context.Context, deviceID string) (models.PilotType, error) {
 	// Конвертируем hex device ID в int
-	addr, err := strconv.ParseInt(deviceID, 16, 32)
+	addr, err := models.DeviceAddr(deviceID)
 	if err != nil {
 		return models.PilotTypeUnknown, fmt.Errorf("invalid device ID format: %s", deviceID)
 	}
@@ -483,10 +831,28 @@ func (r *MySQLRepository) GetPilotAircraftType(ctx context.Context, deviceID str
 	return models.PilotType(aircraftType), nil
 }
 
+// GetPilotName получает имя пилота из таблицы name. Пустая строка, если имя неизвестно
+func (r *MySQLRepository) GetPilotName(ctx context.Context, deviceID string) (string, error) {
+	addr, err := models.DeviceAddr(deviceID)
+	if err != nil {
+		return "", fmt.Errorf("invalid device ID format: %s", deviceID)
+	}
+
+	var name sql.NullString
+	err = r.db.QueryRowContext(ctx, `SELECT name FROM name WHERE addr = ?`, addr).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query pilot name: %w", err)
+	}
+	return name.String, nil
+}
+
 // SavePilotToHistory сохраняет данные пилота в историю (для backup)
 func (r *MySQLRepository) SavePilotToHistory(ctx context.Context, pilot *models.Pilot) error {
 	// Конвертируем hex device ID в int
-	addr, err := strconv.ParseInt(pilot.DeviceID, 16, 32)
+	addr, err := models.DeviceAddr(pilot.DeviceID)
 	if err != nil {
 		return fmt.Errorf("invalid device ID format: %s", pilot.DeviceID)
 	}
@@ -618,7 +984,7 @@ func (r *MySQLRepository) SavePilotsBatch(ctx context.Context, pilots []*models.
 		}
 
 		// Конвертируем hex device ID в int
-		addr, err := strconv.ParseInt(pilot.DeviceID, 16, 32)
+		addr, err := models.DeviceAddr(pilot.DeviceID)
 		if err != nil {
 			r.logger.WithField("device_id", pilot.DeviceID).WithField("error", err).Warn("Invalid device ID format, skipping")
 			continue
@@ -665,7 +1031,7 @@ func (r *MySQLRepository) SavePilotsBatch(ctx context.Context, pilots []*models.
 	currentTrackID := firstTrackID
 
 	for _, pilot := range pilots {
-		addr, err := strconv.ParseInt(pilot.DeviceID, 16, 32)
+		addr, err := models.DeviceAddr(pilot.DeviceID)
 		if err != nil {
 			continue
 		}
@@ -695,7 +1061,7 @@ func (r *MySQLRepository) SavePilotsBatch(ctx context.Context, pilots []*models.
 
 		nameArgs := make([]interface{}, 0, len(pilotsWithNames)*2)
 		for _, pilot := range pilotsWithNames {
-			addr, err := strconv.ParseInt(pilot.DeviceID, 16, 32)
+			addr, err := models.DeviceAddr(pilot.DeviceID)
 			if err != nil {
 				continue
 			}
@@ -732,7 +1098,7 @@ func (r *MySQLRepository) SaveThermalsBatch(ctx context.Context, thermals []*mod
 	args := make([]interface{}, 0, len(thermals)*8)
 	for _, thermal := range thermals {
 		// Конвертируем hex reported_by в int
-		addr, err := strconv.ParseInt(thermal.ReportedBy, 16, 32)
+		addr, err := models.DeviceAddr(thermal.ReportedBy)
 		if err != nil {
 			r.logger.WithField("reported_by", thermal.ReportedBy).WithField("error", err).Warn("Invalid thermal reported_by format, skipping")
 			continue
@@ -740,7 +1106,7 @@ func (r *MySQLRepository) SaveThermalsBatch(ctx context.Context, thermals []*mod
 
 		args = append(args,
 			addr, thermal.Position.Latitude, thermal.Position.Longitude,
-			thermal.Position.Altitude, thermal.Quality, thermal.ClimbRate,
+			thermal.Position.Altitude, thermal.Quality, int(math.Round(float64(thermal.ClimbRate)*thermalClimbScale)),
 			thermal.WindSpeed, thermal.WindDirection)
 	}
 
@@ -781,7 +1147,7 @@ func (r *MySQLRepository) SaveStationsBatch(ctx context.Context, stations []*mod
 	args := make([]interface{}, 0, len(stations)*12)
 	for _, station := range stations {
 		// Конвертируем hex station ID в int
-		addr, err := strconv.ParseInt(station.ID, 16, 32)
+		addr, err := models.DeviceAddr(station.ID)
 		if err != nil {
 			r.logger.WithField("station_id", station.ID).WithField("error", err).Warn("Invalid station ID format, skipping")
 			continue
@@ -803,6 +1169,53 @@ func (r *MySQLRepository) SaveStationsBatch(ctx context.Context, stations []*mod
 	return nil
 }
 
+// SaveGroundObjectsBatch сохраняет батч позиций наземных объектов в MySQL
+func (r *MySQLRepository) SaveGroundObjectsBatch(ctx context.Context, groundObjects []*models.GroundObject) error {
+	if len(groundObjects) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(groundObjects)*7)
+	validObjects := 0
+	for _, obj := range groundObjects {
+		if obj.Position == nil {
+			r.logger.WithField("device_id", obj.DeviceID).Warn("Ground object has nil position, skipping")
+			continue
+		}
+
+		// Конвертируем hex device ID в int
+		addr, err := models.DeviceAddr(obj.DeviceID)
+		if err != nil {
+			r.logger.WithField("device_id", obj.DeviceID).WithField("error", err).Warn("Invalid device ID format, skipping")
+			continue
+		}
+
+		args = append(args,
+			addr, uint8(obj.Type), obj.Position.Latitude, obj.Position.Longitude,
+			obj.TrackOnline, obj.IsEmergency(), obj.LastUpdate)
+		validObjects++
+	}
+
+	if validObjects == 0 {
+		r.logger.Warn("No valid ground objects to save in batch")
+		return nil
+	}
+
+	query := `
+		INSERT INTO ground_track (
+			addr, ground_type, latitude, longitude, track_online, emergency, datestamp
+		) VALUES ` + r.generatePlaceholders(validObjects, 7)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to batch insert ground objects: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	r.logger.WithField("count", affected).Debug("Saved ground objects batch to MySQL")
+	return nil
+}
+
 // generatePlaceholders генерирует плейсхолдеры для batch INSERT
 func (r *MySQLRepository) generatePlaceholders(count, fieldsPerRecord int) string {
 	if count == 0 {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +23,8 @@ const (
 	ThermalsGeoKey      = "thermals:geo"      // GEO индекс для термиков
 	StationsGeoKey      = "stations:geo"      // GEO индекс для метеостанций
 	GroundObjectsGeoKey = "ground_objects:geo" // GEO индекс для наземных объектов
+	MessagesGeoKey      = "messages:geo"       // GEO индекс для текстовых сообщений
+	LandmarksGeoKey     = "landmarks:geo"      // GEO индекс для ориентиров и зон
 	
 	// Дополнительные индексы
 	ThermalsTimeKey = "thermals:time" // Z-SET индекс термиков по времени
@@ -32,6 +35,11 @@ const (
 	StationPrefix      = "station:"       // station:{addr}
 	GroundObjectPrefix = "ground:"        // ground:{addr}
 	TrackPrefix        = "track:"         // track:{addr} - список точек трека
+	MessagePrefix      = "message:"       // message:{id}
+	LandmarkPrefix     = "landmark:"      // landmark:{id}
+	
+	// Инциденты по сигналам бедствия
+	AlertsKey = "alerts" // HSET {id} -> JSON инцидента
 	
 	// Префиксы для клиентов и подписок
 	ClientPrefix        = "client:"         // client:{id}
@@ -50,8 +58,11 @@ const (
 	ThermalTTL      = 6 * time.Hour      // 21600 секунд
 	StationTTL      = 24 * time.Hour     // 86400 секунд
 	GroundObjectTTL = 4 * time.Hour      // 14400 секунд
+	MessageTTL      = 6 * time.Hour      // 21600 секунд
+	LandmarkTTL     = 8 * time.Hour      // 28800 секунд (максимальное время жизни ориентира в FANET)
 	ClientTTL       = 5 * time.Minute    // 300 секунд
 	AuthTokenTTL    = 1 * time.Hour      // 3600 секунд
+	AlertTTL        = 7 * 24 * time.Hour // Продлевается при каждой записи и очистке инцидентов
 	
 	// Настройки для списков
 	MaxTrackPoints    = 999          // Максимум точек в треке
@@ -196,8 +207,22 @@ func (r *RedisRepository) SavePilot(ctx context.Context, pilot *models.Pilot) er
 	if pilot.VisibilityStatus != "" {
 		pilotData["visibility_status"] = pilot.VisibilityStatus
 	}
+	if pilot.Reception != nil {
+		pilotData["receivers"] = pilot.Reception.Stations
+		pilotData["best_snr"] = pilot.Reception.BestSNR
+		pilotData["best_rssi"] = pilot.Reception.BestRSSI
+	}
+	if pilot.Smoothed != nil {
+		if smoothed, err := json.Marshal(pilot.Smoothed); err == nil {
+			pilotData["smoothed"] = smoothed
+		}
+	}
 	
 	pipe.HSet(ctx, pilotKey, pilotData)
+	if pilot.Smoothed == nil {
+		// Сглаживание могло быть сброшено: устаревшая оценка не должна остаться в хеше
+		pipe.HDel(ctx, pilotKey, "smoothed")
+	}
 
 	// Устанавливаем TTL
 	pipe.Expire(ctx, pilotKey, PilotTTL)
@@ -750,6 +775,8 @@ func (r *RedisRepository) GetStats(ctx context.Context) (map[string]interface{},
 	pilotsCountCmd := pipe.ZCard(ctx, PilotsGeoKey)
 	thermalsCountCmd := pipe.ZCard(ctx, ThermalsGeoKey)
 	stationsCountCmd := pipe.ZCard(ctx, StationsGeoKey)
+	messagesCountCmd := pipe.ZCard(ctx, MessagesGeoKey)
+	landmarksCountCmd := pipe.ZCard(ctx, LandmarksGeoKey)
 	infoCmd := pipe.Info(ctx, "memory")
 	
 	_, err := pipe.Exec(ctx)
@@ -761,6 +788,8 @@ func (r *RedisRepository) GetStats(ctx context.Context) (map[string]interface{},
 		"pilots_count":   pilotsCountCmd.Val(),
 		"thermals_count": thermalsCountCmd.Val(),
 		"stations_count": stationsCountCmd.Val(),
+		"messages_count": messagesCountCmd.Val(),
+		"landmarks_count": landmarksCountCmd.Val(),
 		"memory_info":    infoCmd.Val(),
 	}
 	
@@ -866,6 +895,26 @@ func (r *RedisRepository) mapToPilot(deviceID string, data map[string]string, lo
 		pilot.VisibilityStatus = visStatus
 	}
 
+	// Качество приема (список станций хранится только в памяти ReceptionTracker)
+	if receiversStr, ok := data["receivers"]; ok {
+		if receivers, err := strconv.Atoi(receiversStr); err == nil && receivers > 0 {
+			pilot.Reception = &models.ReceptionStats{Stations: receivers}
+			if snr, err := strconv.ParseInt(data["best_snr"], 10, 16); err == nil {
+				pilot.Reception.BestSNR = int16(snr)
+			}
+			if rssi, err := strconv.ParseInt(data["best_rssi"], 10, 16); err == nil {
+				pilot.Reception.BestRSSI = int16(rssi)
+			}
+		}
+	}
+
+	if smoothedStr, ok := data["smoothed"]; ok && smoothedStr != "" {
+		var smoothed models.PilotEstimate
+		if err := json.Unmarshal([]byte(smoothedStr), &smoothed); err == nil {
+			pilot.Smoothed = &smoothed
+		}
+	}
+
 	return pilot, nil
 }
 
@@ -881,7 +930,7 @@ func (r *RedisRepository) mapToThermal(thermalID string, data map[string]string,
 
 	if addrStr, ok := data["addr"]; ok {
 		if addr, err := strconv.Atoi(addrStr); err == nil {
-			thermal.ReportedBy = fmt.Sprintf("%06X", addr)
+			thermal.ReportedBy = models.DeviceIDFromAddr(uint32(addr))
 		}
 	}
 
@@ -899,7 +948,7 @@ func (r *RedisRepository) mapToThermal(thermalID string, data map[string]string,
 
 	if climbStr, ok := data["climb"]; ok {
 		if climb, err := strconv.ParseFloat(climbStr, 64); err == nil {
-			thermal.ClimbRate = float32(climb) // м/с -> м/с * 10
+			thermal.ClimbRate = float32(climb) // м/с
 		}
 	}
 
@@ -1144,12 +1193,16 @@ func (r *RedisRepository) SaveGroundObject(ctx context.Context, groundObject *mo
 
 	// Сохраняем детальные данные в HSET
 	groundKey := GroundObjectPrefix + groundObject.DeviceID
-	pipe.HSet(ctx, groundKey, map[string]interface{}{
-		"name":         groundObject.Name,
+	fields := map[string]interface{}{
 		"type":         uint8(groundObject.Type),
 		"track_online": groundObject.TrackOnline,
 		"last_update":  groundObject.LastUpdate.Unix(),
-	})
+	}
+	// Имя приходит отдельным пакетом (Type 2) - не затираем его пустым значением
+	if groundObject.Name != "" {
+		fields["name"] = groundObject.Name
+	}
+	pipe.HSet(ctx, groundKey, fields)
 
 	// Устанавливаем TTL (4 часа для наземных объектов)
 	pipe.Expire(ctx, groundKey, GroundObjectTTL)
@@ -1176,6 +1229,41 @@ func (r *RedisRepository) SaveGroundObject(ctx context.Context, groundObject *mo
 	return nil
 }
 
+// GetGroundObject возвращает наземный объект по device ID или nil, если объект не найден
+func (r *RedisRepository) GetGroundObject(ctx context.Context, deviceID string) (*models.GroundObject, error) {
+	if deviceID == "" {
+		return nil, fmt.Errorf("device ID cannot be empty")
+	}
+
+	start := time.Now()
+
+	// Координаты наземного объекта хранятся только в GEO индексе
+	pipe := r.client.Pipeline()
+	dataCmd := pipe.HGetAll(ctx, GroundObjectPrefix+deviceID)
+	posCmd := pipe.GeoPos(ctx, GroundObjectsGeoKey, fmt.Sprintf("ground:%s", deviceID))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		metrics.RedisOperationErrors.WithLabelValues("get_ground_object").Inc()
+		return nil, fmt.Errorf("failed to get ground object: %w", err)
+	}
+
+	data := dataCmd.Val()
+	positions := posCmd.Val()
+	if len(data) == 0 || len(positions) == 0 || positions[0] == nil {
+		return nil, nil // Объект не найден или без координат
+	}
+
+	groundObject, err := r.mapToGroundObject(deviceID, data, &redis.GeoLocation{
+		Latitude:  positions[0].Latitude,
+		Longitude: positions[0].Longitude,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to map ground object data: %w", err)
+	}
+
+	metrics.RedisOperationDuration.WithLabelValues("get_ground_object").Observe(time.Since(start).Seconds())
+	return groundObject, nil
+}
+
 // GetGroundObjectsInRadius возвращает наземные объекты в радиусе от центра
 func (r *RedisRepository) GetGroundObjectsInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.GroundObject, error) {
 	start := time.Now()
@@ -1322,4 +1410,352 @@ func (r *RedisRepository) mapToGroundObject(deviceID string, data map[string]str
 	}
 
 	return groundObject, nil
-}
\ No newline at end of file
+}
+
+// SaveMessage сохраняет текстовое сообщение в Redis
+func (r *RedisRepository) SaveMessage(ctx context.Context, message *models.Message) error {
+	if message == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+	if message.Position == nil {
+		return fmt.Errorf("message position is required")
+	}
+
+	start := time.Now()
+	pipe := r.client.Pipeline()
+
+	// Сообщение без валидной позиции нельзя найти по радиусу, поэтому не сохраняем его
+	// Redis GEO ограничения: lat [-85.05112878, 85.05112878], lon [-180, 180]
+	if message.Position.Latitude == 0 && message.Position.Longitude == 0 ||
+		message.Position.Latitude < -85.05112878 || message.Position.Latitude > 85.05112878 ||
+		message.Position.Longitude < -180 || message.Position.Longitude > 180 ||
+		math.IsNaN(message.Position.Latitude) || math.IsNaN(message.Position.Longitude) ||
+		math.IsInf(message.Position.Latitude, 0) || math.IsInf(message.Position.Longitude, 0) {
+		return fmt.Errorf("invalid message coordinates: %f, %f", message.Position.Latitude, message.Position.Longitude)
+	}
+
+	pipe.GeoAdd(ctx, MessagesGeoKey, &redis.GeoLocation{
+		Name:      message.ID,
+		Latitude:  message.Position.Latitude,
+		Longitude: message.Position.Longitude,
+	})
+
+	// Сохраняем детальные данные
+	messageKey := MessagePrefix + message.ID
+	messageData, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message data: %w", err)
+	}
+
+	pipe.Set(ctx, messageKey, messageData, MessageTTL)
+	pipe.Expire(ctx, MessagesGeoKey, MessageTTL)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		metrics.RedisOperationErrors.WithLabelValues("save_message").Inc()
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+
+	r.logger.WithFields(map[string]interface{}{
+		"message_id": message.ID,
+		"from":       message.From,
+		"to":         message.To,
+		"lat":        message.Position.Latitude,
+		"lon":        message.Position.Longitude,
+	}).Debug("Saved message to Redis")
+
+	// Записываем метрики
+	duration := time.Since(start).Seconds()
+	metrics.RedisOperationDuration.WithLabelValues("save_message").Observe(duration)
+
+	return nil
+}
+
+// maxMessagesInRadius максимальное количество сообщений в ответе GetMessagesInRadius
+const maxMessagesInRadius = 500
+
+// GetMessagesInRadius возвращает текстовые сообщения в указанном радиусе (новые первыми)
+func (r *RedisRepository) GetMessagesInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Message, error) {
+	start := time.Now()
+
+	locations, err := r.client.GeoRadius(ctx, MessagesGeoKey, center.Longitude, center.Latitude, &redis.GeoRadiusQuery{
+		Radius:    radiusKM,
+		Unit:      "km",
+		WithCoord: true,
+	}).Result()
+
+	if err != nil && err != redis.Nil {
+		metrics.RedisOperationErrors.WithLabelValues("get_messages_radius").Inc()
+		return nil, fmt.Errorf("failed to get messages in radius: %w", err)
+	}
+
+	if len(locations) == 0 {
+		return []*models.Message{}, nil
+	}
+
+	// Получаем детальные данные
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(locations))
+
+	for i, loc := range locations {
+		cmds[i] = pipe.Get(ctx, MessagePrefix+loc.Name)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		metrics.RedisOperationErrors.WithLabelValues("get_messages_details").Inc()
+		return nil, fmt.Errorf("failed to get message details: %w", err)
+	}
+
+	messages := make([]*models.Message, 0, len(locations))
+	expired := make([]interface{}, 0)
+	for i, cmd := range cmds {
+		if cmd.Err() == redis.Nil {
+			// Детальные данные истекли по TTL - запись в GEO индексе больше не нужна
+			expired = append(expired, locations[i].Name)
+			continue
+		}
+		if cmd.Err() != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"message_id": locations[i].Name,
+				"error":      cmd.Err(),
+			}).Warn("Failed to get message data")
+			continue
+		}
+
+		var message models.Message
+		if err := json.Unmarshal([]byte(cmd.Val()), &message); err != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"message_id": locations[i].Name,
+				"error":      err,
+			}).Warn("Failed to unmarshal message data")
+			continue
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if len(expired) > 0 {
+		if err := r.client.ZRem(ctx, MessagesGeoKey, expired...).Err(); err != nil {
+			r.logger.WithField("error", err).Warn("Failed to remove expired messages from GEO index")
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Timestamp.After(messages[j].Timestamp)
+	})
+
+	// Лимит применяется после сортировки по времени: при ограничении GEORADIUS
+	// ближние старые сообщения вытесняли бы новые дальние
+	if len(messages) > maxMessagesInRadius {
+		messages = messages[:maxMessagesInRadius]
+	}
+
+	// Записываем метрики
+	duration := time.Since(start).Seconds()
+	metrics.RedisOperationDuration.WithLabelValues("get_messages_radius").Observe(duration)
+
+	return messages, nil
+}
+
+// SaveLandmark сохраняет ориентир в Redis.
+// В GEO индекс добавляется центр геометрии, детальные данные живут до ExpiresAt
+func (r *RedisRepository) SaveLandmark(ctx context.Context, landmark *models.Landmark) error {
+	if landmark == nil {
+		return fmt.Errorf("landmark cannot be nil")
+	}
+
+	start := time.Now()
+
+	// Ориентир без валидного центра нельзя найти по радиусу, поэтому не сохраняем его
+	// Redis GEO ограничения: lat [-85.05112878, 85.05112878], lon [-180, 180]
+	center := landmark.Center()
+	if center.Latitude == 0 && center.Longitude == 0 ||
+		center.Latitude < -85.05112878 || center.Latitude > 85.05112878 ||
+		center.Longitude < -180 || center.Longitude > 180 ||
+		math.IsNaN(center.Latitude) || math.IsNaN(center.Longitude) ||
+		math.IsInf(center.Latitude, 0) || math.IsInf(center.Longitude, 0) {
+		return fmt.Errorf("invalid landmark coordinates: %f, %f", center.Latitude, center.Longitude)
+	}
+
+	ttl := LandmarkTTL
+	if !landmark.ExpiresAt.IsZero() {
+		ttl = time.Until(landmark.ExpiresAt)
+		if ttl <= 0 {
+			return fmt.Errorf("landmark already expired")
+		}
+		if ttl > LandmarkTTL {
+			ttl = LandmarkTTL
+		}
+	}
+
+	landmarkData, err := json.Marshal(landmark)
+	if err != nil {
+		return fmt.Errorf("failed to marshal landmark data: %w", err)
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.GeoAdd(ctx, LandmarksGeoKey, &redis.GeoLocation{
+		Name:      landmark.ID,
+		Latitude:  center.Latitude,
+		Longitude: center.Longitude,
+	})
+	pipe.Set(ctx, LandmarkPrefix+landmark.ID, landmarkData, ttl)
+	pipe.Expire(ctx, LandmarksGeoKey, LandmarkTTL)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		metrics.RedisOperationErrors.WithLabelValues("save_landmark").Inc()
+		return fmt.Errorf("failed to save landmark: %w", err)
+	}
+
+	r.logger.WithFields(map[string]interface{}{
+		"landmark_id": landmark.ID,
+		"type":        landmark.Type.String(),
+		"layer":       landmark.Layer.String(),
+		"points":      len(landmark.Points),
+		"ttl":         ttl.String(),
+	}).Debug("Saved landmark to Redis")
+
+	// Записываем метрики
+	duration := time.Since(start).Seconds()
+	metrics.RedisOperationDuration.WithLabelValues("save_landmark").Observe(duration)
+
+	return nil
+}
+
+// GetLandmarksInRadius возвращает ориентиры, центр которых находится в указанном радиусе
+func (r *RedisRepository) GetLandmarksInRadius(ctx context.Context, center models.GeoPoint, radiusKM float64) ([]*models.Landmark, error) {
+	start := time.Now()
+
+	locations, err := r.client.GeoRadius(ctx, LandmarksGeoKey, center.Longitude, center.Latitude, &redis.GeoRadiusQuery{
+		Radius:    radiusKM,
+		Unit:      "km",
+		WithCoord: true,
+		Count:     500, // Максимум 500 ориентиров
+		Sort:      "ASC",
+	}).Result()
+
+	if err != nil && err != redis.Nil {
+		metrics.RedisOperationErrors.WithLabelValues("get_landmarks_radius").Inc()
+		return nil, fmt.Errorf("failed to get landmarks in radius: %w", err)
+	}
+
+	if len(locations) == 0 {
+		return []*models.Landmark{}, nil
+	}
+
+	// Получаем детальные данные
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(locations))
+
+	for i, loc := range locations {
+		cmds[i] = pipe.Get(ctx, LandmarkPrefix+loc.Name)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		metrics.RedisOperationErrors.WithLabelValues("get_landmarks_details").Inc()
+		return nil, fmt.Errorf("failed to get landmark details: %w", err)
+	}
+
+	landmarks := make([]*models.Landmark, 0, len(locations))
+	expired := make([]interface{}, 0)
+	for i, cmd := range cmds {
+		if cmd.Err() == redis.Nil {
+			// Время жизни ориентира истекло - удаляем его из GEO индекса
+			expired = append(expired, locations[i].Name)
+			continue
+		}
+		if cmd.Err() != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"landmark_id": locations[i].Name,
+				"error":       cmd.Err(),
+			}).Warn("Failed to get landmark data")
+			continue
+		}
+
+		var landmark models.Landmark
+		if err := json.Unmarshal([]byte(cmd.Val()), &landmark); err != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"landmark_id": locations[i].Name,
+				"error":       err,
+			}).Warn("Failed to unmarshal landmark data")
+			continue
+		}
+
+		landmarks = append(landmarks, &landmark)
+	}
+
+	if len(expired) > 0 {
+		if err := r.client.ZRem(ctx, LandmarksGeoKey, expired...).Err(); err != nil {
+			r.logger.WithField("error", err).Warn("Failed to remove expired landmarks from GEO index")
+		}
+	}
+
+	// Записываем метрики
+	duration := time.Since(start).Seconds()
+	metrics.RedisOperationDuration.WithLabelValues("get_landmarks_radius").Observe(duration)
+
+	return landmarks, nil
+}
+
+// SaveAlert сохраняет инцидент в хеш инцидентов и продлевает его TTL
+func (r *RedisRepository) SaveAlert(ctx context.Context, alert *models.Alert) error {
+	if alert == nil {
+		return fmt.Errorf("alert cannot be nil")
+	}
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, AlertsKey, alert.ID, data)
+	pipe.Expire(ctx, AlertsKey, AlertTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		metrics.RedisOperationErrors.WithLabelValues("save_alert").Inc()
+		return fmt.Errorf("failed to save alert: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlerts удаляет инциденты и продлевает TTL хеша оставшихся.
+// Без ID только продлевает TTL
+func (r *RedisRepository) DeleteAlerts(ctx context.Context, ids ...string) error {
+	pipe := r.client.TxPipeline()
+	if len(ids) > 0 {
+		pipe.HDel(ctx, AlertsKey, ids...)
+	}
+	pipe.Expire(ctx, AlertsKey, AlertTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		metrics.RedisOperationErrors.WithLabelValues("delete_alerts").Inc()
+		return fmt.Errorf("failed to delete alerts: %w", err)
+	}
+	return nil
+}
+
+// LoadAlerts возвращает все сохраненные инциденты. Поврежденные записи пропускаются
+func (r *RedisRepository) LoadAlerts(ctx context.Context) ([]*models.Alert, error) {
+	data, err := r.client.HGetAll(ctx, AlertsKey).Result()
+	if err != nil {
+		metrics.RedisOperationErrors.WithLabelValues("load_alerts").Inc()
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+
+	alerts := make([]*models.Alert, 0, len(data))
+	for id, value := range data {
+		var alert models.Alert
+		if err := json.Unmarshal([]byte(value), &alert); err != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"alert_id": id,
+				"error":    err,
+			}).Warn("Failed to unmarshal alert data")
+			continue
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	return alerts, nil
+}
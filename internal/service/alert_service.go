@@ -0,0 +1,550 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// AlertEvent событие жизненного цикла инцидента
+type AlertEvent string
+
+const (
+	AlertEventOpened       AlertEvent = "opened"       // Новый инцидент
+	AlertEventUpdated      AlertEvent = "updated"      // Новые пакеты по открытому инциденту
+	AlertEventAcknowledged AlertEvent = "acknowledged" // Инцидент принят администратором
+	AlertEventResolved     AlertEvent = "resolved"     // Инцидент закрыт
+)
+
+// AlertNotifier внешний канал оповещения (webhook, SMS-шлюз и т.п.)
+type AlertNotifier interface {
+	Notify(ctx context.Context, event AlertEvent, alert *models.Alert) error
+}
+
+// AlertStore хранилище инцидентов, переживающее перезапуск (реализуется repository.RedisRepository)
+type AlertStore interface {
+	SaveAlert(ctx context.Context, alert *models.Alert) error
+	DeleteAlerts(ctx context.Context, ids ...string) error
+	LoadAlerts(ctx context.Context) ([]*models.Alert, error)
+}
+
+// alertStoreTimeout таймаут записи в хранилище инцидентов
+const alertStoreTimeout = 2 * time.Second
+
+// AlertBroadcaster доставляет инциденты подключенным клиентам (реализуется WebSocket обработчиком)
+type AlertBroadcaster interface {
+	BroadcastAlert(event AlertEvent, alert *models.Alert)
+}
+
+// AlertConfig конфигурация сервиса инцидентов
+type AlertConfig struct {
+	// Время после закрытия, в течение которого повторные сигналы от устройства игнорируются
+	ResolveCooldown time.Duration
+	// Минимальный интервал между WebSocket обновлениями одного инцидента
+	UpdateInterval time.Duration
+	// Таймаут вызова внешнего оповещения
+	NotifyTimeout time.Duration
+}
+
+// DefaultAlertConfig возвращает конфигурацию по умолчанию
+func DefaultAlertConfig() *AlertConfig {
+	return &AlertConfig{
+		ResolveCooldown: 10 * time.Minute,
+		UpdateInterval:  30 * time.Second,
+		NotifyTimeout:   5 * time.Second,
+	}
+}
+
+// AlertService ведет инциденты по сигналам бедствия от наземных объектов
+type AlertService struct {
+	alerts   map[string]*models.Alert // ID инцидента -> инцидент
+	byDevice map[string]string        // Device ID -> ID последнего инцидента
+	lastPush map[string]time.Time     // ID инцидента -> время последнего WebSocket обновления
+	version  uint64                   // Номер последнего снимка для хранилища
+	mu       sync.RWMutex
+
+	persisted map[string]uint64 // ID инцидента -> номер записанного снимка
+	storeMu   sync.Mutex        // Упорядочивает записи в хранилище, не блокируя mu
+
+	config      *AlertConfig
+	logger      *utils.Logger
+	notifier    AlertNotifier
+	broadcaster AlertBroadcaster
+	store       AlertStore
+}
+
+// NewAlertService создает новый сервис инцидентов. notifier может быть nil
+func NewAlertService(logger *utils.Logger, config *AlertConfig, notifier AlertNotifier) *AlertService {
+	if config == nil {
+		config = DefaultAlertConfig()
+	}
+
+	return &AlertService{
+		alerts:   make(map[string]*models.Alert),
+		byDevice: make(map[string]string),
+		lastPush: make(map[string]time.Time),
+		config:   config,
+		logger:   logger,
+		notifier: notifier,
+
+		persisted: make(map[string]uint64),
+	}
+}
+
+// SetBroadcaster устанавливает канал доставки инцидентов клиентам
+func (s *AlertService) SetBroadcaster(broadcaster AlertBroadcaster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcaster = broadcaster
+}
+
+// Restore подключает хранилище и загружает из него инциденты, чтобы открытые
+// инциденты и период после закрытия пережили перезапуск. Вызывается до начала
+// обработки пакетов. Изменения инцидентов сохраняются в хранилище, даже если
+// загрузка не удалась. Возвращает количество загруженных инцидентов
+func (s *AlertService) Restore(ctx context.Context, store AlertStore) (int, error) {
+	alerts, err := store.LoadAlerts(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+	if err != nil {
+		return 0, err
+	}
+
+	for _, alert := range alerts {
+		s.alerts[alert.ID] = alert
+
+		// Устройству соответствует последний по времени открытия инцидент
+		if id, ok := s.byDevice[alert.DeviceID]; !ok || s.alerts[id].FirstSeen.Before(alert.FirstSeen) {
+			s.byDevice[alert.DeviceID] = alert.ID
+		}
+		if alert.IsActive() {
+			s.lastPush[alert.ID] = alert.LastSeen
+		}
+	}
+	s.updateActiveGauge()
+
+	return len(alerts), nil
+}
+
+// ProcessGroundObject обрабатывает пакет наземного объекта.
+// Возвращает инцидент (копию) и признак того, что инцидент был открыт этим пакетом.
+// Для пакетов без сигнала бедствия возвращает (nil, false)
+func (s *AlertService) ProcessGroundObject(obj *models.GroundObject) (*models.Alert, bool) {
+	if obj == nil || !models.IsAlertType(obj.Type) {
+		return nil, false
+	}
+
+	deviceID := obj.DeviceID
+	if deviceID == "" {
+		deviceID = obj.Address
+	}
+
+	now := obj.LastUpdate
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	s.mu.Lock()
+
+	if id, ok := s.byDevice[deviceID]; ok {
+		alert := s.alerts[id]
+		switch {
+		case alert == nil:
+			// Инцидент был удален очисткой, открываем новый
+		case alert.IsActive():
+			// Дедупликация: повторный пакет обновляет существующий инцидент
+			alert.LastSeen = now
+			alert.PacketCount++
+			alert.Type = obj.Type
+			if obj.Position != nil {
+				pos := *obj.Position
+				alert.Position = &pos
+			}
+
+			// Счетчики в хранилище обновляются с той же частотой, что и у клиентов
+			var push bool
+			var write *alertWrite
+			if now.Sub(s.lastPush[id]) >= s.config.UpdateInterval {
+				s.lastPush[id] = now
+				push = true
+				write = s.snapshotForStore(alert)
+			}
+			snapshot := *alert
+			broadcaster := s.broadcaster
+			s.mu.Unlock()
+
+			s.persist(write)
+			if push && broadcaster != nil {
+				broadcaster.BroadcastAlert(AlertEventUpdated, &snapshot)
+			}
+			return &snapshot, false
+		case alert.ResolvedAt != nil && now.Sub(*alert.ResolvedAt) < s.config.ResolveCooldown:
+			// Устройство продолжает передавать сигнал сразу после закрытия инцидента
+			s.mu.Unlock()
+			return nil, false
+		}
+	}
+
+	alert := &models.Alert{
+		ID:          models.GenerateAlertID(deviceID, now),
+		DeviceID:    deviceID,
+		Type:        obj.Type,
+		Status:      models.AlertStatusOpen,
+		FirstSeen:   now,
+		LastSeen:    now,
+		PacketCount: 1,
+	}
+	if obj.Position != nil {
+		pos := *obj.Position
+		alert.Position = &pos
+	}
+
+	s.alerts[alert.ID] = alert
+	s.byDevice[deviceID] = alert.ID
+	s.lastPush[alert.ID] = now
+	s.updateActiveGauge()
+	write := s.snapshotForStore(alert)
+
+	snapshot := *alert
+	s.mu.Unlock()
+
+	s.persist(write)
+	metrics.AlertsOpened.WithLabelValues(obj.Type.String()).Inc()
+
+	s.logger.WithFields(map[string]interface{}{
+		"alert_id":  snapshot.ID,
+		"device_id": deviceID,
+		"type":      obj.Type.String(),
+	}).Warn("Distress alert opened")
+
+	s.publish(AlertEventOpened, &snapshot)
+	return &snapshot, true
+}
+
+// Acknowledge переводит инцидент в статус "принят"
+func (s *AlertService) Acknowledge(id string, userID int, note string) (*models.Alert, error) {
+	s.mu.Lock()
+
+	alert, ok := s.alerts[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("alert not found: %s", id)
+	}
+	if alert.Status != models.AlertStatusOpen {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("alert %s is already %s", id, alert.Status)
+	}
+
+	now := time.Now()
+	alert.Status = models.AlertStatusAcknowledged
+	alert.AcknowledgedBy = userID
+	alert.AcknowledgedAt = &now
+	if note != "" {
+		alert.Note = note
+	}
+	write := s.snapshotForStore(alert)
+
+	snapshot := *alert
+	s.mu.Unlock()
+
+	s.persist(write)
+	s.logger.WithFields(map[string]interface{}{
+		"alert_id": id,
+		"user_id":  userID,
+	}).Info("Distress alert acknowledged")
+
+	s.publish(AlertEventAcknowledged, &snapshot)
+	return &snapshot, nil
+}
+
+// Resolve закрывает инцидент. Закрыть можно только принятый инцидент:
+// открытый инцидент сначала принимается (Acknowledge), чтобы у каждого
+// закрытого сигнала бедствия был ответственный
+func (s *AlertService) Resolve(id string, userID int, note string) (*models.Alert, error) {
+	s.mu.Lock()
+
+	alert, ok := s.alerts[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("alert not found: %s", id)
+	}
+	switch alert.Status {
+	case models.AlertStatusResolved:
+		s.mu.Unlock()
+		return nil, fmt.Errorf("alert %s is already %s", id, alert.Status)
+	case models.AlertStatusOpen:
+		s.mu.Unlock()
+		return nil, fmt.Errorf("alert %s must be acknowledged before it is resolved", id)
+	}
+
+	now := time.Now()
+	alert.Status = models.AlertStatusResolved
+	alert.ResolvedBy = userID
+	alert.ResolvedAt = &now
+	if note != "" {
+		alert.Note = note
+	}
+	delete(s.lastPush, id)
+	s.updateActiveGauge()
+	write := s.snapshotForStore(alert)
+
+	snapshot := *alert
+	s.mu.Unlock()
+
+	s.persist(write)
+	s.logger.WithFields(map[string]interface{}{
+		"alert_id": id,
+		"user_id":  userID,
+	}).Info("Distress alert resolved")
+
+	s.publish(AlertEventResolved, &snapshot)
+	return &snapshot, nil
+}
+
+// GetAlert возвращает инцидент по ID
+func (s *AlertService) GetAlert(id string) (*models.Alert, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alert, ok := s.alerts[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *alert
+	return &snapshot, true
+}
+
+// ListAlerts возвращает инциденты с заданным статусом (пустой статус - все), новые первыми
+func (s *AlertService) ListAlerts(status models.AlertStatus) []*models.Alert {
+	s.mu.RLock()
+	result := make([]*models.Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		if status != "" && alert.Status != status {
+			continue
+		}
+		snapshot := *alert
+		result = append(result, &snapshot)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FirstSeen.After(result[j].FirstSeen)
+	})
+
+	return result
+}
+
+// CleanupResolved удаляет закрытые инциденты старше maxAge.
+// Открытые и принятые инциденты не удаляются никогда
+func (s *AlertService) CleanupResolved(maxAge time.Duration) int {
+	s.mu.Lock()
+
+	now := time.Now()
+	var cleaned []string
+
+	for id, alert := range s.alerts {
+		if alert.ResolvedAt == nil || now.Sub(*alert.ResolvedAt) <= maxAge {
+			continue
+		}
+		delete(s.alerts, id)
+		if s.byDevice[alert.DeviceID] == id {
+			delete(s.byDevice, alert.DeviceID)
+		}
+		cleaned = append(cleaned, id)
+	}
+	store := s.store
+	s.mu.Unlock()
+
+	// Вызывается и без удаленных инцидентов: хранилище продлевает срок хранения остальных
+	if store != nil {
+		s.storeMu.Lock()
+		for _, id := range cleaned {
+			delete(s.persisted, id)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), alertStoreTimeout)
+		if err := store.DeleteAlerts(ctx, cleaned...); err != nil {
+			s.logger.WithField("error", err).Error("Failed to delete resolved alerts from store")
+		}
+		cancel()
+		s.storeMu.Unlock()
+	}
+
+	if len(cleaned) > 0 {
+		s.logger.WithField("cleaned", len(cleaned)).Debug("Cleaned up resolved alerts")
+	}
+
+	return len(cleaned)
+}
+
+// alertWrite снимок инцидента для записи в хранилище
+type alertWrite struct {
+	alert   models.Alert
+	version uint64
+	store   AlertStore
+}
+
+// snapshotForStore снимает копию инцидента для persist. Вызывается под
+// блокировкой, возвращает nil без хранилища
+func (s *AlertService) snapshotForStore(alert *models.Alert) *alertWrite {
+	if s.store == nil {
+		return nil
+	}
+	s.version++
+	return &alertWrite{alert: *alert, version: s.version, store: s.store}
+}
+
+// persist сохраняет снимок инцидента в хранилище. Вызывается после снятия
+// блокировки, чтобы запись в Redis не задерживала обработку пакетов. Снимок,
+// который обогнал более новый снимок того же инцидента, не записывается.
+// Ошибка хранилища не мешает работе с инцидентом в памяти
+func (s *AlertService) persist(write *alertWrite) {
+	if write == nil {
+		return
+	}
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if write.version <= s.persisted[write.alert.ID] {
+		return
+	}
+	s.persisted[write.alert.ID] = write.version
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertStoreTimeout)
+	defer cancel()
+
+	if err := write.store.SaveAlert(ctx, &write.alert); err != nil {
+		s.logger.WithField("error", err).
+			WithField("alert_id", write.alert.ID).
+			Error("Failed to persist alert")
+	}
+}
+
+// publish рассылает событие клиентам и во внешний канал оповещения
+func (s *AlertService) publish(event AlertEvent, alert *models.Alert) {
+	s.mu.RLock()
+	broadcaster := s.broadcaster
+	s.mu.RUnlock()
+
+	if broadcaster != nil {
+		broadcaster.BroadcastAlert(event, alert)
+	}
+
+	if s.notifier == nil {
+		return
+	}
+
+	// Внешний вызов не должен блокировать обработку MQTT
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.NotifyTimeout)
+		defer cancel()
+
+		if err := s.notifier.Notify(ctx, event, alert); err != nil {
+			metrics.AlertNotifications.WithLabelValues("error").Inc()
+			s.logger.WithField("error", err).
+				WithField("alert_id", alert.ID).
+				WithField("event", string(event)).
+				Error("Failed to send alert notification")
+			return
+		}
+		metrics.AlertNotifications.WithLabelValues("success").Inc()
+	}()
+}
+
+// updateActiveGauge обновляет метрику активных инцидентов (вызывается под блокировкой)
+func (s *AlertService) updateActiveGauge() {
+	active := 0
+	for _, alert := range s.alerts {
+		if alert.IsActive() {
+			active++
+		}
+	}
+	metrics.AlertsActive.Set(float64(active))
+}
+
+// WebhookNotifier отправляет события инцидентов POST запросом с JSON телом
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// WebhookPayload тело webhook запроса
+type WebhookPayload struct {
+	Event     AlertEvent    `json:"event"`
+	Alert     *models.Alert `json:"alert"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+// NewWebhookNotifier создает webhook оповещение. Если client nil, используется http.DefaultClient
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookNotifier{
+		url:    url,
+		client: client,
+	}
+}
+
+// GatewayWebhookPayload тело webhook запроса о состоянии базовой станции
+type GatewayWebhookPayload struct {
+	Event     GatewayEvent    `json:"event"`
+	Gateway   *models.Gateway `json:"gateway"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// Notify реализует AlertNotifier
+func (w *WebhookNotifier) Notify(ctx context.Context, event AlertEvent, alert *models.Alert) error {
+	return w.post(ctx, WebhookPayload{
+		Event:     event,
+		Alert:     alert,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// NotifyGateway реализует GatewayNotifier
+func (w *WebhookNotifier) NotifyGateway(ctx context.Context, event GatewayEvent, gateway *models.Gateway) error {
+	return w.post(ctx, GatewayWebhookPayload{
+		Event:     event,
+		Gateway:   gateway,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// post отправляет payload в формате JSON
+func (w *WebhookNotifier) post(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
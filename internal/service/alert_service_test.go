@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBroadcaster запоминает разосланные события
+type recordingBroadcaster struct {
+	mu     sync.Mutex
+	events []AlertEvent
+}
+
+func (b *recordingBroadcaster) BroadcastAlert(event AlertEvent, alert *models.Alert) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+func (b *recordingBroadcaster) Events() []AlertEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]AlertEvent(nil), b.events...)
+}
+
+func distressObject(deviceID string, groundType models.GroundType, at time.Time) *models.GroundObject {
+	return &models.GroundObject{
+		DeviceID:   deviceID,
+		Type:       groundType,
+		Position:   &models.GeoPoint{Latitude: 46.05, Longitude: 14.50},
+		LastUpdate: at,
+	}
+}
+
+func TestAlertService_Lifecycle(t *testing.T) {
+	logger := utils.NewLogger("debug", "text")
+	broadcaster := &recordingBroadcaster{}
+	svc := NewAlertService(logger, nil, nil)
+	svc.SetBroadcaster(broadcaster)
+
+	t.Run("non distress packet is ignored", func(t *testing.T) {
+		alert, opened := svc.ProcessGroundObject(distressObject("AABBCC", models.GroundTypeWalking, time.Now()))
+		assert.Nil(t, alert)
+		assert.False(t, opened)
+
+		alert, opened = svc.ProcessGroundObject(distressObject("AABBCC", models.GroundTypeNeedTechnicalSupport, time.Now()))
+		assert.Nil(t, alert)
+		assert.False(t, opened)
+	})
+
+	now := time.Now()
+	alert, opened := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, now))
+	require.NotNil(t, alert)
+	assert.True(t, opened)
+	assert.Equal(t, models.AlertStatusOpen, alert.Status)
+	assert.Equal(t, 1, alert.PacketCount)
+
+	t.Run("repeated packets are deduplicated", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			dup, opened := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, now.Add(time.Duration(i)*time.Second)))
+			require.NotNil(t, dup)
+			assert.False(t, opened)
+			assert.Equal(t, alert.ID, dup.ID)
+		}
+
+		current, ok := svc.GetAlert(alert.ID)
+		require.True(t, ok)
+		assert.Equal(t, 4, current.PacketCount)
+		assert.Len(t, svc.ListAlerts(""), 1)
+	})
+
+	t.Run("acknowledge", func(t *testing.T) {
+		acked, err := svc.Acknowledge(alert.ID, 42, "rescue team dispatched")
+		require.NoError(t, err)
+		assert.Equal(t, models.AlertStatusAcknowledged, acked.Status)
+		assert.Equal(t, 42, acked.AcknowledgedBy)
+		assert.NotNil(t, acked.AcknowledgedAt)
+
+		_, err = svc.Acknowledge(alert.ID, 42, "")
+		assert.Error(t, err, "acknowledging twice must fail")
+
+		// Принятый инцидент остается активным и продолжает дедуплицировать пакеты,
+		// обновление позиции рассылается не чаще UpdateInterval
+		_, opened := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, now.Add(time.Minute)))
+		assert.False(t, opened)
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		resolved, err := svc.Resolve(alert.ID, 42, "pilot is safe")
+		require.NoError(t, err)
+		assert.Equal(t, models.AlertStatusResolved, resolved.Status)
+		assert.Equal(t, "pilot is safe", resolved.Note)
+		assert.False(t, resolved.IsActive())
+
+		_, err = svc.Resolve(alert.ID, 42, "")
+		assert.Error(t, err)
+
+		assert.Empty(t, svc.ListAlerts(models.AlertStatusOpen))
+		assert.Len(t, svc.ListAlerts(models.AlertStatusResolved), 1)
+	})
+
+	t.Run("cooldown after resolve", func(t *testing.T) {
+		alert, opened := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, time.Now()))
+		assert.Nil(t, alert)
+		assert.False(t, opened)
+	})
+
+	t.Run("unknown alert", func(t *testing.T) {
+		_, err := svc.Acknowledge("missing", 1, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("open alert cannot be resolved without acknowledge", func(t *testing.T) {
+		other, opened := svc.ProcessGroundObject(distressObject("778899", models.GroundTypeNeedMedicalHelp, time.Now()))
+		require.True(t, opened)
+
+		_, err := svc.Resolve(other.ID, 42, "")
+		assert.Error(t, err)
+		current, _ := svc.GetAlert(other.ID)
+		assert.Equal(t, models.AlertStatusOpen, current.Status)
+	})
+
+	assert.Equal(t, []AlertEvent{
+		AlertEventOpened,
+		AlertEventAcknowledged,
+		AlertEventUpdated,
+		AlertEventResolved,
+		AlertEventOpened,
+	}, broadcaster.Events())
+}
+
+func TestAlertService_ReopenAfterCooldown(t *testing.T) {
+	logger := utils.NewLogger("debug", "text")
+	svc := NewAlertService(logger, &AlertConfig{
+		ResolveCooldown: time.Millisecond,
+		UpdateInterval:  time.Minute,
+		NotifyTimeout:   time.Second,
+	}, nil)
+
+	first, opened := svc.ProcessGroundObject(distressObject("445566", models.GroundTypeNeedMedicalHelp, time.Now()))
+	require.True(t, opened)
+	_, err := svc.Acknowledge(first.ID, 1, "")
+	require.NoError(t, err)
+	_, err = svc.Resolve(first.ID, 1, "")
+	require.NoError(t, err)
+
+	second, opened := svc.ProcessGroundObject(distressObject("445566", models.GroundTypeDistressCallAuto, time.Now().Add(time.Second)))
+	require.NotNil(t, second)
+	assert.True(t, opened)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, models.GroundTypeDistressCallAuto, second.Type)
+
+	// Закрытый инцидент удаляется очисткой, открытый остается
+	assert.Equal(t, 1, svc.CleanupResolved(0))
+	assert.Len(t, svc.ListAlerts(""), 1)
+}
+
+// memoryAlertStore хранит инциденты в JSON, как Redis
+type memoryAlertStore struct {
+	mu      sync.Mutex
+	alerts  map[string][]byte
+	loadErr error
+}
+
+func newMemoryAlertStore() *memoryAlertStore {
+	return &memoryAlertStore{alerts: make(map[string][]byte)}
+}
+
+func (s *memoryAlertStore) SaveAlert(ctx context.Context, alert *models.Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[alert.ID] = data
+	return nil
+}
+
+func (s *memoryAlertStore) DeleteAlerts(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.alerts, id)
+	}
+	return nil
+}
+
+func (s *memoryAlertStore) LoadAlerts(ctx context.Context) ([]*models.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	var alerts []*models.Alert
+	for _, data := range s.alerts {
+		var alert models.Alert
+		if err := json.Unmarshal(data, &alert); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts, nil
+}
+
+func TestAlertService_RestoreAfterRestart(t *testing.T) {
+	logger := utils.NewLogger("error", "text")
+	store := newMemoryAlertStore()
+	now := time.Now().Truncate(time.Second)
+
+	svc := NewAlertService(logger, nil, nil)
+	restored, err := svc.Restore(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored)
+
+	active, _ := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, now))
+	_, err = svc.Acknowledge(active.ID, 42, "rescue team dispatched")
+	require.NoError(t, err)
+
+	resolved, _ := svc.ProcessGroundObject(distressObject("445566", models.GroundTypeNeedMedicalHelp, now))
+	_, err = svc.Acknowledge(resolved.ID, 42, "")
+	require.NoError(t, err)
+	_, err = svc.Resolve(resolved.ID, 42, "pilot is safe")
+	require.NoError(t, err)
+
+	// Перезапуск: новый сервис загружает инциденты из хранилища
+	restarted := NewAlertService(logger, nil, nil)
+	restored, err = restarted.Restore(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+
+	current, ok := restarted.GetAlert(active.ID)
+	require.True(t, ok)
+	assert.Equal(t, models.AlertStatusAcknowledged, current.Status)
+	assert.Equal(t, "rescue team dispatched", current.Note)
+	assert.True(t, now.Equal(current.FirstSeen))
+
+	// Пакеты открытого инцидента продолжают дедуплицироваться
+	dup, opened := restarted.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, now.Add(time.Minute)))
+	require.NotNil(t, dup)
+	assert.False(t, opened)
+	assert.Equal(t, active.ID, dup.ID)
+	assert.Equal(t, 2, dup.PacketCount)
+
+	// Период после закрытия тоже переживает перезапуск
+	again, opened := restarted.ProcessGroundObject(distressObject("445566", models.GroundTypeNeedMedicalHelp, now.Add(time.Second)))
+	assert.Nil(t, again)
+	assert.False(t, opened)
+
+	// Очистка удаляет закрытый инцидент и из хранилища
+	assert.Equal(t, 1, restarted.CleanupResolved(0))
+	alerts, err := store.LoadAlerts(context.Background())
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, active.ID, alerts[0].ID)
+	assert.Equal(t, 2, alerts[0].PacketCount)
+}
+
+// blockingAlertStore задерживает запись, пока тест не отпустит ее
+type blockingAlertStore struct {
+	*memoryAlertStore
+	saving  chan string
+	release chan struct{}
+}
+
+func (s *blockingAlertStore) SaveAlert(ctx context.Context, alert *models.Alert) error {
+	s.saving <- alert.ID
+	<-s.release
+	return s.memoryAlertStore.SaveAlert(ctx, alert)
+}
+
+func TestAlertService_PersistOutsideLock(t *testing.T) {
+	store := &blockingAlertStore{
+		memoryAlertStore: newMemoryAlertStore(),
+		saving:           make(chan string, 1),
+		release:          make(chan struct{}),
+	}
+	svc := NewAlertService(utils.NewLogger("error", "text"), nil, nil)
+	_, err := svc.Restore(context.Background(), store)
+	require.NoError(t, err)
+
+	opened := make(chan *models.Alert, 1)
+	go func() {
+		alert, _ := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, time.Now()))
+		opened <- alert
+	}()
+	id := <-store.saving
+
+	// Пока запись в хранилище висит, инцидент уже доступен
+	read := make(chan *models.Alert, 1)
+	go func() {
+		current, _ := svc.GetAlert(id)
+		read <- current
+	}()
+	select {
+	case current := <-read:
+		require.NotNil(t, current)
+		assert.Equal(t, models.AlertStatusOpen, current.Status)
+	case <-time.After(time.Second):
+		t.Fatal("alert service is locked during the store write")
+	}
+
+	close(store.release)
+	require.Equal(t, id, (<-opened).ID)
+	assert.Contains(t, store.alerts, id)
+}
+
+func TestAlertService_RestoreError(t *testing.T) {
+	store := newMemoryAlertStore()
+	store.loadErr = errors.New("connection refused")
+
+	svc := NewAlertService(utils.NewLogger("error", "text"), nil, nil)
+	restored, err := svc.Restore(context.Background(), store)
+	assert.Error(t, err)
+	assert.Equal(t, 0, restored)
+
+	// Новые инциденты сохраняются, даже если загрузка не удалась
+	alert, opened := svc.ProcessGroundObject(distressObject("112233", models.GroundTypeDistressCall, time.Now()))
+	require.True(t, opened)
+	assert.Contains(t, store.alerts, alert.ID)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan WebhookPayload, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- payload
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	logger := utils.NewLogger("debug", "text")
+	svc := NewAlertService(logger, nil, NewWebhookNotifier(server.URL, server.Client()))
+
+	alert, opened := svc.ProcessGroundObject(distressObject("778899", models.GroundTypeDistressCall, time.Now()))
+	require.True(t, opened)
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, AlertEventOpened, payload.Event)
+		require.NotNil(t, payload.Alert)
+		assert.Equal(t, alert.ID, payload.Alert.ID)
+		assert.Equal(t, "778899", payload.Alert.DeviceID)
+		assert.Equal(t, models.AlertStatusOpen, payload.Alert.Status)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	_, err := svc.Acknowledge(alert.ID, 7, "")
+	require.NoError(t, err)
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, AlertEventAcknowledged, payload.Event)
+		assert.Equal(t, 7, payload.Alert.AcknowledgedBy)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called on acknowledge")
+	}
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, server.Client())
+	err := notifier.Notify(context.Background(), AlertEventOpened, &models.Alert{ID: "x"})
+	assert.Error(t, err)
+}
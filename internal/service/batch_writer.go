@@ -22,11 +22,13 @@ type BatchWriter struct {
 	pilotChan   chan *models.Pilot
 	thermalChan chan *models.Thermal
 	stationChan chan *models.Station
+	groundChan  chan *models.GroundObject
 
 	// Буферы для батчинга
 	pilotBuffer   []*models.Pilot
 	thermalBuffer []*models.Thermal
 	stationBuffer []*models.Station
+	groundBuffer  []*models.GroundObject
 
 	// Контроль жизненного цикла
 	ctx    context.Context
@@ -39,12 +41,12 @@ type BatchWriter struct {
 
 // BatchConfig конфигурация батчера
 type BatchConfig struct {
-	BatchSize       int           `json:"batch_size"`        // Размер батча
-	FlushInterval   time.Duration `json:"flush_interval"`    // Интервал принудительного flush
-	ChannelBuffer   int           `json:"channel_buffer"`    // Размер буфера канала
-	WorkerCount     int           `json:"worker_count"`      // Количество worker'ов
-	MaxRetries      int           `json:"max_retries"`       // Максимум повторов
-	RetryDelay      time.Duration `json:"retry_delay"`       // Задержка между повторами
+	BatchSize     int           `json:"batch_size"`     // Размер батча
+	FlushInterval time.Duration `json:"flush_interval"` // Интервал принудительного flush
+	ChannelBuffer int           `json:"channel_buffer"` // Размер буфера канала
+	WorkerCount   int           `json:"worker_count"`   // Количество worker'ов
+	MaxRetries    int           `json:"max_retries"`    // Максимум повторов
+	RetryDelay    time.Duration `json:"retry_delay"`    // Задержка между повторами
 }
 
 // BatchMetrics метрики производительности
@@ -67,10 +69,16 @@ type BatchMetrics struct {
 	StationsProcessed int64 `json:"stations_processed"`
 	StationsErrors    int64 `json:"stations_errors"`
 
+	GroundObjectsQueued    int64 `json:"ground_objects_queued"`
+	GroundObjectsBatched   int64 `json:"ground_objects_batched"`
+	GroundObjectsProcessed int64 `json:"ground_objects_processed"`
+	GroundObjectsErrors    int64 `json:"ground_objects_errors"`
+
 	// Производительность
 	QueueDepthPilots   int64         `json:"queue_depth_pilots"`
 	QueueDepthThermals int64         `json:"queue_depth_thermals"`
 	QueueDepthStations int64         `json:"queue_depth_stations"`
+	QueueDepthGround   int64         `json:"queue_depth_ground_objects"`
 	LastFlushDuration  time.Duration `json:"last_flush_duration"`
 	LastBatchSize      int           `json:"last_batch_size"`
 }
@@ -78,11 +86,11 @@ type BatchMetrics struct {
 // DefaultBatchConfig возвращает конфигурацию по умолчанию
 func DefaultBatchConfig() *BatchConfig {
 	return &BatchConfig{
-		BatchSize:     1000,                // 1000 записей в батче
-		FlushInterval: 5 * time.Second,     // Flush каждые 5 секунд
-		ChannelBuffer: 10000,               // Буфер канала 10k записей
-		WorkerCount:   10,                  // 10 worker'ов для MySQL
-		MaxRetries:    3,                   // 3 попытки при ошибках
+		BatchSize:     1000,                   // 1000 записей в батче
+		FlushInterval: 5 * time.Second,        // Flush каждые 5 секунд
+		ChannelBuffer: 10000,                  // Буфер канала 10k записей
+		WorkerCount:   10,                     // 10 worker'ов для MySQL
+		MaxRetries:    3,                      // 3 попытки при ошибках
 		RetryDelay:    100 * time.Millisecond, // 100ms между попытками
 	}
 }
@@ -106,11 +114,13 @@ func NewBatchWriter(mysqlRepo repository.MySQLRepositoryInterface, logger *utils
 		pilotChan:   make(chan *models.Pilot, config.ChannelBuffer),
 		thermalChan: make(chan *models.Thermal, config.ChannelBuffer),
 		stationChan: make(chan *models.Station, config.ChannelBuffer),
+		groundChan:  make(chan *models.GroundObject, config.ChannelBuffer),
 
 		// Буферы для батчинга
 		pilotBuffer:   make([]*models.Pilot, 0, config.BatchSize),
 		thermalBuffer: make([]*models.Thermal, 0, config.BatchSize),
 		stationBuffer: make([]*models.Station, 0, config.BatchSize),
+		groundBuffer:  make([]*models.GroundObject, 0, config.BatchSize),
 
 		metrics: &BatchMetrics{},
 	}
@@ -134,7 +144,11 @@ func (bw *BatchWriter) start() {
 	// Worker для станций
 	bw.wg.Add(1)
 	go bw.stationWorker()
-	
+
+	// Worker для наземных объектов
+	bw.wg.Add(1)
+	go bw.groundObjectWorker()
+
 	// Worker для периодического обновления метрик
 	bw.wg.Add(1)
 	go bw.metricsWorker()
@@ -153,7 +167,7 @@ func (bw *BatchWriter) QueuePilot(pilot *models.Pilot) error {
 		bw.metrics.PilotsQueued++
 		bw.metrics.QueueDepthPilots = int64(len(bw.pilotChan))
 		bw.metrics.mu.Unlock()
-		
+
 		// Обновляем Prometheus метрику
 		metrics.MySQLQueueSize.WithLabelValues("pilots").Set(float64(len(bw.pilotChan)))
 		return nil
@@ -163,7 +177,7 @@ func (bw *BatchWriter) QueuePilot(pilot *models.Pilot) error {
 		bw.metrics.mu.Lock()
 		bw.metrics.PilotsErrors++
 		bw.metrics.mu.Unlock()
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 		return fmt.Errorf("pilot queue is full")
@@ -178,7 +192,7 @@ func (bw *BatchWriter) QueueThermal(thermal *models.Thermal) error {
 		bw.metrics.ThermalsQueued++
 		bw.metrics.QueueDepthThermals = int64(len(bw.thermalChan))
 		bw.metrics.mu.Unlock()
-		
+
 		// Обновляем Prometheus метрику
 		metrics.MySQLQueueSize.WithLabelValues("thermals").Set(float64(len(bw.thermalChan)))
 		return nil
@@ -188,7 +202,7 @@ func (bw *BatchWriter) QueueThermal(thermal *models.Thermal) error {
 		bw.metrics.mu.Lock()
 		bw.metrics.ThermalsErrors++
 		bw.metrics.mu.Unlock()
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 		return fmt.Errorf("thermal queue is full")
@@ -203,7 +217,7 @@ func (bw *BatchWriter) QueueStation(station *models.Station) error {
 		bw.metrics.StationsQueued++
 		bw.metrics.QueueDepthStations = int64(len(bw.stationChan))
 		bw.metrics.mu.Unlock()
-		
+
 		// Обновляем Prometheus метрику
 		metrics.MySQLQueueSize.WithLabelValues("stations").Set(float64(len(bw.stationChan)))
 		return nil
@@ -213,13 +227,38 @@ func (bw *BatchWriter) QueueStation(station *models.Station) error {
 		bw.metrics.mu.Lock()
 		bw.metrics.StationsErrors++
 		bw.metrics.mu.Unlock()
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 		return fmt.Errorf("station queue is full")
 	}
 }
 
+// QueueGroundObject добавляет наземный объект в очередь для сохранения
+func (bw *BatchWriter) QueueGroundObject(groundObject *models.GroundObject) error {
+	select {
+	case bw.groundChan <- groundObject:
+		bw.metrics.mu.Lock()
+		bw.metrics.GroundObjectsQueued++
+		bw.metrics.QueueDepthGround = int64(len(bw.groundChan))
+		bw.metrics.mu.Unlock()
+
+		// Обновляем Prometheus метрику
+		metrics.MySQLQueueSize.WithLabelValues("ground_objects").Set(float64(len(bw.groundChan)))
+		return nil
+	case <-bw.ctx.Done():
+		return fmt.Errorf("batch writer is shutting down")
+	default:
+		bw.metrics.mu.Lock()
+		bw.metrics.GroundObjectsErrors++
+		bw.metrics.mu.Unlock()
+
+		// Увеличиваем счетчик ошибок
+		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
+		return fmt.Errorf("ground object queue is full")
+	}
+}
+
 // pilotWorker обрабатывает батчи пилотов
 func (bw *BatchWriter) pilotWorker() {
 	defer bw.wg.Done()
@@ -231,7 +270,7 @@ func (bw *BatchWriter) pilotWorker() {
 		select {
 		case pilot := <-bw.pilotChan:
 			bw.pilotBuffer = append(bw.pilotBuffer, pilot)
-			
+
 			// Флашим при достижении размера батча
 			if len(bw.pilotBuffer) >= bw.config.BatchSize {
 				metrics.MySQLBatchFlushes.WithLabelValues("pilots", "size_limit").Inc()
@@ -267,7 +306,7 @@ func (bw *BatchWriter) thermalWorker() {
 		select {
 		case thermal := <-bw.thermalChan:
 			bw.thermalBuffer = append(bw.thermalBuffer, thermal)
-			
+
 			if len(bw.thermalBuffer) >= bw.config.BatchSize {
 				bw.flushThermals()
 			}
@@ -297,7 +336,7 @@ func (bw *BatchWriter) stationWorker() {
 		select {
 		case station := <-bw.stationChan:
 			bw.stationBuffer = append(bw.stationBuffer, station)
-			
+
 			if len(bw.stationBuffer) >= bw.config.BatchSize {
 				bw.flushStations()
 			}
@@ -316,6 +355,41 @@ func (bw *BatchWriter) stationWorker() {
 	}
 }
 
+// groundObjectWorker обрабатывает батчи наземных объектов
+func (bw *BatchWriter) groundObjectWorker() {
+	defer bw.wg.Done()
+
+	ticker := time.NewTicker(bw.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case groundObject := <-bw.groundChan:
+			if groundObject == nil {
+				// Сигнал принудительного flush
+				bw.flushGroundObjects()
+				continue
+			}
+			bw.groundBuffer = append(bw.groundBuffer, groundObject)
+
+			if len(bw.groundBuffer) >= bw.config.BatchSize {
+				bw.flushGroundObjects()
+			}
+
+		case <-ticker.C:
+			if len(bw.groundBuffer) > 0 {
+				bw.flushGroundObjects()
+			}
+
+		case <-bw.ctx.Done():
+			if len(bw.groundBuffer) > 0 {
+				bw.flushGroundObjects()
+			}
+			return
+		}
+	}
+}
+
 // flushPilots сохраняет батч пилотов в MySQL
 func (bw *BatchWriter) flushPilots() {
 	if len(bw.pilotBuffer) == 0 {
@@ -326,11 +400,11 @@ func (bw *BatchWriter) flushPilots() {
 	batch := make([]*models.Pilot, len(bw.pilotBuffer))
 	copy(batch, bw.pilotBuffer)
 	bw.pilotBuffer = bw.pilotBuffer[:0] // Очищаем буфер
-	
+
 	// Трекаем размер батча
 	batchSize := len(batch)
 	metrics.MySQLBatchSize.WithLabelValues("pilots").Observe(float64(batchSize))
-	
+
 	// Логируем детали батча
 	bw.logger.WithField("batch_size", batchSize).
 		WithField("queue_depth", len(bw.pilotChan)).
@@ -342,7 +416,7 @@ func (bw *BatchWriter) flushPilots() {
 	})
 
 	duration := time.Since(start)
-	
+
 	// Трекаем длительность операции
 	metrics.MySQLBatchDuration.WithLabelValues("pilots").Observe(duration.Seconds())
 
@@ -353,7 +427,7 @@ func (bw *BatchWriter) flushPilots() {
 			WithField("duration", duration).
 			WithField("error", err).
 			Error("Failed to flush pilots batch")
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 	} else {
@@ -366,7 +440,7 @@ func (bw *BatchWriter) flushPilots() {
 	bw.metrics.LastFlushDuration = duration
 	bw.metrics.LastBatchSize = len(batch)
 	bw.metrics.mu.Unlock()
-	
+
 	// Обновляем метрику размера очереди после flush
 	metrics.MySQLQueueSize.WithLabelValues("pilots").Set(float64(len(bw.pilotChan)))
 }
@@ -381,7 +455,7 @@ func (bw *BatchWriter) flushThermals() {
 	batch := make([]*models.Thermal, len(bw.thermalBuffer))
 	copy(batch, bw.thermalBuffer)
 	bw.thermalBuffer = bw.thermalBuffer[:0]
-	
+
 	// Трекаем размер батча
 	batchSize := len(batch)
 	metrics.MySQLBatchSize.WithLabelValues("thermals").Observe(float64(batchSize))
@@ -391,7 +465,7 @@ func (bw *BatchWriter) flushThermals() {
 	})
 
 	duration := time.Since(start)
-	
+
 	// Трекаем длительность операции
 	metrics.MySQLBatchDuration.WithLabelValues("pilots").Observe(duration.Seconds())
 
@@ -402,7 +476,7 @@ func (bw *BatchWriter) flushThermals() {
 			WithField("duration", duration).
 			WithField("error", err).
 			Error("Failed to flush thermals batch")
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 	} else {
@@ -413,7 +487,7 @@ func (bw *BatchWriter) flushThermals() {
 			Debug("Flushed thermals batch to MySQL")
 	}
 	bw.metrics.mu.Unlock()
-	
+
 	// Обновляем метрику размера очереди после flush
 	metrics.MySQLQueueSize.WithLabelValues("thermals").Set(float64(len(bw.thermalChan)))
 }
@@ -428,7 +502,7 @@ func (bw *BatchWriter) flushStations() {
 	batch := make([]*models.Station, len(bw.stationBuffer))
 	copy(batch, bw.stationBuffer)
 	bw.stationBuffer = bw.stationBuffer[:0]
-	
+
 	// Трекаем размер батча
 	batchSize := len(batch)
 	metrics.MySQLBatchSize.WithLabelValues("thermals").Observe(float64(batchSize))
@@ -438,7 +512,7 @@ func (bw *BatchWriter) flushStations() {
 	})
 
 	duration := time.Since(start)
-	
+
 	// Трекаем длительность операции
 	metrics.MySQLBatchDuration.WithLabelValues("pilots").Observe(duration.Seconds())
 
@@ -449,7 +523,7 @@ func (bw *BatchWriter) flushStations() {
 			WithField("duration", duration).
 			WithField("error", err).
 			Error("Failed to flush stations batch")
-		
+
 		// Увеличиваем счетчик ошибок
 		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
 	} else {
@@ -460,15 +534,62 @@ func (bw *BatchWriter) flushStations() {
 			Debug("Flushed stations batch to MySQL")
 	}
 	bw.metrics.mu.Unlock()
-	
+
 	// Обновляем метрику размера очереди после flush
 	metrics.MySQLQueueSize.WithLabelValues("stations").Set(float64(len(bw.stationChan)))
 }
 
+// flushGroundObjects сохраняет батч наземных объектов в MySQL
+func (bw *BatchWriter) flushGroundObjects() {
+	if len(bw.groundBuffer) == 0 {
+		return
+	}
+
+	start := time.Now()
+	batch := make([]*models.GroundObject, len(bw.groundBuffer))
+	copy(batch, bw.groundBuffer)
+	bw.groundBuffer = bw.groundBuffer[:0]
+
+	// Трекаем размер батча
+	metrics.MySQLBatchSize.WithLabelValues("ground_objects").Observe(float64(len(batch)))
+
+	err := bw.retryOperation(func() error {
+		return bw.mysqlRepo.SaveGroundObjectsBatch(bw.ctx, batch)
+	})
+
+	duration := time.Since(start)
+
+	// Трекаем длительность операции
+	metrics.MySQLBatchDuration.WithLabelValues("ground_objects").Observe(duration.Seconds())
+
+	bw.metrics.mu.Lock()
+	if err != nil {
+		bw.metrics.GroundObjectsErrors += int64(len(batch))
+		bw.logger.WithField("batch_size", len(batch)).
+			WithField("duration", duration).
+			WithField("error", err).
+			Error("Failed to flush ground objects batch")
+
+		// Увеличиваем счетчик ошибок
+		metrics.MySQLWriteErrors.WithLabelValues("queue_full").Inc()
+	} else {
+		bw.metrics.GroundObjectsBatched++
+		bw.metrics.GroundObjectsProcessed += int64(len(batch))
+		bw.logger.WithField("batch_size", len(batch)).
+			WithField("duration", duration).
+			Debug("Flushed ground objects batch to MySQL")
+	}
+	bw.metrics.QueueDepthGround = int64(len(bw.groundChan))
+	bw.metrics.mu.Unlock()
+
+	// Обновляем метрику размера очереди после flush
+	metrics.MySQLQueueSize.WithLabelValues("ground_objects").Set(float64(len(bw.groundChan)))
+}
+
 // retryOperation выполняет операцию с повторами
 func (bw *BatchWriter) retryOperation(operation func() error) error {
 	var lastErr error
-	
+
 	for attempt := 0; attempt <= bw.config.MaxRetries; attempt++ {
 		if attempt > 0 {
 			select {
@@ -508,12 +629,12 @@ func (bw *BatchWriter) GetMetrics() BatchMetrics {
 // metricsWorker периодически обновляет метрики
 func (bw *BatchWriter) metricsWorker() {
 	defer bw.wg.Done()
-	
+
 	metricsTicker := time.NewTicker(10 * time.Second) // Обновляем метрики каждые 10 секунд
 	statusTicker := time.NewTicker(60 * time.Second)  // Логируем статус каждую минуту
 	defer metricsTicker.Stop()
 	defer statusTicker.Stop()
-	
+
 	for {
 		select {
 		case <-metricsTicker.C:
@@ -521,11 +642,13 @@ func (bw *BatchWriter) metricsWorker() {
 			pilotsQueueSize := len(bw.pilotChan)
 			thermalsQueueSize := len(bw.thermalChan)
 			stationsQueueSize := len(bw.stationChan)
-			
+			groundQueueSize := len(bw.groundChan)
+
 			metrics.MySQLQueueSize.WithLabelValues("pilots").Set(float64(pilotsQueueSize))
 			metrics.MySQLQueueSize.WithLabelValues("thermals").Set(float64(thermalsQueueSize))
 			metrics.MySQLQueueSize.WithLabelValues("stations").Set(float64(stationsQueueSize))
-			
+			metrics.MySQLQueueSize.WithLabelValues("ground_objects").Set(float64(groundQueueSize))
+
 			// Обновляем статусные метрики
 			bw.metrics.mu.RLock()
 			metrics.MySQLBatchWriterStatus.WithLabelValues("pilots_queued").Set(float64(bw.metrics.PilotsQueued))
@@ -533,24 +656,26 @@ func (bw *BatchWriter) metricsWorker() {
 			metrics.MySQLBatchWriterStatus.WithLabelValues("pilots_errors").Set(float64(bw.metrics.PilotsErrors))
 			metrics.MySQLBatchWriterStatus.WithLabelValues("last_batch_size").Set(float64(bw.metrics.LastBatchSize))
 			bw.metrics.mu.RUnlock()
-			
+
 		case <-statusTicker.C:
 			// Периодическое логирование статуса
 			bw.metrics.mu.RLock()
 			bw.logger.WithFields(map[string]interface{}{
-				"pilots_queued":     bw.metrics.PilotsQueued,
-				"pilots_processed":  bw.metrics.PilotsProcessed,
-				"pilots_errors":     bw.metrics.PilotsErrors,
-				"pilots_queue_size": len(bw.pilotChan),
-				"thermals_queued":   bw.metrics.ThermalsQueued,
-				"thermals_processed": bw.metrics.ThermalsProcessed,
-				"stations_queued":   bw.metrics.StationsQueued,
-				"stations_processed": bw.metrics.StationsProcessed,
-				"last_batch_size":   bw.metrics.LastBatchSize,
-				"last_flush_ms":     bw.metrics.LastFlushDuration.Milliseconds(),
+				"pilots_queued":            bw.metrics.PilotsQueued,
+				"pilots_processed":         bw.metrics.PilotsProcessed,
+				"pilots_errors":            bw.metrics.PilotsErrors,
+				"pilots_queue_size":        len(bw.pilotChan),
+				"thermals_queued":          bw.metrics.ThermalsQueued,
+				"thermals_processed":       bw.metrics.ThermalsProcessed,
+				"stations_queued":          bw.metrics.StationsQueued,
+				"stations_processed":       bw.metrics.StationsProcessed,
+				"ground_objects_queued":    bw.metrics.GroundObjectsQueued,
+				"ground_objects_processed": bw.metrics.GroundObjectsProcessed,
+				"last_batch_size":          bw.metrics.LastBatchSize,
+				"last_flush_ms":            bw.metrics.LastFlushDuration.Milliseconds(),
 			}).Info("Batch writer status")
 			bw.metrics.mu.RUnlock()
-			
+
 		case <-bw.ctx.Done():
 			return
 		}
@@ -571,6 +696,7 @@ func (bw *BatchWriter) Stop() error {
 	close(bw.pilotChan)
 	close(bw.thermalChan)
 	close(bw.stationChan)
+	close(bw.groundChan)
 
 	bw.logger.Info("MySQL batch writer stopped")
 	return nil
@@ -580,7 +706,7 @@ func (bw *BatchWriter) Stop() error {
 func (bw *BatchWriter) Flush() error {
 	// Отправляем пустые объекты для принудительного flush
 	// Это безопасно, так как flush проверяет размер буфера
-	
+
 	select {
 	case bw.pilotChan <- nil:
 	case <-time.After(time.Second):
@@ -599,5 +725,11 @@ func (bw *BatchWriter) Flush() error {
 		return fmt.Errorf("timeout flushing stations")
 	}
 
+	select {
+	case bw.groundChan <- nil:
+	case <-time.After(time.Second):
+		return fmt.Errorf("timeout flushing ground objects")
+	}
+
 	return nil
-}
\ No newline at end of file
+}
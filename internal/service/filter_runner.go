@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// ErrInvalidFilterChain неизвестная цепочка или некорректное описание фильтров
+var ErrInvalidFilterChain = errors.New("invalid filter chain")
+
+// FilterTrackSource источник сохраненных треков (реализуется repository.MySQLRepository)
+type FilterTrackSource interface {
+	GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error)
+	GetPilotAircraftType(ctx context.Context, deviceID string) (models.PilotType, error)
+}
+
+// FilterRunRequest запуск цепочки фильтров над сохраненным треком: именованная
+// цепочка (из конфигурации или встроенные level1-level3) либо произвольный список фильтров
+type FilterRunRequest struct {
+	DeviceID string
+	From     time.Time
+	To       time.Time
+	Chain    string                  // Имя цепочки
+	Filters  []filter.StepSpec       // Произвольная цепочка (вместо Chain)
+	Config   *filter.ConfigOverrides // Переопределения DefaultFilterConfig для всех фильтров
+}
+
+// FilterRun результат запуска цепочки с отчетом по каждому фильтру
+type FilterRun struct {
+	DeviceID       string              `json:"addr"`
+	AircraftType   models.PilotType    `json:"aircraft_type"`
+	Chain          string              `json:"chain"`
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	OriginalPoints int                 `json:"original_points"`
+	FinalPoints    int                 `json:"final_points"`
+	Truncated      bool                `json:"truncated"` // Трек за период длиннее maxPoints
+	Statistics     filter.FilterStats  `json:"statistics"`
+	Steps          []filter.StepReport `json:"steps"`
+}
+
+// FilterRunner запускает цепочки фильтров над треками из MySQL для настройки
+// фильтрации без передеплоя
+type FilterRunner struct {
+	source    FilterTrackSource
+	chains    *filter.ChainSet
+	logger    *utils.Logger
+	maxPoints int
+}
+
+// NewFilterRunner создает сервис. chains может быть nil (только встроенные уровни)
+func NewFilterRunner(source FilterTrackSource, chains *filter.ChainSet, logger *utils.Logger, maxPoints int) *FilterRunner {
+	if maxPoints <= 0 {
+		maxPoints = 200000
+	}
+
+	return &FilterRunner{
+		source:    source,
+		chains:    chains,
+		logger:    logger,
+		maxPoints: maxPoints,
+	}
+}
+
+// Chains возвращает цепочки из конфигурации
+func (r *FilterRunner) Chains() *filter.ChainSet {
+	return r.chains
+}
+
+// Run загружает трек за период и применяет к нему цепочку фильтров
+func (r *FilterRunner) Run(ctx context.Context, request *FilterRunRequest) (*FilterRun, error) {
+	chain, name, err := r.buildChain(request)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := r.source.GetPilotTrackBetween(ctx, request.DeviceID, request.From, request.To, r.maxPoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load track: %w", err)
+	}
+
+	aircraftType, err := r.source.GetPilotAircraftType(ctx, request.DeviceID)
+	if err != nil {
+		r.logger.WithField("error", err).WithField("device_id", request.DeviceID).Warn("Failed to get aircraft type, using default")
+		aircraftType = models.PilotTypeUnknown
+	}
+
+	track := &filter.TrackData{
+		DeviceID:     request.DeviceID,
+		AircraftType: aircraftType,
+		Points:       make([]filter.TrackPoint, len(points)),
+	}
+	for i, point := range points {
+		track.Points[i] = filter.TrackPoint{
+			Position:  point.GeoPoint,
+			Timestamp: point.Timestamp,
+		}
+	}
+
+	result, steps, err := chain.FilterWithReport(track)
+	if err != nil {
+		return nil, fmt.Errorf("filter chain failed: %w", err)
+	}
+
+	run := &FilterRun{
+		DeviceID:       request.DeviceID,
+		AircraftType:   aircraftType,
+		Chain:          name,
+		From:           request.From,
+		To:             request.To,
+		OriginalPoints: len(points),
+		Truncated:      len(points) >= r.maxPoints,
+		Statistics:     result.Statistics,
+		Steps:          steps,
+	}
+	for _, point := range result.Points {
+		if !point.Filtered {
+			run.FinalPoints++
+		}
+	}
+
+	r.logger.WithField("device_id", request.DeviceID).
+		WithField("chain", name).
+		WithField("original_points", run.OriginalPoints).
+		WithField("final_points", run.FinalPoints).
+		Info("Filter chain run completed")
+
+	return run, nil
+}
+
+// buildChain создает цепочку запроса и возвращает ее имя
+func (r *FilterRunner) buildChain(request *FilterRunRequest) (*filter.FilterChain, string, error) {
+	base := request.Config.Apply(filter.DefaultFilterConfig())
+
+	if len(request.Filters) > 0 {
+		spec := filter.ChainSpec{Name: "custom", Filters: request.Filters}
+		chain, err := spec.Build(base, r.logger)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidFilterChain, err)
+		}
+		return chain, spec.Name, nil
+	}
+
+	if spec, ok := r.chains.Get(request.Chain); ok {
+		chain, err := spec.Build(base, r.logger)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidFilterChain, err)
+		}
+		return chain, spec.Name, nil
+	}
+
+	// Встроенные уровни фильтрации трека
+	levels := map[string]int{"level1": 1, "level2": 2, "level3": 3}
+	if level, ok := levels[request.Chain]; ok {
+		if chain, ok := r.chains.LevelChain(level, base, r.logger).(*filter.FilterChain); ok {
+			return chain, request.Chain, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: unknown chain %q", ErrInvalidFilterChain, request.Chain)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTrackSource сохраненный трек в памяти
+type fakeTrackSource struct {
+	points       []models.TrackGeoPoint
+	aircraftType models.PilotType
+	err          error
+}
+
+func (s *fakeTrackSource) GetPilotTrackBetween(ctx context.Context, deviceID string, from, to time.Time, limit int) ([]models.TrackGeoPoint, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.points) > limit {
+		return s.points[:limit], nil
+	}
+	return s.points, nil
+}
+
+func (s *fakeTrackSource) GetPilotAircraftType(ctx context.Context, deviceID string) (models.PilotType, error) {
+	return s.aircraftType, nil
+}
+
+// trackWithSpike прямой трек параглайдера с одной точкой-телепортацией и дублем
+func trackWithSpike(start time.Time) []models.TrackGeoPoint {
+	points := make([]models.TrackGeoPoint, 0, 62)
+	for i := 0; i < 60; i++ {
+		points = append(points, models.TrackGeoPoint{
+			GeoPoint:  models.GeoPoint{Latitude: 46 + float64(i)*0.001, Longitude: 14},
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+		})
+	}
+	// Телепортация на ~100 км между соседними точками
+	points[30].GeoPoint = models.GeoPoint{Latitude: 47, Longitude: 14}
+	// Дубль координат
+	points = append(points[:41], points[40:]...)
+	points[41].Timestamp = points[40].Timestamp.Add(time.Second)
+	return points
+}
+
+func TestFilterRunner_BuiltinLevel(t *testing.T) {
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	source := &fakeTrackSource{points: trackWithSpike(start), aircraftType: models.PilotTypeParaglider}
+	runner := NewFilterRunner(source, nil, utils.NewLogger("error", "text"), 0)
+
+	run, err := runner.Run(context.Background(), &FilterRunRequest{
+		DeviceID: "ABC123",
+		From:     start,
+		To:       start.Add(time.Hour),
+		Chain:    "level1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "level1", run.Chain)
+	assert.Equal(t, models.PilotTypeParaglider, run.AircraftType)
+	assert.Equal(t, 61, run.OriginalPoints)
+	assert.False(t, run.Truncated)
+	require.Len(t, run.Steps, 2)
+	assert.Equal(t, "DuplicateFilter", run.Steps[0].Filter)
+	assert.Equal(t, "SmartTeleportationFilter", run.Steps[1].Filter)
+
+	// Каждый шаг получает выход предыдущего, удаленные точки сходятся с итогом
+	removed := 0
+	for i, step := range run.Steps {
+		assert.Equal(t, step.InputPoints-step.Removed, step.OutputPoints, "step %d", i)
+		assert.Len(t, step.RemovedPoints, step.Removed)
+		for _, point := range step.RemovedPoints {
+			assert.NotEmpty(t, point.Reason)
+		}
+		removed += step.Removed
+	}
+	assert.Equal(t, run.OriginalPoints-removed, run.FinalPoints)
+	assert.Less(t, run.FinalPoints, run.OriginalPoints)
+
+	// Телепортация удалена
+	var spikeRemoved bool
+	for _, step := range run.Steps {
+		for _, point := range step.RemovedPoints {
+			if point.Position.Latitude == 47 {
+				spikeRemoved = true
+			}
+		}
+	}
+	assert.True(t, spikeRemoved)
+}
+
+func TestFilterRunner_CustomChain(t *testing.T) {
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	source := &fakeTrackSource{points: trackWithSpike(start), aircraftType: models.PilotTypeParaglider}
+	runner := NewFilterRunner(source, nil, utils.NewLogger("error", "text"), 0)
+
+	minDistance := 500.0
+	run, err := runner.Run(context.Background(), &FilterRunRequest{
+		DeviceID: "ABC123",
+		From:     start,
+		To:       start.Add(time.Hour),
+		Filters: []filter.StepSpec{
+			{Filter: "DuplicateFilter", Config: &filter.ConfigOverrides{MinDistanceMeters: &minDistance}},
+			{Filter: "TimeGapSegmentationFilter", Params: filter.FilterParams{GapMinutes: 5}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "custom", run.Chain)
+	require.Len(t, run.Steps, 2)
+	// Точки через ~110 м ближе min_distance_meters шага
+	assert.Greater(t, run.Steps[0].Removed, run.OriginalPoints/2)
+	assert.Equal(t, "TimeGapSegmentationFilter", run.Steps[1].Filter)
+	assert.Equal(t, run.Steps[0].OutputPoints, run.FinalPoints)
+}
+
+func TestFilterRunner_InvalidChain(t *testing.T) {
+	runner := NewFilterRunner(&fakeTrackSource{}, nil, utils.NewLogger("error", "text"), 0)
+
+	_, err := runner.Run(context.Background(), &FilterRunRequest{DeviceID: "ABC123", Chain: "level9"})
+	assert.ErrorIs(t, err, ErrInvalidFilterChain)
+
+	_, err = runner.Run(context.Background(), &FilterRunRequest{
+		DeviceID: "ABC123",
+		Filters:  []filter.StepSpec{{Filter: "NoSuchFilter"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidFilterChain)
+}
+
+func TestFilterRunner_SourceError(t *testing.T) {
+	runner := NewFilterRunner(&fakeTrackSource{err: errors.New("db down")}, nil, utils.NewLogger("error", "text"), 0)
+
+	_, err := runner.Run(context.Background(), &FilterRunRequest{DeviceID: "ABC123", Chain: "level2"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidFilterChain)
+}
+
+func TestFilterRunner_Truncated(t *testing.T) {
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	runner := NewFilterRunner(&fakeTrackSource{points: trackWithSpike(start)}, nil, utils.NewLogger("error", "text"), 20)
+
+	run, err := runner.Run(context.Background(), &FilterRunRequest{DeviceID: "ABC123", Chain: "level1"})
+	require.NoError(t, err)
+	assert.True(t, run.Truncated)
+	assert.Equal(t, 20, run.OriginalPoints)
+}
+
+func TestLoadChainSet_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+chains:
+  - name: level1
+    description: Только дубли
+    filters:
+      - filter: DuplicateFilter
+  - name: strict
+    config:
+      max_speeds:
+        1: 60
+    filters:
+      - filter: PreCleanupFilter
+      - filter: TimeGapSegmentationFilter
+        params:
+          gap_minutes: 10
+      - filter: SegmentedFilterChain
+`), 0o644))
+
+	chains, err := filter.LoadChainSet(path)
+	require.NoError(t, err)
+
+	specs := chains.Specs()
+	require.Len(t, specs, 2)
+	assert.Equal(t, "level1", specs[0].Name)
+	assert.Equal(t, 60.0, specs[1].Config.MaxSpeeds[models.PilotTypeParaglider])
+	assert.Equal(t, 10, specs[1].Filters[1].Params.GapMinutes)
+
+	// level1 из файла заменяет встроенный уровень
+	level1 := chains.LevelChain(1, filter.DefaultFilterConfig(), utils.NewLogger("error", "text"))
+	assert.Contains(t, level1.Description(), "DuplicateFilter")
+	assert.NotContains(t, level1.Description(), "SmartTeleportationFilter")
+
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	runner := NewFilterRunner(&fakeTrackSource{points: trackWithSpike(start)}, chains, utils.NewLogger("error", "text"), 0)
+	run, err := runner.Run(context.Background(), &FilterRunRequest{DeviceID: "ABC123", Chain: "strict"})
+	require.NoError(t, err)
+	assert.Len(t, run.Steps, 3)
+}
+
+func TestLoadChainSet_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	unknown := filepath.Join(dir, "unknown.json")
+	require.NoError(t, os.WriteFile(unknown, []byte(`{"chains":[{"name":"x","filters":[{"filter":"Nope"}]}]}`), 0o644))
+	_, err := filter.LoadChainSet(unknown)
+	assert.Error(t, err)
+
+	duplicate := filepath.Join(dir, "duplicate.json")
+	require.NoError(t, os.WriteFile(duplicate, []byte(`{"chains":[
+		{"name":"x","filters":[{"filter":"DuplicateFilter"}]},
+		{"name":"x","filters":[{"filter":"OutlierFilter"}]}]}`), 0o644))
+	_, err = filter.LoadChainSet(duplicate)
+	assert.Error(t, err)
+}
+
+func TestFilterChain_CollectRejected(t *testing.T) {
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	points := trackWithSpike(start)
+	track := &filter.TrackData{DeviceID: "ABC123", AircraftType: models.PilotTypeParaglider}
+	for _, point := range points {
+		track.Points = append(track.Points, filter.TrackPoint{Position: point.GeoPoint, Timestamp: point.Timestamp})
+	}
+
+	config := filter.DefaultFilterConfig()
+	config.CollectRejected = true
+	result, err := filter.NewLevel2FilterChain(config, utils.NewLogger("error", "text")).Filter(track)
+	require.NoError(t, err)
+
+	kept := 0
+	for _, point := range result.Points {
+		if !point.Filtered {
+			kept++
+		}
+	}
+	require.NotEmpty(t, result.Rejected)
+	assert.Equal(t, len(points)-kept, len(result.Rejected))
+
+	// Точки, удаленные внутри SegmentedFilterChain, приписаны вложенным фильтрам уровня 1
+	byFilter := make(map[string]int)
+	var spike *filter.RejectedPoint
+	for i, point := range result.Rejected {
+		assert.NotEmpty(t, point.Reason)
+		assert.NotEmpty(t, point.Kind)
+		assert.NotEqual(t, "SegmentedFilterChain", point.Filter)
+		byFilter[point.Filter]++
+		if point.Position.Latitude == 47 {
+			spike = &result.Rejected[i]
+		}
+	}
+	assert.Equal(t, byFilter, result.Statistics.RejectedByFilter)
+	assert.Greater(t, byFilter["DuplicateFilter"], 0)
+
+	require.NotNil(t, spike)
+	assert.Contains(t, []string{filter.RejectTeleport, filter.RejectSpeed, filter.RejectOutlier, filter.RejectPingPong}, spike.Kind)
+
+	// Без CollectRejected список не собирается
+	result, err = filter.NewLevel2FilterChain(filter.DefaultFilterConfig(), utils.NewLogger("error", "text")).Filter(track)
+	require.NoError(t, err)
+	assert.Empty(t, result.Rejected)
+	assert.Nil(t, result.Statistics.RejectedByFilter)
+}
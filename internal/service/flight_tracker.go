@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// FlightEvent событие жизненного цикла полета
+type FlightEvent string
+
+const (
+	FlightEventTakeoff FlightEvent = "takeoff" // Взлет подтвержден
+	FlightEventLanding FlightEvent = "landing" // Посадка подтверждена
+	FlightEventLost    FlightEvent = "lost"    // Устройство пропало в воздухе
+)
+
+// FlightStore хранилище полетов (реализуется repository.MySQLRepository)
+type FlightStore interface {
+	CreateFlight(ctx context.Context, flight *models.Flight) (int64, error)
+	UpdateFlight(ctx context.Context, flight *models.Flight) error
+}
+
+// FlightBroadcaster доставляет события полетов клиентам (реализуется WebSocket обработчиком)
+type FlightBroadcaster interface {
+	BroadcastFlight(event FlightEvent, flight *models.Flight)
+}
+
+// FlightConfig конфигурация определения полетов
+type FlightConfig struct {
+	// Время непрерывной полетной активности до подтверждения взлета
+	TakeoffConfirm time.Duration
+	// Время непрерывной наземной активности до подтверждения посадки
+	LandingConfirm time.Duration
+	// Максимальная вертикальная скорость на земле (м/с)
+	LandingMaxClimb float32
+	// Время без позиций, после которого полет закрывается со статусом lost
+	LostTimeout time.Duration
+	// Минимальный интервал между точками трека полета в памяти
+	TrackInterval time.Duration
+}
+
+// DefaultFlightConfig возвращает конфигурацию по умолчанию
+func DefaultFlightConfig() *FlightConfig {
+	return &FlightConfig{
+		TakeoffConfirm:  20 * time.Second,
+		LandingConfirm:  60 * time.Second,
+		LandingMaxClimb: 1,
+		LostTimeout:     10 * time.Minute,
+		TrackInterval:   5 * time.Second,
+	}
+}
+
+// flightState состояние конечного автомата одного устройства
+type flightState struct {
+	activity filter.ActivityType
+	lastFix  models.GeoPoint
+	lastSeen time.Time
+
+	// На земле: точки непрерывной полетной активности до подтверждения взлета
+	pending []*models.TrackPoint
+
+	// В воздухе
+	flight       *models.Flight
+	track        *models.Track
+	landingSince time.Time // Начало наземной активности (кандидат на посадку)
+	landingPoint models.GeoPoint
+}
+
+// flightNotice событие, доставляемое после освобождения блокировки
+type flightNotice struct {
+	event  FlightEvent
+	flight *models.Flight
+	state  *flightState
+}
+
+// FlightTracker определяет взлеты и посадки по живым позициям устройств.
+// Активность (земля/полет) классифицируется по скорости с гистерезисом
+// filter.ActivitySegmentationFilter; смена активности подтверждается, если
+// она длится TakeoffConfirm/LandingConfirm. Статистика полета считается
+// методами models.Track
+type FlightTracker struct {
+	states map[string]*flightState // Device ID -> состояние
+	mu     sync.Mutex
+
+	segmenter   *filter.ActivitySegmentationFilter
+	config      *FlightConfig
+	logger      *utils.Logger
+	store       FlightStore
+	broadcaster FlightBroadcaster
+	now         func() time.Time
+}
+
+// NewFlightTracker создает трекер полетов. config и store могут быть nil
+func NewFlightTracker(logger *utils.Logger, config *FlightConfig, store FlightStore) *FlightTracker {
+	if config == nil {
+		config = DefaultFlightConfig()
+	}
+
+	return &FlightTracker{
+		states:    make(map[string]*flightState),
+		segmenter: filter.NewActivitySegmentationFilter(filter.DefaultFilterConfig(), logger),
+		config:    config,
+		logger:    logger,
+		store:     store,
+		now:       time.Now,
+	}
+}
+
+// SetBroadcaster устанавливает канал доставки событий полетов клиентам
+func (t *FlightTracker) SetBroadcaster(broadcaster FlightBroadcaster) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.broadcaster = broadcaster
+}
+
+// Observe обрабатывает позицию устройства. Позиции одного устройства должны
+// приходить по порядку (гарантируется ingest.Pipeline)
+func (t *FlightTracker) Observe(ctx context.Context, pilot *models.Pilot) {
+	if pilot == nil || pilot.Position == nil {
+		return
+	}
+
+	ts := pilot.LastUpdate
+	if ts.IsZero() {
+		ts = t.now()
+	}
+
+	t.mu.Lock()
+
+	state, ok := t.states[pilot.DeviceID]
+	if !ok {
+		state = &flightState{activity: filter.ActivityTypeGround}
+		t.states[pilot.DeviceID] = state
+	} else if !ts.After(state.lastSeen) {
+		t.mu.Unlock()
+		return
+	}
+
+	speed := float64(pilot.Speed)
+	if speed == 0 && ok {
+		// Не все устройства передают скорость - оцениваем по предыдущей позиции
+		if dt := ts.Sub(state.lastSeen).Seconds(); dt > 0 {
+			speed = state.lastFix.DistanceTo(*pilot.Position) / dt * 3600
+		}
+	}
+	activity := t.segmenter.ClassifyActivity(speed, state.activity)
+
+	point := &models.TrackPoint{
+		Position:  &models.GeoPoint{Latitude: pilot.Position.Latitude, Longitude: pilot.Position.Longitude, Altitude: pilot.Position.Altitude},
+		Altitude:  pilot.Position.Altitude,
+		Speed:     float32(speed),
+		Climb:     float32(pilot.ClimbRate) / 10,
+		Timestamp: ts,
+	}
+
+	var notice *flightNotice
+	if state.flight == nil {
+		notice = t.observeGround(pilot, state, activity, point)
+	} else {
+		notice = t.observeFlight(state, activity, point)
+	}
+
+	state.activity = activity
+	state.lastFix = *point.Position
+	state.lastSeen = ts
+	if notice != nil {
+		t.updateActiveGauge()
+	}
+	t.mu.Unlock()
+
+	if notice != nil {
+		t.publish(ctx, notice)
+	}
+}
+
+// observeGround обрабатывает позицию устройства на земле
+func (t *FlightTracker) observeGround(pilot *models.Pilot, state *flightState, activity filter.ActivityType, point *models.TrackPoint) *flightNotice {
+	if activity != filter.ActivityTypeFlight {
+		state.pending = nil
+		return nil
+	}
+
+	state.pending = append(state.pending, point)
+	takeoff := state.pending[0]
+	if point.Timestamp.Sub(takeoff.Timestamp) < t.config.TakeoffConfirm {
+		return nil
+	}
+
+	state.flight = &models.Flight{
+		DeviceID:    pilot.DeviceID,
+		Type:        pilot.Type,
+		Status:      models.FlightStatusActive,
+		TakeoffTime: takeoff.Timestamp,
+		Takeoff:     *takeoff.Position,
+	}
+	state.track = &models.Track{}
+	for _, p := range state.pending {
+		t.appendTrackPoint(state.track, p)
+	}
+	state.pending = nil
+	state.landingSince = time.Time{}
+
+	return &flightNotice{event: FlightEventTakeoff, flight: snapshotFlight(state, point.Timestamp), state: state}
+}
+
+// observeFlight обрабатывает позицию устройства в воздухе
+func (t *FlightTracker) observeFlight(state *flightState, activity filter.ActivityType, point *models.TrackPoint) *flightNotice {
+	t.appendTrackPoint(state.track, point)
+
+	onGround := activity == filter.ActivityTypeGround &&
+		float32(math.Abs(float64(point.Climb))) < t.config.LandingMaxClimb
+	if !onGround {
+		state.landingSince = time.Time{}
+		return nil
+	}
+
+	if state.landingSince.IsZero() {
+		state.landingSince = point.Timestamp
+		state.landingPoint = *point.Position
+	}
+	if point.Timestamp.Sub(state.landingSince) < t.config.LandingConfirm {
+		return nil
+	}
+
+	return t.closeFlight(state, models.FlightStatusLanded, state.landingSince, state.landingPoint, FlightEventLanding)
+}
+
+// closeFlight завершает полет устройства и возвращает событие для доставки
+func (t *FlightTracker) closeFlight(state *flightState, status models.FlightStatus, end time.Time, position models.GeoPoint, event FlightEvent) *flightNotice {
+	flight := state.flight
+	flight.Status = status
+	flight.LandingTime = &end
+	flight.Landing = &position
+	updateFlightStats(flight, state.track, end)
+
+	state.flight = nil
+	state.track = nil
+	state.landingSince = time.Time{}
+
+	snapshot := *flight
+	return &flightNotice{event: event, flight: &snapshot, state: state}
+}
+
+// appendTrackPoint добавляет точку в трек полета с прореживанием по TrackInterval
+func (t *FlightTracker) appendTrackPoint(track *models.Track, point *models.TrackPoint) {
+	if n := len(track.Points); n > 0 && point.Timestamp.Sub(track.Points[n-1].Timestamp) < t.config.TrackInterval {
+		return
+	}
+	track.Points = append(track.Points, point)
+}
+
+// updateFlightStats пересчитывает статистику полета по треку до момента end
+func updateFlightStats(flight *models.Flight, track *models.Track, end time.Time) {
+	points := track.Points
+	for len(points) > 0 && points[len(points)-1].Timestamp.After(end) {
+		points = points[:len(points)-1]
+	}
+
+	stats := &models.Track{
+		Points:    points,
+		StartTime: flight.TakeoffTime,
+		EndTime:   end,
+	}
+	flight.Duration = int64(stats.GetDuration().Seconds())
+	flight.Distance = math.Round(stats.GetDistance()*100) / 100
+	flight.MaxAltitude = stats.GetMaxAltitude()
+	flight.MaxClimb = stats.GetMaxClimb()
+}
+
+// snapshotFlight возвращает копию активного полета со статистикой на момент end
+func snapshotFlight(state *flightState, end time.Time) *models.Flight {
+	updateFlightStats(state.flight, state.track, end)
+	flight := *state.flight
+	return &flight
+}
+
+// publish сохраняет полет и рассылает событие клиентам
+func (t *FlightTracker) publish(ctx context.Context, notice *flightNotice) {
+	flight := notice.flight
+	metrics.FlightEvents.WithLabelValues(string(notice.event)).Inc()
+
+	if t.store != nil {
+		if flight.ID == 0 {
+			id, err := t.store.CreateFlight(ctx, flight)
+			if err != nil {
+				t.logger.WithField("error", err).WithField("device_id", flight.DeviceID).
+					Warn("Failed to save flight")
+			} else {
+				flight.ID = id
+				t.mu.Lock()
+				if notice.state.flight != nil && notice.state.flight.TakeoffTime.Equal(flight.TakeoffTime) {
+					notice.state.flight.ID = id
+				}
+				t.mu.Unlock()
+			}
+		} else if err := t.store.UpdateFlight(ctx, flight); err != nil {
+			t.logger.WithField("error", err).WithField("flight_id", flight.ID).
+				Warn("Failed to update flight")
+		}
+	}
+
+	t.logger.WithFields(map[string]interface{}{
+		"flight_id": flight.ID,
+		"device_id": flight.DeviceID,
+		"event":     notice.event,
+		"duration":  flight.Duration,
+		"distance":  flight.Distance,
+	}).Info("Flight event")
+
+	t.mu.Lock()
+	broadcaster := t.broadcaster
+	t.mu.Unlock()
+
+	if broadcaster != nil {
+		broadcaster.BroadcastFlight(notice.event, flight)
+	}
+}
+
+// CheckLost закрывает полеты устройств, от которых нет позиций дольше LostTimeout,
+// и удаляет состояние давно не активных устройств на земле.
+// Возвращает количество закрытых полетов
+func (t *FlightTracker) CheckLost(ctx context.Context) int {
+	now := t.now()
+
+	t.mu.Lock()
+	var notices []*flightNotice
+	for deviceID, state := range t.states {
+		if now.Sub(state.lastSeen) < t.config.LostTimeout {
+			continue
+		}
+		if state.flight != nil {
+			notices = append(notices, t.closeFlight(state, models.FlightStatusLost, state.lastSeen, state.lastFix, FlightEventLost))
+		}
+		delete(t.states, deviceID)
+	}
+	t.updateActiveGauge()
+	t.mu.Unlock()
+
+	for _, notice := range notices {
+		t.publish(ctx, notice)
+	}
+	return len(notices)
+}
+
+// ActiveFlight возвращает текущий полет устройства (копию) со статистикой на момент последней позиции
+func (t *FlightTracker) ActiveFlight(deviceID string) (*models.Flight, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[deviceID]
+	if !ok || state.flight == nil {
+		return nil, false
+	}
+	return snapshotFlight(state, state.lastSeen), true
+}
+
+// ActiveFlights возвращает все текущие полеты, упорядоченные по времени взлета
+func (t *FlightTracker) ActiveFlights() []*models.Flight {
+	t.mu.Lock()
+	flights := make([]*models.Flight, 0)
+	for _, state := range t.states {
+		if state.flight != nil {
+			flights = append(flights, snapshotFlight(state, state.lastSeen))
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(flights, func(i, j int) bool {
+		return flights[i].TakeoffTime.Before(flights[j].TakeoffTime)
+	})
+	return flights
+}
+
+// updateActiveGauge обновляет метрику полетов в воздухе (вызывается под блокировкой)
+func (t *FlightTracker) updateActiveGauge() {
+	active := 0
+	for _, state := range t.states {
+		if state.flight != nil {
+			active++
+		}
+	}
+	metrics.FlightsActive.Set(float64(active))
+}
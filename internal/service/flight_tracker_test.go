@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFlightStore хранит полеты в памяти
+type memoryFlightStore struct {
+	flights map[int64]models.Flight
+	nextID  int64
+}
+
+func (s *memoryFlightStore) CreateFlight(ctx context.Context, flight *models.Flight) (int64, error) {
+	s.nextID++
+	stored := *flight
+	stored.ID = s.nextID
+	s.flights[stored.ID] = stored
+	return stored.ID, nil
+}
+
+func (s *memoryFlightStore) UpdateFlight(ctx context.Context, flight *models.Flight) error {
+	s.flights[flight.ID] = *flight
+	return nil
+}
+
+// recordingFlightBroadcaster запоминает разосланные события
+type recordingFlightBroadcaster struct {
+	mu     sync.Mutex
+	events []FlightEvent
+}
+
+func (b *recordingFlightBroadcaster) BroadcastFlight(event FlightEvent, flight *models.Flight) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+func newTestFlightTracker() (*FlightTracker, *memoryFlightStore, *recordingFlightBroadcaster) {
+	store := &memoryFlightStore{flights: make(map[int64]models.Flight)}
+	broadcaster := &recordingFlightBroadcaster{}
+	tracker := NewFlightTracker(utils.NewLogger("error", "text"), nil, store)
+	tracker.SetBroadcaster(broadcaster)
+	return tracker, store, broadcaster
+}
+
+// flightFix позиция устройства через offset секунд после start
+func flightFix(start time.Time, offset int, lat float64, alt int32, speed float32, climb int16) *models.Pilot {
+	return &models.Pilot{
+		DeviceID:   "ABC123",
+		Type:       models.PilotTypeParaglider,
+		Position:   &models.GeoPoint{Latitude: lat, Longitude: 14, Altitude: alt},
+		Speed:      speed,
+		ClimbRate:  climb,
+		LastUpdate: start.Add(time.Duration(offset) * time.Second),
+	}
+}
+
+func TestFlightTracker_TakeoffAndLanding(t *testing.T) {
+	tracker, store, broadcaster := newTestFlightTracker()
+	ctx := context.Background()
+	start := time.Date(2024, 6, 10, 11, 0, 0, 0, time.UTC)
+
+	// Подготовка на старте
+	tracker.Observe(ctx, flightFix(start, 0, 46.0, 1500, 0, 0))
+	tracker.Observe(ctx, flightFix(start, 5, 46.0, 1500, 2, 0))
+
+	// Разбег и полет с набором высоты
+	lat := 46.0
+	for i := 0; i <= 60; i++ {
+		lat += 0.001
+		tracker.Observe(ctx, flightFix(start, 10+i*5, lat, 1500+int32(i*10), 30, 25))
+	}
+
+	flight, ok := tracker.ActiveFlight("ABC123")
+	require.True(t, ok)
+	assert.Equal(t, models.FlightStatusActive, flight.Status)
+	assert.Equal(t, start.Add(10*time.Second), flight.TakeoffTime)
+	assert.InDelta(t, 46.001, flight.Takeoff.Latitude, 1e-9)
+	assert.Equal(t, int64(1), flight.ID)
+	assert.Equal(t, []FlightEvent{FlightEventTakeoff}, broadcaster.events)
+
+	// Посадка и сбор крыла
+	for i := 0; i <= 20; i++ {
+		tracker.Observe(ctx, flightFix(start, 320+i*5, lat, 900, 1, 0))
+	}
+
+	_, ok = tracker.ActiveFlight("ABC123")
+	assert.False(t, ok)
+	assert.Equal(t, []FlightEvent{FlightEventTakeoff, FlightEventLanding}, broadcaster.events)
+
+	stored := store.flights[1]
+	assert.Equal(t, models.FlightStatusLanded, stored.Status)
+	require.NotNil(t, stored.LandingTime)
+	assert.Equal(t, start.Add(320*time.Second), *stored.LandingTime)
+	assert.Equal(t, int64(310), stored.Duration)
+	assert.InDelta(t, 6.67, stored.Distance, 0.05)
+	assert.Equal(t, int32(2100), stored.MaxAltitude)
+	assert.Equal(t, float32(2.5), stored.MaxClimb)
+	require.NotNil(t, stored.Landing)
+	assert.Equal(t, int32(900), stored.Landing.Altitude)
+}
+
+func TestFlightTracker_SpeedSpikeIsNotTakeoff(t *testing.T) {
+	tracker, store, broadcaster := newTestFlightTracker()
+	ctx := context.Background()
+	start := time.Date(2024, 6, 10, 11, 0, 0, 0, time.UTC)
+
+	// Поездка на подъемнике: короткие участки с высокой скоростью
+	tracker.Observe(ctx, flightFix(start, 0, 46.0, 500, 0, 0))
+	tracker.Observe(ctx, flightFix(start, 5, 46.0, 500, 25, 0))
+	tracker.Observe(ctx, flightFix(start, 10, 46.0, 500, 25, 0))
+	tracker.Observe(ctx, flightFix(start, 15, 46.0, 500, 3, 0))
+	tracker.Observe(ctx, flightFix(start, 20, 46.0, 500, 25, 0))
+	tracker.Observe(ctx, flightFix(start, 25, 46.0, 500, 0, 0))
+
+	assert.Empty(t, tracker.ActiveFlights())
+	assert.Empty(t, store.flights)
+	assert.Empty(t, broadcaster.events)
+}
+
+func TestFlightTracker_Lost(t *testing.T) {
+	tracker, store, broadcaster := newTestFlightTracker()
+	ctx := context.Background()
+	start := time.Date(2024, 6, 10, 11, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= 10; i++ {
+		tracker.Observe(ctx, flightFix(start, i*5, 46.0+float64(i)*0.001, 1500, 30, 0))
+	}
+	require.Len(t, tracker.ActiveFlights(), 1)
+
+	tracker.now = func() time.Time { return start.Add(5 * time.Minute) }
+	assert.Equal(t, 0, tracker.CheckLost(ctx))
+
+	tracker.now = func() time.Time { return start.Add(20 * time.Minute) }
+	assert.Equal(t, 1, tracker.CheckLost(ctx))
+	assert.Empty(t, tracker.ActiveFlights())
+
+	stored := store.flights[1]
+	assert.Equal(t, models.FlightStatusLost, stored.Status)
+	require.NotNil(t, stored.LandingTime)
+	assert.Equal(t, start.Add(50*time.Second), *stored.LandingTime)
+	assert.InDelta(t, 46.01, stored.Landing.Latitude, 1e-9)
+	assert.Equal(t, []FlightEvent{FlightEventTakeoff, FlightEventLost}, broadcaster.events)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// FriendsPrefix префикс множеств устройств, за которыми следит пользователь: friends:{user_id}
+	FriendsPrefix = "friends:"
+	// MaxFriends максимум устройств в списке друзей
+	MaxFriends = 100
+)
+
+// ErrTooManyFriends список друзей превысил бы MaxFriends
+var ErrTooManyFriends = fmt.Errorf("friends list is limited to %d devices", MaxFriends)
+
+// FriendsRedis операции Redis, нужные списку друзей
+type FriendsRedis interface {
+	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
+	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// FriendsStore хранит в Redis списки друзей пользователей: FANET адреса
+// устройств, обновления которых клиент получает вне зависимости от области карты
+type FriendsStore struct {
+	client FriendsRedis
+}
+
+// NewFriendsStore создает хранилище списков друзей
+func NewFriendsStore(client FriendsRedis) *FriendsStore {
+	return &FriendsStore{client: client}
+}
+
+// FriendsKey возвращает ключ списка друзей пользователя
+func FriendsKey(userID int) string {
+	return FriendsPrefix + strconv.Itoa(userID)
+}
+
+// NormalizeDeviceID приводит адрес к виду устройств в Redis: 6 hex цифр FANET адреса
+// в верхнем регистре или ID OGN/FLARM устройства с префиксом (FLRDDA5BA)
+func NormalizeDeviceID(deviceID string) (string, error) {
+	return models.ParseDeviceID(deviceID)
+}
+
+// List возвращает отсортированный список друзей пользователя
+func (s *FriendsStore) List(ctx context.Context, userID int) ([]string, error) {
+	devices, err := s.client.SMembers(ctx, FriendsKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	sort.Strings(devices)
+	return devices, nil
+}
+
+// Add добавляет устройства в список друзей и возвращает новый список
+func (s *FriendsStore) Add(ctx context.Context, userID int, deviceIDs []string) ([]string, error) {
+	current, err := s.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(current))
+	for _, deviceID := range current {
+		known[deviceID] = true
+	}
+	var added []interface{}
+	for _, deviceID := range deviceIDs {
+		if !known[deviceID] {
+			known[deviceID] = true
+			added = append(added, deviceID)
+		}
+	}
+	if len(added) == 0 {
+		return current, nil
+	}
+	if len(known) > MaxFriends {
+		return nil, ErrTooManyFriends
+	}
+
+	if err := s.client.SAdd(ctx, FriendsKey(userID), added...).Err(); err != nil {
+		return nil, fmt.Errorf("failed to add friends: %w", err)
+	}
+	return s.List(ctx, userID)
+}
+
+// Remove удаляет устройства из списка друзей и возвращает новый список
+func (s *FriendsStore) Remove(ctx context.Context, userID int, deviceIDs []string) ([]string, error) {
+	members := make([]interface{}, len(deviceIDs))
+	for i, deviceID := range deviceIDs {
+		members[i] = deviceID
+	}
+	if len(members) > 0 {
+		if err := s.client.SRem(ctx, FriendsKey(userID), members...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to remove friends: %w", err)
+		}
+	}
+	return s.List(ctx, userID)
+}
+
+// Replace заменяет список друзей целиком
+func (s *FriendsStore) Replace(ctx context.Context, userID int, deviceIDs []string) ([]string, error) {
+	unique := make(map[string]bool, len(deviceIDs))
+	members := make([]interface{}, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		if !unique[deviceID] {
+			unique[deviceID] = true
+			members = append(members, deviceID)
+		}
+	}
+	if len(members) > MaxFriends {
+		return nil, ErrTooManyFriends
+	}
+
+	if err := s.client.Del(ctx, FriendsKey(userID)).Err(); err != nil {
+		return nil, fmt.Errorf("failed to clear friends: %w", err)
+	}
+	if len(members) > 0 {
+		if err := s.client.SAdd(ctx, FriendsKey(userID), members...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to save friends: %w", err)
+		}
+	}
+	return s.List(ctx, userID)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDeviceID(t *testing.T) {
+	for input, expected := range map[string]string{
+		"abc123": "ABC123",
+		" 1234 ": "001234",
+		"FFFFFF": "FFFFFF",
+		// OGN/FLARM устройства: ID с префиксом или числовой адрес из protobuf
+		"flrdda5ba": "FLRDDA5BA",
+		"02DDA5BA":  "FLRDDA5BA",
+		"00DDA5BA":  "DDA5BA",
+	} {
+		deviceID, err := NormalizeDeviceID(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, deviceID)
+	}
+
+	for _, input := range []string{"", "XYZ", "1234567", "-1", "FLRDDA5", "ABCDDA5BA", "09DDA5BA"} {
+		_, err := NormalizeDeviceID(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestFriendsStore(t *testing.T) {
+	ctx := context.Background()
+	client := testutil.NewMemorySets()
+	store := NewFriendsStore(client)
+
+	friends, err := store.List(ctx, 42)
+	require.NoError(t, err)
+	assert.Empty(t, friends)
+
+	friends, err = store.Add(ctx, 42, []string{"DEF456", "ABC123", "DEF456"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ABC123", "DEF456"}, friends)
+	assert.Len(t, client.Members(FriendsKey(42)), 2)
+
+	// Списки разных пользователей независимы
+	other, err := store.List(ctx, 7)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	friends, err = store.Remove(ctx, 42, []string{"ABC123"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DEF456"}, friends)
+
+	friends, err = store.Replace(ctx, 42, []string{"000001", "000002"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"000001", "000002"}, friends)
+
+	// Лимит списка
+	many := make([]string, MaxFriends)
+	for i := range many {
+		many[i] = fmt.Sprintf("%06X", 0xA00000+i)
+	}
+	_, err = store.Add(ctx, 42, many)
+	assert.ErrorIs(t, err, ErrTooManyFriends)
+	_, err = store.Replace(ctx, 42, append(many, "FFFFFF"))
+	assert.ErrorIs(t, err, ErrTooManyFriends)
+
+	friends, err = store.Replace(ctx, 42, nil)
+	require.NoError(t, err)
+	assert.Empty(t, friends)
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// GatewayEvent событие изменения состояния базовой станции
+type GatewayEvent string
+
+const (
+	GatewayEventOffline GatewayEvent = "gateway_offline" // Станция перестала присылать пакеты
+	GatewayEventOnline  GatewayEvent = "gateway_online"  // Станция снова на связи
+)
+
+// GatewayNotifier внешний канал оповещения о состоянии базовых станций
+type GatewayNotifier interface {
+	NotifyGateway(ctx context.Context, event GatewayEvent, gateway *models.Gateway) error
+}
+
+// GatewayConfig конфигурация реестра базовых станций
+type GatewayConfig struct {
+	// Время без пакетов, после которого станция считается offline
+	OfflineAfter time.Duration
+	// Количество последних принятых позиций для оценки покрытия
+	MaxPositions int
+	// Таймаут вызова внешнего оповещения
+	NotifyTimeout time.Duration
+}
+
+// DefaultGatewayConfig возвращает конфигурацию по умолчанию
+func DefaultGatewayConfig() *GatewayConfig {
+	return &GatewayConfig{
+		OfflineAfter:  10 * time.Minute,
+		MaxPositions:  500,
+		NotifyTimeout: 5 * time.Second,
+	}
+}
+
+// GatewayObservation пакет, принятый базовой станцией
+type GatewayObservation struct {
+	ChipID   string
+	DeviceID string
+	Type     uint8
+	RSSI     int16
+	SNR      int16
+	Position *models.GeoPoint // nil для пакетов без координат
+}
+
+const (
+	// gatewayRateWindow количество минутных интервалов для расчета частоты пакетов
+	gatewayRateWindow = 15
+	// minCoveragePositions минимальное количество позиций для оценки покрытия
+	minCoveragePositions = 3
+)
+
+var (
+	// Границы интервалов распределения, последний интервал включает все большие значения
+	rssiBuckets = []float64{-120, -110, -100, -90, -80, -70, -60, -50}
+	snrBuckets  = []float64{-15, -10, -5, 0, 5, 10, 15, 20}
+)
+
+// signalAccumulator накапливает распределение уровня сигнала
+type signalAccumulator struct {
+	min, max, sum float64
+	count         uint64
+	bounds        []float64
+	buckets       []uint64
+}
+
+func newSignalAccumulator(bounds []float64) *signalAccumulator {
+	return &signalAccumulator{bounds: bounds, buckets: make([]uint64, len(bounds))}
+}
+
+func (a *signalAccumulator) add(value float64) {
+	if a.count == 0 || value < a.min {
+		a.min = value
+	}
+	if a.count == 0 || value > a.max {
+		a.max = value
+	}
+	a.sum += value
+	a.count++
+
+	i := sort.SearchFloat64s(a.bounds, value)
+	if i == len(a.bounds) {
+		i--
+	}
+	a.buckets[i]++
+}
+
+func (a *signalAccumulator) stats() models.SignalStats {
+	stats := models.SignalStats{
+		Min:     a.min,
+		Max:     a.max,
+		Buckets: make([]models.HistogramBucket, len(a.bounds)),
+	}
+	if a.count > 0 {
+		stats.Avg = math.Round(a.sum/float64(a.count)*10) / 10
+	}
+	for i, bound := range a.bounds {
+		stats.Buckets[i] = models.HistogramBucket{Le: bound, Count: a.buckets[i]}
+	}
+	return stats
+}
+
+// gatewayState накопленные данные одной станции
+type gatewayState struct {
+	chipID    string
+	firstSeen time.Time
+	lastSeen  time.Time
+	online    bool
+
+	packets uint64
+	types   map[uint8]uint64
+
+	// Кольцевой буфер пакетов по минутам
+	rateCounts  [gatewayRateWindow]uint64
+	rateMinutes [gatewayRateWindow]int64
+
+	rssi *signalAccumulator
+	snr  *signalAccumulator
+
+	// Кольцевой буфер принятых позиций
+	positions    []models.HeardPosition
+	positionNext int
+}
+
+// GatewayRegistry ведет реестр базовых станций: трафик, качество приема,
+// принятые позиции и оценку покрытия. Станция, не присылавшая пакеты
+// дольше OfflineAfter, считается offline
+type GatewayRegistry struct {
+	gateways map[string]*gatewayState
+	mu       sync.RWMutex
+
+	config   *GatewayConfig
+	logger   *utils.Logger
+	notifier GatewayNotifier
+	now      func() time.Time
+}
+
+// NewGatewayRegistry создает реестр базовых станций. config и notifier могут быть nil
+func NewGatewayRegistry(logger *utils.Logger, config *GatewayConfig, notifier GatewayNotifier) *GatewayRegistry {
+	if config == nil {
+		config = DefaultGatewayConfig()
+	}
+
+	return &GatewayRegistry{
+		gateways: make(map[string]*gatewayState),
+		config:   config,
+		logger:   logger,
+		notifier: notifier,
+		now:      time.Now,
+	}
+}
+
+// Observe учитывает пакет, принятый станцией
+func (r *GatewayRegistry) Observe(obs GatewayObservation) {
+	if obs.ChipID == "" {
+		return
+	}
+	now := r.now()
+
+	r.mu.Lock()
+	state, exists := r.gateways[obs.ChipID]
+	if !exists {
+		state = &gatewayState{
+			chipID:    obs.ChipID,
+			firstSeen: now,
+			types:     make(map[uint8]uint64),
+			rssi:      newSignalAccumulator(rssiBuckets),
+			snr:       newSignalAccumulator(snrBuckets),
+		}
+		r.gateways[obs.ChipID] = state
+	}
+
+	wasOnline := state.online
+	state.online = true
+	state.lastSeen = now
+	state.packets++
+	state.types[obs.Type]++
+
+	minute := now.Unix() / 60
+	slot := minute % gatewayRateWindow
+	if state.rateMinutes[slot] != minute {
+		state.rateMinutes[slot] = minute
+		state.rateCounts[slot] = 0
+	}
+	state.rateCounts[slot]++
+
+	state.rssi.add(float64(obs.RSSI))
+	state.snr.add(float64(obs.SNR))
+
+	if obs.Position != nil && r.config.MaxPositions > 0 {
+		heard := models.HeardPosition{
+			DeviceID:  obs.DeviceID,
+			Latitude:  obs.Position.Latitude,
+			Longitude: obs.Position.Longitude,
+			RSSI:      obs.RSSI,
+			SNR:       obs.SNR,
+			Timestamp: now,
+		}
+		if len(state.positions) < r.config.MaxPositions {
+			state.positions = append(state.positions, heard)
+		} else {
+			state.positions[state.positionNext] = heard
+			state.positionNext = (state.positionNext + 1) % r.config.MaxPositions
+		}
+	}
+
+	var snapshot *models.Gateway
+	if !wasOnline {
+		snapshot = r.snapshot(state, now, false)
+	}
+	r.mu.Unlock()
+
+	metrics.GatewayPackets.WithLabelValues(obs.ChipID).Inc()
+
+	if snapshot != nil {
+		metrics.GatewayOnline.WithLabelValues(obs.ChipID).Set(1)
+		if exists {
+			r.logger.WithField("chip_id", obs.ChipID).Info("Base station is back online")
+			r.notify(GatewayEventOnline, snapshot)
+		} else {
+			r.logger.WithField("chip_id", obs.ChipID).Info("New base station registered")
+		}
+	}
+}
+
+// CheckStatus переводит молчащие станции в offline и обновляет метрики.
+// Вызывается периодически. Возвращает количество станций, ушедших в offline
+func (r *GatewayRegistry) CheckStatus() int {
+	now := r.now()
+
+	r.mu.Lock()
+	var wentOffline []*models.Gateway
+	gateways := make([]*models.Gateway, 0, len(r.gateways))
+	for _, state := range r.gateways {
+		if state.online && now.Sub(state.lastSeen) > r.config.OfflineAfter {
+			state.online = false
+			wentOffline = append(wentOffline, r.snapshot(state, now, false))
+		}
+		gateways = append(gateways, r.snapshot(state, now, false))
+	}
+	r.mu.Unlock()
+
+	for _, gateway := range gateways {
+		online := 0.0
+		if gateway.Online {
+			online = 1
+		}
+		metrics.GatewayOnline.WithLabelValues(gateway.ChipID).Set(online)
+		metrics.GatewayLastSeen.WithLabelValues(gateway.ChipID).Set(float64(gateway.LastSeen.Unix()))
+		metrics.GatewayPacketRate.WithLabelValues(gateway.ChipID).Set(gateway.PacketRate)
+		if gateway.Coverage != nil {
+			metrics.GatewayCoverageRadius.WithLabelValues(gateway.ChipID).Set(gateway.Coverage.RadiusKM)
+		}
+	}
+
+	for _, gateway := range wentOffline {
+		r.logger.WithFields(map[string]interface{}{
+			"chip_id":   gateway.ChipID,
+			"last_seen": gateway.LastSeen,
+			"silent":    now.Sub(gateway.LastSeen).Round(time.Second),
+		}).Warn("Base station went offline")
+		r.notify(GatewayEventOffline, gateway)
+	}
+
+	return len(wentOffline)
+}
+
+// ListGateways возвращает все станции без списка позиций, отсортированные по chip_id.
+// online фильтрует по состоянию (nil - все)
+func (r *GatewayRegistry) ListGateways(online *bool) []*models.Gateway {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*models.Gateway, 0, len(r.gateways))
+	for _, state := range r.gateways {
+		if online != nil && state.online != *online {
+			continue
+		}
+		result = append(result, r.snapshot(state, now, false))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChipID < result[j].ChipID
+	})
+	return result
+}
+
+// GetGateway возвращает станцию вместе с принятыми позициями
+func (r *GatewayRegistry) GetGateway(chipID string) (*models.Gateway, bool) {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, ok := r.gateways[chipID]
+	if !ok {
+		return nil, false
+	}
+	return r.snapshot(state, now, true), true
+}
+
+// Position возвращает оценку положения станции по принятым позициям или nil,
+// если станция неизвестна или позиций для оценки недостаточно
+func (r *GatewayRegistry) Position(chipID string) *models.GeoPoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, ok := r.gateways[chipID]
+	if !ok {
+		return nil
+	}
+	coverage := estimateCoverage(state.orderedPositions())
+	if coverage == nil {
+		return nil
+	}
+	center := coverage.Center
+	return &center
+}
+
+// Cleanup удаляет станции, молчащие дольше maxAge, вместе с их метриками
+func (r *GatewayRegistry) Cleanup(maxAge time.Duration) int {
+	cutoff := r.now().Add(-maxAge)
+
+	r.mu.Lock()
+	var removed []string
+	for chipID, state := range r.gateways {
+		if state.lastSeen.Before(cutoff) {
+			delete(r.gateways, chipID)
+			removed = append(removed, chipID)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, chipID := range removed {
+		metrics.GatewayPackets.DeleteLabelValues(chipID)
+		metrics.GatewayOnline.DeleteLabelValues(chipID)
+		metrics.GatewayLastSeen.DeleteLabelValues(chipID)
+		metrics.GatewayPacketRate.DeleteLabelValues(chipID)
+		metrics.GatewayCoverageRadius.DeleteLabelValues(chipID)
+	}
+
+	if len(removed) > 0 {
+		r.logger.WithField("removed", len(removed)).Info("Removed stale base stations from registry")
+	}
+	return len(removed)
+}
+
+// snapshot формирует модель станции. Вызывается под блокировкой
+func (r *GatewayRegistry) snapshot(state *gatewayState, now time.Time, withPositions bool) *models.Gateway {
+	gateway := &models.Gateway{
+		ChipID:     state.chipID,
+		Online:     state.online,
+		FirstSeen:  state.firstSeen,
+		LastSeen:   state.lastSeen,
+		Packets:    state.packets,
+		PacketRate: state.packetRate(now),
+		Types:      make(map[uint8]uint64, len(state.types)),
+		RSSI:       state.rssi.stats(),
+		SNR:        state.snr.stats(),
+	}
+	for packetType, count := range state.types {
+		gateway.Types[packetType] = count
+	}
+
+	positions := state.orderedPositions()
+	gateway.Coverage = estimateCoverage(positions)
+	if withPositions {
+		gateway.Positions = positions
+	}
+
+	return gateway
+}
+
+// notify отправляет событие во внешний канал в фоне
+func (r *GatewayRegistry) notify(event GatewayEvent, gateway *models.Gateway) {
+	if r.notifier == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.config.NotifyTimeout)
+		defer cancel()
+
+		if err := r.notifier.NotifyGateway(ctx, event, gateway); err != nil {
+			r.logger.WithFields(map[string]interface{}{
+				"chip_id": gateway.ChipID,
+				"event":   event,
+				"error":   err,
+			}).Warn("Failed to send base station notification")
+		}
+	}()
+}
+
+// packetRate возвращает среднее количество пакетов в минуту за окно частоты
+func (s *gatewayState) packetRate(now time.Time) float64 {
+	minute := now.Unix() / 60
+	var total uint64
+	for i := range s.rateCounts {
+		if minute-s.rateMinutes[i] < gatewayRateWindow {
+			total += s.rateCounts[i]
+		}
+	}
+
+	// Для недавно появившейся станции делим на фактическое время наблюдения
+	minutes := min(int64(gatewayRateWindow), minute-s.firstSeen.Unix()/60+1)
+	return math.Round(float64(total)/float64(minutes)*10) / 10
+}
+
+// orderedPositions возвращает копию принятых позиций от старых к новым
+func (s *gatewayState) orderedPositions() []models.HeardPosition {
+	result := make([]models.HeardPosition, 0, len(s.positions))
+	result = append(result, s.positions[s.positionNext:]...)
+	result = append(result, s.positions[:s.positionNext]...)
+	return result
+}
+
+// estimateCoverage оценивает положение станции центром позиций, взвешенным по
+// амплитуде сигнала, и радиус покрытия 95-м процентилем расстояния до позиций
+func estimateCoverage(positions []models.HeardPosition) *models.GatewayCoverage {
+	if len(positions) < minCoveragePositions {
+		return nil
+	}
+
+	var latSum, lonSum, weightSum float64
+	for _, p := range positions {
+		weight := math.Pow(10, float64(p.RSSI)/20)
+		latSum += p.Latitude * weight
+		lonSum += p.Longitude * weight
+		weightSum += weight
+	}
+	center := models.GeoPoint{Latitude: latSum / weightSum, Longitude: lonSum / weightSum}
+
+	distances := make([]float64, len(positions))
+	for i, p := range positions {
+		distances[i] = center.DistanceTo(models.GeoPoint{Latitude: p.Latitude, Longitude: p.Longitude})
+	}
+	sort.Float64s(distances)
+
+	p95 := distances[int(math.Ceil(0.95*float64(len(distances))))-1]
+	return &models.GatewayCoverage{
+		Center: models.GeoPoint{
+			Latitude:  math.Round(center.Latitude*1e5) / 1e5,
+			Longitude: math.Round(center.Longitude*1e5) / 1e5,
+		},
+		RadiusKM:      math.Round(p95*100) / 100,
+		MaxDistanceKM: math.Round(distances[len(distances)-1]*100) / 100,
+		Positions:     len(positions),
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingGatewayNotifier запоминает события базовых станций
+type recordingGatewayNotifier struct {
+	mu     sync.Mutex
+	events []GatewayEvent
+}
+
+func (n *recordingGatewayNotifier) NotifyGateway(ctx context.Context, event GatewayEvent, gateway *models.Gateway) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+func (n *recordingGatewayNotifier) Events() []GatewayEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]GatewayEvent(nil), n.events...)
+}
+
+func newTestGatewayRegistry(config *GatewayConfig, notifier GatewayNotifier) (*GatewayRegistry, *testutil.Clock) {
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 30, 0, time.UTC))
+	registry := NewGatewayRegistry(utils.NewLogger("error", "text"), config, notifier)
+	registry.now = clock.Now
+	return registry, clock
+}
+
+func TestGatewayRegistry_Stats(t *testing.T) {
+	registry, clock := newTestGatewayRegistry(nil, nil)
+
+	registry.Observe(GatewayObservation{ChipID: "GW1", DeviceID: "ABC123", Type: 1, RSSI: -95, SNR: 3})
+	registry.Observe(GatewayObservation{ChipID: "GW1", DeviceID: "ABC123", Type: 2, RSSI: -75, SNR: 11})
+	clock.Advance(time.Minute)
+	registry.Observe(GatewayObservation{ChipID: "GW1", DeviceID: "DEF456", Type: 1, RSSI: -200, SNR: 40})
+	registry.Observe(GatewayObservation{ChipID: "", DeviceID: "OGN001", Type: 1})
+
+	gateways := registry.ListGateways(nil)
+	require.Len(t, gateways, 1, "observations without chip_id are ignored")
+
+	gw := gateways[0]
+	assert.Equal(t, "GW1", gw.ChipID)
+	assert.True(t, gw.Online)
+	assert.Equal(t, uint64(3), gw.Packets)
+	assert.Equal(t, map[uint8]uint64{1: 2, 2: 1}, gw.Types)
+	assert.Equal(t, 1.5, gw.PacketRate, "3 packets over 2 minutes")
+
+	assert.Equal(t, -200.0, gw.RSSI.Min)
+	assert.Equal(t, -75.0, gw.RSSI.Max)
+	assert.Equal(t, -123.3, gw.RSSI.Avg)
+	// Значения за границами попадают в крайние интервалы
+	assert.Equal(t, models.HistogramBucket{Le: -120, Count: 1}, gw.RSSI.Buckets[0])
+	assert.Equal(t, models.HistogramBucket{Le: -90, Count: 1}, gw.RSSI.Buckets[3])
+	assert.Equal(t, models.HistogramBucket{Le: 20, Count: 1}, gw.SNR.Buckets[len(gw.SNR.Buckets)-1])
+
+	assert.Nil(t, gw.Coverage, "no positions heard")
+	assert.Empty(t, gw.Positions, "list does not include positions")
+
+	// Через 15 минут без пакетов частота падает до нуля
+	clock.Advance(20 * time.Minute)
+	gw, ok := registry.GetGateway("GW1")
+	require.True(t, ok)
+	assert.Equal(t, 0.0, gw.PacketRate)
+
+	_, ok = registry.GetGateway("UNKNOWN")
+	assert.False(t, ok)
+}
+
+func TestGatewayRegistry_Coverage(t *testing.T) {
+	registry, _ := newTestGatewayRegistry(&GatewayConfig{OfflineAfter: time.Minute, MaxPositions: 4}, nil)
+
+	// Станция в точке (46.0, 14.0). Ближняя позиция вытесняется из буфера,
+	// оценка строится по четырем позициям вокруг станции
+	station := models.GeoPoint{Latitude: 46.0, Longitude: 14.0}
+	observations := []struct {
+		lat, lon float64
+		rssi     int16
+	}{
+		{46.0, 14.0, -50},
+		{46.1, 14.0, -100},
+		{45.9, 14.0, -100},
+		{46.0, 14.1, -100},
+		{46.0, 13.9, -100},
+	}
+	for i, o := range observations {
+		registry.Observe(GatewayObservation{
+			ChipID:   "GW1",
+			DeviceID: "ABC123",
+			Type:     1,
+			RSSI:     o.rssi,
+			Position: &models.GeoPoint{Latitude: o.lat, Longitude: o.lon},
+		})
+		if i == 1 {
+			gw, _ := registry.GetGateway("GW1")
+			assert.Nil(t, gw.Coverage, "not enough positions")
+			assert.Nil(t, registry.Position("GW1"))
+		}
+	}
+
+	gw, ok := registry.GetGateway("GW1")
+	require.True(t, ok)
+	require.Len(t, gw.Positions, 4, "only MaxPositions latest positions are kept")
+	assert.Equal(t, 46.1, gw.Positions[0].Latitude, "oldest kept position first")
+	assert.Equal(t, 13.9, gw.Positions[3].Longitude)
+
+	require.NotNil(t, gw.Coverage)
+	assert.Equal(t, 4, gw.Coverage.Positions)
+	assert.Less(t, gw.Coverage.Center.DistanceTo(station), 1.0)
+	assert.InDelta(t, 11.1, gw.Coverage.MaxDistanceKM, 0.5)
+	assert.InDelta(t, 11.1, gw.Coverage.RadiusKM, 0.5)
+
+	require.NotNil(t, registry.Position("GW1"))
+	assert.Equal(t, gw.Coverage.Center, *registry.Position("GW1"))
+	assert.Nil(t, registry.Position("UNKNOWN"))
+}
+
+func TestGatewayRegistry_OfflineAndBack(t *testing.T) {
+	notifier := &recordingGatewayNotifier{}
+	registry, clock := newTestGatewayRegistry(&GatewayConfig{OfflineAfter: 10 * time.Minute, MaxPositions: 10, NotifyTimeout: time.Second}, notifier)
+
+	registry.Observe(GatewayObservation{ChipID: "GW1", Type: 1})
+	registry.Observe(GatewayObservation{ChipID: "GW2", Type: 1})
+	assert.Equal(t, 0, registry.CheckStatus())
+
+	clock.Advance(8 * time.Minute)
+	registry.Observe(GatewayObservation{ChipID: "GW2", Type: 1})
+
+	clock.Advance(5 * time.Minute)
+	assert.Equal(t, 1, registry.CheckStatus(), "GW1 silent for 13 minutes")
+	assert.Equal(t, 0, registry.CheckStatus(), "offline is reported once")
+
+	offline := false
+	gateways := registry.ListGateways(&offline)
+	require.Len(t, gateways, 1)
+	assert.Equal(t, "GW1", gateways[0].ChipID)
+
+	registry.Observe(GatewayObservation{ChipID: "GW1", Type: 1})
+	online := true
+	assert.Len(t, registry.ListGateways(&online), 2)
+
+	assert.Eventually(t, func() bool {
+		return len(notifier.Events()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []GatewayEvent{GatewayEventOffline, GatewayEventOnline}, notifier.Events())
+
+	clock.Advance(2 * time.Hour)
+	registry.Observe(GatewayObservation{ChipID: "GW2", Type: 1})
+	assert.Equal(t, 1, registry.Cleanup(time.Hour))
+	assert.Len(t, registry.ListGateways(nil), 1)
+}
+
+func TestWebhookNotifier_Gateway(t *testing.T) {
+	var received GatewayWebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, nil)
+	err := notifier.NotifyGateway(context.Background(), GatewayEventOffline, &models.Gateway{ChipID: "GW1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, GatewayEventOffline, received.Event)
+	require.NotNil(t, received.Gateway)
+	assert.Equal(t, "GW1", received.Gateway.ChipID)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/mmcloughlin/geohash"
+)
+
+// ThermalHistorySource источник исторических термиков (реализуется repository.MySQLRepository)
+type ThermalHistorySource interface {
+	GetThermalHistory(ctx context.Context, filter *models.ThermalHeatmapFilter, limit int) ([]*models.Thermal, error)
+}
+
+// HeatmapService строит тепловую карту исторических термиков по geohash ячейкам.
+// Область, период и скороподъемность фильтруются в MySQL, время суток, месяц
+// и ветер - в сервисе (время суток считается в часовом поясе запроса)
+type HeatmapService struct {
+	source      ThermalHistorySource
+	logger      *utils.Logger
+	maxThermals int // Максимум термиков из MySQL на один запрос
+}
+
+// NewHeatmapService создает сервис тепловой карты
+func NewHeatmapService(source ThermalHistorySource, logger *utils.Logger, maxThermals int) *HeatmapService {
+	if maxThermals <= 0 {
+		maxThermals = 50000
+	}
+
+	return &HeatmapService{
+		source:      source,
+		logger:      logger,
+		maxThermals: maxThermals,
+	}
+}
+
+// BuildThermalHeatmap агрегирует термики, подходящие под фильтр, в ячейки geohash точности precision
+func (s *HeatmapService) BuildThermalHeatmap(ctx context.Context, filter *models.ThermalHeatmapFilter, precision int) (*models.ThermalHeatmap, error) {
+	thermals, err := s.source.GetThermalHistory(ctx, filter, s.maxThermals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thermal history: %w", err)
+	}
+
+	matched := make([]*models.Thermal, 0, len(thermals))
+	for _, thermal := range thermals {
+		if matchThermal(filter, thermal) {
+			matched = append(matched, thermal)
+		}
+	}
+
+	heatmap := &models.ThermalHeatmap{
+		Precision: precision,
+		From:      filter.From,
+		To:        filter.To,
+		Thermals:  len(matched),
+		Truncated: len(thermals) >= s.maxThermals,
+		Cells:     aggregateHeatmap(matched, precision, filter.Location),
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"loaded":    len(thermals),
+		"matched":   len(matched),
+		"cells":     len(heatmap.Cells),
+		"precision": precision,
+		"truncated": heatmap.Truncated,
+	}).Debug("Built thermal heatmap")
+
+	return heatmap, nil
+}
+
+// matchThermal проверяет фильтры, которые не выражаются в SQL
+func matchThermal(filter *models.ThermalHeatmapFilter, thermal *models.Thermal) bool {
+	if thermal.Position == nil {
+		return false
+	}
+	if thermal.ClimbRate < filter.MinClimb {
+		return false
+	}
+
+	local := thermal.Timestamp
+	if filter.Location != nil {
+		local = local.In(filter.Location)
+	}
+	if len(filter.Months) > 0 && !slices.Contains(filter.Months, local.Month()) {
+		return false
+	}
+	if filter.HourFrom >= 0 && filter.HourTo >= 0 && !inCircularRange(local.Hour(), filter.HourFrom, filter.HourTo, 24) {
+		return false
+	}
+
+	if filter.WindFrom != nil && filter.WindTo != nil {
+		// Направление ветра в штиль не определено
+		if thermal.WindSpeed == 0 {
+			return false
+		}
+		if !inCircularRange(int(thermal.WindDirection), int(*filter.WindFrom), int(*filter.WindTo), 360) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inCircularRange проверяет value в [from, to] на окружности длины period (from > to - через ноль)
+func inCircularRange(value, from, to, period int) bool {
+	value, from, to = value%period, from%period, to%period
+	if from <= to {
+		return value >= from && value <= to
+	}
+	return value >= from || value <= to
+}
+
+// heatmapAccumulator накапливает термики одной ячейки
+type heatmapAccumulator struct {
+	count    int
+	climb    float64
+	maxClimb float32
+	altitude int64
+	pilots   map[string]struct{}
+	days     map[string]struct{}
+}
+
+// aggregateHeatmap группирует термики по geohash ячейкам. Ячейки упорядочены
+// по убыванию количества термиков, затем по geohash
+func aggregateHeatmap(thermals []*models.Thermal, precision int, location *time.Location) []models.HeatmapCell {
+	cells := make(map[string]*heatmapAccumulator)
+	for _, thermal := range thermals {
+		hash := thermal.Position.Geohash(precision)
+		acc, ok := cells[hash]
+		if !ok {
+			acc = &heatmapAccumulator{
+				maxClimb: float32(math.Inf(-1)),
+				pilots:   make(map[string]struct{}),
+				days:     make(map[string]struct{}),
+			}
+			cells[hash] = acc
+		}
+
+		climb := thermal.ClimbRate
+		acc.count++
+		acc.climb += float64(climb)
+		acc.maxClimb = max(acc.maxClimb, climb)
+		acc.altitude += int64(thermal.Position.Altitude)
+		acc.pilots[thermal.ReportedBy] = struct{}{}
+
+		day := thermal.Timestamp
+		if location != nil {
+			day = day.In(location)
+		}
+		acc.days[day.Format("2006-01-02")] = struct{}{}
+	}
+
+	result := make([]models.HeatmapCell, 0, len(cells))
+	for hash, acc := range cells {
+		box := geohash.BoundingBox(hash)
+		lat, lon := box.Center()
+		result = append(result, models.HeatmapCell{
+			Geohash: hash,
+			Center:  models.GeoPoint{Latitude: lat, Longitude: lon},
+			Bounds: models.Bounds{
+				Southwest: models.GeoPoint{Latitude: box.MinLat, Longitude: box.MinLng},
+				Northeast: models.GeoPoint{Latitude: box.MaxLat, Longitude: box.MaxLng},
+			},
+			Count:       acc.count,
+			Pilots:      len(acc.pilots),
+			AvgClimb:    float32(math.Round(acc.climb/float64(acc.count)*10) / 10),
+			MaxClimb:    acc.maxClimb,
+			AvgAltitude: int32(acc.altitude / int64(acc.count)),
+			Days:        len(acc.days),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Geohash < result[j].Geohash
+	})
+	return result
+}
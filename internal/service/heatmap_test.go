@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/mqtt"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticThermalHistory отдает заранее заданные термики
+type staticThermalHistory struct {
+	thermals []*models.Thermal
+	limit    int
+}
+
+func (s *staticThermalHistory) GetThermalHistory(ctx context.Context, filter *models.ThermalHeatmapFilter, limit int) ([]*models.Thermal, error) {
+	s.limit = limit
+	if len(s.thermals) > limit {
+		return s.thermals[:limit], nil
+	}
+	return s.thermals, nil
+}
+
+func historicalThermal(reportedBy string, lat, lon float64, climb float32, windDirection uint16, ts time.Time) *models.Thermal {
+	return &models.Thermal{
+		ReportedBy:    reportedBy,
+		Position:      &models.GeoPoint{Latitude: lat, Longitude: lon, Altitude: 1500},
+		ClimbRate:     climb,
+		WindSpeed:     15,
+		WindDirection: windDirection,
+		Timestamp:     ts,
+	}
+}
+
+// fanetThermal декодирует FANET Type 9 со средним набором climbCms (см/с)
+// и преобразует его в термик так же, как ingest
+func fanetThermal(t *testing.T, reportedBy string, lat, lon float64, climbCms int16, ts time.Time) *models.Thermal {
+	t.Helper()
+
+	frame := make([]byte, 8+4+13)
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(ts.Unix()))
+	frame[8] = 9
+	data := frame[12:]
+	latRaw := int32(math.Round(lat * 93206.04))
+	lonRaw := int32(math.Round(lon * 46603.02))
+	data[0], data[1], data[2] = byte(latRaw), byte(latRaw>>8), byte(latRaw>>16)
+	data[3], data[4], data[5] = byte(lonRaw), byte(lonRaw>>8), byte(lonRaw>>16)
+	binary.LittleEndian.PutUint16(data[6:8], 1500)
+	data[8] = 3
+	binary.LittleEndian.PutUint16(data[9:11], uint16(climbCms))
+
+	msg, err := mqtt.NewParser(utils.NewLogger("error", "text")).Parse("fb/b/ABC123/f/9", frame)
+	require.NoError(t, err)
+	parsed, ok := msg.Data.(*mqtt.ThermalData)
+	require.True(t, ok)
+
+	return &models.Thermal{
+		ReportedBy: reportedBy,
+		Position:   &models.GeoPoint{Latitude: parsed.Latitude, Longitude: parsed.Longitude, Altitude: parsed.Altitude},
+		Quality:    int32(parsed.Strength),
+		ClimbRate:  parsed.ClimbRate,
+		Timestamp:  ts,
+		Source:     models.ThermalSourceFANET,
+	}
+}
+
+func newHeatmapFilter() *models.ThermalHeatmapFilter {
+	return &models.ThermalHeatmapFilter{
+		Bounds: models.Bounds{
+			Southwest: models.GeoPoint{Latitude: 45, Longitude: 13},
+			Northeast: models.GeoPoint{Latitude: 47, Longitude: 15},
+		},
+		From:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		HourFrom: -1,
+		HourTo:   -1,
+	}
+}
+
+func TestHeatmapService_Aggregation(t *testing.T) {
+	june := time.Date(2024, 6, 10, 13, 0, 0, 0, time.UTC)
+	source := &staticThermalHistory{thermals: []*models.Thermal{
+		// Домашний термик: три термика, два пилота, два дня
+		historicalThermal("AAA001", 46.0001, 14.0001, 2, 270, june),
+		historicalThermal("AAA002", 46.0002, 14.0002, 3, 270, june.Add(time.Hour)),
+		historicalThermal("AAA001", 46.0003, 14.0003, 1, 270, june.Add(24*time.Hour)),
+		// Отдельная ячейка
+		historicalThermal("AAA003", 46.5, 14.5, 1.5, 90, june),
+	}}
+
+	heatmapService := NewHeatmapService(source, utils.NewLogger("error", "text"), 0)
+	heatmap, err := heatmapService.BuildThermalHeatmap(context.Background(), newHeatmapFilter(), 6)
+	require.NoError(t, err)
+
+	assert.Equal(t, 50000, source.limit, "default limit")
+	assert.Equal(t, 4, heatmap.Thermals)
+	assert.False(t, heatmap.Truncated)
+	require.Len(t, heatmap.Cells, 2)
+
+	house := heatmap.Cells[0]
+	assert.Equal(t, models.GeoPoint{Latitude: 46.0001, Longitude: 14.0001}.Geohash(6), house.Geohash)
+	assert.Equal(t, 3, house.Count)
+	assert.Equal(t, 2, house.Pilots)
+	assert.Equal(t, 2, house.Days)
+	assert.Equal(t, float32(2), house.AvgClimb)
+	assert.Equal(t, float32(3), house.MaxClimb)
+	assert.Equal(t, int32(1500), house.AvgAltitude)
+	assert.True(t, models.GeoPoint{Latitude: 46.0001, Longitude: 14.0001}.IsInBounds(house.Bounds.Southwest, house.Bounds.Northeast))
+	assert.True(t, house.Center.IsInBounds(house.Bounds.Southwest, house.Bounds.Northeast))
+
+	assert.Equal(t, 1, heatmap.Cells[1].Count)
+
+	// Лимит выборки отражается в ответе
+	heatmapService = NewHeatmapService(source, utils.NewLogger("error", "text"), 2)
+	heatmap, err = heatmapService.BuildThermalHeatmap(context.Background(), newHeatmapFilter(), 6)
+	require.NoError(t, err)
+	assert.True(t, heatmap.Truncated)
+	assert.Equal(t, 2, heatmap.Thermals)
+}
+
+func TestHeatmapService_FANETAndDerivedThermals(t *testing.T) {
+	june := time.Date(2024, 6, 10, 13, 0, 0, 0, time.UTC)
+
+	// Кружение с набором 2 м/с и FANET Type 9 с 3 м/с в одной ячейке
+	detector, _ := newTestThermalDetector()
+	derived := observeTrack(detector, circlingTrack("AAA001", 46.0, 14.0, 2, june, 25))
+	require.Len(t, derived, 1)
+	reported := fanetThermal(t, "AAA002", 46.0001, 14.0001, 300, june)
+
+	source := &staticThermalHistory{thermals: []*models.Thermal{derived[0], reported}}
+	heatmapService := NewHeatmapService(source, utils.NewLogger("error", "text"), 0)
+	heatmap, err := heatmapService.BuildThermalHeatmap(context.Background(), newHeatmapFilter(), 6)
+	require.NoError(t, err)
+	require.Len(t, heatmap.Cells, 1)
+
+	cell := heatmap.Cells[0]
+	assert.Equal(t, 2, cell.Count)
+	assert.InDelta(t, 2.5, cell.AvgClimb, 0.1)
+	assert.InDelta(t, 3, cell.MaxClimb, 0.01)
+
+	// Фильтр минимального набора одинаково применяется к обоим источникам
+	filter := newHeatmapFilter()
+	filter.MinClimb = 2.5
+	assert.False(t, matchThermal(filter, derived[0]))
+	assert.True(t, matchThermal(filter, reported))
+}
+
+func TestHeatmapService_Filters(t *testing.T) {
+	ljubljana, err := time.LoadLocation("Europe/Ljubljana")
+	require.NoError(t, err)
+
+	// 11:30 UTC летом - 13:30 по местному времени
+	noon := time.Date(2024, 7, 1, 11, 30, 0, 0, time.UTC)
+	night := time.Date(2024, 7, 1, 23, 30, 0, 0, time.UTC) // 01:30 местного времени 2 июля
+	april := time.Date(2024, 4, 1, 11, 30, 0, 0, time.UTC)
+
+	calm := historicalThermal("AAA004", 46, 14, 2, 0, noon)
+	calm.WindSpeed = 0
+
+	tests := []struct {
+		name    string
+		filter  func(f *models.ThermalHeatmapFilter)
+		thermal *models.Thermal
+		match   bool
+	}{
+		{"no filters", func(f *models.ThermalHeatmapFilter) {}, historicalThermal("A", 46, 14, 0.5, 0, noon), true},
+		{"min climb", func(f *models.ThermalHeatmapFilter) { f.MinClimb = 1 }, historicalThermal("A", 46, 14, 0.5, 0, noon), false},
+		{"local hour inside", func(f *models.ThermalHeatmapFilter) {
+			f.Location, f.HourFrom, f.HourTo = ljubljana, 12, 16
+		}, historicalThermal("A", 46, 14, 2, 0, noon), true},
+		{"utc hour outside", func(f *models.ThermalHeatmapFilter) {
+			f.HourFrom, f.HourTo = 12, 16
+		}, historicalThermal("A", 46, 14, 2, 0, noon), false},
+		{"hours over midnight", func(f *models.ThermalHeatmapFilter) {
+			f.Location, f.HourFrom, f.HourTo = ljubljana, 22, 3
+		}, historicalThermal("A", 46, 14, 2, 0, night), true},
+		{"month", func(f *models.ThermalHeatmapFilter) {
+			f.Months = []time.Month{time.May, time.June, time.July}
+		}, historicalThermal("A", 46, 14, 2, 0, april), false},
+		{"wind sector over north", func(f *models.ThermalHeatmapFilter) {
+			from, to := uint16(300), uint16(30)
+			f.WindFrom, f.WindTo = &from, &to
+		}, historicalThermal("A", 46, 14, 2, 10, noon), true},
+		{"wind outside sector", func(f *models.ThermalHeatmapFilter) {
+			from, to := uint16(300), uint16(30)
+			f.WindFrom, f.WindTo = &from, &to
+		}, historicalThermal("A", 46, 14, 2, 180, noon), false},
+		{"calm excluded by wind filter", func(f *models.ThermalHeatmapFilter) {
+			from, to := uint16(0), uint16(359)
+			f.WindFrom, f.WindTo = &from, &to
+		}, calm, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newHeatmapFilter()
+			tt.filter(filter)
+			assert.Equal(t, tt.match, matchThermal(filter, tt.thermal))
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// earthRadiusMeters радиус Земли для локальной проекции
+const earthRadiusMeters = 6371000.0
+
+// KalmanConfig конфигурация сглаживания позиций фильтром Калмана
+type KalmanConfig struct {
+	PositionNoise        float64       // Погрешность GPS по горизонтали, 1 сигма (м)
+	AltitudeNoise        float64       // Погрешность высоты, 1 сигма (м)
+	SpeedNoise           float64       // Погрешность скорости из пакета, 1 сигма (м/с)
+	ClimbNoise           float64       // Погрешность вертикальной скорости, 1 сигма (м/с)
+	Acceleration         float64       // Шум процесса: ускорение по горизонтали, 1 сигма (м/с²)
+	VerticalAcceleration float64       // Шум процесса: вертикальное ускорение, 1 сигма (м/с²)
+	GateChi2             float64       // Порог квадрата расстояния Махаланобиса (2 степени свободы)
+	MaxGateRejects       int           // Отклонений подряд, после которых фильтр начинается заново
+	MaxGap               time.Duration // Интервал между позициями, после которого фильтр начинается заново
+	PredictionHorizon    time.Duration // Горизонт прогноза позиции
+}
+
+// DefaultKalmanConfig возвращает конфигурацию по умолчанию
+func DefaultKalmanConfig() *KalmanConfig {
+	return &KalmanConfig{
+		PositionNoise:        10,
+		AltitudeNoise:        15,
+		SpeedNoise:           1.5,
+		ClimbNoise:           0.5,
+		Acceleration:         2,
+		VerticalAcceleration: 1,
+		GateChi2:             13.8, // 99.9% для распределения хи-квадрат с 2 степенями свободы
+		MaxGateRejects:       3,
+		MaxGap:               2 * time.Minute,
+		PredictionHorizon:    5 * time.Second,
+	}
+}
+
+// kalmanAxis фильтр постоянной скорости по одной оси: позиция (м) и скорость (м/с)
+type kalmanAxis struct {
+	pos, vel      float64
+	p00, p01, p11 float64 // Симметричная ковариация
+}
+
+// predict продвигает состояние на dt секунд с шумом ускорения accel
+func (a *kalmanAxis) predict(dt, accel float64) {
+	q := accel * accel
+	a.pos += a.vel * dt
+	a.p00 += 2*dt*a.p01 + dt*dt*a.p11 + q*dt*dt*dt*dt/4
+	a.p01 += dt*a.p11 + q*dt*dt*dt/2
+	a.p11 += q * dt * dt
+}
+
+// update учитывает измерение позиции и скорости с дисперсиями rPos и rVel
+func (a *kalmanAxis) update(pos, vel, rPos, rVel float64) {
+	// S = P + R, K = P * S^-1
+	s00, s01, s11 := a.p00+rPos, a.p01, a.p11+rVel
+	det := s00*s11 - s01*s01
+	if det <= 0 {
+		return
+	}
+	i00, i01, i11 := s11/det, -s01/det, s00/det
+
+	k00 := a.p00*i00 + a.p01*i01
+	k01 := a.p00*i01 + a.p01*i11
+	k10 := a.p01*i00 + a.p11*i01
+	k11 := a.p01*i01 + a.p11*i11
+
+	yPos, yVel := pos-a.pos, vel-a.vel
+	a.pos += k00*yPos + k01*yVel
+	a.vel += k10*yPos + k11*yVel
+
+	// P = (I - K) * P
+	p00 := (1-k00)*a.p00 - k01*a.p01
+	p01 := (1-k00)*a.p01 - k01*a.p11
+	p11 := -k10*a.p01 + (1-k11)*a.p11
+	a.p00, a.p01, a.p11 = p00, p01, p11
+}
+
+// kalmanTrack состояние фильтра одного устройства в локальной проекции
+// с началом в первой позиции (восток, север, высота)
+type kalmanTrack struct {
+	originLat, originLon float64
+	east, north, up      kalmanAxis
+	timestamp            time.Time
+	rejects              int
+}
+
+// KalmanSmoother сглаживает позиции пилотов фильтром Калмана постоянной скорости
+// (позиция, путевая скорость и вертикальная скорость) по координатам, скорости,
+// курсу и вертикальной скорости из FANET пакетов и прогнозирует позицию между
+// пакетами. Позиция, далекая от прогноза (расстояние Махаланобиса больше GateChi2),
+// считается выбросом и не меняет состояние фильтра
+type KalmanSmoother struct {
+	tracks map[string]*kalmanTrack // Device ID -> состояние фильтра
+	mu     sync.Mutex
+
+	config *KalmanConfig
+	logger *utils.Logger
+	now    func() time.Time
+}
+
+// NewKalmanSmoother создает фильтр. config может быть nil
+func NewKalmanSmoother(logger *utils.Logger, config *KalmanConfig) *KalmanSmoother {
+	if config == nil {
+		config = DefaultKalmanConfig()
+	}
+
+	return &KalmanSmoother{
+		tracks: make(map[string]*kalmanTrack),
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Observe учитывает позицию пилота и записывает оценку в pilot.Smoothed.
+// Возвращает false, если позиция отклонена как выброс: тогда pilot.Smoothed
+// содержит прогноз фильтра на время позиции
+func (k *KalmanSmoother) Observe(pilot *models.Pilot) bool {
+	if pilot == nil || pilot.Position == nil {
+		return true
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	track, ok := k.tracks[pilot.DeviceID]
+	if !ok || pilot.LastUpdate.Sub(track.timestamp) > k.config.MaxGap {
+		pilot.Smoothed = k.reset(pilot)
+		return true
+	}
+
+	dt := pilot.LastUpdate.Sub(track.timestamp).Seconds()
+	if dt <= 0 {
+		pilot.Smoothed = k.estimate(track, 0)
+		return true
+	}
+
+	k.predict(track, dt)
+	track.timestamp = pilot.LastUpdate
+
+	east, north := track.project(pilot.Position.Latitude, pilot.Position.Longitude)
+	rPos := k.config.PositionNoise * k.config.PositionNoise
+	yEast, yNorth := east-track.east.pos, north-track.north.pos
+	distance := yEast*yEast/(track.east.p00+rPos) + yNorth*yNorth/(track.north.p00+rPos)
+
+	if distance > k.config.GateChi2 {
+		track.rejects++
+		metrics.KalmanGateRejections.Inc()
+		if track.rejects >= k.config.MaxGateRejects {
+			// Пилот стабильно не там, где ожидает фильтр: ошибочно состояние фильтра
+			pilot.Smoothed = k.reset(pilot)
+			return true
+		}
+
+		k.logger.WithFields(map[string]interface{}{
+			"device_id":   pilot.DeviceID,
+			"mahalanobis": math.Sqrt(distance),
+			"innovation":  math.Hypot(yEast, yNorth),
+		}).Debug("Position rejected by Kalman gate")
+
+		pilot.Smoothed = k.estimate(track, 0)
+		return false
+	}
+	track.rejects = 0
+
+	vEast, vNorth := pilotVelocity(pilot)
+	rVel := k.config.SpeedNoise * k.config.SpeedNoise
+	track.east.update(east, vEast, rPos, rVel)
+	track.north.update(north, vNorth, rPos, rVel)
+	track.up.update(float64(pilot.Position.Altitude), float64(pilot.ClimbRate)/10,
+		k.config.AltitudeNoise*k.config.AltitudeNoise, k.config.ClimbNoise*k.config.ClimbNoise)
+
+	pilot.Smoothed = k.estimate(track, 0)
+	return true
+}
+
+// Reset начинает фильтр устройства заново с позиции пилота и записывает
+// оценку в pilot.Smoothed
+func (k *KalmanSmoother) Reset(pilot *models.Pilot) {
+	if pilot == nil || pilot.Position == nil {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	pilot.Smoothed = k.reset(pilot)
+}
+
+// Predict возвращает оценку состояния устройства, продвинутую на момент at
+func (k *KalmanSmoother) Predict(deviceID string, at time.Time) (*models.PilotEstimate, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	track, ok := k.tracks[deviceID]
+	if !ok {
+		return nil, false
+	}
+
+	dt := at.Sub(track.timestamp).Seconds()
+	if dt < 0 {
+		dt = 0
+	}
+	return k.estimate(track, dt), true
+}
+
+// Cleanup удаляет состояние устройств без позиций дольше maxAge.
+// Возвращает количество удаленных устройств
+func (k *KalmanSmoother) Cleanup(maxAge time.Duration) int {
+	now := k.now()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	removed := 0
+	for deviceID, track := range k.tracks {
+		if now.Sub(track.timestamp) > maxAge {
+			delete(k.tracks, deviceID)
+			removed++
+		}
+	}
+
+	metrics.KalmanTrackedDevices.Set(float64(len(k.tracks)))
+	return removed
+}
+
+// reset создает состояние фильтра по позиции пилота
+func (k *KalmanSmoother) reset(pilot *models.Pilot) *models.PilotEstimate {
+	vEast, vNorth := pilotVelocity(pilot)
+	rPos := k.config.PositionNoise * k.config.PositionNoise
+	rVel := k.config.SpeedNoise * k.config.SpeedNoise
+
+	track := &kalmanTrack{
+		originLat: pilot.Position.Latitude,
+		originLon: pilot.Position.Longitude,
+		east:      kalmanAxis{pos: 0, vel: vEast, p00: rPos, p11: rVel},
+		north:     kalmanAxis{pos: 0, vel: vNorth, p00: rPos, p11: rVel},
+		up: kalmanAxis{
+			pos: float64(pilot.Position.Altitude),
+			vel: float64(pilot.ClimbRate) / 10,
+			p00: k.config.AltitudeNoise * k.config.AltitudeNoise,
+			p11: k.config.ClimbNoise * k.config.ClimbNoise,
+		},
+		timestamp: pilot.LastUpdate,
+	}
+	k.tracks[pilot.DeviceID] = track
+	metrics.KalmanTrackedDevices.Set(float64(len(k.tracks)))
+
+	return k.estimate(track, 0)
+}
+
+// predict продвигает все оси на dt секунд
+func (k *KalmanSmoother) predict(track *kalmanTrack, dt float64) {
+	track.east.predict(dt, k.config.Acceleration)
+	track.north.predict(dt, k.config.Acceleration)
+	track.up.predict(dt, k.config.VerticalAcceleration)
+}
+
+// estimate возвращает оценку, продвинутую на dt секунд, и прогноз на PredictionHorizon после нее
+func (k *KalmanSmoother) estimate(track *kalmanTrack, dt float64) *models.PilotEstimate {
+	horizon := k.config.PredictionHorizon.Seconds()
+	east, north, up := track.east, track.north, track.up
+	if dt > 0 {
+		east.predict(dt, k.config.Acceleration)
+		north.predict(dt, k.config.Acceleration)
+		up.predict(dt, k.config.VerticalAcceleration)
+	}
+
+	lat, lon := track.unproject(east.pos, north.pos)
+	predLat, predLon := track.unproject(east.pos+east.vel*horizon, north.pos+north.vel*horizon)
+	at := track.timestamp.Add(time.Duration(dt * float64(time.Second)))
+
+	return &models.PilotEstimate{
+		Position: models.GeoPoint{
+			Latitude:  lat,
+			Longitude: lon,
+			Altitude:  int32(math.Round(up.pos)),
+		},
+		Speed:    float32(math.Hypot(east.vel, north.vel) * 3.6),
+		Heading:  float32(math.Mod(math.Atan2(east.vel, north.vel)*180/math.Pi+360, 360)),
+		Climb:    float32(up.vel),
+		Accuracy: float32(math.Sqrt((east.p00 + north.p00) / 2)),
+		Predicted: models.GeoPoint{
+			Latitude:  predLat,
+			Longitude: predLon,
+			Altitude:  int32(math.Round(up.pos + up.vel*horizon)),
+		},
+		PredictedAt: at.Add(k.config.PredictionHorizon),
+	}
+}
+
+// project переводит координаты в метры на восток и север от начала проекции
+func (t *kalmanTrack) project(lat, lon float64) (float64, float64) {
+	east := (lon - t.originLon) * math.Pi / 180 * earthRadiusMeters * math.Cos(t.originLat*math.Pi/180)
+	north := (lat - t.originLat) * math.Pi / 180 * earthRadiusMeters
+	return east, north
+}
+
+// unproject переводит метры от начала проекции в координаты
+func (t *kalmanTrack) unproject(east, north float64) (float64, float64) {
+	lat := t.originLat + north/earthRadiusMeters*180/math.Pi
+	lon := t.originLon + east/(earthRadiusMeters*math.Cos(t.originLat*math.Pi/180))*180/math.Pi
+	return lat, lon
+}
+
+// pilotVelocity возвращает скорость из пакета в м/с на восток и север
+func pilotVelocity(pilot *models.Pilot) (float64, float64) {
+	speed := float64(pilot.Speed) / 3.6
+	heading := float64(pilot.Heading) * math.Pi / 180
+	return speed * math.Sin(heading), speed * math.Cos(heading)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metersPerDegree длина градуса широты
+const metersPerDegree = 111195.0
+
+// northbound возвращает позицию пилота, летящего на север со скоростью 10 м/с,
+// через elapsed секунд со смещением на восток offsetEast метров
+func northbound(pilotType models.PilotType, start time.Time, elapsed, offsetNorth, offsetEast float64) *models.Pilot {
+	return &models.Pilot{
+		DeviceID: "ABC123",
+		Type:     pilotType,
+		Position: &models.GeoPoint{
+			Latitude:  46.0 + (10*elapsed+offsetNorth)/metersPerDegree,
+			Longitude: 13.0 + offsetEast/(metersPerDegree*math.Cos(46.0*math.Pi/180)),
+			Altitude:  1500,
+		},
+		Speed:      36,
+		Heading:    0,
+		LastUpdate: start.Add(time.Duration(elapsed * float64(time.Second))),
+	}
+}
+
+// northError возвращает отклонение позиции от идеального трека на момент elapsed (м)
+func northError(position models.GeoPoint, elapsed float64) float64 {
+	ideal := models.GeoPoint{Latitude: 46.0 + 10*elapsed/metersPerDegree, Longitude: 13.0}
+	return ideal.DistanceTo(position) * 1000
+}
+
+func TestKalmanSmoother_ReducesNoise(t *testing.T) {
+	smoother := NewKalmanSmoother(utils.NewLogger("error", "text"), nil)
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+	random := rand.New(rand.NewSource(1))
+
+	var rawError, smoothedError float64
+	for i := 0; i < 40; i++ {
+		elapsed := float64(i * 3)
+		pilot := northbound(models.PilotTypeParaglider, start, elapsed, random.NormFloat64()*8, random.NormFloat64()*8)
+		require.True(t, smoother.Observe(pilot))
+		require.NotNil(t, pilot.Smoothed)
+
+		if i >= 10 {
+			rawError += northError(*pilot.Position, elapsed)
+			smoothedError += northError(pilot.Smoothed.Position, elapsed)
+		}
+	}
+
+	assert.Less(t, smoothedError, rawError*0.8)
+
+	estimate, ok := smoother.Predict("ABC123", start.Add(117*time.Second))
+	require.True(t, ok)
+	assert.InDelta(t, 36, estimate.Speed, 3)
+	assert.InDelta(t, 0, math.Sin(float64(estimate.Heading)*math.Pi/180), 0.1)
+	assert.Less(t, estimate.Accuracy, float32(10))
+}
+
+func TestKalmanSmoother_Prediction(t *testing.T) {
+	smoother := NewKalmanSmoother(utils.NewLogger("error", "text"), nil)
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	var last *models.Pilot
+	for i := 0; i < 10; i++ {
+		last = northbound(models.PilotTypeParaglider, start, float64(i*3), 0, 0)
+		require.True(t, smoother.Observe(last))
+	}
+
+	// Прогноз на горизонт по умолчанию (5 секунд) - 50 метров на север
+	smoothed := last.Smoothed
+	assert.Equal(t, last.LastUpdate.Add(5*time.Second), smoothed.PredictedAt)
+	assert.InDelta(t, 50, smoothed.Position.DistanceTo(smoothed.Predicted)*1000, 5)
+
+	// Счисление пути между пакетами
+	estimate, ok := smoother.Predict("ABC123", last.LastUpdate.Add(2*time.Second))
+	require.True(t, ok)
+	assert.InDelta(t, 20, smoothed.Position.DistanceTo(estimate.Position)*1000, 3)
+
+	_, ok = smoother.Predict("FFFFFF", last.LastUpdate)
+	assert.False(t, ok)
+}
+
+func TestKalmanSmoother_GateRejectsOutlier(t *testing.T) {
+	smoother := NewKalmanSmoother(utils.NewLogger("error", "text"), nil)
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 10; i++ {
+		require.True(t, smoother.Observe(northbound(models.PilotTypeParaglider, start, float64(i*3), 0, 0)))
+	}
+
+	// Позиция в 500 метрах от трека отклоняется, оценка остается на треке
+	outlier := northbound(models.PilotTypeParaglider, start, 30, 0, 500)
+	assert.False(t, smoother.Observe(outlier))
+	require.NotNil(t, outlier.Smoothed)
+	assert.Less(t, northError(outlier.Smoothed.Position, 30), 10.0)
+
+	assert.True(t, smoother.Observe(northbound(models.PilotTypeParaglider, start, 33, 0, 0)))
+
+	// Пилот стабильно в другом месте: после MaxGateRejects фильтр начинается заново
+	accepted := 0
+	for i := 0; i < 3; i++ {
+		if smoother.Observe(northbound(models.PilotTypeParaglider, start, float64(36+i*3), 0, 2000)) {
+			accepted++
+		}
+	}
+	assert.Equal(t, 1, accepted)
+}
+
+func TestKalmanSmoother_Cleanup(t *testing.T) {
+	smoother := NewKalmanSmoother(utils.NewLogger("error", "text"), nil)
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC))
+	smoother.now = clock.Now
+
+	smoother.Observe(northbound(models.PilotTypeParaglider, clock.Now(), 0, 0, 0))
+	assert.Equal(t, 0, smoother.Cleanup(time.Hour))
+
+	clock.Advance(2 * time.Hour)
+	assert.Equal(t, 1, smoother.Cleanup(time.Hour))
+	assert.Empty(t, smoother.tracks)
+}
+
+func TestValidationService_KalmanGate(t *testing.T) {
+	logger := utils.NewLogger("error", "text")
+	validation := NewValidationService(logger, nil)
+	validation.SetSmoother(NewKalmanSmoother(logger, nil))
+	start := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 6; i++ {
+		pilot := northbound(models.PilotTypePowered, start, float64(i*10), 0, 0)
+		_, shouldStore, err := validation.ValidatePilot(pilot)
+		require.NoError(t, err)
+		require.NotNil(t, pilot.Smoothed)
+		if i >= 2 {
+			assert.True(t, shouldStore)
+		}
+	}
+
+	// 1 км в сторону за 10 секунд допустимо по скорости, но далеко от прогноза
+	outlier := northbound(models.PilotTypePowered, start, 60, 0, 1000)
+	isValid, shouldStore, err := validation.ValidatePilot(outlier)
+	require.NoError(t, err)
+	assert.False(t, isValid)
+	assert.True(t, shouldStore)
+	require.NotNil(t, outlier.Smoothed)
+	assert.Less(t, northError(outlier.Smoothed.Position, 60), 50.0)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// LiveFilterConfig конфигурация потоковой фильтрации живых позиций
+type LiveFilterConfig struct {
+	MaxDelay time.Duration        // Ожидание следующей точки для отложенного скачка
+	IdleTTL  time.Duration        // Время без позиций, после которого состояние устройства удаляется
+	Filter   *filter.FilterConfig // Лимиты скорости и порог выбросов, как у фильтров GetTrack
+}
+
+// DefaultLiveFilterConfig возвращает конфигурацию по умолчанию
+func DefaultLiveFilterConfig() *LiveFilterConfig {
+	return &LiveFilterConfig{
+		MaxDelay: 15 * time.Second,
+		IdleTTL:  30 * time.Minute,
+		Filter:   filter.DefaultFilterConfig(),
+	}
+}
+
+// liveStream состояние фильтра одного устройства
+type liveStream struct {
+	filter    *filter.StreamFilter
+	pending   *models.Pilot // Отложенная позиция
+	delayedAt time.Time
+	lastSeen  time.Time
+}
+
+// LiveFilter фильтрует живые позиции до сохранения в Redis и рассылки по WebSocket,
+// чтобы живые треки совпадали с тем, что позже вернет GetTrack. Для каждого
+// устройства хранится filter.StreamFilter с ограниченным окном: позиция
+// принимается, откладывается до следующей позиции или отклоняется.
+// Позиции одного устройства должны поступать по порядку (ingest.Pipeline)
+type LiveFilter struct {
+	streams map[string]*liveStream // Device ID -> состояние фильтра
+	mu      sync.Mutex
+
+	config *LiveFilterConfig
+	logger *utils.Logger
+	now    func() time.Time
+}
+
+// NewLiveFilter создает потоковый фильтр живых позиций. config может быть nil
+func NewLiveFilter(logger *utils.Logger, config *LiveFilterConfig) *LiveFilter {
+	if config == nil {
+		config = DefaultLiveFilterConfig()
+	}
+	if config.Filter == nil {
+		config.Filter = filter.DefaultFilterConfig()
+	}
+
+	return &LiveFilter{
+		streams: make(map[string]*liveStream),
+		config:  config,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Observe пропускает позицию пилота через фильтр устройства и возвращает позиции,
+// которые можно публиковать, в порядке времени: ранее отложенную, если следующая
+// позиция ее подтвердила, и текущую, если она принята. Отложенная или отклоненная
+// текущая позиция не возвращается
+func (f *LiveFilter) Observe(pilot *models.Pilot) []*models.Pilot {
+	if pilot == nil || pilot.Position == nil {
+		return nil
+	}
+
+	now := f.now()
+	point := filter.TrackPoint{
+		Position:  *pilot.Position,
+		Timestamp: pilot.LastUpdate,
+	}
+	if point.Timestamp.IsZero() {
+		point.Timestamp = now
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stream, ok := f.streams[pilot.DeviceID]
+	if !ok {
+		stream = &liveStream{filter: filter.NewStreamFilter(f.config.Filter, pilot.Type)}
+		f.streams[pilot.DeviceID] = stream
+		metrics.LiveFilterDevices.Set(float64(len(f.streams)))
+	}
+	stream.filter.SetAircraftType(pilot.Type)
+	stream.lastSeen = now
+
+	previous := stream.pending
+	stream.pending = nil
+
+	result := stream.filter.Push(point)
+	metrics.LiveFilterPoints.WithLabelValues(result.Decision.String()).Inc()
+
+	released := make([]*models.Pilot, 0, 2)
+	if previous != nil {
+		// Отложенная позиция разрешается первой: она либо среди принятых, либо среди отклоненных
+		current := 0
+		if result.Decision == filter.StreamAccept {
+			current = 1
+		}
+		if len(result.Released) > current {
+			released = append(released, previous)
+			metrics.LiveFilterPoints.WithLabelValues("release").Inc()
+		}
+	}
+
+	switch result.Decision {
+	case filter.StreamAccept:
+		released = append(released, pilot)
+	case filter.StreamDelay:
+		stream.pending = pilot
+		stream.delayedAt = now
+	}
+
+	for _, rejected := range result.Rejected {
+		metrics.LiveFilterRejected.WithLabelValues(rejected.Kind).Inc()
+		f.logger.WithFields(map[string]interface{}{
+			"device_id": pilot.DeviceID,
+			"filter":    rejected.Filter,
+			"reason":    rejected.Reason,
+			"lat":       rejected.Position.Latitude,
+			"lon":       rejected.Position.Longitude,
+			"speed":     rejected.Speed,
+		}).Debug("Live position rejected by stream filter")
+	}
+
+	return released
+}
+
+// ExpiredDevices возвращает устройства с отложенной позицией, для которой
+// следующая позиция не пришла за MaxDelay. Сами позиции выпускает Release
+// в том же порядке обработки, что и Observe
+func (f *LiveFilter) ExpiredDevices() []string {
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var devices []string
+	for deviceID, stream := range f.streams {
+		if stream.pending != nil && now.Sub(stream.delayedAt) >= f.config.MaxDelay {
+			devices = append(devices, deviceID)
+		}
+	}
+	return devices
+}
+
+// Release принимает отложенную позицию устройства, если следующая позиция не
+// пришла за MaxDelay, и возвращает ее для публикации. nil - позиции нет: она
+// не отложена, еще ждет или уже разрешена следующей позицией
+func (f *LiveFilter) Release(deviceID string) *models.Pilot {
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stream, ok := f.streams[deviceID]
+	if !ok || stream.pending == nil || now.Sub(stream.delayedAt) < f.config.MaxDelay {
+		return nil
+	}
+
+	pending := stream.pending
+	stream.pending = nil
+	if _, ok := stream.filter.Flush(); !ok {
+		return nil
+	}
+	metrics.LiveFilterPoints.WithLabelValues("release").Inc()
+	return pending
+}
+
+// Cleanup удаляет состояние устройств без позиций дольше IdleTTL.
+// Возвращает количество удаленных устройств
+func (f *LiveFilter) Cleanup() int {
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	removed := 0
+	for deviceID, stream := range f.streams {
+		if stream.pending == nil && now.Sub(stream.lastSeen) > f.config.IdleTTL {
+			delete(f.streams, deviceID)
+			removed++
+		}
+	}
+
+	metrics.LiveFilterDevices.Set(float64(len(f.streams)))
+	return removed
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLiveFilter() (*LiveFilter, *testutil.Clock) {
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC))
+	liveFilter := NewLiveFilter(utils.NewLogger("error", "text"), nil)
+	liveFilter.now = clock.Now
+	return liveFilter, clock
+}
+
+func livePilot(lat, lon float64, ts time.Time) *models.Pilot {
+	return &models.Pilot{
+		DeviceID:   "ABC123",
+		Type:       models.PilotTypeParaglider,
+		Position:   &models.GeoPoint{Latitude: lat, Longitude: lon, Altitude: 1500},
+		LastUpdate: ts,
+	}
+}
+
+// observeStraight подает count позиций на север с шагом ~111 м каждые 10 секунд (40 км/ч)
+// и возвращает время последней позиции
+func observeStraight(t *testing.T, liveFilter *LiveFilter, start time.Time, count int) time.Time {
+	ts := start
+	for i := 0; i < count; i++ {
+		ts = start.Add(time.Duration(i) * 10 * time.Second)
+		released := liveFilter.Observe(livePilot(46.0+float64(i)*0.001, 13.0, ts))
+		require.Len(t, released, 1, "position %d must be accepted", i)
+	}
+	return ts
+}
+
+func TestLiveFilter_AcceptsSmoothTrack(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	observeStraight(t, liveFilter, clock.Now(), 30)
+}
+
+func TestLiveFilter_RejectsTeleport(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	last := observeStraight(t, liveFilter, clock.Now(), 20)
+
+	// Скачок на ~100 км за 10 секунд
+	assert.Empty(t, liveFilter.Observe(livePilot(47.0, 13.0, last.Add(10*time.Second))))
+
+	next := livePilot(46.020, 13.0, last.Add(20*time.Second))
+	assert.Equal(t, []*models.Pilot{next}, liveFilter.Observe(next))
+}
+
+func TestLiveFilter_DelaysAndRejectsLocalOutlier(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	last := observeStraight(t, liveFilter, clock.Now(), 20)
+
+	// ~3 км в сторону за 2 минуты: скорость допустима, но скачок больше порога выброса
+	assert.Empty(t, liveFilter.Observe(livePilot(46.019, 13.04, last.Add(2*time.Minute))))
+
+	// Следующая позиция вернулась к треку - отложенная отклоняется как выброс
+	next := livePilot(46.021, 13.0, last.Add(2*time.Minute+10*time.Second))
+	assert.Equal(t, []*models.Pilot{next}, liveFilter.Observe(next))
+}
+
+func TestLiveFilter_DelaysAndReleasesJump(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	last := observeStraight(t, liveFilter, clock.Now(), 20)
+
+	jump := livePilot(46.019, 13.04, last.Add(2*time.Minute))
+	assert.Empty(t, liveFilter.Observe(jump))
+
+	// Трек продолжается от новой позиции - скачок подтвержден
+	next := livePilot(46.020, 13.04, last.Add(2*time.Minute+10*time.Second))
+	assert.Equal(t, []*models.Pilot{jump, next}, liveFilter.Observe(next))
+}
+
+func TestLiveFilter_ExpiredReleasesDelayed(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	last := observeStraight(t, liveFilter, clock.Now(), 20)
+
+	jump := livePilot(46.019, 13.04, last.Add(2*time.Minute))
+	assert.Empty(t, liveFilter.Observe(jump))
+	assert.Empty(t, liveFilter.ExpiredDevices())
+	assert.Nil(t, liveFilter.Release("ABC123"))
+
+	clock.Advance(liveFilter.config.MaxDelay)
+	assert.Equal(t, []string{"ABC123"}, liveFilter.ExpiredDevices())
+	assert.Equal(t, jump, liveFilter.Release("ABC123"))
+	assert.Empty(t, liveFilter.ExpiredDevices())
+	assert.Nil(t, liveFilter.Release("ABC123"))
+	assert.Nil(t, liveFilter.Release("UNKNOWN"))
+
+	// Принятый скачок стал опорной точкой
+	next := livePilot(46.020, 13.04, last.Add(2*time.Minute+10*time.Second))
+	assert.Equal(t, []*models.Pilot{next}, liveFilter.Observe(next))
+}
+
+func TestLiveFilter_ReanchorsAfterRejectStreak(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	last := observeStraight(t, liveFilter, clock.Now(), 20)
+
+	// Устройство стабильно сообщает позиции далеко от последней принятой
+	var released []*models.Pilot
+	for i := 1; i <= 5; i++ {
+		released = liveFilter.Observe(livePilot(47.0+float64(i)*0.001, 13.0, last.Add(time.Duration(i)*10*time.Second)))
+		if i < 5 {
+			assert.Empty(t, released, "position %d must be rejected", i)
+		}
+	}
+	require.Len(t, released, 1)
+	assert.Equal(t, 47.005, released[0].Position.Latitude)
+}
+
+func TestLiveFilter_Cleanup(t *testing.T) {
+	liveFilter, clock := newTestLiveFilter()
+	observeStraight(t, liveFilter, clock.Now(), 3)
+
+	assert.Equal(t, 0, liveFilter.Cleanup())
+
+	clock.Advance(liveFilter.config.IdleTTL + time.Minute)
+	assert.Equal(t, 1, liveFilter.Cleanup())
+	assert.Empty(t, liveFilter.streams)
+}
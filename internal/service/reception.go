@@ -0,0 +1,190 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// ReceptionConfig конфигурация учета приема пакетов базовыми станциями
+type ReceptionConfig struct {
+	// Окно, в течение которого копии пакета от других станций считаются дубликатами.
+	// 0 отключает дедупликацию, статистика приема при этом продолжает собираться
+	DedupWindow time.Duration
+	// Период, за который станция считается слышащей устройство
+	StatsWindow time.Duration
+}
+
+// DefaultReceptionConfig возвращает конфигурацию по умолчанию
+func DefaultReceptionConfig() *ReceptionConfig {
+	return &ReceptionConfig{
+		DedupWindow: 2 * time.Second,
+		StatsWindow: 5 * time.Minute,
+	}
+}
+
+// packetKey идентифицирует пакет независимо от принявшей его станции
+type packetKey struct {
+	deviceID string
+	msgType  uint8
+	hash     uint64
+}
+
+// packetReception копии одного пакета в пределах окна дедупликации
+type packetReception struct {
+	firstSeen time.Time
+	gateways  map[string]struct{}
+}
+
+// ReceptionTracker дедуплицирует копии FANET пакетов, принятые несколькими
+// базовыми станциями, и ведет статистику приема по устройствам
+type ReceptionTracker struct {
+	packets map[packetKey]*packetReception
+	devices map[string]map[string]*models.GatewayReception // Device ID -> Chip ID -> прием
+	mu      sync.Mutex
+
+	config *ReceptionConfig
+	logger *utils.Logger
+	now    func() time.Time
+}
+
+// NewReceptionTracker создает учет приема. config может быть nil
+func NewReceptionTracker(logger *utils.Logger, config *ReceptionConfig) *ReceptionTracker {
+	if config == nil {
+		config = DefaultReceptionConfig()
+	}
+
+	return &ReceptionTracker{
+		packets: make(map[packetKey]*packetReception),
+		devices: make(map[string]map[string]*models.GatewayReception),
+		config:  config,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Observe учитывает прием пакета станцией gateway.ChipID и возвращает true,
+// если это первая копия пакета и его нужно обработать.
+// Пакеты без полезной нагрузки (не из MQTT) не дедуплицируются
+func (t *ReceptionTracker) Observe(deviceID string, msgType uint8, payload []byte, gateway models.GatewayReception) bool {
+	now := t.now()
+	if gateway.LastHeard.IsZero() {
+		gateway.LastHeard = now
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if gateway.ChipID != "" {
+		gateways, ok := t.devices[deviceID]
+		if !ok {
+			gateways = make(map[string]*models.GatewayReception)
+			t.devices[deviceID] = gateways
+		}
+		gateways[gateway.ChipID] = &gateway
+	}
+
+	if t.config.DedupWindow <= 0 || len(payload) == 0 {
+		metrics.ReceptionPackets.WithLabelValues("unique").Inc()
+		return true
+	}
+
+	key := packetKey{deviceID: deviceID, msgType: msgType, hash: models.PacketHash(payload)}
+
+	packet, ok := t.packets[key]
+	if ok && now.Sub(packet.firstSeen) <= t.config.DedupWindow {
+		packet.gateways[gateway.ChipID] = struct{}{}
+		metrics.ReceptionPackets.WithLabelValues("duplicate").Inc()
+		return false
+	}
+	if ok {
+		// Окно истекло, тот же payload - новый пакет (например, стоящий на месте пилот)
+		metrics.ReceptionReceivers.Observe(float64(len(packet.gateways)))
+	}
+
+	t.packets[key] = &packetReception{
+		firstSeen: now,
+		gateways:  map[string]struct{}{gateway.ChipID: {}},
+	}
+	metrics.ReceptionPackets.WithLabelValues("unique").Inc()
+	return true
+}
+
+// Stats возвращает статистику приема устройства за окно статистики или nil,
+// если ни одна станция его не слышала
+func (t *ReceptionTracker) Stats(deviceID string) *models.ReceptionStats {
+	cutoff := t.now().Add(-t.config.StatsWindow)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stats *models.ReceptionStats
+	for _, gateway := range t.devices[deviceID] {
+		if gateway.LastHeard.Before(cutoff) {
+			continue
+		}
+		if stats == nil {
+			stats = &models.ReceptionStats{BestSNR: gateway.SNR, BestRSSI: gateway.RSSI}
+		}
+		stats.Gateways = append(stats.Gateways, *gateway)
+		stats.BestSNR = max(stats.BestSNR, gateway.SNR)
+		stats.BestRSSI = max(stats.BestRSSI, gateway.RSSI)
+	}
+	if stats == nil {
+		return nil
+	}
+
+	stats.Stations = len(stats.Gateways)
+	sort.Slice(stats.Gateways, func(i, j int) bool {
+		if stats.Gateways[i].SNR != stats.Gateways[j].SNR {
+			return stats.Gateways[i].SNR > stats.Gateways[j].SNR
+		}
+		return stats.Gateways[i].ChipID < stats.Gateways[j].ChipID
+	})
+	return stats
+}
+
+// Cleanup закрывает истекшие окна дедупликации и удаляет устаревшие приемы.
+// Возвращает количество удаленных записей
+func (t *ReceptionTracker) Cleanup() int {
+	now := t.now()
+	statsCutoff := now.Add(-t.config.StatsWindow)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	removed := 0
+	for key, packet := range t.packets {
+		if now.Sub(packet.firstSeen) > t.config.DedupWindow {
+			metrics.ReceptionReceivers.Observe(float64(len(packet.gateways)))
+			delete(t.packets, key)
+			removed++
+		}
+	}
+
+	for deviceID, gateways := range t.devices {
+		for chipID, gateway := range gateways {
+			if gateway.LastHeard.Before(statsCutoff) {
+				delete(gateways, chipID)
+				removed++
+			}
+		}
+		if len(gateways) == 0 {
+			delete(t.devices, deviceID)
+		}
+	}
+
+	if removed > 0 {
+		t.logger.WithFields(map[string]interface{}{
+			"removed":       removed,
+			"open_packets":  len(t.packets),
+			"devices_heard": len(t.devices),
+		}).Debug("Cleaned up reception state")
+	}
+
+	return removed
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReceptionTracker создает учет приема с управляемыми часами
+func newTestReceptionTracker(config *ReceptionConfig) (*ReceptionTracker, *testutil.Clock) {
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC))
+	tracker := NewReceptionTracker(utils.NewLogger("error", "text"), config)
+	tracker.now = clock.Now
+	return tracker, clock
+}
+
+func gateway(chipID string, rssi, snr int16) models.GatewayReception {
+	return models.GatewayReception{ChipID: chipID, RSSI: rssi, SNR: snr}
+}
+
+func TestReceptionTracker_Dedup(t *testing.T) {
+	tracker, clock := newTestReceptionTracker(&ReceptionConfig{DedupWindow: 2 * time.Second, StatsWindow: time.Minute})
+	payload := []byte{0x01, 0x02, 0x03}
+
+	assert.True(t, tracker.Observe("ABC123", 1, payload, gateway("GW1", -90, 5)))
+	assert.False(t, tracker.Observe("ABC123", 1, payload, gateway("GW2", -70, 12)), "copy from another gateway")
+	assert.False(t, tracker.Observe("ABC123", 1, payload, gateway("GW3", -100, -2)), "copy from another gateway")
+
+	// Другой payload, тип или устройство - отдельный пакет
+	assert.True(t, tracker.Observe("ABC123", 1, []byte{0x04}, gateway("GW1", -90, 5)))
+	assert.True(t, tracker.Observe("ABC123", 2, payload, gateway("GW1", -90, 5)))
+	assert.True(t, tracker.Observe("DEF456", 1, payload, gateway("GW1", -90, 5)))
+
+	// После окна тот же payload обрабатывается снова
+	clock.Advance(3 * time.Second)
+	assert.True(t, tracker.Observe("ABC123", 1, payload, gateway("GW2", -70, 12)))
+
+	// Без полезной нагрузки (OGN, NMEA) дедупликация не выполняется
+	assert.True(t, tracker.Observe("DDD001", 1, nil, gateway("", 0, 0)))
+	assert.True(t, tracker.Observe("DDD001", 1, nil, gateway("", 0, 0)))
+	assert.Nil(t, tracker.Stats("DDD001"))
+}
+
+func TestReceptionTracker_DedupDisabled(t *testing.T) {
+	tracker, _ := newTestReceptionTracker(&ReceptionConfig{DedupWindow: 0, StatsWindow: time.Minute})
+
+	assert.True(t, tracker.Observe("ABC123", 1, []byte{0x01}, gateway("GW1", -90, 5)))
+	assert.True(t, tracker.Observe("ABC123", 1, []byte{0x01}, gateway("GW2", -70, 12)))
+
+	stats := tracker.Stats("ABC123")
+	require.NotNil(t, stats)
+	assert.Equal(t, 2, stats.Stations)
+}
+
+func TestReceptionTracker_Stats(t *testing.T) {
+	tracker, clock := newTestReceptionTracker(&ReceptionConfig{DedupWindow: 2 * time.Second, StatsWindow: time.Minute})
+	payload := []byte{0x01}
+
+	tracker.Observe("ABC123", 1, payload, gateway("GW1", -90, 5))
+	tracker.Observe("ABC123", 1, payload, gateway("GW2", -70, 12))
+	tracker.Observe("ABC123", 1, payload, gateway("GW3", -60, -2))
+
+	stats := tracker.Stats("ABC123")
+	require.NotNil(t, stats)
+	assert.Equal(t, 3, stats.Stations)
+	assert.Equal(t, int16(12), stats.BestSNR)
+	assert.Equal(t, int16(-60), stats.BestRSSI)
+	require.Len(t, stats.Gateways, 3)
+	assert.Equal(t, "GW2", stats.Gateways[0].ChipID, "sorted by SNR")
+	assert.Equal(t, "GW3", stats.Gateways[2].ChipID)
+
+	// Повторный прием обновляет значения станции
+	clock.Advance(40 * time.Second)
+	tracker.Observe("ABC123", 1, []byte{0x02}, gateway("GW1", -80, 15))
+
+	// GW2 и GW3 выходят из окна статистики
+	clock.Advance(30 * time.Second)
+	stats = tracker.Stats("ABC123")
+	require.NotNil(t, stats)
+	assert.Equal(t, 1, stats.Stations)
+	assert.Equal(t, int16(15), stats.BestSNR)
+
+	assert.Nil(t, tracker.Stats("UNKNOWN"))
+}
+
+func TestReceptionTracker_Cleanup(t *testing.T) {
+	tracker, clock := newTestReceptionTracker(&ReceptionConfig{DedupWindow: 2 * time.Second, StatsWindow: time.Minute})
+
+	tracker.Observe("ABC123", 1, []byte{0x01}, gateway("GW1", -90, 5))
+	tracker.Observe("ABC123", 1, []byte{0x01}, gateway("GW2", -70, 12))
+	assert.Equal(t, 0, tracker.Cleanup(), "window is still open")
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, 1, tracker.Cleanup(), "dedup window closed")
+	assert.NotNil(t, tracker.Stats("ABC123"))
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, 2, tracker.Cleanup(), "both gateways expired")
+	assert.Nil(t, tracker.Stats("ABC123"))
+	assert.Empty(t, tracker.devices)
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+)
+
+// ThermalDetectorConfig конфигурация обнаружения термиков по трекам пилотов
+type ThermalDetectorConfig struct {
+	Window             time.Duration // Окно анализа трека одного пилота
+	MinDuration        time.Duration // Минимальная длительность кружения
+	MinFixes           int           // Минимальное количество позиций в окне
+	MinTurn            float64       // Минимальный суммарный разворот (градусы)
+	MinTurnConsistency float64       // Доля разворота в одну сторону 0-1 (восьмерки не считаются)
+	MinClimb           float64       // Минимальный средний набор высоты (м/с)
+	MaxRadius          float64       // Максимальный радиус кружения (км)
+	ClusterRadius      float64       // Радиус объединения кружений нескольких пилотов (км)
+	HitTTL             time.Duration // Время, в течение которого кружение участвует в кластере
+}
+
+// DefaultThermalDetectorConfig возвращает конфигурацию по умолчанию
+func DefaultThermalDetectorConfig() *ThermalDetectorConfig {
+	return &ThermalDetectorConfig{
+		Window:             90 * time.Second,
+		MinDuration:        20 * time.Second,
+		MinFixes:           5,
+		MinTurn:            360,
+		MinTurnConsistency: 0.7,
+		MinClimb:           0.3,
+		MaxRadius:          0.4,
+		ClusterRadius:      0.5,
+		HitTTL:             20 * time.Minute,
+	}
+}
+
+// trackFix позиция пилота, используемая для анализа кружения
+type trackFix struct {
+	position  models.GeoPoint
+	heading   float64
+	timestamp time.Time
+}
+
+// circlingHit одно обнаруженное кружение с набором высоты
+type circlingHit struct {
+	deviceID string
+	thermal  models.Thermal
+	circles  float64
+}
+
+// derivedThermal кластер кружений, опубликованный как термик
+type derivedThermal struct {
+	id        string
+	position  models.GeoPoint
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// ThermalDetector обнаруживает термики по живым трекам: пилот, непрерывно
+// кружащий в одну сторону с набором высоты, находится в термике.
+// Кружения нескольких пилотов поблизости объединяются models.MergeThermals
+// в один термик, достоверность растет с количеством пилотов
+type ThermalDetector struct {
+	tracks   map[string][]trackFix // Device ID -> позиции в окне
+	hits     []circlingHit
+	thermals []*derivedThermal
+	mu       sync.Mutex
+
+	config *ThermalDetectorConfig
+	logger *utils.Logger
+	now    func() time.Time
+}
+
+// NewThermalDetector создает детектор термиков. config может быть nil
+func NewThermalDetector(logger *utils.Logger, config *ThermalDetectorConfig) *ThermalDetector {
+	if config == nil {
+		config = DefaultThermalDetectorConfig()
+	}
+
+	return &ThermalDetector{
+		tracks: make(map[string][]trackFix),
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Observe добавляет позицию пилота в трек. Если пилот завершил кружение
+// с набором высоты, возвращает обновленный термик (Source = derived),
+// иначе nil
+func (d *ThermalDetector) Observe(pilot *models.Pilot) *models.Thermal {
+	if pilot == nil || pilot.Position == nil || !isSoaringAircraft(pilot.Type) {
+		return nil
+	}
+
+	fix := trackFix{
+		position:  *pilot.Position,
+		heading:   float64(pilot.Heading),
+		timestamp: pilot.LastUpdate,
+	}
+	if fix.timestamp.IsZero() {
+		fix.timestamp = d.now()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	track := d.tracks[pilot.DeviceID]
+	if n := len(track); n > 0 {
+		last := track[n-1]
+		if !fix.timestamp.After(last.timestamp) {
+			return nil
+		}
+		if fix.timestamp.Sub(last.timestamp) > d.config.Window {
+			track = track[:0]
+		}
+	}
+
+	track = append(track, fix)
+	cutoff := fix.timestamp.Add(-d.config.Window)
+	start := 0
+	for start < len(track)-1 && track[start].timestamp.Before(cutoff) {
+		start++
+	}
+	track = track[start:]
+
+	hit, ok := d.detectCircling(pilot.DeviceID, track)
+	if !ok {
+		d.tracks[pilot.DeviceID] = track
+		return nil
+	}
+
+	// Следующее кружение пилота отсчитывается заново
+	d.tracks[pilot.DeviceID] = []trackFix{fix}
+	metrics.ThermalCirclingHits.Inc()
+
+	thermal := d.addHit(hit)
+	metrics.ThermalsDerived.Inc()
+
+	d.logger.WithFields(map[string]interface{}{
+		"thermal_id":  thermal.ID,
+		"device_id":   pilot.DeviceID,
+		"climb_rate":  thermal.ClimbRate,
+		"pilot_count": thermal.PilotCount,
+		"confidence":  thermal.Confidence,
+	}).Debug("Derived thermal from circling pilot")
+
+	return thermal
+}
+
+// detectCircling проверяет, кружит ли пилот с набором высоты в пределах окна
+func (d *ThermalDetector) detectCircling(deviceID string, track []trackFix) (circlingHit, bool) {
+	if len(track) < d.config.MinFixes {
+		return circlingHit{}, false
+	}
+
+	first, last := track[0], track[len(track)-1]
+	duration := last.timestamp.Sub(first.timestamp)
+	if duration < d.config.MinDuration {
+		return circlingHit{}, false
+	}
+
+	var turn, absTurn float64
+	for i := 1; i < len(track); i++ {
+		delta := math.Mod(track[i].heading-track[i-1].heading+540, 360) - 180
+		turn += delta
+		absTurn += math.Abs(delta)
+	}
+	if math.Abs(turn) < d.config.MinTurn || absTurn == 0 || math.Abs(turn)/absTurn < d.config.MinTurnConsistency {
+		return circlingHit{}, false
+	}
+
+	climb := float64(last.position.Altitude-first.position.Altitude) / duration.Seconds()
+	if climb < d.config.MinClimb {
+		return circlingHit{}, false
+	}
+
+	var center models.GeoPoint
+	for _, f := range track {
+		center.Latitude += f.position.Latitude
+		center.Longitude += f.position.Longitude
+		center.Altitude = max(center.Altitude, f.position.Altitude)
+	}
+	center.Latitude /= float64(len(track))
+	center.Longitude /= float64(len(track))
+
+	for _, f := range track {
+		if center.DistanceTo(f.position) > d.config.MaxRadius {
+			return circlingHit{}, false
+		}
+	}
+
+	return circlingHit{
+		deviceID: deviceID,
+		circles:  math.Abs(turn) / 360,
+		thermal: models.Thermal{
+			ReportedBy: deviceID,
+			Position:   &center,
+			Quality:    thermalQuality(climb),
+			ClimbRate:  float32(climb),
+			Timestamp:  last.timestamp,
+			LastSeen:   last.timestamp,
+			Source:     models.ThermalSourceDerived,
+		},
+	}, true
+}
+
+// addHit добавляет кружение и возвращает термик его кластера
+func (d *ThermalDetector) addHit(hit circlingHit) *models.Thermal {
+	now := hit.thermal.Timestamp
+	d.pruneHits(now.Add(-d.config.HitTTL))
+	d.hits = append(d.hits, hit)
+
+	// Новое кружение первым: первый результат MergeThermals - его кластер.
+	// MergeThermals меняет позиции на месте, поэтому передаются копии
+	candidates := make([]models.Thermal, 0, len(d.hits))
+	candidates = append(candidates, copyThermal(hit.thermal))
+	for _, h := range d.hits[:len(d.hits)-1] {
+		candidates = append(candidates, copyThermal(h.thermal))
+	}
+	cluster := models.MergeThermals(candidates, d.config.ClusterRadius)[0]
+
+	pilots := map[string]struct{}{hit.deviceID: {}}
+	circles := hit.circles
+	for _, h := range d.hits[:len(d.hits)-1] {
+		if cluster.Position.DistanceTo(*h.thermal.Position) <= d.config.ClusterRadius {
+			pilots[h.deviceID] = struct{}{}
+			circles += h.circles
+		}
+	}
+
+	thermal := &models.Thermal{
+		ReportedBy: hit.deviceID,
+		Position:   cluster.Position,
+		Quality:    cluster.Quality,
+		ClimbRate:  cluster.ClimbRate,
+		PilotCount: int32(len(pilots)),
+		Timestamp:  now,
+		LastSeen:   now,
+		Source:     models.ThermalSourceDerived,
+		Confidence: thermalConfidence(len(pilots), circles, float64(cluster.ClimbRate)),
+	}
+
+	// Кластер рядом с уже опубликованным термиком обновляет его, а не создает новый
+	existing := d.nearestThermal(*thermal.Position)
+	if existing == nil {
+		existing = &derivedThermal{
+			id:        models.GenerateThermalID(*thermal.Position, now),
+			firstSeen: now,
+		}
+		d.thermals = append(d.thermals, existing)
+	}
+	existing.position = *thermal.Position
+	existing.lastSeen = now
+
+	thermal.ID = existing.id
+	thermal.Timestamp = existing.firstSeen
+	return thermal
+}
+
+// nearestThermal возвращает ближайший опубликованный термик в радиусе кластера
+func (d *ThermalDetector) nearestThermal(position models.GeoPoint) *derivedThermal {
+	var nearest *derivedThermal
+	best := d.config.ClusterRadius
+	for _, t := range d.thermals {
+		if distance := t.position.DistanceTo(position); distance <= best {
+			nearest = t
+			best = distance
+		}
+	}
+	return nearest
+}
+
+// pruneHits удаляет кружения и термики старше cutoff
+func (d *ThermalDetector) pruneHits(cutoff time.Time) {
+	hits := d.hits[:0]
+	for _, h := range d.hits {
+		if !h.thermal.Timestamp.Before(cutoff) {
+			hits = append(hits, h)
+		}
+	}
+	d.hits = hits
+
+	thermals := d.thermals[:0]
+	for _, t := range d.thermals {
+		if !t.lastSeen.Before(cutoff) {
+			thermals = append(thermals, t)
+		}
+	}
+	d.thermals = thermals
+}
+
+// Cleanup удаляет треки пилотов без позиций в окне анализа и устаревшие
+// кружения. Возвращает количество удаленных треков
+func (d *ThermalDetector) Cleanup() int {
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	removed := 0
+	for deviceID, track := range d.tracks {
+		if len(track) == 0 || now.Sub(track[len(track)-1].timestamp) > d.config.Window {
+			delete(d.tracks, deviceID)
+			removed++
+		}
+	}
+	d.pruneHits(now.Add(-d.config.HitTTL))
+
+	metrics.ThermalDetectorPilots.Set(float64(len(d.tracks)))
+	return removed
+}
+
+// isSoaringAircraft возвращает true для безмоторных аппаратов, которые кружат в термиках
+func isSoaringAircraft(t models.PilotType) bool {
+	switch t {
+	case models.PilotTypeParaglider, models.PilotTypeHangglider, models.PilotTypeGlider:
+		return true
+	default:
+		return false
+	}
+}
+
+// thermalQuality переводит средний набор высоты (м/с) в качество 0-5
+func thermalQuality(climb float64) int32 {
+	return int32(math.Max(0, math.Min(5, math.Round(climb))))
+}
+
+// thermalConfidence оценивает достоверность термика 0-1: несколько пилотов
+// весят больше, чем много кругов или сильный набор одного пилота
+func thermalConfidence(pilots int, circles, climb float64) float32 {
+	pilotScore := 1 - math.Pow(0.5, float64(pilots))
+	circleScore := math.Min(circles/3, 1)
+	climbScore := math.Min(climb/2, 1)
+
+	confidence := 0.4*pilotScore + 0.3*circleScore + 0.3*climbScore
+	return float32(math.Round(confidence*100) / 100)
+}
+
+// copyThermal копирует термик вместе с позицией
+func copyThermal(t models.Thermal) models.Thermal {
+	if t.Position != nil {
+		position := *t.Position
+		t.Position = &position
+	}
+	return t
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/internal/testutil"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThermalDetector() (*ThermalDetector, *testutil.Clock) {
+	clock := testutil.NewClock(time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC))
+	detector := NewThermalDetector(utils.NewLogger("error", "text"), nil)
+	detector.now = clock.Now
+	return detector, clock
+}
+
+// circlingTrack строит позиции пилота, кружащего по часовой стрелке с радиусом
+// 100 м на скорости 11 м/с (круг за ~57 с) и набором climb м/с, каждые 3 секунды
+func circlingTrack(deviceID string, lat, lon float64, climb float64, start time.Time, fixes int) []*models.Pilot {
+	const radius, speed, step = 100.0, 11.0, 3.0
+	omega := speed / radius * 180 / math.Pi // град/с
+
+	track := make([]*models.Pilot, fixes)
+	for i := range track {
+		elapsed := float64(i) * step
+		angle := omega * elapsed
+		rad := angle * math.Pi / 180
+		track[i] = &models.Pilot{
+			DeviceID: deviceID,
+			Type:     models.PilotTypeParaglider,
+			Position: &models.GeoPoint{
+				Latitude:  lat + radius*math.Cos(rad)/111320,
+				Longitude: lon + radius*math.Sin(rad)/(111320*math.Cos(lat*math.Pi/180)),
+				Altitude:  int32(1200 + climb*elapsed),
+			},
+			Heading:    float32(math.Mod(angle+90, 360)),
+			LastUpdate: start.Add(time.Duration(elapsed * float64(time.Second))),
+		}
+	}
+	return track
+}
+
+// observeTrack возвращает термики, обнаруженные на треке
+func observeTrack(detector *ThermalDetector, track []*models.Pilot) []*models.Thermal {
+	var thermals []*models.Thermal
+	for _, pilot := range track {
+		if thermal := detector.Observe(pilot); thermal != nil {
+			thermals = append(thermals, thermal)
+		}
+	}
+	return thermals
+}
+
+func TestThermalDetector_CirclingWithClimb(t *testing.T) {
+	detector, clock := newTestThermalDetector()
+
+	thermals := observeTrack(detector, circlingTrack("ABC123", 46.0, 14.0, 2, clock.Now(), 25))
+	require.Len(t, thermals, 1, "one full circle within 25 fixes")
+
+	thermal := thermals[0]
+	assert.Equal(t, models.ThermalSourceDerived, thermal.Source)
+	assert.Equal(t, "ABC123", thermal.ReportedBy)
+	assert.Equal(t, int32(1), thermal.PilotCount)
+	assert.InDelta(t, 2, thermal.ClimbRate, 0.1, "climb in m/s")
+	assert.Equal(t, int32(2), thermal.Quality)
+	assert.Less(t, thermal.Position.DistanceTo(models.GeoPoint{Latitude: 46.0, Longitude: 14.0}), 0.05)
+	assert.Greater(t, thermal.Confidence, float32(0))
+	assert.Less(t, thermal.Confidence, float32(1))
+	assert.NoError(t, thermal.Validate())
+}
+
+func TestThermalDetector_NoThermal(t *testing.T) {
+	detector, clock := newTestThermalDetector()
+
+	// Кружение со снижением
+	assert.Empty(t, observeTrack(detector, circlingTrack("SINK01", 46.0, 14.0, -1, clock.Now(), 40)))
+
+	// Прямой полет с набором
+	straight := make([]*models.Pilot, 30)
+	for i := range straight {
+		straight[i] = &models.Pilot{
+			DeviceID:   "LINE01",
+			Type:       models.PilotTypeParaglider,
+			Position:   &models.GeoPoint{Latitude: 46.0 + float64(i)*0.0003, Longitude: 14.0, Altitude: int32(1200 + i*6)},
+			Heading:    0,
+			LastUpdate: clock.Now().Add(time.Duration(i*3) * time.Second),
+		}
+	}
+	assert.Empty(t, observeTrack(detector, straight))
+
+	// Мотопараплан в термиках не учитывается
+	powered := circlingTrack("MOTOR1", 46.0, 14.0, 2, clock.Now(), 25)
+	for _, pilot := range powered {
+		pilot.Type = models.PilotTypePowered
+	}
+	assert.Empty(t, observeTrack(detector, powered))
+}
+
+func TestThermalDetector_MergesPilots(t *testing.T) {
+	detector, clock := newTestThermalDetector()
+
+	first := observeTrack(detector, circlingTrack("ABC123", 46.0, 14.0, 1.5, clock.Now(), 25))
+	require.Len(t, first, 1)
+
+	// Второй пилот кружит в 150 м от первого минутой позже
+	second := observeTrack(detector, circlingTrack("DEF456", 46.00135, 14.0, 2.5, clock.Now().Add(time.Minute), 25))
+	require.Len(t, second, 1)
+
+	assert.Equal(t, first[0].ID, second[0].ID, "same thermal is updated")
+	assert.Equal(t, int32(2), second[0].PilotCount)
+	assert.Equal(t, "DEF456", second[0].ReportedBy)
+	assert.InDelta(t, 2, second[0].ClimbRate, 0.15, "average of both pilots")
+	assert.Greater(t, second[0].Confidence, first[0].Confidence)
+	assert.True(t, second[0].LastSeen.After(second[0].Timestamp))
+
+	// Далекий термик - отдельный
+	far := observeTrack(detector, circlingTrack("GHI789", 46.1, 14.0, 2, clock.Now().Add(2*time.Minute), 25))
+	require.Len(t, far, 1)
+	assert.NotEqual(t, first[0].ID, far[0].ID)
+	assert.Equal(t, int32(1), far[0].PilotCount)
+
+	// Через HitTTL кружения забываются
+	clock.Advance(time.Hour)
+	assert.Equal(t, 3, detector.Cleanup())
+	again := observeTrack(detector, circlingTrack("ABC123", 46.0, 14.0, 2, clock.Now(), 25))
+	require.Len(t, again, 1)
+	assert.Equal(t, int32(1), again[0].PilotCount)
+	assert.NotEqual(t, first[0].ID, again[0].ID)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/metrics"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TrackLODPrefix префикс хешей с версиями трека: track:lod:{addr}:{filter-level}:{period},
+	// поле z{zoom} - JSON filter.FilterResult упрощенного трека
+	TrackLODPrefix = "track:lod:"
+
+	TrackLODLiveTTL    = 30 * time.Second // Трек, который еще пополняется (период до текущего момента)
+	TrackLODHistoryTTL = time.Hour        // Трек за завершившийся период
+)
+
+// TrackLODStore операции Redis, нужные кэшу версий трека
+type TrackLODStore interface {
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+}
+
+// TrackLODCache хранит в Redis упрощенные версии отфильтрованного трека для всех
+// масштабов карты filter.MinLODZoom..filter.MaxLODZoom. Версии строятся за один
+// проход Douglas-Peucker (filter.Significance) и сохраняются одним хешем, поэтому
+// запросы трека при смене масштаба не повторяют загрузку из MySQL и фильтрацию
+type TrackLODCache struct {
+	client TrackLODStore
+	logger *utils.Logger
+}
+
+// NewTrackLODCache создает кэш версий трека
+func NewTrackLODCache(client TrackLODStore, logger *utils.Logger) *TrackLODCache {
+	return &TrackLODCache{
+		client: client,
+		logger: logger,
+	}
+}
+
+// TrackLODKey возвращает ключ версий трека устройства для уровня фильтрации и периода
+func TrackLODKey(deviceID string, filterLevel int, period string) string {
+	return fmt.Sprintf("%s%s:%d:%s", TrackLODPrefix, deviceID, filterLevel, period)
+}
+
+// BuildTrackLODs строит упрощенные версии трека для всех масштабов карты.
+// Статистика фильтрации и счетчики точек берутся из исходного результата
+func BuildTrackLODs(result *filter.FilterResult) map[int]*filter.FilterResult {
+	significance := filter.Significance(result.Points)
+
+	versions := make(map[int]*filter.FilterResult, filter.MaxLODZoom-filter.MinLODZoom+1)
+	for zoom := filter.MinLODZoom; zoom <= filter.MaxLODZoom; zoom++ {
+		version := *result
+		version.Points = filter.SimplifyBySignificance(result.Points, significance, filter.LODTolerance(result.Points, zoom))
+		versions[zoom] = &version
+	}
+	return versions
+}
+
+// Get возвращает версию трека для масштаба zoom или nil, если ее нет в кэше
+func (c *TrackLODCache) Get(ctx context.Context, key string, zoom int) (*filter.FilterResult, error) {
+	data, err := c.client.HGet(ctx, key, lodField(filter.ClampLODZoom(zoom))).Bytes()
+	if err == redis.Nil {
+		metrics.TrackLODCache.WithLabelValues("miss").Inc()
+		return nil, nil
+	}
+	if err != nil {
+		metrics.TrackLODCache.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("failed to get track LOD from cache: %w", err)
+	}
+
+	var result filter.FilterResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		metrics.TrackLODCache.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("failed to deserialize track LOD: %w", err)
+	}
+
+	metrics.TrackLODCache.WithLabelValues("hit").Inc()
+	return &result, nil
+}
+
+// Save сохраняет версии трека одним хешем с временем жизни ttl
+func (c *TrackLODCache) Save(ctx context.Context, key string, versions map[int]*filter.FilterResult, ttl time.Duration) error {
+	values := make([]interface{}, 0, len(versions)*2)
+	for zoom, version := range versions {
+		data, err := json.Marshal(version)
+		if err != nil {
+			return fmt.Errorf("failed to serialize track LOD: %w", err)
+		}
+		values = append(values, lodField(zoom), data)
+	}
+
+	if err := c.client.HSet(ctx, key, values...).Err(); err != nil {
+		return fmt.Errorf("failed to save track LOD: %w", err)
+	}
+	if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set track LOD TTL: %w", err)
+	}
+
+	c.logger.WithField("key", key).WithField("versions", len(versions)).Debug("Track LOD cached")
+	return nil
+}
+
+// lodField возвращает поле хеша для масштаба карты
+func lodField(zoom int) string {
+	return "z" + strconv.Itoa(zoom)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/flybeeper/fanet-backend/internal/filter"
+	"github.com/flybeeper/fanet-backend/internal/models"
+	"github.com/flybeeper/fanet-backend/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLODStore хеши Redis в памяти
+type memoryLODStore struct {
+	hashes map[string]map[string]string
+	ttl    map[string]time.Duration
+}
+
+func newMemoryLODStore() *memoryLODStore {
+	return &memoryLODStore{
+		hashes: make(map[string]map[string]string),
+		ttl:    make(map[string]time.Duration),
+	}
+}
+
+func (s *memoryLODStore) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx)
+	value, ok := s.hashes[key][field]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	cmd.SetVal(value)
+	return cmd
+}
+
+func (s *memoryLODStore) HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd {
+	if s.hashes[key] == nil {
+		s.hashes[key] = make(map[string]string)
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		s.hashes[key][values[i].(string)] = string(values[i+1].([]byte))
+	}
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(int64(len(values) / 2))
+	return cmd
+}
+
+func (s *memoryLODStore) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	s.ttl[key] = expiration
+	cmd := redis.NewBoolCmd(ctx)
+	cmd.SetVal(true)
+	return cmd
+}
+
+// zigzagTrack трек из двух сегментов с шумом до ~200 м вокруг прямой
+func zigzagTrack(n int) []filter.TrackPoint {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+
+	points := make([]filter.TrackPoint, n)
+	for i := range points {
+		segment := 1
+		if i >= n/2 {
+			segment = 2
+		}
+		points[i] = filter.TrackPoint{
+			Position: models.GeoPoint{
+				Latitude:  46 + float64(i)*0.001 + (rng.Float64()-0.5)*0.004,
+				Longitude: 14 + (rng.Float64()-0.5)*0.004,
+			},
+			Timestamp: start.Add(time.Duration(i) * 5 * time.Second),
+			SegmentID: segment,
+		}
+	}
+	return points
+}
+
+// classicDouglasPeucker рекурсивный Douglas-Peucker для сверки с filter.Significance
+func classicDouglasPeucker(points []filter.TrackPoint, tolerance float64) []filter.TrackPoint {
+	if len(points) < 3 {
+		return points
+	}
+
+	split, maxDistance := 0, -1.0
+	for i := 1; i < len(points)-1; i++ {
+		// Расстояние считается через Significance на трех точках (та же геометрия)
+		triple := []filter.TrackPoint{points[0], points[i], points[len(points)-1]}
+		if distance := filter.Significance(triple)[1]; distance > maxDistance {
+			split, maxDistance = i, distance
+		}
+	}
+	if maxDistance <= tolerance {
+		return []filter.TrackPoint{points[0], points[len(points)-1]}
+	}
+
+	left := classicDouglasPeucker(points[:split+1], tolerance)
+	right := classicDouglasPeucker(points[split:], tolerance)
+	return append(left[:len(left)-1:len(left)-1], right...)
+}
+
+func TestSimplify_MatchesClassicDouglasPeucker(t *testing.T) {
+	points := zigzagTrack(200)[:100] // один сегмент
+
+	for _, tolerance := range []float64{10, 50, 150, 1000} {
+		expected := classicDouglasPeucker(points, tolerance)
+		actual := filter.Simplify(points, tolerance)
+		require.Len(t, actual, len(expected), "tolerance %v", tolerance)
+		for i := range expected {
+			assert.Equal(t, expected[i].Timestamp, actual[i].Timestamp)
+		}
+	}
+}
+
+func TestBuildTrackLODs(t *testing.T) {
+	points := zigzagTrack(400)
+	points[10].Filtered = true
+	result := &filter.FilterResult{OriginalCount: len(points), FilteredCount: 1, Points: points}
+
+	versions := BuildTrackLODs(result)
+	require.Len(t, versions, filter.MaxLODZoom-filter.MinLODZoom+1)
+
+	previous := 0
+	for zoom := filter.MinLODZoom; zoom <= filter.MaxLODZoom; zoom++ {
+		version := versions[zoom]
+		assert.Equal(t, len(points), version.OriginalCount)
+		assert.GreaterOrEqual(t, len(version.Points), previous, "zoom %d", zoom)
+		previous = len(version.Points)
+
+		// Границы сегментов и отфильтрованные точки сохраняются
+		timestamps := make(map[time.Time]bool, len(version.Points))
+		for _, point := range version.Points {
+			timestamps[point.Timestamp] = true
+		}
+		for _, i := range []int{0, 10, 199, 200, 399} {
+			assert.True(t, timestamps[points[i].Timestamp], "zoom %d point %d", zoom, i)
+		}
+	}
+
+	assert.Less(t, len(versions[filter.MinLODZoom].Points), 20)
+	assert.Greater(t, len(versions[filter.MaxLODZoom].Points), 300)
+}
+
+func TestTrackLODCache(t *testing.T) {
+	store := newMemoryLODStore()
+	cache := NewTrackLODCache(store, utils.NewLogger("debug", "text"))
+	key := TrackLODKey("ABC123", 3, "h12")
+	assert.Equal(t, "track:lod:ABC123:3:h12", key)
+
+	cached, err := cache.Get(context.Background(), key, 12)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+
+	versions := BuildTrackLODs(&filter.FilterResult{Points: zigzagTrack(100)})
+	require.NoError(t, cache.Save(context.Background(), key, versions, TrackLODLiveTTL))
+	assert.Equal(t, TrackLODLiveTTL, store.ttl[key])
+
+	cached, err = cache.Get(context.Background(), key, 12)
+	require.NoError(t, err)
+	require.NotNil(t, cached)
+	assert.Len(t, cached.Points, len(versions[12].Points))
+
+	// Масштаб вне диапазона приводится к ближайшей версии
+	cached, err = cache.Get(context.Background(), key, 20)
+	require.NoError(t, err)
+	assert.Len(t, cached.Points, len(versions[filter.MaxLODZoom].Points))
+}
+
+func TestToleranceForZoom(t *testing.T) {
+	// Масштаб 12 на экваторе - около 38 м на пиксель, на 60° широты - вдвое меньше
+	assert.InDelta(t, 38.2, filter.ToleranceForZoom(12, 0), 0.1)
+	assert.InDelta(t, filter.ToleranceForZoom(12, 0)/2, filter.ToleranceForZoom(12, 60), 0.01)
+	assert.False(t, math.IsNaN(filter.ToleranceForZoom(0, 90)))
+}
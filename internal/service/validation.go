@@ -18,6 +18,8 @@ type ValidationService struct {
 	config  *models.ValidationConfig
 	logger  *utils.Logger
 	metrics ValidationMetrics
+
+	smoother *KalmanSmoother // Необязательное сглаживание и отсев выбросов
 }
 
 // ValidationMetrics метрики валидации
@@ -41,6 +43,14 @@ func NewValidationService(logger *utils.Logger, config *models.ValidationConfig)
 	}
 }
 
+// SetSmoother включает сглаживание позиций фильтром Калмана: ValidatePilot
+// заполняет pilot.Smoothed и считает невалидной позицию, далекую от прогноза
+func (s *ValidationService) SetSmoother(smoother *KalmanSmoother) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.smoother = smoother
+}
+
 // ValidatePilot проверяет и валидирует данные пилота
 // Возвращает (isValid, shouldStore, error) где:
 // - isValid: пакет прошел валидацию
@@ -68,6 +78,10 @@ func (s *ValidationService) ValidatePilot(pilot *models.Pilot) (bool, bool, erro
 			ConsecutiveInvalidPackets: 0,
 		}
 		s.states[pilot.DeviceID] = state
+
+		if s.smoother != nil {
+			s.smoother.Reset(pilot)
+		}
 		
 		// Обновляем метрику активных состояний
 		metrics.ValidationActiveStates.Set(float64(len(s.states)))
@@ -121,6 +135,10 @@ func (s *ValidationService) ValidatePilot(pilot *models.Pilot) (bool, bool, erro
 		state.FirstSeen = pilot.LastUpdate
 		state.LastPosition = pilot.Position
 		state.LastUpdate = pilot.LastUpdate
+
+		if s.smoother != nil {
+			s.smoother.Reset(pilot)
+		}
 		
 		// Большой интервал считаем невалидным пакетом
 		s.updateValidationScore(state, false)
@@ -154,6 +172,13 @@ func (s *ValidationService) ValidatePilot(pilot *models.Pilot) (bool, bool, erro
 	// Определяем валидность на основе скорости
 	isValid := speedKmh <= maxSpeed
 
+	// Правдоподобная по скорости позиция может быть далека от прогноза фильтра
+	gated := false
+	if isValid && s.smoother != nil && !s.smoother.Observe(pilot) {
+		isValid = false
+		gated = true
+	}
+
 	s.logger.WithFields(map[string]interface{}{
 		"device_id": pilot.DeviceID,
 		"speed_kmh": speedKmh,
@@ -163,6 +188,7 @@ func (s *ValidationService) ValidatePilot(pilot *models.Pilot) (bool, bool, erro
 		"aircraft_type": pilot.Type,
 		"packet_count": state.PacketCount,
 		"is_valid": isValid,
+		"kalman_gated": gated,
 		"current_score": state.ValidationScore,
 	}).Debug("Validating pilot movement")
 
@@ -186,7 +212,9 @@ func (s *ValidationService) ValidatePilot(pilot *models.Pilot) (bool, bool, erro
 	} else {
 		s.metrics.RejectedPackets++
 		metrics.ValidationRejectedPackets.Inc()
-		metrics.ValidationSpeedViolations.WithLabelValues(pilot.Type.String()).Inc()
+		if !gated {
+			metrics.ValidationSpeedViolations.WithLabelValues(pilot.Type.String()).Inc()
+		}
 		
 		// Если скорость нереалистична, обновляем опорную точку
 		state.FirstSeen = pilot.LastUpdate
@@ -256,6 +284,10 @@ func (s *ValidationService) CleanupOldStates(maxAge time.Duration) int {
 		}
 	}
 
+	if s.smoother != nil {
+		s.smoother.Cleanup(maxAge)
+	}
+
 	if removed > 0 {
 		s.logger.WithField("removed", removed).
 			Debug("Cleaned up old validation states")
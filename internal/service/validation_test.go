@@ -30,9 +30,10 @@ func TestValidationService_ValidatePilot(t *testing.T) {
 	t.Run("FirstPacketNotValidated", func(t *testing.T) {
 		pilot := createPilot("ABC123", 46.0, 8.0, time.Now(), models.PilotTypeParaglider)
 		
-		valid, err := service.ValidatePilot(pilot)
+		valid, store, err := service.ValidatePilot(pilot)
 		require.NoError(t, err)
 		assert.False(t, valid, "First packet should not be validated")
+		assert.False(t, store, "First packet should not be stored")
 		
 		// Проверяем, что состояние создано
 		state, exists := service.GetValidationState("ABC123")
@@ -47,20 +48,30 @@ func TestValidationService_ValidatePilot(t *testing.T) {
 		
 		// Первый пакет
 		pilot1 := createPilot(deviceID, 46.0, 8.0, baseTime, models.PilotTypeParaglider)
-		valid, err := service.ValidatePilot(pilot1)
+		valid, store, err := service.ValidatePilot(pilot1)
 		require.NoError(t, err)
 		assert.False(t, valid)
+		assert.False(t, store)
 		
 		// Второй пакет через 5 минут, 2 км дальше (скорость ~24 км/ч - реалистично для параплана)
 		pilot2 := createPilot(deviceID, 46.018, 8.0, baseTime.Add(5*time.Minute), models.PilotTypeParaglider)
-		valid, err = service.ValidatePilot(pilot2)
+		valid, store, err = service.ValidatePilot(pilot2)
 		require.NoError(t, err)
 		assert.True(t, valid, "Realistic speed should validate")
+		assert.False(t, store, "Score 65 is below the add threshold")
+		
+		// Третий пакет поднимает счет выше порога появления в API
+		pilot3 := createPilot(deviceID, 46.036, 8.0, baseTime.Add(10*time.Minute), models.PilotTypeParaglider)
+		valid, store, err = service.ValidatePilot(pilot3)
+		require.NoError(t, err)
+		assert.True(t, valid)
+		assert.True(t, store, "Score 80 reaches the add threshold")
 		
 		// Проверяем состояние
 		state, exists := service.GetValidationState(deviceID)
 		require.True(t, exists)
 		assert.True(t, state.IsValidated)
+		assert.Equal(t, 80, state.ValidationScore)
 	})
 
 	t.Run("UnrealisticSpeedRejection", func(t *testing.T) {
@@ -69,21 +80,23 @@ func TestValidationService_ValidatePilot(t *testing.T) {
 		
 		// Первый пакет
 		pilot1 := createPilot(deviceID, 46.0, 8.0, baseTime, models.PilotTypeParaglider)
-		valid, err := service.ValidatePilot(pilot1)
+		valid, _, err := service.ValidatePilot(pilot1)
 		require.NoError(t, err)
 		assert.False(t, valid)
 		
 		// Второй пакет через 1 минуту, 50 км дальше (скорость 3000 км/ч - нереально)
 		pilot2 := createPilot(deviceID, 46.5, 8.0, baseTime.Add(1*time.Minute), models.PilotTypeParaglider)
-		valid, err = service.ValidatePilot(pilot2)
+		valid, store, err := service.ValidatePilot(pilot2)
 		require.NoError(t, err)
 		assert.False(t, valid, "Unrealistic speed should not validate")
+		assert.False(t, store)
 		
-		// Проверяем, что состояние сброшено
+		// Проверяем, что счет снижен штрафом
 		state, exists := service.GetValidationState(deviceID)
 		require.True(t, exists)
 		assert.False(t, state.IsValidated)
-		assert.Equal(t, 1, state.PacketCount) // Счетчик сброшен
+		assert.Equal(t, 25, state.ValidationScore)
+		assert.Equal(t, 1, state.ConsecutiveInvalidPackets)
 	})
 
 	t.Run("DifferentAircraftTypes", func(t *testing.T) {
@@ -96,7 +109,7 @@ func TestValidationService_ValidatePilot(t *testing.T) {
 		
 		// 150 км/ч для планера - должно пройти валидацию
 		pilot2 := createPilot(deviceID, 46.1, 8.0, baseTime.Add(5*time.Minute), models.PilotTypeGlider)
-		valid, err := service.ValidatePilot(pilot2)
+		valid, _, err := service.ValidatePilot(pilot2)
 		require.NoError(t, err)
 		assert.True(t, valid, "High speed should be valid for glider")
 	})
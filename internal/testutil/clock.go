@@ -0,0 +1,31 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock управляемое время для сервисов с подменяемой функцией now
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock создает время, остановленное в момент start
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now возвращает текущее время часов, подставляется вместо time.Now
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance переводит часы вперед на d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
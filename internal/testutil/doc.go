@@ -0,0 +1,3 @@
+// Package testutil содержит общие для тестов заглушки: управляемое время
+// и хранилища в памяти вместо Redis
+package testutil
@@ -0,0 +1,70 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemorySets множества Redis в памяти (реализует service.FriendsRedis)
+type MemorySets struct {
+	mu   sync.Mutex
+	sets map[string]map[string]bool
+}
+
+// NewMemorySets создает пустое хранилище множеств
+func NewMemorySets() *MemorySets {
+	return &MemorySets{sets: make(map[string]map[string]bool)}
+}
+
+func (s *MemorySets) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cmd := redis.NewStringSliceCmd(ctx)
+	members := []string{}
+	for member := range s.sets[key] {
+		members = append(members, member)
+	}
+	cmd.SetVal(members)
+	return cmd
+}
+
+func (s *MemorySets) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sets[key] == nil {
+		s.sets[key] = make(map[string]bool)
+	}
+	for _, member := range members {
+		s.sets[key][member.(string)] = true
+	}
+	return redis.NewIntCmd(ctx)
+}
+
+func (s *MemorySets) SRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, member := range members {
+		delete(s.sets[key], member.(string))
+	}
+	return redis.NewIntCmd(ctx)
+}
+
+func (s *MemorySets) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.sets, key)
+	}
+	return redis.NewIntCmd(ctx)
+}
+
+// Members возвращает элементы множества key без учета порядка
+func (s *MemorySets) Members(key string) []string {
+	return s.SMembers(context.Background(), key).Val()
+}
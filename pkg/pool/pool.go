@@ -285,6 +285,9 @@ func (p *ObjectPools) PutPbUpdate(update *pb.Update) {
 	update.Action = 0
 	update.Data = nil
 	update.Sequence = 0
+	update.Subscriptions = update.Subscriptions[:0]
+	update.Delta = false
+	update.ClearedFields = update.ClearedFields[:0]
 	p.pbUpdatePool.Put(update)
 }
 